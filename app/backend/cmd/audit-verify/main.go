@@ -0,0 +1,45 @@
+// Command audit-verify checks the audit log hash chain in the configured database and prints the result
+// as JSON. It exits with status 1 when the chain has gaps, modified rows or invalid tombstones, and 2 when
+// the check could not run. It reads the same environment as the server (DB_*, AUDIT_SIGNING_KEY, JWT_SECRET).
+//
+//	go run ./cmd/audit-verify
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/rm/roadmap/backend/internal/auditchain"
+	"github.com/rm/roadmap/backend/internal/config"
+	"github.com/rm/roadmap/backend/internal/repositories"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func main() {
+	cfg := config.Load()
+	dsn := fmt.Sprintf(
+		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		cfg.Database.Host, cfg.Database.Port, cfg.Database.User,
+		cfg.Database.Password, cfg.Database.DBName, cfg.Database.SSLMode,
+	)
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "audit-verify: db connect failed:", err)
+		os.Exit(2)
+	}
+	res, err := auditchain.Verify(context.Background(), repositories.NewAuditRepository(db), auditchain.DeriveKey(cfg.AuditSigningSecret()))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "audit-verify:", err)
+		os.Exit(2)
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(res)
+	if !res.Valid {
+		os.Exit(1)
+	}
+}
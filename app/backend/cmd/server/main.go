@@ -2,19 +2,28 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rm/roadmap/backend/internal/auditchain"
 	"github.com/rm/roadmap/backend/internal/auth"
+	"github.com/rm/roadmap/backend/internal/authz"
 	"github.com/rm/roadmap/backend/internal/config"
 	"github.com/rm/roadmap/backend/internal/handlers"
 	"github.com/rm/roadmap/backend/internal/logger"
+	"github.com/rm/roadmap/backend/internal/mail"
 	"github.com/rm/roadmap/backend/internal/middleware"
 	"github.com/rm/roadmap/backend/internal/models"
+	"github.com/rm/roadmap/backend/internal/realtime"
 	"github.com/rm/roadmap/backend/internal/repositories"
 	"github.com/rm/roadmap/backend/internal/services"
 	"github.com/rm/roadmap/backend/internal/telemetry"
@@ -70,6 +79,8 @@ func main() {
 		&models.Dependency{},
 		&models.ProductRequest{},
 		&models.AuditLog{},
+		&models.AuditTombstone{},
+		&models.AuditChainHead{},
 		&models.ProductVersion{},
 		&models.ProductDeletionRequest{},
 		&models.Group{},
@@ -77,16 +88,30 @@ func main() {
 		&models.Notification{},
 		&models.UserDottedLineManager{},
 		&models.ActivityLog{},
+		&models.ActivityLogHead{},
+		&models.LoginLockout{},
+		&models.RolePermission{},
+		&models.RolePermissionSeed{},
+		&models.ProductMember{},
+		&models.Session{},
+		&models.JWTSigningKey{},
+		&models.OutboxEvent{},
+		&models.AuditRetentionPolicy{},
+		&models.AuditLegalHold{},
+		&models.ForwarderCheckpoint{},
+		&models.NotificationDelivery{},
+		&models.NotificationEmailSetting{},
+		&models.NotificationPreference{},
+		&models.NotificationDefault{},
+		&models.WebhookSubscription{},
+		&models.WebhookDelivery{},
+		&models.WebhookAttempt{},
+		&models.MilestoneReminder{},
+		&models.Watch{},
 	); err != nil {
 		logger.Fatal("migrate failed", zap.Error(err))
 	}
 
-	jwtService := auth.NewJWTService(
-		cfg.JWT.Secret,
-		cfg.JWT.AccessExpiryMin,
-		cfg.JWT.RefreshExpiryMin,
-	)
-
 	userRepo := repositories.NewUserRepository(db)
 	productRepo := repositories.NewProductRepository(db)
 	milestoneRepo := repositories.NewMilestoneRepository(db)
@@ -99,27 +124,219 @@ func main() {
 	groupRepo := repositories.NewGroupRepository(db)
 	versionDepRepo := repositories.NewProductVersionDependencyRepository(db)
 	notificationRepo := repositories.NewNotificationRepository(db)
+	deliveryRepo := repositories.NewNotificationDeliveryRepository(db)
+	notificationPrefRepo := repositories.NewNotificationPreferenceRepository(db)
 	holdingRepo := repositories.NewHoldingCompanyRepository(db)
 	companyRepo := repositories.NewCompanyRepository(db)
 	funcRepo := repositories.NewFunctionRepository(db)
 	deptRepo := repositories.NewDepartmentRepository(db)
 	teamRepo := repositories.NewTeamRepository(db)
 	dottedLineRepo := repositories.NewUserDottedLineRepository(db)
+	loginLockoutRepo := repositories.NewLoginLockoutRepository(db)
+	rolePermRepo := repositories.NewRolePermissionRepository(db)
+	memberRepo := repositories.NewProductMemberRepository(db)
+	sessionRepo := repositories.NewSessionRepository(db)
+	jwtKeyRepo := repositories.NewJWTKeyRepository(db)
+	outboxRepo := repositories.NewOutboxRepository(db)
+	retentionRepo := repositories.NewAuditRetentionRepository(db)
+	webhookRepo := repositories.NewWebhookRepository(db)
+	reminderRepo := repositories.NewMilestoneReminderRepository(db)
+	watchRepo := repositories.NewWatchRepository(db)
+	transactor := repositories.NewTransactor(db)
+
+	// Retired keys keep verifying for the longest token lifetime so rotation never logs anyone out.
+	keyring, err := auth.NewKeyring(jwtKeyRepo, transactor, repositories.NewAdvisoryLock(db, "jwt_keyring"), auth.KeyringConfig{
+		Algorithm:   cfg.JWT.Algorithm,
+		RotateEvery: time.Duration(cfg.JWT.KeyRotationHours) * time.Hour,
+		VerifyGrace: time.Duration(max(cfg.JWT.AccessExpiryMin, cfg.JWT.RefreshExpiryMin)) * time.Minute,
+		Prepublish:  10 * time.Minute,
+		Secret:      cfg.JWT.Secret,
+	}, logger)
+	if err != nil {
+		logger.Fatal("jwt keyring config invalid", zap.Error(err))
+	}
+	if err := keyring.Load(context.Background()); err != nil {
+		logger.Fatal("jwt keyring load failed", zap.Error(err))
+	}
+	jwtService := auth.NewJWTService(
+		keyring,
+		cfg.JWT.Issuer,
+		cfg.JWT.AccessExpiryMin,
+		cfg.JWT.RefreshExpiryMin,
+	)
 
-	auditSvc := services.NewAuditService(auditRepo, productRepo, logger)
-	activitySvc := services.NewActivityService(activityRepo, logger)
-	notificationSvc := services.NewNotificationService(notificationRepo)
-
-	authSvc := services.NewAuthService(userRepo, jwtService)
-	productSvc := services.NewProductService(productRepo, versionRepo, deletionReqRepo, groupRepo, milestoneRepo, auditSvc, activitySvc, notificationSvc)
-	groupSvc := services.NewGroupService(groupRepo)
-	milestoneSvc := services.NewMilestoneService(milestoneRepo, productRepo, depRepo, auditSvc, activitySvc)
-	depSvc := services.NewDependencyService(depRepo, milestoneRepo, auditSvc, activitySvc)
-	reqSvc := services.NewProductRequestService(reqRepo, productRepo, userRepo, auditSvc, activitySvc, notificationSvc)
-	productVersionSvc := services.NewProductVersionService(versionRepo, productRepo, auditSvc, activitySvc)
-	versionDepSvc := services.NewProductVersionDependencyService(versionDepRepo, versionRepo, productRepo, auditSvc)
-	deletionReqSvc := services.NewProductDeletionRequestService(deletionReqRepo, productRepo, versionRepo, userRepo, auditSvc, activitySvc, notificationSvc)
-	orgSvc := services.NewOrgService(holdingRepo, companyRepo, funcRepo, deptRepo, teamRepo)
+	policy := authz.NewEngine(rolePermRepo, 30*time.Second)
+	if err := policy.SeedDefaults(context.Background()); err != nil {
+		logger.Fatal("seed role permissions failed", zap.Error(err))
+	}
+
+	visibilitySvc := services.NewOrgVisibilityService(userRepo)
+	// Audit and activity records go through the outbox, in the same transaction as the change they describe;
+	// the dispatcher queues webhook deliveries for the audit records it writes.
+	webhookFanout := services.NewWebhookFanout(webhookRepo, groupRepo)
+	outboxSvc := services.NewOutboxService(outboxRepo, auditRepo, activityRepo, webhookFanout, services.OutboxConfig{
+		BatchSize:    cfg.Outbox.BatchSize,
+		PollInterval: time.Duration(cfg.Outbox.PollIntervalMs) * time.Millisecond,
+		MaxAttempts:  cfg.Outbox.MaxAttempts,
+	}, logger)
+	auditSvc := services.NewAuditService(auditRepo, outboxSvc, transactor, productRepo, memberRepo, visibilitySvc, logger, policy, auditchain.DeriveKey(cfg.AuditSigningSecret()))
+	// Rows written before the hash chain existed are appended to it once; later rows are chained on write.
+	if n, err := auditSvc.SealUnchained(context.Background()); err != nil {
+		logger.Fatal("audit chain seal failed", zap.Error(err))
+	} else if n > 0 {
+		logger.Info("audit chain: sealed pre-existing rows", zap.Int("count", n))
+	}
+	activitySvc := services.NewActivityService(activityRepo, outboxSvc, visibilitySvc, logger, policy)
+	// Notification email is queued in notification_deliveries and sent in the background; without SMTP_HOST
+	// only the settings endpoints work.
+	var mailSender mail.Sender
+	if cfg.Email.SMTPHost != "" {
+		smtpSender, err := mail.NewSMTPSender(mail.SMTPConfig{
+			Host:     cfg.Email.SMTPHost,
+			Port:     cfg.Email.SMTPPort,
+			Username: cfg.Email.SMTPUsername,
+			Password: cfg.Email.SMTPPassword,
+			From:     cfg.Email.SMTPFrom,
+			TLS:      cfg.Email.SMTPTLS,
+			Timeout:  time.Duration(cfg.Email.SMTPTimeoutSec) * time.Second,
+		})
+		if err != nil {
+			logger.Fatal("smtp config invalid", zap.Error(err))
+		}
+		mailSender = smtpSender
+	}
+	emailSvc, err := services.NewNotificationEmailService(deliveryRepo, notificationRepo, userRepo, transactor, mailSender, services.NotificationEmailConfig{
+		BaseURL:      cfg.Email.AppBaseURL,
+		PollInterval: time.Duration(cfg.Email.PollIntervalSec) * time.Second,
+		BatchSize:    cfg.Email.BatchSize,
+		MaxAttempts:  cfg.Email.MaxAttempts,
+		Secret:       cfg.JWT.Secret,
+	}, logger)
+	if err != nil {
+		logger.Fatal("notification email templates invalid", zap.Error(err))
+	}
+	notificationPrefSvc := services.NewNotificationPreferenceService(notificationPrefRepo, transactor, auditSvc, logger)
+	// Realtime events reach the SSE clients of every replica through Postgres LISTEN/NOTIFY unless this is
+	// a single replica configured with the local broadcaster.
+	var broadcaster realtime.Broadcaster
+	switch cfg.Realtime.Broadcaster {
+	case "local":
+		broadcaster = realtime.NewLocalBroadcaster()
+	case "postgres":
+		sqlDB, err := db.DB()
+		if err != nil {
+			logger.Fatal("db handle unavailable", zap.Error(err))
+		}
+		broadcaster = realtime.NewPostgresBroadcaster(sqlDB, dsn, logger)
+	default:
+		logger.Fatal("REALTIME_BROADCASTER must be postgres or local", zap.String("value", cfg.Realtime.Broadcaster))
+	}
+	hub := realtime.NewHub(broadcaster, logger)
+	notificationSvc := services.NewNotificationService(notificationRepo, userRepo, notificationPrefSvc, emailSvc, hub)
+	notificationRetentionSvc := services.NewNotificationRetentionService(notificationRepo, services.NotificationRetentionConfig{
+		Interval: time.Duration(cfg.Notification.RetentionIntervalMin) * time.Minute,
+		MaxAge:   time.Duration(cfg.Notification.RetentionDays) * 24 * time.Hour,
+	}, logger)
+
+	loginGuard := services.NewLoginGuardService(loginLockoutRepo, userRepo, services.LoginGuardConfig{
+		MaxFailedAttempts:   cfg.Login.MaxFailedAttempts,
+		IPMaxFailedAttempts: cfg.Login.IPMaxFailedAttempts,
+		FailureWindow:       time.Duration(cfg.Login.FailureWindowMin) * time.Minute,
+		LockoutBase:         time.Duration(cfg.Login.LockoutBaseSec) * time.Second,
+		LockoutMax:          time.Duration(cfg.Login.LockoutMaxSec) * time.Second,
+	}, transactor, auditSvc, activitySvc, logger)
+	sessionSvc := services.NewSessionService(sessionRepo, transactor, auditSvc, hub, logger)
+	// Directory login; nil keeps local password auth only.
+	var directory auth.Authenticator
+	if cfg.LDAP.URL != "" {
+		groupRoles, err := auth.ParseGroupRoles(cfg.LDAP.GroupRoles)
+		if err != nil {
+			logger.Fatal("LDAP_GROUP_ROLES invalid", zap.Error(err))
+		}
+		defaultRole := cfg.LDAP.DefaultRole
+		if defaultRole == "none" {
+			defaultRole = ""
+		}
+		role, err := auth.ParseRole(defaultRole)
+		if err != nil {
+			logger.Fatal("LDAP_DEFAULT_ROLE invalid", zap.Error(err))
+		}
+		ldapAuth, err := auth.NewLDAPAuthenticator(auth.LDAPConfig{
+			URL:                cfg.LDAP.URL,
+			StartTLS:           cfg.LDAP.StartTLS,
+			InsecureSkipVerify: cfg.LDAP.InsecureSkipVerify,
+			BindDN:             cfg.LDAP.BindDN,
+			BindPassword:       cfg.LDAP.BindPassword,
+			BaseDN:             cfg.LDAP.BaseDN,
+			UserFilter:         cfg.LDAP.UserFilter,
+			EmailAttr:          cfg.LDAP.EmailAttr,
+			NameAttr:           cfg.LDAP.NameAttr,
+			GroupAttr:          cfg.LDAP.GroupAttr,
+			GroupBaseDN:        cfg.LDAP.GroupBaseDN,
+			GroupFilter:        cfg.LDAP.GroupFilter,
+			GroupRoles:         groupRoles,
+			DefaultRole:        role,
+			Timeout:            time.Duration(cfg.LDAP.TimeoutSec) * time.Second,
+		})
+		if err != nil {
+			logger.Fatal("ldap config invalid", zap.Error(err))
+		}
+		directory = ldapAuth
+	}
+	authSvc := services.NewAuthService(userRepo, jwtService, loginGuard, sessionSvc, directory)
+	watchSvc := services.NewWatchService(watchRepo, productRepo, versionRepo, groupRepo, milestoneRepo, versionDepRepo, notificationSvc, policy, logger)
+	productSvc := services.NewProductService(productRepo, versionRepo, deletionReqRepo, groupRepo, milestoneRepo, memberRepo, visibilitySvc, transactor, auditSvc, activitySvc, notificationSvc, policy, watchSvc, hub)
+	groupSvc := services.NewGroupService(groupRepo, policy, transactor, auditSvc)
+	milestoneSvc := services.NewMilestoneService(milestoneRepo, productRepo, depRepo, memberRepo, transactor, auditSvc, activitySvc, policy, watchSvc, hub)
+	depSvc := services.NewDependencyService(depRepo, milestoneRepo, transactor, auditSvc, activitySvc)
+	reqSvc := services.NewProductRequestService(reqRepo, productRepo, userRepo, transactor, auditSvc, activitySvc, notificationSvc, policy)
+	productVersionSvc := services.NewProductVersionService(versionRepo, productRepo, memberRepo, transactor, auditSvc, activitySvc, policy, watchSvc)
+	versionDepSvc := services.NewProductVersionDependencyService(versionDepRepo, versionRepo, productRepo, memberRepo, transactor, auditSvc, policy)
+	deletionReqSvc := services.NewProductDeletionRequestService(deletionReqRepo, productRepo, versionRepo, userRepo, transactor, auditSvc, activitySvc, notificationSvc, policy)
+	memberSvc := services.NewProductMemberService(memberRepo, productRepo, productSvc, userRepo, transactor, auditSvc, activitySvc, notificationSvc, policy)
+	retentionSvc := services.NewAuditRetentionService(retentionRepo, transactor, auditSvc, services.AuditRetentionConfig{
+		Interval:  time.Duration(cfg.Audit.RetentionIntervalMin) * time.Minute,
+		BatchSize: cfg.Audit.RetentionBatchSize,
+	}, logger)
+	webhookAllowed, err := services.ParseWebhookAllowedNets(cfg.Webhook.AllowedCIDRs)
+	if err != nil {
+		logger.Fatal("WEBHOOK_ALLOWED_CIDRS invalid", zap.Error(err))
+	}
+	webhookSvc := services.NewWebhookService(webhookRepo, webhookFanout, transactor, auditSvc, services.WebhookConfig{
+		PollInterval: time.Duration(cfg.Webhook.PollIntervalSec) * time.Second,
+		BatchSize:    cfg.Webhook.BatchSize,
+		MaxAttempts:  cfg.Webhook.MaxAttempts,
+		Timeout:      time.Duration(cfg.Webhook.TimeoutSec) * time.Second,
+		BaseURL:      cfg.Email.AppBaseURL,
+		AllowedNets:  webhookAllowed,
+	}, logger)
+	if cfg.ChatOps.TeamsSecret != "" {
+		if _, err := base64.StdEncoding.DecodeString(cfg.ChatOps.TeamsSecret); err != nil {
+			logger.Fatal("CHATOPS_TEAMS_SECRET is not valid base64", zap.Error(err))
+		}
+	}
+	chatOpsSvc := services.NewChatOpsService(productRepo, versionDepRepo, watchSvc, cfg.Email.AppBaseURL)
+	reminderDays, err := services.ParseReminderDays(cfg.Reminder.Days)
+	if err != nil {
+		logger.Fatal("MILESTONE_REMINDER_DAYS invalid", zap.Error(err))
+	}
+	// One replica sends reminders at a time: the one holding the advisory lock.
+	reminderSvc := services.NewMilestoneReminderService(reminderRepo, memberRepo, watchRepo, notificationSvc, repositories.NewAdvisoryLock(db, "milestone_reminders"), services.MilestoneReminderConfig{
+		Interval:            time.Duration(cfg.Reminder.IntervalMin) * time.Minute,
+		DaysBefore:          reminderDays,
+		OverdueLookbackDays: cfg.Reminder.OverdueLookbackDays,
+	}, logger)
+	restoreSvc := services.NewRestoreService(auditRepo, productRepo, milestoneRepo, versionRepo, memberRepo, transactor, auditSvc, activitySvc, policy)
+	orgSvc := services.NewOrgService(holdingRepo, companyRepo, funcRepo, deptRepo, teamRepo, transactor, auditSvc)
+	scimCfg := services.SCIMConfig{BaseURL: cfg.SCIM.BaseURL}
+	if cfg.SCIM.DefaultDepartmentID != "" {
+		id, err := uuid.Parse(cfg.SCIM.DefaultDepartmentID)
+		if err != nil {
+			logger.Fatal("SCIM_DEFAULT_DEPARTMENT_ID is not a valid id", zap.Error(err))
+		}
+		scimCfg.DefaultDepartmentID = &id
+	}
+	scimSvc := services.NewSCIMService(userRepo, teamRepo, dottedLineRepo, productRepo, memberRepo, sessionSvc, transactor, auditSvc, notificationSvc, scimCfg, logger)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -131,21 +348,64 @@ func main() {
 	} else {
 		defer func() { _ = tp.Shutdown(ctx) }()
 	}
+	go keyring.Run(ctx)
+	dispatcherCtx, stopDispatcher := context.WithCancel(ctx)
+	dispatcherDone := make(chan struct{})
+	go func() {
+		defer close(dispatcherDone)
+		outboxSvc.Run(dispatcherCtx)
+	}()
+	go retentionSvc.Start(ctx)
+	go hub.Run(ctx)
+	go sessionSvc.Run(ctx)
+	go webhookSvc.Run(ctx)
+	go reminderSvc.Start(ctx)
+	go notificationRetentionSvc.Start(ctx)
+	if emailSvc.Enabled() {
+		go emailSvc.Run(ctx)
+		logger.Info("notification email enabled", zap.String("smtp_host", cfg.Email.SMTPHost))
+	}
+	if cfg.SIEM.Target != "" {
+		forwarder, err := services.NewSIEMForwarder(repositories.NewForwarderCheckpointRepository(db), auditRepo, activityRepo, transactor, services.SIEMForwarderConfig{
+			Target:    cfg.SIEM.Target,
+			Format:    cfg.SIEM.Format,
+			Streams:   services.ParseSIEMStreams(cfg.SIEM.Streams),
+			Interval:  time.Duration(cfg.SIEM.IntervalSec) * time.Second,
+			BatchSize: cfg.SIEM.BatchSize,
+			Timeout:   time.Duration(cfg.SIEM.TimeoutSec) * time.Second,
+		}, logger)
+		if err != nil {
+			logger.Fatal("siem forwarder config invalid", zap.Error(err))
+		}
+		go forwarder.Run(ctx)
+		logger.Info("siem forwarder enabled", zap.String("target", cfg.SIEM.Target), zap.String("format", cfg.SIEM.Format))
+	}
 
-	authHandler := handlers.NewAuthHandler(authSvc, activitySvc, logger)
+	authHandler := handlers.NewAuthHandler(authSvc, activitySvc, loginGuard, logger)
 	productHandler := handlers.NewProductHandler(productSvc, logger)
 	milestoneHandler := handlers.NewMilestoneHandler(milestoneSvc)
 	depHandler := handlers.NewDependencyHandler(depSvc)
 	reqHandler := handlers.NewProductRequestHandler(reqSvc)
-	userHandler := handlers.NewUserHandler(userRepo, dottedLineRepo, productRepo)
+	userHandler := handlers.NewUserHandler(userRepo, dottedLineRepo, productRepo, memberRepo, sessionSvc, transactor, auditSvc, policy)
 	orgHandler := handlers.NewOrgHandler(orgSvc)
 	productVersionHandler := handlers.NewProductVersionHandler(productVersionSvc)
 	versionDepHandler := handlers.NewProductVersionDependencyHandler(versionDepSvc)
 	deletionReqHandler := handlers.NewProductDeletionRequestHandler(deletionReqSvc)
-	notificationHandler := handlers.NewNotificationHandler(notificationSvc)
-	auditHandler := handlers.NewAuditHandler(auditSvc, authSvc)
-	activityHandler := handlers.NewActivityHandler(activitySvc)
+	notificationHandler := handlers.NewNotificationHandler(notificationSvc, emailSvc)
+	notificationPrefHandler := handlers.NewNotificationPreferenceHandler(notificationPrefSvc)
+	eventHandler := handlers.NewEventHandler(hub, notificationSvc, sessionSvc, productSvc, time.Duration(cfg.Realtime.HeartbeatSec)*time.Second, logger)
+	auditHandler := handlers.NewAuditHandler(auditSvc, restoreSvc, authSvc)
+	retentionHandler := handlers.NewAuditRetentionHandler(retentionSvc)
+	webhookHandler := handlers.NewWebhookHandler(webhookSvc)
+	watchHandler := handlers.NewWatchHandler(watchSvc)
+	activityHandler := handlers.NewActivityHandler(activitySvc, sessionSvc)
 	groupHandler := handlers.NewGroupHandler(groupSvc)
+	permissionHandler := handlers.NewPermissionHandler(policy, userRepo)
+	memberHandler := handlers.NewProductMemberHandler(memberSvc)
+	sessionHandler := handlers.NewSessionHandler(sessionSvc, userRepo)
+	jwksHandler := handlers.NewJWKSHandler(keyring)
+	scimHandler := handlers.NewSCIMHandler(scimSvc)
+	chatOpsHandler := handlers.NewChatOpsHandler(chatOpsSvc, cfg.ChatOps.SlackSigningSecret, cfg.ChatOps.TeamsSecret)
 
 	r := gin.New()
 	// When behind Next.js proxy (Docker Compose), trust proxy so ClientIP etc. work from X-Forwarded-*
@@ -159,6 +419,7 @@ func main() {
 	r.Use(middleware.Prometheus())
 	r.Use(middleware.Telemetry())
 	r.Use(middleware.RateLimit())
+	r.Use(middleware.Locale())
 
 	// Unauthenticated health check (for proxy); pings DB so 503 indicates DB issue.
 	// GET /api/health?loki_test=1 logs a line so you can verify backend logs in Loki (Grafana Explore).
@@ -180,21 +441,66 @@ func main() {
 		c.JSON(http.StatusOK, gin.H{"status": "ok", "db": "ok"})
 	})
 
+	r.GET("/.well-known/jwks.json", jwksHandler.Get)
 	r.POST("/auth/login", authHandler.Login)
 	r.POST("/auth/register", authHandler.Register)
 	r.POST("/auth/refresh", authHandler.Refresh)
+	// Unsubscribe links in notification email are signed and work without logging in.
+	r.GET("/api/notifications/unsubscribe", notificationHandler.Unsubscribe)
+	r.POST("/api/notifications/unsubscribe", notificationHandler.Unsubscribe)
+	// Chat commands are signed by Slack or Teams; each endpoint is only mounted when its secret is configured.
+	if cfg.ChatOps.SlackSigningSecret != "" {
+		r.POST("/api/chatops/slack/command", chatOpsHandler.SlackCommand)
+	}
+	if cfg.ChatOps.TeamsSecret != "" {
+		r.POST("/api/chatops/teams/command", chatOpsHandler.TeamsCommand)
+	}
+
+	// SCIM 2.0 provisioning for the identity provider; only mounted when a token is configured.
+	if cfg.SCIM.BearerToken != "" {
+		scimAPI := r.Group("/scim/v2")
+		scimAPI.Use(middleware.SCIMAuth(cfg.SCIM.BearerToken))
+		scimAPI.Use(middleware.AuditContext())
+		{
+			scimAPI.GET("/ServiceProviderConfig", scimHandler.ServiceProviderConfig)
+			scimAPI.GET("/ResourceTypes", scimHandler.ResourceTypes)
+			scimAPI.GET("/Users", scimHandler.ListUsers)
+			scimAPI.POST("/Users", scimHandler.CreateUser)
+			scimAPI.GET("/Users/:id", scimHandler.GetUser)
+			scimAPI.PUT("/Users/:id", scimHandler.ReplaceUser)
+			scimAPI.PATCH("/Users/:id", scimHandler.PatchUser)
+			scimAPI.DELETE("/Users/:id", scimHandler.DeleteUser)
+			scimAPI.GET("/Groups", scimHandler.ListGroups)
+			scimAPI.POST("/Groups", scimHandler.CreateGroup)
+			scimAPI.GET("/Groups/:id", scimHandler.GetGroup)
+			scimAPI.PUT("/Groups/:id", scimHandler.ReplaceGroup)
+			scimAPI.PATCH("/Groups/:id", scimHandler.PatchGroup)
+			scimAPI.DELETE("/Groups/:id", scimHandler.DeleteGroup)
+		}
+	}
 
 	api := r.Group("/api")
-	api.Use(middleware.Auth(jwtService))
+	api.Use(middleware.Auth(jwtService, sessionSvc))
 	api.Use(middleware.AuditContext())
+	api.Use(middleware.VisibilityCache())
 	{
 		api.POST("/auth/logout", activityHandler.Logout)
+		api.GET("/sessions", sessionHandler.ListMine)
+		api.DELETE("/sessions", sessionHandler.RevokeAllMine)
+		api.DELETE("/sessions/:id", sessionHandler.RevokeMine)
+		api.GET("/locale", userHandler.GetLocale)
+		api.PUT("/locale", userHandler.UpdateLocale)
 
 		api.GET("/products", productHandler.List)
-		api.POST("/products", productHandler.Create)
+		api.POST("/products", middleware.RequirePermission(policy, authz.PermProductCreate), productHandler.Create)
 		api.GET("/products/:id", productHandler.GetByID)
 		api.PUT("/products/:id", productHandler.Update)
-		api.DELETE("/products/:id", middleware.RequireAdmin(), productHandler.Delete)
+		api.DELETE("/products/:id", middleware.RequirePermission(policy, authz.PermProductDelete), productHandler.Delete)
+
+		api.GET("/products/:id/members", memberHandler.List)
+		api.POST("/products/:id/members", memberHandler.Add)
+		api.PUT("/products/:id/members/:user_id", memberHandler.Update)
+		api.DELETE("/products/:id/members/:user_id", memberHandler.Delete)
 
 		api.GET("/products/:id/milestones", milestoneHandler.ListByProduct)
 		api.GET("/products/:id/versions", productVersionHandler.ListByProduct)
@@ -206,7 +512,7 @@ func main() {
 		api.DELETE("/product-version-dependencies/:id", versionDepHandler.Delete)
 		api.POST("/products/:id/request-deletion", deletionReqHandler.Create)
 		api.GET("/product-deletion-requests", deletionReqHandler.List)
-		api.PUT("/product-deletion-requests/:id/approve", middleware.RequireAdmin(), deletionReqHandler.Approve)
+		api.PUT("/product-deletion-requests/:id/approve", middleware.RequirePermission(policy, authz.PermRequestApprove), deletionReqHandler.Approve)
 		api.POST("/milestones", milestoneHandler.Create)
 		api.PUT("/milestones/:id", milestoneHandler.Update)
 		api.DELETE("/milestones/:id", milestoneHandler.Delete)
@@ -217,57 +523,105 @@ func main() {
 
 		api.POST("/product-requests", reqHandler.Create)
 		api.GET("/product-requests", reqHandler.List)
-		api.PUT("/product-requests/:id/approve", middleware.RequireAdmin(), reqHandler.Approve)
+		api.PUT("/product-requests/:id/approve", middleware.RequirePermission(policy, authz.PermRequestApprove), reqHandler.Approve)
+
+		api.GET("/events", eventHandler.Stream)
 
 		api.GET("/notifications", notificationHandler.List)
 		api.GET("/notifications/unread-count", notificationHandler.UnreadCount)
 		api.PUT("/notifications/read-all", notificationHandler.MarkReadAll)
+		api.POST("/notifications/bulk", notificationHandler.Bulk)
 		api.PUT("/notifications/:id/read", notificationHandler.MarkRead)
 		api.PUT("/notifications/:id/archive", notificationHandler.Archive)
 		api.DELETE("/notifications/:id", notificationHandler.Delete)
+		api.GET("/notifications/email-settings", notificationHandler.EmailSettings)
+		api.PUT("/notifications/email-settings", notificationHandler.UpdateEmailSettings)
+		api.GET("/notifications/preferences", notificationPrefHandler.Get)
+		api.PUT("/notifications/preferences", notificationPrefHandler.Update)
+		api.GET("/notification-defaults", middleware.RequirePermission(policy, authz.PermNotificationManage), notificationPrefHandler.Defaults)
+		api.PUT("/notification-defaults", middleware.RequirePermission(policy, authz.PermNotificationManage), notificationPrefHandler.UpdateDefaults)
+		api.GET("/notification-defaults/audit", middleware.RequirePermission(policy, authz.PermNotificationManage), notificationPrefHandler.History)
+
+		api.GET("/watches", watchHandler.List)
+		api.POST("/watches", watchHandler.Watch)
+		api.DELETE("/watches/:entity_type/:entity_id", watchHandler.Unwatch)
+
+		api.GET("/webhooks", middleware.RequirePermission(policy, authz.PermWebhookManage), webhookHandler.List)
+		api.POST("/webhooks", middleware.RequirePermission(policy, authz.PermWebhookManage), webhookHandler.Create)
+		api.GET("/webhooks/:id", middleware.RequirePermission(policy, authz.PermWebhookManage), webhookHandler.Get)
+		api.PUT("/webhooks/:id", middleware.RequirePermission(policy, authz.PermWebhookManage), webhookHandler.Update)
+		api.DELETE("/webhooks/:id", middleware.RequirePermission(policy, authz.PermWebhookManage), webhookHandler.Delete)
+		api.GET("/webhooks/:id/deliveries", middleware.RequirePermission(policy, authz.PermWebhookManage), webhookHandler.Deliveries)
+		api.GET("/webhooks/:id/deliveries/:delivery_id", middleware.RequirePermission(policy, authz.PermWebhookManage), webhookHandler.Delivery)
+		api.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", middleware.RequirePermission(policy, authz.PermWebhookManage), webhookHandler.Redeliver)
+
+		api.GET("/users", middleware.RequirePermission(policy, authz.PermUserManage), userHandler.List)
+		api.GET("/users/:id", middleware.RequirePermission(policy, authz.PermUserManage), userHandler.GetByID)
+		api.PUT("/users/:id", middleware.RequirePermission(policy, authz.PermUserManage), userHandler.Update)
+		api.PUT("/users/:id/remove-from-products", middleware.RequirePermission(policy, authz.PermUserManage), userHandler.RemoveFromProducts)
+		api.DELETE("/users/:id", middleware.RequirePermission(policy, authz.PermUserManage), userHandler.Delete)
+		api.POST("/users/:id/unlock", middleware.RequirePermission(policy, authz.PermLoginUnlock), authHandler.UnlockUser)
+		api.GET("/login-lockouts", middleware.RequirePermission(policy, authz.PermLoginUnlock), authHandler.ListLockouts)
+		api.DELETE("/login-lockouts/:id", middleware.RequirePermission(policy, authz.PermLoginUnlock), authHandler.DeleteLockout)
+		api.GET("/users/:id/sessions", middleware.RequirePermission(policy, authz.PermSessionManage), sessionHandler.ListForUser)
+		api.DELETE("/users/:id/sessions", middleware.RequirePermission(policy, authz.PermSessionManage), sessionHandler.RevokeAllForUser)
+		api.DELETE("/users/:id/sessions/:session_id", middleware.RequirePermission(policy, authz.PermSessionManage), sessionHandler.RevokeForUser)
+		api.GET("/users/:id/dotted-line-managers", middleware.RequirePermission(policy, authz.PermUserManage), userHandler.ListDottedLineManagers)
+		api.POST("/users/:id/dotted-line-managers", middleware.RequirePermission(policy, authz.PermUserManage), userHandler.AddDottedLineManager)
+		api.DELETE("/users/:id/dotted-line-managers/:manager_id", middleware.RequirePermission(policy, authz.PermUserManage), userHandler.RemoveDottedLineManager)
+
+		api.GET("/holding-companies", middleware.RequirePermission(policy, authz.PermOrgManage), orgHandler.ListHoldingCompanies)
+		api.POST("/holding-companies", middleware.RequirePermission(policy, authz.PermOrgManage), orgHandler.CreateHoldingCompany)
+		api.GET("/holding-companies/:id", middleware.RequirePermission(policy, authz.PermOrgManage), orgHandler.GetHoldingCompany)
+		api.PUT("/holding-companies/:id", middleware.RequirePermission(policy, authz.PermOrgManage), orgHandler.UpdateHoldingCompany)
+		api.DELETE("/holding-companies/:id", middleware.RequirePermission(policy, authz.PermOrgManage), orgHandler.DeleteHoldingCompany)
+
+		api.GET("/companies", middleware.RequirePermission(policy, authz.PermOrgManage), orgHandler.ListCompanies)
+		api.POST("/companies", middleware.RequirePermission(policy, authz.PermOrgManage), orgHandler.CreateCompany)
+		api.GET("/companies/:id", middleware.RequirePermission(policy, authz.PermOrgManage), orgHandler.GetCompany)
+		api.PUT("/companies/:id", middleware.RequirePermission(policy, authz.PermOrgManage), orgHandler.UpdateCompany)
+		api.DELETE("/companies/:id", middleware.RequirePermission(policy, authz.PermOrgManage), orgHandler.DeleteCompany)
 
-		api.GET("/users", middleware.RequireAdmin(), userHandler.List)
-		api.GET("/users/:id", middleware.RequireAdmin(), userHandler.GetByID)
-		api.PUT("/users/:id", middleware.RequireAdmin(), userHandler.Update)
-		api.PUT("/users/:id/remove-from-products", middleware.RequireAdmin(), userHandler.RemoveFromProducts)
-		api.DELETE("/users/:id", middleware.RequireAdmin(), userHandler.Delete)
-		api.GET("/users/:id/dotted-line-managers", middleware.RequireAdmin(), userHandler.ListDottedLineManagers)
-		api.POST("/users/:id/dotted-line-managers", middleware.RequireAdmin(), userHandler.AddDottedLineManager)
-		api.DELETE("/users/:id/dotted-line-managers/:manager_id", middleware.RequireAdmin(), userHandler.RemoveDottedLineManager)
-
-		api.GET("/holding-companies", middleware.RequireAdmin(), orgHandler.ListHoldingCompanies)
-		api.POST("/holding-companies", middleware.RequireAdmin(), orgHandler.CreateHoldingCompany)
-		api.GET("/holding-companies/:id", middleware.RequireAdmin(), orgHandler.GetHoldingCompany)
-		api.PUT("/holding-companies/:id", middleware.RequireAdmin(), orgHandler.UpdateHoldingCompany)
-		api.DELETE("/holding-companies/:id", middleware.RequireAdmin(), orgHandler.DeleteHoldingCompany)
-
-		api.GET("/companies", middleware.RequireAdmin(), orgHandler.ListCompanies)
-		api.POST("/companies", middleware.RequireAdmin(), orgHandler.CreateCompany)
-		api.GET("/companies/:id", middleware.RequireAdmin(), orgHandler.GetCompany)
-		api.PUT("/companies/:id", middleware.RequireAdmin(), orgHandler.UpdateCompany)
-		api.DELETE("/companies/:id", middleware.RequireAdmin(), orgHandler.DeleteCompany)
-
-		api.GET("/functions", middleware.RequireAdmin(), orgHandler.ListFunctions)
-		api.POST("/functions", middleware.RequireAdmin(), orgHandler.CreateFunction)
-		api.GET("/functions/:id", middleware.RequireAdmin(), orgHandler.GetFunction)
-		api.PUT("/functions/:id", middleware.RequireAdmin(), orgHandler.UpdateFunction)
-		api.DELETE("/functions/:id", middleware.RequireAdmin(), orgHandler.DeleteFunction)
-
-		api.GET("/departments", middleware.RequireAdmin(), orgHandler.ListDepartments)
-		api.POST("/departments", middleware.RequireAdmin(), orgHandler.CreateDepartment)
-		api.GET("/departments/:id", middleware.RequireAdmin(), orgHandler.GetDepartment)
-		api.PUT("/departments/:id", middleware.RequireAdmin(), orgHandler.UpdateDepartment)
-		api.DELETE("/departments/:id", middleware.RequireAdmin(), orgHandler.DeleteDepartment)
-
-		api.GET("/teams", middleware.RequireAdmin(), orgHandler.ListTeams)
-		api.POST("/teams", middleware.RequireAdmin(), orgHandler.CreateTeam)
-		api.GET("/teams/:id", middleware.RequireAdmin(), orgHandler.GetTeam)
-		api.PUT("/teams/:id", middleware.RequireAdmin(), orgHandler.UpdateTeam)
-		api.DELETE("/teams/:id", middleware.RequireAdmin(), orgHandler.DeleteTeam)
+		api.GET("/functions", middleware.RequirePermission(policy, authz.PermOrgManage), orgHandler.ListFunctions)
+		api.POST("/functions", middleware.RequirePermission(policy, authz.PermOrgManage), orgHandler.CreateFunction)
+		api.GET("/functions/:id", middleware.RequirePermission(policy, authz.PermOrgManage), orgHandler.GetFunction)
+		api.PUT("/functions/:id", middleware.RequirePermission(policy, authz.PermOrgManage), orgHandler.UpdateFunction)
+		api.DELETE("/functions/:id", middleware.RequirePermission(policy, authz.PermOrgManage), orgHandler.DeleteFunction)
+
+		api.GET("/departments", middleware.RequirePermission(policy, authz.PermOrgManage), orgHandler.ListDepartments)
+		api.POST("/departments", middleware.RequirePermission(policy, authz.PermOrgManage), orgHandler.CreateDepartment)
+		api.GET("/departments/:id", middleware.RequirePermission(policy, authz.PermOrgManage), orgHandler.GetDepartment)
+		api.PUT("/departments/:id", middleware.RequirePermission(policy, authz.PermOrgManage), orgHandler.UpdateDepartment)
+		api.DELETE("/departments/:id", middleware.RequirePermission(policy, authz.PermOrgManage), orgHandler.DeleteDepartment)
+
+		api.GET("/teams", middleware.RequirePermission(policy, authz.PermOrgManage), orgHandler.ListTeams)
+		api.POST("/teams", middleware.RequirePermission(policy, authz.PermOrgManage), orgHandler.CreateTeam)
+		api.GET("/teams/:id", middleware.RequirePermission(policy, authz.PermOrgManage), orgHandler.GetTeam)
+		api.PUT("/teams/:id", middleware.RequirePermission(policy, authz.PermOrgManage), orgHandler.UpdateTeam)
+		api.DELETE("/teams/:id", middleware.RequirePermission(policy, authz.PermOrgManage), orgHandler.DeleteTeam)
 		api.GET("/audit-logs", auditHandler.List)
-		api.POST("/audit-logs/archive", middleware.RequireAdmin(), auditHandler.Archive)
-		api.POST("/audit-logs/archive/delete", middleware.RequireAdmin(), auditHandler.DeleteArchived)
+		api.GET("/audit-logs/export", auditHandler.Export)
+		api.POST("/audit-logs/archive", middleware.RequirePermission(policy, authz.PermAuditArchive), auditHandler.Archive)
+		api.POST("/audit-logs/archive/delete", middleware.RequirePermission(policy, authz.PermAuditPurge), auditHandler.DeleteArchived)
+		api.GET("/audit-logs/verify", middleware.RequirePermission(policy, authz.PermAuditVerify), auditHandler.Verify)
+		api.GET("/audit-logs/entity/:type/:id", auditHandler.EntityHistory)
+		api.POST("/audit-logs/:id/restore", auditHandler.Restore)
+
+		api.GET("/audit-retention/policies", middleware.RequirePermission(policy, authz.PermAuditRetention), retentionHandler.ListPolicies)
+		api.POST("/audit-retention/policies", middleware.RequirePermission(policy, authz.PermAuditRetention), retentionHandler.CreatePolicy)
+		api.PUT("/audit-retention/policies/:id", middleware.RequirePermission(policy, authz.PermAuditRetention), retentionHandler.UpdatePolicy)
+		api.DELETE("/audit-retention/policies/:id", middleware.RequirePermission(policy, authz.PermAuditRetention), retentionHandler.DeletePolicy)
+		api.GET("/audit-retention/holds", middleware.RequirePermission(policy, authz.PermAuditRetention), retentionHandler.ListHolds)
+		api.POST("/audit-retention/holds", middleware.RequirePermission(policy, authz.PermAuditRetention), retentionHandler.CreateHold)
+		api.DELETE("/audit-retention/holds/:id", middleware.RequirePermission(policy, authz.PermAuditRetention), retentionHandler.DeleteHold)
+		api.GET("/audit-retention/preview", middleware.RequirePermission(policy, authz.PermAuditRetention), retentionHandler.Preview)
+		api.POST("/audit-retention/run", middleware.RequirePermission(policy, authz.PermAuditRetention), retentionHandler.Run)
 		api.GET("/activity-logs", activityHandler.List)
+		api.GET("/activity-logs/export", activityHandler.Export)
+
+		api.GET("/permissions", middleware.RequirePermission(policy, authz.PermPermissionRead), permissionHandler.Overview)
+		api.GET("/permissions/me", permissionHandler.Me)
+		api.GET("/users/:id/permissions", middleware.RequirePermission(policy, authz.PermPermissionRead), permissionHandler.ForUser)
 
 		api.GET("/groups", groupHandler.List)
 		api.POST("/groups", groupHandler.Create)
@@ -284,9 +638,35 @@ func main() {
 	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
 	addr := ":" + cfg.Server.Port
-	logger.Info("server listening", zap.String("addr", addr))
-	if err := r.Run(addr); err != nil {
-		logger.Fatal("server run failed", zap.Error(err))
-		os.Exit(1)
+	srv := &http.Server{Addr: addr, Handler: r}
+	sigCtx, stopSignals := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+	serveErr := make(chan error, 1)
+	go func() {
+		logger.Info("server listening", zap.String("addr", addr))
+		serveErr <- srv.ListenAndServe()
+	}()
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			logger.Fatal("server run failed", zap.Error(err))
+		}
+	case <-sigCtx.Done():
+		logger.Info("shutting down")
+	}
+
+	// Stop taking requests, then flush what they enqueued before exiting.
+	drainTimeout := time.Duration(cfg.Outbox.DrainTimeoutSec) * time.Second
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer shutdownCancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logger.Warn("http shutdown incomplete", zap.Error(err))
+	}
+	stopDispatcher()
+	<-dispatcherDone
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer drainCancel()
+	if err := outboxSvc.Drain(drainCtx); err != nil {
+		logger.Warn("outbox drain incomplete; remaining events are dispatched on next start", zap.Error(err))
 	}
 }
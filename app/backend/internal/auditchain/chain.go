@@ -0,0 +1,290 @@
+// Package auditchain makes audit_logs tamper-evident. Every row stores the SHA-256 of its canonical
+// content, which includes the previous row's hash, so changing, inserting or removing a row breaks the
+// chain. Deleting rows leaves an HMAC-signed tombstone that keeps the chain verifiable.
+//
+// The archived flag and timestamps of archiving or soft-deletion are status, not content, and are not hashed.
+package auditchain
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/models"
+)
+
+// GenesisHash is the previous hash of the first row in the chain.
+const GenesisHash = "0000000000000000000000000000000000000000000000000000000000000000"
+
+// Seal assigns e its position in the chain and computes its hash. JSON payloads are normalized the way
+// they come back from a jsonb column, and the timestamp is truncated to Postgres precision, so the stored
+// row hashes to the same value when read back.
+func Seal(e *models.AuditLog, seq int64, prevHash string) {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now()
+	}
+	e.Timestamp = e.Timestamp.UTC().Truncate(time.Microsecond)
+	e.OldData = normalize(e.OldData)
+	e.NewData = normalize(e.NewData)
+	e.Metadata = normalize(e.Metadata)
+	e.Seq = &seq
+	e.PrevHash = prevHash
+	e.Hash = Hash(e)
+}
+
+// Hash returns the hex SHA-256 of the row's canonical content.
+func Hash(e *models.AuditLog) string {
+	sum := sha256.Sum256(Canonical(e))
+	return hex.EncodeToString(sum[:])
+}
+
+// Canonical is the byte form that is hashed: a JSON object with fixed field order (map keys inside the
+// payloads are sorted by encoding/json).
+func Canonical(e *models.AuditLog) []byte {
+	var seq int64
+	if e.Seq != nil {
+		seq = *e.Seq
+	}
+	var userID string
+	if e.UserID != nil {
+		userID = e.UserID.String()
+	}
+	b, _ := json.Marshal(struct {
+		Seq        int64        `json:"seq"`
+		ID         string       `json:"id"`
+		Timestamp  string       `json:"timestamp"`
+		UserID     string       `json:"user_id"`
+		Action     string       `json:"action"`
+		EntityType string       `json:"entity_type"`
+		EntityID   string       `json:"entity_id"`
+		OldData    models.JSONB `json:"old_data"`
+		NewData    models.JSONB `json:"new_data"`
+		Metadata   models.JSONB `json:"metadata"`
+		IPAddress  string       `json:"ip_address"`
+		UserAgent  string       `json:"user_agent"`
+		TraceID    string       `json:"trace_id"`
+		PrevHash   string       `json:"prev_hash"`
+	}{
+		seq, e.ID.String(), e.Timestamp.UTC().Format(time.RFC3339Nano), userID, e.Action, e.EntityType, e.EntityID,
+		e.OldData, e.NewData, e.Metadata, e.IPAddress, e.UserAgent, e.TraceID, e.PrevHash,
+	})
+	return b
+}
+
+func normalize(j models.JSONB) models.JSONB {
+	if j == nil {
+		return nil
+	}
+	b, err := json.Marshal(j)
+	if err != nil {
+		return j
+	}
+	var out models.JSONB
+	if err := json.Unmarshal(b, &out); err != nil {
+		return j
+	}
+	return out
+}
+
+// DeriveKey turns a configured secret into the tombstone signing key.
+func DeriveKey(secret string) []byte {
+	sum := sha256.Sum256([]byte("roadmap audit tombstone key\x00" + secret))
+	return sum[:]
+}
+
+// NewTombstone records the deletion of a chained row. The signature covers the row's chain position and
+// hash plus who deleted it and when, so a tombstone can neither be forged nor moved.
+func NewTombstone(e *models.AuditLog, deletedBy *uuid.UUID, deletedAt time.Time, key []byte) models.AuditTombstone {
+	t := models.AuditTombstone{
+		Seq:        *e.Seq,
+		AuditLogID: e.ID,
+		Hash:       e.Hash,
+		PrevHash:   e.PrevHash,
+		DeletedBy:  deletedBy,
+		DeletedAt:  deletedAt.UTC().Truncate(time.Microsecond),
+	}
+	t.Signature = Sign(&t, key)
+	return t
+}
+
+// Sign returns the hex HMAC-SHA256 of the tombstone's fields.
+func Sign(t *models.AuditTombstone, key []byte) string {
+	var by string
+	if t.DeletedBy != nil {
+		by = t.DeletedBy.String()
+	}
+	mac := hmac.New(sha256.New, key)
+	for _, f := range []string{strconv.FormatInt(t.Seq, 10), t.AuditLogID.String(), t.Hash, t.PrevHash, by, t.DeletedAt.UTC().Format(time.RFC3339Nano)} {
+		mac.Write([]byte(f))
+		mac.Write([]byte{0})
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Source reads the chain in seq order. Implemented by repositories.AuditRepository.
+type Source interface {
+	ChainPage(ctx context.Context, afterSeq int64, limit int) ([]models.AuditLog, error)
+	TombstonePage(ctx context.Context, afterSeq int64, limit int) ([]models.AuditTombstone, error)
+	ChainHead(ctx context.Context) (*models.AuditChainHead, error)
+	CountUnchained(ctx context.Context) (int64, error)
+}
+
+// Problem kinds reported by Verify.
+const (
+	ProblemGap          = "gap"           // a seq has neither a row nor a tombstone
+	ProblemModified     = "modified"      // a row's content no longer matches its hash
+	ProblemBrokenLink   = "broken_link"   // prev_hash does not match the previous entry's hash
+	ProblemBadSignature = "bad_signature" // a tombstone's signature is invalid
+	ProblemDuplicate    = "duplicate"     // a seq has both a row and a tombstone
+	ProblemHead         = "head_mismatch" // the chain does not end where the recorded head says
+)
+
+type Problem struct {
+	Seq    int64  `json:"seq"`
+	ID     string `json:"id,omitempty"`
+	Kind   string `json:"kind"`
+	Detail string `json:"detail"`
+}
+
+type Result struct {
+	Valid      bool      `json:"valid"`
+	Checked    int64     `json:"checked"`    // rows verified
+	Tombstones int64     `json:"tombstones"` // deleted rows covered by a tombstone
+	Unchained  int64     `json:"unchained"`  // rows written before chaining that have not been sealed yet
+	HeadSeq    int64     `json:"head_seq"`
+	HeadHash   string    `json:"head_hash"`
+	Problems   []Problem `json:"problems"`
+	Truncated  bool      `json:"truncated,omitempty"` // more problems than MaxProblems
+}
+
+// MaxProblems caps the problems Verify reports; a single tampered row usually produces two.
+const MaxProblems = 100
+
+const pageSize = 1000
+
+// Verify walks the whole chain and reports gaps, modified rows, broken links, bad tombstones and a head
+// that does not match the last entry.
+func Verify(ctx context.Context, src Source, key []byte) (*Result, error) {
+	res := &Result{Problems: []Problem{}}
+	add := func(p Problem) {
+		if len(res.Problems) >= MaxProblems {
+			res.Truncated = true
+			return
+		}
+		res.Problems = append(res.Problems, p)
+	}
+	unchained, err := src.CountUnchained(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res.Unchained = unchained
+
+	rows := &pager[models.AuditLog]{fetch: src.ChainPage, seq: func(e *models.AuditLog) int64 { return *e.Seq }}
+	tombs := &pager[models.AuditTombstone]{fetch: src.TombstonePage, seq: func(t *models.AuditTombstone) int64 { return t.Seq }}
+	expected, prevHash := int64(1), GenesisHash
+	for {
+		row, err := rows.peek(ctx)
+		if err != nil {
+			return nil, err
+		}
+		tomb, err := tombs.peek(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if row == nil && tomb == nil {
+			break
+		}
+		next := int64(-1)
+		if row != nil {
+			next = *row.Seq
+		}
+		if tomb != nil && (next < 0 || tomb.Seq < next) {
+			next = tomb.Seq
+		}
+		if next > expected {
+			add(Problem{Seq: expected, Kind: ProblemGap, Detail: fmt.Sprintf("seq %d to %d missing", expected, next-1)})
+			prevHash = "" // unknown; the link check of the next entry is skipped
+		}
+		var id, hash, prev string
+		switch {
+		case row != nil && *row.Seq == next:
+			rows.pop()
+			id, hash, prev = row.ID.String(), row.Hash, row.PrevHash
+			res.Checked++
+			if Hash(row) != row.Hash {
+				add(Problem{Seq: next, ID: id, Kind: ProblemModified, Detail: "content does not match stored hash"})
+			}
+			if tomb != nil && tomb.Seq == next {
+				tombs.pop()
+				add(Problem{Seq: next, ID: id, Kind: ProblemDuplicate, Detail: "row exists but a tombstone claims it was deleted"})
+			}
+		default:
+			tombs.pop()
+			id, hash, prev = tomb.AuditLogID.String(), tomb.Hash, tomb.PrevHash
+			res.Tombstones++
+			if !hmac.Equal([]byte(Sign(tomb, key)), []byte(tomb.Signature)) {
+				add(Problem{Seq: next, ID: id, Kind: ProblemBadSignature, Detail: "tombstone signature invalid"})
+			}
+		}
+		if prevHash != "" && prev != prevHash {
+			add(Problem{Seq: next, ID: id, Kind: ProblemBrokenLink, Detail: "prev_hash does not match the previous entry"})
+		}
+		prevHash = hash
+		res.HeadSeq, res.HeadHash = next, hash
+		expected = next + 1
+	}
+
+	head, err := src.ChainHead(ctx)
+	if err != nil {
+		return nil, err
+	}
+	headSeq, headHash := int64(0), GenesisHash
+	if head != nil {
+		headSeq, headHash = head.Seq, head.Hash
+	}
+	if res.HeadSeq != headSeq || (res.HeadSeq > 0 && res.HeadHash != headHash) {
+		add(Problem{Seq: headSeq, Kind: ProblemHead, Detail: fmt.Sprintf("chain ends at seq %d but head is seq %d", res.HeadSeq, headSeq)})
+	}
+	res.Valid = len(res.Problems) == 0
+	return res, nil
+}
+
+// pager buffers one page at a time from a seq-ordered source.
+type pager[T any] struct {
+	fetch func(ctx context.Context, afterSeq int64, limit int) ([]T, error)
+	seq   func(*T) int64
+	buf   []T
+	last  int64
+	done  bool
+}
+
+func (p *pager[T]) peek(ctx context.Context) (*T, error) {
+	if len(p.buf) == 0 && !p.done {
+		page, err := p.fetch(ctx, p.last, pageSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(page) < pageSize {
+			p.done = true
+		}
+		p.buf = page
+		if len(page) > 0 {
+			p.last = p.seq(&page[len(page)-1])
+		}
+	}
+	if len(p.buf) == 0 {
+		return nil, nil
+	}
+	return &p.buf[0], nil
+}
+
+func (p *pager[T]) pop() { p.buf = p.buf[1:] }
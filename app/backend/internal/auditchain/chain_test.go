@@ -0,0 +1,314 @@
+package auditchain
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/models"
+)
+
+var testKey = DeriveKey("test secret")
+
+// memSource is an in-memory chain, kept in seq order like the audit repository returns it.
+type memSource struct {
+	rows      []models.AuditLog
+	tombs     []models.AuditTombstone
+	head      *models.AuditChainHead
+	unchained int64
+	fetches   int
+}
+
+func (m *memSource) ChainPage(_ context.Context, afterSeq int64, limit int) ([]models.AuditLog, error) {
+	m.fetches++
+	return page(m.rows, afterSeq, limit, func(e *models.AuditLog) int64 { return *e.Seq }), nil
+}
+
+func (m *memSource) TombstonePage(_ context.Context, afterSeq int64, limit int) ([]models.AuditTombstone, error) {
+	m.fetches++
+	return page(m.tombs, afterSeq, limit, func(t *models.AuditTombstone) int64 { return t.Seq }), nil
+}
+
+func (m *memSource) ChainHead(context.Context) (*models.AuditChainHead, error) { return m.head, nil }
+
+func (m *memSource) CountUnchained(context.Context) (int64, error) { return m.unchained, nil }
+
+func page[T any](all []T, afterSeq int64, limit int, seq func(*T) int64) []T {
+	var out []T
+	for i := range all {
+		if seq(&all[i]) > afterSeq && len(out) < limit {
+			out = append(out, all[i])
+		}
+	}
+	return out
+}
+
+// newChain seals n rows and records the head the way the audit repository does.
+func newChain(n int) *memSource {
+	m := &memSource{}
+	prev := GenesisHash
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	for i := 1; i <= n; i++ {
+		e := models.AuditLog{
+			Timestamp:  start.Add(time.Duration(i) * time.Second),
+			Action:     "update",
+			EntityType: "product",
+			EntityID:   uuid.NewString(),
+			NewData:    models.JSONB{"name": "Atlas", "version": i},
+		}
+		Seal(&e, int64(i), prev)
+		prev = e.Hash
+		m.rows = append(m.rows, e)
+	}
+	if n > 0 {
+		m.head = &models.AuditChainHead{ID: 1, Seq: int64(n), Hash: prev}
+	}
+	return m
+}
+
+// remove deletes the row at seq, leaving a tombstone signed with key unless key is nil.
+func (m *memSource) remove(seq int64, key []byte) {
+	for i := range m.rows {
+		if *m.rows[i].Seq != seq {
+			continue
+		}
+		if key != nil {
+			m.addTombstone(NewTombstone(&m.rows[i], nil, time.Now(), key))
+		}
+		m.rows = append(m.rows[:i], m.rows[i+1:]...)
+		return
+	}
+}
+
+func (m *memSource) addTombstone(t models.AuditTombstone) {
+	m.tombs = append(m.tombs, t)
+	sort.Slice(m.tombs, func(i, j int) bool { return m.tombs[i].Seq < m.tombs[j].Seq })
+}
+
+func (m *memSource) row(seq int64) *models.AuditLog {
+	for i := range m.rows {
+		if *m.rows[i].Seq == seq {
+			return &m.rows[i]
+		}
+	}
+	return nil
+}
+
+func kinds(res *Result) []string {
+	out := []string{}
+	for _, p := range res.Problems {
+		out = append(out, p.Kind)
+	}
+	return out
+}
+
+// TestSealRoundTrip checks that a sealed row still hashes to its stored hash after a trip through the
+// database, which returns JSON numbers as float64 and timestamps with microsecond precision.
+func TestSealRoundTrip(t *testing.T) {
+	uid := uuid.New()
+	e := models.AuditLog{
+		Timestamp:  time.Date(2026, 3, 1, 9, 0, 0, 123456789, time.FixedZone("CET", 3600)),
+		UserID:     &uid,
+		Action:     "update",
+		EntityType: "milestone",
+		EntityID:   uuid.NewString(),
+		OldData:    models.JSONB{"progress": 40, "tags": []string{"q1"}},
+		NewData:    models.JSONB{"progress": 45, "owner": map[string]interface{}{"id": uid}},
+		IPAddress:  "192.0.2.7",
+	}
+	Seal(&e, 1, GenesisHash)
+	if e.Hash != Hash(&e) || len(e.Hash) != 64 {
+		t.Fatalf("sealed hash %q does not match Hash", e.Hash)
+	}
+
+	stored, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var read models.AuditLog
+	if err := json.Unmarshal(stored, &read); err != nil {
+		t.Fatal(err)
+	}
+	read.Timestamp = read.Timestamp.In(time.Local)
+	if got := Hash(&read); got != e.Hash {
+		t.Errorf("row read back hashes to %s, sealed as %s", got, e.Hash)
+	}
+
+	read.NewData["progress"] = 46.0
+	if Hash(&read) == e.Hash {
+		t.Error("changed content hashes to the sealed hash")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		tamper     func(m *memSource)
+		want       []string
+		tombstones int64
+	}{
+		{name: "intact", tamper: func(*memSource) {}, want: []string{}},
+		{
+			name:       "deleted with tombstone",
+			tamper:     func(m *memSource) { m.remove(3, testKey) },
+			want:       []string{},
+			tombstones: 1,
+		},
+		{
+			name:   "modified row",
+			tamper: func(m *memSource) { m.row(2).Action = "delete" },
+			want:   []string{ProblemModified},
+		},
+		{
+			name:   "modified payload",
+			tamper: func(m *memSource) { m.row(4).NewData["name"] = "Zeus" },
+			want:   []string{ProblemModified},
+		},
+		{
+			name:   "removed row",
+			tamper: func(m *memSource) { m.remove(3, nil) },
+			want:   []string{ProblemGap},
+		},
+		{
+			name:   "removed rows",
+			tamper: func(m *memSource) { m.remove(2, nil); m.remove(3, nil) },
+			want:   []string{ProblemGap},
+		},
+		{
+			name: "resealed row",
+			tamper: func(m *memSource) {
+				r := m.row(3)
+				r.Action = "delete"
+				Seal(r, 3, r.PrevHash)
+			},
+			want: []string{ProblemBrokenLink},
+		},
+		{
+			name: "forged tombstone",
+			tamper: func(m *memSource) {
+				r := *m.row(3)
+				m.remove(3, nil)
+				m.addTombstone(NewTombstone(&r, nil, time.Now(), DeriveKey("guessed secret")))
+			},
+			want:       []string{ProblemBadSignature},
+			tombstones: 1,
+		},
+		{
+			name: "moved tombstone",
+			tamper: func(m *memSource) {
+				m.remove(3, testKey)
+				m.tombs[0].Seq = 2
+				m.remove(2, nil)
+			},
+			want:       []string{ProblemBadSignature, ProblemBrokenLink, ProblemGap},
+			tombstones: 1,
+		},
+		{
+			name:   "row and tombstone",
+			tamper: func(m *memSource) { m.addTombstone(NewTombstone(m.row(3), nil, time.Now(), testKey)) },
+			want:   []string{ProblemDuplicate},
+		},
+		{
+			name:   "head ahead of chain",
+			tamper: func(m *memSource) { m.remove(5, nil) },
+			want:   []string{ProblemHead},
+		},
+		{
+			name:   "head hash",
+			tamper: func(m *memSource) { m.head.Hash = GenesisHash },
+			want:   []string{ProblemHead},
+		},
+		{
+			name:   "head missing",
+			tamper: func(m *memSource) { m.head = nil },
+			want:   []string{ProblemHead},
+		},
+	} {
+		m := newChain(5)
+		tc.tamper(m)
+		res, err := Verify(context.Background(), m, testKey)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if got := kinds(res); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: problems %v, want %v", tc.name, got, tc.want)
+		}
+		if res.Valid != (len(tc.want) == 0) {
+			t.Errorf("%s: valid = %v with problems %v", tc.name, res.Valid, res.Problems)
+		}
+		if res.Tombstones != tc.tombstones {
+			t.Errorf("%s: %d tombstones, want %d", tc.name, res.Tombstones, tc.tombstones)
+		}
+	}
+}
+
+func TestVerifyEmpty(t *testing.T) {
+	m := newChain(0)
+	m.unchained = 3
+	res, err := Verify(context.Background(), m, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Valid || res.Checked != 0 || res.Unchained != 3 || res.HeadSeq != 0 {
+		t.Errorf("empty chain: %+v", res)
+	}
+}
+
+// TestVerifyPages walks a chain longer than one page with tombstones on both sides of a page boundary.
+func TestVerifyPages(t *testing.T) {
+	n := 2*pageSize + 5
+	m := newChain(n)
+	for _, seq := range []int64{1, pageSize, pageSize + 1, int64(n)} {
+		m.remove(seq, testKey)
+	}
+	res, err := Verify(context.Background(), m, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Valid {
+		t.Fatalf("problems %v", res.Problems)
+	}
+	if res.Checked != int64(n-4) || res.Tombstones != 4 || res.HeadSeq != int64(n) || res.HeadHash != m.head.Hash {
+		t.Errorf("checked %d, tombstones %d, head %d %s", res.Checked, res.Tombstones, res.HeadSeq, res.HeadHash)
+	}
+	if m.fetches < 4 {
+		t.Errorf("%d page fetches for %d rows", m.fetches, n)
+	}
+
+	m.row(pageSize + 2).EntityID = "tampered"
+	m.remove(2*pageSize, nil)
+	res, err = Verify(context.Background(), m, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Problem{
+		{Seq: pageSize + 2, Kind: ProblemModified},
+		{Seq: 2 * pageSize, Kind: ProblemGap},
+	}
+	if len(res.Problems) != len(want) {
+		t.Fatalf("problems %v, want %v", res.Problems, want)
+	}
+	for i, p := range res.Problems {
+		if p.Seq != want[i].Seq || p.Kind != want[i].Kind {
+			t.Errorf("problem %d is %s at seq %d, want %s at seq %d", i, p.Kind, p.Seq, want[i].Kind, want[i].Seq)
+		}
+	}
+}
+
+func TestMaxProblems(t *testing.T) {
+	m := newChain(MaxProblems + 10)
+	for i := range m.rows {
+		m.rows[i].Action = "forged"
+	}
+	res, err := Verify(context.Background(), m, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Problems) != MaxProblems || !res.Truncated || res.Checked != int64(len(m.rows)) {
+		t.Errorf("%d problems, truncated %v, checked %d", len(res.Problems), res.Truncated, res.Checked)
+	}
+}
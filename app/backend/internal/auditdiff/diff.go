@@ -0,0 +1,110 @@
+// Package auditdiff computes field-level changes between the old and new data of an audit record.
+//
+// Data is compared as it is stored in the jsonb columns (maps, slices, strings, float64, bool, nil).
+// Nested objects are walked key by key and arrays index by index, so a change deep inside a JSONB
+// field is reported at its own path (e.g. "metadata.owner.name" or "tags[2]"). A missing key and a
+// key set to null are treated the same, since response payloads omit empty fields.
+package auditdiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Change operations.
+const (
+	OpAdded    = "added"
+	OpRemoved  = "removed"
+	OpModified = "modified"
+)
+
+// Change is one field that differs between old and new data.
+type Change struct {
+	Path string      `json:"path"`
+	Op   string      `json:"op"`
+	Old  interface{} `json:"old"`
+	New  interface{} `json:"new"`
+}
+
+// Diff returns the changes from old to new, ordered by path. A nil old (create) reports every field as
+// added and a nil new (delete) every field as removed. Both sides are first normalized the way a jsonb
+// column returns them, so 3 and 3.0 or a struct and the map it encodes to are not reported as changes.
+func Diff(old, new map[string]interface{}) []Change {
+	var out []Change
+	walkMap("", normalize(old), normalize(new), &out)
+	return out
+}
+
+func normalize(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return m
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return m
+	}
+	return out
+}
+
+func walk(path string, a, b interface{}, out *[]Change) {
+	switch {
+	case a == nil && b == nil:
+		return
+	case a == nil:
+		*out = append(*out, Change{Path: path, Op: OpAdded, New: b})
+		return
+	case b == nil:
+		*out = append(*out, Change{Path: path, Op: OpRemoved, Old: a})
+		return
+	}
+	if am, ok := a.(map[string]interface{}); ok {
+		if bm, ok := b.(map[string]interface{}); ok {
+			walkMap(path, am, bm, out)
+			return
+		}
+	}
+	if as, ok := a.([]interface{}); ok {
+		if bs, ok := b.([]interface{}); ok {
+			for i := 0; i < max(len(as), len(bs)); i++ {
+				var av, bv interface{}
+				if i < len(as) {
+					av = as[i]
+				}
+				if i < len(bs) {
+					bv = bs[i]
+				}
+				walk(fmt.Sprintf("%s[%d]", path, i), av, bv, out)
+			}
+			return
+		}
+	}
+	if !reflect.DeepEqual(a, b) {
+		*out = append(*out, Change{Path: path, Op: OpModified, Old: a, New: b})
+	}
+}
+
+func walkMap(path string, a, b map[string]interface{}, out *[]Change) {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		p := k
+		if path != "" {
+			p = path + "." + k
+		}
+		walk(p, a[k], b[k], out)
+	}
+}
@@ -0,0 +1,100 @@
+package auditdiff
+
+import (
+	"reflect"
+	"testing"
+)
+
+type obj = map[string]interface{}
+type list = []interface{}
+
+func TestDiff(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		old, new obj
+		want     []Change
+	}{
+		{name: "both nil"},
+		{name: "equal", old: obj{"name": "Atlas", "tags": list{"a"}}, new: obj{"name": "Atlas", "tags": list{"a"}}},
+		{
+			name: "create",
+			new:  obj{"name": "Atlas", "owner": obj{"id": "u1"}},
+			want: []Change{
+				{Path: "name", Op: OpAdded, New: "Atlas"},
+				{Path: "owner", Op: OpAdded, New: obj{"id": "u1"}},
+			},
+		},
+		{
+			name: "delete",
+			old:  obj{"name": "Atlas", "progress": 40.0},
+			want: []Change{
+				{Path: "name", Op: OpRemoved, Old: "Atlas"},
+				{Path: "progress", Op: OpRemoved, Old: 40.0},
+			},
+		},
+		{
+			name: "added and removed keys",
+			old:  obj{"name": "Atlas", "description": "old", "status": "draft"},
+			new:  obj{"name": "Atlas", "status": "draft", "url": "https://example.com"},
+			want: []Change{
+				{Path: "description", Op: OpRemoved, Old: "old"},
+				{Path: "url", Op: OpAdded, New: "https://example.com"},
+			},
+		},
+		{
+			name: "null is missing",
+			old:  obj{"manager_id": nil, "team_id": "t1"},
+			new:  obj{"team_id": nil},
+			want: []Change{{Path: "team_id", Op: OpRemoved, Old: "t1"}},
+		},
+		{
+			name: "nested maps",
+			old:  obj{"metadata": obj{"owner": obj{"name": "Ann", "id": "u1"}, "source": "ui"}},
+			new:  obj{"metadata": obj{"owner": obj{"name": "Bob", "id": "u1"}, "source": "ui", "reason": "handover"}},
+			want: []Change{
+				{Path: "metadata.owner.name", Op: OpModified, Old: "Ann", New: "Bob"},
+				{Path: "metadata.reason", Op: OpAdded, New: "handover"},
+			},
+		},
+		{
+			name: "arrays",
+			old:  obj{"tags": list{"a", "b", "c"}, "steps": list{obj{"done": false}}},
+			new:  obj{"tags": list{"a", "x"}, "steps": list{obj{"done": true}}},
+			want: []Change{
+				{Path: "steps[0].done", Op: OpModified, Old: false, New: true},
+				{Path: "tags[1]", Op: OpModified, Old: "b", New: "x"},
+				{Path: "tags[2]", Op: OpRemoved, Old: "c"},
+			},
+		},
+		{
+			name: "type change",
+			old:  obj{"owner": "u1", "target": obj{"q": 1}},
+			new:  obj{"owner": obj{"id": "u1"}, "target": "Q1"},
+			want: []Change{
+				{Path: "owner", Op: OpModified, Old: "u1", New: obj{"id": "u1"}},
+				{Path: "target", Op: OpModified, Old: obj{"q": 1.0}, New: "Q1"},
+			},
+		},
+		{
+			name: "numbers differing only in representation",
+			old:  obj{"progress": 40, "budget": int64(1500), "weight": float32(0.5), "nested": obj{"n": uint8(3)}, "ids": []int{1, 2}},
+			new:  obj{"progress": 40.0, "budget": 1500.0, "weight": 0.5, "nested": obj{"n": 3.0}, "ids": list{1.0, 2.0}},
+		},
+		{
+			name: "numbers that differ",
+			old:  obj{"progress": 40},
+			new:  obj{"progress": 40.5},
+			want: []Change{{Path: "progress", Op: OpModified, Old: 40.0, New: 40.5}},
+		},
+		{
+			name: "typed values",
+			old:  obj{"tags": []string{"a"}, "owner": map[string]string{"id": "u1"}},
+			new:  obj{"tags": list{"a"}, "owner": obj{"id": "u2"}},
+			want: []Change{{Path: "owner.id", Op: OpModified, Old: "u1", New: "u2"}},
+		},
+	} {
+		if got := Diff(tc.old, tc.new); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %+v, want %+v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -10,69 +10,116 @@ import (
 
 var ErrInvalidToken = errors.New("invalid token")
 
+// Token types, carried in the typ claim so a refresh token is never accepted as an access token or the
+// other way round.
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
 type Claims struct {
-	UserID string `json:"user_id"`
-	Email  string `json:"email"`
-	Role   string `json:"role"`
+	UserID    string `json:"user_id"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid,omitempty"` // server-side session (login) the token belongs to
+	Type      string `json:"typ"`           // TokenTypeAccess | TokenTypeRefresh
 	jwt.RegisteredClaims
 }
 
+// JWTService issues and verifies tokens signed with the keyring's asymmetric keys (RS256 or EdDSA).
+// Tokens carry the signing key's kid so other services can verify them from /.well-known/jwks.json.
 type JWTService struct {
-	secret           []byte
-	accessExpiry     time.Duration
-	refreshExpiry    time.Duration
+	keys          *Keyring
+	issuer        string
+	accessExpiry  time.Duration
+	refreshExpiry time.Duration
 }
 
-func NewJWTService(secret string, accessMin, refreshMin int) *JWTService {
+func NewJWTService(keys *Keyring, issuer string, accessMin, refreshMin int) *JWTService {
 	return &JWTService{
-		secret:        []byte(secret),
+		keys:          keys,
+		issuer:        issuer,
 		accessExpiry:  time.Duration(accessMin) * time.Minute,
 		refreshExpiry: time.Duration(refreshMin) * time.Minute,
 	}
 }
 
-func (s *JWTService) GenerateAccessToken(userID uuid.UUID, email, role string) (string, int, error) {
+// GenerateAccessToken returns the signed token, its jti and its lifetime in seconds.
+func (s *JWTService) GenerateAccessToken(userID uuid.UUID, email, role string, sessionID uuid.UUID) (string, string, int, error) {
 	exp := time.Now().Add(s.accessExpiry)
+	jti := uuid.New().String()
 	claims := &Claims{
-		UserID: userID.String(),
-		Email:  email,
-		Role:   role,
+		UserID:    userID.String(),
+		Email:     email,
+		Role:      role,
+		SessionID: sessionID.String(),
+		Type:      TokenTypeAccess,
 		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.issuer,
 			ExpiresAt: jwt.NewNumericDate(exp),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			ID:        uuid.New().String(),
+			ID:        jti,
 		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	str, err := token.SignedString(s.secret)
+	str, err := s.sign(claims)
 	if err != nil {
-		return "", 0, err
+		return "", "", 0, err
 	}
-	return str, int(s.accessExpiry.Seconds()), nil
+	return str, jti, int(s.accessExpiry.Seconds()), nil
 }
 
-func (s *JWTService) GenerateRefreshToken(userID uuid.UUID) (string, error) {
+// GenerateRefreshToken returns the signed token and its jti.
+func (s *JWTService) GenerateRefreshToken(userID uuid.UUID, sessionID uuid.UUID) (string, string, error) {
+	jti := uuid.New().String()
 	claims := &Claims{
-		UserID: userID.String(),
+		UserID:    userID.String(),
+		SessionID: sessionID.String(),
+		Type:      TokenTypeRefresh,
 		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.issuer,
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.refreshExpiry)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			ID:        uuid.New().String(),
+			ID:        jti,
 		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(s.secret)
+	str, err := s.sign(claims)
+	if err != nil {
+		return "", "", err
+	}
+	return str, jti, nil
+}
+
+// RefreshExpiry is how long a refresh token, and so an idle session, stays valid.
+func (s *JWTService) RefreshExpiry() time.Duration {
+	return s.refreshExpiry
+}
+
+func (s *JWTService) sign(claims *Claims) (string, error) {
+	key, err := s.keys.current()
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.id
+	return token.SignedString(key.private)
 }
 
-func (s *JWTService) ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(t *jwt.Token) (interface{}, error) {
-		return s.secret, nil
-	})
+// ValidateToken accepts only RS256/EdDSA tokens of tokenType whose kid names a known, unexpired key of that
+// algorithm. Tokens issued before the typ claim are rejected, so those clients log in again once.
+func (s *JWTService) ValidateToken(tokenString, tokenType string) (*Claims, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{AlgRS256, AlgEdDSA}),
+		jwt.WithExpirationRequired(),
+	}
+	if s.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(s.issuer))
+	}
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, s.keys.keyFunc, opts...)
 	if err != nil {
 		return nil, ErrInvalidToken
 	}
 	claims, ok := token.Claims.(*Claims)
-	if !ok || !token.Valid {
+	if !ok || !token.Valid || claims.Type != tokenType {
 		return nil, ErrInvalidToken
 	}
 	return claims, nil
@@ -0,0 +1,211 @@
+package auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/models"
+)
+
+const testIssuer = "roadmap-test"
+
+func newTestJWT(t *testing.T, alg string) (*JWTService, *Keyring, *memKeys) {
+	t.Helper()
+	repo := &memKeys{}
+	k, _ := newTestKeyring(t, repo, &serialTx{}, alg)
+	if err := k.Load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return NewJWTService(k, testIssuer, 15, 60), k, repo
+}
+
+// forge signs claims with an arbitrary method, key and kid.
+func forge(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, claims jwt.Claims) string {
+	t.Helper()
+	tok := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		tok.Header["kid"] = kid
+	}
+	s, err := tok.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func accessClaims(mod func(*Claims)) *Claims {
+	c := &Claims{
+		UserID: uuid.NewString(),
+		Role:   "admin",
+		Type:   TokenTypeAccess,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    testIssuer,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+	if mod != nil {
+		mod(c)
+	}
+	return c
+}
+
+func TestValidateToken(t *testing.T) {
+	s, k, _ := newTestJWT(t, AlgRS256)
+	key, err := k.current()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(key.public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	uid, sid := uuid.New(), uuid.New()
+	access, _, _, err := s.GenerateAccessToken(uid, "ann@example.com", "admin", sid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refresh, _, err := s.GenerateRefreshToken(uid, sid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name      string
+		token     string
+		tokenType string
+		ok        bool
+	}{
+		{"access token", access, TokenTypeAccess, true},
+		{"refresh token", refresh, TokenTypeRefresh, true},
+		{"refresh token as access token", refresh, TokenTypeAccess, false},
+		{"access token as refresh token", access, TokenTypeRefresh, false},
+		{"forged with the signing key", forge(t, key.method, key.private, key.id, accessClaims(nil)), TokenTypeAccess, true},
+		{"no typ", forge(t, key.method, key.private, key.id, accessClaims(func(c *Claims) { c.Type = "" })), TokenTypeAccess, false},
+		{"HS256 with the public key", forge(t, jwt.SigningMethodHS256, pubDER, key.id, accessClaims(nil)), TokenTypeAccess, false},
+		{"HS256 with the public key PEM", forge(t, jwt.SigningMethodHS256, pubPEM, key.id, accessClaims(nil)), TokenTypeAccess, false},
+		{"alg none", forge(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, key.id, accessClaims(nil)), TokenTypeAccess, false},
+		{"kid of an RS256 key with EdDSA", forge(t, jwt.SigningMethodEdDSA, edKey, key.id, accessClaims(nil)), TokenTypeAccess, false},
+		{"unknown kid", forge(t, key.method, key.private, "unknown", accessClaims(nil)), TokenTypeAccess, false},
+		{"no kid", forge(t, key.method, key.private, "", accessClaims(nil)), TokenTypeAccess, false},
+		{"no exp", forge(t, key.method, key.private, key.id, accessClaims(func(c *Claims) { c.ExpiresAt = nil })), TokenTypeAccess, false},
+		{"expired", forge(t, key.method, key.private, key.id, accessClaims(func(c *Claims) {
+			c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+		})), TokenTypeAccess, false},
+		{"no iss", forge(t, key.method, key.private, key.id, accessClaims(func(c *Claims) { c.Issuer = "" })), TokenTypeAccess, false},
+		{"other iss", forge(t, key.method, key.private, key.id, accessClaims(func(c *Claims) { c.Issuer = "elsewhere" })), TokenTypeAccess, false},
+		{"garbage", "not.a.token", TokenTypeAccess, false},
+	} {
+		claims, err := s.ValidateToken(tc.token, tc.tokenType)
+		if tc.ok && (err != nil || claims.Type != tc.tokenType) {
+			t.Errorf("%s: got %v, want valid", tc.name, err)
+		}
+		if !tc.ok && err != ErrInvalidToken {
+			t.Errorf("%s: got %v, want ErrInvalidToken", tc.name, err)
+		}
+	}
+
+	claims, err := s.ValidateToken(access, TokenTypeAccess)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.UserID != uid.String() || claims.SessionID != sid.String() || claims.Role != "admin" || claims.ID == "" {
+		t.Errorf("claims %+v", claims)
+	}
+}
+
+// TestValidateTokenUnknownKid checks that a kid signed by another instance right after it rotated is found by
+// a reload, and that unknown kids reload at most once per unknownKidReloadInterval.
+func TestValidateTokenUnknownKid(t *testing.T) {
+	s, k, repo := newTestJWT(t, AlgEdDSA)
+	other, _ := newTestKeyring(t, repo, &serialTx{}, AlgEdDSA)
+	if err := other.create(context.Background(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := other.reload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	token, _, _, err := NewJWTService(other, testIssuer, 15, 60).GenerateAccessToken(uuid.New(), "", "user", uuid.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lists := repo.listCount()
+	if _, err := s.ValidateToken(token, TokenTypeAccess); err != ErrInvalidToken {
+		t.Errorf("new kid right after a reload: got %v, want ErrInvalidToken", err)
+	}
+	if repo.listCount() != lists {
+		t.Error("reloaded within unknownKidReloadInterval")
+	}
+
+	k.mu.Lock()
+	k.lastReload = time.Now().Add(-unknownKidReloadInterval)
+	k.mu.Unlock()
+	if _, err := s.ValidateToken(token, TokenTypeAccess); err != nil {
+		t.Errorf("new kid after the interval: %v", err)
+	}
+	if _, err := s.ValidateToken(forge(t, jwt.SigningMethodEdDSA, ed25519.NewKeyFromSeed(make([]byte, 32)), "unknown", accessClaims(nil)), TokenTypeAccess); err != ErrInvalidToken {
+		t.Errorf("unknown kid: got %v", err)
+	}
+	if repo.listCount() != lists+1 {
+		t.Errorf("%d reloads, want one", repo.listCount()-lists)
+	}
+}
+
+// TestValidateTokenAfterRotation checks that tokens signed by a retired key verify until the key expires.
+func TestValidateTokenAfterRotation(t *testing.T) {
+	ctx := context.Background()
+	s, k, repo := newTestJWT(t, AlgEdDSA)
+	old, _, _, err := s.GenerateAccessToken(uuid.New(), "", "user", uuid.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := repo.rows[0].ID
+
+	// Retire the first key now; the rotation creates its successor.
+	now := time.Now()
+	repo.update(first, func(r *models.JWTSigningKey) {
+		r.ActivatesAt = now.Add(-testRotateEvery)
+		r.RetiresAt = now
+		r.ExpiresAt = now.Add(time.Hour)
+	})
+	if err := k.reload(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.rotateIfDue(ctx, now); err != nil {
+		t.Fatal(err)
+	}
+	current, err := k.current()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.id == first {
+		t.Fatal("the retired key still signs")
+	}
+	fresh, _, _, err := s.GenerateAccessToken(uuid.New(), "", "user", uuid.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, token := range map[string]string{"retired key": old, "successor": fresh} {
+		if _, err := s.ValidateToken(token, TokenTypeAccess); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	repo.update(first, func(r *models.JWTSigningKey) { r.ExpiresAt = now.Add(-time.Second) })
+	if err := k.reload(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ValidateToken(old, TokenTypeAccess); err != ErrInvalidToken {
+		t.Errorf("expired key: got %v, want ErrInvalidToken", err)
+	}
+}
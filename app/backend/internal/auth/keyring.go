@@ -0,0 +1,485 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rm/roadmap/backend/internal/models"
+	"github.com/rm/roadmap/backend/internal/repositories"
+	"go.uber.org/zap"
+)
+
+// Supported signing algorithms.
+const (
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+var (
+	ErrNoSigningKey         = errors.New("no active JWT signing key")
+	ErrUnsupportedAlgorithm = errors.New("unsupported JWT algorithm")
+)
+
+const (
+	rsaKeyBits = 2048
+	// keyringTick is how often the keyring reloads from the database and rotates when due.
+	keyringTick = time.Minute
+	// unknownKidReloadInterval rate-limits reloads triggered by tokens with a kid we have not seen yet
+	// (signed by another instance right after it rotated).
+	unknownKidReloadInterval = 10 * time.Second
+)
+
+type KeyringConfig struct {
+	Algorithm   string        // RS256 | EdDSA; used for newly generated keys
+	RotateEvery time.Duration // how long a key signs before its successor takes over
+	VerifyGrace time.Duration // how long a retired key still verifies; at least the longest token lifetime
+	Prepublish  time.Duration // a successor is in the JWKS this long before it signs, so verifiers can fetch it
+	Secret      string        // encrypts private keys at rest (JWT_SECRET)
+}
+
+// TxLocker serializes rotation across instances for the transaction carried by ctx
+// (repositories.AdvisoryLock).
+type TxLocker interface {
+	Lock(ctx context.Context) error
+}
+
+type signingKey struct {
+	id          string
+	alg         string
+	method      jwt.SigningMethod
+	private     crypto.Signer
+	public      crypto.PublicKey
+	activatesAt time.Time
+	retiresAt   time.Time
+	expiresAt   time.Time
+}
+
+// Keyring holds the asymmetric JWT keys shared by all server instances through the jwt_signing_keys table.
+// Exactly one key signs at a time; every unexpired key verifies and is published as a JWKS.
+type Keyring struct {
+	repo repositories.JWTKeyRepository
+	tx   repositories.Transactor
+	lock TxLocker // serializes rotation across instances
+	cfg  KeyringConfig
+	aead cipher.AEAD
+	log  *zap.Logger
+
+	mu         sync.RWMutex
+	keys       map[string]*signingKey
+	lastReload time.Time
+}
+
+func NewKeyring(repo repositories.JWTKeyRepository, tx repositories.Transactor, lock TxLocker, cfg KeyringConfig, log *zap.Logger) (*Keyring, error) {
+	if _, err := methodFor(cfg.Algorithm); err != nil {
+		return nil, err
+	}
+	if cfg.Secret == "" {
+		return nil, errors.New("JWT_SECRET is required to encrypt signing keys")
+	}
+	if cfg.RotateEvery <= 0 {
+		return nil, errors.New("JWT key rotation interval must be positive")
+	}
+	if cfg.Prepublish > cfg.RotateEvery/2 {
+		cfg.Prepublish = cfg.RotateEvery / 2
+	}
+	sum := sha256.Sum256([]byte(cfg.Secret))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if log == nil {
+		log = zap.NewNop()
+	}
+	return &Keyring{repo: repo, tx: tx, lock: lock, cfg: cfg, aead: aead, log: log, keys: make(map[string]*signingKey)}, nil
+}
+
+// Load reads the keys from the database and creates a signing key if none is active. Call once at startup.
+func (k *Keyring) Load(ctx context.Context) error {
+	if err := k.reload(ctx); err != nil {
+		return err
+	}
+	return k.rotateIfDue(ctx, time.Now())
+}
+
+// Run reloads the keyring and rotates keys on schedule until ctx is cancelled.
+func (k *Keyring) Run(ctx context.Context) {
+	t := time.NewTicker(keyringTick)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			now := time.Now()
+			if err := k.reload(ctx); err != nil {
+				k.log.Warn("jwt keyring reload failed", zap.Error(err))
+				continue
+			}
+			if err := k.rotateIfDue(ctx, now); err != nil {
+				k.log.Error("jwt key rotation failed", zap.Error(err))
+			}
+			if n, err := k.repo.DeleteExpired(ctx, now); err != nil {
+				k.log.Warn("jwt expired key cleanup failed", zap.Error(err))
+			} else if n > 0 {
+				k.log.Info("jwt expired keys removed", zap.Int64("count", n))
+			}
+		}
+	}
+}
+
+func (k *Keyring) reload(ctx context.Context) error {
+	now := time.Now()
+	rows, err := k.repo.ListValid(ctx, now)
+	if err != nil {
+		return err
+	}
+	keys := make(map[string]*signingKey, len(rows))
+	for i := range rows {
+		sk, err := k.decode(&rows[i])
+		if err != nil {
+			k.log.Error("jwt signing key unusable", zap.String("kid", rows[i].ID), zap.Error(err))
+			continue
+		}
+		if sk.private == nil {
+			// Usually JWT_SECRET differs between instances; the key still verifies but this instance won't sign with it.
+			k.log.Warn("jwt signing key cannot be decrypted; using it for verification only", zap.String("kid", sk.id))
+		}
+		keys[sk.id] = sk
+	}
+	k.mu.Lock()
+	k.keys = keys
+	k.lastReload = now
+	k.mu.Unlock()
+	return nil
+}
+
+// rotateIfDue creates a key when none is signing, when the signing key retires within Prepublish and has
+// no successor yet, or when JWT_ALGORITHM changed. Successors are published before they start signing.
+// Instances that find a rotation due at the same time take turns under the advisory lock, and each re-reads
+// the keys once it holds the lock, so only the first one creates a key.
+func (k *Keyring) rotateIfDue(ctx context.Context, now time.Time) error {
+	if _, due := k.due(now); !due {
+		return nil
+	}
+	created := false
+	err := k.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := k.lock.Lock(ctx); err != nil {
+			return err
+		}
+		if err := k.reload(ctx); err != nil {
+			return err
+		}
+		activatesAt, due := k.due(now)
+		if !due {
+			return nil
+		}
+		created = true
+		return k.create(ctx, activatesAt)
+	})
+	if err != nil || !created {
+		return err
+	}
+	// Reload only after commit so this instance never signs with a key the others cannot see.
+	return k.reload(ctx)
+}
+
+// due reports whether a key must be created and when it should start signing.
+func (k *Keyring) due(now time.Time) (time.Time, bool) {
+	k.mu.RLock()
+	current := k.signingAt(now)
+	var successor *signingKey
+	if current != nil {
+		for _, sk := range k.keys {
+			if sk.activatesAt.After(current.activatesAt) && sk.alg == k.cfg.Algorithm {
+				successor = sk
+			}
+		}
+	}
+	k.mu.RUnlock()
+
+	switch {
+	case current == nil:
+		return now, true
+	case successor != nil:
+		return time.Time{}, false
+	case current.alg != k.cfg.Algorithm:
+		return now.Add(k.cfg.Prepublish), true
+	case current.retiresAt.Sub(now) <= k.cfg.Prepublish:
+		return current.retiresAt, true
+	}
+	return time.Time{}, false
+}
+
+func (k *Keyring) create(ctx context.Context, activatesAt time.Time) error {
+	priv, err := generateKey(k.cfg.Algorithm)
+	if err != nil {
+		return err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return err
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(priv.Public())
+	if err != nil {
+		return err
+	}
+	jwk, err := publicJWK(priv.Public(), k.cfg.Algorithm, "")
+	if err != nil {
+		return err
+	}
+	kid, err := thumbprint(jwk)
+	if err != nil {
+		return err
+	}
+	sealed, err := k.seal(der)
+	if err != nil {
+		return err
+	}
+	retiresAt := activatesAt.Add(k.cfg.RotateEvery)
+	row := &models.JWTSigningKey{
+		ID:          kid,
+		Algorithm:   k.cfg.Algorithm,
+		PrivateKey:  sealed,
+		PublicKey:   pubDER,
+		ActivatesAt: activatesAt,
+		RetiresAt:   retiresAt,
+		ExpiresAt:   retiresAt.Add(k.cfg.VerifyGrace),
+	}
+	if err := k.repo.Create(ctx, row); err != nil {
+		return err
+	}
+	k.log.Info("jwt signing key created",
+		zap.String("kid", kid), zap.String("alg", row.Algorithm), zap.Time("activates_at", activatesAt), zap.Time("retires_at", retiresAt))
+	return nil
+}
+
+func (k *Keyring) decode(row *models.JWTSigningKey) (*signingKey, error) {
+	method, err := methodFor(row.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := x509.ParsePKIXPublicKey(row.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	sk := &signingKey{
+		id:          row.ID,
+		alg:         row.Algorithm,
+		method:      method,
+		public:      pub,
+		activatesAt: row.ActivatesAt,
+		retiresAt:   row.RetiresAt,
+		expiresAt:   row.ExpiresAt,
+	}
+	der, err := k.open(row.PrivateKey)
+	if err != nil {
+		return sk, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("key %s is not a signer", row.ID)
+	}
+	sk.private = signer
+	return sk, nil
+}
+
+// signingAt returns the most recently activated key that may sign at now. Caller holds k.mu.
+func (k *Keyring) signingAt(now time.Time) *signingKey {
+	var best *signingKey
+	for _, sk := range k.keys {
+		if sk.private == nil || sk.activatesAt.After(now) || !now.Before(sk.retiresAt) {
+			continue
+		}
+		if best == nil || sk.activatesAt.After(best.activatesAt) {
+			best = sk
+		}
+	}
+	return best
+}
+
+func (k *Keyring) current() (*signingKey, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if sk := k.signingAt(time.Now()); sk != nil {
+		return sk, nil
+	}
+	return nil, ErrNoSigningKey
+}
+
+// keyFunc resolves the verification key from the token's kid and rejects any algorithm other than
+// the one that key was created for.
+func (k *Keyring) keyFunc(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	if kid == "" {
+		return nil, ErrInvalidToken
+	}
+	sk := k.lookup(kid)
+	if sk == nil {
+		k.mu.RLock()
+		stale := time.Since(k.lastReload) > unknownKidReloadInterval
+		k.mu.RUnlock()
+		if stale {
+			if err := k.reload(context.Background()); err != nil {
+				k.log.Warn("jwt keyring reload failed", zap.Error(err))
+			}
+			sk = k.lookup(kid)
+		}
+	}
+	if sk == nil || !time.Now().Before(sk.expiresAt) {
+		return nil, ErrInvalidToken
+	}
+	if t.Method == nil || t.Method.Alg() != sk.alg {
+		return nil, ErrInvalidToken
+	}
+	return sk.public, nil
+}
+
+func (k *Keyring) lookup(kid string) *signingKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.keys[kid]
+}
+
+// JWK is one public key in RFC 7517 form.
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns every key that is valid for verification, newest first, including published successors.
+func (k *Keyring) JWKS() JWKSet {
+	k.mu.RLock()
+	list := make([]*signingKey, 0, len(k.keys))
+	now := time.Now()
+	for _, sk := range k.keys {
+		if now.Before(sk.expiresAt) {
+			list = append(list, sk)
+		}
+	}
+	k.mu.RUnlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].activatesAt.After(list[j].activatesAt) })
+	set := JWKSet{Keys: make([]JWK, 0, len(list))}
+	for _, sk := range list {
+		jwk, err := publicJWK(sk.public, sk.alg, sk.id)
+		if err != nil {
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// JWKSMaxAge is how long clients may cache the JWKS; successors are published at least this early.
+func (k *Keyring) JWKSMaxAge() time.Duration {
+	return k.cfg.Prepublish / 2
+}
+
+func (k *Keyring) seal(plain []byte) ([]byte, error) {
+	nonce := make([]byte, k.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return k.aead.Seal(nonce, nonce, plain, nil), nil
+}
+
+func (k *Keyring) open(sealed []byte) ([]byte, error) {
+	n := k.aead.NonceSize()
+	if len(sealed) < n {
+		return nil, errors.New("sealed key too short")
+	}
+	return k.aead.Open(nil, sealed[:n], sealed[n:], nil)
+}
+
+func methodFor(alg string) (jwt.SigningMethod, error) {
+	switch alg {
+	case AlgRS256:
+		return jwt.SigningMethodRS256, nil
+	case AlgEdDSA:
+		return jwt.SigningMethodEdDSA, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, alg)
+}
+
+func generateKey(alg string) (crypto.Signer, error) {
+	switch alg {
+	case AlgRS256:
+		return rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case AlgEdDSA:
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		return priv, err
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, alg)
+}
+
+func publicJWK(pub crypto.PublicKey, alg, kid string) (JWK, error) {
+	b64 := base64.RawURLEncoding.EncodeToString
+	switch p := pub.(type) {
+	case *rsa.PublicKey:
+		return JWK{Kty: "RSA", Use: "sig", Alg: alg, Kid: kid, N: b64(p.N.Bytes()), E: b64(big.NewInt(int64(p.E)).Bytes())}, nil
+	case ed25519.PublicKey:
+		return JWK{Kty: "OKP", Use: "sig", Alg: alg, Kid: kid, Crv: "Ed25519", X: b64(p)}, nil
+	}
+	return JWK{}, fmt.Errorf("unsupported public key type %T", pub)
+}
+
+// thumbprint is the RFC 7638 JWK thumbprint, used as the kid.
+func thumbprint(j JWK) (string, error) {
+	var members interface{}
+	switch j.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{j.E, j.Kty, j.N}
+	case "OKP":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{j.Crv, j.Kty, j.X}
+	default:
+		return "", fmt.Errorf("unsupported key type %q", j.Kty)
+	}
+	b, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rm/roadmap/backend/internal/models"
+)
+
+// memKeys is an in-memory jwt_signing_keys table shared by the keyrings of several instances.
+type memKeys struct {
+	mu    sync.Mutex
+	rows  []models.JWTSigningKey
+	lists int
+}
+
+func (m *memKeys) ListValid(_ context.Context, now time.Time) ([]models.JWTSigningKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lists++
+	var out []models.JWTSigningKey
+	for _, r := range m.rows {
+		if now.Before(r.ExpiresAt) {
+			out = append(out, r)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ActivatesAt.Before(out[j].ActivatesAt) })
+	return out, nil
+}
+
+func (m *memKeys) Create(_ context.Context, k *models.JWTSigningKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rows = append(m.rows, *k)
+	return nil
+}
+
+func (m *memKeys) DeleteExpired(_ context.Context, before time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	kept := m.rows[:0]
+	for _, r := range m.rows {
+		if r.ExpiresAt.After(before) {
+			kept = append(kept, r)
+		}
+	}
+	n := int64(len(m.rows) - len(kept))
+	m.rows = kept
+	return n, nil
+}
+
+// listCount is how often the table was read.
+func (m *memKeys) listCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lists
+}
+
+// update changes the row with kid, as another instance or an operator would.
+func (m *memKeys) update(kid string, f func(*models.JWTSigningKey)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.rows {
+		if m.rows[i].ID == kid {
+			f(&m.rows[i])
+		}
+	}
+}
+
+// serialTx runs transactions one at a time, which is what the advisory lock guarantees in Postgres.
+type serialTx struct{ mu sync.Mutex }
+
+func (t *serialTx) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return fn(ctx)
+}
+
+type countingLock struct{ locks int }
+
+func (l *countingLock) Lock(context.Context) error {
+	l.locks++
+	return nil
+}
+
+const testRotateEvery = 24 * time.Hour
+
+func newTestKeyring(t *testing.T, repo *memKeys, tx *serialTx, alg string) (*Keyring, *countingLock) {
+	t.Helper()
+	lock := &countingLock{}
+	k, err := NewKeyring(repo, tx, lock, KeyringConfig{
+		Algorithm:   alg,
+		RotateEvery: testRotateEvery,
+		VerifyGrace: time.Hour,
+		Prepublish:  10 * time.Minute,
+		Secret:      "test secret",
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k, lock
+}
+
+func TestKeyringRotation(t *testing.T) {
+	ctx := context.Background()
+	repo, tx := &memKeys{}, &serialTx{}
+	a, lockA := newTestKeyring(t, repo, tx, AlgEdDSA)
+	b, lockB := newTestKeyring(t, repo, tx, AlgEdDSA)
+	if err := a.Load(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Load(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.rows) != 1 || lockA.locks != 1 || lockB.locks != 0 {
+		t.Fatalf("startup: %d keys, locks %d/%d; want one key created under the lock", len(repo.rows), lockA.locks, lockB.locks)
+	}
+	first := repo.rows[0]
+
+	// Both instances find the successor due at the same time; the second re-reads under the lock and
+	// finds the key the first created.
+	now := first.RetiresAt.Add(-5 * time.Minute)
+	if err := a.rotateIfDue(ctx, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.rotateIfDue(ctx, now); err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.rows) != 2 || lockB.locks != 1 {
+		t.Fatalf("rotation: %d keys, %d locks on the second instance; want one successor", len(repo.rows), lockB.locks)
+	}
+	next := repo.rows[1]
+	if !next.ActivatesAt.Equal(first.RetiresAt) || next.ID == first.ID {
+		t.Errorf("successor %s activates at %v, want %v", next.ID, next.ActivatesAt, first.RetiresAt)
+	}
+	if !next.ExpiresAt.Equal(next.RetiresAt.Add(time.Hour)) {
+		t.Errorf("successor expires at %v, want its retirement plus the verify grace", next.ExpiresAt)
+	}
+
+	// Nothing else is due until the successor nears retirement.
+	locks := lockA.locks
+	if err := a.rotateIfDue(ctx, now.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.rows) != 2 || lockA.locks != locks {
+		t.Errorf("%d keys, %d locks after the successor exists", len(repo.rows), lockA.locks)
+	}
+	if jwks := a.JWKS(); len(jwks.Keys) != 2 || jwks.Keys[0].Kid != next.ID {
+		t.Errorf("JWKS %+v, want the successor published first", jwks)
+	}
+}
+
+func TestKeyringAlgorithmChange(t *testing.T) {
+	ctx := context.Background()
+	repo, tx := &memKeys{}, &serialTx{}
+	old, _ := newTestKeyring(t, repo, tx, AlgEdDSA)
+	if err := old.Load(ctx); err != nil {
+		t.Fatal(err)
+	}
+	k, lock := newTestKeyring(t, repo, tx, AlgRS256)
+	if err := k.Load(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.rows) != 2 || lock.locks != 1 || repo.rows[1].Algorithm != AlgRS256 {
+		t.Fatalf("%d keys, want an RS256 successor", len(repo.rows))
+	}
+	if !repo.rows[1].ActivatesAt.After(time.Now()) {
+		t.Error("the successor signs before verifiers could fetch it")
+	}
+}
@@ -0,0 +1,291 @@
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/rm/roadmap/backend/internal/models"
+)
+
+// Authenticator verifies a login against an external identity source (e.g. LDAP/Active Directory).
+// AuthService.Login falls back to local password auth for local accounts when it returns
+// ErrDirectoryUserNotFound or ErrDirectoryUnavailable, so break-glass accounts keep working without the directory.
+type Authenticator interface {
+	Authenticate(ctx context.Context, login, password string) (*Identity, error)
+}
+
+// Identity is a user as the directory sees them, with the role mapped from their groups.
+type Identity struct {
+	DN     string
+	Email  string
+	Name   string
+	Groups []string
+	Role   models.Role
+}
+
+var (
+	ErrDirectoryUserNotFound       = errors.New("user not found in directory")
+	ErrDirectoryInvalidCredentials = errors.New("directory rejected credentials")
+	ErrDirectoryUnavailable        = errors.New("directory unavailable")
+	ErrDirectoryNoRole             = errors.New("user is not in any mapped directory group")
+)
+
+type LDAPConfig struct {
+	URL                string // ldap://host:389 or ldaps://host:636
+	StartTLS           bool   // upgrade ldap:// connections with StartTLS
+	InsecureSkipVerify bool   // accept any server certificate (testing only)
+	BindDN             string // service account used for the search; empty = anonymous
+	BindPassword       string
+	BaseDN             string        // subtree searched for users
+	UserFilter         string        // {login} is replaced with the escaped login, e.g. (mail={login})
+	EmailAttr          string        // default mail
+	NameAttr           string        // default displayName
+	GroupAttr          string        // user attribute listing group DNs; default memberOf
+	GroupBaseDN        string        // when set, also search groups with GroupFilter ({dn} = user DN)
+	GroupFilter        string        // default (member={dn})
+	GroupRoles         []GroupRole   // group DN → role; the highest mapped role wins
+	DefaultRole        models.Role   // role for users in no mapped group; empty = deny them
+	Timeout            time.Duration // dial and per-request timeout
+}
+
+type GroupRole struct {
+	DN   *ldap.DN
+	Role models.Role
+}
+
+// LDAPAuthenticator authenticates with search+bind: it binds as the service account, finds exactly one
+// entry matching UserFilter, then binds as that entry with the user's password.
+type LDAPAuthenticator struct {
+	cfg LDAPConfig
+}
+
+func NewLDAPAuthenticator(cfg LDAPConfig) (*LDAPAuthenticator, error) {
+	if cfg.URL == "" || cfg.BaseDN == "" {
+		return nil, errors.New("ldap: URL and base DN are required")
+	}
+	if cfg.UserFilter == "" {
+		cfg.UserFilter = "(mail={login})"
+	}
+	if !strings.Contains(cfg.UserFilter, "{login}") {
+		return nil, errors.New("ldap: user filter must contain {login}")
+	}
+	if _, err := ldap.CompileFilter(strings.ReplaceAll(cfg.UserFilter, "{login}", "x")); err != nil {
+		return nil, fmt.Errorf("ldap: invalid user filter: %w", err)
+	}
+	if cfg.EmailAttr == "" {
+		cfg.EmailAttr = "mail"
+	}
+	if cfg.NameAttr == "" {
+		cfg.NameAttr = "displayName"
+	}
+	if cfg.GroupAttr == "" {
+		cfg.GroupAttr = "memberOf"
+	}
+	if cfg.GroupFilter == "" {
+		cfg.GroupFilter = "(member={dn})"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	return &LDAPAuthenticator{cfg: cfg}, nil
+}
+
+// ParseGroupRoles parses "groupDN=role;groupDN=role". The role is after the last '=' since DNs contain '='.
+func ParseGroupRoles(s string) ([]GroupRole, error) {
+	var out []GroupRole
+	for _, entry := range strings.Split(s, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		i := strings.LastIndex(entry, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("ldap: invalid group mapping %q", entry)
+		}
+		role, err := ParseRole(entry[i+1:])
+		if err != nil {
+			return nil, err
+		}
+		dn, err := ldap.ParseDN(strings.TrimSpace(entry[:i]))
+		if err != nil {
+			return nil, fmt.Errorf("ldap: invalid group DN %q: %w", entry[:i], err)
+		}
+		out = append(out, GroupRole{DN: dn, Role: role})
+	}
+	return out, nil
+}
+
+// ParseRole accepts user, owner, admin or superadmin; empty means none.
+func ParseRole(s string) (models.Role, error) {
+	switch r := models.Role(strings.ToLower(strings.TrimSpace(s))); r {
+	case "", models.RoleUser, models.RoleOwner, models.RoleAdmin, models.RoleSuperadmin:
+		return r, nil
+	}
+	return "", fmt.Errorf("ldap: unknown role %q", s)
+}
+
+func (a *LDAPAuthenticator) Authenticate(ctx context.Context, login, password string) (*Identity, error) {
+	// An empty password would be an unauthenticated bind, which most servers accept.
+	if password == "" {
+		return nil, ErrDirectoryInvalidCredentials
+	}
+	conn, err := a.dial(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDirectoryUnavailable, err)
+	}
+	defer conn.Close()
+
+	if err := a.bindService(conn); err != nil {
+		return nil, err
+	}
+	entry, err := a.findUser(conn, login)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrDirectoryInvalidCredentials
+		}
+		return nil, fmt.Errorf("%w: user bind: %v", ErrDirectoryUnavailable, err)
+	}
+
+	id := &Identity{
+		DN:     entry.DN,
+		Email:  strings.TrimSpace(entry.GetAttributeValue(a.cfg.EmailAttr)),
+		Name:   strings.TrimSpace(entry.GetAttributeValue(a.cfg.NameAttr)),
+		Groups: entry.GetAttributeValues(a.cfg.GroupAttr),
+	}
+	if id.Email == "" {
+		id.Email = login
+	}
+	if id.Name == "" {
+		id.Name = id.Email
+	}
+	if a.cfg.GroupBaseDN != "" {
+		// Some servers only let the service account read groups.
+		if err := a.bindService(conn); err != nil {
+			return nil, err
+		}
+		groups, err := a.searchGroups(conn, entry.DN)
+		if err != nil {
+			return nil, err
+		}
+		id.Groups = append(id.Groups, groups...)
+	}
+	id.Role = a.mapRole(id.Groups)
+	if id.Role == "" {
+		return nil, ErrDirectoryNoRole
+	}
+	return id, nil
+}
+
+func (a *LDAPAuthenticator) dial(ctx context.Context) (*ldap.Conn, error) {
+	dialer := &net.Dialer{Timeout: a.cfg.Timeout}
+	if deadline, ok := ctx.Deadline(); ok {
+		dialer.Deadline = deadline
+	}
+	tlsCfg := &tls.Config{InsecureSkipVerify: a.cfg.InsecureSkipVerify} //nolint:gosec // opt-in for test directories
+	conn, err := ldap.DialURL(a.cfg.URL, ldap.DialWithDialer(dialer), ldap.DialWithTLSConfig(tlsCfg))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(a.cfg.Timeout)
+	if a.cfg.StartTLS {
+		if host, _, err := net.SplitHostPort(strings.TrimPrefix(a.cfg.URL, "ldap://")); err == nil {
+			tlsCfg.ServerName = host
+		}
+		if err := conn.StartTLS(tlsCfg); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+func (a *LDAPAuthenticator) bindService(conn *ldap.Conn) error {
+	var err error
+	if a.cfg.BindDN == "" {
+		err = conn.UnauthenticatedBind("")
+	} else {
+		err = conn.Bind(a.cfg.BindDN, a.cfg.BindPassword)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: service bind: %v", ErrDirectoryUnavailable, err)
+	}
+	return nil
+}
+
+func (a *LDAPAuthenticator) findUser(conn *ldap.Conn, login string) (*ldap.Entry, error) {
+	filter := strings.ReplaceAll(a.cfg.UserFilter, "{login}", ldap.EscapeFilter(login))
+	attrs := []string{a.cfg.EmailAttr, a.cfg.NameAttr, a.cfg.GroupAttr}
+	req := ldap.NewSearchRequest(a.cfg.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, int(a.cfg.Timeout.Seconds()), false, filter, attrs, nil)
+	res, err := conn.Search(req)
+	if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchObject) {
+			return nil, ErrDirectoryUserNotFound
+		}
+		return nil, fmt.Errorf("%w: user search: %v", ErrDirectoryUnavailable, err)
+	}
+	if res == nil || len(res.Entries) == 0 {
+		return nil, ErrDirectoryUserNotFound
+	}
+	if len(res.Entries) > 1 {
+		// Binding as either entry would be a guess; refuse rather than pick one.
+		return nil, ErrDirectoryInvalidCredentials
+	}
+	return res.Entries[0], nil
+}
+
+func (a *LDAPAuthenticator) searchGroups(conn *ldap.Conn, userDN string) ([]string, error) {
+	filter := strings.ReplaceAll(a.cfg.GroupFilter, "{dn}", ldap.EscapeFilter(userDN))
+	req := ldap.NewSearchRequest(a.cfg.GroupBaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, int(a.cfg.Timeout.Seconds()), false, filter, []string{"dn"}, nil)
+	res, err := conn.Search(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: group search: %v", ErrDirectoryUnavailable, err)
+	}
+	groups := make([]string, len(res.Entries))
+	for i, e := range res.Entries {
+		groups[i] = e.DN
+	}
+	return groups, nil
+}
+
+// mapRole returns the highest role mapped from groups, else DefaultRole.
+func (a *LDAPAuthenticator) mapRole(groups []string) models.Role {
+	best := models.Role("")
+	for _, g := range groups {
+		dn, err := ldap.ParseDN(g)
+		if err != nil {
+			continue
+		}
+		for _, m := range a.cfg.GroupRoles {
+			if m.DN.EqualFold(dn) && roleRank(m.Role) > roleRank(best) {
+				best = m.Role
+			}
+		}
+	}
+	if best == "" {
+		return a.cfg.DefaultRole
+	}
+	return best
+}
+
+func roleRank(r models.Role) int {
+	switch r {
+	case models.RoleUser:
+		return 1
+	case models.RoleOwner:
+		return 2
+	case models.RoleAdmin:
+		return 3
+	case models.RoleSuperadmin:
+		return 4
+	}
+	return 0
+}
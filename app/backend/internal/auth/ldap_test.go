@@ -0,0 +1,351 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/rm/roadmap/backend/internal/models"
+)
+
+// fakeDirectory is an in-process LDAP server that understands just enough of the protocol for
+// search+bind: simple binds, subtree searches with and/or/not/equality/present filters, and unbind.
+type fakeDirectory struct {
+	ln      net.Listener
+	entries map[string]fakeEntry // keyed by lower-cased DN
+
+	mu    sync.Mutex
+	binds []string
+}
+
+type fakeEntry struct {
+	dn       string
+	password string
+	attrs    map[string][]string
+}
+
+func newFakeDirectory(t *testing.T, entries ...fakeEntry) *fakeDirectory {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	d := &fakeDirectory{ln: ln, entries: map[string]fakeEntry{}}
+	for _, e := range entries {
+		d.entries[strings.ToLower(e.dn)] = e
+	}
+	go d.serve()
+	t.Cleanup(func() { ln.Close() })
+	return d
+}
+
+func (d *fakeDirectory) URL() string { return "ldap://" + d.ln.Addr().String() }
+
+func (d *fakeDirectory) boundDNs() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.binds...)
+}
+
+func (d *fakeDirectory) serve() {
+	for {
+		conn, err := d.ln.Accept()
+		if err != nil {
+			return
+		}
+		go d.handle(conn)
+	}
+}
+
+func (d *fakeDirectory) handle(conn net.Conn) {
+	defer conn.Close()
+	for {
+		req, err := ber.ReadPacket(conn)
+		if err != nil || len(req.Children) < 2 {
+			return
+		}
+		msgID := req.Children[0].Value.(int64)
+		op := req.Children[1]
+		switch op.Tag {
+		case ldap.ApplicationBindRequest:
+			dn, pw := op.Children[1].Data.String(), op.Children[2].Data.String()
+			d.mu.Lock()
+			d.binds = append(d.binds, dn)
+			d.mu.Unlock()
+			code := uint16(ldap.LDAPResultInvalidCredentials)
+			if e, ok := d.entries[strings.ToLower(dn)]; ok && e.password != "" && e.password == pw {
+				code = ldap.LDAPResultSuccess
+			}
+			conn.Write(ldapResult(msgID, ldap.ApplicationBindResponse, code).Bytes())
+		case ldap.ApplicationSearchRequest:
+			base := strings.ToLower(op.Children[0].Data.String())
+			for key, e := range d.entries {
+				if (key == base || strings.HasSuffix(key, ","+base)) && matchFilter(op.Children[6], e) {
+					conn.Write(searchEntry(msgID, e).Bytes())
+				}
+			}
+			conn.Write(ldapResult(msgID, ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess).Bytes())
+		case ldap.ApplicationUnbindRequest:
+			return
+		}
+	}
+}
+
+func matchFilter(f *ber.Packet, e fakeEntry) bool {
+	switch f.Tag {
+	case ldap.FilterAnd:
+		for _, c := range f.Children {
+			if !matchFilter(c, e) {
+				return false
+			}
+		}
+		return true
+	case ldap.FilterOr:
+		for _, c := range f.Children {
+			if matchFilter(c, e) {
+				return true
+			}
+		}
+		return false
+	case ldap.FilterNot:
+		return !matchFilter(f.Children[0], e)
+	case ldap.FilterEqualityMatch:
+		attr, want := f.Children[0].Data.String(), f.Children[1].Data.String()
+		for _, v := range e.get(attr) {
+			if strings.EqualFold(v, want) {
+				return true
+			}
+		}
+		return false
+	case ldap.FilterPresent:
+		return len(e.get(f.Data.String())) > 0
+	}
+	return false
+}
+
+func (e fakeEntry) get(attr string) []string {
+	for k, v := range e.attrs {
+		if strings.EqualFold(k, attr) {
+			return v
+		}
+	}
+	return nil
+}
+
+func ldapResult(msgID int64, tag ber.Tag, code uint16) *ber.Packet {
+	p := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+	p.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, msgID, ""))
+	res := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "")
+	res.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), ""))
+	res.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+	res.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+	p.AppendChild(res)
+	return p
+}
+
+func searchEntry(msgID int64, e fakeEntry) *ber.Packet {
+	p := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+	p.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, msgID, ""))
+	res := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "")
+	res.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, e.dn, ""))
+	attrs := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+	for name, vals := range e.attrs {
+		a := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+		a.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, ""))
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "")
+		for _, v := range vals {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, v, ""))
+		}
+		a.AppendChild(set)
+		attrs.AppendChild(a)
+	}
+	res.AppendChild(attrs)
+	p.AppendChild(res)
+	return p
+}
+
+const (
+	testServiceDN = "cn=svc,dc=example,dc=com"
+	testAdmins    = "cn=Roadmap Admins,ou=groups,dc=example,dc=com"
+	testOwners    = "cn=roadmap-owners,ou=groups,dc=example,dc=com"
+)
+
+func testDirectory(t *testing.T) *fakeDirectory {
+	return newFakeDirectory(t,
+		fakeEntry{dn: testServiceDN, password: "svc-secret"},
+		fakeEntry{dn: "uid=alice,ou=people,dc=example,dc=com", password: "alice-pw", attrs: map[string][]string{
+			"objectClass": {"person"},
+			"mail":        {"alice@example.com"},
+			"displayName": {"Alice Adams"},
+			"memberOf":    {"CN=roadmap admins,OU=Groups,DC=example,DC=com", "cn=staff,ou=groups,dc=example,dc=com"},
+		}},
+		fakeEntry{dn: "uid=bob,ou=people,dc=example,dc=com", password: "bob-pw", attrs: map[string][]string{
+			"objectClass": {"person"},
+			"mail":        {"bob@example.com"},
+			"displayName": {"Bob Brown"},
+		}},
+		fakeEntry{dn: testOwners, attrs: map[string][]string{
+			"objectClass": {"groupOfNames"},
+			"member":      {"uid=bob,ou=people,dc=example,dc=com"},
+		}},
+	)
+}
+
+func testLDAPConfig(t *testing.T, d *fakeDirectory) LDAPConfig {
+	roles, err := ParseGroupRoles(testAdmins + "=admin; " + testOwners + "=owner")
+	if err != nil {
+		t.Fatalf("ParseGroupRoles: %v", err)
+	}
+	return LDAPConfig{
+		URL:          d.URL(),
+		BindDN:       testServiceDN,
+		BindPassword: "svc-secret",
+		BaseDN:       "ou=people,dc=example,dc=com",
+		UserFilter:   "(&(objectClass=person)(mail={login}))",
+		GroupRoles:   roles,
+		DefaultRole:  models.RoleUser,
+	}
+}
+
+func newTestAuthenticator(t *testing.T, cfg LDAPConfig) *LDAPAuthenticator {
+	t.Helper()
+	a, err := NewLDAPAuthenticator(cfg)
+	if err != nil {
+		t.Fatalf("NewLDAPAuthenticator: %v", err)
+	}
+	return a
+}
+
+func TestLDAPAuthenticate_mapsGroupToRole(t *testing.T) {
+	d := testDirectory(t)
+	a := newTestAuthenticator(t, testLDAPConfig(t, d))
+
+	id, err := a.Authenticate(context.Background(), "alice@example.com", "alice-pw")
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if id.Role != models.RoleAdmin {
+		t.Errorf("role = %q, want admin (group DNs compare case-insensitively)", id.Role)
+	}
+	if id.Email != "alice@example.com" || id.Name != "Alice Adams" {
+		t.Errorf("identity = %+v", id)
+	}
+	binds := d.boundDNs()
+	if len(binds) != 2 || binds[0] != testServiceDN || binds[1] != "uid=alice,ou=people,dc=example,dc=com" {
+		t.Errorf("binds = %v, want service account then user", binds)
+	}
+}
+
+func TestLDAPAuthenticate_defaultRole(t *testing.T) {
+	d := testDirectory(t)
+	a := newTestAuthenticator(t, testLDAPConfig(t, d))
+
+	id, err := a.Authenticate(context.Background(), "bob@example.com", "bob-pw")
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if id.Role != models.RoleUser {
+		t.Errorf("role = %q, want default user", id.Role)
+	}
+}
+
+func TestLDAPAuthenticate_noRoleDenied(t *testing.T) {
+	d := testDirectory(t)
+	cfg := testLDAPConfig(t, d)
+	cfg.DefaultRole = ""
+	a := newTestAuthenticator(t, cfg)
+
+	if _, err := a.Authenticate(context.Background(), "bob@example.com", "bob-pw"); !errors.Is(err, ErrDirectoryNoRole) {
+		t.Fatalf("err = %v, want ErrDirectoryNoRole", err)
+	}
+}
+
+func TestLDAPAuthenticate_groupSearch(t *testing.T) {
+	d := testDirectory(t)
+	cfg := testLDAPConfig(t, d)
+	cfg.GroupBaseDN = "ou=groups,dc=example,dc=com"
+	a := newTestAuthenticator(t, cfg)
+
+	id, err := a.Authenticate(context.Background(), "bob@example.com", "bob-pw")
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if id.Role != models.RoleOwner {
+		t.Errorf("role = %q, want owner from group search", id.Role)
+	}
+}
+
+func TestLDAPAuthenticate_wrongPassword(t *testing.T) {
+	d := testDirectory(t)
+	a := newTestAuthenticator(t, testLDAPConfig(t, d))
+
+	if _, err := a.Authenticate(context.Background(), "alice@example.com", "nope"); !errors.Is(err, ErrDirectoryInvalidCredentials) {
+		t.Fatalf("err = %v, want ErrDirectoryInvalidCredentials", err)
+	}
+}
+
+func TestLDAPAuthenticate_emptyPasswordNeverBinds(t *testing.T) {
+	d := testDirectory(t)
+	a := newTestAuthenticator(t, testLDAPConfig(t, d))
+
+	if _, err := a.Authenticate(context.Background(), "alice@example.com", ""); !errors.Is(err, ErrDirectoryInvalidCredentials) {
+		t.Fatalf("err = %v, want ErrDirectoryInvalidCredentials", err)
+	}
+	if binds := d.boundDNs(); len(binds) != 0 {
+		t.Errorf("binds = %v, want none", binds)
+	}
+}
+
+func TestLDAPAuthenticate_unknownUser(t *testing.T) {
+	d := testDirectory(t)
+	a := newTestAuthenticator(t, testLDAPConfig(t, d))
+
+	for _, login := range []string{"carol@example.com", "*", "*)(mail=*"} {
+		if _, err := a.Authenticate(context.Background(), login, "pw"); !errors.Is(err, ErrDirectoryUserNotFound) {
+			t.Errorf("login %q: err = %v, want ErrDirectoryUserNotFound", login, err)
+		}
+	}
+}
+
+func TestLDAPAuthenticate_badServiceAccount(t *testing.T) {
+	d := testDirectory(t)
+	cfg := testLDAPConfig(t, d)
+	cfg.BindPassword = "wrong"
+	a := newTestAuthenticator(t, cfg)
+
+	if _, err := a.Authenticate(context.Background(), "alice@example.com", "alice-pw"); !errors.Is(err, ErrDirectoryUnavailable) {
+		t.Fatalf("err = %v, want ErrDirectoryUnavailable", err)
+	}
+}
+
+func TestLDAPAuthenticate_unreachable(t *testing.T) {
+	d := testDirectory(t)
+	cfg := testLDAPConfig(t, d)
+	d.ln.Close()
+	a := newTestAuthenticator(t, cfg)
+
+	if _, err := a.Authenticate(context.Background(), "alice@example.com", "alice-pw"); !errors.Is(err, ErrDirectoryUnavailable) {
+		t.Fatalf("err = %v, want ErrDirectoryUnavailable", err)
+	}
+}
+
+func TestParseGroupRoles(t *testing.T) {
+	roles, err := ParseGroupRoles("cn=a,dc=x=admin;;cn=b,dc=x = OWNER ")
+	if err != nil {
+		t.Fatalf("ParseGroupRoles: %v", err)
+	}
+	if len(roles) != 2 || roles[0].Role != models.RoleAdmin || roles[1].Role != models.RoleOwner {
+		t.Fatalf("roles = %+v", roles)
+	}
+	if _, err := ParseGroupRoles("cn=a,dc=x=root"); err == nil {
+		t.Error("unknown role accepted")
+	}
+	if _, err := ParseGroupRoles("admin"); err == nil {
+		t.Error("mapping without DN accepted")
+	}
+}
@@ -0,0 +1,224 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/models"
+	"github.com/rm/roadmap/backend/internal/repositories"
+)
+
+var (
+	ErrForbidden   = errors.New("forbidden")
+	ErrUnknownRole = errors.New("unknown role")
+)
+
+// Subject is the caller being authorized.
+type Subject struct {
+	UserID uuid.UUID
+	Role   models.Role
+}
+
+// Resource is what the action targets. OwnerID and Delegates are compared against the subject for
+// ":own" grants; Delegates holds users acting for the owner (e.g. product co-owners and editors).
+// The zero value (Any) asks whether the subject may act on every resource of that kind.
+type Resource struct {
+	Type      string
+	ID        string
+	OwnerID   *uuid.UUID
+	Delegates []uuid.UUID
+}
+
+// Any is a type-level resource: only unscoped grants match it.
+var Any = Resource{}
+
+// Owned returns a resource owned by ownerID, optionally shared with delegates.
+func Owned(resourceType string, id string, ownerID *uuid.UUID, delegates ...uuid.UUID) Resource {
+	return Resource{Type: resourceType, ID: id, OwnerID: ownerID, Delegates: delegates}
+}
+
+// ownedBy reports whether userID owns the resource or acts for its owner.
+func (r Resource) ownedBy(userID uuid.UUID) bool {
+	if userID == uuid.Nil {
+		return false
+	}
+	if r.OwnerID != nil && *r.OwnerID == userID {
+		return true
+	}
+	for _, d := range r.Delegates {
+		if d == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// Grant is one effective permission of a role.
+type Grant struct {
+	Permission Permission
+	Scope      string // any | own
+}
+
+// Engine evaluates permissions from the role_permissions table, cached in memory for ttl.
+type Engine struct {
+	repo repositories.RolePermissionRepository
+	ttl  time.Duration
+
+	mu       sync.RWMutex
+	grants   map[models.Role]map[string]bool
+	loadedAt time.Time
+}
+
+func NewEngine(repo repositories.RolePermissionRepository, ttl time.Duration) *Engine {
+	return &Engine{repo: repo, ttl: ttl}
+}
+
+// Roles lists the roles the engine knows about, highest first.
+var Roles = []models.Role{models.RoleSuperadmin, models.RoleAdmin, models.RoleOwner, models.RoleUser}
+
+func normalizeRole(r models.Role) models.Role {
+	return models.Role(strings.ToLower(strings.TrimSpace(string(r))))
+}
+
+// Authorize returns nil when subject may perform action on resource, ErrForbidden otherwise.
+func (e *Engine) Authorize(ctx context.Context, subject Subject, action Permission, resource Resource) error {
+	role := normalizeRole(subject.Role)
+	if role == models.RoleSuperadmin {
+		return nil
+	}
+	grants, err := e.roleGrants(ctx, role)
+	if err != nil {
+		return err
+	}
+	if grants[string(action)] {
+		return nil
+	}
+	if grants[string(action)+OwnSuffix] && resource.ownedBy(subject.UserID) {
+		return nil
+	}
+	return ErrForbidden
+}
+
+// Allowed is Authorize as a bool; lookup errors count as denied.
+func (e *Engine) Allowed(ctx context.Context, subject Subject, action Permission, resource Resource) bool {
+	return e.Authorize(ctx, subject, action, resource) == nil
+}
+
+// HasOwnScope reports whether the role holds action at least for its own resources.
+func (e *Engine) HasOwnScope(ctx context.Context, subject Subject, action Permission) bool {
+	return e.Allowed(ctx, subject, action, Owned("", "", &subject.UserID))
+}
+
+// EffectivePermissions returns the grants of a role, sorted by permission.
+func (e *Engine) EffectivePermissions(ctx context.Context, role models.Role) ([]Grant, error) {
+	role = normalizeRole(role)
+	if !knownRole(role) {
+		return nil, ErrUnknownRole
+	}
+	if role == models.RoleSuperadmin {
+		out := make([]Grant, len(Catalog))
+		for i, d := range Catalog {
+			out[i] = Grant{Permission: d.Permission, Scope: "any"}
+		}
+		return out, nil
+	}
+	grants, err := e.roleGrants(ctx, role)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Grant, 0, len(grants))
+	for g := range grants {
+		if p, ok := strings.CutSuffix(g, OwnSuffix); ok {
+			// An unscoped grant supersedes the :own one.
+			if !grants[p] {
+				out = append(out, Grant{Permission: Permission(p), Scope: "own"})
+			}
+			continue
+		}
+		out = append(out, Grant{Permission: Permission(g), Scope: "any"})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Permission < out[j].Permission })
+	return out, nil
+}
+
+// SeedDefaults grants every entry of DefaultGrants that has not been seeded before, so defaults added in a
+// later release reach existing installs while a default an admin revoked stays revoked.
+func (e *Engine) SeedDefaults(ctx context.Context) error {
+	seeded, err := e.repo.ListSeeded(ctx)
+	if err != nil {
+		return err
+	}
+	done := make(map[models.Role]map[string]bool)
+	for _, s := range seeded {
+		r := normalizeRole(s.Role)
+		if done[r] == nil {
+			done[r] = make(map[string]bool)
+		}
+		done[r][s.Permission] = true
+	}
+	for role, grants := range DefaultGrants {
+		var missing []string
+		for _, g := range grants {
+			if !done[role][g] {
+				missing = append(missing, g)
+			}
+		}
+		if len(missing) == 0 {
+			continue
+		}
+		if err := e.repo.Seed(ctx, role, missing); err != nil {
+			return err
+		}
+	}
+	e.Invalidate()
+	return nil
+}
+
+// Invalidate drops the cache so the next check reloads from the database.
+func (e *Engine) Invalidate() {
+	e.mu.Lock()
+	e.grants = nil
+	e.mu.Unlock()
+}
+
+func (e *Engine) roleGrants(ctx context.Context, role models.Role) (map[string]bool, error) {
+	e.mu.RLock()
+	if e.grants != nil && time.Since(e.loadedAt) < e.ttl {
+		g := e.grants[role]
+		e.mu.RUnlock()
+		return g, nil
+	}
+	e.mu.RUnlock()
+
+	list, err := e.repo.ListAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	grants := make(map[models.Role]map[string]bool)
+	for _, rp := range list {
+		r := normalizeRole(rp.Role)
+		if grants[r] == nil {
+			grants[r] = make(map[string]bool)
+		}
+		grants[r][rp.Permission] = true
+	}
+	e.mu.Lock()
+	e.grants = grants
+	e.loadedAt = time.Now()
+	e.mu.Unlock()
+	return grants[role], nil
+}
+
+func knownRole(r models.Role) bool {
+	for _, k := range Roles {
+		if k == r {
+			return true
+		}
+	}
+	return false
+}
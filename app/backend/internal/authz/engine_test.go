@@ -0,0 +1,292 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/models"
+)
+
+// memGrants is an in-memory role_permissions table with its seed markers.
+type memGrants struct {
+	rows   []models.RolePermission
+	seeded []models.RolePermissionSeed
+	lists  int
+	seeds  int
+	err    error
+}
+
+func (m *memGrants) ListAll(context.Context) ([]models.RolePermission, error) {
+	m.lists++
+	return m.rows, m.err
+}
+
+func (m *memGrants) ListSeeded(context.Context) ([]models.RolePermissionSeed, error) { return m.seeded, m.err }
+
+func (m *memGrants) Seed(_ context.Context, role models.Role, permissions []string) error {
+	m.seeds++
+	for _, p := range permissions {
+		if !m.has(role, p) {
+			m.rows = append(m.rows, models.RolePermission{ID: uuid.New(), Role: role, Permission: p})
+		}
+		m.seeded = append(m.seeded, models.RolePermissionSeed{Role: role, Permission: p})
+	}
+	return nil
+}
+
+func (m *memGrants) has(role models.Role, permission string) bool {
+	for _, r := range m.rows {
+		if r.Role == role && r.Permission == permission {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *memGrants) ReplaceForRole(_ context.Context, role models.Role, permissions []string) error {
+	kept := m.rows[:0]
+	for _, r := range m.rows {
+		if r.Role != role {
+			kept = append(kept, r)
+		}
+	}
+	for _, p := range permissions {
+		kept = append(kept, models.RolePermission{ID: uuid.New(), Role: role, Permission: p})
+	}
+	m.rows = kept
+	return nil
+}
+
+func grantRows(role models.Role, permissions ...string) []models.RolePermission {
+	out := make([]models.RolePermission, len(permissions))
+	for i, p := range permissions {
+		out[i] = models.RolePermission{ID: uuid.New(), Role: role, Permission: p}
+	}
+	return out
+}
+
+func TestAuthorize(t *testing.T) {
+	me, owner, other := uuid.New(), uuid.New(), uuid.New()
+	repo := &memGrants{rows: append(
+		grantRows(models.RoleOwner,
+			string(PermProductCreate),
+			string(PermProductUpdate)+OwnSuffix,
+			string(PermMilestoneWrite)+OwnSuffix,
+			string(PermAuditRead), string(PermAuditRead)+OwnSuffix),
+		grantRows(" Admin ", string(PermUserManage))...)}
+	e := NewEngine(repo, time.Minute)
+	mine := Owned("product", "p1", &me)
+	delegated := Owned("product", "p2", &owner, other, me)
+	foreign := Owned("product", "p3", &owner, other)
+	unowned := Owned("product", "p4", nil)
+
+	for _, tc := range []struct {
+		name     string
+		subject  Subject
+		action   Permission
+		resource Resource
+		want     bool
+	}{
+		{"unscoped grant on any resource", Subject{me, models.RoleOwner}, PermProductCreate, Any, true},
+		{"unscoped grant on a foreign resource", Subject{me, models.RoleOwner}, PermProductCreate, foreign, true},
+		{"own grant on own resource", Subject{me, models.RoleOwner}, PermProductUpdate, mine, true},
+		{"own grant on a foreign resource", Subject{me, models.RoleOwner}, PermProductUpdate, foreign, false},
+		{"own grant type-level", Subject{me, models.RoleOwner}, PermProductUpdate, Any, false},
+		{"own grant without an owner", Subject{me, models.RoleOwner}, PermProductUpdate, unowned, false},
+		{"own grant as delegate", Subject{me, models.RoleOwner}, PermMilestoneWrite, delegated, true},
+		{"own grant for another delegate", Subject{uuid.New(), models.RoleOwner}, PermMilestoneWrite, delegated, false},
+		{"own grant for the nil user", Subject{uuid.Nil, models.RoleOwner}, PermProductUpdate, Owned("product", "p5", &uuid.Nil, uuid.Nil), false},
+		{"both scopes type-level", Subject{me, models.RoleOwner}, PermAuditRead, Any, true},
+		{"no grant", Subject{me, models.RoleOwner}, PermProductDelete, mine, false},
+		{"role name normalized", Subject{me, "ADMIN"}, PermUserManage, Any, true},
+		{"role without grants", Subject{me, models.RoleUser}, PermProductCreate, Any, false},
+		{"unknown role", Subject{me, "intern"}, PermProductCreate, Any, false},
+		{"superadmin without rows", Subject{me, models.RoleSuperadmin}, PermUserGrantSuperadmin, foreign, true},
+	} {
+		err := e.Authorize(context.Background(), tc.subject, tc.action, tc.resource)
+		if got := err == nil; got != tc.want {
+			t.Errorf("%s: Authorize = %v, want allowed %v", tc.name, err, tc.want)
+		}
+		if !tc.want && !errors.Is(err, ErrForbidden) {
+			t.Errorf("%s: got %v, want ErrForbidden", tc.name, err)
+		}
+	}
+
+	if !e.HasOwnScope(context.Background(), Subject{me, models.RoleOwner}, PermProductUpdate) {
+		t.Error("HasOwnScope: owner has product:update:own")
+	}
+	if e.HasOwnScope(context.Background(), Subject{me, models.RoleOwner}, PermProductDelete) {
+		t.Error("HasOwnScope: owner has no product:delete grant")
+	}
+	if repo.lists != 1 {
+		t.Errorf("grants loaded %d times, want once within the ttl", repo.lists)
+	}
+}
+
+func TestAuthorizeCache(t *testing.T) {
+	me := uuid.New()
+	repo := &memGrants{}
+	e := NewEngine(repo, time.Hour)
+	subject := Subject{me, models.RoleUser}
+	if e.Allowed(context.Background(), subject, PermProductCreate, Any) {
+		t.Fatal("allowed before the grant exists")
+	}
+	repo.rows = grantRows(models.RoleUser, string(PermProductCreate))
+	if e.Allowed(context.Background(), subject, PermProductCreate, Any) {
+		t.Error("grant seen before the cache expired")
+	}
+	e.Invalidate()
+	if !e.Allowed(context.Background(), subject, PermProductCreate, Any) {
+		t.Error("grant not seen after Invalidate")
+	}
+
+	repo.err = errors.New("db down")
+	e.Invalidate()
+	if err := e.Authorize(context.Background(), subject, PermProductCreate, Any); !errors.Is(err, repo.err) {
+		t.Errorf("lookup failure: got %v", err)
+	}
+	if e.Allowed(context.Background(), subject, PermProductCreate, Any) {
+		t.Error("lookup failure allowed")
+	}
+}
+
+func TestEffectivePermissions(t *testing.T) {
+	repo := &memGrants{rows: grantRows(models.RoleOwner,
+		string(PermProductUpdate)+OwnSuffix,
+		string(PermAuditRead), string(PermAuditRead)+OwnSuffix,
+		string(PermProductCreate))}
+	e := NewEngine(repo, time.Minute)
+	got, err := e.EffectivePermissions(context.Background(), models.RoleOwner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Grant{
+		{PermAuditRead, "any"},
+		{PermProductCreate, "any"},
+		{PermProductUpdate, "own"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("owner: got %v, want %v", got, want)
+	}
+
+	got, err = e.EffectivePermissions(context.Background(), models.RoleSuperadmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(Catalog) {
+		t.Errorf("superadmin: %d grants, want the %d in the catalog", len(got), len(Catalog))
+	}
+	if _, err := e.EffectivePermissions(context.Background(), "intern"); !errors.Is(err, ErrUnknownRole) {
+		t.Errorf("unknown role: got %v", err)
+	}
+}
+
+func TestSeedDefaults(t *testing.T) {
+	ctx := context.Background()
+	repo := &memGrants{}
+	e := NewEngine(repo, time.Hour)
+	owner := Subject{uuid.New(), models.RoleOwner}
+	if e.Allowed(ctx, owner, PermProductCreate, Any) {
+		t.Fatal("allowed before seeding")
+	}
+	if err := e.SeedDefaults(ctx); err != nil {
+		t.Fatal(err)
+	}
+	want := 0
+	for _, grants := range DefaultGrants {
+		want += len(grants)
+	}
+	if len(repo.rows) != want || len(repo.seeded) != want {
+		t.Errorf("seeded %d rows and %d markers, want %d", len(repo.rows), len(repo.seeded), want)
+	}
+	if !e.Allowed(ctx, owner, PermProductCreate, Any) {
+		t.Error("seeded grant not seen: SeedDefaults must invalidate the cache")
+	}
+
+	// Nothing left to seed on the next start.
+	repo.seeds = 0
+	if err := e.SeedDefaults(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if repo.seeds != 0 || len(repo.rows) != want {
+		t.Errorf("reseeding wrote %d times, %d rows", repo.seeds, len(repo.rows))
+	}
+
+	// A default an admin revoked stays revoked.
+	if err := repo.ReplaceForRole(ctx, models.RoleOwner, []string{string(PermGroupRead) + OwnSuffix}); err != nil {
+		t.Fatal(err)
+	}
+	e.Invalidate()
+	if err := e.SeedDefaults(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if e.Allowed(ctx, owner, PermProductCreate, Any) {
+		t.Error("revoked default granted again")
+	}
+}
+
+// TestSeedDefaultsUpgrade starts from a table written by an older release, before product:read existed.
+func TestSeedDefaultsUpgrade(t *testing.T) {
+	ctx := context.Background()
+	repo := &memGrants{rows: append(
+		grantRows(models.RoleOwner, string(PermProductCreate), "custom:grant"),
+		grantRows(models.RoleUser, string(PermProductCreate))...)}
+	e := NewEngine(repo, time.Hour)
+	if err := e.SeedDefaults(ctx); err != nil {
+		t.Fatal(err)
+	}
+	owner, user := Subject{uuid.New(), models.RoleOwner}, Subject{uuid.New(), models.RoleUser}
+	if !e.HasOwnScope(ctx, owner, PermProductRead) || e.Allowed(ctx, owner, PermProductRead, Any) {
+		t.Error("owner: want product:read:own after the upgrade")
+	}
+	if !e.Allowed(ctx, user, PermProductRead, Any) {
+		t.Error("user: want product:read after the upgrade")
+	}
+	if !repo.has(models.RoleOwner, "custom:grant") {
+		t.Error("an admin's own grant was dropped")
+	}
+	seen := map[string]bool{}
+	for _, r := range repo.rows {
+		k := string(r.Role) + " " + r.Permission
+		if seen[k] {
+			t.Errorf("%s granted twice", k)
+		}
+		seen[k] = true
+	}
+}
+
+func TestDefaultGrantsInCatalog(t *testing.T) {
+	for role, grants := range DefaultGrants {
+		for _, g := range grants {
+			p, own := strings.CutSuffix(g, OwnSuffix)
+			d, ok := Lookup(Permission(p))
+			if !ok {
+				t.Errorf("%s: %s is not in the catalog", role, g)
+				continue
+			}
+			if own && !d.Ownable {
+				t.Errorf("%s: %s is granted :own but is not ownable", role, p)
+			}
+		}
+	}
+}
+
+// TestDefaultGrantsReadProducts guards product listing: ProductService.ReadScope shows nothing to a role
+// without product:read or product:read:own, so every default role needs one of them.
+func TestDefaultGrantsReadProducts(t *testing.T) {
+	ctx := context.Background()
+	e := NewEngine(&memGrants{}, time.Hour)
+	if err := e.SeedDefaults(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, role := range Roles {
+		if !e.HasOwnScope(ctx, Subject{uuid.New(), role}, PermProductRead) {
+			t.Errorf("%s cannot list products by default", role)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// Package authz is the central policy engine. Roles are mapped to named permissions through the
+// role_permissions table; Engine.Authorize is the single check used by middleware and services.
+//
+// A grant is either "resource:action" (applies to every resource) or "resource:action:own"
+// (applies only when Resource.OwnerID is the subject). Superadmin is always allowed so the
+// permission table can never lock everyone out.
+package authz
+
+import "github.com/rm/roadmap/backend/internal/models"
+
+type Permission string
+
+const (
+	PermProductRead         Permission = "product:read" // :own limits it to the caller's reporting subtree
+	PermProductCreate       Permission = "product:create"
+	PermProductUpdate       Permission = "product:update"
+	PermProductDelete       Permission = "product:delete"
+	PermProductStatus       Permission = "product:status" // change approval or lifecycle status
+	PermProductMembers      Permission = "product:members"
+	PermMilestoneWrite      Permission = "milestone:write"
+	PermVersionWrite        Permission = "version:write"
+	PermDependencyWrite     Permission = "dependency:write"
+	PermRequestApprove      Permission = "request:approve"
+	PermGroupRead           Permission = "group:read"
+	PermGroupWrite          Permission = "group:write"
+	PermUserManage          Permission = "user:manage"
+	PermUserGrantSuperadmin Permission = "user:grant_superadmin"
+	PermOrgManage           Permission = "org:manage"
+	PermAuditRead           Permission = "audit:read"
+	PermAuditArchive        Permission = "audit:archive"
+	PermAuditPurge          Permission = "audit:purge"
+	PermAuditVerify         Permission = "audit:verify"
+	PermAuditRestore        Permission = "audit:restore"   // revert or undelete an entity from an audit snapshot
+	PermAuditRetention      Permission = "audit:retention" // manage retention policies and legal holds, run retention
+	PermActivityRead        Permission = "activity:read"
+	PermLoginUnlock         Permission = "login:unlock"
+	PermSessionManage       Permission = "session:manage"
+	PermRoadmapEdit         Permission = "roadmap:edit"
+	PermPermissionRead      Permission = "permission:read"
+	PermNotificationManage  Permission = "notification:manage" // organization notification defaults and mandatory types
+	PermWebhookManage       Permission = "webhook:manage"      // outbound webhook subscriptions and their delivery log
+)
+
+// OwnSuffix marks a grant that only applies to resources owned by the subject.
+const OwnSuffix = ":own"
+
+// Definition describes a permission for the admin catalog.
+type Definition struct {
+	Permission  Permission `json:"permission"`
+	Description string     `json:"description"`
+	Ownable     bool       `json:"ownable"` // can be granted with the :own scope
+}
+
+// Catalog lists every permission the application checks.
+var Catalog = []Definition{
+	{PermProductRead, "See products; with :own only those owned by the user or anyone reporting to them", true},
+	{PermProductCreate, "Create products", false},
+	{PermProductUpdate, "Edit product fields", true},
+	{PermProductDelete, "Delete products", false},
+	{PermProductStatus, "Change product approval and lifecycle status", false},
+	{PermProductMembers, "Add, change and remove product collaborators", true},
+	{PermMilestoneWrite, "Create, edit and delete milestones", true},
+	{PermVersionWrite, "Create, edit and delete product versions", true},
+	{PermDependencyWrite, "Create and delete version dependencies", true},
+	{PermRequestApprove, "See and approve product creation and deletion requests", false},
+	{PermGroupRead, "View product groups", true},
+	{PermGroupWrite, "Edit and delete product groups", true},
+	{PermUserManage, "Manage users, managers and product ownership", false},
+	{PermUserGrantSuperadmin, "Grant the superadmin role or modify superadmin users", false},
+	{PermOrgManage, "Manage the organization hierarchy", false},
+	{PermAuditRead, "Read audit logs", true},
+	{PermAuditArchive, "Archive audit logs", false},
+	{PermAuditPurge, "Permanently delete archived audit logs", false},
+	{PermAuditVerify, "Verify the audit log hash chain", false},
+	{PermAuditRestore, "Restore products, milestones and versions from audit snapshots", true},
+	{PermAuditRetention, "Manage audit retention policies and legal holds", false},
+	{PermActivityRead, "Read activity logs", true},
+	{PermLoginUnlock, "View and lift login lockouts", false},
+	{PermSessionManage, "List and revoke other users' sessions", false},
+	{PermRoadmapEdit, "Edit the roadmap Gantt", false},
+	{PermPermissionRead, "Inspect role and user permissions", false},
+	{PermNotificationManage, "Manage organization notification defaults and see preference changes", false},
+	{PermWebhookManage, "Manage webhook subscriptions, inspect and redeliver their deliveries", false},
+}
+
+// DefaultGrants seeds role_permissions on startup; each entry is written once (see Engine.SeedDefaults).
+// They mirror the behaviour of the former hard-coded role checks. Superadmin needs no rows; it is always
+// allowed.
+var DefaultGrants = map[models.Role][]string{
+	models.RoleAdmin: {
+		string(PermProductRead), string(PermProductCreate), string(PermProductUpdate), string(PermProductDelete), string(PermProductStatus), string(PermProductMembers),
+		string(PermMilestoneWrite), string(PermVersionWrite), string(PermDependencyWrite),
+		string(PermRequestApprove), string(PermGroupRead), string(PermGroupWrite),
+		string(PermUserManage), string(PermOrgManage),
+		string(PermAuditRead), string(PermAuditArchive), string(PermAuditPurge), string(PermAuditVerify), string(PermAuditRestore), string(PermAuditRetention),
+		string(PermActivityRead), string(PermLoginUnlock), string(PermSessionManage), string(PermPermissionRead),
+		string(PermNotificationManage), string(PermWebhookManage),
+	},
+	models.RoleOwner: {
+		string(PermProductRead) + OwnSuffix, string(PermProductCreate), string(PermProductUpdate) + OwnSuffix, string(PermProductMembers) + OwnSuffix,
+		string(PermMilestoneWrite) + OwnSuffix, string(PermVersionWrite) + OwnSuffix, string(PermDependencyWrite) + OwnSuffix,
+		string(PermGroupRead) + OwnSuffix, string(PermGroupWrite) + OwnSuffix,
+		string(PermAuditRead) + OwnSuffix, string(PermAuditRestore) + OwnSuffix, string(PermActivityRead) + OwnSuffix,
+	},
+	models.RoleUser: {
+		string(PermProductRead),
+		string(PermProductCreate), // user-created products start pending
+		string(PermProductUpdate) + OwnSuffix, string(PermProductMembers) + OwnSuffix,
+		string(PermMilestoneWrite) + OwnSuffix, string(PermVersionWrite) + OwnSuffix, string(PermDependencyWrite) + OwnSuffix,
+		string(PermGroupRead) + OwnSuffix, string(PermGroupWrite) + OwnSuffix,
+		string(PermAuditRead) + OwnSuffix, string(PermAuditRestore) + OwnSuffix, string(PermActivityRead) + OwnSuffix,
+	},
+}
+
+// Lookup returns the catalog entry for a permission.
+func Lookup(p Permission) (Definition, bool) {
+	for _, d := range Catalog {
+		if d.Permission == p {
+			return d, true
+		}
+	}
+	return Definition{}, false
+}
@@ -0,0 +1,184 @@
+package chatops
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// Secrets the recorded requests in testdata were signed with.
+const (
+	testSlackSecret = "8f742231b10e8888abcd99yyyzzz85a5"
+	testTeamsToken  = "bXktdGVhbXMtc2VjdXJpdHktdG9rZW4tZm9yLXRlc3Rz"
+)
+
+// readRequest loads a recorded HTTP request and its raw body.
+func readRequest(t *testing.T, name string) (*http.Request, []byte) {
+	t.Helper()
+	raw, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(raw)))
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return req, body
+}
+
+func TestVerifySlack(t *testing.T) {
+	req, body := readRequest(t, "slack_command.http")
+	ts, sig := req.Header.Get(SlackTimestampHeader), req.Header.Get(SlackSignatureHeader)
+	sent := time.Unix(1729339200, 0)
+
+	if err := VerifySlack(testSlackSecret, ts, sig, body, sent.Add(30*time.Second)); err != nil {
+		t.Fatalf("recorded request: %v", err)
+	}
+	tampered := bytes.Replace(body, []byte("Payments"), []byte("Billing"), 1)
+	for _, tc := range []struct {
+		name    string
+		secret  string
+		ts, sig string
+		body    []byte
+		now     time.Time
+		want    error
+	}{
+		{"wrong secret", "other", ts, sig, body, sent, ErrBadSignature},
+		{"tampered body", testSlackSecret, ts, sig, tampered, sent, ErrBadSignature},
+		{"other timestamp", testSlackSecret, "1729339201", sig, body, sent, ErrBadSignature},
+		{"missing version", testSlackSecret, ts, sig[3:], body, sent, ErrBadSignature},
+		{"replayed late", testSlackSecret, ts, sig, body, sent.Add(MaxSkew + time.Second), ErrStaleRequest},
+		{"from the future", testSlackSecret, ts, sig, body, sent.Add(-MaxSkew - time.Second), ErrStaleRequest},
+		{"bad timestamp", testSlackSecret, "yesterday", sig, body, sent, ErrBadSignature},
+	} {
+		if err := VerifySlack(tc.secret, tc.ts, tc.sig, tc.body, tc.now); !errors.Is(err, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd, err := ParseCommand(form.Get("text"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Command{Kind: CommandNext, Milestone: "GA", Product: "Payments API"}); cmd != want {
+		t.Errorf("command = %+v, want %+v", cmd, want)
+	}
+}
+
+func TestVerifyTeams(t *testing.T) {
+	req, body := readRequest(t, "teams_command.http")
+	auth := req.Header.Get(TeamsSignatureHeader)
+
+	if err := VerifyTeams(testTeamsToken, auth, body); err != nil {
+		t.Fatalf("recorded request: %v", err)
+	}
+	if err := VerifyTeams("b3RoZXItdG9rZW4=", auth, body); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("wrong token: %v", err)
+	}
+	if err := VerifyTeams(testTeamsToken, auth, append(body, ' ')); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("tampered body: %v", err)
+	}
+	if err := VerifyTeams(testTeamsToken, "Bearer x", body); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("wrong scheme: %v", err)
+	}
+
+	var activity struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(body, &activity); err != nil {
+		t.Fatal(err)
+	}
+	cmd, err := ParseCommand(activity.Text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Command{Kind: CommandBlocked, Product: "Payments API"}); cmd != want {
+		t.Errorf("command = %+v, want %+v", cmd, want)
+	}
+}
+
+func TestParseCommand(t *testing.T) {
+	for _, tc := range []struct {
+		text string
+		want Command
+		err  error
+	}{
+		{"", Command{Kind: CommandHelp}, nil},
+		{"  HELP ", Command{Kind: CommandHelp}, nil},
+		{"/roadmap blocked", Command{Kind: CommandBlocked}, nil},
+		{"Next Tested Successfully FOR Checkout", Command{Kind: CommandNext, Milestone: "Tested Successfully", Product: "Checkout"}, nil},
+		{"next ga for Data for Teams", Command{Kind: CommandNext, Milestone: "ga", Product: "Data for Teams"}, nil},
+		{"next GA", Command{}, ErrUnknownCommand},
+		{"next for Checkout", Command{}, ErrUnknownCommand},
+		{"blocked Checkout", Command{}, ErrUnknownCommand},
+		{"deploy everything", Command{}, ErrUnknownCommand},
+	} {
+		got, err := ParseCommand(tc.text)
+		if !errors.Is(err, tc.err) || got != tc.want {
+			t.Errorf("ParseCommand(%q) = %+v, %v; want %+v, %v", tc.text, got, err, tc.want, tc.err)
+		}
+	}
+}
+
+func TestEventPayloads(t *testing.T) {
+	raw, err := os.ReadFile(filepath.Join("testdata", "event_milestone_update.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		t.Fatal(err)
+	}
+	m := EventMessage(payload, "https://roadmap.example.com/")
+	if want := `Milestone "GA" updated`; m.Title != want {
+		t.Errorf("title = %q, want %q", m.Title, want)
+	}
+	if len(m.Fields) != 2 || m.Fields[0] != (Field{"end_date", "2024-11-08 → 2024-11-22"}) {
+		t.Errorf("fields = %+v", m.Fields)
+	}
+	golden(t, "event_milestone_update.slack.json", SlackPayload(m))
+	golden(t, "event_milestone_update.teams.json", TeamsPayload(m))
+}
+
+// golden compares v, as indented JSON, with a file in testdata; -update rewrites the file.
+func golden(t *testing.T, name string, v interface{}) {
+	t.Helper()
+	got, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, '\n')
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs:\n%s", name, got)
+	}
+}
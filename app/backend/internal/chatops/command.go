@@ -0,0 +1,68 @@
+package chatops
+
+import (
+	"errors"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// Command kinds.
+const (
+	CommandHelp    = "help"
+	CommandNext    = "next"
+	CommandBlocked = "blocked"
+)
+
+// Usage lists the commands ParseCommand understands.
+const Usage = "next <milestone> for <product> – the next upcoming milestone of that type or label, e.g. next GA for Payments\n" +
+	"blocked [for <product>] – product versions waiting on a dependency that is not ready\n" +
+	"help – this list"
+
+var ErrUnknownCommand = errors.New("chatops: unknown command")
+
+// Command is a parsed slash command.
+type Command struct {
+	Kind      string
+	Milestone string // next: milestone type or label, e.g. "GA"
+	Product   string // next: required; blocked: optional filter
+}
+
+// mentionRe matches the bot mention Teams puts in front of outgoing webhook messages.
+var mentionRe = regexp.MustCompile(`(?is)<at>.*?</at>`)
+
+// ParseCommand parses the text of a command: what follows "/roadmap" in Slack, or the message that
+// mentioned the bot in Teams. Keywords are case-insensitive; an empty text asks for help.
+func ParseCommand(text string) (Command, error) {
+	text = mentionRe.ReplaceAllString(text, " ")
+	text = html.UnescapeString(strings.ReplaceAll(text, "&nbsp;", " "))
+	fields := strings.Fields(text)
+	if len(fields) > 0 && strings.EqualFold(fields[0], "/roadmap") {
+		fields = fields[1:]
+	}
+	if len(fields) == 0 {
+		return Command{Kind: CommandHelp}, nil
+	}
+	switch verb, rest := strings.ToLower(fields[0]), fields[1:]; verb {
+	case CommandHelp:
+		return Command{Kind: CommandHelp}, nil
+	case CommandBlocked:
+		if len(rest) == 0 {
+			return Command{Kind: CommandBlocked}, nil
+		}
+		if len(rest) > 1 && strings.EqualFold(rest[0], "for") {
+			return Command{Kind: CommandBlocked, Product: strings.Join(rest[1:], " ")}, nil
+		}
+	case CommandNext:
+		for i, f := range rest {
+			if strings.EqualFold(f, "for") && i > 0 && i < len(rest)-1 {
+				return Command{
+					Kind:      CommandNext,
+					Milestone: strings.Join(rest[:i], " "),
+					Product:   strings.Join(rest[i+1:], " "),
+				}, nil
+			}
+		}
+	}
+	return Command{}, ErrUnknownCommand
+}
@@ -0,0 +1,145 @@
+package chatops
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// eventFieldsLimit caps the changed fields listed for one event.
+	eventFieldsLimit = 8
+	eventValueLimit  = 120
+)
+
+// eventSkipFields are bookkeeping fields left out of change listings.
+var eventSkipFields = map[string]bool{
+	"id":         true,
+	"created_at": true,
+	"updated_at": true,
+	"deleted_at": true,
+	"created_by": true,
+	"updated_by": true,
+}
+
+// EventMessage renders a webhook event payload (see the webhooks section of the README) as a chat message:
+// the entity and what happened to it, and the fields that changed, or for a create the fields it was
+// created with. baseURL, when set, links the message to the product page.
+func EventMessage(payload map[string]interface{}, baseURL string) Message {
+	entityType, _ := payload["entity_type"].(string)
+	action, _ := payload["action"].(string)
+	oldData, _ := payload["old"].(map[string]interface{})
+	newData, _ := payload["new"].(map[string]interface{})
+
+	kind := strings.ReplaceAll(entityType, "_", " ")
+	if kind != "" {
+		kind = strings.ToUpper(kind[:1]) + kind[1:]
+	}
+	title := kind + " " + pastTense(action)
+	if name := entityName(newData); name != "" {
+		title = fmt.Sprintf("%s %q %s", kind, name, pastTense(action))
+	} else if name := entityName(oldData); name != "" {
+		title = fmt.Sprintf("%s %q %s", kind, name, pastTense(action))
+	}
+	m := Message{Title: strings.TrimSpace(title), Fields: eventFields(oldData, newData)}
+	if ts, _ := payload["timestamp"].(string); ts != "" {
+		m.Text = "At " + ts
+	}
+	switch action {
+	case "create", "restore":
+		m.Color = ColorGood
+	case "delete":
+		m.Color = ColorAttention
+	}
+	if productID, _ := payload["product_id"].(string); productID != "" {
+		m.Link = ProductLink(baseURL, productID)
+	}
+	return m
+}
+
+// ProductLink is the URL of a product's page, or "" without a base URL.
+func ProductLink(baseURL, productID string) string {
+	if baseURL == "" {
+		return ""
+	}
+	return strings.TrimRight(baseURL, "/") + "/products/" + productID
+}
+
+// entityName is the name, label, version or email in an entity's data, whichever comes first.
+func entityName(data map[string]interface{}) string {
+	for _, k := range []string{"name", "label", "version", "email"} {
+		if s, ok := data[k].(string); ok && s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// eventFields lists the fields whose value differs between oldData and newData, as "old → new", sorted by
+// name; a create lists the non-empty new values.
+func eventFields(oldData, newData map[string]interface{}) []Field {
+	if newData == nil {
+		return nil
+	}
+	keys := make([]string, 0, len(newData))
+	for k := range newData {
+		if !eventSkipFields[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var out []Field
+	for _, k := range keys {
+		nv := formatValue(newData[k])
+		if oldData == nil {
+			if nv != "" {
+				out = append(out, Field{Name: k, Value: nv})
+			}
+		} else if ov := formatValue(oldData[k]); ov != nv {
+			if ov == "" {
+				ov = "–"
+			}
+			if nv == "" {
+				nv = "–"
+			}
+			out = append(out, Field{Name: k, Value: ov + " → " + nv})
+		}
+		if len(out) == eventFieldsLimit {
+			break
+		}
+	}
+	return out
+}
+
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return truncate(v, eventValueLimit)
+	case bool, float64:
+		return fmt.Sprint(v)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return truncate(string(b), eventValueLimit)
+}
+
+func pastTense(action string) string {
+	switch action {
+	case "":
+		return "changed"
+	case "create":
+		return "created"
+	case "update":
+		return "updated"
+	case "delete":
+		return "deleted"
+	case "restore":
+		return "restored"
+	}
+	return strings.ReplaceAll(action, "_", " ")
+}
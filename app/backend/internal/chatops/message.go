@@ -0,0 +1,226 @@
+// Package chatops connects Roadmap to Slack and Microsoft Teams.
+//
+// Messages are rendered as Slack Block Kit payloads or as Teams messages carrying an Adaptive Card, both for
+// event notifications posted to incoming webhooks and for the answers to slash commands. Incoming command
+// requests are authenticated with the platform's HMAC signature: Slack's signing secret, or the security token
+// of a Teams outgoing webhook.
+package chatops
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Message formats.
+const (
+	FormatSlack = "slack"
+	FormatTeams = "teams"
+)
+
+// Message colors; they set the Teams card title color and are ignored by Slack blocks.
+const (
+	ColorDefault   = ""
+	ColorGood      = "good"
+	ColorWarning   = "warning"
+	ColorAttention = "attention"
+)
+
+const (
+	// slackHeaderLimit and slackTextLimit are Block Kit's limits for header and section text.
+	slackHeaderLimit = 150
+	slackTextLimit   = 3000
+	// slackFieldsLimit is the number of fields one section block takes.
+	slackFieldsLimit = 10
+
+	linkTitle = "Open in Roadmap"
+)
+
+// Field is a name/value pair shown as a Slack section field or a Teams fact.
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Message is a platform-neutral chat message. Text is plain text with newlines; Link, when set, becomes a
+// button.
+type Message struct {
+	Title  string
+	Text   string
+	Fields []Field
+	Link   string
+	Color  string
+}
+
+// SlackMessage is a Block Kit message, for an incoming webhook or as a slash-command response.
+type SlackMessage struct {
+	ResponseType string       `json:"response_type,omitempty"` // in_channel | ephemeral; commands only
+	Text         string       `json:"text"`                    // notification fallback
+	Blocks       []slackBlock `json:"blocks"`
+}
+
+type slackBlock struct {
+	Type     string        `json:"type"`
+	Text     *slackText    `json:"text,omitempty"`
+	Fields   []slackText   `json:"fields,omitempty"`
+	Elements []slackButton `json:"elements,omitempty"`
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackButton struct {
+	Type string    `json:"type"`
+	Text slackText `json:"text"`
+	URL  string    `json:"url"`
+}
+
+// SlackPayload renders m as Block Kit: a header, the text, the fields, and a button to the link.
+func SlackPayload(m Message) SlackMessage {
+	out := SlackMessage{Text: slackEscape(m.Title)}
+	if m.Title != "" {
+		out.Blocks = append(out.Blocks, slackBlock{Type: "header", Text: &slackText{Type: "plain_text", Text: truncate(m.Title, slackHeaderLimit)}})
+	}
+	if m.Text != "" {
+		out.Blocks = append(out.Blocks, slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: truncate(slackEscape(m.Text), slackTextLimit)}})
+	}
+	for len(m.Fields) > 0 {
+		n := min(len(m.Fields), slackFieldsLimit)
+		b := slackBlock{Type: "section"}
+		for _, f := range m.Fields[:n] {
+			b.Fields = append(b.Fields, slackText{Type: "mrkdwn", Text: truncate("*"+slackEscape(f.Name)+"*\n"+slackEscape(f.Value), 2000)})
+		}
+		out.Blocks = append(out.Blocks, b)
+		m.Fields = m.Fields[n:]
+	}
+	if m.Link != "" {
+		out.Blocks = append(out.Blocks, slackBlock{Type: "actions", Elements: []slackButton{{
+			Type: "button",
+			Text: slackText{Type: "plain_text", Text: linkTitle},
+			URL:  m.Link,
+		}}})
+	}
+	return out
+}
+
+// SlackCommandResponse renders m as the response to a slash command, visible to the whole channel or only
+// to the user who ran it.
+func SlackCommandResponse(m Message, inChannel bool) SlackMessage {
+	out := SlackPayload(m)
+	out.ResponseType = "ephemeral"
+	if inChannel {
+		out.ResponseType = "in_channel"
+	}
+	return out
+}
+
+// slackEscape escapes the characters mrkdwn treats as control characters.
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// TeamsMessage is a Teams message with one Adaptive Card attachment, for an incoming webhook or as the
+// response to an outgoing webhook.
+type TeamsMessage struct {
+	Type        string            `json:"type"`
+	Attachments []teamsAttachment `json:"attachments"`
+}
+
+type teamsAttachment struct {
+	ContentType string    `json:"contentType"`
+	ContentURL  *string   `json:"contentUrl"`
+	Content     teamsCard `json:"content"`
+}
+
+type teamsCard struct {
+	Schema  string         `json:"$schema"`
+	Type    string         `json:"type"`
+	Version string         `json:"version"`
+	Body    []teamsElement `json:"body"`
+	Actions []teamsAction  `json:"actions,omitempty"`
+}
+
+type teamsElement struct {
+	Type   string      `json:"type"`
+	Text   string      `json:"text,omitempty"`
+	Weight string      `json:"weight,omitempty"`
+	Size   string      `json:"size,omitempty"`
+	Color  string      `json:"color,omitempty"`
+	Wrap   bool        `json:"wrap,omitempty"`
+	Facts  []teamsFact `json:"facts,omitempty"`
+}
+
+type teamsFact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+type teamsAction struct {
+	Type  string `json:"type"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// TeamsPayload renders m as an Adaptive Card 1.4: a title, the text, a fact set of the fields, and an
+// action opening the link.
+func TeamsPayload(m Message) TeamsMessage {
+	card := teamsCard{
+		Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+		Type:    "AdaptiveCard",
+		Version: "1.4",
+		Body:    []teamsElement{},
+	}
+	if m.Title != "" {
+		card.Body = append(card.Body, teamsElement{Type: "TextBlock", Text: m.Title, Weight: "Bolder", Size: "Medium", Color: teamsColor(m.Color), Wrap: true})
+	}
+	if m.Text != "" {
+		card.Body = append(card.Body, teamsElement{Type: "TextBlock", Text: m.Text, Wrap: true})
+	}
+	if len(m.Fields) > 0 {
+		fs := teamsElement{Type: "FactSet"}
+		for _, f := range m.Fields {
+			fs.Facts = append(fs.Facts, teamsFact{Title: f.Name, Value: f.Value})
+		}
+		card.Body = append(card.Body, fs)
+	}
+	if m.Link != "" {
+		card.Actions = []teamsAction{{Type: "Action.OpenUrl", Title: linkTitle, URL: m.Link}}
+	}
+	return TeamsMessage{
+		Type:        "message",
+		Attachments: []teamsAttachment{{ContentType: "application/vnd.microsoft.card.adaptive", Content: card}},
+	}
+}
+
+func teamsColor(c string) string {
+	switch c {
+	case ColorGood:
+		return "Good"
+	case ColorWarning:
+		return "Warning"
+	case ColorAttention:
+		return "Attention"
+	}
+	return ""
+}
+
+// Payload renders m in format, FormatSlack or FormatTeams; ok is false for any other format.
+func Payload(format string, m Message) (v interface{}, ok bool) {
+	switch format {
+	case FormatSlack:
+		return SlackPayload(m), true
+	case FormatTeams:
+		return TeamsPayload(m), true
+	}
+	return nil, false
+}
+
+// truncate shortens s to at most n runes, ending it with an ellipsis when cut.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	r := []rune(s)
+	return string(r[:n-1]) + "…"
+}
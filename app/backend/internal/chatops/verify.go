@@ -0,0 +1,89 @@
+package chatops
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrBadSignature = errors.New("chatops: invalid request signature")
+	ErrStaleRequest = errors.New("chatops: request timestamp outside the allowed window")
+)
+
+// Request headers carrying the signatures.
+const (
+	SlackSignatureHeader = "X-Slack-Signature"
+	SlackTimestampHeader = "X-Slack-Request-Timestamp"
+	TeamsSignatureHeader = "Authorization"
+)
+
+// MaxSkew is how far a Slack request timestamp may be from now, which bounds replays of a captured request.
+const MaxSkew = 5 * time.Minute
+
+// VerifySlack checks a Slack request: signature must be "v0=" and the hex HMAC-SHA256, keyed with the app's
+// signing secret, of "v0:", the timestamp, ":", and the raw body.
+func VerifySlack(signingSecret, timestamp, signature string, body []byte, now time.Time) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrBadSignature
+	}
+	if d := now.Sub(time.Unix(ts, 0)); d > MaxSkew || d < -MaxSkew {
+		return ErrStaleRequest
+	}
+	got, ok := strings.CutPrefix(signature, "v0=")
+	if !ok {
+		return ErrBadSignature
+	}
+	sig, err := hex.DecodeString(got)
+	if err != nil {
+		return ErrBadSignature
+	}
+	if !hmac.Equal(sig, SlackSignature(signingSecret, timestamp, body)) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// SlackSignature is the raw HMAC-SHA256 Slack signs a request with.
+func SlackSignature(signingSecret, timestamp string, body []byte) []byte {
+	m := hmac.New(sha256.New, []byte(signingSecret))
+	m.Write([]byte("v0:" + timestamp + ":"))
+	m.Write(body)
+	return m.Sum(nil)
+}
+
+// VerifyTeams checks a Teams outgoing webhook request: authorization must be "HMAC " and the base64
+// HMAC-SHA256 of the raw body, keyed with the base64-decoded security token Teams showed when the
+// webhook was created.
+func VerifyTeams(securityToken, authorization string, body []byte) error {
+	got, ok := strings.CutPrefix(authorization, "HMAC ")
+	if !ok {
+		return ErrBadSignature
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(got))
+	if err != nil {
+		return ErrBadSignature
+	}
+	want, err := TeamsSignature(securityToken, body)
+	if err != nil || !hmac.Equal(sig, want) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// TeamsSignature is the raw HMAC-SHA256 Teams signs a request with.
+func TeamsSignature(securityToken string, body []byte) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(securityToken)
+	if err != nil {
+		return nil, err
+	}
+	m := hmac.New(sha256.New, key)
+	m.Write(body)
+	return m.Sum(nil), nil
+}
@@ -10,9 +10,73 @@
 //	DB_PASSWORD             — PostgreSQL password (default: postgres)
 //	DB_NAME                 — PostgreSQL database name (default: roadmap)
 //	DB_SSLMODE              — PostgreSQL sslmode (default: disable)
-//	JWT_SECRET               — JWT signing secret; must be set in production (default: change-me-in-production)
+//	JWT_SECRET               — Encrypts the JWT signing keys stored in the database; must be set in production and equal on all instances (default: change-me-in-production)
+//	JWT_ALGORITHM           — Signing algorithm for new keys: RS256|EdDSA (default: RS256)
+//	JWT_KEY_ROTATION_HOURS  — How long a signing key is used before the next one takes over (default: 720)
+//	JWT_ISSUER              — iss claim set on and required from tokens (default: roadmap)
 //	JWT_ACCESS_EXPIRY_MIN   — Access token expiry in minutes (default: 60)
 //	JWT_REFRESH_EXPIRY_MIN  — Refresh token expiry in minutes (default: 10080)
+//	LOGIN_MAX_FAILED_ATTEMPTS    — Failed logins per account before lockout (default: 5)
+//	LOGIN_IP_MAX_FAILED_ATTEMPTS — Failed logins per client IP before lockout (default: 20)
+//	LOGIN_FAILURE_WINDOW_MIN     — Failures older than this no longer count (default: 15)
+//	LOGIN_LOCKOUT_BASE_SEC       — First lockout duration; doubles on each further failure (default: 60)
+//	LOGIN_LOCKOUT_MAX_SEC        — Upper bound for a single lockout (default: 3600)
+//	LDAP_URL                     — ldap:// or ldaps:// URL of the directory; empty = local password auth only (default: "")
+//	LDAP_START_TLS               — Upgrade ldap:// connections with StartTLS (default: false)
+//	LDAP_INSECURE_SKIP_VERIFY    — Skip TLS certificate verification; testing only (default: false)
+//	LDAP_BIND_DN                 — Service account used to search for users; empty = anonymous (default: "")
+//	LDAP_BIND_PASSWORD           — Service account password (default: "")
+//	LDAP_BASE_DN                 — Subtree searched for users (default: "")
+//	LDAP_USER_FILTER             — User search filter; {login} is the escaped login (default: (mail={login}))
+//	LDAP_EMAIL_ATTR              — Attribute synced to the user's email (default: mail)
+//	LDAP_NAME_ATTR               — Attribute synced to the user's name (default: displayName)
+//	LDAP_GROUP_ATTR              — User attribute listing group DNs (default: memberOf)
+//	LDAP_GROUP_BASE_DN           — If set, groups are also searched here with LDAP_GROUP_FILTER (default: "")
+//	LDAP_GROUP_FILTER            — Group search filter; {dn} is the user's DN (default: (member={dn}))
+//	LDAP_GROUP_ROLES             — Group DN to role mapping, "groupDN=role;groupDN=role"; highest role wins (default: "")
+//	LDAP_DEFAULT_ROLE            — Role for directory users in no mapped group; none = deny login (default: user)
+//	LDAP_TIMEOUT_SEC             — Dial and request timeout (default: 10)
+//	SCIM_BEARER_TOKEN            — Bearer token the identity provider uses for /scim/v2; empty = SCIM disabled (default: "")
+//	SCIM_BASE_URL                — Public URL of /scim/v2, used in meta.location (default: "", relative locations)
+//	SCIM_DEFAULT_DEPARTMENT_ID   — Department for SCIM groups created without a departmentId (default: "")
+//	AUDIT_SIGNING_KEY            — Signs audit log deletion tombstones; must stay stable to verify old tombstones (default: "", derived from JWT_SECRET)
+//	AUDIT_RETENTION_INTERVAL_MIN — Minutes between scheduled retention runs; 0 = scheduler disabled (default: 60)
+//	AUDIT_RETENTION_BATCH_SIZE   — Audit logs archived or purged per statement during retention (default: 500)
+//	SIEM_FORWARD_TARGET          — Syslog target for the SIEM forwarder, tcp://, udp:// or tls://host:port; empty = disabled (default: "")
+//	SIEM_FORWARD_FORMAT          — syslog (RFC 5424 with structured data) or cef (default: syslog)
+//	SIEM_FORWARD_STREAMS         — Comma-separated streams to forward: audit, activity (default: audit,activity)
+//	SIEM_FORWARD_INTERVAL_SEC    — Seconds between checks for new records (default: 5)
+//	SIEM_FORWARD_BATCH_SIZE      — Records sent per checkpoint update (default: 200)
+//	SIEM_FORWARD_TIMEOUT_SEC     — Dial and write timeout (default: 10)
+//	OUTBOX_BATCH_SIZE            — Audit/activity events written per dispatcher transaction (default: 100)
+//	OUTBOX_POLL_INTERVAL_MS      — How often the dispatcher checks the outbox when not woken (default: 1000)
+//	OUTBOX_MAX_ATTEMPTS          — Failed deliveries before an event is set aside (default: 10)
+//	OUTBOX_DRAIN_TIMEOUT_SEC     — Time allowed on shutdown to flush the outbox (default: 10)
+//	APP_BASE_URL                 — Public URL of the app, used for links in email (default: http://localhost:3000)
+//	SMTP_HOST                    — SMTP relay for notification email; empty = email disabled (default: "")
+//	SMTP_PORT                    — SMTP relay port (default: 587)
+//	SMTP_USERNAME                — SMTP AUTH user; empty = no authentication (default: "")
+//	SMTP_PASSWORD                — SMTP AUTH password (default: "")
+//	SMTP_FROM                    — Sender address of notification email (default: Roadmap <roadmap@localhost>)
+//	SMTP_TLS                     — starttls, tls (implicit, port 465) or none (default: starttls)
+//	SMTP_TIMEOUT_SEC             — Dial and SMTP conversation timeout (default: 15)
+//	NOTIFY_EMAIL_POLL_SEC        — Seconds between checks for due email and digests when not woken (default: 10)
+//	NOTIFY_EMAIL_BATCH_SIZE      — Deliveries claimed per check (default: 50)
+//	NOTIFY_EMAIL_MAX_ATTEMPTS    — Failed sends before a notification email is given up (default: 8)
+//	NOTIFICATION_RETENTION_DAYS  — Read, archived and deleted notifications older than this are purged; 0 keeps them (default: 90)
+//	NOTIFICATION_RETENTION_INTERVAL_MIN — Minutes between notification retention runs (default: 60)
+//	REALTIME_BROADCASTER         — How realtime events reach other replicas: postgres (LISTEN/NOTIFY) or local (single replica) (default: postgres)
+//	REALTIME_HEARTBEAT_SEC       — Seconds between event stream keep-alives and session re-checks (default: 25)
+//	MILESTONE_REMINDER_INTERVAL_MIN — Minutes between checks for due milestone reminders; 0 disables them (default: 15)
+//	MILESTONE_REMINDER_DAYS      — Comma-separated days before a milestone starts or ends to remind owners (default: 7,1)
+//	MILESTONE_OVERDUE_LOOKBACK_DAYS — Milestones that ended longer ago get no overdue alert (default: 7)
+//	WEBHOOK_POLL_SEC             — Seconds between checks for due webhook deliveries when not woken (default: 5)
+//	WEBHOOK_BATCH_SIZE           — Webhook deliveries claimed per check (default: 50)
+//	WEBHOOK_MAX_ATTEMPTS         — Failed attempts before a webhook delivery is dead (default: 10)
+//	WEBHOOK_TIMEOUT_SEC          — Timeout of one webhook request (default: 10)
+//	WEBHOOK_ALLOWED_CIDRS        — Comma-separated internal ranges or addresses webhooks may reach; loopback, private and link-local are refused otherwise (default: "")
+//	CHATOPS_SLACK_SIGNING_SECRET — Slack app signing secret for the /roadmap slash command; empty = disabled (default: "")
+//	CHATOPS_TEAMS_SECRET         — Security token of the Teams outgoing webhook (base64); empty = disabled (default: "")
 //	LOG_LEVEL               — Log level: debug|info|warn|error (default: info)
 //	LOG_FORMAT              — Log format: console|json (default: json)
 //	OTEL_EXPORTER_OTLP_ENDPOINT — OpenTelemetry OTLP endpoint; empty = disabled (default: "")
@@ -24,11 +88,23 @@ import (
 )
 
 type Config struct {
-	Server   Server
-	Database Database
-	JWT      JWT
-	Log      Log
-	Otel     Otel
+	Server       Server
+	Database     Database
+	JWT          JWT
+	Login        Login
+	LDAP         LDAP
+	SCIM         SCIM
+	Audit        Audit
+	SIEM         SIEM
+	Outbox       Outbox
+	Email        Email
+	Notification Notification
+	Realtime     Realtime
+	Reminder     Reminder
+	Webhook      Webhook
+	ChatOps      ChatOps
+	Log          Log
+	Otel         Otel
 }
 
 type Server struct {
@@ -46,10 +122,130 @@ type Database struct {
 
 type JWT struct {
 	Secret           string // JWT_SECRET
+	Algorithm        string // JWT_ALGORITHM
+	KeyRotationHours int    // JWT_KEY_ROTATION_HOURS
+	Issuer           string // JWT_ISSUER
 	AccessExpiryMin  int    // JWT_ACCESS_EXPIRY_MIN (minutes)
 	RefreshExpiryMin int    // JWT_REFRESH_EXPIRY_MIN (minutes)
 }
 
+// Login controls brute-force protection on /auth/login (per account and per client IP).
+type Login struct {
+	MaxFailedAttempts   int // LOGIN_MAX_FAILED_ATTEMPTS
+	IPMaxFailedAttempts int // LOGIN_IP_MAX_FAILED_ATTEMPTS
+	FailureWindowMin    int // LOGIN_FAILURE_WINDOW_MIN (minutes)
+	LockoutBaseSec      int // LOGIN_LOCKOUT_BASE_SEC (seconds)
+	LockoutMaxSec       int // LOGIN_LOCKOUT_MAX_SEC (seconds)
+}
+
+// LDAP configures directory (LDAP/Active Directory) authentication for /auth/login.
+type LDAP struct {
+	URL                string // LDAP_URL; empty = disabled
+	StartTLS           bool   // LDAP_START_TLS
+	InsecureSkipVerify bool   // LDAP_INSECURE_SKIP_VERIFY
+	BindDN             string // LDAP_BIND_DN
+	BindPassword       string // LDAP_BIND_PASSWORD
+	BaseDN             string // LDAP_BASE_DN
+	UserFilter         string // LDAP_USER_FILTER
+	EmailAttr          string // LDAP_EMAIL_ATTR
+	NameAttr           string // LDAP_NAME_ATTR
+	GroupAttr          string // LDAP_GROUP_ATTR
+	GroupBaseDN        string // LDAP_GROUP_BASE_DN
+	GroupFilter        string // LDAP_GROUP_FILTER
+	GroupRoles         string // LDAP_GROUP_ROLES
+	DefaultRole        string // LDAP_DEFAULT_ROLE; "none" = deny unmapped users
+	TimeoutSec         int    // LDAP_TIMEOUT_SEC (seconds)
+}
+
+// SCIM controls the SCIM 2.0 provisioning endpoints under /scim/v2.
+type SCIM struct {
+	BearerToken         string // SCIM_BEARER_TOKEN; empty = disabled
+	BaseURL             string // SCIM_BASE_URL
+	DefaultDepartmentID string // SCIM_DEFAULT_DEPARTMENT_ID
+}
+
+// Audit configures the tamper-evident audit log (internal/auditchain).
+type Audit struct {
+	SigningKey           string // AUDIT_SIGNING_KEY; empty = derived from JWT_SECRET
+	RetentionIntervalMin int    // AUDIT_RETENTION_INTERVAL_MIN; 0 = retention scheduler disabled
+	RetentionBatchSize   int    // AUDIT_RETENTION_BATCH_SIZE
+}
+
+// AuditSigningSecret is the secret the audit tombstone key is derived from.
+func (c *Config) AuditSigningSecret() string {
+	if c.Audit.SigningKey != "" {
+		return c.Audit.SigningKey
+	}
+	return c.JWT.Secret
+}
+
+// SIEM configures forwarding of audit and activity records to a SIEM over syslog (internal/siem).
+type SIEM struct {
+	Target      string // SIEM_FORWARD_TARGET; empty = disabled
+	Format      string // SIEM_FORWARD_FORMAT: syslog|cef
+	Streams     string // SIEM_FORWARD_STREAMS, comma-separated
+	IntervalSec int    // SIEM_FORWARD_INTERVAL_SEC
+	BatchSize   int    // SIEM_FORWARD_BATCH_SIZE
+	TimeoutSec  int    // SIEM_FORWARD_TIMEOUT_SEC
+}
+
+// Outbox controls the dispatcher that moves audit and activity events from the outbox to their tables.
+type Outbox struct {
+	BatchSize       int // OUTBOX_BATCH_SIZE
+	PollIntervalMs  int // OUTBOX_POLL_INTERVAL_MS (milliseconds)
+	MaxAttempts     int // OUTBOX_MAX_ATTEMPTS
+	DrainTimeoutSec int // OUTBOX_DRAIN_TIMEOUT_SEC (seconds)
+}
+
+// Email configures notification email (internal/mail) and its delivery queue.
+type Email struct {
+	AppBaseURL      string // APP_BASE_URL
+	SMTPHost        string // SMTP_HOST; empty = disabled
+	SMTPPort        int    // SMTP_PORT
+	SMTPUsername    string // SMTP_USERNAME
+	SMTPPassword    string // SMTP_PASSWORD
+	SMTPFrom        string // SMTP_FROM
+	SMTPTLS         string // SMTP_TLS: starttls | tls | none
+	SMTPTimeoutSec  int    // SMTP_TIMEOUT_SEC (seconds)
+	PollIntervalSec int    // NOTIFY_EMAIL_POLL_SEC (seconds)
+	BatchSize       int    // NOTIFY_EMAIL_BATCH_SIZE
+	MaxAttempts     int    // NOTIFY_EMAIL_MAX_ATTEMPTS
+}
+
+// Realtime configures the Server-Sent Events stream (internal/realtime).
+type Realtime struct {
+	Broadcaster  string // REALTIME_BROADCASTER: postgres | local
+	HeartbeatSec int    // REALTIME_HEARTBEAT_SEC (seconds)
+}
+
+// Notification configures notification retention.
+type Notification struct {
+	RetentionDays        int // NOTIFICATION_RETENTION_DAYS (0 = keep forever)
+	RetentionIntervalMin int // NOTIFICATION_RETENTION_INTERVAL_MIN
+}
+
+// Reminder configures milestone deadline reminders and overdue alerts.
+type Reminder struct {
+	IntervalMin         int    // MILESTONE_REMINDER_INTERVAL_MIN (0 = disabled)
+	Days                string // MILESTONE_REMINDER_DAYS: comma-separated lead times in days
+	OverdueLookbackDays int    // MILESTONE_OVERDUE_LOOKBACK_DAYS
+}
+
+// Webhook configures outbound webhook delivery.
+type Webhook struct {
+	PollIntervalSec int    // WEBHOOK_POLL_SEC
+	BatchSize       int    // WEBHOOK_BATCH_SIZE
+	MaxAttempts     int    // WEBHOOK_MAX_ATTEMPTS
+	TimeoutSec      int    // WEBHOOK_TIMEOUT_SEC
+	AllowedCIDRs    string // WEBHOOK_ALLOWED_CIDRS: comma-separated exceptions to the internal-address block
+}
+
+// ChatOps configures the Slack and Teams command endpoints.
+type ChatOps struct {
+	SlackSigningSecret string // CHATOPS_SLACK_SIGNING_SECRET (empty = Slack command disabled)
+	TeamsSecret        string // CHATOPS_TEAMS_SECRET (empty = Teams command disabled)
+}
+
 // Log controls backend logging (internal/logger).
 type Log struct {
 	Level  string // LOG_LEVEL: debug | info | warn | error
@@ -76,9 +272,97 @@ func Load() *Config {
 		},
 		JWT: JWT{
 			Secret:           getEnv("JWT_SECRET", "change-me-in-production"),
+			Algorithm:        getEnv("JWT_ALGORITHM", "RS256"),
+			KeyRotationHours: getEnvInt("JWT_KEY_ROTATION_HOURS", 720), // 30 days
+			Issuer:           getEnv("JWT_ISSUER", "roadmap"),
 			AccessExpiryMin:  getEnvInt("JWT_ACCESS_EXPIRY_MIN", 60),
 			RefreshExpiryMin: getEnvInt("JWT_REFRESH_EXPIRY_MIN", 10080), // 7 days
 		},
+		Login: Login{
+			MaxFailedAttempts:   getEnvInt("LOGIN_MAX_FAILED_ATTEMPTS", 5),
+			IPMaxFailedAttempts: getEnvInt("LOGIN_IP_MAX_FAILED_ATTEMPTS", 20),
+			FailureWindowMin:    getEnvInt("LOGIN_FAILURE_WINDOW_MIN", 15),
+			LockoutBaseSec:      getEnvInt("LOGIN_LOCKOUT_BASE_SEC", 60),
+			LockoutMaxSec:       getEnvInt("LOGIN_LOCKOUT_MAX_SEC", 3600),
+		},
+		LDAP: LDAP{
+			URL:                getEnv("LDAP_URL", ""),
+			StartTLS:           getEnvBool("LDAP_START_TLS", false),
+			InsecureSkipVerify: getEnvBool("LDAP_INSECURE_SKIP_VERIFY", false),
+			BindDN:             getEnv("LDAP_BIND_DN", ""),
+			BindPassword:       getEnv("LDAP_BIND_PASSWORD", ""),
+			BaseDN:             getEnv("LDAP_BASE_DN", ""),
+			UserFilter:         getEnv("LDAP_USER_FILTER", "(mail={login})"),
+			EmailAttr:          getEnv("LDAP_EMAIL_ATTR", "mail"),
+			NameAttr:           getEnv("LDAP_NAME_ATTR", "displayName"),
+			GroupAttr:          getEnv("LDAP_GROUP_ATTR", "memberOf"),
+			GroupBaseDN:        getEnv("LDAP_GROUP_BASE_DN", ""),
+			GroupFilter:        getEnv("LDAP_GROUP_FILTER", "(member={dn})"),
+			GroupRoles:         getEnv("LDAP_GROUP_ROLES", ""),
+			DefaultRole:        getEnv("LDAP_DEFAULT_ROLE", "user"),
+			TimeoutSec:         getEnvInt("LDAP_TIMEOUT_SEC", 10),
+		},
+		SCIM: SCIM{
+			BearerToken:         getEnv("SCIM_BEARER_TOKEN", ""),
+			BaseURL:             getEnv("SCIM_BASE_URL", ""),
+			DefaultDepartmentID: getEnv("SCIM_DEFAULT_DEPARTMENT_ID", ""),
+		},
+		Audit: Audit{
+			SigningKey:           getEnv("AUDIT_SIGNING_KEY", ""),
+			RetentionIntervalMin: getEnvInt("AUDIT_RETENTION_INTERVAL_MIN", 60),
+			RetentionBatchSize:   getEnvInt("AUDIT_RETENTION_BATCH_SIZE", 500),
+		},
+		SIEM: SIEM{
+			Target:      getEnv("SIEM_FORWARD_TARGET", ""),
+			Format:      getEnv("SIEM_FORWARD_FORMAT", "syslog"),
+			Streams:     getEnv("SIEM_FORWARD_STREAMS", "audit,activity"),
+			IntervalSec: getEnvInt("SIEM_FORWARD_INTERVAL_SEC", 5),
+			BatchSize:   getEnvInt("SIEM_FORWARD_BATCH_SIZE", 200),
+			TimeoutSec:  getEnvInt("SIEM_FORWARD_TIMEOUT_SEC", 10),
+		},
+		Outbox: Outbox{
+			BatchSize:       getEnvInt("OUTBOX_BATCH_SIZE", 100),
+			PollIntervalMs:  getEnvInt("OUTBOX_POLL_INTERVAL_MS", 1000),
+			MaxAttempts:     getEnvInt("OUTBOX_MAX_ATTEMPTS", 10),
+			DrainTimeoutSec: getEnvInt("OUTBOX_DRAIN_TIMEOUT_SEC", 10),
+		},
+		Email: Email{
+			AppBaseURL:      getEnv("APP_BASE_URL", "http://localhost:3000"),
+			SMTPHost:        getEnv("SMTP_HOST", ""),
+			SMTPPort:        getEnvInt("SMTP_PORT", 587),
+			SMTPUsername:    getEnv("SMTP_USERNAME", ""),
+			SMTPPassword:    getEnv("SMTP_PASSWORD", ""),
+			SMTPFrom:        getEnv("SMTP_FROM", "Roadmap <roadmap@localhost>"),
+			SMTPTLS:         getEnv("SMTP_TLS", "starttls"),
+			SMTPTimeoutSec:  getEnvInt("SMTP_TIMEOUT_SEC", 15),
+			PollIntervalSec: getEnvInt("NOTIFY_EMAIL_POLL_SEC", 10),
+			BatchSize:       getEnvInt("NOTIFY_EMAIL_BATCH_SIZE", 50),
+			MaxAttempts:     getEnvInt("NOTIFY_EMAIL_MAX_ATTEMPTS", 8),
+		},
+		Notification: Notification{
+			RetentionDays:        getEnvInt("NOTIFICATION_RETENTION_DAYS", 90),
+			RetentionIntervalMin: getEnvInt("NOTIFICATION_RETENTION_INTERVAL_MIN", 60),
+		},
+		Realtime: Realtime{
+			Broadcaster:  getEnv("REALTIME_BROADCASTER", "postgres"),
+			HeartbeatSec: getEnvInt("REALTIME_HEARTBEAT_SEC", 25),
+		},
+		Reminder: Reminder{
+			IntervalMin:         getEnvInt("MILESTONE_REMINDER_INTERVAL_MIN", 15),
+			Days:                getEnv("MILESTONE_REMINDER_DAYS", "7,1"),
+			OverdueLookbackDays: getEnvInt("MILESTONE_OVERDUE_LOOKBACK_DAYS", 7),
+		},
+		Webhook: Webhook{
+			PollIntervalSec: getEnvInt("WEBHOOK_POLL_SEC", 5),
+			BatchSize:       getEnvInt("WEBHOOK_BATCH_SIZE", 50),
+			MaxAttempts:     getEnvInt("WEBHOOK_MAX_ATTEMPTS", 10),
+			TimeoutSec:      getEnvInt("WEBHOOK_TIMEOUT_SEC", 10),
+			AllowedCIDRs:    getEnv("WEBHOOK_ALLOWED_CIDRS", ""),
+		},
+		ChatOps: ChatOps{
+			SlackSigningSecret: getEnv("CHATOPS_SLACK_SIGNING_SECRET", ""),
+			TeamsSecret:        getEnv("CHATOPS_TEAMS_SECRET", ""),
+		},
 		Log: Log{
 			Level:  getEnv("LOG_LEVEL", "info"),
 			Format: getEnv("LOG_FORMAT", "json"),
@@ -96,6 +380,15 @@ func getEnv(key, defaultVal string) string {
 	return defaultVal
 }
 
+func getEnvBool(key string, defaultVal bool) bool {
+	if v := os.Getenv(key); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return defaultVal
+}
+
 func getEnvInt(key string, defaultVal int) int {
 	if v := os.Getenv(key); v != "" {
 		if i, err := strconv.Atoi(v); err == nil {
@@ -17,11 +17,67 @@ type AuditLogResponse struct {
 	Action         string                 `json:"action"`
 	EntityType     string                 `json:"entity_type"`
 	EntityID       string                 `json:"entity_id"`
+	EntityName     string                 `json:"entity_name,omitempty"` // readable name for org, group and user entries
 	ProductName    string                 `json:"product_name,omitempty"`
 	ProductVersion string                 `json:"product_version,omitempty"`
 	OldData        map[string]interface{} `json:"old_data,omitempty"`
 	NewData        map[string]interface{} `json:"new_data,omitempty"`
+	Changes        []AuditFieldChange     `json:"changes,omitempty"`
 	IPAddress      string                 `json:"ip_address"`
 	UserAgent      string                 `json:"user_agent"`
 	TraceID        string                 `json:"trace_id"`
 }
+
+// AuditFieldChange is one field that differs between an audit record's old and new data (see internal/auditdiff).
+type AuditFieldChange struct {
+	Path string      `json:"path"` // dotted path, with [i] for array elements
+	Op   string      `json:"op"`   // added | removed | modified
+	Old  interface{} `json:"old"`
+	New  interface{} `json:"new"`
+}
+
+// AuditActor is the user behind an audit record, resolved even if the user was deleted since.
+type AuditActor struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// AuditTimelineEntry is one change in an entity's history.
+type AuditTimelineEntry struct {
+	ID        string             `json:"id"`
+	Timestamp string             `json:"timestamp"`
+	Action    string             `json:"action"`
+	Actor     *AuditActor        `json:"actor,omitempty"`
+	Changes   []AuditFieldChange `json:"changes"`
+	Archived  bool               `json:"archived"`
+	TraceID   string             `json:"trace_id"`
+}
+
+// AuditTimelineResponse is the chronological change history of one entity.
+type AuditTimelineResponse struct {
+	EntityType  string               `json:"entity_type"`
+	EntityID    string               `json:"entity_id"`
+	ProductID   string               `json:"product_id,omitempty"`
+	ProductName string               `json:"product_name,omitempty"`
+	Entries     []AuditTimelineEntry `json:"entries"`
+	Total       int64                `json:"total"`
+	Limit       int                  `json:"limit"`
+	Offset      int                  `json:"offset"`
+}
+
+// AuditRestoreRequest reverts the entity of an audit entry to the snapshot stored in it.
+type AuditRestoreRequest struct {
+	State            string `json:"state"`              // before (default; the entry's old_data, i.e. undo) | after (its new_data)
+	ExpectedLatestID string `json:"expected_latest_id"` // optional; 409 if the entity's latest audit entry is another one
+	Force            bool   `json:"force"`              // restore even if the entity was changed outside the audit trail
+}
+
+// AuditRestoreResponse is the entity as restored.
+type AuditRestoreResponse struct {
+	EntityType   string                 `json:"entity_type"`
+	EntityID     string                 `json:"entity_id"`
+	Undeleted    bool                   `json:"undeleted"`
+	RestoredFrom string                 `json:"restored_from"` // audit entry ID
+	Data         map[string]interface{} `json:"data"`
+}
@@ -0,0 +1,55 @@
+package dto
+
+// AuditRetentionPolicyRequest creates or replaces a retention policy. Empty entity_type or action matches any.
+type AuditRetentionPolicyRequest struct {
+	EntityType       string `json:"entity_type"`
+	Action           string `json:"action"`
+	ArchiveAfterDays int    `json:"archive_after_days"` // 0 = never archive automatically
+	PurgeAfterDays   int    `json:"purge_after_days"`   // days after archiving; 0 = never purge
+	Enabled          *bool  `json:"enabled"`            // default true
+}
+
+type AuditRetentionPolicyResponse struct {
+	ID               string  `json:"id"`
+	EntityType       string  `json:"entity_type"`
+	Action           string  `json:"action"`
+	ArchiveAfterDays int     `json:"archive_after_days"`
+	PurgeAfterDays   int     `json:"purge_after_days"`
+	Enabled          bool    `json:"enabled"`
+	CreatedBy        *string `json:"created_by,omitempty"`
+	CreatedAt        string  `json:"created_at"`
+	UpdatedAt        string  `json:"updated_at"`
+}
+
+type AuditLegalHoldRequest struct {
+	EntityType string `json:"entity_type" binding:"required"`
+	EntityID   string `json:"entity_id" binding:"required"`
+	Reason     string `json:"reason" binding:"required"`
+}
+
+type AuditLegalHoldResponse struct {
+	ID         string  `json:"id"`
+	EntityType string  `json:"entity_type"`
+	EntityID   string  `json:"entity_id"`
+	Reason     string  `json:"reason"`
+	CreatedBy  *string `json:"created_by,omitempty"`
+	CreatedAt  string  `json:"created_at"`
+}
+
+// AuditRetentionPolicyResult is what one policy archived and purged in a run, or would in a dry run.
+type AuditRetentionPolicyResult struct {
+	PolicyID   string `json:"policy_id"`
+	EntityType string `json:"entity_type"`
+	Action     string `json:"action"`
+	Archived   int64  `json:"archived"`
+	Purged     int64  `json:"purged"`
+}
+
+type AuditRetentionRunResult struct {
+	DryRun     bool                         `json:"dry_run"`
+	StartedAt  string                       `json:"started_at"`
+	Archived   int64                        `json:"archived"`
+	Purged     int64                        `json:"purged"`
+	Policies   []AuditRetentionPolicyResult `json:"policies"`
+	Incomplete bool                         `json:"incomplete,omitempty"` // stopped early (shutdown or error); the next run continues
+}
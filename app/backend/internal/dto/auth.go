@@ -24,8 +24,10 @@ type UserResponse struct {
 	Name             string  `json:"name"`
 	Email            string  `json:"email"`
 	Role             string  `json:"role"`
+	Active           bool    `json:"active"`
 	TeamID           *string `json:"team_id,omitempty"`
 	DirectManagerID  *string `json:"direct_manager_id,omitempty"`
+	Locale           string  `json:"locale,omitempty"`
 }
 type UserUpdateRequest struct {
 	Name             *string `json:"name"`
@@ -33,6 +35,14 @@ type UserUpdateRequest struct {
 	TeamID           *string `json:"team_id"`
 	DirectManagerID  *string `json:"direct_manager_id"`
 }
+// LocaleRequest sets the caller's locale; "" clears it.
+type LocaleRequest struct {
+	Locale string `json:"locale"`
+}
+type LocaleResponse struct {
+	Locale    string   `json:"locale"`
+	Supported []string `json:"supported"`
+}
 type UserDottedLineManagerResponse struct {
 	ID        string        `json:"id"`
 	UserID    string        `json:"user_id"`
@@ -0,0 +1,10 @@
+package dto
+
+type LoginLockoutResponse struct {
+	ID           string  `json:"id"`
+	Scope        string  `json:"scope"`
+	Key          string  `json:"key"`
+	FailedCount  int     `json:"failed_count"`
+	LastFailedAt string  `json:"last_failed_at"`
+	LockedUntil  *string `json:"locked_until,omitempty"`
+}
@@ -20,6 +20,7 @@ type MilestoneUpdateRequest struct {
 	Type      *string                `json:"type"`
 	Color     *string                `json:"color"`
 	Extra     map[string]interface{} `json:"extra"`
+	Completed *bool                  `json:"completed"` // true marks the milestone done, false reopens it
 }
 
 type MilestoneResponse struct {
@@ -32,5 +33,6 @@ type MilestoneResponse struct {
 	Type      string                 `json:"type"`
 	Color     string                 `json:"color"`
 	Extra     map[string]interface{} `json:"extra,omitempty"`
+	CompletedAt string               `json:"completed_at,omitempty"` // empty while open
 	CreatedAt string                 `json:"created_at"`
 }
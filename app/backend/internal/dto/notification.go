@@ -11,6 +11,8 @@ type NotificationResponse struct {
 	ReadAt            *string `json:"read_at,omitempty"`
 	ArchivedAt        *string `json:"archived_at,omitempty"`
 	CreatedAt         string  `json:"created_at"`
+	ThreadCount       int64   `json:"thread_count,omitempty"`        // threaded lists: notifications in the thread
+	ThreadUnreadCount int64   `json:"thread_unread_count,omitempty"` // threaded lists: unread ones among them
 }
 
 type NotificationListResponse struct {
@@ -20,6 +22,86 @@ type NotificationListResponse struct {
 	Limit  int                    `json:"limit"`
 }
 
+// NotificationBulkRequest marks read, archives or deletes every notification of the caller that matches all
+// given criteria; at least one is required. With threads, ids also select the rest of their threads.
+type NotificationBulkRequest struct {
+	Action            string   `json:"action" binding:"required"` // read | archive | delete
+	IDs               []string `json:"ids"`
+	Threads           bool     `json:"threads"`
+	Type              string   `json:"type"`
+	RelatedEntityType string   `json:"related_entity_type"`
+	RelatedEntityID   string   `json:"related_entity_id"`
+	Read              *bool    `json:"read"`
+	Archived          *bool    `json:"archived"`
+	Before            string   `json:"before"` // RFC 3339 or YYYY-MM-DD; created before
+}
+
+type NotificationBulkResponse struct {
+	Count int64 `json:"count"`
+}
+
 type NotificationUnreadCountResponse struct {
 	Count int64 `json:"count"`
 }
+
+type NotificationEmailSettingsResponse struct {
+	Frequency    string `json:"frequency"`     // immediate | daily | weekly | off
+	EmailEnabled bool   `json:"email_enabled"` // false when the server has no SMTP relay configured
+}
+
+type NotificationEmailSettingsRequest struct {
+	Frequency string `json:"frequency" binding:"required"`
+}
+
+// NotificationPreferenceResponse is the effective setting of one notification type on one channel.
+type NotificationPreferenceResponse struct {
+	Type      string `json:"type"`
+	Channel   string `json:"channel"`
+	Enabled   bool   `json:"enabled"`
+	Mandatory bool   `json:"mandatory"` // set by the organization; cannot be turned off
+	Source    string `json:"source"`    // user | default
+}
+
+type NotificationPreferencesResponse struct {
+	Items    []NotificationPreferenceResponse `json:"items"`
+	Types    []string                         `json:"types"`
+	Channels []string                         `json:"channels"`
+}
+
+// NotificationPreferenceUpdate sets one preference; a null enabled removes it, so the default applies again.
+type NotificationPreferenceUpdate struct {
+	Type    string `json:"type" binding:"required"`
+	Channel string `json:"channel" binding:"required"`
+	Enabled *bool  `json:"enabled"`
+}
+
+type NotificationPreferencesRequest struct {
+	Preferences []NotificationPreferenceUpdate `json:"preferences" binding:"required,dive"`
+}
+
+type NotificationDefaultResponse struct {
+	Type      string  `json:"type"`
+	Channel   string  `json:"channel"`
+	Enabled   bool    `json:"enabled"`
+	Mandatory bool    `json:"mandatory"`
+	UpdatedBy string  `json:"updated_by,omitempty"`
+	UpdatedAt *string `json:"updated_at,omitempty"` // nil for built-in defaults nobody has changed
+}
+
+type NotificationDefaultsResponse struct {
+	Items    []NotificationDefaultResponse `json:"items"`
+	Types    []string                      `json:"types"`
+	Channels []string                      `json:"channels"`
+}
+
+// NotificationDefaultUpdate sets the organization default of one type on one channel. Mandatory implies enabled.
+type NotificationDefaultUpdate struct {
+	Type      string `json:"type" binding:"required"`
+	Channel   string `json:"channel" binding:"required"`
+	Enabled   bool   `json:"enabled"`
+	Mandatory bool   `json:"mandatory"`
+}
+
+type NotificationDefaultsRequest struct {
+	Defaults []NotificationDefaultUpdate `json:"defaults" binding:"required,dive"`
+}
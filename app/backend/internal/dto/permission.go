@@ -0,0 +1,23 @@
+package dto
+
+type PermissionGrant struct {
+	Permission string `json:"permission"`
+	Scope      string `json:"scope"` // any | own
+}
+
+type PermissionDefinition struct {
+	Permission  string `json:"permission"`
+	Description string `json:"description"`
+	Ownable     bool   `json:"ownable"`
+}
+
+type PermissionOverviewResponse struct {
+	Catalog []PermissionDefinition       `json:"catalog"`
+	Roles   map[string][]PermissionGrant `json:"roles"`
+}
+
+type EffectivePermissionsResponse struct {
+	UserID      string            `json:"user_id"`
+	Role        string            `json:"role"`
+	Permissions []PermissionGrant `json:"permissions"`
+}
@@ -0,0 +1,21 @@
+package dto
+
+type ProductMemberAddRequest struct {
+	UserID string `json:"user_id" binding:"required"`
+	Role   string `json:"role" binding:"required"` // co_owner | editor | viewer
+}
+
+type ProductMemberUpdateRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+type ProductMemberResponse struct {
+	ID        string  `json:"id"`
+	ProductID string  `json:"product_id"`
+	UserID    string  `json:"user_id"`
+	UserName  string  `json:"user_name,omitempty"`
+	UserEmail string  `json:"user_email,omitempty"`
+	Role      string  `json:"role"`
+	AddedBy   *string `json:"added_by,omitempty"`
+	CreatedAt string  `json:"created_at"`
+}
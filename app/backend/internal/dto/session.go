@@ -0,0 +1,17 @@
+package dto
+
+type SessionResponse struct {
+	ID         string `json:"id"`
+	UserID     string `json:"user_id"`
+	IPAddress  string `json:"ip_address"`
+	UserAgent  string `json:"user_agent"`
+	Device     string `json:"device"`
+	CreatedAt  string `json:"created_at"`
+	LastSeenAt string `json:"last_seen_at"`
+	ExpiresAt  string `json:"expires_at"`
+	Current    bool   `json:"current"` // the session the request was made with
+}
+
+type SessionRevokeAllResponse struct {
+	Revoked int `json:"revoked"`
+}
@@ -0,0 +1,14 @@
+package dto
+
+type WatchRequest struct {
+	EntityType string `json:"entity_type" binding:"required"` // product, product_version or group
+	EntityID   string `json:"entity_id" binding:"required"`
+}
+
+type WatchResponse struct {
+	EntityType string  `json:"entity_type"`
+	EntityID   string  `json:"entity_id"`
+	Name       string  `json:"name"`                 // product name, "<product> <version>" or group name; empty when the entity is gone
+	ProductID  *string `json:"product_id,omitempty"` // the version's product
+	CreatedAt  string  `json:"created_at"`
+}
@@ -0,0 +1,55 @@
+package dto
+
+// WebhookRequest creates or replaces a webhook subscription. Empty entity_types or actions match any; an
+// empty secret on create generates one, on update keeps the current one.
+type WebhookRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	URL         string   `json:"url" binding:"required"`
+	Format      string   `json:"format"` // json (default) | slack | teams
+	Secret      string   `json:"secret"`
+	EntityTypes []string `json:"entity_types"`
+	Actions     []string `json:"actions"`
+	ProductID   string   `json:"product_id"`
+	GroupID     string   `json:"group_id"`
+	Active      *bool    `json:"active"` // default true
+}
+
+type WebhookResponse struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	URL         string   `json:"url"`
+	Format      string   `json:"format"`
+	Secret      string   `json:"secret,omitempty"` // only in the create response
+	EntityTypes []string `json:"entity_types"`
+	Actions     []string `json:"actions"`
+	ProductID   *string  `json:"product_id,omitempty"`
+	GroupID     *string  `json:"group_id,omitempty"`
+	Active      bool     `json:"active"`
+	CreatedBy   *string  `json:"created_by,omitempty"`
+	CreatedAt   string   `json:"created_at"`
+	UpdatedAt   string   `json:"updated_at"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID             string                   `json:"id"`
+	SubscriptionID string                   `json:"subscription_id"`
+	AuditLogID     string                   `json:"audit_log_id"`
+	Event          string                   `json:"event"`
+	Status         string                   `json:"status"` // pending | delivered | dead
+	Attempts       int                      `json:"attempts"`
+	NextAttemptAt  *string                  `json:"next_attempt_at,omitempty"` // pending only
+	LastStatusCode int                      `json:"last_status_code,omitempty"`
+	LastError      string                   `json:"last_error,omitempty"`
+	DeliveredAt    *string                  `json:"delivered_at,omitempty"`
+	CreatedAt      string                   `json:"created_at"`
+	Payload        map[string]interface{}   `json:"payload,omitempty"`     // single delivery only
+	AttemptLog     []WebhookAttemptResponse `json:"attempt_log,omitempty"` // single delivery only
+}
+
+type WebhookAttemptResponse struct {
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Response   string `json:"response,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+	CreatedAt  string `json:"created_at"`
+}
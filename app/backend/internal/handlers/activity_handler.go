@@ -3,22 +3,21 @@ package handlers
 import (
 	"net/http"
 	"strconv"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/rm/roadmap/backend/internal/dto"
 	"github.com/rm/roadmap/backend/internal/middleware"
-	"github.com/rm/roadmap/backend/internal/models"
 	"github.com/rm/roadmap/backend/internal/services"
 )
 
 type ActivityHandler struct {
 	activityService *services.ActivityService
+	sessionService  *services.SessionService
 }
 
-func NewActivityHandler(activityService *services.ActivityService) *ActivityHandler {
-	return &ActivityHandler{activityService: activityService}
+func NewActivityHandler(activityService *services.ActivityService, sessionService *services.SessionService) *ActivityHandler {
+	return &ActivityHandler{activityService: activityService, sessionService: sessionService}
 }
 
 func (h *ActivityHandler) getCaller(c *gin.Context) (uuid.UUID, string) {
@@ -53,29 +52,22 @@ func (h *ActivityHandler) List(c *gin.Context) {
 	if order != "asc" {
 		order = "desc"
 	}
-	var dateFrom, dateTo *time.Time
-	if df := c.Query("date_from"); df != "" {
-		if t, err := time.Parse("2006-01-02", df); err == nil {
-			dateFrom = &t
-		}
-	}
-	if dt := c.Query("date_to"); dt != "" {
-		if t, err := time.Parse("2006-01-02", dt); err == nil {
-			t = t.Add(24*time.Hour - time.Nanosecond)
-			dateTo = &t
-		}
-	}
-	// Non-admin users must provide a date range (restrict to their activity within dates).
-	if !models.Role(callerRole).IsAdminOrAbove() {
+	dateFrom, dateTo := parseDateRange(c)
+	// Callers limited to their own activity must provide a date range.
+	if !h.activityService.CanReadAll(c.Request.Context(), callerID, callerRole) {
 		if dateFrom == nil || dateTo == nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "date_from and date_to are required for user activity"})
+			respondError(c, http.StatusBadRequest, errActivityRangeRequired)
 			return
 		}
 	}
 
 	list, total, err := h.activityService.List(c.Request.Context(), limit, offset, action, dateFrom, dateTo, sortBy, order, callerID, callerRole)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if err == services.ErrForbidden {
+			respondError(c, http.StatusForbidden, err)
+			return
+		}
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusOK, dto.PageResult[dto.ActivityLogResponse]{
@@ -86,7 +78,48 @@ func (h *ActivityHandler) List(c *gin.Context) {
 	})
 }
 
-// Logout logs the logout activity and returns 200. Requires Auth. Token invalidation is client-side.
+var activityCSVHeader = []string{"id", "timestamp", "user_id", "user_email", "action", "entity_type", "entity_id", "details", "ip_address", "user_agent"}
+
+// Export streams the activity logs matching the List filters (action, date_from, date_to) oldest first as
+// NDJSON (default) or CSV (?format=csv). Callers limited to their own activity must give a date range.
+func (h *ActivityHandler) Export(c *gin.Context) {
+	format, ok := exportFormat(c)
+	if !ok {
+		respondError(c, http.StatusBadRequest, errInvalidExportFormat)
+		return
+	}
+	callerID, callerRole := h.getCaller(c)
+	dateFrom, dateTo := parseDateRange(c)
+	if !h.activityService.CanReadAll(c.Request.Context(), callerID, callerRole) {
+		if dateFrom == nil || dateTo == nil {
+			respondError(c, http.StatusBadRequest, errActivityRangeRequired)
+			return
+		}
+	}
+
+	w := newExportStream(c, format, "activity-logs", activityCSVHeader)
+	err := h.activityService.Export(c.Request.Context(), c.Query("action"), dateFrom, dateTo, callerID, callerRole, func(r *dto.ActivityLogResponse) error {
+		var userID, email string
+		if r.UserID != nil {
+			userID = *r.UserID
+		}
+		if r.User != nil {
+			email = r.User.Email
+		}
+		return w.write(r, []string{r.ID, r.Timestamp, userID, email, r.Action, r.EntityType, r.EntityID, r.Details, r.IPAddress, r.UserAgent})
+	})
+	if err != nil && !w.started {
+		if err == services.ErrForbidden {
+			respondError(c, http.StatusForbidden, err)
+			return
+		}
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	w.finish(err)
+}
+
+// Logout revokes the current session, logs the logout activity and returns 200. Requires Auth.
 // Every logout request is logged (with user id when authenticated, otherwise with nil user for audit).
 func (h *ActivityHandler) Logout(c *gin.Context) {
 	meta := middleware.GetAuditMeta(c)
@@ -98,6 +131,14 @@ func (h *ActivityHandler) Logout(c *gin.Context) {
 			}
 		}
 	}
+	if sidVal, _ := c.Get(middleware.SessionIDKey); sidVal != nil && userID != nil {
+		if sid, err := uuid.Parse(sidVal.(string)); err == nil {
+			if err := h.sessionService.Revoke(c.Request.Context(), *userID, sid, meta); err != nil && err != services.ErrSessionNotFound {
+				respondError(c, http.StatusInternalServerError, err)
+				return
+			}
+		}
+	}
 	h.activityService.Log(c.Request.Context(), services.ActivityEntry{
 		UserID:    userID,
 		Action:    "logout",
@@ -1,25 +1,29 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
-	"time"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/rm/roadmap/backend/internal/dto"
 	"github.com/rm/roadmap/backend/internal/middleware"
 	"github.com/rm/roadmap/backend/internal/models"
+	"github.com/rm/roadmap/backend/internal/repositories"
 	"github.com/rm/roadmap/backend/internal/services"
+	"gorm.io/gorm"
 )
 
 type AuditHandler struct {
-	auditService *services.AuditService
-	authService  *services.AuthService
+	auditService   *services.AuditService
+	restoreService *services.RestoreService
+	authService    *services.AuthService
 }
 
-func NewAuditHandler(auditService *services.AuditService, authService *services.AuthService) *AuditHandler {
-	return &AuditHandler{auditService: auditService, authService: authService}
+func NewAuditHandler(auditService *services.AuditService, restoreService *services.RestoreService, authService *services.AuthService) *AuditHandler {
+	return &AuditHandler{auditService: auditService, restoreService: restoreService, authService: authService}
 }
 
 func (h *AuditHandler) getCaller(c *gin.Context) (uuid.UUID, string) {
@@ -38,6 +42,33 @@ func (h *AuditHandler) getCaller(c *gin.Context) (uuid.UUID, string) {
 	return id, roleStr
 }
 
+// auditFilter reads the search filters shared by List and Export: entity_type, action, entity_id, user (ID,
+// or part of a name or email), ip, trace_id, date_from, date_to, archived (default false), q (free text) and
+// any number of where data filters (see services.ParseAuditDataPredicate).
+func auditFilter(c *gin.Context) (repositories.AuditFilter, error) {
+	f := repositories.AuditFilter{
+		EntityType: c.Query("entity_type"),
+		Action:     c.Query("action"),
+		EntityID:   c.Query("entity_id"),
+		User:       c.Query("user"),
+		IPAddress:  c.Query("ip"),
+		TraceID:    c.Query("trace_id"),
+		Text:       strings.TrimSpace(c.Query("q")),
+	}
+	// Default to main (non-archived) logs when archived param is omitted
+	archived := c.Query("archived") == "true"
+	f.Archived = &archived
+	f.DateFrom, f.DateTo = parseDateRange(c)
+	for _, w := range c.QueryArray("where") {
+		p, err := services.ParseAuditDataPredicate(w)
+		if err != nil {
+			return f, err
+		}
+		f.Data = append(f.Data, p)
+	}
+	return f, nil
+}
+
 func (h *AuditHandler) List(c *gin.Context) {
 	callerID, callerRole := h.getCaller(c)
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
@@ -48,35 +79,24 @@ func (h *AuditHandler) List(c *gin.Context) {
 	if offset < 0 {
 		offset = 0
 	}
-	entityType := c.Query("entity_type")
-	action := c.Query("action")
 	sortBy := c.DefaultQuery("sort_by", "timestamp")
 	order := c.DefaultQuery("order", "desc")
 	if order != "asc" {
 		order = "desc"
 	}
-	// Default to main (non-archived) logs when archived param is omitted
-	archived := new(bool)
-	*archived = false
-	if a := c.Query("archived"); a == "true" {
-		*archived = true
-	}
-	var dateFrom, dateTo *time.Time
-	if df := c.Query("date_from"); df != "" {
-		if t, err := time.Parse("2006-01-02", df); err == nil {
-			dateFrom = &t
-		}
-	}
-	if dt := c.Query("date_to"); dt != "" {
-		if t, err := time.Parse("2006-01-02", dt); err == nil {
-			t = t.Add(24*time.Hour - time.Nanosecond) // end of day
-			dateTo = &t
-		}
+	f, err := auditFilter(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
 	}
 
-	list, total, err := h.auditService.List(c.Request.Context(), limit, offset, entityType, action, dateFrom, dateTo, archived, sortBy, order, callerID, callerRole)
+	list, total, err := h.auditService.List(c.Request.Context(), limit, offset, f, sortBy, order, callerID, callerRole)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if err == services.ErrForbidden {
+			respondError(c, http.StatusForbidden, err)
+			return
+		}
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusOK, dto.PageResult[dto.AuditLogResponse]{
@@ -87,6 +107,54 @@ func (h *AuditHandler) List(c *gin.Context) {
 	})
 }
 
+var auditCSVHeader = []string{"id", "timestamp", "user_id", "user_email", "action", "entity_type", "entity_id", "ip_address", "user_agent", "trace_id", "old_data", "new_data"}
+
+// Export streams the audit logs matching the List filters oldest first as NDJSON (default) or CSV (?format=csv), in the caller's audit scope.
+func (h *AuditHandler) Export(c *gin.Context) {
+	format, ok := exportFormat(c)
+	if !ok {
+		respondError(c, http.StatusBadRequest, errInvalidExportFormat)
+		return
+	}
+	callerID, callerRole := h.getCaller(c)
+	f, err := auditFilter(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	w := newExportStream(c, format, "audit-logs", auditCSVHeader)
+	err = h.auditService.Export(c.Request.Context(), f, callerID, callerRole, func(r *dto.AuditLogResponse) error {
+		return w.write(r, auditCSVRow(r))
+	})
+	if err != nil && !w.started {
+		if err == services.ErrForbidden {
+			respondError(c, http.StatusForbidden, err)
+			return
+		}
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	w.finish(err)
+}
+
+func auditCSVRow(r *dto.AuditLogResponse) []string {
+	var userID, email, oldData, newData string
+	if r.UserID != nil {
+		userID = *r.UserID
+	}
+	if r.User != nil {
+		email = r.User.Email
+	}
+	if r.OldData != nil {
+		oldData = jsonString(r.OldData)
+	}
+	if r.NewData != nil {
+		newData = jsonString(r.NewData)
+	}
+	return []string{r.ID, r.Timestamp, userID, email, r.Action, r.EntityType, r.EntityID, r.IPAddress, r.UserAgent, r.TraceID, oldData, newData}
+}
+
 type archiveRequest struct {
 	IDs []string `json:"ids"`
 }
@@ -94,7 +162,7 @@ type archiveRequest struct {
 func (h *AuditHandler) Archive(c *gin.Context) {
 	var req archiveRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body: ids array required"})
+		respondError(c, http.StatusBadRequest, errIDsRequired)
 		return
 	}
 	if len(req.IDs) == 0 {
@@ -105,13 +173,13 @@ func (h *AuditHandler) Archive(c *gin.Context) {
 	for _, s := range req.IDs {
 		id, err := uuid.Parse(s)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id: " + s})
+			respondError(c, http.StatusBadRequest, invalidParam("id: "+s))
 			return
 		}
 		ids = append(ids, id)
 	}
 	if err := h.auditService.Archive(c.Request.Context(), ids); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"archived": len(ids)})
@@ -125,34 +193,101 @@ type deleteArchivedRequest struct {
 func (h *AuditHandler) DeleteArchived(c *gin.Context) {
 	var req deleteArchivedRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "ids and password are required"})
+		respondError(c, http.StatusBadRequest, errIDsAndPasswordRequired)
 		return
 	}
 	if len(req.IDs) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "ids cannot be empty"})
-		return
-	}
-	callerID, callerRole := h.getCaller(c)
-	if !models.Role(callerRole).IsAdminOrAbove() {
-		c.JSON(http.StatusForbidden, gin.H{"error": "only admins can delete archived logs"})
+		respondError(c, http.StatusBadRequest, errIDsRequired)
 		return
 	}
+	// audit:purge is enforced on the route; the password re-check guards against a hijacked session.
+	callerID, _ := h.getCaller(c)
 	if err := h.authService.VerifyPassword(callerID, req.Password); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid password"})
+		respondError(c, http.StatusUnauthorized, errInvalidPassword)
 		return
 	}
 	ids := make([]uuid.UUID, 0, len(req.IDs))
 	for _, s := range req.IDs {
 		id, err := uuid.Parse(s)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id: " + s})
+			respondError(c, http.StatusBadRequest, invalidParam("id: "+s))
 			return
 		}
 		ids = append(ids, id)
 	}
-	if err := h.auditService.DeleteArchived(c.Request.Context(), ids); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	n, err := h.auditService.DeleteArchived(c.Request.Context(), ids, middleware.GetAuditMeta(c))
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": n})
+}
+
+// Verify walks the audit hash chain and reports gaps, modified rows and invalid tombstones.
+func (h *AuditHandler) Verify(c *gin.Context) {
+	res, err := h.auditService.VerifyChain(c.Request.Context())
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// EntityHistory returns the chronological change timeline of one entity, e.g. /audit-logs/entity/product/:id.
+func (h *AuditHandler) EntityHistory(c *gin.Context) {
+	callerID, callerRole := h.getCaller(c)
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit < 1 || limit > 500 {
+		limit = 100
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	resp, err := h.auditService.EntityHistory(c.Request.Context(), c.Param("type"), c.Param("id"), limit, offset, callerID, callerRole)
+	if err != nil {
+		if err == services.ErrForbidden {
+			respondError(c, http.StatusForbidden, err)
+			return
+		}
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Restore reverts the entity of an audit entry to the entry's snapshot, undeleting it if needed.
+func (h *AuditHandler) Restore(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, invalidParam("id"))
+		return
+	}
+	var req dto.AuditRestoreRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, err)
+			return
+		}
+	}
+	callerID, callerRole := h.getCaller(c)
+	resp, err := h.restoreService.Restore(c.Request.Context(), id, req, callerID, models.Role(callerRole), middleware.GetAuditMeta(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrForbidden):
+			respondError(c, http.StatusForbidden, err)
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			respondError(c, http.StatusNotFound, errAuditEntryNotFound)
+		case errors.Is(err, services.ErrRestoreConflict), errors.Is(err, services.ErrRestoreParentGone):
+			respondError(c, http.StatusConflict, err)
+		case errors.Is(err, services.ErrRestoreUnsupported), errors.Is(err, services.ErrRestoreInvalidState),
+			errors.Is(err, services.ErrRestoreNoSnapshot), errors.Is(err, services.ErrEndDateBeforeStart),
+			errors.Is(err, services.ErrInvalidOwnerID):
+			respondError(c, http.StatusUnprocessableEntity, err)
+		default:
+			respondError(c, http.StatusInternalServerError, err)
+		}
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"deleted": len(ids)})
+	c.JSON(http.StatusOK, resp)
 }
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/dto"
+	"github.com/rm/roadmap/backend/internal/middleware"
+	"github.com/rm/roadmap/backend/internal/services"
+)
+
+type AuditRetentionHandler struct {
+	retentionService *services.AuditRetentionService
+}
+
+func NewAuditRetentionHandler(retentionService *services.AuditRetentionService) *AuditRetentionHandler {
+	return &AuditRetentionHandler{retentionService: retentionService}
+}
+
+func (h *AuditRetentionHandler) ListPolicies(c *gin.Context) {
+	list, err := h.retentionService.ListPolicies(c.Request.Context())
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": list})
+}
+
+func (h *AuditRetentionHandler) CreatePolicy(c *gin.Context) {
+	var req dto.AuditRetentionPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	resp, err := h.retentionService.CreatePolicy(c.Request.Context(), req, middleware.GetAuditMeta(c))
+	if err != nil {
+		retentionError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+func (h *AuditRetentionHandler) UpdatePolicy(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, invalidParam("id"))
+		return
+	}
+	var req dto.AuditRetentionPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	resp, err := h.retentionService.UpdatePolicy(c.Request.Context(), id, req, middleware.GetAuditMeta(c))
+	if err != nil {
+		retentionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *AuditRetentionHandler) DeletePolicy(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, invalidParam("id"))
+		return
+	}
+	if err := h.retentionService.DeletePolicy(c.Request.Context(), id, middleware.GetAuditMeta(c)); err != nil {
+		retentionError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AuditRetentionHandler) ListHolds(c *gin.Context) {
+	list, err := h.retentionService.ListHolds(c.Request.Context())
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": list})
+}
+
+func (h *AuditRetentionHandler) CreateHold(c *gin.Context) {
+	var req dto.AuditLegalHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	resp, err := h.retentionService.CreateHold(c.Request.Context(), req, middleware.GetAuditMeta(c))
+	if err != nil {
+		retentionError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+func (h *AuditRetentionHandler) DeleteHold(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, invalidParam("id"))
+		return
+	}
+	if err := h.retentionService.DeleteHold(c.Request.Context(), id, middleware.GetAuditMeta(c)); err != nil {
+		retentionError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Preview reports what a retention run would archive and purge now, per policy, without changing anything.
+func (h *AuditRetentionHandler) Preview(c *gin.Context) {
+	res, err := h.retentionService.Run(c.Request.Context(), true, middleware.GetAuditMeta(c))
+	if err != nil {
+		retentionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// Run applies the retention policies now. ?dry_run=true behaves like Preview.
+func (h *AuditRetentionHandler) Run(c *gin.Context) {
+	dryRun := c.Query("dry_run") == "true"
+	res, err := h.retentionService.Run(c.Request.Context(), dryRun, middleware.GetAuditMeta(c))
+	if err != nil {
+		retentionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func retentionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrRetentionPolicyNotFound), errors.Is(err, services.ErrLegalHoldNotFound):
+		respondError(c, http.StatusNotFound, err)
+	case errors.Is(err, services.ErrRetentionPolicyExists), errors.Is(err, services.ErrLegalHoldExists),
+		errors.Is(err, services.ErrRetentionAlreadyRunning):
+		respondError(c, http.StatusConflict, err)
+	case errors.Is(err, services.ErrRetentionInvalid):
+		respondError(c, http.StatusUnprocessableEntity, err)
+	default:
+		respondError(c, http.StatusInternalServerError, err)
+	}
+}
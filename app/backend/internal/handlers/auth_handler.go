@@ -1,11 +1,15 @@
 package handlers
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/rm/roadmap/backend/internal/dto"
+	"github.com/rm/roadmap/backend/internal/middleware"
 	"github.com/rm/roadmap/backend/internal/services"
 	"go.uber.org/zap"
 )
@@ -13,29 +17,40 @@ import (
 type AuthHandler struct {
 	authService     *services.AuthService
 	activityService *services.ActivityService
+	loginGuard      *services.LoginGuardService
 	log             *zap.Logger
 }
 
-func NewAuthHandler(authService *services.AuthService, activityService *services.ActivityService, log *zap.Logger) *AuthHandler {
+func NewAuthHandler(authService *services.AuthService, activityService *services.ActivityService, loginGuard *services.LoginGuardService, log *zap.Logger) *AuthHandler {
 	if log == nil {
 		log = zap.NewNop()
 	}
-	return &AuthHandler{authService: authService, activityService: activityService, log: log}
+	return &AuthHandler{authService: authService, activityService: activityService, loginGuard: loginGuard, log: log}
 }
 
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req dto.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
-	resp, err := h.authService.Login(req)
+	resp, err := h.authService.Login(c.Request.Context(), req, c.ClientIP(), c.Request.UserAgent())
 	if err != nil {
 		// Log every failed login attempt for audit
 		details := "error"
 		if err == services.ErrInvalidCredentials {
 			details = "invalid credentials"
 		}
+		if err == services.ErrAccountDisabled {
+			details = "account deactivated"
+		}
+		if errors.Is(err, services.ErrDirectoryDown) {
+			details = "directory unavailable"
+		}
+		var locked *services.LoginLockedError
+		if errors.As(err, &locked) {
+			details = "locked"
+		}
 		h.activityService.Log(c.Request.Context(), services.ActivityEntry{
 			UserID:    nil,
 			Action:    "login_failed",
@@ -44,11 +59,26 @@ func (h *AuthHandler) Login(c *gin.Context) {
 			UserAgent: c.Request.UserAgent(),
 		})
 		if err == services.ErrInvalidCredentials {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid email or password"})
+			respondError(c, http.StatusUnauthorized, services.ErrInvalidCredentials)
+			return
+		}
+		if err == services.ErrAccountDisabled {
+			respondError(c, http.StatusForbidden, err)
+			return
+		}
+		if errors.Is(err, services.ErrDirectoryDown) {
+			h.log.Warn("login: directory unavailable", zap.String("email", req.Email), zap.Error(err))
+			respondError(c, http.StatusServiceUnavailable, services.ErrDirectoryDown)
+			return
+		}
+		// Same response whether or not the email exists; lockouts are tracked for unknown emails too.
+		if locked != nil {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+			respondError(c, http.StatusTooManyRequests, services.ErrLoginLocked)
 			return
 		}
 		h.log.Error("login failed", zap.String("email", req.Email), zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	// Log every successful login
@@ -65,20 +95,20 @@ func (h *AuthHandler) Login(c *gin.Context) {
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req dto.RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
 	if req.Role == "" {
 		req.Role = "user"
 	}
-	resp, err := h.authService.Register(req)
+	resp, err := h.authService.Register(c.Request.Context(), req, c.ClientIP(), c.Request.UserAgent())
 	if err != nil {
 		if err == services.ErrEmailExists {
-			c.JSON(http.StatusConflict, gin.H{"error": "email already registered"})
+			respondError(c, http.StatusConflict, services.ErrEmailExists)
 			return
 		}
 		h.log.Error("register failed", zap.String("email", req.Email), zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusCreated, resp)
@@ -91,13 +121,51 @@ type RefreshRequest struct {
 func (h *AuthHandler) Refresh(c *gin.Context) {
 	var req RefreshRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
-	resp, err := h.authService.Refresh(req.RefreshToken)
+	resp, err := h.authService.Refresh(c.Request.Context(), req.RefreshToken)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+		respondError(c, http.StatusUnauthorized, errInvalidRefreshToken)
 		return
 	}
 	c.JSON(http.StatusOK, resp)
 }
+
+// ListLockouts returns accounts and client IPs that are currently locked out of login. Admin only.
+func (h *AuthHandler) ListLockouts(c *gin.Context) {
+	list, err := h.loginGuard.ListLocked(c.Request.Context())
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// DeleteLockout lifts a single account or IP lockout. Admin only.
+func (h *AuthHandler) DeleteLockout(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, invalidParam("id"))
+		return
+	}
+	if err := h.loginGuard.Unlock(c.Request.Context(), id, middleware.GetAuditMeta(c)); err != nil {
+		respondError(c, http.StatusNotFound, errLockoutNotFound)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// UnlockUser clears the login lockout for a user's account. Admin only.
+func (h *AuthHandler) UnlockUser(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, invalidParam("id"))
+		return
+	}
+	if err := h.loginGuard.UnlockUser(c.Request.Context(), id, middleware.GetAuditMeta(c)); err != nil {
+		respondError(c, http.StatusNotFound, services.ErrUserNotFound)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
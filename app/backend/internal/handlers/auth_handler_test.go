@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rm/roadmap/backend/internal/models"
+	"github.com/rm/roadmap/backend/internal/repositories"
+	"github.com/rm/roadmap/backend/internal/services"
+	"gorm.io/gorm"
+)
+
+// lockedRows serves login_lockouts rows keyed by scope and key; the guard only reads them before a login.
+type lockedRows struct {
+	repositories.LoginLockoutRepository
+	rows map[string]time.Time
+}
+
+func (l lockedRows) Get(_ context.Context, scope, key string) (*models.LoginLockout, error) {
+	until, ok := l.rows[scope+"/"+key]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &models.LoginLockout{Scope: scope, Key: key, LockedUntil: &until}, nil
+}
+
+// discardOutbox drops the activity entries the handler writes.
+type discardOutbox struct{ repositories.OutboxRepository }
+
+func (discardOutbox) Enqueue(context.Context, *models.OutboxEvent) error { return nil }
+
+func TestLoginLocked(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now := time.Now()
+	for _, tc := range []struct {
+		name       string
+		rows       map[string]time.Time
+		retryAfter string
+	}{
+		{"account", map[string]time.Time{models.LockoutScopeAccount + "/ann@example.com": now.Add(90 * time.Second)}, "90"},
+		{"ip", map[string]time.Time{models.LockoutScopeIP + "/192.0.2.1": now.Add(1500 * time.Millisecond)}, "2"},
+		{"longest lock wins", map[string]time.Time{
+			models.LockoutScopeAccount + "/ann@example.com": now.Add(time.Minute),
+			models.LockoutScopeIP + "/192.0.2.1":            now.Add(10 * time.Minute),
+		}, "600"},
+	} {
+		guard := services.NewLoginGuardService(lockedRows{rows: tc.rows}, nil, services.LoginGuardConfig{MaxFailedAttempts: 5, IPMaxFailedAttempts: 20}, nil, nil, nil, nil)
+		activity := services.NewActivityService(nil, services.NewOutboxService(discardOutbox{}, nil, nil, nil, services.OutboxConfig{}, nil), nil, nil, nil)
+		h := NewAuthHandler(services.NewAuthService(nil, nil, guard, nil, nil), activity, guard, nil)
+
+		r := gin.New()
+		r.POST("/login", h.Login)
+		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"email":"Ann@example.com","password":"guess"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = "192.0.2.1:40000"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		var body struct{ Code string }
+		_ = json.Unmarshal(w.Body.Bytes(), &body)
+		if w.Code != http.StatusTooManyRequests || body.Code != "login_locked" {
+			t.Errorf("%s: %d %s, want 429 login_locked", tc.name, w.Code, w.Body)
+		}
+		if got := w.Header().Get("Retry-After"); got != tc.retryAfter {
+			t.Errorf("%s: Retry-After %q, want %q", tc.name, got, tc.retryAfter)
+		}
+	}
+}
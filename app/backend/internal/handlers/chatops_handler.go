@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rm/roadmap/backend/internal/chatops"
+	"github.com/rm/roadmap/backend/internal/services"
+)
+
+// chatOpsBodyLimit bounds the command requests read; Slack and Teams send a few kilobytes.
+const chatOpsBodyLimit = 256 << 10
+
+// ChatOpsHandler serves the Slack slash command and the Teams outgoing webhook. Requests are authenticated
+// by their signature rather than a session, so the routes are public.
+type ChatOpsHandler struct {
+	svc         *services.ChatOpsService
+	slackSecret string
+	teamsToken  string
+}
+
+func NewChatOpsHandler(svc *services.ChatOpsService, slackSecret, teamsToken string) *ChatOpsHandler {
+	return &ChatOpsHandler{svc: svc, slackSecret: slackSecret, teamsToken: teamsToken}
+}
+
+// SlackCommand answers a /roadmap slash command with a Block Kit message.
+func (h *ChatOpsHandler) SlackCommand(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, chatOpsBodyLimit))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	err = chatops.VerifySlack(h.slackSecret, c.GetHeader(chatops.SlackTimestampHeader), c.GetHeader(chatops.SlackSignatureHeader), body, time.Now())
+	if err != nil {
+		respondError(c, http.StatusUnauthorized, err)
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, errInvalidBody)
+		return
+	}
+	m, public, err := h.answer(c, form.Get("text"))
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, chatops.SlackCommandResponse(m, public))
+}
+
+// TeamsCommand answers a message that mentions the Teams outgoing webhook with an Adaptive Card.
+func (h *ChatOpsHandler) TeamsCommand(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, chatOpsBodyLimit))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := chatops.VerifyTeams(h.teamsToken, c.GetHeader(chatops.TeamsSignatureHeader), body); err != nil {
+		respondError(c, http.StatusUnauthorized, err)
+		return
+	}
+	var activity struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(body, &activity); err != nil {
+		respondError(c, http.StatusBadRequest, errInvalidBody)
+		return
+	}
+	m, _, err := h.answer(c, activity.Text)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, chatops.TeamsPayload(m))
+}
+
+// answer parses and runs a command; an unknown one is answered with the usage.
+func (h *ChatOpsHandler) answer(c *gin.Context, text string) (chatops.Message, bool, error) {
+	cmd, err := chatops.ParseCommand(text)
+	if err != nil {
+		return chatops.Message{
+			Title: "Unknown command",
+			Text:  fmt.Sprintf("I don't know how to answer that. Try:\n%s", chatops.Usage),
+		}, false, nil
+	}
+	return h.svc.Answer(c.Request.Context(), cmd, time.Now())
+}
@@ -27,14 +27,14 @@ func (h *DependencyHandler) List(c *gin.Context) {
 	if productID != nil {
 		p, err := uuid.Parse(*productID)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid product_id"})
+			respondError(c, http.StatusBadRequest, invalidParam("product_id"))
 			return
 		}
 		parsed = &p
 	}
 	list, err := h.dependencyService.List(parsed)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusOK, list)
@@ -43,13 +43,13 @@ func (h *DependencyHandler) List(c *gin.Context) {
 func (h *DependencyHandler) Create(c *gin.Context) {
 	var req dto.DependencyCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
 	meta := middleware.GetAuditMeta(c)
 	resp, err := h.dependencyService.Create(c.Request.Context(), req, meta)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusCreated, resp)
@@ -58,12 +58,12 @@ func (h *DependencyHandler) Create(c *gin.Context) {
 func (h *DependencyHandler) Delete(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		respondError(c, http.StatusBadRequest, invalidParam("id"))
 		return
 	}
 	meta := middleware.GetAuditMeta(c)
 	if err := h.dependencyService.Delete(c.Request.Context(), id, meta); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.Status(http.StatusNoContent)
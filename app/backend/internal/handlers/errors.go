@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rm/roadmap/backend/internal/auth"
+	"github.com/rm/roadmap/backend/internal/authz"
+	"github.com/rm/roadmap/backend/internal/i18n"
+	"github.com/rm/roadmap/backend/internal/middleware"
+	"github.com/rm/roadmap/backend/internal/repositories"
+	"github.com/rm/roadmap/backend/internal/services"
+	"gorm.io/gorm"
+)
+
+// Errors the handlers answer with themselves.
+var (
+	errSuperadminRoleOnly      = errors.New("only superadmin can set role to superadmin")
+	errSuperadminUserOnly      = errors.New("only superadmin can modify a superadmin user")
+	errOwnManager              = errors.New("user cannot be their own manager")
+	errOwnDottedLineManager    = errors.New("user cannot have themselves as dotted-line manager")
+	errDottedLineManagerExists = errors.New("already added")
+	errDeleteSelf              = errors.New("cannot delete your own user")
+	errDeleteSuperadmin        = errors.New("cannot delete a superadmin user")
+	errAdminOnly               = errors.New("admin only")
+	errMemberManageForbidden   = errors.New("only the product owner, a co-owner or an admin can manage members")
+	errProductNotActive        = errors.New("only active products can be edited")
+	errMilestoneEditForbidden  = errors.New("only product owner, co-owners, editors or admin can edit when product is active")
+	errVersionAddForbidden     = errors.New("only product owner, co-owners, editors or admin can add versions when product is active")
+	errVersionEditForbidden    = errors.New("only product owner, co-owners, editors or admin can modify versions when product is active")
+	errInvalidRefreshToken     = errors.New("invalid refresh token")
+	errInvalidPassword         = errors.New("invalid password")
+	errLockoutNotFound         = errors.New("lockout not found")
+	errAuditEntryNotFound      = errors.New("audit entry or entity not found")
+	errInvalidFromDate         = errors.New("invalid from_date (use YYYY-MM-DD)")
+	errInvalidToDate           = errors.New("invalid to_date (use YYYY-MM-DD)")
+	errActivityRangeRequired   = errors.New("date_from and date_to are required for user activity")
+	errInvalidExportFormat     = errors.New("format must be ndjson or csv")
+	errInvalidDeliveryStatus   = errors.New("status must be pending, delivered or dead")
+	errIDsRequired             = errors.New("ids array required")
+	errIDsAndPasswordRequired  = errors.New("ids and password are required")
+	errInvalidBody             = errors.New("invalid request body")
+	errUnsupportedLocale       = errors.New("unsupported locale")
+)
+
+// errorCodes maps errors to the stable code reported next to the message, so that clients can tell errors
+// apart without matching on the message, which is localized. The message for a code is "error.<code>" in
+// the catalogs. Errors not listed get a code from their HTTP status, such as "bad_request".
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{gorm.ErrRecordNotFound, "not_found"},
+	{authz.ErrForbidden, "forbidden"},
+	{authz.ErrUnknownRole, "unknown_role"},
+	{auth.ErrInvalidToken, "invalid_token"},
+	{repositories.ErrManagerHierarchyTooDeep, "manager_hierarchy_too_deep"},
+	{repositories.ErrManagerCycle, "manager_cycle"},
+
+	{services.ErrInvalidCredentials, "invalid_credentials"},
+	{services.ErrEmailExists, "email_exists"},
+	{services.ErrAccountDisabled, "account_disabled"},
+	{services.ErrDirectoryDown, "directory_unavailable"},
+	{services.ErrLoginLocked, "login_locked"},
+	{services.ErrSessionNotFound, "session_not_found"},
+	{services.ErrSessionRevoked, "session_revoked"},
+	{services.ErrUserNotFound, "user_not_found"},
+	{services.ErrProductNotFound, "product_not_found"},
+	{services.ErrInvalidOwnerID, "invalid_owner_id"},
+	{services.ErrProductHasVersions, "product_has_versions"},
+	{services.ErrProductMemberNotFound, "product_member_not_found"},
+	{services.ErrProductMemberExists, "product_member_exists"},
+	{services.ErrInvalidMemberRole, "invalid_member_role"},
+	{services.ErrMemberIsOwner, "member_is_owner"},
+	{services.ErrGroupNotFound, "group_not_found"},
+	{services.ErrGroupDescTooShort, "group_description_too_short"},
+	{services.ErrEndDateBeforeStart, "end_date_before_start"},
+	{services.ErrCertifyRequiresTestedSuccessfully, "certify_requires_tested_successfully"},
+	{services.ErrWatchInvalid, "watch_invalid"},
+	{services.ErrWatchEntityNotFound, "watch_entity_not_found"},
+	{services.ErrWatchNotFound, "watch_not_found"},
+	{services.ErrNotificationBulkInvalid, "notification_bulk_invalid"},
+	{services.ErrInvalidNotificationPreference, "invalid_notification_preference"},
+	{services.ErrNotificationPreferenceMandatory, "notification_preference_mandatory"},
+	{services.ErrInvalidEmailFrequency, "invalid_email_frequency"},
+	{services.ErrInvalidUnsubscribeToken, "invalid_unsubscribe_token"},
+	{services.ErrWebhookNotFound, "webhook_not_found"},
+	{services.ErrWebhookDeliveryNotFound, "webhook_delivery_not_found"},
+	{services.ErrWebhookInvalid, "webhook_invalid"},
+	{services.ErrWebhookDestination, "webhook_destination_forbidden"},
+	{services.ErrInvalidAuditPredicate, "invalid_audit_predicate"},
+	{services.ErrRestoreUnsupported, "restore_unsupported"},
+	{services.ErrRestoreInvalidState, "restore_invalid_state"},
+	{services.ErrRestoreNoSnapshot, "restore_no_snapshot"},
+	{services.ErrRestoreConflict, "restore_conflict"},
+	{services.ErrRestoreParentGone, "restore_parent_gone"},
+	{services.ErrRetentionInvalid, "retention_invalid"},
+	{services.ErrRetentionPolicyNotFound, "retention_policy_not_found"},
+	{services.ErrRetentionPolicyExists, "retention_policy_exists"},
+	{services.ErrLegalHoldNotFound, "legal_hold_not_found"},
+	{services.ErrLegalHoldExists, "legal_hold_exists"},
+	{services.ErrRetentionAlreadyRunning, "retention_already_running"},
+
+	{errSuperadminRoleOnly, "superadmin_role_only"},
+	{errSuperadminUserOnly, "superadmin_user_only"},
+	{errOwnManager, "own_manager"},
+	{errOwnDottedLineManager, "own_dotted_line_manager"},
+	{errDottedLineManagerExists, "dotted_line_manager_exists"},
+	{errDeleteSelf, "delete_self"},
+	{errDeleteSuperadmin, "delete_superadmin"},
+	{errAdminOnly, "admin_only"},
+	{errMemberManageForbidden, "member_manage_forbidden"},
+	{errProductNotActive, "product_not_active"},
+	{errMilestoneEditForbidden, "milestone_edit_forbidden"},
+	{errVersionAddForbidden, "version_add_forbidden"},
+	{errVersionEditForbidden, "version_edit_forbidden"},
+	{errInvalidRefreshToken, "invalid_refresh_token"},
+	{errInvalidPassword, "invalid_password"},
+	{errLockoutNotFound, "lockout_not_found"},
+	{errAuditEntryNotFound, "audit_entry_not_found"},
+	{errInvalidFromDate, "invalid_from_date"},
+	{errInvalidToDate, "invalid_to_date"},
+	{errActivityRangeRequired, "activity_range_required"},
+	{errInvalidExportFormat, "invalid_export_format"},
+	{errInvalidDeliveryStatus, "invalid_delivery_status"},
+	{errIDsRequired, "ids_required"},
+	{errIDsAndPasswordRequired, "ids_and_password_required"},
+	{errInvalidBody, "invalid_body"},
+	{errUnsupportedLocale, "unsupported_locale"},
+}
+
+// paramError reports a missing or malformed parameter, by name.
+type paramError struct {
+	name    string
+	missing bool
+}
+
+func (e *paramError) Error() string {
+	if e.missing {
+		return e.name + " required"
+	}
+	return "invalid " + e.name
+}
+
+func invalidParam(name string) error { return &paramError{name: name} }
+
+func missingParam(name string) error { return &paramError{name: name, missing: true} }
+
+// respondError answers with status and err as {"error": message, "code": code}. The message is localized
+// when err is a known error itself; a wrapped one keeps its own text, which carries the detail.
+func respondError(c *gin.Context, status int, err error) {
+	locale := middleware.GetLocale(c)
+	var pe *paramError
+	if errors.As(err, &pe) {
+		code := "invalid_parameter"
+		if pe.missing {
+			code = "missing_parameter"
+		}
+		c.JSON(status, gin.H{"error": i18n.T(locale, "error."+code, map[string]string{"name": pe.name}), "code": code})
+		return
+	}
+	for _, e := range errorCodes {
+		if !errors.Is(err, e.err) {
+			continue
+		}
+		msg := err.Error()
+		if err == e.err && i18n.Has("error."+e.code) {
+			msg = i18n.T(locale, "error."+e.code, nil)
+		}
+		c.JSON(status, gin.H{"error": msg, "code": e.code})
+		return
+	}
+	c.JSON(status, gin.H{"error": err.Error(), "code": statusCode(status)})
+}
+
+// statusCode is the code of an error without one of its own: the status text in snake case.
+func statusCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "error"
+	}
+	return strings.ToLower(strings.NewReplacer(" ", "_", "-", "_", "'", "").Replace(text))
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/auth"
+	"github.com/rm/roadmap/backend/internal/dto"
+	"github.com/rm/roadmap/backend/internal/middleware"
+	"github.com/rm/roadmap/backend/internal/models"
+	"github.com/rm/roadmap/backend/internal/realtime"
+	"github.com/rm/roadmap/backend/internal/services"
+	"go.uber.org/zap"
+)
+
+// EventHandler streams realtime events to the browser over Server-Sent Events.
+type EventHandler struct {
+	hub             *realtime.Hub
+	notificationSvc *services.NotificationService
+	sessions        *services.SessionService
+	products        *services.ProductService
+	heartbeat       time.Duration
+	log             *zap.Logger
+}
+
+func NewEventHandler(hub *realtime.Hub, notificationSvc *services.NotificationService, sessions *services.SessionService, products *services.ProductService, heartbeat time.Duration, log *zap.Logger) *EventHandler {
+	if heartbeat <= 0 {
+		heartbeat = 25 * time.Second
+	}
+	if log == nil {
+		log = zap.NewNop()
+	}
+	return &EventHandler{hub: hub, notificationSvc: notificationSvc, sessions: sessions, products: products, heartbeat: heartbeat, log: log}
+}
+
+// Stream sends the caller's new notifications and unread count, and changes to the products and milestones
+// they can see, until the client disconnects. Each heartbeat re-checks the session; the stream ends with a
+// "reauth" event when the access token expires or the session is revoked, and the client reconnects with a
+// fresh token.
+func (h *EventHandler) Stream(c *gin.Context) {
+	userIDStr, _ := c.Get(middleware.UserIDKey)
+	role, _ := c.Get(middleware.UserRoleKey)
+	sidStr, _ := c.Get(middleware.SessionIDKey)
+	userID, _ := uuid.Parse(userIDStr.(string))
+	sid, _ := uuid.Parse(sidStr.(string))
+	roleStr, _ := role.(string)
+	ctx := c.Request.Context()
+
+	filter, err := h.filter(userID, models.Role(roleStr))
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	sub := h.hub.Subscribe(filter.accepts)
+	defer h.hub.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // keep nginx from buffering the stream
+	c.Status(http.StatusOK)
+	fmt.Fprintf(c.Writer, "retry: 5000\n\n")
+	if count, err := h.notificationSvc.UnreadCount(userID); err == nil {
+		h.write(c, realtime.EventUnreadCount, dto.NotificationUnreadCountResponse{Count: count})
+	}
+	c.Writer.Flush()
+
+	expires := time.NewTimer(time.Hour)
+	if claims, ok := c.Get(middleware.ClaimsKey); ok {
+		if cl, ok := claims.(*auth.Claims); ok && cl.ExpiresAt != nil {
+			expires.Reset(time.Until(cl.ExpiresAt.Time))
+		}
+	}
+	defer expires.Stop()
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-expires.C:
+			h.write(c, "reauth", gin.H{"reason": "token expired"})
+			c.Writer.Flush()
+			return
+		case <-heartbeat.C:
+			if err := h.sessions.Validate(ctx, sid, userID); err != nil {
+				h.write(c, "reauth", gin.H{"reason": "session ended"})
+				c.Writer.Flush()
+				return
+			}
+			if err := filter.refresh(); err != nil {
+				h.log.Warn("event stream: refreshing visibility failed", zap.Error(err))
+			}
+			fmt.Fprintf(c.Writer, ": ping\n\n")
+			c.Writer.Flush()
+		case ev, ok := <-sub.C:
+			if !ok {
+				// Fell behind; the client reconnects and refetches.
+				return
+			}
+			h.write(c, ev.Type, ev)
+			c.Writer.Flush()
+		}
+	}
+}
+
+// write sends one SSE message. Product and milestone events carry {action, product_id, data}; the others
+// carry their data as is.
+func (h *EventHandler) write(c *gin.Context, eventType string, v interface{}) {
+	var payload interface{} = v
+	if ev, ok := v.(realtime.Event); ok {
+		switch ev.Type {
+		case realtime.EventProduct, realtime.EventMilestone:
+			payload = gin.H{"action": ev.Action, "product_id": ev.ProductID, "data": ev.Data}
+		case realtime.EventResync:
+			payload = gin.H{}
+		default:
+			payload = ev.Data
+		}
+	}
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", eventType, b)
+}
+
+// eventFilter decides which events one connection receives. Everyone sees their own notifications; product
+// and milestone changes follow the product list (ProductService.ReadScope).
+type eventFilter struct {
+	products *services.ProductService
+	userID   uuid.UUID
+	role     models.Role
+	scope    atomic.Pointer[productScope] // read by the hub's goroutine
+}
+
+type productScope struct {
+	all    bool
+	owners []uuid.UUID
+}
+
+func (h *EventHandler) filter(userID uuid.UUID, role models.Role) (*eventFilter, error) {
+	f := &eventFilter{products: h.products, userID: userID, role: role}
+	return f, f.refresh()
+}
+
+// refresh reloads which products the user sees, so permission and reporting-line changes reach open
+// streams. It does not use the request context: that carries the per-request visibility cache, which would
+// never expire on a stream.
+func (f *eventFilter) refresh() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	owners, all, err := f.products.ReadScope(ctx, f.userID, f.role)
+	if err != nil {
+		return err
+	}
+	f.scope.Store(&productScope{all: all, owners: owners})
+	return nil
+}
+
+func (f *eventFilter) accepts(ev realtime.Event) bool {
+	if ev.UserID != nil {
+		return *ev.UserID == f.userID
+	}
+	if ev.Type != realtime.EventProduct && ev.Type != realtime.EventMilestone {
+		return false
+	}
+	scope := f.scope.Load()
+	if scope == nil {
+		return false
+	}
+	if scope.all {
+		return true
+	}
+	for _, owner := range ev.OwnerIDs {
+		if slices.Contains(scope.owners, owner) {
+			return true
+		}
+	}
+	return false
+}
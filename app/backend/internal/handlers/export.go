@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	exportFormatNDJSON = "ndjson"
+	exportFormatCSV    = "csv"
+
+	// exportFlushEvery is how many rows are written between flushes of the chunked response.
+	exportFlushEvery = 200
+)
+
+// parseDateRange reads the date_from and date_to query parameters (YYYY-MM-DD); date_to covers the whole day.
+// Unparseable dates are ignored.
+func parseDateRange(c *gin.Context) (dateFrom, dateTo *time.Time) {
+	if df := c.Query("date_from"); df != "" {
+		if t, err := time.Parse("2006-01-02", df); err == nil {
+			dateFrom = &t
+		}
+	}
+	if dt := c.Query("date_to"); dt != "" {
+		if t, err := time.Parse("2006-01-02", dt); err == nil {
+			t = t.Add(24*time.Hour - time.Nanosecond) // end of day
+			dateTo = &t
+		}
+	}
+	return dateFrom, dateTo
+}
+
+// exportStream writes an export to the response row by row as NDJSON or CSV. Headers go out with the first
+// row, so an error before any output can still be answered with a normal JSON error. An error after that is
+// reported in the X-Export-Error trailer and, for NDJSON, as a final {"error": ...} line.
+type exportStream struct {
+	c         *gin.Context
+	format    string
+	filename  string
+	csvHeader []string
+	csv       *csv.Writer
+	started   bool
+	rows      int
+}
+
+func newExportStream(c *gin.Context, format, name string, csvHeader []string) *exportStream {
+	return &exportStream{
+		c:         c,
+		format:    format,
+		filename:  fmt.Sprintf("%s-%s.%s", name, time.Now().UTC().Format("20060102-150405"), format),
+		csvHeader: csvHeader,
+	}
+}
+
+// exportFormat returns the ?format parameter, defaulting to NDJSON, and whether it is supported.
+func exportFormat(c *gin.Context) (string, bool) {
+	f := c.DefaultQuery("format", exportFormatNDJSON)
+	return f, f == exportFormatNDJSON || f == exportFormatCSV
+}
+
+func (w *exportStream) start() {
+	w.started = true
+	h := w.c.Writer.Header()
+	if w.format == exportFormatCSV {
+		h.Set("Content-Type", "text/csv; charset=utf-8")
+	} else {
+		h.Set("Content-Type", "application/x-ndjson")
+	}
+	h.Set("Content-Disposition", `attachment; filename="`+w.filename+`"`)
+	h.Set("Cache-Control", "no-store")
+	h.Set("Trailer", "X-Export-Error")
+	w.c.Status(http.StatusOK)
+	if w.format == exportFormatCSV {
+		w.csv = csv.NewWriter(w.c.Writer)
+		_ = w.csv.Write(w.csvHeader)
+	}
+}
+
+// write sends one row: v as a JSON line, or csvRow as a CSV record.
+func (w *exportStream) write(v interface{}, csvRow []string) error {
+	if !w.started {
+		w.start()
+	}
+	if w.format == exportFormatCSV {
+		for i := range csvRow {
+			csvRow[i] = csvSafe(csvRow[i])
+		}
+		if err := w.csv.Write(csvRow); err != nil {
+			return err
+		}
+	} else {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if _, err := w.c.Writer.Write(append(b, '\n')); err != nil {
+			return err
+		}
+	}
+	w.rows++
+	if w.rows%exportFlushEvery == 0 {
+		w.flush()
+	}
+	return nil
+}
+
+// finish completes the response; err is an error that stopped the export after output had started.
+func (w *exportStream) finish(err error) {
+	if !w.started {
+		w.start()
+	}
+	if err != nil {
+		if w.format == exportFormatNDJSON {
+			b, _ := json.Marshal(gin.H{"error": err.Error()})
+			_, _ = w.c.Writer.Write(append(b, '\n'))
+		}
+		w.flush()
+		w.c.Writer.Header().Set("X-Export-Error", err.Error())
+		return
+	}
+	w.flush()
+}
+
+func (w *exportStream) flush() {
+	if w.csv != nil {
+		w.csv.Flush()
+	}
+	w.c.Writer.Flush()
+}
+
+// csvSafe neutralizes values a spreadsheet would evaluate as a formula.
+func csvSafe(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// jsonString renders v as compact JSON for a CSV cell; nil becomes an empty cell.
+func jsonString(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
@@ -33,21 +33,21 @@ func (h *GroupHandler) getCaller(c *gin.Context) (uuid.UUID, models.Role) {
 func (h *GroupHandler) Create(c *gin.Context) {
 	var req dto.GroupCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
 	callerID, _ := h.getCaller(c)
-	resp, err := h.groupService.Create(req, callerID)
+	resp, err := h.groupService.Create(c.Request.Context(), req, callerID, middleware.GetAuditMeta(c))
 	if err != nil {
 		if err == services.ErrForbidden {
-			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			respondError(c, http.StatusForbidden, err)
 			return
 		}
 		if err == services.ErrGroupDescTooShort {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			respondError(c, http.StatusBadRequest, err)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusCreated, resp)
@@ -55,9 +55,13 @@ func (h *GroupHandler) Create(c *gin.Context) {
 
 func (h *GroupHandler) List(c *gin.Context) {
 	callerID, callerRole := h.getCaller(c)
-	list, err := h.groupService.List(callerID, callerRole)
+	list, err := h.groupService.List(c.Request.Context(), callerID, callerRole)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if err == services.ErrForbidden {
+			respondError(c, http.StatusForbidden, err)
+			return
+		}
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusOK, list)
@@ -66,17 +70,17 @@ func (h *GroupHandler) List(c *gin.Context) {
 func (h *GroupHandler) GetByID(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		respondError(c, http.StatusBadRequest, invalidParam("id"))
 		return
 	}
 	callerID, callerRole := h.getCaller(c)
-	resp, err := h.groupService.GetByID(id, callerID, callerRole)
+	resp, err := h.groupService.GetByID(c.Request.Context(), id, callerID, callerRole)
 	if err != nil {
 		if err == services.ErrGroupNotFound || err == services.ErrForbidden {
-			c.JSON(http.StatusNotFound, gin.H{"error": "group not found"})
+			respondError(c, http.StatusNotFound, services.ErrGroupNotFound)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusOK, resp)
@@ -85,26 +89,26 @@ func (h *GroupHandler) GetByID(c *gin.Context) {
 func (h *GroupHandler) Update(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		respondError(c, http.StatusBadRequest, invalidParam("id"))
 		return
 	}
 	var req dto.GroupUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
 	callerID, callerRole := h.getCaller(c)
-	resp, err := h.groupService.Update(id, req, callerID, callerRole)
+	resp, err := h.groupService.Update(c.Request.Context(), id, req, callerID, callerRole, middleware.GetAuditMeta(c))
 	if err != nil {
 		if err == services.ErrGroupNotFound || err == services.ErrForbidden {
-			c.JSON(http.StatusNotFound, gin.H{"error": "group not found"})
+			respondError(c, http.StatusNotFound, services.ErrGroupNotFound)
 			return
 		}
 		if err == services.ErrGroupDescTooShort {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			respondError(c, http.StatusBadRequest, err)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusOK, resp)
@@ -113,16 +117,16 @@ func (h *GroupHandler) Update(c *gin.Context) {
 func (h *GroupHandler) Delete(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		respondError(c, http.StatusBadRequest, invalidParam("id"))
 		return
 	}
 	callerID, callerRole := h.getCaller(c)
-	if err := h.groupService.Delete(id, callerID, callerRole); err != nil {
+	if err := h.groupService.Delete(c.Request.Context(), id, callerID, callerRole, middleware.GetAuditMeta(c)); err != nil {
 		if err == services.ErrGroupNotFound || err == services.ErrForbidden {
-			c.JSON(http.StatusNotFound, gin.H{"error": "group not found"})
+			respondError(c, http.StatusNotFound, services.ErrGroupNotFound)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.Status(http.StatusNoContent)
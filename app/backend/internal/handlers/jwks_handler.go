@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rm/roadmap/backend/internal/auth"
+)
+
+type JWKSHandler struct {
+	keys *auth.Keyring
+}
+
+func NewJWKSHandler(keys *auth.Keyring) *JWKSHandler {
+	return &JWKSHandler{keys: keys}
+}
+
+// Get serves the public keys that verify our tokens (RFC 7517). Unauthenticated.
+func (h *JWKSHandler) Get(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age="+strconv.Itoa(int(h.keys.JWKSMaxAge().Seconds())))
+	c.JSON(http.StatusOK, h.keys.JWKS())
+}
@@ -27,7 +27,7 @@ func (h *MilestoneHandler) getCallerRole(c *gin.Context) models.Role {
 func (h *MilestoneHandler) Create(c *gin.Context) {
 	var req dto.MilestoneCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
 	callerID, _ := c.Get(middleware.UserIDKey)
@@ -36,14 +36,14 @@ func (h *MilestoneHandler) Create(c *gin.Context) {
 	resp, err := h.milestoneService.Create(c.Request.Context(), req, callerIDUUID, h.getCallerRole(c), meta)
 	if err != nil {
 		if err == services.ErrForbidden {
-			c.JSON(http.StatusForbidden, gin.H{"error": "only active products can be edited"})
+			respondError(c, http.StatusForbidden, errProductNotActive)
 			return
 		}
 		if err == services.ErrEndDateBeforeStart || err == services.ErrCertifyRequiresTestedSuccessfully {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			respondError(c, http.StatusBadRequest, err)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusCreated, resp)
@@ -52,12 +52,12 @@ func (h *MilestoneHandler) Create(c *gin.Context) {
 func (h *MilestoneHandler) ListByProduct(c *gin.Context) {
 	productID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid product id"})
+		respondError(c, http.StatusBadRequest, invalidParam("product id"))
 		return
 	}
 	list, err := h.milestoneService.ListByProductID(productID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusOK, list)
@@ -66,12 +66,12 @@ func (h *MilestoneHandler) ListByProduct(c *gin.Context) {
 func (h *MilestoneHandler) Update(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		respondError(c, http.StatusBadRequest, invalidParam("id"))
 		return
 	}
 	var req dto.MilestoneUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
 	callerID, _ := c.Get(middleware.UserIDKey)
@@ -80,14 +80,14 @@ func (h *MilestoneHandler) Update(c *gin.Context) {
 	resp, err := h.milestoneService.Update(c.Request.Context(), id, req, callerIDUUID, h.getCallerRole(c), meta)
 	if err != nil {
 		if err == services.ErrForbidden {
-			c.JSON(http.StatusForbidden, gin.H{"error": "only active products can be edited"})
+			respondError(c, http.StatusForbidden, errProductNotActive)
 			return
 		}
 		if err == services.ErrEndDateBeforeStart || err == services.ErrCertifyRequiresTestedSuccessfully {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			respondError(c, http.StatusBadRequest, err)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusOK, resp)
@@ -96,7 +96,7 @@ func (h *MilestoneHandler) Update(c *gin.Context) {
 func (h *MilestoneHandler) Delete(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		respondError(c, http.StatusBadRequest, invalidParam("id"))
 		return
 	}
 	callerID, _ := c.Get(middleware.UserIDKey)
@@ -104,10 +104,10 @@ func (h *MilestoneHandler) Delete(c *gin.Context) {
 	meta := middleware.GetAuditMeta(c)
 	if err := h.milestoneService.Delete(c.Request.Context(), id, callerIDUUID, h.getCallerRole(c), meta); err != nil {
 		if err == services.ErrForbidden {
-			c.JSON(http.StatusForbidden, gin.H{"error": "only product owner or admin can edit when product is active"})
+			respondError(c, http.StatusForbidden, errMilestoneEditForbidden)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.Status(http.StatusNoContent)
@@ -1,21 +1,25 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/dto"
 	"github.com/rm/roadmap/backend/internal/middleware"
+	"github.com/rm/roadmap/backend/internal/repositories"
 	"github.com/rm/roadmap/backend/internal/services"
 )
 
 type NotificationHandler struct {
-	svc *services.NotificationService
+	svc      *services.NotificationService
+	emailSvc *services.NotificationEmailService
 }
 
-func NewNotificationHandler(svc *services.NotificationService) *NotificationHandler {
-	return &NotificationHandler{svc: svc}
+func NewNotificationHandler(svc *services.NotificationService, emailSvc *services.NotificationEmailService) *NotificationHandler {
+	return &NotificationHandler{svc: svc, emailSvc: emailSvc}
 }
 
 func (h *NotificationHandler) getCallerID(c *gin.Context) uuid.UUID {
@@ -24,9 +28,32 @@ func (h *NotificationHandler) getCallerID(c *gin.Context) uuid.UUID {
 	return id
 }
 
+// List returns the caller's notifications, collapsed into threads unless threads=false. archived=true
+// includes archived ones; type, related_entity_type, related_entity_id and unread=true narrow the list, e.g.
+// to expand one thread.
 func (h *NotificationHandler) List(c *gin.Context) {
 	userID := h.getCallerID(c)
-	includeArchived := c.Query("archived") == "true"
+	f := repositories.NotificationFilter{
+		Type:              c.Query("type"),
+		RelatedEntityType: c.Query("related_entity_type"),
+	}
+	if c.Query("archived") != "true" {
+		archived := false
+		f.Archived = &archived
+	}
+	if c.Query("unread") == "true" {
+		read := false
+		f.Read = &read
+	}
+	if v := c.Query("related_entity_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, invalidParam("related_entity_id"))
+			return
+		}
+		f.RelatedEntityID = &id
+	}
+	threaded := c.Query("threads") != "false"
 	limit := 50
 	offset := 0
 	if l := c.Query("limit"); l != "" {
@@ -39,9 +66,9 @@ func (h *NotificationHandler) List(c *gin.Context) {
 			offset = n
 		}
 	}
-	resp, err := h.svc.List(userID, includeArchived, limit, offset)
+	resp, err := h.svc.List(userID, middleware.GetLocale(c), f, threaded, limit, offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusOK, resp)
@@ -51,7 +78,7 @@ func (h *NotificationHandler) UnreadCount(c *gin.Context) {
 	userID := h.getCallerID(c)
 	count, err := h.svc.UnreadCount(userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"count": count})
@@ -60,12 +87,12 @@ func (h *NotificationHandler) UnreadCount(c *gin.Context) {
 func (h *NotificationHandler) MarkRead(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		respondError(c, http.StatusBadRequest, invalidParam("id"))
 		return
 	}
 	userID := h.getCallerID(c)
 	if err := h.svc.MarkRead(id, userID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.Status(http.StatusNoContent)
@@ -74,7 +101,7 @@ func (h *NotificationHandler) MarkRead(c *gin.Context) {
 func (h *NotificationHandler) MarkReadAll(c *gin.Context) {
 	userID := h.getCallerID(c)
 	if err := h.svc.MarkReadAll(userID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.Status(http.StatusNoContent)
@@ -83,12 +110,12 @@ func (h *NotificationHandler) MarkReadAll(c *gin.Context) {
 func (h *NotificationHandler) Archive(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		respondError(c, http.StatusBadRequest, invalidParam("id"))
 		return
 	}
 	userID := h.getCallerID(c)
 	if err := h.svc.Archive(id, userID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.Status(http.StatusNoContent)
@@ -97,13 +124,82 @@ func (h *NotificationHandler) Archive(c *gin.Context) {
 func (h *NotificationHandler) Delete(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		respondError(c, http.StatusBadRequest, invalidParam("id"))
 		return
 	}
 	userID := h.getCallerID(c)
 	if err := h.svc.Delete(id, userID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.Status(http.StatusNoContent)
 }
+
+// Bulk marks read, archives or deletes the caller's notifications matching the request.
+func (h *NotificationHandler) Bulk(c *gin.Context) {
+	var req dto.NotificationBulkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	n, err := h.svc.Bulk(h.getCallerID(c), req)
+	if errors.Is(err, services.ErrNotificationBulkInvalid) {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	} else if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, dto.NotificationBulkResponse{Count: n})
+}
+
+func (h *NotificationHandler) EmailSettings(c *gin.Context) {
+	resp, err := h.emailSvc.Settings(c.Request.Context(), h.getCallerID(c))
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *NotificationHandler) UpdateEmailSettings(c *gin.Context) {
+	var req dto.NotificationEmailSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	resp, err := h.emailSvc.UpdateSettings(c.Request.Context(), h.getCallerID(c), req.Frequency)
+	if errors.Is(err, services.ErrInvalidEmailFrequency) {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	} else if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Unsubscribe is the unauthenticated target of the unsubscribe link in notification email. GET (a click in
+// the email) answers with a short page; POST is the RFC 8058 one-click request mail clients send.
+func (h *NotificationHandler) Unsubscribe(c *gin.Context) {
+	err := h.emailSvc.Unsubscribe(c.Request.Context(), c.Query("token"))
+	status := http.StatusOK
+	if errors.Is(err, services.ErrInvalidUnsubscribeToken) {
+		status = http.StatusBadRequest
+	} else if err != nil {
+		status = http.StatusInternalServerError
+	}
+	if c.Request.Method == http.MethodPost {
+		if err != nil {
+			respondError(c, status, err)
+			return
+		}
+		c.JSON(status, gin.H{"status": "unsubscribed"})
+		return
+	}
+	page := "You will no longer receive notification email from Roadmap. You can turn it back on in your notification settings."
+	if err != nil {
+		page = "This unsubscribe link is invalid or could not be processed. You can turn off email in your notification settings."
+	}
+	c.Data(status, "text/html; charset=utf-8", []byte("<!DOCTYPE html><html><head><meta charset=\"utf-8\"><title>Roadmap</title></head><body><p>"+page+"</p></body></html>"))
+}
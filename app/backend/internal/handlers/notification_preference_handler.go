@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/dto"
+	"github.com/rm/roadmap/backend/internal/middleware"
+	"github.com/rm/roadmap/backend/internal/services"
+)
+
+type NotificationPreferenceHandler struct {
+	svc *services.NotificationPreferenceService
+}
+
+func NewNotificationPreferenceHandler(svc *services.NotificationPreferenceService) *NotificationPreferenceHandler {
+	return &NotificationPreferenceHandler{svc: svc}
+}
+
+func (h *NotificationPreferenceHandler) getCaller(c *gin.Context) (uuid.UUID, string) {
+	userID, _ := c.Get(middleware.UserIDKey)
+	role, _ := c.Get(middleware.UserRoleKey)
+	id, _ := uuid.Parse(userID.(string))
+	roleStr, _ := role.(string)
+	return id, roleStr
+}
+
+// Get returns the caller's effective preferences.
+func (h *NotificationPreferenceHandler) Get(c *gin.Context) {
+	userID, _ := h.getCaller(c)
+	resp, err := h.svc.ForUser(c.Request.Context(), userID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Update changes the caller's preferences.
+func (h *NotificationPreferenceHandler) Update(c *gin.Context) {
+	var req dto.NotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	userID, _ := h.getCaller(c)
+	resp, err := h.svc.Update(c.Request.Context(), userID, req, middleware.GetAuditMeta(c))
+	if err != nil {
+		notificationPreferenceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Defaults returns the organization defaults.
+func (h *NotificationPreferenceHandler) Defaults(c *gin.Context) {
+	resp, err := h.svc.Defaults(c.Request.Context())
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// UpdateDefaults changes organization defaults and mandatory types.
+func (h *NotificationPreferenceHandler) UpdateDefaults(c *gin.Context) {
+	var req dto.NotificationDefaultsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	resp, err := h.svc.UpdateDefaults(c.Request.Context(), req, middleware.GetAuditMeta(c))
+	if err != nil {
+		notificationPreferenceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// History returns the audit trail of preference changes; ?user_id= narrows it to one user, ?user_id=defaults
+// to the organization defaults.
+func (h *NotificationPreferenceHandler) History(c *gin.Context) {
+	callerID, callerRole := h.getCaller(c)
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit < 1 || limit > 100 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	list, total, err := h.svc.History(c.Request.Context(), c.Query("user_id"), limit, offset, callerID, callerRole)
+	if err != nil {
+		if errors.Is(err, services.ErrForbidden) {
+			respondError(c, http.StatusForbidden, err)
+			return
+		}
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, dto.PageResult[dto.AuditLogResponse]{Items: list, Total: total, Limit: limit, Offset: offset})
+}
+
+func notificationPreferenceError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidNotificationPreference):
+		respondError(c, http.StatusBadRequest, err)
+	case errors.Is(err, services.ErrNotificationPreferenceMandatory):
+		respondError(c, http.StatusConflict, err)
+	default:
+		respondError(c, http.StatusInternalServerError, err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/rm/roadmap/backend/internal/dto"
+	"github.com/rm/roadmap/backend/internal/middleware"
 	"github.com/rm/roadmap/backend/internal/services"
 )
 
@@ -20,12 +21,12 @@ func NewOrgHandler(svc *services.OrgService) *OrgHandler {
 func parseUUIDParam(c *gin.Context, name string) (uuid.UUID, bool) {
 	s := c.Param(name)
 	if s == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": name + " required"})
+		respondError(c, http.StatusBadRequest, missingParam(name))
 		return uuid.Nil, false
 	}
 	id, err := uuid.Parse(s)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name})
+		respondError(c, http.StatusBadRequest, invalidParam(name))
 		return uuid.Nil, false
 	}
 	return id, true
@@ -35,7 +36,7 @@ func parseUUIDParam(c *gin.Context, name string) (uuid.UUID, bool) {
 func (h *OrgHandler) ListHoldingCompanies(c *gin.Context) {
 	list, err := h.svc.ListHoldingCompanies()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusOK, list)
@@ -43,12 +44,12 @@ func (h *OrgHandler) ListHoldingCompanies(c *gin.Context) {
 func (h *OrgHandler) CreateHoldingCompany(c *gin.Context) {
 	var req dto.HoldingCompanyCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
-	resp, err := h.svc.CreateHoldingCompany(req)
+	resp, err := h.svc.CreateHoldingCompany(c.Request.Context(), req, middleware.GetAuditMeta(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusCreated, resp)
@@ -60,7 +61,7 @@ func (h *OrgHandler) GetHoldingCompany(c *gin.Context) {
 	}
 	resp, err := h.svc.GetHoldingCompany(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, http.StatusNotFound, err)
 		return
 	}
 	c.JSON(http.StatusOK, resp)
@@ -72,12 +73,12 @@ func (h *OrgHandler) UpdateHoldingCompany(c *gin.Context) {
 	}
 	var req dto.HoldingCompanyUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
-	resp, err := h.svc.UpdateHoldingCompany(id, req)
+	resp, err := h.svc.UpdateHoldingCompany(c.Request.Context(), id, req, middleware.GetAuditMeta(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusOK, resp)
@@ -87,8 +88,8 @@ func (h *OrgHandler) DeleteHoldingCompany(c *gin.Context) {
 	if !ok {
 		return
 	}
-	if err := h.svc.DeleteHoldingCompany(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.svc.DeleteHoldingCompany(c.Request.Context(), id, middleware.GetAuditMeta(c)); err != nil {
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.Status(http.StatusNoContent)
@@ -104,7 +105,7 @@ func (h *OrgHandler) ListCompanies(c *gin.Context) {
 	}
 	list, err := h.svc.ListCompanies(holdingID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusOK, list)
@@ -112,12 +113,12 @@ func (h *OrgHandler) ListCompanies(c *gin.Context) {
 func (h *OrgHandler) CreateCompany(c *gin.Context) {
 	var req dto.CompanyCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
-	resp, err := h.svc.CreateCompany(req)
+	resp, err := h.svc.CreateCompany(c.Request.Context(), req, middleware.GetAuditMeta(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusCreated, resp)
@@ -129,7 +130,7 @@ func (h *OrgHandler) GetCompany(c *gin.Context) {
 	}
 	resp, err := h.svc.GetCompany(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, http.StatusNotFound, err)
 		return
 	}
 	c.JSON(http.StatusOK, resp)
@@ -141,12 +142,12 @@ func (h *OrgHandler) UpdateCompany(c *gin.Context) {
 	}
 	var req dto.CompanyUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
-	resp, err := h.svc.UpdateCompany(id, req)
+	resp, err := h.svc.UpdateCompany(c.Request.Context(), id, req, middleware.GetAuditMeta(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusOK, resp)
@@ -156,8 +157,8 @@ func (h *OrgHandler) DeleteCompany(c *gin.Context) {
 	if !ok {
 		return
 	}
-	if err := h.svc.DeleteCompany(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.svc.DeleteCompany(c.Request.Context(), id, middleware.GetAuditMeta(c)); err != nil {
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.Status(http.StatusNoContent)
@@ -173,7 +174,7 @@ func (h *OrgHandler) ListFunctions(c *gin.Context) {
 	}
 	list, err := h.svc.ListFunctions(companyID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusOK, list)
@@ -181,12 +182,12 @@ func (h *OrgHandler) ListFunctions(c *gin.Context) {
 func (h *OrgHandler) CreateFunction(c *gin.Context) {
 	var req dto.FunctionCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
-	resp, err := h.svc.CreateFunction(req)
+	resp, err := h.svc.CreateFunction(c.Request.Context(), req, middleware.GetAuditMeta(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusCreated, resp)
@@ -198,7 +199,7 @@ func (h *OrgHandler) GetFunction(c *gin.Context) {
 	}
 	resp, err := h.svc.GetFunction(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, http.StatusNotFound, err)
 		return
 	}
 	c.JSON(http.StatusOK, resp)
@@ -210,12 +211,12 @@ func (h *OrgHandler) UpdateFunction(c *gin.Context) {
 	}
 	var req dto.FunctionUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
-	resp, err := h.svc.UpdateFunction(id, req)
+	resp, err := h.svc.UpdateFunction(c.Request.Context(), id, req, middleware.GetAuditMeta(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusOK, resp)
@@ -225,8 +226,8 @@ func (h *OrgHandler) DeleteFunction(c *gin.Context) {
 	if !ok {
 		return
 	}
-	if err := h.svc.DeleteFunction(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.svc.DeleteFunction(c.Request.Context(), id, middleware.GetAuditMeta(c)); err != nil {
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.Status(http.StatusNoContent)
@@ -242,7 +243,7 @@ func (h *OrgHandler) ListDepartments(c *gin.Context) {
 	}
 	list, err := h.svc.ListDepartments(functionID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusOK, list)
@@ -250,12 +251,12 @@ func (h *OrgHandler) ListDepartments(c *gin.Context) {
 func (h *OrgHandler) CreateDepartment(c *gin.Context) {
 	var req dto.DepartmentCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
-	resp, err := h.svc.CreateDepartment(req)
+	resp, err := h.svc.CreateDepartment(c.Request.Context(), req, middleware.GetAuditMeta(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusCreated, resp)
@@ -267,7 +268,7 @@ func (h *OrgHandler) GetDepartment(c *gin.Context) {
 	}
 	resp, err := h.svc.GetDepartment(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, http.StatusNotFound, err)
 		return
 	}
 	c.JSON(http.StatusOK, resp)
@@ -279,12 +280,12 @@ func (h *OrgHandler) UpdateDepartment(c *gin.Context) {
 	}
 	var req dto.DepartmentUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
-	resp, err := h.svc.UpdateDepartment(id, req)
+	resp, err := h.svc.UpdateDepartment(c.Request.Context(), id, req, middleware.GetAuditMeta(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusOK, resp)
@@ -294,8 +295,8 @@ func (h *OrgHandler) DeleteDepartment(c *gin.Context) {
 	if !ok {
 		return
 	}
-	if err := h.svc.DeleteDepartment(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.svc.DeleteDepartment(c.Request.Context(), id, middleware.GetAuditMeta(c)); err != nil {
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.Status(http.StatusNoContent)
@@ -311,7 +312,7 @@ func (h *OrgHandler) ListTeams(c *gin.Context) {
 	}
 	list, err := h.svc.ListTeams(departmentID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusOK, list)
@@ -319,12 +320,12 @@ func (h *OrgHandler) ListTeams(c *gin.Context) {
 func (h *OrgHandler) CreateTeam(c *gin.Context) {
 	var req dto.TeamCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
-	resp, err := h.svc.CreateTeam(req)
+	resp, err := h.svc.CreateTeam(c.Request.Context(), req, middleware.GetAuditMeta(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusCreated, resp)
@@ -336,7 +337,7 @@ func (h *OrgHandler) GetTeam(c *gin.Context) {
 	}
 	resp, err := h.svc.GetTeam(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, http.StatusNotFound, err)
 		return
 	}
 	c.JSON(http.StatusOK, resp)
@@ -348,12 +349,12 @@ func (h *OrgHandler) UpdateTeam(c *gin.Context) {
 	}
 	var req dto.TeamUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
-	resp, err := h.svc.UpdateTeam(id, req)
+	resp, err := h.svc.UpdateTeam(c.Request.Context(), id, req, middleware.GetAuditMeta(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusOK, resp)
@@ -363,8 +364,8 @@ func (h *OrgHandler) DeleteTeam(c *gin.Context) {
 	if !ok {
 		return
 	}
-	if err := h.svc.DeleteTeam(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.svc.DeleteTeam(c.Request.Context(), id, middleware.GetAuditMeta(c)); err != nil {
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.Status(http.StatusNoContent)
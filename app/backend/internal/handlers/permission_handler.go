@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/authz"
+	"github.com/rm/roadmap/backend/internal/dto"
+	"github.com/rm/roadmap/backend/internal/middleware"
+	"github.com/rm/roadmap/backend/internal/models"
+	"github.com/rm/roadmap/backend/internal/repositories"
+	"github.com/rm/roadmap/backend/internal/services"
+)
+
+type PermissionHandler struct {
+	policy   *authz.Engine
+	userRepo repositories.UserRepository
+}
+
+func NewPermissionHandler(policy *authz.Engine, userRepo repositories.UserRepository) *PermissionHandler {
+	return &PermissionHandler{policy: policy, userRepo: userRepo}
+}
+
+// Overview returns the permission catalog and the grants of every role.
+func (h *PermissionHandler) Overview(c *gin.Context) {
+	resp := dto.PermissionOverviewResponse{
+		Catalog: make([]dto.PermissionDefinition, len(authz.Catalog)),
+		Roles:   make(map[string][]dto.PermissionGrant, len(authz.Roles)),
+	}
+	for i, d := range authz.Catalog {
+		resp.Catalog[i] = dto.PermissionDefinition{Permission: string(d.Permission), Description: d.Description, Ownable: d.Ownable}
+	}
+	for _, role := range authz.Roles {
+		grants, err := h.policy.EffectivePermissions(c.Request.Context(), role)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, err)
+			return
+		}
+		resp.Roles[string(role)] = grantsToDTO(grants)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Me returns the effective permissions of the caller (used by the UI to show or hide actions).
+func (h *PermissionHandler) Me(c *gin.Context) {
+	sub := middleware.GetSubject(c)
+	h.respondEffective(c, sub.UserID, sub.Role)
+}
+
+// ForUser returns the effective permissions of any user.
+func (h *PermissionHandler) ForUser(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, invalidParam("id"))
+		return
+	}
+	u, err := h.userRepo.GetByID(id)
+	if err != nil {
+		respondError(c, http.StatusNotFound, services.ErrUserNotFound)
+		return
+	}
+	h.respondEffective(c, u.ID, u.Role)
+}
+
+func (h *PermissionHandler) respondEffective(c *gin.Context, userID uuid.UUID, role models.Role) {
+	grants, err := h.policy.EffectivePermissions(c.Request.Context(), role)
+	if err != nil {
+		if err == authz.ErrUnknownRole {
+			c.JSON(http.StatusOK, dto.EffectivePermissionsResponse{UserID: userID.String(), Role: string(role), Permissions: []dto.PermissionGrant{}})
+			return
+		}
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, dto.EffectivePermissionsResponse{UserID: userID.String(), Role: string(role), Permissions: grantsToDTO(grants)})
+}
+
+func grantsToDTO(grants []authz.Grant) []dto.PermissionGrant {
+	out := make([]dto.PermissionGrant, len(grants))
+	for i, g := range grants {
+		out[i] = dto.PermissionGrant{Permission: string(g.Permission), Scope: g.Scope}
+	}
+	return out
+}
@@ -29,19 +29,19 @@ func (h *ProductDeletionRequestHandler) getCallerID(c *gin.Context) uuid.UUID {
 func (h *ProductDeletionRequestHandler) Create(c *gin.Context) {
 	productIDStr := c.Param("id")
 	if productIDStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "product id required"})
+		respondError(c, http.StatusBadRequest, missingParam("product id"))
 		return
 	}
 	productID, err := uuid.Parse(productIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid product id"})
+		respondError(c, http.StatusBadRequest, invalidParam("product id"))
 		return
 	}
 	callerID := h.getCallerID(c)
 	meta := middleware.GetAuditMeta(c)
 	resp, err := h.productDeletionRequestService.Create(c.Request.Context(), productID, callerID, meta)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusCreated, resp)
@@ -63,7 +63,7 @@ func (h *ProductDeletionRequestHandler) List(c *gin.Context) {
 	if o := c.Query("owner_id"); o != "" {
 		parsed, err := uuid.Parse(o)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid owner_id"})
+			respondError(c, http.StatusBadRequest, invalidParam("owner_id"))
 			return
 		}
 		ownerID = &parsed
@@ -72,7 +72,7 @@ func (h *ProductDeletionRequestHandler) List(c *gin.Context) {
 	if f := c.Query("from_date"); f != "" {
 		t, err := time.Parse("2006-01-02", f)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from_date (use YYYY-MM-DD)"})
+			respondError(c, http.StatusBadRequest, errInvalidFromDate)
 			return
 		}
 		fromDate = &t
@@ -80,14 +80,14 @@ func (h *ProductDeletionRequestHandler) List(c *gin.Context) {
 	if t := c.Query("to_date"); t != "" {
 		parsed, err := time.Parse("2006-01-02", t)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to_date (use YYYY-MM-DD)"})
+			respondError(c, http.StatusBadRequest, errInvalidToDate)
 			return
 		}
 		toDate = &parsed
 	}
-	list, err := h.productDeletionRequestService.List(status, callerID, roleStr, ownerID, fromDate, toDate)
+	list, err := h.productDeletionRequestService.List(c.Request.Context(), status, callerID, roleStr, ownerID, fromDate, toDate)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusOK, list)
@@ -96,18 +96,18 @@ func (h *ProductDeletionRequestHandler) List(c *gin.Context) {
 func (h *ProductDeletionRequestHandler) Approve(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		respondError(c, http.StatusBadRequest, invalidParam("id"))
 		return
 	}
 	var req dto.ProductDeletionApproveRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
 	meta := middleware.GetAuditMeta(c)
 	resp, err := h.productDeletionRequestService.Approve(c.Request.Context(), id, req.Approved, meta)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusOK, resp)
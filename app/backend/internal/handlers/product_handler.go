@@ -36,7 +36,7 @@ func (h *ProductHandler) getCaller(c *gin.Context) (uuid.UUID, models.Role) {
 func (h *ProductHandler) Create(c *gin.Context) {
 	var req dto.ProductCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
 	callerID, callerRole := h.getCaller(c)
@@ -47,7 +47,7 @@ func (h *ProductHandler) Create(c *gin.Context) {
 	meta := middleware.GetAuditMeta(c)
 	resp, err := h.productService.Create(c.Request.Context(), req, ownerID, callerRole.IsAdminOrAbove(), meta)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusCreated, resp)
@@ -110,9 +110,9 @@ func (h *ProductHandler) List(c *gin.Context) {
 	}
 	pr := dto.PageRequest{Limit: limit, Offset: offset}
 	pr.Normalize(20, 100)
-	result, err := h.productService.List(ownerID, status, lifecycleStatus, category1, category2, category3, groupID, ungroupedOnly, dateFrom, dateTo, sortBy, order, callerID, callerRole, pr.Limit, pr.Offset)
+	result, err := h.productService.List(c.Request.Context(), ownerID, status, lifecycleStatus, category1, category2, category3, groupID, ungroupedOnly, dateFrom, dateTo, sortBy, order, callerID, callerRole, pr.Limit, pr.Offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusOK, result)
@@ -121,12 +121,12 @@ func (h *ProductHandler) List(c *gin.Context) {
 func (h *ProductHandler) GetByID(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		respondError(c, http.StatusBadRequest, invalidParam("id"))
 		return
 	}
-	resp, err := h.productService.GetByID(id)
+	resp, err := h.productService.GetByID(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
+		respondError(c, http.StatusNotFound, services.ErrProductNotFound)
 		return
 	}
 	c.JSON(http.StatusOK, resp)
@@ -135,13 +135,13 @@ func (h *ProductHandler) GetByID(c *gin.Context) {
 func (h *ProductHandler) Update(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		respondError(c, http.StatusBadRequest, invalidParam("id"))
 		return
 	}
 	var req dto.ProductUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.log.Warn("product update bind error", zap.String("product_id", id.String()), zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
 	callerID, callerRole := h.getCaller(c)
@@ -149,15 +149,15 @@ func (h *ProductHandler) Update(c *gin.Context) {
 	resp, err := h.productService.Update(c.Request.Context(), id, req, callerID, callerRole, meta)
 	if err != nil {
 		if err == services.ErrForbidden {
-			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			respondError(c, http.StatusForbidden, services.ErrForbidden)
 			return
 		}
 		if err == services.ErrInvalidOwnerID {
 			h.log.Warn("product update invalid owner_id", zap.String("product_id", id.String()))
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid owner_id"})
+			respondError(c, http.StatusBadRequest, invalidParam("owner_id"))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusOK, resp)
@@ -166,21 +166,21 @@ func (h *ProductHandler) Update(c *gin.Context) {
 func (h *ProductHandler) Delete(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		respondError(c, http.StatusBadRequest, invalidParam("id"))
 		return
 	}
 	_, callerRole := h.getCaller(c)
 	meta := middleware.GetAuditMeta(c)
 	if err := h.productService.Delete(c.Request.Context(), id, callerRole, meta); err != nil {
 		if err == services.ErrForbidden {
-			c.JSON(http.StatusForbidden, gin.H{"error": "admin only"})
+			respondError(c, http.StatusForbidden, errAdminOnly)
 			return
 		}
 		if err == services.ErrProductHasVersions {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "delete all versions first"})
+			respondError(c, http.StatusBadRequest, services.ErrProductHasVersions)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.Status(http.StatusNoContent)
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/dto"
+	"github.com/rm/roadmap/backend/internal/middleware"
+	"github.com/rm/roadmap/backend/internal/models"
+	"github.com/rm/roadmap/backend/internal/services"
+)
+
+type ProductMemberHandler struct {
+	memberService *services.ProductMemberService
+}
+
+func NewProductMemberHandler(memberService *services.ProductMemberService) *ProductMemberHandler {
+	return &ProductMemberHandler{memberService: memberService}
+}
+
+func (h *ProductMemberHandler) getCaller(c *gin.Context) (uuid.UUID, models.Role) {
+	userID, _ := c.Get(middleware.UserIDKey)
+	role, _ := c.Get(middleware.UserRoleKey)
+	id, _ := uuid.Parse(userID.(string))
+	return id, models.Role(role.(string))
+}
+
+func (h *ProductMemberHandler) List(c *gin.Context) {
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, invalidParam("product id"))
+		return
+	}
+	callerID, callerRole := h.getCaller(c)
+	list, err := h.memberService.List(c.Request.Context(), productID, callerID, callerRole)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+func (h *ProductMemberHandler) Add(c *gin.Context) {
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, invalidParam("product id"))
+		return
+	}
+	var req dto.ProductMemberAddRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	callerID, callerRole := h.getCaller(c)
+	resp, err := h.memberService.Add(c.Request.Context(), productID, req, callerID, callerRole, middleware.GetAuditMeta(c))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+func (h *ProductMemberHandler) Update(c *gin.Context) {
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, invalidParam("product id"))
+		return
+	}
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, invalidParam("user id"))
+		return
+	}
+	var req dto.ProductMemberUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	callerID, callerRole := h.getCaller(c)
+	resp, err := h.memberService.UpdateRole(c.Request.Context(), productID, userID, req, callerID, callerRole, middleware.GetAuditMeta(c))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *ProductMemberHandler) Delete(c *gin.Context) {
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, invalidParam("product id"))
+		return
+	}
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, invalidParam("user id"))
+		return
+	}
+	callerID, callerRole := h.getCaller(c)
+	if err := h.memberService.Remove(c.Request.Context(), productID, userID, callerID, callerRole, middleware.GetAuditMeta(c)); err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *ProductMemberHandler) writeError(c *gin.Context, err error) {
+	switch err {
+	case services.ErrForbidden:
+		respondError(c, http.StatusForbidden, errMemberManageForbidden)
+	case services.ErrProductNotFound, services.ErrProductMemberNotFound, services.ErrUserNotFound:
+		respondError(c, http.StatusNotFound, err)
+	case services.ErrProductMemberExists:
+		respondError(c, http.StatusConflict, err)
+	case services.ErrInvalidMemberRole, services.ErrMemberIsOwner:
+		respondError(c, http.StatusBadRequest, err)
+	default:
+		respondError(c, http.StatusInternalServerError, err)
+	}
+}
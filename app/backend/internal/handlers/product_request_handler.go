@@ -29,14 +29,14 @@ func (h *ProductRequestHandler) getCallerID(c *gin.Context) uuid.UUID {
 func (h *ProductRequestHandler) Create(c *gin.Context) {
 	var req dto.ProductRequestCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
 	userID := h.getCallerID(c)
 	meta := middleware.GetAuditMeta(c)
 	resp, err := h.productRequestService.Create(c.Request.Context(), req, userID, meta)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusCreated, resp)
@@ -58,7 +58,7 @@ func (h *ProductRequestHandler) List(c *gin.Context) {
 	if o := c.Query("owner_id"); o != "" {
 		parsed, err := uuid.Parse(o)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid owner_id"})
+			respondError(c, http.StatusBadRequest, invalidParam("owner_id"))
 			return
 		}
 		ownerID = &parsed
@@ -67,7 +67,7 @@ func (h *ProductRequestHandler) List(c *gin.Context) {
 	if f := c.Query("from_date"); f != "" {
 		t, err := time.Parse("2006-01-02", f)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from_date (use YYYY-MM-DD)"})
+			respondError(c, http.StatusBadRequest, errInvalidFromDate)
 			return
 		}
 		fromDate = &t
@@ -75,14 +75,14 @@ func (h *ProductRequestHandler) List(c *gin.Context) {
 	if t := c.Query("to_date"); t != "" {
 		parsed, err := time.Parse("2006-01-02", t)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to_date (use YYYY-MM-DD)"})
+			respondError(c, http.StatusBadRequest, errInvalidToDate)
 			return
 		}
 		toDate = &parsed
 	}
-	list, err := h.productRequestService.List(status, callerID, roleStr, ownerID, fromDate, toDate)
+	list, err := h.productRequestService.List(c.Request.Context(), status, callerID, roleStr, ownerID, fromDate, toDate)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusOK, list)
@@ -91,19 +91,19 @@ func (h *ProductRequestHandler) List(c *gin.Context) {
 func (h *ProductRequestHandler) Approve(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		respondError(c, http.StatusBadRequest, invalidParam("id"))
 		return
 	}
 	var req dto.ProductRequestApproveRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
 	var ownerID *uuid.UUID
 	if req.Approved && req.OwnerID != "" {
 		parsed, err := uuid.Parse(req.OwnerID)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid owner_id"})
+			respondError(c, http.StatusBadRequest, invalidParam("owner_id"))
 			return
 		}
 		ownerID = &parsed
@@ -111,7 +111,7 @@ func (h *ProductRequestHandler) Approve(c *gin.Context) {
 	meta := middleware.GetAuditMeta(c)
 	resp, err := h.productRequestService.Approve(c.Request.Context(), id, req.Approved, ownerID, meta)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusOK, resp)
@@ -40,17 +40,17 @@ func (h *ProductVersionDependencyHandler) getCaller(c *gin.Context) (uuid.UUID,
 func (h *ProductVersionDependencyHandler) ListByProductVersion(c *gin.Context) {
 	versionID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid product version id"})
+		respondError(c, http.StatusBadRequest, invalidParam("product version id"))
 		return
 	}
 	callerID, callerRole := h.getCaller(c)
 	list, err := h.svc.ListByProductVersionID(c.Request.Context(), versionID, callerID, callerRole)
 	if err != nil {
 		if err == services.ErrForbidden {
-			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			respondError(c, http.StatusForbidden, err)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusOK, list)
@@ -59,7 +59,7 @@ func (h *ProductVersionDependencyHandler) ListByProductVersion(c *gin.Context) {
 func (h *ProductVersionDependencyHandler) Create(c *gin.Context) {
 	var req dto.ProductVersionDependencyCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
 	callerID, callerRole := h.getCaller(c)
@@ -67,10 +67,10 @@ func (h *ProductVersionDependencyHandler) Create(c *gin.Context) {
 	resp, err := h.svc.Create(c.Request.Context(), req, callerID, callerRole, meta)
 	if err != nil {
 		if err == services.ErrForbidden {
-			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			respondError(c, http.StatusForbidden, err)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusCreated, resp)
@@ -79,17 +79,17 @@ func (h *ProductVersionDependencyHandler) Create(c *gin.Context) {
 func (h *ProductVersionDependencyHandler) Delete(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		respondError(c, http.StatusBadRequest, invalidParam("id"))
 		return
 	}
 	callerID, callerRole := h.getCaller(c)
 	meta := middleware.GetAuditMeta(c)
 	if err := h.svc.Delete(c.Request.Context(), id, callerID, callerRole, meta); err != nil {
 		if err == services.ErrForbidden {
-			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			respondError(c, http.StatusForbidden, err)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.Status(http.StatusNoContent)
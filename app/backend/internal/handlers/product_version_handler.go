@@ -29,12 +29,12 @@ func (h *ProductVersionHandler) getCaller(c *gin.Context) (uuid.UUID, models.Rol
 func (h *ProductVersionHandler) ListByProduct(c *gin.Context) {
 	productID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid product id"})
+		respondError(c, http.StatusBadRequest, invalidParam("product id"))
 		return
 	}
 	list, err := h.productVersionService.ListByProductID(productID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusOK, list)
@@ -43,7 +43,7 @@ func (h *ProductVersionHandler) ListByProduct(c *gin.Context) {
 func (h *ProductVersionHandler) Create(c *gin.Context) {
 	var req dto.ProductVersionCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
 	callerID, callerRole := h.getCaller(c)
@@ -51,10 +51,10 @@ func (h *ProductVersionHandler) Create(c *gin.Context) {
 	resp, err := h.productVersionService.Create(c.Request.Context(), req, callerID, callerRole, meta)
 	if err != nil {
 		if err == services.ErrForbidden {
-			c.JSON(http.StatusForbidden, gin.H{"error": "only product owner or admin can add versions when product is active"})
+			respondError(c, http.StatusForbidden, errVersionAddForbidden)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusCreated, resp)
@@ -63,12 +63,12 @@ func (h *ProductVersionHandler) Create(c *gin.Context) {
 func (h *ProductVersionHandler) Update(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		respondError(c, http.StatusBadRequest, invalidParam("id"))
 		return
 	}
 	var req dto.ProductVersionUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
 	callerID, callerRole := h.getCaller(c)
@@ -76,10 +76,10 @@ func (h *ProductVersionHandler) Update(c *gin.Context) {
 	resp, err := h.productVersionService.Update(c.Request.Context(), id, req, callerID, callerRole, meta)
 	if err != nil {
 		if err == services.ErrForbidden {
-			c.JSON(http.StatusForbidden, gin.H{"error": "only product owner or admin can modify versions when product is active"})
+			respondError(c, http.StatusForbidden, errVersionEditForbidden)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.JSON(http.StatusOK, resp)
@@ -88,17 +88,17 @@ func (h *ProductVersionHandler) Update(c *gin.Context) {
 func (h *ProductVersionHandler) Delete(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		respondError(c, http.StatusBadRequest, invalidParam("id"))
 		return
 	}
 	callerID, callerRole := h.getCaller(c)
 	meta := middleware.GetAuditMeta(c)
 	if err := h.productVersionService.Delete(c.Request.Context(), id, callerID, callerRole, meta); err != nil {
 		if err == services.ErrForbidden {
-			c.JSON(http.StatusForbidden, gin.H{"error": "only product owner or admin can modify versions when product is active"})
+			respondError(c, http.StatusForbidden, errVersionEditForbidden)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.Status(http.StatusNoContent)
@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rm/roadmap/backend/internal/middleware"
+	"github.com/rm/roadmap/backend/internal/scim"
+	"github.com/rm/roadmap/backend/internal/services"
+	"gorm.io/gorm"
+)
+
+// SCIMHandler serves /scim/v2 for identity providers. Responses use application/scim+json and SCIM error bodies.
+type SCIMHandler struct {
+	scimService *services.SCIMService
+}
+
+func NewSCIMHandler(scimService *services.SCIMService) *SCIMHandler {
+	return &SCIMHandler{scimService: scimService}
+}
+
+func (h *SCIMHandler) ServiceProviderConfig(c *gin.Context) {
+	h.respond(c, http.StatusOK, gin.H{
+		"schemas":        []string{scim.SchemaServiceProvider},
+		"patch":          gin.H{"supported": true},
+		"bulk":           gin.H{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         gin.H{"supported": true, "maxResults": scim.MaxCount},
+		"changePassword": gin.H{"supported": true},
+		"sort":           gin.H{"supported": false},
+		"etag":           gin.H{"supported": false},
+		"authenticationSchemes": []gin.H{{
+			"type":        "oauthbearertoken",
+			"name":        "Bearer token",
+			"description": "Static bearer token configured with SCIM_BEARER_TOKEN",
+			"primary":     true,
+		}},
+	})
+}
+
+func (h *SCIMHandler) ResourceTypes(c *gin.Context) {
+	h.respond(c, http.StatusOK, []gin.H{
+		{
+			"schemas":  []string{scim.SchemaResourceType},
+			"id":       "User",
+			"name":     "User",
+			"endpoint": "/Users",
+			"schema":   scim.SchemaUser,
+			"schemaExtensions": []gin.H{
+				{"schema": scim.SchemaEnterpriseUser, "required": false},
+				{"schema": scim.SchemaRoadmapUser, "required": false},
+			},
+		},
+		{
+			"schemas":          []string{scim.SchemaResourceType},
+			"id":               "Group",
+			"name":             "Group",
+			"endpoint":         "/Groups",
+			"schema":           scim.SchemaGroup,
+			"schemaExtensions": []gin.H{{"schema": scim.SchemaRoadmapGroup, "required": false}},
+		},
+	})
+}
+
+func (h *SCIMHandler) ListUsers(c *gin.Context) {
+	startIndex, count := scimPageParams(c)
+	list, err := h.scimService.ListUsers(c.Request.Context(), c.Query("filter"), startIndex, count)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	h.respond(c, http.StatusOK, list)
+}
+
+func (h *SCIMHandler) GetUser(c *gin.Context) {
+	u, err := h.scimService.GetUser(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	h.respond(c, http.StatusOK, u)
+}
+
+func (h *SCIMHandler) CreateUser(c *gin.Context) {
+	var in scim.User
+	if err := c.ShouldBindJSON(&in); err != nil {
+		h.writeError(c, scim.BadRequest("invalidSyntax", "%s", err.Error()))
+		return
+	}
+	u, err := h.scimService.CreateUser(c.Request.Context(), in, middleware.GetAuditMeta(c))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.Header("Location", u.Meta.Location)
+	h.respond(c, http.StatusCreated, u)
+}
+
+func (h *SCIMHandler) ReplaceUser(c *gin.Context) {
+	var in scim.User
+	if err := c.ShouldBindJSON(&in); err != nil {
+		h.writeError(c, scim.BadRequest("invalidSyntax", "%s", err.Error()))
+		return
+	}
+	u, err := h.scimService.ReplaceUser(c.Request.Context(), c.Param("id"), in, middleware.GetAuditMeta(c))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	h.respond(c, http.StatusOK, u)
+}
+
+func (h *SCIMHandler) PatchUser(c *gin.Context) {
+	var req scim.PatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.writeError(c, scim.BadRequest("invalidSyntax", "%s", err.Error()))
+		return
+	}
+	u, err := h.scimService.PatchUser(c.Request.Context(), c.Param("id"), req, middleware.GetAuditMeta(c))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	h.respond(c, http.StatusOK, u)
+}
+
+func (h *SCIMHandler) DeleteUser(c *gin.Context) {
+	if err := h.scimService.DeleteUser(c.Request.Context(), c.Param("id"), middleware.GetAuditMeta(c)); err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *SCIMHandler) ListGroups(c *gin.Context) {
+	startIndex, count := scimPageParams(c)
+	list, err := h.scimService.ListGroups(c.Request.Context(), c.Query("filter"), startIndex, count, scimWithMembers(c))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	h.respond(c, http.StatusOK, list)
+}
+
+func (h *SCIMHandler) GetGroup(c *gin.Context) {
+	g, err := h.scimService.GetGroup(c.Request.Context(), c.Param("id"), scimWithMembers(c))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	h.respond(c, http.StatusOK, g)
+}
+
+func (h *SCIMHandler) CreateGroup(c *gin.Context) {
+	var in scim.Group
+	if err := c.ShouldBindJSON(&in); err != nil {
+		h.writeError(c, scim.BadRequest("invalidSyntax", "%s", err.Error()))
+		return
+	}
+	g, err := h.scimService.CreateGroup(c.Request.Context(), in, middleware.GetAuditMeta(c))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.Header("Location", g.Meta.Location)
+	h.respond(c, http.StatusCreated, g)
+}
+
+func (h *SCIMHandler) ReplaceGroup(c *gin.Context) {
+	var in scim.Group
+	if err := c.ShouldBindJSON(&in); err != nil {
+		h.writeError(c, scim.BadRequest("invalidSyntax", "%s", err.Error()))
+		return
+	}
+	g, err := h.scimService.ReplaceGroup(c.Request.Context(), c.Param("id"), in, middleware.GetAuditMeta(c))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	h.respond(c, http.StatusOK, g)
+}
+
+func (h *SCIMHandler) PatchGroup(c *gin.Context) {
+	var req scim.PatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.writeError(c, scim.BadRequest("invalidSyntax", "%s", err.Error()))
+		return
+	}
+	g, err := h.scimService.PatchGroup(c.Request.Context(), c.Param("id"), req, middleware.GetAuditMeta(c))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	h.respond(c, http.StatusOK, g)
+}
+
+func (h *SCIMHandler) DeleteGroup(c *gin.Context) {
+	if err := h.scimService.DeleteGroup(c.Request.Context(), c.Param("id"), middleware.GetAuditMeta(c)); err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *SCIMHandler) respond(c *gin.Context, status int, body interface{}) {
+	c.Header("Content-Type", scim.ContentType)
+	c.JSON(status, body)
+}
+
+func (h *SCIMHandler) writeError(c *gin.Context, err error) {
+	var se *scim.Error
+	switch {
+	case errors.As(err, &se):
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		se = &scim.Error{Status: http.StatusNotFound, Detail: "resource not found"}
+	default:
+		se = &scim.Error{Status: http.StatusInternalServerError, Detail: err.Error()}
+	}
+	h.respond(c, se.Status, se)
+}
+
+// scimPageParams reads startIndex and count; count defaults to scim.DefaultCount.
+func scimPageParams(c *gin.Context) (int, int) {
+	startIndex, _ := strconv.Atoi(c.DefaultQuery("startIndex", "1"))
+	count, err := strconv.Atoi(c.DefaultQuery("count", strconv.Itoa(scim.DefaultCount)))
+	if err != nil {
+		count = scim.DefaultCount
+	}
+	return startIndex, count
+}
+
+// scimWithMembers is false when the IdP asks to leave members out (excludedAttributes=members), which keeps
+// group listings cheap for large teams.
+func scimWithMembers(c *gin.Context) bool {
+	for _, a := range strings.Split(c.Query("excludedAttributes"), ",") {
+		if strings.EqualFold(strings.TrimSpace(a), "members") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/dto"
+	"github.com/rm/roadmap/backend/internal/middleware"
+	"github.com/rm/roadmap/backend/internal/repositories"
+	"github.com/rm/roadmap/backend/internal/services"
+)
+
+type SessionHandler struct {
+	sessionService *services.SessionService
+	userRepo       repositories.UserRepository
+}
+
+func NewSessionHandler(sessionService *services.SessionService, userRepo repositories.UserRepository) *SessionHandler {
+	return &SessionHandler{sessionService: sessionService, userRepo: userRepo}
+}
+
+// currentSession returns the caller's user ID and the session the request was authenticated with.
+func (h *SessionHandler) currentSession(c *gin.Context) (uuid.UUID, uuid.UUID) {
+	userID, _ := c.Get(middleware.UserIDKey)
+	sid, _ := c.Get(middleware.SessionIDKey)
+	uid, _ := uuid.Parse(userID.(string))
+	sessionID, _ := uuid.Parse(sid.(string))
+	return uid, sessionID
+}
+
+// ListMine returns the caller's active sessions.
+func (h *SessionHandler) ListMine(c *gin.Context) {
+	userID, sid := h.currentSession(c)
+	list, err := h.sessionService.List(c.Request.Context(), userID, sid)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// RevokeMine revokes one of the caller's sessions (including the current one).
+func (h *SessionHandler) RevokeMine(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, invalidParam("id"))
+		return
+	}
+	userID, _ := h.currentSession(c)
+	h.revoke(c, userID, id)
+}
+
+// RevokeAllMine revokes the caller's other sessions; ?include_current=true signs out everywhere.
+func (h *SessionHandler) RevokeAllMine(c *gin.Context) {
+	userID, sid := h.currentSession(c)
+	var except []uuid.UUID
+	if c.Query("include_current") != "true" {
+		except = []uuid.UUID{sid}
+	}
+	n, err := h.sessionService.RevokeAllForUser(c.Request.Context(), userID, except, middleware.GetAuditMeta(c))
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, dto.SessionRevokeAllResponse{Revoked: n})
+}
+
+// ListForUser returns a user's active sessions. Requires session:manage.
+func (h *SessionHandler) ListForUser(c *gin.Context) {
+	userID, ok := h.targetUser(c)
+	if !ok {
+		return
+	}
+	_, sid := h.currentSession(c)
+	list, err := h.sessionService.List(c.Request.Context(), userID, sid)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// RevokeForUser revokes one session of a user. Requires session:manage.
+func (h *SessionHandler) RevokeForUser(c *gin.Context) {
+	userID, ok := h.targetUser(c)
+	if !ok {
+		return
+	}
+	id, err := uuid.Parse(c.Param("session_id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, invalidParam("session_id"))
+		return
+	}
+	h.revoke(c, userID, id)
+}
+
+// RevokeAllForUser revokes every session of a user. Requires session:manage.
+func (h *SessionHandler) RevokeAllForUser(c *gin.Context) {
+	userID, ok := h.targetUser(c)
+	if !ok {
+		return
+	}
+	n, err := h.sessionService.RevokeAllForUser(c.Request.Context(), userID, nil, middleware.GetAuditMeta(c))
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, dto.SessionRevokeAllResponse{Revoked: n})
+}
+
+func (h *SessionHandler) targetUser(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, invalidParam("id"))
+		return uuid.Nil, false
+	}
+	if _, err := h.userRepo.GetByID(id); err != nil {
+		respondError(c, http.StatusNotFound, services.ErrUserNotFound)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (h *SessionHandler) revoke(c *gin.Context, userID, sessionID uuid.UUID) {
+	if err := h.sessionService.Revoke(c.Request.Context(), userID, sessionID, middleware.GetAuditMeta(c)); err != nil {
+		if err == services.ErrSessionNotFound {
+			respondError(c, http.StatusNotFound, err)
+			return
+		}
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -1,12 +1,16 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/authz"
 	"github.com/rm/roadmap/backend/internal/dto"
+	"github.com/rm/roadmap/backend/internal/i18n"
 	"github.com/rm/roadmap/backend/internal/middleware"
 	"github.com/rm/roadmap/backend/internal/models"
 	"github.com/rm/roadmap/backend/internal/repositories"
@@ -17,10 +21,15 @@ type UserHandler struct {
 	userRepo    repositories.UserRepository
 	dottedRepo  repositories.UserDottedLineRepository
 	productRepo repositories.ProductRepository
+	memberRepo  repositories.ProductMemberRepository
+	sessionSvc  *services.SessionService
+	tx          repositories.Transactor
+	auditSvc    *services.AuditService
+	policy      *authz.Engine
 }
 
-func NewUserHandler(userRepo repositories.UserRepository, dottedRepo repositories.UserDottedLineRepository, productRepo repositories.ProductRepository) *UserHandler {
-	return &UserHandler{userRepo: userRepo, dottedRepo: dottedRepo, productRepo: productRepo}
+func NewUserHandler(userRepo repositories.UserRepository, dottedRepo repositories.UserDottedLineRepository, productRepo repositories.ProductRepository, memberRepo repositories.ProductMemberRepository, sessionSvc *services.SessionService, tx repositories.Transactor, auditSvc *services.AuditService, policy *authz.Engine) *UserHandler {
+	return &UserHandler{userRepo: userRepo, dottedRepo: dottedRepo, productRepo: productRepo, memberRepo: memberRepo, sessionSvc: sessionSvc, tx: tx, auditSvc: auditSvc, policy: policy}
 }
 
 func (h *UserHandler) List(c *gin.Context) {
@@ -37,7 +46,7 @@ func (h *UserHandler) List(c *gin.Context) {
 	}
 	users, err := h.userRepo.List(teamID, directManagerID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	out := make([]dto.UserResponse, len(users))
@@ -50,12 +59,12 @@ func (h *UserHandler) List(c *gin.Context) {
 func (h *UserHandler) GetByID(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		respondError(c, http.StatusBadRequest, invalidParam("id"))
 		return
 	}
 	u, err := h.userRepo.GetByID(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		respondError(c, http.StatusNotFound, services.ErrUserNotFound)
 		return
 	}
 	c.JSON(http.StatusOK, services.UserToResponse(u))
@@ -64,35 +73,33 @@ func (h *UserHandler) GetByID(c *gin.Context) {
 func (h *UserHandler) Update(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		respondError(c, http.StatusBadRequest, invalidParam("id"))
 		return
 	}
 	var req dto.UserUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, err)
 		return
 	}
 	u, err := h.userRepo.GetByID(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		respondError(c, http.StatusNotFound, services.ErrUserNotFound)
 		return
 	}
-	callerRoleStr, _ := c.Get(middleware.UserRoleKey)
-	callerRole := models.Role("")
-	if r, ok := callerRoleStr.(string); ok {
-		callerRole = models.Role(r)
-	}
-	// Only superadmin can set role to superadmin or modify a user who is superadmin
+	// Setting role to superadmin or modifying a superadmin user needs user:grant_superadmin
+	canGrantSuperadmin := h.policy.Allowed(c.Request.Context(), middleware.GetSubject(c), authz.PermUserGrantSuperadmin, authz.Any)
 	if req.Role != nil && strings.ToLower(*req.Role) == "superadmin" {
-		if strings.ToLower(string(callerRole)) != "superadmin" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "only superadmin can set role to superadmin"})
+		if !canGrantSuperadmin {
+			respondError(c, http.StatusForbidden, errSuperadminRoleOnly)
 			return
 		}
 	}
-	if strings.ToLower(string(u.Role)) == "superadmin" && strings.ToLower(string(callerRole)) != "superadmin" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "only superadmin can modify a superadmin user"})
+	if strings.ToLower(string(u.Role)) == "superadmin" && !canGrantSuperadmin {
+		respondError(c, http.StatusForbidden, errSuperadminUserOnly)
 		return
 	}
+	oldData := services.ToJSONB(services.UserToResponse(u))
+	oldRole := u.Role
 	if req.Name != nil {
 		u.Name = *req.Name
 	}
@@ -105,7 +112,7 @@ func (h *UserHandler) Update(c *gin.Context) {
 		} else {
 			tid, err := uuid.Parse(*req.TeamID)
 			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid team_id"})
+				respondError(c, http.StatusBadRequest, invalidParam("team_id"))
 				return
 			}
 			u.TeamID = &tid
@@ -117,20 +124,20 @@ func (h *UserHandler) Update(c *gin.Context) {
 		} else {
 			mid, err := uuid.Parse(*req.DirectManagerID)
 			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid direct_manager_id"})
+				respondError(c, http.StatusBadRequest, invalidParam("direct_manager_id"))
 				return
 			}
 			if mid == id {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "user cannot be their own manager"})
+				respondError(c, http.StatusBadRequest, errOwnManager)
 				return
 			}
-			depth, err := h.userRepo.ManagerChainDepth(mid)
+			depth, err := h.userRepo.ManagerChainDepth(c.Request.Context(), mid)
 			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				respondError(c, http.StatusBadRequest, err)
 				return
 			}
 			if depth >= repositories.MaxManagerHierarchyDepth {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "manager hierarchy would exceed maximum depth (16)"})
+				respondError(c, http.StatusBadRequest, repositories.ErrManagerHierarchyTooDeep)
 				return
 			}
 			u.DirectManagerID = &mid
@@ -139,32 +146,43 @@ func (h *UserHandler) Update(c *gin.Context) {
 	// Clear associations so GORM persists team_id and direct_manager_id columns
 	u.Team = nil
 	u.DirectManager = nil
-	if err := h.userRepo.Update(u); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-	// Validate manager chain (depth and cycle) after update
-	if u.DirectManagerID != nil {
-		if _, err := h.userRepo.ManagerChainDepth(id); err != nil {
-			u.DirectManagerID = nil
-			u.DirectManager = nil
-			_ = h.userRepo.Update(u)
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
+	resp := services.UserToResponse(u)
+	// Role changes get their own action so they can be searched for and alerted on.
+	action := "update"
+	if u.Role != oldRole {
+		action = "update_role"
+	}
+	err = h.tx.InTx(c.Request.Context(), func(ctx context.Context) error {
+		if err := h.userRepo.Update(ctx, u); err != nil {
+			return err
 		}
+		// Validate manager chain (depth and cycle) after update; a bad chain rolls the update back
+		if u.DirectManagerID != nil {
+			if _, err := h.userRepo.ManagerChainDepth(ctx, id); err != nil {
+				return err
+			}
+		}
+		return h.auditSvc.Record(ctx, middleware.GetAuditMeta(c), action, "user", id.String(), oldData, services.ToJSONB(resp), nil)
+	})
+	if errors.Is(err, repositories.ErrManagerCycle) || errors.Is(err, repositories.ErrManagerHierarchyTooDeep) {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	} else if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
 	}
-	c.JSON(http.StatusOK, services.UserToResponse(u))
+	c.JSON(http.StatusOK, resp)
 }
 
 func (h *UserHandler) ListDottedLineManagers(c *gin.Context) {
 	userID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		respondError(c, http.StatusBadRequest, invalidParam("id"))
 		return
 	}
 	list, err := h.dottedRepo.ListByUserID(userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	out := make([]dto.UserDottedLineManagerResponse, len(list))
@@ -186,60 +204,90 @@ func (h *UserHandler) ListDottedLineManagers(c *gin.Context) {
 func (h *UserHandler) AddDottedLineManager(c *gin.Context) {
 	userID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		respondError(c, http.StatusBadRequest, invalidParam("id"))
 		return
 	}
 	var body struct {
 		ManagerID string `json:"manager_id" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "manager_id required"})
+		respondError(c, http.StatusBadRequest, missingParam("manager_id"))
 		return
 	}
 	managerID, err := uuid.Parse(body.ManagerID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid manager_id"})
+		respondError(c, http.StatusBadRequest, invalidParam("manager_id"))
 		return
 	}
 	if userID == managerID {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "user cannot have themselves as dotted-line manager"})
+		respondError(c, http.StatusBadRequest, errOwnDottedLineManager)
 		return
 	}
 	ok, err := h.dottedRepo.Exists(userID, managerID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	if ok {
-		c.JSON(http.StatusConflict, gin.H{"error": "already added"})
+		respondError(c, http.StatusConflict, errDottedLineManagerExists)
 		return
 	}
 	ud := &models.UserDottedLineManager{UserID: userID, ManagerID: managerID}
-	if err := h.dottedRepo.Create(ud); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	var resp dto.UserDottedLineManagerResponse
+	if err := h.tx.InTx(c.Request.Context(), func(ctx context.Context) error {
+		if err := h.dottedRepo.Create(ctx, ud); err != nil {
+			return err
+		}
+		resp = dto.UserDottedLineManagerResponse{
+			ID:        ud.ID.String(),
+			UserID:    ud.UserID.String(),
+			ManagerID: ud.ManagerID.String(),
+			CreatedAt: ud.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		}
+		return h.auditSvc.Record(ctx, middleware.GetAuditMeta(c), "add_dotted_line_manager", "user", userID.String(),
+			nil, services.ToJSONB(resp), h.withUserName(userID, models.JSONB{"manager_id": resp.ManagerID}))
+	}); err != nil {
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
-	c.JSON(http.StatusCreated, dto.UserDottedLineManagerResponse{
-		ID:        ud.ID.String(),
-		UserID:    ud.UserID.String(),
-		ManagerID: ud.ManagerID.String(),
-		CreatedAt: ud.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	})
+	c.JSON(http.StatusCreated, resp)
 }
 
 func (h *UserHandler) RemoveDottedLineManager(c *gin.Context) {
 	userID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		respondError(c, http.StatusBadRequest, invalidParam("id"))
 		return
 	}
 	managerID, err := uuid.Parse(c.Param("manager_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid manager_id"})
+		respondError(c, http.StatusBadRequest, invalidParam("manager_id"))
 		return
 	}
-	if err := h.dottedRepo.Delete(userID, managerID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	var oldData models.JSONB
+	if list, err := h.dottedRepo.ListByUserID(userID); err == nil {
+		for i := range list {
+			if list[i].ManagerID == managerID {
+				oldData = services.ToJSONB(dto.UserDottedLineManagerResponse{
+					ID:        list[i].ID.String(),
+					UserID:    list[i].UserID.String(),
+					ManagerID: list[i].ManagerID.String(),
+					CreatedAt: list[i].CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+				})
+			}
+		}
+	}
+	if err := h.tx.InTx(c.Request.Context(), func(ctx context.Context) error {
+		if err := h.dottedRepo.Delete(ctx, userID, managerID); err != nil {
+			return err
+		}
+		if oldData == nil {
+			return nil // nothing was removed
+		}
+		return h.auditSvc.Record(ctx, middleware.GetAuditMeta(c), "remove_dotted_line_manager", "user", userID.String(),
+			oldData, nil, h.withUserName(userID, models.JSONB{"manager_id": managerID.String()}))
+	}); err != nil {
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.Status(http.StatusNoContent)
@@ -248,15 +296,29 @@ func (h *UserHandler) RemoveDottedLineManager(c *gin.Context) {
 func (h *UserHandler) RemoveFromProducts(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		respondError(c, http.StatusBadRequest, invalidParam("id"))
 		return
 	}
 	if _, err := h.userRepo.GetByID(id); err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		respondError(c, http.StatusNotFound, services.ErrUserNotFound)
+		return
+	}
+	oldData, err := h.productLinks(c, id)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
-	if err := h.productRepo.ClearOwnerForUser(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.tx.InTx(c.Request.Context(), func(ctx context.Context) error {
+		if err := h.productRepo.ClearOwnerForUser(ctx, id); err != nil {
+			return err
+		}
+		if err := h.memberRepo.DeleteByUser(ctx, id); err != nil {
+			return err
+		}
+		return h.auditSvc.Record(ctx, middleware.GetAuditMeta(c), "remove_from_products", "user", id.String(),
+			oldData, models.JSONB{"owned_product_ids": []string{}, "member_product_ids": []string{}}, h.withUserName(id, nil))
+	}); err != nil {
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.Status(http.StatusNoContent)
@@ -265,31 +327,135 @@ func (h *UserHandler) RemoveFromProducts(c *gin.Context) {
 func (h *UserHandler) Delete(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		respondError(c, http.StatusBadRequest, invalidParam("id"))
 		return
 	}
 	callerIDStr, _ := c.Get(middleware.UserIDKey)
 	callerID, _ := uuid.Parse(callerIDStr.(string))
 	if id == callerID {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot delete your own user"})
+		respondError(c, http.StatusBadRequest, errDeleteSelf)
 		return
 	}
 	u, err := h.userRepo.GetByID(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		respondError(c, http.StatusNotFound, services.ErrUserNotFound)
 		return
 	}
 	if strings.ToLower(string(u.Role)) == "superadmin" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "cannot delete a superadmin user"})
+		respondError(c, http.StatusForbidden, errDeleteSuperadmin)
 		return
 	}
-	if err := h.productRepo.ClearOwnerForUser(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	links, err := h.productLinks(c, id)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
-	if err := h.userRepo.Delete(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	meta := middleware.GetAuditMeta(c)
+	if err := h.tx.InTx(c.Request.Context(), func(ctx context.Context) error {
+		if err := h.productRepo.ClearOwnerForUser(ctx, id); err != nil {
+			return err
+		}
+		if err := h.memberRepo.DeleteByUser(ctx, id); err != nil {
+			return err
+		}
+		// Revoke before deleting so tokens already issued to the user stop working immediately.
+		if _, err := h.sessionSvc.RevokeAllForUser(ctx, id, nil, meta); err != nil {
+			return err
+		}
+		if err := h.userRepo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return h.auditSvc.Record(ctx, meta, "delete", "user", id.String(), services.ToJSONB(services.UserToResponse(u)), nil, links)
+	}); err != nil {
+		respondError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.Status(http.StatusNoContent)
 }
+
+func (h *UserHandler) getCallerID(c *gin.Context) uuid.UUID {
+	userID, _ := c.Get(middleware.UserIDKey)
+	id, _ := uuid.Parse(userID.(string))
+	return id
+}
+
+// GetLocale returns the caller's locale and the locales there are catalogs for.
+func (h *UserHandler) GetLocale(c *gin.Context) {
+	u, err := h.userRepo.GetByID(h.getCallerID(c))
+	if err != nil {
+		respondError(c, http.StatusNotFound, services.ErrUserNotFound)
+		return
+	}
+	c.JSON(http.StatusOK, dto.LocaleResponse{Locale: u.Locale, Supported: i18n.Supported()})
+}
+
+// UpdateLocale sets the locale the caller's notifications and email are rendered in.
+func (h *UserHandler) UpdateLocale(c *gin.Context) {
+	var req dto.LocaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	locale := i18n.Normalize(req.Locale)
+	if locale == "" && strings.TrimSpace(req.Locale) != "" {
+		respondError(c, http.StatusBadRequest, errUnsupportedLocale)
+		return
+	}
+	id := h.getCallerID(c)
+	u, err := h.userRepo.GetByID(id)
+	if err != nil {
+		respondError(c, http.StatusNotFound, services.ErrUserNotFound)
+		return
+	}
+	if u.Locale != locale {
+		old := u.Locale
+		u.Locale = locale
+		u.Team = nil
+		u.DirectManager = nil
+		if err := h.tx.InTx(c.Request.Context(), func(ctx context.Context) error {
+			if err := h.userRepo.Update(ctx, u); err != nil {
+				return err
+			}
+			return h.auditSvc.Record(ctx, middleware.GetAuditMeta(c), "update_locale", "user", id.String(),
+				models.JSONB{"locale": old}, models.JSONB{"locale": locale}, models.JSONB{"user_name": u.Name})
+		}); err != nil {
+			respondError(c, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	c.JSON(http.StatusOK, dto.LocaleResponse{Locale: u.Locale, Supported: i18n.Supported()})
+}
+
+// productLinks snapshots the products a user owns and is a member of, for auditing their removal.
+func (h *UserHandler) productLinks(c *gin.Context, id uuid.UUID) (models.JSONB, error) {
+	owned, err := h.productRepo.ListIDsByOwners([]uuid.UUID{id})
+	if err != nil {
+		return nil, err
+	}
+	member, err := h.memberRepo.ListProductIDsForUser(c.Request.Context(), id)
+	if err != nil {
+		return nil, err
+	}
+	ids := func(in []uuid.UUID) []string {
+		out := make([]string, len(in))
+		for i := range in {
+			out[i] = in[i].String()
+		}
+		return out
+	}
+	return models.JSONB{"owned_product_ids": ids(owned), "member_product_ids": ids(member)}, nil
+}
+
+// withUserName adds the name of user id to metadata, for entries whose data is not the user itself, so that
+// the log reads without a lookup.
+func (h *UserHandler) withUserName(id uuid.UUID, metadata models.JSONB) models.JSONB {
+	u, err := h.userRepo.GetByID(id)
+	if err != nil {
+		return metadata
+	}
+	if metadata == nil {
+		metadata = models.JSONB{}
+	}
+	metadata["user_name"] = u.Name
+	return metadata
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/dto"
+	"github.com/rm/roadmap/backend/internal/middleware"
+	"github.com/rm/roadmap/backend/internal/models"
+	"github.com/rm/roadmap/backend/internal/services"
+)
+
+type WatchHandler struct {
+	svc *services.WatchService
+}
+
+func NewWatchHandler(svc *services.WatchService) *WatchHandler {
+	return &WatchHandler{svc: svc}
+}
+
+func (h *WatchHandler) getCaller(c *gin.Context) (uuid.UUID, models.Role) {
+	userID, _ := c.Get(middleware.UserIDKey)
+	role, _ := c.Get(middleware.UserRoleKey)
+	id, _ := uuid.Parse(userID.(string))
+	roleStr, _ := role.(string)
+	return id, models.Role(roleStr)
+}
+
+// List returns what the caller watches.
+func (h *WatchHandler) List(c *gin.Context) {
+	callerID, _ := h.getCaller(c)
+	list, err := h.svc.List(c.Request.Context(), callerID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// Watch subscribes the caller to a product, version or group: 201 when new, 200 when already watched.
+func (h *WatchHandler) Watch(c *gin.Context) {
+	var req dto.WatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	callerID, callerRole := h.getCaller(c)
+	resp, created, err := h.svc.Watch(c.Request.Context(), req, callerID, callerRole)
+	if err != nil {
+		watchError(c, err)
+		return
+	}
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, resp)
+}
+
+// Unwatch removes the caller's watch of /watches/:entity_type/:entity_id.
+func (h *WatchHandler) Unwatch(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("entity_id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, invalidParam("entity id"))
+		return
+	}
+	callerID, _ := h.getCaller(c)
+	if err := h.svc.Unwatch(c.Request.Context(), c.Param("entity_type"), id, callerID); err != nil {
+		watchError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func watchError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrWatchInvalid):
+		respondError(c, http.StatusBadRequest, err)
+	case errors.Is(err, services.ErrWatchEntityNotFound), errors.Is(err, services.ErrWatchNotFound):
+		respondError(c, http.StatusNotFound, err)
+	case errors.Is(err, services.ErrForbidden):
+		respondError(c, http.StatusForbidden, err)
+	default:
+		respondError(c, http.StatusInternalServerError, err)
+	}
+}
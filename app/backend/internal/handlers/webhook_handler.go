@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/dto"
+	"github.com/rm/roadmap/backend/internal/middleware"
+	"github.com/rm/roadmap/backend/internal/models"
+	"github.com/rm/roadmap/backend/internal/services"
+)
+
+type WebhookHandler struct {
+	svc *services.WebhookService
+}
+
+func NewWebhookHandler(svc *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{svc: svc}
+}
+
+func (h *WebhookHandler) List(c *gin.Context) {
+	list, err := h.svc.List(c.Request.Context())
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+func (h *WebhookHandler) Get(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, invalidParam("id"))
+		return
+	}
+	resp, err := h.svc.Get(c.Request.Context(), id)
+	if err != nil {
+		webhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Create adds a subscription; the response is the only one that shows the signing secret.
+func (h *WebhookHandler) Create(c *gin.Context) {
+	var req dto.WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	resp, err := h.svc.Create(c.Request.Context(), req, middleware.GetAuditMeta(c))
+	if err != nil {
+		webhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+func (h *WebhookHandler) Update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, invalidParam("id"))
+		return
+	}
+	var req dto.WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err)
+		return
+	}
+	resp, err := h.svc.Update(c.Request.Context(), id, req, middleware.GetAuditMeta(c))
+	if err != nil {
+		webhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, invalidParam("id"))
+		return
+	}
+	if err := h.svc.Delete(c.Request.Context(), id, middleware.GetAuditMeta(c)); err != nil {
+		webhookError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Deliveries returns the subscription's delivery log, newest first; ?status= is pending, delivered or dead.
+func (h *WebhookHandler) Deliveries(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, invalidParam("id"))
+		return
+	}
+	status := c.Query("status")
+	switch status {
+	case "", models.WebhookDeliveryPending, models.WebhookDeliveryDelivered, models.WebhookDeliveryDead:
+	default:
+		respondError(c, http.StatusBadRequest, errInvalidDeliveryStatus)
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit < 1 || limit > 100 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	resp, err := h.svc.ListDeliveries(c.Request.Context(), id, status, limit, offset)
+	if err != nil {
+		webhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Delivery returns one delivery with its payload and attempts.
+func (h *WebhookHandler) Delivery(c *gin.Context) {
+	id, deliveryID, ok := webhookDeliveryParams(c)
+	if !ok {
+		return
+	}
+	resp, err := h.svc.GetDelivery(c.Request.Context(), id, deliveryID)
+	if err != nil {
+		webhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Redeliver queues a delivery to be sent again, including dead and already delivered ones.
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	id, deliveryID, ok := webhookDeliveryParams(c)
+	if !ok {
+		return
+	}
+	resp, err := h.svc.Redeliver(c.Request.Context(), id, deliveryID, middleware.GetAuditMeta(c))
+	if err != nil {
+		webhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, resp)
+}
+
+func webhookDeliveryParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, invalidParam("id"))
+		return uuid.Nil, uuid.Nil, false
+	}
+	deliveryID, err := uuid.Parse(c.Param("delivery_id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, invalidParam("delivery id"))
+		return uuid.Nil, uuid.Nil, false
+	}
+	return id, deliveryID, true
+}
+
+func webhookError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrWebhookInvalid), errors.Is(err, services.ErrWebhookDestination):
+		respondError(c, http.StatusBadRequest, err)
+	case errors.Is(err, services.ErrWebhookNotFound), errors.Is(err, services.ErrWebhookDeliveryNotFound):
+		respondError(c, http.StatusNotFound, err)
+	default:
+		respondError(c, http.StatusInternalServerError, err)
+	}
+}
@@ -0,0 +1,140 @@
+// Package i18n holds the message catalogs notifications, email and API errors are rendered from.
+//
+// A catalog is a flat JSON object in locales/<locale>.json mapping a key to a template; "{name}" in a
+// template is replaced by the parameter of that name. English is the default locale and the reference
+// catalog: a key missing from another locale falls back to English, and one missing from English renders as
+// the key itself, so a lookup never fails.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLocale is used when neither the user nor the request names a supported locale.
+const DefaultLocale = "en"
+
+//go:embed locales/*.json
+var localeFS embed.FS
+
+// catalogs maps a locale to its messages; it is loaded once from the embedded files.
+var catalogs = mustLoad()
+
+func mustLoad() map[string]map[string]string {
+	files, err := localeFS.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	out := make(map[string]map[string]string, len(files))
+	for _, f := range files {
+		raw, err := localeFS.ReadFile(path.Join("locales", f.Name()))
+		if err != nil {
+			panic(err)
+		}
+		var c map[string]string
+		if err := json.Unmarshal(raw, &c); err != nil {
+			panic(fmt.Sprintf("i18n: %s: %v", f.Name(), err))
+		}
+		out[strings.TrimSuffix(f.Name(), ".json")] = c
+	}
+	if out[DefaultLocale] == nil {
+		panic("i18n: no catalog for the default locale")
+	}
+	return out
+}
+
+// Supported lists the locales with a catalog, the default first.
+func Supported() []string {
+	out := make([]string, 0, len(catalogs))
+	for l := range catalogs {
+		if l != DefaultLocale {
+			out = append(out, l)
+		}
+	}
+	sort.Strings(out)
+	return append([]string{DefaultLocale}, out...)
+}
+
+// Normalize maps a language tag to the supported locale of its language, e.g. "de-AT" and "DE" to "de". It
+// returns "" when the language has no catalog.
+func Normalize(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	if _, ok := catalogs[tag]; !ok {
+		return ""
+	}
+	return tag
+}
+
+// FromAcceptLanguage picks the supported locale an Accept-Language header prefers most, or DefaultLocale.
+// Ties keep the header's order.
+func FromAcceptLanguage(header string) string {
+	best, bestQ := DefaultLocale, 0.0
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = f
+		}
+		if l := Normalize(tag); l != "" && q > bestQ {
+			best, bestQ = l, q
+		}
+	}
+	return best
+}
+
+// Has reports whether the default catalog has key.
+func Has(key string) bool {
+	_, ok := catalogs[DefaultLocale][key]
+	return ok
+}
+
+// T renders key in locale with params substituted.
+func T(locale, key string, params map[string]string) string {
+	tmpl, ok := catalogs[Normalize(locale)][key]
+	if !ok {
+		if tmpl, ok = catalogs[DefaultLocale][key]; !ok {
+			return key
+		}
+	}
+	return substitute(tmpl, params)
+}
+
+// substitute replaces each "{name}" in tmpl with params[name]; unknown names are left as they are.
+func substitute(tmpl string, params map[string]string) string {
+	if len(params) == 0 || !strings.Contains(tmpl, "{") {
+		return tmpl
+	}
+	var b strings.Builder
+	for {
+		open := strings.IndexByte(tmpl, '{')
+		if open < 0 {
+			break
+		}
+		end := strings.IndexByte(tmpl[open:], '}')
+		if end < 0 {
+			break
+		}
+		name := tmpl[open+1 : open+end]
+		v, ok := params[name]
+		if !ok {
+			v = tmpl[open : open+end+1]
+		}
+		b.WriteString(tmpl[:open])
+		b.WriteString(v)
+		tmpl = tmpl[open+end+1:]
+	}
+	b.WriteString(tmpl)
+	return b.String()
+}
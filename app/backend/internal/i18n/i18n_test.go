@@ -0,0 +1,85 @@
+package i18n
+
+import (
+	"regexp"
+	"sort"
+	"testing"
+)
+
+var placeholder = regexp.MustCompile(`\{[a-z_]+\}`)
+
+// TestCatalogsMatch guards against a key or a placeholder added to one catalog and not the others.
+func TestCatalogsMatch(t *testing.T) {
+	ref := catalogs[DefaultLocale]
+	for locale, c := range catalogs {
+		if locale == DefaultLocale {
+			continue
+		}
+		for key, tmpl := range ref {
+			other, ok := c[key]
+			if !ok {
+				t.Errorf("%s: missing %q", locale, key)
+				continue
+			}
+			if want, got := placeholders(tmpl), placeholders(other); want != got {
+				t.Errorf("%s: %q has placeholders %s, want %s", locale, key, got, want)
+			}
+		}
+		for key := range c {
+			if _, ok := ref[key]; !ok {
+				t.Errorf("%s: %q is not in the %s catalog", locale, key, DefaultLocale)
+			}
+		}
+	}
+}
+
+func placeholders(tmpl string) string {
+	names := placeholder.FindAllString(tmpl, -1)
+	sort.Strings(names)
+	out := ""
+	for i, n := range names {
+		if i > 0 && names[i-1] == n {
+			continue
+		}
+		out += n
+	}
+	return out
+}
+
+func TestFromAcceptLanguage(t *testing.T) {
+	for header, want := range map[string]string{
+		"":                           "en",
+		"de":                         "de",
+		"de-AT,de;q=0.9,en;q=0.8":    "de",
+		"fr-FR,fr;q=0.9,de;q=0.5":    "de",
+		"en-US,en;q=0.9,de;q=0.8":    "en",
+		"fr;q=0.9,de;q=0.4,en;q=0.6": "en",
+		"*":                          "en",
+		"de;q=bogus":                 "en",
+	} {
+		if got := FromAcceptLanguage(header); got != want {
+			t.Errorf("FromAcceptLanguage(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	m := M("notification.product_status_changed.message", "product", "Atlas").
+		Append(M("notification.product_status_changed.status").Term("status", "term.product_status.approved"))
+	en, de := m.Render("en"), m.Render("de-DE")
+	if want := `An admin has updated your product "Atlas". Status is now: approved.`; en != want {
+		t.Errorf("rendered %q, want %q", en, want)
+	}
+	if en == de {
+		t.Fatalf("German rendering is the English one: %q", en)
+	}
+	if got := m.Render("fr"); got != en {
+		t.Errorf("unsupported locale rendered %q, want the English %q", got, en)
+	}
+	if got := M("no.such.key").Render("de"); got != "no.such.key" {
+		t.Errorf("missing key rendered %q", got)
+	}
+	if got := M("notification.product_status_changed.status").Term("status", "term.product_status.unheard_of").Render("de"); got != "Der Status ist jetzt: unheard_of." {
+		t.Errorf("missing term rendered %q", got)
+	}
+}
@@ -0,0 +1,74 @@
+package i18n
+
+import "strings"
+
+// Message is a catalog key with its parameters, kept instead of rendered text so that it can be rendered
+// later in the reader's locale. It is stored as JSON with notifications.
+type Message struct {
+	Key    string            `json:"key"`
+	Params map[string]string `json:"params,omitempty"`
+	// Terms are parameters whose value is itself a catalog key, such as a role or a status, rendered in the
+	// same locale. A term missing from the catalogs renders as its last key segment.
+	Terms map[string]string `json:"terms,omitempty"`
+	// Then are sentences appended to the message, each separated by a space.
+	Then []Message `json:"then,omitempty"`
+}
+
+// M builds a message from key and name/value pairs of parameters.
+func M(key string, pairs ...string) Message {
+	m := Message{Key: key}
+	if len(pairs) > 1 {
+		m.Params = make(map[string]string, len(pairs)/2)
+		for i := 0; i+1 < len(pairs); i += 2 {
+			m.Params[pairs[i]] = pairs[i+1]
+		}
+	}
+	return m
+}
+
+// Term returns m with the parameter name rendered from the catalog key key.
+func (m Message) Term(name, key string) Message {
+	terms := make(map[string]string, len(m.Terms)+1)
+	for k, v := range m.Terms {
+		terms[k] = v
+	}
+	terms[name] = key
+	m.Terms = terms
+	return m
+}
+
+// Append returns m followed by the sentences next.
+func (m Message) Append(next ...Message) Message {
+	m.Then = append(m.Then[:len(m.Then):len(m.Then)], next...)
+	return m
+}
+
+// Render renders m in locale.
+func (m Message) Render(locale string) string {
+	params := m.Params
+	if len(m.Terms) > 0 {
+		params = make(map[string]string, len(m.Params)+len(m.Terms))
+		for k, v := range m.Params {
+			params[k] = v
+		}
+		for k, key := range m.Terms {
+			v := T(locale, key, nil)
+			if v == key {
+				v = key[strings.LastIndexByte(key, '.')+1:]
+			}
+			params[k] = v
+		}
+	}
+	out := T(locale, m.Key, params)
+	for _, next := range m.Then {
+		if s := next.Render(locale); s != "" {
+			out += " " + s
+		}
+	}
+	return out
+}
+
+// String renders m in the default locale.
+func (m Message) String() string {
+	return m.Render(DefaultLocale)
+}
@@ -0,0 +1,232 @@
+// Package mail renders notification email from templates and delivers it over SMTP.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TLS modes for SMTPConfig.TLS.
+const (
+	TLSStartTLS = "starttls" // plain connection upgraded with STARTTLS; the server must offer it
+	TLSImplicit = "tls"      // TLS from the first byte (SMTPS, usually port 465)
+	TLSNone     = "none"     // no encryption; local relays and tests only
+)
+
+// Message is one email. Text is required; HTML, if set, is sent as the preferred alternative.
+type Message struct {
+	To      string // recipient address
+	ToName  string // recipient display name, optional
+	Subject string
+	Text    string
+	HTML    string
+	Headers map[string]string // extra headers such as List-Unsubscribe
+}
+
+// Sender delivers a message.
+type Sender interface {
+	Send(ctx context.Context, m *Message) error
+}
+
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string // empty = no AUTH
+	Password string
+	From     string // e.g. "Roadmap <roadmap@example.com>"
+	TLS      string // TLSStartTLS (default), TLSImplicit or TLSNone
+	Timeout  time.Duration
+}
+
+// SMTPSender sends each message on its own connection, which keeps it safe for concurrent use and free of
+// idle-connection handling; notification volume does not call for pooling.
+type SMTPSender struct {
+	cfg  SMTPConfig
+	from *netmail.Address
+}
+
+func NewSMTPSender(cfg SMTPConfig) (*SMTPSender, error) {
+	if cfg.Host == "" {
+		return nil, errors.New("mail: SMTP host is required")
+	}
+	if cfg.Port <= 0 {
+		cfg.Port = 587
+	}
+	switch cfg.TLS {
+	case "":
+		cfg.TLS = TLSStartTLS
+	case TLSStartTLS, TLSImplicit, TLSNone:
+	default:
+		return nil, fmt.Errorf("mail: TLS mode %q must be starttls, tls or none", cfg.TLS)
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 15 * time.Second
+	}
+	from, err := netmail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("mail: bad from address %q: %w", cfg.From, err)
+	}
+	return &SMTPSender{cfg: cfg, from: from}, nil
+}
+
+// Send delivers m. Errors the server reports with a 5xx code are permanent (see IsPermanent); anything else,
+// including connection failures, is worth retrying.
+func (s *SMTPSender) Send(ctx context.Context, m *Message) error {
+	if _, err := netmail.ParseAddress(m.To); err != nil {
+		return &textproto.Error{Code: 553, Msg: "bad recipient address " + strconv.Quote(m.To)}
+	}
+	body, err := buildMessage(s.from, m)
+	if err != nil {
+		return err
+	}
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	d := &net.Dialer{Timeout: s.cfg.Timeout}
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(s.cfg.Timeout)
+	if dl, ok := ctx.Deadline(); ok && dl.Before(deadline) {
+		deadline = dl
+	}
+	_ = conn.SetDeadline(deadline)
+	tlsConfig := &tls.Config{ServerName: s.cfg.Host, MinVersion: tls.VersionTLS12}
+	if s.cfg.TLS == TLSImplicit {
+		conn = tls.Client(conn, tlsConfig)
+	}
+	c, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if s.cfg.TLS == TLSStartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return errors.New("mail: server does not offer STARTTLS")
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if s.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(s.from.Address); err != nil {
+		return err
+	}
+	if err := c.Rcpt(m.To); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// IsPermanent reports whether err is a permanent SMTP failure (5xx), such as an unknown mailbox, that
+// retrying will not fix.
+func IsPermanent(err error) bool {
+	var te *textproto.Error
+	return errors.As(err, &te) && te.Code >= 500 && te.Code < 600
+}
+
+// buildMessage renders m as an RFC 5322 message: multipart/alternative with quoted-printable text and HTML
+// parts, or a single text part when there is no HTML.
+func buildMessage(from *netmail.Address, m *Message) ([]byte, error) {
+	var b bytes.Buffer
+	to := &netmail.Address{Name: m.ToName, Address: m.To}
+	header := func(k, v string) {
+		// Values come from notification content; never let them start a new header.
+		v = strings.NewReplacer("\r", " ", "\n", " ").Replace(v)
+		b.WriteString(k + ": " + v + "\r\n")
+	}
+	header("From", from.String())
+	header("To", to.String())
+	header("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", messageID(from.Address))
+	header("MIME-Version", "1.0")
+	keys := make([]string, 0, len(m.Headers))
+	for k := range m.Headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		header(textproto.CanonicalMIMEHeaderKey(k), m.Headers[k])
+	}
+	if m.HTML == "" {
+		header("Content-Type", "text/plain; charset=utf-8")
+		header("Content-Transfer-Encoding", "quoted-printable")
+		b.WriteString("\r\n")
+		if err := writeQP(&b, m.Text); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	}
+	mw := multipart.NewWriter(&b)
+	header("Content-Type", "multipart/alternative; boundary="+mw.Boundary())
+	b.WriteString("\r\n")
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", m.Text},
+		{"text/html; charset=utf-8", m.HTML},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQP(w, part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func writeQP(w io.Writer, s string) error {
+	qw := quotedprintable.NewWriter(w) // writes line breaks as CRLF
+	if _, err := qw.Write([]byte(s)); err != nil {
+		return err
+	}
+	return qw.Close()
+}
+
+func messageID(from string) string {
+	domain := "localhost"
+	if i := strings.LastIndexByte(from, '@'); i >= 0 {
+		domain = from[i+1:]
+	}
+	var r [12]byte
+	_, _ = rand.Read(r[:])
+	return "<" + hex.EncodeToString(r[:]) + "@" + domain + ">"
+}
@@ -0,0 +1,293 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	netmail "net/mail"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSMTP is an in-process SMTP server that accepts mail for any recipient except those in reject, and
+// records what it receives. It never offers STARTTLS or AUTH.
+type fakeSMTP struct {
+	ln     net.Listener
+	reject map[string]bool
+
+	mu       sync.Mutex
+	received []fakeMail
+}
+
+type fakeMail struct {
+	from, to string
+	data     string
+}
+
+func newFakeSMTP(t *testing.T, reject ...string) *fakeSMTP {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &fakeSMTP{ln: ln, reject: map[string]bool{}}
+	for _, r := range reject {
+		s.reject[r] = true
+	}
+	go s.serve()
+	t.Cleanup(func() { ln.Close() })
+	return s
+}
+
+func (s *fakeSMTP) config() SMTPConfig {
+	return SMTPConfig{Host: "127.0.0.1", Port: s.ln.Addr().(*net.TCPAddr).Port, From: "Roadmap <roadmap@example.com>", TLS: TLSNone, Timeout: 5 * time.Second}
+}
+
+func (s *fakeSMTP) messages() []fakeMail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]fakeMail(nil), s.received...)
+}
+
+func (s *fakeSMTP) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTP) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = io.WriteString(conn, line+"\r\n") }
+	reply("220 fake ESMTP")
+	var cur fakeMail
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch verb {
+		case "EHLO", "HELO":
+			reply("250-fake")
+			reply("250 8BITMIME")
+		case "MAIL":
+			cur = fakeMail{from: addrParam(line)}
+			reply("250 ok")
+		case "RCPT":
+			cur.to = addrParam(line)
+			if s.reject[cur.to] {
+				reply("550 5.1.1 mailbox unavailable")
+				continue
+			}
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			var b strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				b.WriteString(strings.TrimPrefix(l, "."))
+			}
+			cur.data = b.String()
+			s.mu.Lock()
+			s.received = append(s.received, cur)
+			s.mu.Unlock()
+			reply("250 queued")
+		case "RSET", "NOOP":
+			reply("250 ok")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func addrParam(line string) string {
+	i, j := strings.IndexByte(line, '<'), strings.IndexByte(line, '>')
+	if i < 0 || j < i {
+		return ""
+	}
+	return line[i+1 : j]
+}
+
+// parts returns the bodies of a received message by content type; multipart.Reader undoes the
+// quoted-printable encoding.
+func parts(t *testing.T, msg *netmail.Message) map[string]string {
+	t.Helper()
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("content type = %q, %v; want multipart/alternative", msg.Header.Get("Content-Type"), err)
+	}
+	out := map[string]string{}
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			return out
+		}
+		if err != nil {
+			t.Fatalf("next part: %v", err)
+		}
+		body, err := io.ReadAll(p)
+		if err != nil {
+			t.Fatalf("read part: %v", err)
+		}
+		ct, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		out[ct] = string(body)
+	}
+}
+
+func TestSMTPSenderDeliversRenderedNotification(t *testing.T) {
+	srv := newFakeSMTP(t)
+	sender, err := NewSMTPSender(srv.config())
+	if err != nil {
+		t.Fatalf("NewSMTPSender: %v", err)
+	}
+	renderer, err := NewRenderer()
+	if err != nil {
+		t.Fatalf("NewRenderer: %v", err)
+	}
+	m, err := renderer.Notification(&Notification{
+		RecipientName: "Dana",
+		Type:          "product_request_approved",
+		Title:         "Product request approved: Café <Beta>",
+		Message:       "Your request for <script>alert(1)</script> was approved.",
+		Link:          "https://roadmap.example.com/products/42",
+		CreatedAt:     time.Now(),
+		Links:         Links{Unsubscribe: "https://roadmap.example.com/api/notifications/unsubscribe?token=abc", Settings: "https://roadmap.example.com/notifications"},
+	})
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	m.To, m.ToName = "dana@example.com", "Dana"
+	m.Headers = map[string]string{"List-Unsubscribe": "<https://roadmap.example.com/api/notifications/unsubscribe?token=abc>"}
+	if err := sender.Send(context.Background(), m); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	got := srv.messages()
+	if len(got) != 1 {
+		t.Fatalf("received %d messages, want 1", len(got))
+	}
+	if got[0].from != "roadmap@example.com" || got[0].to != "dana@example.com" {
+		t.Errorf("envelope = %s -> %s", got[0].from, got[0].to)
+	}
+	msg, err := netmail.ReadMessage(strings.NewReader(got[0].data))
+	if err != nil {
+		t.Fatalf("parse message: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != "[Roadmap] Product request approved: Café <Beta>" {
+		t.Errorf("subject = %q, %v", subject, err)
+	}
+	if h := msg.Header.Get("List-Unsubscribe"); h != "<https://roadmap.example.com/api/notifications/unsubscribe?token=abc>" {
+		t.Errorf("List-Unsubscribe = %q", h)
+	}
+	if msg.Header.Get("Message-Id") == "" || msg.Header.Get("Date") == "" {
+		t.Error("missing Message-ID or Date")
+	}
+	p := parts(t, msg)
+	text, html := p["text/plain"], p["text/html"]
+	for _, want := range []string{"Hi Dana,", "your product request was approved", "<script>alert(1)</script>", "https://roadmap.example.com/products/42", "unsubscribe?token=abc"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text part misses %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(html, "<script>") || !strings.Contains(html, "&lt;script&gt;") {
+		t.Errorf("html part does not escape the message:\n%s", html)
+	}
+	if !strings.Contains(html, `href="https://roadmap.example.com/products/42"`) {
+		t.Errorf("html part misses the link:\n%s", html)
+	}
+}
+
+func TestSMTPSenderRejectedRecipientIsPermanent(t *testing.T) {
+	srv := newFakeSMTP(t, "gone@example.com")
+	sender, err := NewSMTPSender(srv.config())
+	if err != nil {
+		t.Fatalf("NewSMTPSender: %v", err)
+	}
+	err = sender.Send(context.Background(), &Message{To: "gone@example.com", Subject: "s", Text: "t"})
+	if err == nil || !IsPermanent(err) {
+		t.Fatalf("Send error = %v, want permanent failure", err)
+	}
+	if n := len(srv.messages()); n != 0 {
+		t.Errorf("received %d messages, want 0", n)
+	}
+}
+
+func TestSMTPSenderTransientFailures(t *testing.T) {
+	srv := newFakeSMTP(t)
+	cfg := srv.config()
+	cfg.TLS = TLSStartTLS
+	sender, err := NewSMTPSender(cfg)
+	if err != nil {
+		t.Fatalf("NewSMTPSender: %v", err)
+	}
+	err = sender.Send(context.Background(), &Message{To: "dana@example.com", Subject: "s", Text: "t"})
+	if err == nil || IsPermanent(err) {
+		t.Errorf("STARTTLS missing: error = %v, want a retryable failure", err)
+	}
+
+	srv.ln.Close()
+	cfg.TLS = TLSNone
+	sender, _ = NewSMTPSender(cfg)
+	err = sender.Send(context.Background(), &Message{To: "dana@example.com", Subject: "s", Text: "t"})
+	if err == nil || IsPermanent(err) {
+		t.Errorf("server down: error = %v, want a retryable failure", err)
+	}
+}
+
+func TestRendererFallbackAndDigest(t *testing.T) {
+	r, err := NewRenderer()
+	if err != nil {
+		t.Fatalf("NewRenderer: %v", err)
+	}
+	m, err := r.Notification(&Notification{Type: "something_new", Title: "Something happened", Message: "Details here."})
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if m.Subject != "[Roadmap] Something happened" || !strings.Contains(m.Text, "Hi there,") || !strings.Contains(m.Text, "Details here.") {
+		t.Errorf("fallback message = %q / %q", m.Subject, m.Text)
+	}
+
+	at := time.Date(2026, 3, 4, 9, 30, 0, 0, time.UTC)
+	d, err := r.Digest(&Digest{
+		RecipientName: "Dana",
+		Period:        "weekly",
+		Items: []Notification{
+			{Title: "Status changed", Message: "Alpha is now GA.", CreatedAt: at},
+			{Title: "Added to product", Message: "You were added to Beta.", Link: "https://roadmap.example.com/products/7", CreatedAt: at},
+		},
+		Link: "https://roadmap.example.com/notifications",
+	})
+	if err != nil {
+		t.Fatalf("digest: %v", err)
+	}
+	if d.Subject != "[Roadmap] Your weekly digest: 2 notifications" {
+		t.Errorf("digest subject = %q", d.Subject)
+	}
+	for _, want := range []string{"Alpha is now GA.", "You were added to Beta.", "Mar 4, 09:30 UTC", "https://roadmap.example.com/products/7"} {
+		if !strings.Contains(d.Text, want) || !strings.Contains(d.HTML, want) {
+			t.Errorf("digest misses %q", want)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/rm/roadmap/backend/internal/i18n"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// subjectPrefix marks every notification email so users can filter them.
+const subjectPrefix = "[Roadmap] "
+
+// Links are the URLs every email carries.
+type Links struct {
+	Unsubscribe string // one-click unsubscribe from all notification email
+	Settings    string // where the user changes email frequency
+}
+
+// Notification is the data for a single notification email.
+type Notification struct {
+	RecipientName string
+	Locale        string // the recipient's locale; the default when empty
+	Type          string // models.Notification.Type; selects the template
+	Title         string
+	Message       string
+	Link          string // the related item in the app, if any
+	CreatedAt     time.Time
+	Links
+}
+
+// Digest is the data for a daily or weekly digest.
+type Digest struct {
+	RecipientName string
+	Locale        string
+	Period        string // "daily" or "weekly"
+	Items         []Notification
+	Link          string // the notifications page
+	Links
+}
+
+// Renderer renders notification email from the embedded templates. Each notification type has a content
+// block in templates/notifications.{txt,html} named after the type, falling back to "default"; the result is
+// wrapped in the shared layout. The templates' text comes from the i18n catalogs through the "t" function,
+// which takes a key and name/value pairs of parameters, so they are parsed once per locale.
+type Renderer struct {
+	text map[string]*texttemplate.Template
+	html map[string]*htmltemplate.Template
+}
+
+func NewRenderer() (*Renderer, error) {
+	r := &Renderer{text: map[string]*texttemplate.Template{}, html: map[string]*htmltemplate.Template{}}
+	for _, locale := range i18n.Supported() {
+		t := translator(locale)
+		text, err := texttemplate.New("").Funcs(texttemplate.FuncMap{"t": t}).ParseFS(templateFS, "templates/*.txt")
+		if err != nil {
+			return nil, err
+		}
+		html, err := htmltemplate.New("").Funcs(htmltemplate.FuncMap{"t": t}).ParseFS(templateFS, "templates/*.html")
+		if err != nil {
+			return nil, err
+		}
+		r.text[locale], r.html[locale] = text, html
+	}
+	return r, nil
+}
+
+func translator(locale string) func(key string, pairs ...string) string {
+	return func(key string, pairs ...string) string {
+		return i18n.M(key, pairs...).Render(locale)
+	}
+}
+
+// Notification renders a single notification. The caller sets To and any headers.
+func (r *Renderer) Notification(n *Notification) (*Message, error) {
+	locale := r.locale(n.Locale)
+	name := n.Type
+	if r.text[locale].Lookup(name) == nil || r.html[locale].Lookup(name) == nil {
+		name = "default"
+	}
+	return r.render(locale, name, subjectPrefix+n.Title, n)
+}
+
+// Digest renders a digest of d.Items.
+func (r *Renderer) Digest(d *Digest) (*Message, error) {
+	locale := r.locale(d.Locale)
+	noun := "mail.digest.notification_other"
+	if len(d.Items) == 1 {
+		noun = "mail.digest.notification_one"
+	}
+	subject := i18n.M("mail.digest."+d.Period+".subject", "count", fmt.Sprint(len(d.Items))).Term("noun", noun).Render(locale)
+	return r.render(locale, "digest", subjectPrefix+subject, d)
+}
+
+// locale is the supported locale of l, or the default.
+func (r *Renderer) locale(l string) string {
+	if l = i18n.Normalize(l); l == "" {
+		return i18n.DefaultLocale
+	}
+	return l
+}
+
+func (r *Renderer) render(locale, name, subject string, data interface{}) (*Message, error) {
+	var text, textBody, html, htmlBody bytes.Buffer
+	if err := r.text[locale].ExecuteTemplate(&textBody, name, data); err != nil {
+		return nil, err
+	}
+	if err := r.text[locale].ExecuteTemplate(&text, "layout", layoutData{Body: strings.TrimSpace(textBody.String()), Data: data}); err != nil {
+		return nil, err
+	}
+	if err := r.html[locale].ExecuteTemplate(&htmlBody, name, data); err != nil {
+		return nil, err
+	}
+	// htmlBody was produced by html/template, so it is already escaped.
+	if err := r.html[locale].ExecuteTemplate(&html, "layout", layoutData{Body: htmltemplate.HTML(htmlBody.String()), Data: data}); err != nil {
+		return nil, err
+	}
+	return &Message{Subject: subject, Text: text.String(), HTML: html.String()}, nil
+}
+
+// layoutData is passed to the layout: the rendered content and the content's own data, for the links.
+type layoutData struct {
+	Body interface{}
+	Data interface{}
+}
@@ -5,31 +5,54 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/rm/roadmap/backend/internal/auth"
+	"github.com/rm/roadmap/backend/internal/services"
 )
 
 const UserIDKey = "user_id"
 const UserRoleKey = "user_role"
 const ClaimsKey = "claims"
+const SessionIDKey = "session_id"
 
-func Auth(jwt *auth.JWTService) gin.HandlerFunc {
+// Auth validates the bearer token and checks that its session has not been revoked.
+func Auth(jwt *auth.JWTService, sessions *services.SessionService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		header := c.GetHeader("Authorization")
 		if header == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing authorization header"})
+			abortWithCode(c, http.StatusUnauthorized, "missing_authorization")
 			return
 		}
 		parts := strings.SplitN(header, " ", 2)
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization header"})
+			abortWithCode(c, http.StatusUnauthorized, "invalid_authorization")
 			return
 		}
-		claims, err := jwt.ValidateToken(parts[1])
+		claims, err := jwt.ValidateToken(parts[1], auth.TokenTypeAccess)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
+			abortWithCode(c, http.StatusUnauthorized, "invalid_token")
+			return
+		}
+		userID, err := uuid.Parse(claims.UserID)
+		if err != nil {
+			abortWithCode(c, http.StatusUnauthorized, "invalid_token")
+			return
+		}
+		sid, err := uuid.Parse(claims.SessionID)
+		if err != nil {
+			abortWithCode(c, http.StatusUnauthorized, "invalid_token")
+			return
+		}
+		if err := sessions.Validate(c.Request.Context(), sid, userID); err != nil {
+			if err == services.ErrSessionRevoked {
+				abortWithCode(c, http.StatusUnauthorized, "session_revoked")
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "code": "internal_server_error"})
 			return
 		}
 		c.Set(ClaimsKey, claims)
+		c.Set(SessionIDKey, claims.SessionID)
 		c.Set(UserIDKey, claims.UserID)
 		c.Set(UserRoleKey, claims.Role)
 		c.Next()
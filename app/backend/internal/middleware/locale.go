@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/rm/roadmap/backend/internal/i18n"
+)
+
+const LocaleKey = "locale"
+
+// Locale resolves the locale responses are rendered in from the Accept-Language header. The web app sends the
+// user's chosen locale there, so a browser's language only applies until the user picks one.
+func Locale() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(LocaleKey, i18n.FromAcceptLanguage(c.GetHeader("Accept-Language")))
+		c.Next()
+	}
+}
+
+// GetLocale returns the request's locale, resolving it when the Locale middleware did not run.
+func GetLocale(c *gin.Context) string {
+	if l := c.GetString(LocaleKey); l != "" {
+		return l
+	}
+	return i18n.FromAcceptLanguage(c.GetHeader("Accept-Language"))
+}
+
+// abortWithCode ends the request with the stable error code and its message in the request's locale, the
+// same shape handlers answer errors with.
+func abortWithCode(c *gin.Context, status int, code string) {
+	c.AbortWithStatusJSON(status, gin.H{"error": i18n.T(GetLocale(c), "error."+code, nil), "code": code})
+}
@@ -44,7 +44,7 @@ func RateLimit() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		key := c.ClientIP()
 		if l := defaultLimiter.get(key); !l.Allow() {
-			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+			abortWithCode(c, http.StatusTooManyRequests, "rate_limited")
 			return
 		}
 		c.Next()
@@ -4,6 +4,9 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/authz"
+	"github.com/rm/roadmap/backend/internal/models"
 )
 
 func RequireRole(roles ...string) gin.HandlerFunc {
@@ -14,11 +17,11 @@ func RequireRole(roles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role, exists := c.Get(UserRoleKey)
 		if !exists {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			abortWithCode(c, http.StatusUnauthorized, "unauthorized")
 			return
 		}
 		if !set[role.(string)] {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			abortWithCode(c, http.StatusForbidden, "forbidden")
 			return
 		}
 		c.Next()
@@ -33,3 +36,39 @@ func RequireAdmin() gin.HandlerFunc {
 func RequireOwnerOrAdmin() gin.HandlerFunc {
 	return RequireRole("admin", "owner", "superadmin")
 }
+
+// GetSubject builds the authorization subject for the authenticated caller.
+func GetSubject(c *gin.Context) authz.Subject {
+	var sub authz.Subject
+	if v, ok := c.Get(UserIDKey); ok {
+		if s, ok := v.(string); ok {
+			sub.UserID, _ = uuid.Parse(s)
+		}
+	}
+	if v, ok := c.Get(UserRoleKey); ok {
+		if s, ok := v.(string); ok {
+			sub.Role = models.Role(s)
+		}
+	}
+	return sub
+}
+
+// RequirePermission allows the request when the caller holds perm without an ownership scope.
+// Routes whose access depends on the target resource check ownership in the service instead.
+func RequirePermission(engine *authz.Engine, perm authz.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, exists := c.Get(UserRoleKey); !exists {
+			abortWithCode(c, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		if err := engine.Authorize(c.Request.Context(), GetSubject(c), perm, authz.Any); err != nil {
+			if err == authz.ErrForbidden {
+				abortWithCode(c, http.StatusForbidden, "forbidden")
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "code": "internal_server_error"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -12,9 +12,7 @@ func Recovery(log *zap.Logger) gin.HandlerFunc {
 		defer func() {
 			if err := recover(); err != nil {
 				log.Error("panic recovered", zap.Any("error", err))
-				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-					"error": "Internal server error. Check server logs.",
-				})
+				abortWithCode(c, http.StatusInternalServerError, "internal_server_error")
 			}
 		}()
 		c.Next()
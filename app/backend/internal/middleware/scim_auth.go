@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rm/roadmap/backend/internal/scim"
+)
+
+// SCIMAuth checks the identity provider's static bearer token (SCIM_BEARER_TOKEN).
+func SCIMAuth(token string) gin.HandlerFunc {
+	want := []byte(token)
+	return func(c *gin.Context) {
+		got, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), want) != 1 {
+			c.Header("Content-Type", scim.ContentType)
+			c.Header("WWW-Authenticate", `Bearer realm="scim"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, &scim.Error{Status: http.StatusUnauthorized, Detail: "invalid bearer token"})
+			return
+		}
+		c.Next()
+	}
+}
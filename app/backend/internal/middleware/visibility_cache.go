@@ -0,0 +1,15 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/rm/roadmap/backend/internal/services"
+)
+
+// VisibilityCache attaches a per-request cache for org-hierarchy lookups, so a request that lists
+// products, audit logs and activity resolves the caller's reporting subtree only once.
+func VisibilityCache() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(services.WithVisibilityCache(c.Request.Context()))
+		c.Next()
+	}
+}
@@ -0,0 +1,9 @@
+-- Role -> permission grants used by the authz policy engine. "resource:action:own" grants are limited to
+-- resources the caller owns. The server seeds the defaults on startup when this table is empty.
+CREATE TABLE IF NOT EXISTS role_permissions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    role VARCHAR(20) NOT NULL,
+    permission VARCHAR(100) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_role_permission ON role_permissions(role, permission);
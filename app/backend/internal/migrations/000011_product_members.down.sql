@@ -0,0 +1,2 @@
+DELETE FROM role_permissions WHERE permission IN ('product:members', 'product:members:own');
+DROP TABLE IF EXISTS product_members;
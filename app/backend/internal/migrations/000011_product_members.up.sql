@@ -0,0 +1,19 @@
+-- Per-product collaborators next to the single owner: co_owner, editor, viewer
+CREATE TABLE IF NOT EXISTS product_members (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL,
+    added_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_member ON product_members(product_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_product_members_user_id ON product_members(user_id);
+
+-- Grant member management to existing role_permissions (admin: any product; owner/user: own products)
+INSERT INTO role_permissions (id, role, permission) VALUES
+    (gen_random_uuid(), 'admin', 'product:members'),
+    (gen_random_uuid(), 'owner', 'product:members:own'),
+    (gen_random_uuid(), 'user', 'product:members:own')
+ON CONFLICT (role, permission) DO NOTHING;
@@ -0,0 +1,2 @@
+DELETE FROM role_permissions WHERE permission = 'session:manage';
+DROP TABLE IF EXISTS user_sessions;
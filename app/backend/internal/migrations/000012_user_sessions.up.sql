@@ -0,0 +1,21 @@
+-- Server-side sessions: every issued token carries its session ID (sid) so sessions can be listed and revoked
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    access_jti VARCHAR(64) NOT NULL,
+    refresh_jti VARCHAR(64) NOT NULL,
+    ip_address VARCHAR(45),
+    user_agent TEXT,
+    device VARCHAR(100),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_user_id ON user_sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_expires_at ON user_sessions(expires_at);
+
+-- Managing other users' sessions is an admin permission
+INSERT INTO role_permissions (id, role, permission) VALUES
+    (gen_random_uuid(), 'admin', 'session:manage')
+ON CONFLICT (role, permission) DO NOTHING;
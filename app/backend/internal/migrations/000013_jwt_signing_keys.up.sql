@@ -0,0 +1,12 @@
+-- Asymmetric JWT keyring shared by all instances; private keys are AES-GCM encrypted with a key derived from JWT_SECRET
+CREATE TABLE IF NOT EXISTS jwt_signing_keys (
+    id VARCHAR(64) PRIMARY KEY,
+    algorithm VARCHAR(10) NOT NULL,
+    private_key BYTEA NOT NULL,
+    public_key BYTEA NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    activates_at TIMESTAMPTZ NOT NULL,
+    retires_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_jwt_signing_keys_expires_at ON jwt_signing_keys(expires_at);
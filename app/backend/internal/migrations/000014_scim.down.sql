@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_teams_external_id;
+ALTER TABLE teams DROP COLUMN IF EXISTS external_id;
+DROP INDEX IF EXISTS idx_users_external_id;
+ALTER TABLE users DROP COLUMN IF EXISTS external_id;
+ALTER TABLE users DROP COLUMN IF EXISTS active;
@@ -0,0 +1,6 @@
+-- SCIM provisioning: deactivation flag and the IdP's identifiers for users and teams
+ALTER TABLE users ADD COLUMN IF NOT EXISTS active BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS external_id VARCHAR(255);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_external_id ON users(external_id);
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS external_id VARCHAR(255);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_teams_external_id ON teams(external_id);
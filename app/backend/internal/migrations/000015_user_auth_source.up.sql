@@ -0,0 +1,2 @@
+-- Where a user's password is checked: local (bcrypt hash) or ldap (directory bind)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS auth_source VARCHAR(20) NOT NULL DEFAULT 'local';
@@ -0,0 +1,7 @@
+DELETE FROM role_permissions WHERE permission = 'audit:verify';
+DROP TABLE IF EXISTS audit_chain_head;
+DROP TABLE IF EXISTS audit_tombstones;
+DROP INDEX IF EXISTS idx_audit_logs_seq;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS hash;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS prev_hash;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS seq;
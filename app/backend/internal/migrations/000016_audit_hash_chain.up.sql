@@ -0,0 +1,32 @@
+-- Tamper-evident audit log: each row stores its chain position, the previous row's hash and its own hash
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS seq BIGINT;
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS prev_hash VARCHAR(64);
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS hash VARCHAR(64);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_logs_seq ON audit_logs(seq);
+
+-- Deleted rows leave a signed tombstone at their seq so the chain stays verifiable
+CREATE TABLE IF NOT EXISTS audit_tombstones (
+    seq BIGINT PRIMARY KEY,
+    audit_log_id UUID NOT NULL,
+    hash VARCHAR(64) NOT NULL,
+    prev_hash VARCHAR(64) NOT NULL,
+    deleted_by UUID,
+    deleted_at TIMESTAMPTZ NOT NULL,
+    signature VARCHAR(64) NOT NULL
+);
+
+-- Single row with the latest seq and hash; appends lock it so writers never fork the chain
+CREATE TABLE IF NOT EXISTS audit_chain_head (
+    id INTEGER PRIMARY KEY,
+    seq BIGINT NOT NULL,
+    hash VARCHAR(64) NOT NULL,
+    updated_at TIMESTAMPTZ
+);
+INSERT INTO audit_chain_head (id, seq, hash, updated_at)
+VALUES (1, 0, '0000000000000000000000000000000000000000000000000000000000000000', NOW())
+ON CONFLICT (id) DO NOTHING;
+
+-- Verifying the chain is an admin permission
+INSERT INTO role_permissions (id, role, permission) VALUES
+    (gen_random_uuid(), 'admin', 'audit:verify')
+ON CONFLICT (role, permission) DO NOTHING;
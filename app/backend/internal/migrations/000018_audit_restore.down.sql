@@ -0,0 +1 @@
+DELETE FROM role_permissions WHERE permission IN ('audit:restore', 'audit:restore:own');
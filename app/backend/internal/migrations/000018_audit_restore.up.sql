@@ -0,0 +1,6 @@
+-- Restoring entities from audit snapshots: admins on any product, owners and users on their own
+INSERT INTO role_permissions (id, role, permission) VALUES
+    (gen_random_uuid(), 'admin', 'audit:restore'),
+    (gen_random_uuid(), 'owner', 'audit:restore:own'),
+    (gen_random_uuid(), 'user', 'audit:restore:own')
+ON CONFLICT (role, permission) DO NOTHING;
@@ -0,0 +1,3 @@
+DELETE FROM role_permissions WHERE permission = 'audit:retention';
+DROP TABLE IF EXISTS audit_legal_holds;
+DROP TABLE IF EXISTS audit_retention_policies;
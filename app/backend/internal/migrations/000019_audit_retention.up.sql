@@ -0,0 +1,27 @@
+-- Audit retention: per entity type/action archive and purge ages, and legal holds that exempt an entity
+CREATE TABLE IF NOT EXISTS audit_retention_policies (
+    id UUID PRIMARY KEY,
+    entity_type VARCHAR(50) NOT NULL DEFAULT '',
+    action VARCHAR(50) NOT NULL DEFAULT '',
+    archive_after_days INTEGER NOT NULL DEFAULT 0,
+    purge_after_days INTEGER NOT NULL DEFAULT 0,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by UUID,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_retention_scope ON audit_retention_policies(entity_type, action);
+
+CREATE TABLE IF NOT EXISTS audit_legal_holds (
+    id UUID PRIMARY KEY,
+    entity_type VARCHAR(50) NOT NULL,
+    entity_id VARCHAR(100) NOT NULL,
+    reason TEXT,
+    created_by UUID,
+    created_at TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_legal_holds_entity ON audit_legal_holds(entity_type, entity_id);
+
+INSERT INTO role_permissions (id, role, permission) VALUES
+    (gen_random_uuid(), 'admin', 'audit:retention')
+ON CONFLICT (role, permission) DO NOTHING;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_activity_logs_timestamp_id;
+DROP INDEX IF EXISTS idx_audit_logs_timestamp_id;
+DROP INDEX IF EXISTS idx_activity_logs_created_at_id;
+DROP TABLE IF EXISTS forwarder_checkpoints;
@@ -0,0 +1,11 @@
+-- Per-stream progress of the SIEM forwarder: audit by hash chain seq, activity by (created_at, id)
+CREATE TABLE IF NOT EXISTS forwarder_checkpoints (
+    stream VARCHAR(50) PRIMARY KEY,
+    after_seq BIGINT NOT NULL DEFAULT 0,
+    after_time TIMESTAMPTZ,
+    after_id UUID,
+    updated_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_activity_logs_created_at_id ON activity_logs(created_at, id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_timestamp_id ON audit_logs(timestamp, id);
+CREATE INDEX IF NOT EXISTS idx_activity_logs_timestamp_id ON activity_logs(timestamp, id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_audit_logs_ip_address;
+DROP INDEX IF EXISTS idx_audit_logs_new_data;
+DROP INDEX IF EXISTS idx_audit_logs_old_data;
+DROP INDEX IF EXISTS idx_audit_logs_search;
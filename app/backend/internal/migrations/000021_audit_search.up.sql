@@ -0,0 +1,8 @@
+-- Audit log search: free text over action, entity and logged data; JSONB containment on old_data/new_data;
+-- exact lookups by IP. The tsvector expression must match auditSearchDocument in audit_repository.go.
+CREATE INDEX IF NOT EXISTS idx_audit_logs_search ON audit_logs USING GIN (
+    to_tsvector('simple', coalesce(action, '') || ' ' || coalesce(entity_type, '') || ' ' || coalesce(entity_id, '') || ' ' || coalesce(old_data::text, '') || ' ' || coalesce(new_data::text, '') || ' ' || coalesce(metadata::text, ''))
+);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_old_data ON audit_logs USING GIN (old_data jsonb_path_ops);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_new_data ON audit_logs USING GIN (new_data jsonb_path_ops);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_ip_address ON audit_logs(ip_address);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS notification_email_settings;
+DROP INDEX IF EXISTS idx_notification_deliveries_due;
+DROP INDEX IF EXISTS idx_notification_deliveries_user_id;
+DROP INDEX IF EXISTS idx_notification_deliveries_notification_id;
+DROP TABLE IF EXISTS notification_deliveries;
@@ -0,0 +1,24 @@
+-- Notification email: queued deliveries (immediate or waiting for a digest) and per-user frequency
+CREATE TABLE IF NOT EXISTS notification_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    notification_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    channel VARCHAR(20) NOT NULL,
+    digest BOOLEAN NOT NULL DEFAULT false,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL,
+    last_error TEXT,
+    sent_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_notification_id ON notification_deliveries(notification_id);
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_user_id ON notification_deliveries(user_id);
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_due ON notification_deliveries(channel, status, digest, next_attempt_at);
+
+CREATE TABLE IF NOT EXISTS notification_email_settings (
+    user_id UUID PRIMARY KEY,
+    frequency VARCHAR(20) NOT NULL,
+    last_digest_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ
+);
@@ -0,0 +1,4 @@
+DELETE FROM role_permissions WHERE permission = 'notification:manage';
+ALTER TABLE notifications DROP COLUMN IF EXISTS hidden;
+DROP TABLE IF EXISTS notification_defaults;
+DROP TABLE IF EXISTS notification_preferences;
@@ -0,0 +1,26 @@
+-- Notification preferences per user, type and channel; organization defaults and mandatory types
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id UUID NOT NULL,
+    type VARCHAR(64) NOT NULL,
+    channel VARCHAR(20) NOT NULL,
+    enabled BOOLEAN NOT NULL,
+    updated_at TIMESTAMPTZ,
+    PRIMARY KEY (user_id, type, channel)
+);
+
+CREATE TABLE IF NOT EXISTS notification_defaults (
+    type VARCHAR(64) NOT NULL,
+    channel VARCHAR(20) NOT NULL,
+    enabled BOOLEAN NOT NULL,
+    mandatory BOOLEAN NOT NULL,
+    updated_by UUID,
+    updated_at TIMESTAMPTZ,
+    PRIMARY KEY (type, channel)
+);
+
+-- Notifications muted in the app are kept for the other channels but not listed
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS hidden BOOLEAN NOT NULL DEFAULT false;
+
+INSERT INTO role_permissions (id, role, permission) VALUES
+    (gen_random_uuid(), 'admin', 'notification:manage')
+ON CONFLICT (role, permission) DO NOTHING;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS milestone_reminders;
+ALTER TABLE milestones DROP COLUMN IF EXISTS completed_at;
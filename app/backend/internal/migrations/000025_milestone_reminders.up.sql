@@ -0,0 +1,11 @@
+-- Milestone completion, and the reminders and overdue alerts already sent per milestone date
+ALTER TABLE milestones ADD COLUMN IF NOT EXISTS completed_at TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS milestone_reminders (
+    milestone_id UUID NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    date DATE NOT NULL,
+    days_before BIGINT NOT NULL DEFAULT 0,
+    sent_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (milestone_id, kind, date, days_before)
+);
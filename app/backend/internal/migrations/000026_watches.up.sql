@@ -0,0 +1,9 @@
+-- Users watching products, product versions and groups
+CREATE TABLE IF NOT EXISTS watches (
+    user_id UUID NOT NULL,
+    entity_type VARCHAR(32) NOT NULL,
+    entity_id UUID NOT NULL,
+    created_at TIMESTAMPTZ,
+    PRIMARY KEY (user_id, entity_type, entity_id)
+);
+CREATE INDEX IF NOT EXISTS idx_watches_entity_id ON watches (entity_id);
@@ -0,0 +1,2 @@
+-- Webhook body format: the event payload as JSON, or a Slack or Teams message
+ALTER TABLE webhook_subscriptions ADD COLUMN IF NOT EXISTS format VARCHAR(20) NOT NULL DEFAULT 'json';
@@ -0,0 +1,3 @@
+-- Notification threads (type and related entity per user) and the retention job's scan by age
+CREATE INDEX IF NOT EXISTS idx_notifications_user_thread ON notifications(user_id, type, related_entity_type, related_entity_id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_notifications_created_at ON notifications(created_at);
@@ -0,0 +1,3 @@
+ALTER TABLE notifications DROP COLUMN IF EXISTS localized_message;
+ALTER TABLE notifications DROP COLUMN IF EXISTS localized_title;
+ALTER TABLE users DROP COLUMN IF EXISTS locale;
@@ -0,0 +1,4 @@
+-- Per-user locale, and the catalog messages notifications are rendered from in the reader's locale
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locale VARCHAR(10) NOT NULL DEFAULT '';
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS localized_title JSONB;
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS localized_message JSONB;
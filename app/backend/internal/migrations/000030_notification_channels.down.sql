@@ -0,0 +1,2 @@
+-- The deleted webhook and chat settings had no effect and are not restored
+SELECT 1;
@@ -0,0 +1,3 @@
+-- Webhook and chat delivery of personal notifications is not implemented; drop settings that had no effect
+DELETE FROM notification_preferences WHERE channel IN ('webhook', 'chat');
+DELETE FROM notification_defaults WHERE channel IN ('webhook', 'chat');
@@ -0,0 +1,9 @@
+-- Default grants the server has written once (see authz.Engine.SeedDefaults). On startup it grants every
+-- default not listed here, so permissions added in a release (such as product:read) reach existing installs,
+-- and records it, so a default an admin revoked stays revoked.
+CREATE TABLE IF NOT EXISTS role_permission_seeds (
+    role VARCHAR(20) NOT NULL,
+    permission VARCHAR(100) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (role, permission)
+);
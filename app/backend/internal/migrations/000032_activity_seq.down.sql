@@ -0,0 +1,11 @@
+ALTER TABLE forwarder_checkpoints ADD COLUMN IF NOT EXISTS after_time TIMESTAMPTZ;
+ALTER TABLE forwarder_checkpoints ADD COLUMN IF NOT EXISTS after_id UUID;
+UPDATE forwarder_checkpoints c SET after_time = a.created_at, after_id = a.id
+FROM activity_logs a
+WHERE c.stream LIKE '%:activity' AND a.seq = c.after_seq;
+UPDATE forwarder_checkpoints SET after_seq = 0 WHERE stream LIKE '%:activity';
+CREATE INDEX IF NOT EXISTS idx_activity_logs_created_at_id ON activity_logs(created_at, id);
+
+DROP TABLE IF EXISTS activity_log_head;
+DROP INDEX IF EXISTS idx_activity_logs_seq;
+ALTER TABLE activity_logs DROP COLUMN IF EXISTS seq;
@@ -0,0 +1,26 @@
+-- Activity logs get a commit-ordered seq like the audit chain, so the SIEM forwarder no longer trails
+-- inserts by a fixed lag and cannot skip rows that commit late
+ALTER TABLE activity_logs ADD COLUMN IF NOT EXISTS seq BIGINT;
+
+UPDATE activity_logs a SET seq = o.n
+FROM (SELECT id, row_number() OVER (ORDER BY created_at, id) AS n FROM activity_logs) o
+WHERE a.id = o.id AND a.seq IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_activity_logs_seq ON activity_logs(seq);
+
+CREATE TABLE IF NOT EXISTS activity_log_head (
+    id INTEGER PRIMARY KEY,
+    seq BIGINT NOT NULL,
+    updated_at TIMESTAMPTZ
+);
+INSERT INTO activity_log_head (id, seq, updated_at)
+SELECT 1, COALESCE(MAX(seq), 0), NOW() FROM activity_logs
+ON CONFLICT (id) DO NOTHING;
+
+-- Move activity checkpoints from their (created_at, id) cursor to the seq of the last row they passed
+UPDATE forwarder_checkpoints c SET after_seq = COALESCE(
+    (SELECT MAX(a.seq) FROM activity_logs a WHERE (a.created_at, a.id) <= (c.after_time, c.after_id)), 0)
+WHERE c.stream LIKE '%:activity' AND c.after_time IS NOT NULL;
+
+ALTER TABLE forwarder_checkpoints DROP COLUMN IF EXISTS after_time;
+ALTER TABLE forwarder_checkpoints DROP COLUMN IF EXISTS after_id;
+DROP INDEX IF EXISTS idx_activity_logs_created_at_id;
@@ -9,6 +9,7 @@ import (
 
 type ActivityLog struct {
 	ID         uuid.UUID      `gorm:"type:uuid;primaryKey" json:"id"`
+	Seq        *int64         `gorm:"uniqueIndex" json:"seq,omitempty"` // commit order; nil for rows written before it was tracked
 	Timestamp  time.Time      `gorm:"not null;index" json:"timestamp"`
 	UserID     *uuid.UUID     `gorm:"type:uuid;index" json:"user_id"`
 	Action     string         `gorm:"not null;index" json:"action"` // login, login_failed, logout, create, save, delete
@@ -24,6 +25,16 @@ type ActivityLog struct {
 
 func (ActivityLog) TableName() string { return "activity_logs" }
 
+// ActivityLogHead is the single row holding the latest activity seq. Appends lock it, so seqs are assigned
+// in commit order and a reader that has seen seq n will never see a lower one appear later.
+type ActivityLogHead struct {
+	ID        int       `gorm:"primaryKey;autoIncrement:false" json:"-"`
+	Seq       int64     `gorm:"not null" json:"seq"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (ActivityLogHead) TableName() string { return "activity_log_head" }
+
 func (a *ActivityLog) BeforeCreate(tx *gorm.DB) error {
 	if a.ID == uuid.Nil {
 		a.ID = uuid.New()
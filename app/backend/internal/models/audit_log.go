@@ -22,6 +22,9 @@ type AuditLog struct {
 	TraceID    string         `gorm:"index" json:"trace_id"`
 	Archived   bool           `gorm:"default:false;index" json:"archived"`
 	ArchivedAt *time.Time     `json:"archived_at,omitempty"`
+	Seq        *int64         `gorm:"uniqueIndex" json:"seq,omitempty"`            // position in the hash chain; nil until sealed
+	PrevHash   string         `gorm:"type:varchar(64)" json:"prev_hash,omitempty"` // hash of the entry at seq-1
+	Hash       string         `gorm:"type:varchar(64)" json:"hash,omitempty"`      // SHA-256 of the canonical content (see internal/auditchain)
 	CreatedAt  time.Time      `json:"created_at"`
 	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
 
@@ -39,3 +42,28 @@ func (a *AuditLog) BeforeCreate(tx *gorm.DB) error {
 	}
 	return nil
 }
+
+// AuditTombstone stands in for a deleted audit log row so the hash chain stays verifiable. Signature is an
+// HMAC over the row's seq, id and hashes and the deleter, made with the audit signing key.
+type AuditTombstone struct {
+	Seq        int64      `gorm:"primaryKey;autoIncrement:false" json:"seq"`
+	AuditLogID uuid.UUID  `gorm:"type:uuid;not null" json:"audit_log_id"`
+	Hash       string     `gorm:"type:varchar(64);not null" json:"hash"`
+	PrevHash   string     `gorm:"type:varchar(64);not null" json:"prev_hash"`
+	DeletedBy  *uuid.UUID `gorm:"type:uuid" json:"deleted_by,omitempty"`
+	DeletedAt  time.Time  `gorm:"not null" json:"deleted_at"`
+	Signature  string     `gorm:"type:varchar(64);not null" json:"signature"`
+}
+
+func (AuditTombstone) TableName() string { return "audit_tombstones" }
+
+// AuditChainHead is the single row holding the latest seq and hash. Appends lock it, which serializes
+// chain writes across goroutines and instances, and verification compares against it to detect truncation.
+type AuditChainHead struct {
+	ID        int       `gorm:"primaryKey;autoIncrement:false" json:"-"`
+	Seq       int64     `gorm:"not null" json:"seq"`
+	Hash      string    `gorm:"type:varchar(64);not null" json:"hash"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (AuditChainHead) TableName() string { return "audit_chain_head" }
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AuditRetentionPolicy archives and purges audit logs by age. An empty EntityType or Action matches any;
+// when several policies match a row, the most specific one (entity type and action, then entity type,
+// then action, then the catch-all) decides.
+type AuditRetentionPolicy struct {
+	ID               uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	EntityType       string     `gorm:"type:varchar(50);not null;default:'';uniqueIndex:idx_audit_retention_scope" json:"entity_type"`
+	Action           string     `gorm:"type:varchar(50);not null;default:'';uniqueIndex:idx_audit_retention_scope" json:"action"`
+	ArchiveAfterDays int        `gorm:"not null;default:0" json:"archive_after_days"` // 0 = never archive automatically
+	PurgeAfterDays   int        `gorm:"not null;default:0" json:"purge_after_days"`   // days after archiving; 0 = never purge
+	Enabled          bool       `gorm:"not null;default:true" json:"enabled"`
+	CreatedBy        *uuid.UUID `gorm:"type:uuid" json:"created_by,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+func (AuditRetentionPolicy) TableName() string { return "audit_retention_policies" }
+
+func (p *AuditRetentionPolicy) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+// AuditLegalHold exempts every audit log of one entity from retention until the hold is lifted.
+type AuditLegalHold struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	EntityType string     `gorm:"type:varchar(50);not null;uniqueIndex:idx_audit_legal_holds_entity" json:"entity_type"`
+	EntityID   string     `gorm:"type:varchar(100);not null;uniqueIndex:idx_audit_legal_holds_entity" json:"entity_id"`
+	Reason     string     `gorm:"type:text" json:"reason"`
+	CreatedBy  *uuid.UUID `gorm:"type:uuid" json:"created_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (AuditLegalHold) TableName() string { return "audit_legal_holds" }
+
+func (h *AuditLegalHold) BeforeCreate(tx *gorm.DB) error {
+	if h.ID == uuid.Nil {
+		h.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// ForwarderCheckpoint records how far a forwarder has shipped one stream of records: the last audit chain seq
+// or activity seq sent.
+type ForwarderCheckpoint struct {
+	Stream    string    `gorm:"type:varchar(50);primaryKey" json:"stream"`
+	AfterSeq  int64     `gorm:"not null;default:0" json:"after_seq"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (ForwarderCheckpoint) TableName() string { return "forwarder_checkpoints" }
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// JWTSigningKey is one key of the JWT keyring. A key signs new tokens between ActivatesAt and
+// RetiresAt and is published in the JWKS and accepted for verification until ExpiresAt.
+// PrivateKey holds the PKCS#8 DER, AES-GCM encrypted with a key derived from JWT_SECRET.
+type JWTSigningKey struct {
+	ID          string    `gorm:"type:varchar(64);primaryKey" json:"kid"`
+	Algorithm   string    `gorm:"type:varchar(10);not null" json:"alg"` // RS256 | EdDSA
+	PrivateKey  []byte    `gorm:"type:bytea;not null" json:"-"`
+	PublicKey   []byte    `gorm:"type:bytea;not null" json:"-"` // PKIX DER
+	CreatedAt   time.Time `json:"created_at"`
+	ActivatesAt time.Time `gorm:"not null" json:"activates_at"`
+	RetiresAt   time.Time `gorm:"not null" json:"retires_at"`
+	ExpiresAt   time.Time `gorm:"not null;index" json:"expires_at"`
+}
+
+func (JWTSigningKey) TableName() string { return "jwt_signing_keys" }
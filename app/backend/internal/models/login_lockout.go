@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Lockout scopes: failed logins are counted per account (normalized email) and per client IP.
+const (
+	LockoutScopeAccount = "account"
+	LockoutScopeIP      = "ip"
+)
+
+// LoginLockout tracks consecutive failed logins for one account or client IP.
+// Rows exist for unknown emails too, so lockout behaviour never reveals whether an account exists.
+type LoginLockout struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	Scope        string     `gorm:"type:varchar(20);not null;uniqueIndex:idx_login_lockout_scope_key" json:"scope"` // account | ip
+	Key          string     `gorm:"type:varchar(320);not null;uniqueIndex:idx_login_lockout_scope_key" json:"key"`  // lower-cased email or IP
+	FailedCount  int        `gorm:"not null;default:0" json:"failed_count"`
+	LastFailedAt time.Time  `gorm:"not null" json:"last_failed_at"`
+	LockedUntil  *time.Time `gorm:"index" json:"locked_until,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func (LoginLockout) TableName() string { return "login_lockouts" }
+
+func (l *LoginLockout) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"gorm.io/gorm/schema"
+)
+
+var (
+	sqlComment = regexp.MustCompile(`--[^\n]*`)
+	alterTable = regexp.MustCompile(`(?is)^\s*ALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?(\w+)\s+(.*)$`)
+	addColumn  = regexp.MustCompile(`(?is)ADD\s+COLUMN\s+(?:IF\s+NOT\s+EXISTS\s+)?(\w+)\s+([^,]*)`)
+)
+
+// altered are the models whose tables the SQL migrations add columns to.
+var altered = []interface{}{
+	&ActivityLog{}, &AuditLog{}, &ForwarderCheckpoint{}, &Milestone{}, &Notification{},
+	&Product{}, &Team{}, &User{}, &WebhookSubscription{},
+}
+
+// TestAddedColumnsHaveDefaults checks that a column the migrations add to an existing table is not NOT NULL
+// without a default in the model. Deployments without the SQL migrations get the column from AutoMigrate
+// on startup, and Postgres rejects a NOT NULL column without a default on a table that already has rows.
+func TestAddedColumnsHaveDefaults(t *testing.T) {
+	tables := map[string]*schema.Schema{}
+	for _, m := range altered {
+		s, err := schema.Parse(m, &sync.Map{}, schema.NamingStrategy{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tables[s.Table] = s
+	}
+
+	files, err := filepath.Glob("../migrations/*.up.sql")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no migrations found: %v", err)
+	}
+	for _, f := range files {
+		sql, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, stmt := range strings.Split(sqlComment.ReplaceAllString(string(sql), ""), ";") {
+			m := alterTable.FindStringSubmatch(stmt)
+			if m == nil {
+				continue
+			}
+			for _, c := range addColumn.FindAllStringSubmatch(m[2], -1) {
+				s, ok := tables[m[1]]
+				if !ok {
+					t.Errorf("%s: table %s has no model in altered", filepath.Base(f), m[1])
+					continue
+				}
+				field := s.LookUpField(c[1])
+				switch {
+				case field == nil:
+					t.Errorf("%s: %s.%s has no model field", filepath.Base(f), m[1], c[1])
+				case field.NotNull && !hasDefault(field):
+					t.Errorf("%s: %s.%s is not null without a default in the model", filepath.Base(f), m[1], c[1])
+				}
+			}
+		}
+	}
+}
+
+// hasDefault mirrors when gorm's migrator writes a DEFAULT clause for a field.
+func hasDefault(f *schema.Field) bool {
+	return f.HasDefaultValue && (f.DefaultValueInterface != nil || f.DefaultValue != "")
+}
@@ -17,6 +17,7 @@ type Milestone struct {
 	Type      string         `json:"type"` // e.g. alpha, beta, ga, support
 	Color     string         `json:"color"`
 	Extra     JSONB          `gorm:"type:jsonb" json:"extra,omitempty"`
+	CompletedAt *time.Time   `json:"completed_at,omitempty"` // set when the milestone is done; no overdue alerts after that
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
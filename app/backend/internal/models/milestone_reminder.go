@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Milestone reminder kinds.
+const (
+	MilestoneReminderStart   = "start"
+	MilestoneReminderEnd     = "end"
+	MilestoneReminderOverdue = "overdue"
+)
+
+// MilestoneReminder records a reminder that was sent, so each fires once per milestone date: moving the
+// date makes the reminders due again for the new one.
+type MilestoneReminder struct {
+	MilestoneID uuid.UUID `gorm:"type:uuid;primaryKey" json:"milestone_id"`
+	Kind        string    `gorm:"type:varchar(20);primaryKey" json:"kind"`
+	Date        time.Time `gorm:"type:date;primaryKey" json:"date"`        // the start or end date reminded of
+	DaysBefore  int       `gorm:"primaryKey;default:0" json:"days_before"` // the configured lead time; 0 for overdue alerts
+	SentAt      time.Time `gorm:"not null" json:"sent_at"`
+}
+
+func (MilestoneReminder) TableName() string { return "milestone_reminders" }
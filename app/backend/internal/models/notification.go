@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/i18n"
 	"gorm.io/gorm"
 )
 
@@ -13,16 +14,33 @@ type Notification struct {
 	Type              string     `gorm:"type:varchar(64);not null" json:"type"`
 	Title             string     `gorm:"type:varchar(256);not null" json:"title"`
 	Message           string     `gorm:"type:text;not null" json:"message"`
+	// LocalizedTitle and LocalizedMessage are the catalog messages Title and Message were rendered from, in
+	// English; readers get them in their own locale. Notifications stored before localization have neither.
+	LocalizedTitle    *i18n.Message `gorm:"type:jsonb;serializer:json" json:"-"`
+	LocalizedMessage  *i18n.Message `gorm:"type:jsonb;serializer:json" json:"-"`
 	RelatedEntityType string     `gorm:"type:varchar(64)" json:"related_entity_type,omitempty"`
 	RelatedEntityID   *uuid.UUID `gorm:"type:uuid" json:"related_entity_id,omitempty"`
 	ReadAt            *time.Time `json:"read_at,omitempty"`
 	ArchivedAt        *time.Time `json:"archived_at,omitempty"`
+	Hidden            bool       `gorm:"not null;default:false" json:"-"` // muted in the app by the recipient's preferences; kept for other channels
 	DeletedAt         *time.Time `json:"-"`
 	CreatedAt         time.Time  `json:"created_at"`
 }
 
 func (Notification) TableName() string { return "notifications" }
 
+// Text returns the title and message in locale, or as stored when the notification has no catalog message.
+func (n *Notification) Text(locale string) (title, message string) {
+	title, message = n.Title, n.Message
+	if n.LocalizedTitle != nil {
+		title = n.LocalizedTitle.Render(locale)
+	}
+	if n.LocalizedMessage != nil {
+		message = n.LocalizedMessage.Render(locale)
+	}
+	return title, message
+}
+
 func (n *Notification) BeforeCreate(tx *gorm.DB) error {
 	if n.ID == uuid.Nil {
 		n.ID = uuid.New()
@@ -38,4 +56,36 @@ const (
 	NotificationTypeProductDeletionRejected      = "product_deletion_rejected"
 	NotificationTypeProductDeletionRequestSubmitted = "product_deletion_request_submitted"
 	NotificationTypeProductStatusChanged         = "product_status_changed"
+	NotificationTypeProductMemberAdded           = "product_member_added"
+	NotificationTypeProductMemberRoleChanged     = "product_member_role_changed"
+	NotificationTypeProductMemberRemoved         = "product_member_removed"
+	NotificationTypeProductOwnerDeactivated      = "product_owner_deactivated"
+	NotificationTypeMilestoneReminder            = "milestone_reminder" // a milestone starts or ends soon
+	NotificationTypeMilestoneOverdue             = "milestone_overdue"  // a milestone passed its end date without being completed
+	// Sent to watchers of the product, version or group.
+	NotificationTypeMilestoneChanged           = "milestone_changed"
+	NotificationTypeProductVersionChanged      = "product_version_changed"
+	NotificationTypeProductLifecycleChanged    = "product_lifecycle_changed"
+	NotificationTypeDependencyReadinessChanged = "dependency_readiness_changed"
 )
+
+// NotificationTypes lists every notification type, for preferences and their defaults.
+var NotificationTypes = []string{
+	NotificationTypeProductRequestApproved,
+	NotificationTypeProductRequestRejected,
+	NotificationTypeProductRequestSubmitted,
+	NotificationTypeProductDeletionApproved,
+	NotificationTypeProductDeletionRejected,
+	NotificationTypeProductDeletionRequestSubmitted,
+	NotificationTypeProductStatusChanged,
+	NotificationTypeProductMemberAdded,
+	NotificationTypeProductMemberRoleChanged,
+	NotificationTypeProductMemberRemoved,
+	NotificationTypeProductOwnerDeactivated,
+	NotificationTypeMilestoneReminder,
+	NotificationTypeMilestoneOverdue,
+	NotificationTypeMilestoneChanged,
+	NotificationTypeProductVersionChanged,
+	NotificationTypeProductLifecycleChanged,
+	NotificationTypeDependencyReadinessChanged,
+}
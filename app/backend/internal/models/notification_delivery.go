@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Notification delivery channels.
+const (
+	DeliveryChannelInApp = "in_app"
+	DeliveryChannelEmail = "email"
+)
+
+// DeliveryChannels lists every channel a notification can be routed to; preferences for any other channel
+// are rejected. Webhooks and chat carry organization events, not personal notifications.
+var DeliveryChannels = []string{DeliveryChannelInApp, DeliveryChannelEmail}
+
+// Notification delivery statuses.
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusSent      = "sent"
+	DeliveryStatusFailed    = "failed"    // attempts exhausted or permanently rejected
+	DeliveryStatusCancelled = "cancelled" // the user turned the channel off before it was sent
+)
+
+// Email frequencies a user can choose.
+const (
+	EmailFrequencyImmediate = "immediate"
+	EmailFrequencyDaily     = "daily"
+	EmailFrequencyWeekly    = "weekly"
+	EmailFrequencyOff       = "off"
+)
+
+// NotificationDelivery queues a notification for an out-of-app channel. Immediate deliveries are sent once
+// NextAttemptAt has passed; digest deliveries wait for the user's next digest and are sent together.
+type NotificationDelivery struct {
+	ID             int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	NotificationID uuid.UUID  `gorm:"type:uuid;not null;index" json:"notification_id"`
+	UserID         uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	Channel        string     `gorm:"type:varchar(20);not null" json:"channel"`
+	Digest         bool       `gorm:"not null;default:false" json:"digest"`
+	Status         string     `gorm:"type:varchar(20);not null;default:pending" json:"status"`
+	Attempts       int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt  time.Time  `gorm:"not null" json:"next_attempt_at"`
+	LastError      string     `gorm:"type:text" json:"last_error,omitempty"`
+	SentAt         *time.Time `json:"sent_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+func (NotificationDelivery) TableName() string { return "notification_deliveries" }
+
+// NotificationEmailSetting is a user's choice of how often to get notification email. Users without a row
+// get immediate email. LastDigestAt is when the last digest went out, or when the user chose a digest.
+type NotificationEmailSetting struct {
+	UserID       uuid.UUID `gorm:"type:uuid;primaryKey" json:"user_id"`
+	Frequency    string    `gorm:"type:varchar(20);not null" json:"frequency"`
+	LastDigestAt time.Time `gorm:"not null" json:"last_digest_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func (NotificationEmailSetting) TableName() string { return "notification_email_settings" }
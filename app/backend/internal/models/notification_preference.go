@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NotificationPreference is a user's choice for one notification type on one channel. Without a row the
+// organization default applies.
+type NotificationPreference struct {
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey" json:"user_id"`
+	Type      string    `gorm:"type:varchar(64);primaryKey" json:"type"`
+	Channel   string    `gorm:"type:varchar(20);primaryKey" json:"channel"`
+	Enabled   bool      `gorm:"not null" json:"enabled"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (NotificationPreference) TableName() string { return "notification_preferences" }
+
+// NotificationDefault is the organization default for one notification type on one channel. A mandatory
+// default is always delivered and users cannot turn it off.
+type NotificationDefault struct {
+	Type      string     `gorm:"type:varchar(64);primaryKey" json:"type"`
+	Channel   string     `gorm:"type:varchar(20);primaryKey" json:"channel"`
+	Enabled   bool       `gorm:"not null" json:"enabled"`
+	Mandatory bool       `gorm:"not null" json:"mandatory"`
+	UpdatedBy *uuid.UUID `gorm:"type:uuid" json:"updated_by,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+func (NotificationDefault) TableName() string { return "notification_defaults" }
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Outbox event kinds.
+const (
+	OutboxKindAudit    = "audit"
+	OutboxKindActivity = "activity"
+)
+
+// OutboxEvent is an audit or activity record waiting for the outbox dispatcher. It is inserted in the same
+// transaction as the change it describes, so the record is never lost or written for a rolled-back change.
+type OutboxEvent struct {
+	ID        int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Kind      string     `gorm:"type:varchar(20);not null" json:"kind"`
+	Payload   JSONB      `gorm:"type:jsonb;not null" json:"payload"` // the AuditLog or ActivityLog as JSON
+	Attempts  int        `gorm:"not null;default:0" json:"attempts"`
+	LastError string     `gorm:"type:text" json:"last_error,omitempty"`
+	FailedAt  *time.Time `gorm:"index" json:"failed_at,omitempty"` // set when attempts are exhausted; no longer dispatched
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (OutboxEvent) TableName() string { return "outbox_events" }
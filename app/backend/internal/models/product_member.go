@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ProductMemberRole is a collaborator's role on a single product, next to the product owner.
+type ProductMemberRole string
+
+const (
+	ProductMemberCoOwner ProductMemberRole = "co_owner" // edits like the owner and manages members
+	ProductMemberEditor  ProductMemberRole = "editor"   // edits milestones, versions and dependencies
+	ProductMemberViewer  ProductMemberRole = "viewer"   // read access to the product's audit trail
+)
+
+func (r ProductMemberRole) Valid() bool {
+	return r == ProductMemberCoOwner || r == ProductMemberEditor || r == ProductMemberViewer
+}
+
+type ProductMember struct {
+	ID        uuid.UUID         `gorm:"type:uuid;primaryKey" json:"id"`
+	ProductID uuid.UUID         `gorm:"type:uuid;not null;index;uniqueIndex:idx_product_member" json:"product_id"`
+	UserID    uuid.UUID         `gorm:"type:uuid;not null;index;uniqueIndex:idx_product_member" json:"user_id"`
+	Role      ProductMemberRole `gorm:"type:varchar(20);not null" json:"role"`
+	AddedBy   *uuid.UUID        `gorm:"type:uuid" json:"added_by,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+
+	User User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+func (ProductMember) TableName() string { return "product_members" }
+
+func (m *ProductMember) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
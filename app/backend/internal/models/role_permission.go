@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RolePermission grants a named permission to a role. Permission is either "resource:action"
+// (any resource) or "resource:action:own" (only resources the subject owns). See internal/authz.
+type RolePermission struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	Role       Role      `gorm:"type:varchar(20);not null;uniqueIndex:idx_role_permission" json:"role"`
+	Permission string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_role_permission" json:"permission"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (RolePermission) TableName() string { return "role_permissions" }
+
+// RolePermissionSeed records a default grant the server has written once. It is not written again, so a
+// default an admin revoked stays revoked while defaults added in later releases still reach the role.
+type RolePermissionSeed struct {
+	Role       Role      `gorm:"type:varchar(20);primaryKey" json:"role"`
+	Permission string    `gorm:"type:varchar(100);primaryKey" json:"permission"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (RolePermissionSeed) TableName() string { return "role_permission_seeds" }
+
+func (rp *RolePermission) BeforeCreate(tx *gorm.DB) error {
+	if rp.ID == uuid.Nil {
+		rp.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Session is one login of a user. Every token carries the session ID (sid claim); the jti of the
+// current access and refresh token is recorded so refresh-token reuse can be detected.
+// A session is active while RevokedAt is nil and ExpiresAt is in the future.
+type Session struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	AccessJTI  string     `gorm:"column:access_jti;type:varchar(64);not null" json:"-"`
+	RefreshJTI string     `gorm:"column:refresh_jti;type:varchar(64);not null" json:"-"`
+	IPAddress  string     `gorm:"type:varchar(45)" json:"ip_address"`
+	UserAgent  string     `gorm:"type:text" json:"user_agent"`
+	Device     string     `gorm:"type:varchar(100)" json:"device"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `gorm:"not null" json:"last_seen_at"`
+	ExpiresAt  time.Time  `gorm:"not null;index" json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+func (Session) TableName() string { return "user_sessions" }
+
+func (s *Session) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
@@ -12,6 +12,7 @@ type Team struct {
 	DepartmentID uuid.UUID      `gorm:"type:uuid;not null;index" json:"department_id"`
 	Name         string         `gorm:"size:255;not null" json:"name"`
 	ManagerID    *uuid.UUID     `gorm:"type:uuid;index" json:"manager_id,omitempty"`
+	ExternalID   *string        `gorm:"type:varchar(255);uniqueIndex" json:"external_id,omitempty"` // SCIM externalId from the IdP
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
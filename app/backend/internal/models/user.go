@@ -17,6 +17,11 @@ const (
 	RoleUser       Role = "user"
 )
 
+const (
+	AuthSourceLocal = "local"
+	AuthSourceLDAP  = "ldap"
+)
+
 // IsAdminOrAbove returns true for admin or superadmin (hierarchy: user < owner < admin < superadmin).
 func (r Role) IsAdminOrAbove() bool {
 	s := strings.ToLower(string(r))
@@ -31,6 +36,10 @@ type User struct {
 	Role             Role           `gorm:"type:varchar(20);not null" json:"role"`
 	TeamID           *uuid.UUID     `gorm:"type:uuid;index" json:"team_id,omitempty"`
 	DirectManagerID  *uuid.UUID     `gorm:"type:uuid;index" json:"direct_manager_id,omitempty"`
+	Active           bool           `gorm:"not null;default:true" json:"active"`             // deactivated users cannot log in
+	ExternalID       *string        `gorm:"type:varchar(255);uniqueIndex" json:"external_id,omitempty"` // SCIM externalId from the IdP
+	AuthSource       string         `gorm:"type:varchar(20);not null;default:local" json:"auth_source"` // local | ldap; ldap users cannot fall back to a local password
+	Locale           string         `gorm:"type:varchar(10);not null;default:''" json:"locale"`        // i18n locale; empty follows the browser in the app and is English in email
 	CreatedAt        time.Time      `json:"created_at"`
 	UpdatedAt        time.Time      `json:"updated_at"`
 	DeletedAt        gorm.DeletedAt `gorm:"index" json:"-"`
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Watchable entity types.
+const (
+	WatchEntityProduct        = "product"
+	WatchEntityProductVersion = "product_version"
+	WatchEntityGroup          = "group" // covers the products in the group
+)
+
+// Watch subscribes a user to changes of a product, a product version or a group.
+type Watch struct {
+	UserID     uuid.UUID `gorm:"type:uuid;primaryKey" json:"user_id"`
+	EntityType string    `gorm:"type:varchar(32);primaryKey" json:"entity_type"`
+	EntityID   uuid.UUID `gorm:"type:uuid;primaryKey;index" json:"entity_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (Watch) TableName() string { return "watches" }
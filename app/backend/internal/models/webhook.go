@@ -0,0 +1,91 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Webhook delivery statuses.
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryDead      = "dead" // attempts exhausted or permanently rejected; redeliver to try again
+)
+
+// Webhook body formats: the event payload as JSON, or a chat message for a Slack or Teams incoming webhook.
+const (
+	WebhookFormatJSON  = "json"
+	WebhookFormatSlack = "slack"
+	WebhookFormatTeams = "teams"
+)
+
+// WebhookSubscription posts audited changes to an external URL. EntityTypes and Actions are comma-separated
+// lists, empty matching any; ProductID and GroupID narrow it to changes of one product or of the products
+// in one group. Format selects the body: the event payload, or a Slack or Teams message rendered from it.
+type WebhookSubscription struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	Name        string     `gorm:"type:varchar(100);not null" json:"name"`
+	URL         string     `gorm:"type:text;not null" json:"url"`
+	Format      string     `gorm:"type:varchar(20);not null;default:'json'" json:"format"`
+	Secret      string     `gorm:"type:varchar(128);not null" json:"-"` // HMAC-SHA256 signing key
+	EntityTypes string     `gorm:"type:text;not null;default:''" json:"entity_types"`
+	Actions     string     `gorm:"type:text;not null;default:''" json:"actions"`
+	ProductID   *uuid.UUID `gorm:"type:uuid" json:"product_id,omitempty"`
+	GroupID     *uuid.UUID `gorm:"type:uuid" json:"group_id,omitempty"`
+	Active      bool       `gorm:"not null" json:"active"`
+	CreatedBy   *uuid.UUID `gorm:"type:uuid" json:"created_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+func (WebhookSubscription) TableName() string { return "webhook_subscriptions" }
+
+func (s *WebhookSubscription) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// WebhookDelivery is one event queued for one subscription. Payload is the event sent on every attempt,
+// rendered as a chat message for Slack and Teams subscriptions; only the timestamp and signature headers
+// change.
+type WebhookDelivery struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	SubscriptionID uuid.UUID  `gorm:"type:uuid;not null;index" json:"subscription_id"`
+	AuditLogID     uuid.UUID  `gorm:"type:uuid;not null" json:"audit_log_id"`
+	Event          string     `gorm:"type:varchar(100);not null" json:"event"` // entity_type.action
+	Payload        JSONB      `gorm:"type:jsonb;not null" json:"payload"`
+	Status         string     `gorm:"type:varchar(20);not null" json:"status"`
+	Attempts       int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt  time.Time  `gorm:"not null" json:"next_attempt_at"`
+	LastStatusCode int        `gorm:"not null;default:0" json:"last_status_code,omitempty"` // 0 = no HTTP response
+	LastError      string     `gorm:"type:text" json:"last_error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `gorm:"index" json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+func (WebhookDelivery) TableName() string { return "webhook_deliveries" }
+
+func (d *WebhookDelivery) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
+
+// WebhookAttempt records one HTTP attempt of a delivery.
+type WebhookAttempt struct {
+	ID         int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	DeliveryID uuid.UUID `gorm:"type:uuid;not null;index" json:"delivery_id"`
+	StatusCode int       `gorm:"not null;default:0" json:"status_code"` // 0 = no HTTP response
+	Error      string    `gorm:"type:text" json:"error,omitempty"`
+	Response   string    `gorm:"type:text" json:"response,omitempty"` // start of the response body
+	DurationMs int64     `gorm:"not null" json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (WebhookAttempt) TableName() string { return "webhook_attempts" }
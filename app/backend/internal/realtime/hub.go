@@ -0,0 +1,177 @@
+// Package realtime fans out change events to clients connected over Server-Sent Events. Services publish to
+// a Hub; a Broadcaster carries each event to the Hub of every backend replica, which hands it to the local
+// subscribers whose filter accepts it.
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+// Event types.
+const (
+	EventNotification = "notification" // a new notification for UserID
+	EventUnreadCount  = "unread_count" // UserID's unread count changed
+	EventProduct      = "product"      // a product was created, updated or deleted
+	EventMilestone    = "milestone"    // a milestone was created, updated or deleted
+	EventResync       = "resync"       // events may have been missed; clients should refetch
+	// EventSessionRevoked carries the IDs of revoked sessions so every replica drops them from its session
+	// cache. It has no UserID and is never sent to clients.
+	EventSessionRevoked = "session_revoked"
+)
+
+// Event is one change. UserID limits it to one user; product and milestone events carry the product and its
+// owners so subscribers can check they may see it.
+type Event struct {
+	Type      string
+	Action    string // create | update | delete, for product and milestone events
+	UserID    *uuid.UUID
+	ProductID *uuid.UUID
+	OwnerIDs  []uuid.UUID // product owners before and after the change
+	Data      json.RawMessage
+}
+
+// Broadcaster carries published events to the Hub of every replica, including the publishing one.
+type Broadcaster interface {
+	Publish(ctx context.Context, ev Event) error
+	// Run passes every event from any replica to deliver until ctx is done.
+	Run(ctx context.Context, deliver func(Event))
+}
+
+var (
+	realtimeConnections = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "realtime_connections",
+		Help: "Clients connected to the event stream on this replica",
+	})
+	realtimeEventsPublished = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "realtime_events_published_total",
+		Help: "Events published, by type",
+	}, []string{"type"})
+	realtimeSlowClients = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "realtime_slow_client_disconnects_total",
+		Help: "Event stream clients disconnected because they did not keep up",
+	})
+)
+
+// subscriptionBuffer is how many events a subscriber may lag behind before it is disconnected.
+const subscriptionBuffer = 64
+
+// Subscription receives the events its filter accepts on C. C is closed when the subscriber falls too far
+// behind; the client should reconnect and refetch.
+type Subscription struct {
+	C      <-chan Event
+	ch     chan Event
+	filter func(Event) bool
+	closed bool
+}
+
+type Hub struct {
+	broadcaster Broadcaster
+	log         *zap.Logger
+
+	mu   sync.Mutex
+	subs map[*Subscription]struct{}
+}
+
+func NewHub(broadcaster Broadcaster, log *zap.Logger) *Hub {
+	if log == nil {
+		log = zap.NewNop()
+	}
+	return &Hub{broadcaster: broadcaster, log: log, subs: map[*Subscription]struct{}{}}
+}
+
+// Run delivers broadcast events to local subscribers until ctx is done.
+func (h *Hub) Run(ctx context.Context) {
+	h.broadcaster.Run(ctx, h.deliver)
+}
+
+// Publish sends ev to every replica. It is called after the change committed, so a failure is logged
+// rather than returned. A nil Hub publishes nothing.
+func (h *Hub) Publish(ctx context.Context, ev Event) {
+	if h == nil {
+		return
+	}
+	if err := h.broadcaster.Publish(ctx, ev); err != nil {
+		h.log.Warn("realtime publish failed", zap.Error(err), zap.String("type", ev.Type))
+		return
+	}
+	realtimeEventsPublished.WithLabelValues(ev.Type).Inc()
+}
+
+// Subscribe registers a subscriber for the events filter accepts.
+func (h *Hub) Subscribe(filter func(Event) bool) *Subscription {
+	ch := make(chan Event, subscriptionBuffer)
+	sub := &Subscription{C: ch, ch: ch, filter: filter}
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+	realtimeConnections.Inc()
+	return sub
+}
+
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[sub]; !ok {
+		return
+	}
+	delete(h.subs, sub)
+	realtimeConnections.Dec()
+	if !sub.closed {
+		sub.closed = true
+		close(sub.ch)
+	}
+}
+
+func (h *Hub) deliver(ev Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subs {
+		if sub.closed || (ev.Type != EventResync && !sub.filter(ev)) {
+			continue
+		}
+		select {
+		case sub.ch <- ev:
+		default:
+			// Never block the other subscribers on a slow one.
+			sub.closed = true
+			close(sub.ch)
+			realtimeSlowClients.Inc()
+		}
+	}
+}
+
+// LocalBroadcaster delivers events within this process only; it is enough for a single replica.
+type LocalBroadcaster struct {
+	ch chan Event
+}
+
+func NewLocalBroadcaster() *LocalBroadcaster {
+	return &LocalBroadcaster{ch: make(chan Event, 256)}
+}
+
+func (b *LocalBroadcaster) Publish(ctx context.Context, ev Event) error {
+	select {
+	case b.ch <- ev:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *LocalBroadcaster) Run(ctx context.Context, deliver func(Event)) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev := <-b.ch:
+			deliver(ev)
+		}
+	}
+}
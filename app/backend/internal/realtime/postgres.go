@@ -0,0 +1,110 @@
+package realtime
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// PostgresChannel is the LISTEN/NOTIFY channel events travel on.
+const PostgresChannel = "roadmap_events"
+
+// maxNotifyPayload stays under Postgres' 8000 byte NOTIFY payload limit.
+const maxNotifyPayload = 7900
+
+// PostgresBroadcaster carries events between replicas with LISTEN/NOTIFY. Each replica holds one dedicated
+// listening connection; events published while it is reconnecting are lost, so subscribers get a resync
+// event once it is back.
+type PostgresBroadcaster struct {
+	db  *sql.DB
+	dsn string
+	log *zap.Logger
+}
+
+func NewPostgresBroadcaster(db *sql.DB, dsn string, log *zap.Logger) *PostgresBroadcaster {
+	if log == nil {
+		log = zap.NewNop()
+	}
+	return &PostgresBroadcaster{db: db, dsn: dsn, log: log}
+}
+
+// wireEvent is the NOTIFY payload.
+type wireEvent struct {
+	Type      string          `json:"t"`
+	Action    string          `json:"a,omitempty"`
+	UserID    *uuid.UUID      `json:"u,omitempty"`
+	ProductID *uuid.UUID      `json:"p,omitempty"`
+	OwnerIDs  []uuid.UUID     `json:"o,omitempty"`
+	Data      json.RawMessage `json:"d,omitempty"`
+}
+
+// Publish sends ev with pg_notify. An event too large for a NOTIFY payload goes out without its data; clients
+// refetch what it points at.
+func (b *PostgresBroadcaster) Publish(ctx context.Context, ev Event) error {
+	w := wireEvent{Type: ev.Type, Action: ev.Action, UserID: ev.UserID, ProductID: ev.ProductID, OwnerIDs: ev.OwnerIDs, Data: ev.Data}
+	payload, err := json.Marshal(w)
+	if err != nil {
+		return err
+	}
+	if len(payload) > maxNotifyPayload {
+		w.Data = nil
+		if payload, err = json.Marshal(w); err != nil {
+			return err
+		}
+	}
+	_, err = b.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", PostgresChannel, string(payload))
+	return err
+}
+
+func (b *PostgresBroadcaster) Run(ctx context.Context, deliver func(Event)) {
+	backoff := time.Second
+	connected := false
+	for ctx.Err() == nil {
+		err := b.listen(ctx, func() {
+			if connected {
+				deliver(Event{Type: EventResync})
+			}
+			connected, backoff = true, time.Second
+		}, deliver)
+		if ctx.Err() != nil {
+			return
+		}
+		b.log.Warn("realtime listener disconnected", zap.Error(err), zap.Duration("retry_in", backoff))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, 30*time.Second)
+	}
+}
+
+// listen holds a LISTEN connection until it fails or ctx is done; onListening runs once it is listening.
+func (b *PostgresBroadcaster) listen(ctx context.Context, onListening func(), deliver func(Event)) error {
+	conn, err := pgx.Connect(ctx, b.dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+	if _, err := conn.Exec(ctx, "LISTEN "+PostgresChannel); err != nil {
+		return err
+	}
+	onListening()
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		var w wireEvent
+		if err := json.Unmarshal([]byte(n.Payload), &w); err != nil {
+			b.log.Warn("realtime: bad event payload", zap.Error(err))
+			continue
+		}
+		deliver(Event{Type: w.Type, Action: w.Action, UserID: w.UserID, ProductID: w.ProductID, OwnerIDs: w.OwnerIDs, Data: w.Data})
+	}
+}
@@ -2,17 +2,25 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/rm/roadmap/backend/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ActivityRepository interface {
-	Create(ctx context.Context, entry *models.ActivityLog) error
+	CreateBatch(ctx context.Context, entries []models.ActivityLog) error
 	// List returns activity logs. If userID is not nil, only entries for that user are returned.
-	List(ctx context.Context, limit, offset int, action string, dateFrom, dateTo *time.Time, sortBy, order string, userID *uuid.UUID) ([]models.ActivityLog, int64, error)
+	List(ctx context.Context, limit, offset int, action string, dateFrom, dateTo *time.Time, sortBy, order string, userIDs []uuid.UUID) ([]models.ActivityLog, int64, error) // nil userIDs = all users
+	// ExportPage returns up to limit rows matching the List filters after the (timestamp, id) cursor, oldest first.
+	ExportPage(ctx context.Context, action string, dateFrom, dateTo *time.Time, userIDs []uuid.UUID, afterTS time.Time, afterID uuid.UUID, limit int) ([]models.ActivityLog, error)
+	// SeqPage returns up to limit rows with a seq above afterSeq, in seq order.
+	SeqPage(ctx context.Context, afterSeq int64, limit int) ([]models.ActivityLog, error)
+	// LastSeq returns the highest seq assigned so far, 0 if none.
+	LastSeq(ctx context.Context) (int64, error)
 }
 
 type activityRepository struct {
@@ -23,14 +31,43 @@ func NewActivityRepository(db *gorm.DB) ActivityRepository {
 	return &activityRepository{db: db}
 }
 
-func (r *activityRepository) Create(ctx context.Context, entry *models.ActivityLog) error {
-	return r.db.WithContext(ctx).Create(entry).Error
+func (r *activityRepository) CreateBatch(ctx context.Context, entries []models.ActivityLog) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return dbFor(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		head, err := lockActivityHead(tx)
+		if err != nil {
+			return err
+		}
+		for i := range entries {
+			head.Seq++
+			seq := head.Seq
+			entries[i].Seq = &seq
+		}
+		if err := tx.Omit("User").CreateInBatches(entries, 100).Error; err != nil {
+			return err
+		}
+		return tx.Save(head).Error
+	})
 }
 
-func (r *activityRepository) List(ctx context.Context, limit, offset int, action string, dateFrom, dateTo *time.Time, sortBy, order string, userID *uuid.UUID) ([]models.ActivityLog, int64, error) {
-	q := r.db.WithContext(ctx).Model(&models.ActivityLog{})
-	if userID != nil {
-		q = q.Where("user_id = ?", *userID)
+// lockActivityHead returns the activity head locked FOR UPDATE until tx ends, creating it on first use.
+func lockActivityHead(tx *gorm.DB) (*models.ActivityLogHead, error) {
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.ActivityLogHead{ID: 1}).Error; err != nil {
+		return nil, err
+	}
+	var head models.ActivityLogHead
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&head, "id = ?", 1).Error; err != nil {
+		return nil, err
+	}
+	return &head, nil
+}
+
+func (r *activityRepository) List(ctx context.Context, limit, offset int, action string, dateFrom, dateTo *time.Time, sortBy, order string, userIDs []uuid.UUID) ([]models.ActivityLog, int64, error) {
+	q := dbFor(ctx, r.db).Model(&models.ActivityLog{})
+	if userIDs != nil {
+		q = q.Where("user_id IN ?", userIDs)
 	}
 	if action != "" {
 		q = q.Where("action = ?", action)
@@ -67,3 +104,38 @@ func (r *activityRepository) List(ctx context.Context, limit, offset int, action
 	err := q.Preload("User").Limit(limit).Offset(offset).Find(&list).Error
 	return list, total, err
 }
+
+func (r *activityRepository) ExportPage(ctx context.Context, action string, dateFrom, dateTo *time.Time, userIDs []uuid.UUID, afterTS time.Time, afterID uuid.UUID, limit int) ([]models.ActivityLog, error) {
+	q := r.db.WithContext(ctx).Model(&models.ActivityLog{})
+	if userIDs != nil {
+		q = q.Where("user_id IN ?", userIDs)
+	}
+	if action != "" {
+		q = q.Where("action = ?", action)
+	}
+	if dateFrom != nil {
+		q = q.Where("timestamp >= ?", *dateFrom)
+	}
+	if dateTo != nil {
+		q = q.Where("timestamp <= ?", *dateTo)
+	}
+	var list []models.ActivityLog
+	err := q.Where("(timestamp, id) > (?, ?)", afterTS, afterID).
+		Order("timestamp ASC, id ASC").Limit(limit).Preload("User").Find(&list).Error
+	return list, err
+}
+
+func (r *activityRepository) SeqPage(ctx context.Context, afterSeq int64, limit int) ([]models.ActivityLog, error) {
+	var list []models.ActivityLog
+	err := dbFor(ctx, r.db).Where("seq > ?", afterSeq).Order("seq ASC").Limit(limit).Find(&list).Error
+	return list, err
+}
+
+func (r *activityRepository) LastSeq(ctx context.Context) (int64, error) {
+	var head models.ActivityLogHead
+	err := dbFor(ctx, r.db).First(&head, "id = ?", 1).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	return head.Seq, err
+}
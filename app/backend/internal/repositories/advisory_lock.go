@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"hash/fnv"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// AdvisoryLock elects a leader among replicas with a session-level Postgres advisory lock. The lock belongs
+// to one pooled connection that the holder keeps, so it is released when that connection or the process
+// dies and another replica can take over on its next Hold. Lock instead serializes one transaction at a time.
+type AdvisoryLock struct {
+	db  *gorm.DB
+	key int64
+
+	mu   sync.Mutex
+	conn *sql.Conn // set while the lock is held
+}
+
+// NewAdvisoryLock returns the lock named name; every replica must use the same name.
+func NewAdvisoryLock(db *gorm.DB, name string) *AdvisoryLock {
+	h := fnv.New64a()
+	h.Write([]byte("roadmap:" + name))
+	return &AdvisoryLock{db: db, key: int64(h.Sum64())}
+}
+
+// Hold reports whether this replica is the leader: it checks that the connection holding the lock is still
+// alive, or tries to take the lock without waiting.
+func (l *AdvisoryLock) Hold(ctx context.Context) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conn != nil {
+		if err := l.conn.PingContext(ctx); err == nil {
+			return true, nil
+		}
+		// The session is gone and with it the lock.
+		_ = l.conn.Close()
+		l.conn = nil
+	}
+	sqlDB, err := l.db.DB()
+	if err != nil {
+		return false, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+	var ok bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", l.key).Scan(&ok); err != nil || !ok {
+		_ = conn.Close()
+		return false, err
+	}
+	l.conn = conn
+	return true, nil
+}
+
+// Lock waits for the lock in the transaction carried by ctx; it is released when that transaction ends. Do
+// not mix it with Hold on the same name.
+func (l *AdvisoryLock) Lock(ctx context.Context) error {
+	if _, ok := ctx.Value(txKey{}).(*gorm.DB); !ok {
+		return errors.New("advisory lock: Lock needs a transaction")
+	}
+	return dbFor(ctx, l.db).Exec("SELECT pg_advisory_xact_lock(?)", l.key).Error
+}
+
+// Release gives up the lock, if held.
+func (l *AdvisoryLock) Release(ctx context.Context) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conn == nil {
+		return
+	}
+	_, _ = l.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", l.key)
+	_ = l.conn.Close()
+	l.conn = nil
+}
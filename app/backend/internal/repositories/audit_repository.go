@@ -2,24 +2,91 @@ package repositories
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/auditchain"
 	"github.com/rm/roadmap/backend/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type AuditRepository interface {
-	Create(ctx context.Context, entry *models.AuditLog) error
-	List(ctx context.Context, limit, offset int, entityType, action string, dateFrom, dateTo *time.Time, archived *bool, sortBy, order string) ([]models.AuditLog, int64, error)
-	// ListForOwner returns audit logs only for entities belonging to the given product IDs (products the user owns).
-	ListForOwner(ctx context.Context, productIDs []uuid.UUID, limit, offset int, entityType, action string, dateFrom, dateTo *time.Time, archived *bool, sortBy, order string) ([]models.AuditLog, int64, error)
+	// CreateBatch seals entries into the hash chain in order and inserts them. The chain head row is locked
+	// for the duration, so concurrent writers (goroutines or instances) cannot fork the chain.
+	CreateBatch(ctx context.Context, entries []models.AuditLog) error
+	// SealUnchained chains up to limit rows written before chaining existed, oldest first. Returns how many it sealed.
+	SealUnchained(ctx context.Context, limit int) (int, error)
+	List(ctx context.Context, limit, offset int, f AuditFilter, sortBy, order string) ([]models.AuditLog, int64, error)
+	// ListForOwner returns audit logs only for entities belonging to the given product IDs (products the user owns),
+	// plus any entry whose actor is one of actorIDs (e.g. a manager's reporting subtree).
+	ListForOwner(ctx context.Context, productIDs, actorIDs []uuid.UUID, limit, offset int, f AuditFilter, sortBy, order string) ([]models.AuditLog, int64, error)
+	// ExportPage returns up to limit rows matching f after the (timestamp, id) cursor, oldest first, for
+	// streaming exports. Pass the zero time and uuid.Nil for the first page.
+	ExportPage(ctx context.Context, f AuditFilter, afterTS time.Time, afterID uuid.UUID, limit int) ([]models.AuditLog, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.AuditLog, error)
+	// LatestForEntity returns the most recent audit entry for an entity.
+	LatestForEntity(ctx context.Context, entityType, entityID string) (*models.AuditLog, error)
+	// ListByEntity returns the history of one entity oldest first, archived entries included, with the
+	// acting user loaded even if it has since been deleted.
+	ListByEntity(ctx context.Context, entityType, entityID string, limit, offset int) ([]models.AuditLog, int64, error)
 	// Archive marks the given log IDs as archived (archived=true, archived_at=now). Only non-archived rows are updated.
 	Archive(ctx context.Context, ids []uuid.UUID) error
-	// DeleteArchived permanently deletes audit log rows that are archived. Only rows with archived=true are deleted.
-	DeleteArchived(ctx context.Context, ids []uuid.UUID) error
+	// DeleteArchived permanently deletes audit log rows that are archived, leaving the tombstone built by
+	// tombstone for each chained row. Only rows with archived=true are deleted. Returns the rows deleted.
+	DeleteArchived(ctx context.Context, ids []uuid.UUID, tombstone func(*models.AuditLog) models.AuditTombstone) ([]models.AuditLog, error)
+
+	// Chain reads for auditchain.Verify; rows include soft-deleted ones.
+	ChainPage(ctx context.Context, afterSeq int64, limit int) ([]models.AuditLog, error)
+	TombstonePage(ctx context.Context, afterSeq int64, limit int) ([]models.AuditTombstone, error)
+	ChainHead(ctx context.Context) (*models.AuditChainHead, error)
+	CountUnchained(ctx context.Context) (int64, error)
+}
+
+// AuditFilter selects audit logs for List, ListForOwner and ExportPage. Empty fields match everything. With Own
+// set (ExportPage only), rows are limited to ProductIDs and ActorIDs as in ListForOwner.
+type AuditFilter struct {
+	EntityType string
+	Action     string
+	EntityID   string
+	User       string // user ID, or part of the acting user's name or email
+	IPAddress  string
+	TraceID    string
+	DateFrom   *time.Time
+	DateTo     *time.Time
+	Archived   *bool
+	Text       string // free-text query (websearch syntax) over action, entity and logged data
+	Data       []AuditDataPredicate
+	Own        bool
+	ProductIDs []uuid.UUID
+	ActorIDs   []uuid.UUID
+}
+
+// Operators for AuditDataPredicate.
+const (
+	AuditDataEq       = "eq"       // value at Path equals Value
+	AuditDataNe       = "ne"       // value at Path is missing or differs from Value
+	AuditDataContains = "contains" // array at Path holds Value, or text at Path contains it (case-insensitive)
+	AuditDataChanged  = "changed"  // value at Path differs between old_data and new_data
+)
+
+// AuditDataPredicate matches a value inside old_data or new_data.
+type AuditDataPredicate struct {
+	Column string      // "old_data", "new_data", or "" for either
+	Path   []string    // object keys and array indexes, outermost first
+	Op     string      // AuditData*
+	Value  interface{} // JSON value (string, float64, bool or nil); unused for AuditDataChanged
 }
 
+// auditSearchDocument is the text searched by AuditFilter.Text. It must stay identical to the expression of
+// idx_audit_logs_search (migration 000021) for the index to be used.
+const auditSearchDocument = "to_tsvector('simple', coalesce(action, '') || ' ' || coalesce(entity_type, '') || ' ' || coalesce(entity_id, '') || ' ' || coalesce(old_data::text, '') || ' ' || coalesce(new_data::text, '') || ' ' || coalesce(metadata::text, ''))"
+
 type auditRepository struct {
 	db *gorm.DB
 }
@@ -28,32 +95,83 @@ func NewAuditRepository(db *gorm.DB) AuditRepository {
 	return &auditRepository{db: db}
 }
 
-func (r *auditRepository) Create(ctx context.Context, entry *models.AuditLog) error {
-	return r.db.WithContext(ctx).Create(entry).Error
+func (r *auditRepository) CreateBatch(ctx context.Context, entries []models.AuditLog) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return dbFor(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		head, err := lockChainHead(tx)
+		if err != nil {
+			return err
+		}
+		for i := range entries {
+			auditchain.Seal(&entries[i], head.Seq+1, head.Hash)
+			head.Seq, head.Hash = *entries[i].Seq, entries[i].Hash
+		}
+		if err := tx.Omit("User").CreateInBatches(entries, 100).Error; err != nil {
+			return err
+		}
+		return advanceChainHead(tx, head, &entries[len(entries)-1])
+	})
 }
 
-func (r *auditRepository) List(ctx context.Context, limit, offset int, entityType, action string, dateFrom, dateTo *time.Time, archived *bool, sortBy, order string) ([]models.AuditLog, int64, error) {
-	var list []models.AuditLog
-	q := r.db.WithContext(ctx).Model(&models.AuditLog{})
-	if entityType != "" {
-		q = q.Where("entity_type = ?", entityType)
-	}
-	if action != "" {
-		q = q.Where("action = ?", action)
-	}
-	if dateFrom != nil {
-		q = q.Where("timestamp >= ?", *dateFrom)
-	}
-	if dateTo != nil {
-		q = q.Where("timestamp <= ?", *dateTo)
+func (r *auditRepository) SealUnchained(ctx context.Context, limit int) (int, error) {
+	var n int
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		head, err := lockChainHead(tx)
+		if err != nil {
+			return err
+		}
+		var rows []models.AuditLog
+		if err := tx.Unscoped().Where("seq IS NULL").Order("timestamp ASC, id ASC").Limit(limit).Find(&rows).Error; err != nil {
+			return err
+		}
+		for i := range rows {
+			auditchain.Seal(&rows[i], head.Seq+1, head.Hash)
+			if err := tx.Unscoped().Save(&rows[i]).Error; err != nil {
+				return err
+			}
+			if err := advanceChainHead(tx, head, &rows[i]); err != nil {
+				return err
+			}
+		}
+		n = len(rows)
+		return nil
+	})
+	return n, err
+}
+
+// lockChainHead returns the chain head locked FOR UPDATE until tx ends, creating it on first use.
+func lockChainHead(tx *gorm.DB) (*models.AuditChainHead, error) {
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.AuditChainHead{ID: 1, Seq: 0, Hash: auditchain.GenesisHash}).Error; err != nil {
+		return nil, err
 	}
-	if archived != nil {
-		q = q.Where("archived = ?", *archived)
+	var head models.AuditChainHead
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&head, "id = ?", 1).Error; err != nil {
+		return nil, err
 	}
+	return &head, nil
+}
+
+func advanceChainHead(tx *gorm.DB, head *models.AuditChainHead, e *models.AuditLog) error {
+	head.Seq, head.Hash = *e.Seq, e.Hash
+	return tx.Save(head).Error
+}
+
+func (r *auditRepository) List(ctx context.Context, limit, offset int, f AuditFilter, sortBy, order string) ([]models.AuditLog, int64, error) {
+	var list []models.AuditLog
+	q := applyAuditFilter(r.db.WithContext(ctx).Model(&models.AuditLog{}), f)
 	var total int64
 	if err := q.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
+	err := orderAuditLogs(q, sortBy, order).Preload("User").Limit(limit).Offset(offset).Find(&list).Error
+	return list, total, err
+}
+
+// orderAuditLogs sorts q by sortBy (timestamp when unknown), descending unless order is "asc".
+func orderAuditLogs(q *gorm.DB, sortBy, order string) *gorm.DB {
 	col := "timestamp"
 	switch sortBy {
 	case "user_id":
@@ -72,12 +190,39 @@ func (r *auditRepository) List(ctx context.Context, limit, offset int, entityTyp
 		col = "timestamp"
 	}
 	if order == "asc" {
-		q = q.Order(col + " ASC")
-	} else {
-		q = q.Order(col + " DESC")
+		return q.Order(col + " ASC")
 	}
-	err := q.Preload("User").Limit(limit).Offset(offset).Find(&list).Error
-	return list, total, err
+	return q.Order(col + " DESC")
+}
+
+// ownerScope matches entries for the given products (and their milestones and versions) or by one of actorIDs.
+func (r *auditRepository) ownerScope(productIDs, actorIDs []uuid.UUID) *gorm.DB {
+	productIDStrs := make([]string, len(productIDs))
+	for i, id := range productIDs {
+		productIDStrs[i] = id.String()
+	}
+	scope := r.db.Where(
+		"(entity_type = ? AND entity_id IN ?) OR (entity_type = ? AND entity_id IN (SELECT id::text FROM milestones WHERE product_id IN ?)) OR (entity_type = ? AND entity_id IN (SELECT id::text FROM product_versions WHERE product_id IN ?))",
+		"product", productIDStrs, "milestone", productIDs, "product_version", productIDs,
+	)
+	if len(actorIDs) > 0 {
+		scope = scope.Or("user_id IN ?", actorIDs)
+	}
+	return scope
+}
+
+func (r *auditRepository) ExportPage(ctx context.Context, f AuditFilter, afterTS time.Time, afterID uuid.UUID, limit int) ([]models.AuditLog, error) {
+	if f.Own && len(f.ProductIDs) == 0 && len(f.ActorIDs) == 0 {
+		return nil, nil
+	}
+	q := r.db.WithContext(ctx).Model(&models.AuditLog{})
+	if f.Own {
+		q = q.Where(r.ownerScope(f.ProductIDs, f.ActorIDs))
+	}
+	var list []models.AuditLog
+	err := applyAuditFilter(q, f).Where("(timestamp, id) > (?, ?)", afterTS, afterID).
+		Order("timestamp ASC, id ASC").Limit(limit).Preload("User").Find(&list).Error
+	return list, err
 }
 
 func (r *auditRepository) Archive(ctx context.Context, ids []uuid.UUID) error {
@@ -90,67 +235,209 @@ func (r *auditRepository) Archive(ctx context.Context, ids []uuid.UUID) error {
 		Updates(map[string]interface{}{"archived": true, "archived_at": now}).Error
 }
 
-func (r *auditRepository) DeleteArchived(ctx context.Context, ids []uuid.UUID) error {
+func (r *auditRepository) DeleteArchived(ctx context.Context, ids []uuid.UUID, tombstone func(*models.AuditLog) models.AuditTombstone) ([]models.AuditLog, error) {
 	if len(ids) == 0 {
-		return nil
+		return nil, nil
 	}
-	return r.db.WithContext(ctx).Where("id IN ? AND archived = ?", ids, true).Delete(&models.AuditLog{}).Error
+	var rows []models.AuditLog
+	err := dbFor(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ? AND archived = ?", ids, true).Find(&rows).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		deleted := make([]uuid.UUID, len(rows))
+		for i := range rows {
+			deleted[i] = rows[i].ID
+			if rows[i].Seq == nil {
+				continue // never chained; nothing to keep verifiable
+			}
+			t := tombstone(&rows[i])
+			if err := tx.Create(&t).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Unscoped().Where("id IN ?", deleted).Delete(&models.AuditLog{}).Error
+	})
+	return rows, err
 }
 
-func (r *auditRepository) ListForOwner(ctx context.Context, productIDs []uuid.UUID, limit, offset int, entityType, action string, dateFrom, dateTo *time.Time, archived *bool, sortBy, order string) ([]models.AuditLog, int64, error) {
-	if len(productIDs) == 0 {
+func (r *auditRepository) ChainPage(ctx context.Context, afterSeq int64, limit int) ([]models.AuditLog, error) {
+	var list []models.AuditLog
+	err := r.db.WithContext(ctx).Unscoped().Where("seq > ?", afterSeq).Order("seq ASC").Limit(limit).Find(&list).Error
+	return list, err
+}
+
+func (r *auditRepository) TombstonePage(ctx context.Context, afterSeq int64, limit int) ([]models.AuditTombstone, error) {
+	var list []models.AuditTombstone
+	err := r.db.WithContext(ctx).Where("seq > ?", afterSeq).Order("seq ASC").Limit(limit).Find(&list).Error
+	return list, err
+}
+
+func (r *auditRepository) ChainHead(ctx context.Context) (*models.AuditChainHead, error) {
+	var head models.AuditChainHead
+	err := r.db.WithContext(ctx).First(&head, "id = ?", 1).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &head, nil
+}
+
+func (r *auditRepository) CountUnchained(ctx context.Context) (int64, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Unscoped().Model(&models.AuditLog{}).Where("seq IS NULL").Count(&n).Error
+	return n, err
+}
+
+func (r *auditRepository) ListForOwner(ctx context.Context, productIDs, actorIDs []uuid.UUID, limit, offset int, f AuditFilter, sortBy, order string) ([]models.AuditLog, int64, error) {
+	if len(productIDs) == 0 && len(actorIDs) == 0 {
 		return nil, 0, nil
 	}
-	productIDStrs := make([]string, len(productIDs))
-	for i, id := range productIDs {
-		productIDStrs[i] = id.String()
+	var list []models.AuditLog
+	q := r.db.WithContext(ctx).Model(&models.AuditLog{}).Where(r.ownerScope(productIDs, actorIDs))
+	q = applyAuditFilter(q, f)
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := orderAuditLogs(q, sortBy, order).Preload("User").Limit(limit).Offset(offset).Find(&list).Error
+	return list, total, err
+}
+
+func (r *auditRepository) ListByEntity(ctx context.Context, entityType, entityID string, limit, offset int) ([]models.AuditLog, int64, error) {
+	q := r.db.WithContext(ctx).Model(&models.AuditLog{}).Where("entity_type = ? AND entity_id = ?", entityType, entityID)
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
 	}
 	var list []models.AuditLog
-	q := r.db.WithContext(ctx).Model(&models.AuditLog{}).Where(
-		"(entity_type = ? AND entity_id IN ?) OR (entity_type = ? AND entity_id IN (SELECT id::text FROM milestones WHERE product_id IN ?)) OR (entity_type = ? AND entity_id IN (SELECT id::text FROM product_versions WHERE product_id IN ?))",
-		"product", productIDStrs, "milestone", productIDs, "product_version", productIDs,
-	)
-	if entityType != "" {
-		q = q.Where("entity_type = ?", entityType)
+	err := q.Preload("User", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Order("timestamp ASC").Order("seq ASC").Limit(limit).Offset(offset).Find(&list).Error
+	return list, total, err
+}
+
+func (r *auditRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.AuditLog, error) {
+	var a models.AuditLog
+	if err := r.db.WithContext(ctx).First(&a, "id = ?", id).Error; err != nil {
+		return nil, err
 	}
-	if action != "" {
-		q = q.Where("action = ?", action)
+	return &a, nil
+}
+
+func (r *auditRepository) LatestForEntity(ctx context.Context, entityType, entityID string) (*models.AuditLog, error) {
+	var a models.AuditLog
+	err := r.db.WithContext(ctx).Where("entity_type = ? AND entity_id = ?", entityType, entityID).
+		Order("timestamp DESC").Order("seq DESC").First(&a).Error
+	if err != nil {
+		return nil, err
 	}
-	if dateFrom != nil {
-		q = q.Where("timestamp >= ?", *dateFrom)
+	return &a, nil
+}
+
+// applyAuditFilter adds the conditions of f, other than the Own scope, to q.
+func applyAuditFilter(q *gorm.DB, f AuditFilter) *gorm.DB {
+	if f.EntityType != "" {
+		q = q.Where("entity_type = ?", f.EntityType)
 	}
-	if dateTo != nil {
-		q = q.Where("timestamp <= ?", *dateTo)
+	if f.Action != "" {
+		q = q.Where("action = ?", f.Action)
 	}
-	if archived != nil {
-		q = q.Where("archived = ?", *archived)
+	if f.EntityID != "" {
+		q = q.Where("entity_id = ?", f.EntityID)
 	}
-	var total int64
-	if err := q.Count(&total).Error; err != nil {
-		return nil, 0, err
+	if f.User != "" {
+		if id, err := uuid.Parse(f.User); err == nil {
+			q = q.Where("user_id = ?", id)
+		} else {
+			like := "%" + escapeLike(f.User) + "%"
+			q = q.Where("user_id IN (SELECT id FROM users WHERE email ILIKE ? OR name ILIKE ?)", like, like)
+		}
 	}
-	col := "timestamp"
-	switch sortBy {
-	case "user_id":
-		col = "user_id"
-	case "action":
-		col = "action"
-	case "entity_type":
-		col = "entity_type"
-	case "entity_id":
-		col = "entity_id"
-	case "ip_address":
-		col = "ip_address"
-	case "trace_id":
-		col = "trace_id"
-	default:
-		col = "timestamp"
+	if f.IPAddress != "" {
+		q = q.Where("ip_address = ?", f.IPAddress)
 	}
-	if order == "asc" {
-		q = q.Order(col + " ASC")
-	} else {
-		q = q.Order(col + " DESC")
+	if f.TraceID != "" {
+		q = q.Where("trace_id = ?", f.TraceID)
 	}
-	err := q.Preload("User").Limit(limit).Offset(offset).Find(&list).Error
-	return list, total, err
+	if f.DateFrom != nil {
+		q = q.Where("timestamp >= ?", *f.DateFrom)
+	}
+	if f.DateTo != nil {
+		q = q.Where("timestamp <= ?", *f.DateTo)
+	}
+	if f.Archived != nil {
+		q = q.Where("archived = ?", *f.Archived)
+	}
+	if f.Text != "" {
+		q = q.Where(auditSearchDocument+" @@ websearch_to_tsquery('simple', ?)", f.Text)
+	}
+	for _, p := range f.Data {
+		q = q.Where(dataPredicateSQL(p))
+	}
+	return q
+}
+
+// dataPredicateSQL renders p as a condition. Equality on object keys is written as JSONB containment so the
+// jsonb_path_ops GIN indexes on old_data and new_data apply.
+func dataPredicateSQL(p AuditDataPredicate) clause.Expr {
+	path := "{" + strings.Join(p.Path, ",") + "}"
+	if p.Op == AuditDataChanged {
+		return clause.Expr{SQL: "(old_data #> ?::text[]) IS DISTINCT FROM (new_data #> ?::text[])", Vars: []interface{}{path, path}}
+	}
+	cols := []string{"old_data", "new_data"}
+	if p.Column != "" {
+		cols = []string{p.Column}
+	}
+	var parts []string
+	var vars []interface{}
+	for _, col := range cols {
+		switch p.Op {
+		case AuditDataEq, AuditDataNe:
+			var cond string
+			if doc, ok := containmentDoc(p.Path, p.Value); ok {
+				cond = col + " @> ?::jsonb"
+				vars = append(vars, doc)
+			} else {
+				b, _ := json.Marshal(p.Value)
+				cond = "(" + col + " #> ?::text[]) = ?::jsonb"
+				vars = append(vars, path, string(b))
+			}
+			if p.Op == AuditDataNe {
+				cond = "NOT coalesce(" + cond + ", false)"
+			}
+			parts = append(parts, cond)
+		case AuditDataContains:
+			b, _ := json.Marshal([]interface{}{p.Value})
+			parts = append(parts, "(("+col+" #> ?::text[]) @> ?::jsonb OR ("+col+" #>> ?::text[]) ILIKE ?)")
+			vars = append(vars, path, string(b), path, "%"+escapeLike(fmt.Sprint(p.Value))+"%")
+		}
+	}
+	join := " OR "
+	if p.Op == AuditDataNe {
+		join = " AND "
+	}
+	return clause.Expr{SQL: "(" + strings.Join(parts, join) + ")", Vars: vars}
+}
+
+// containmentDoc builds {"a":{"b":value}} for the path a.b; false if the path has an array index.
+func containmentDoc(path []string, value interface{}) (string, bool) {
+	v := value
+	for i := len(path) - 1; i >= 0; i-- {
+		if _, err := strconv.Atoi(path[i]); err == nil {
+			return "", false
+		}
+		v = map[string]interface{}{path[i]: v}
+	}
+	b, err := json.Marshal(v)
+	return string(b), err == nil
+}
+
+// escapeLike escapes the LIKE wildcards in s.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
 }
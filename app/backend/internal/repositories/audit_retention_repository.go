@@ -0,0 +1,152 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// RetentionScope selects the audit logs a retention policy applies to: rows matching EntityType and Action
+// (empty = any) that no more specific policy in Except matches and whose entity is not on legal hold.
+type RetentionScope struct {
+	EntityType string
+	Action     string
+	Except     []RetentionScope
+}
+
+type AuditRetentionRepository interface {
+	ListPolicies(ctx context.Context) ([]models.AuditRetentionPolicy, error)
+	GetPolicy(ctx context.Context, id uuid.UUID) (*models.AuditRetentionPolicy, error)
+	CreatePolicy(ctx context.Context, p *models.AuditRetentionPolicy) error
+	UpdatePolicy(ctx context.Context, p *models.AuditRetentionPolicy) error
+	DeletePolicy(ctx context.Context, id uuid.UUID) error
+
+	ListHolds(ctx context.Context) ([]models.AuditLegalHold, error)
+	GetHold(ctx context.Context, id uuid.UUID) (*models.AuditLegalHold, error)
+	CreateHold(ctx context.Context, h *models.AuditLegalHold) error
+	DeleteHold(ctx context.Context, id uuid.UUID) error
+
+	// CountArchivable counts unarchived rows in scope older than before.
+	CountArchivable(ctx context.Context, scope RetentionScope, before time.Time) (int64, error)
+	// ArchiveBatch archives up to limit unarchived rows in scope older than before, oldest first. Returns the number archived.
+	ArchiveBatch(ctx context.Context, scope RetentionScope, before time.Time, limit int) (int64, error)
+	// CountPurgeable counts archived rows in scope archived before archivedBefore.
+	CountPurgeable(ctx context.Context, scope RetentionScope, archivedBefore time.Time) (int64, error)
+	// PurgeableIDs returns up to limit archived rows in scope archived before archivedBefore, oldest first.
+	PurgeableIDs(ctx context.Context, scope RetentionScope, archivedBefore time.Time, limit int) ([]uuid.UUID, error)
+}
+
+type auditRetentionRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRetentionRepository(db *gorm.DB) AuditRetentionRepository {
+	return &auditRetentionRepository{db: db}
+}
+
+func (r *auditRetentionRepository) ListPolicies(ctx context.Context) ([]models.AuditRetentionPolicy, error) {
+	var list []models.AuditRetentionPolicy
+	err := r.db.WithContext(ctx).Order("entity_type ASC, action ASC").Find(&list).Error
+	return list, err
+}
+
+func (r *auditRetentionRepository) GetPolicy(ctx context.Context, id uuid.UUID) (*models.AuditRetentionPolicy, error) {
+	var p models.AuditRetentionPolicy
+	if err := r.db.WithContext(ctx).First(&p, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *auditRetentionRepository) CreatePolicy(ctx context.Context, p *models.AuditRetentionPolicy) error {
+	return dbFor(ctx, r.db).Create(p).Error
+}
+
+func (r *auditRetentionRepository) UpdatePolicy(ctx context.Context, p *models.AuditRetentionPolicy) error {
+	return dbFor(ctx, r.db).Save(p).Error
+}
+
+func (r *auditRetentionRepository) DeletePolicy(ctx context.Context, id uuid.UUID) error {
+	return dbFor(ctx, r.db).Delete(&models.AuditRetentionPolicy{}, "id = ?", id).Error
+}
+
+func (r *auditRetentionRepository) ListHolds(ctx context.Context) ([]models.AuditLegalHold, error) {
+	var list []models.AuditLegalHold
+	err := r.db.WithContext(ctx).Order("created_at DESC").Find(&list).Error
+	return list, err
+}
+
+func (r *auditRetentionRepository) GetHold(ctx context.Context, id uuid.UUID) (*models.AuditLegalHold, error) {
+	var h models.AuditLegalHold
+	if err := r.db.WithContext(ctx).First(&h, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+func (r *auditRetentionRepository) CreateHold(ctx context.Context, h *models.AuditLegalHold) error {
+	return dbFor(ctx, r.db).Create(h).Error
+}
+
+func (r *auditRetentionRepository) DeleteHold(ctx context.Context, id uuid.UUID) error {
+	return dbFor(ctx, r.db).Delete(&models.AuditLegalHold{}, "id = ?", id).Error
+}
+
+// inScope restricts q (on audit_logs) to the rows scope selects.
+func inScope(q *gorm.DB, scope RetentionScope) *gorm.DB {
+	if scope.EntityType != "" {
+		q = q.Where("audit_logs.entity_type = ?", scope.EntityType)
+	}
+	if scope.Action != "" {
+		q = q.Where("audit_logs.action = ?", scope.Action)
+	}
+	for _, ex := range scope.Except {
+		switch {
+		case ex.EntityType != "" && ex.Action != "":
+			q = q.Where("NOT (audit_logs.entity_type = ? AND audit_logs.action = ?)", ex.EntityType, ex.Action)
+		case ex.EntityType != "":
+			q = q.Where("audit_logs.entity_type <> ?", ex.EntityType)
+		case ex.Action != "":
+			q = q.Where("audit_logs.action <> ?", ex.Action)
+		}
+	}
+	return q.Where("NOT EXISTS (SELECT 1 FROM audit_legal_holds h WHERE h.entity_type = audit_logs.entity_type AND h.entity_id = audit_logs.entity_id)")
+}
+
+func (r *auditRetentionRepository) archivable(ctx context.Context, scope RetentionScope, before time.Time) *gorm.DB {
+	q := r.db.WithContext(ctx).Model(&models.AuditLog{}).Where("(audit_logs.archived = ? OR audit_logs.archived IS NULL) AND audit_logs.timestamp < ?", false, before)
+	return inScope(q, scope)
+}
+
+func (r *auditRetentionRepository) purgeable(ctx context.Context, scope RetentionScope, archivedBefore time.Time) *gorm.DB {
+	q := r.db.WithContext(ctx).Model(&models.AuditLog{}).Where("audit_logs.archived = ? AND COALESCE(audit_logs.archived_at, audit_logs.timestamp) < ?", true, archivedBefore)
+	return inScope(q, scope)
+}
+
+func (r *auditRetentionRepository) CountArchivable(ctx context.Context, scope RetentionScope, before time.Time) (int64, error) {
+	var n int64
+	err := r.archivable(ctx, scope, before).Count(&n).Error
+	return n, err
+}
+
+func (r *auditRetentionRepository) ArchiveBatch(ctx context.Context, scope RetentionScope, before time.Time, limit int) (int64, error) {
+	ids := r.archivable(ctx, scope, before).Select("audit_logs.id").Order("audit_logs.timestamp ASC").Limit(limit)
+	res := r.db.WithContext(ctx).Model(&models.AuditLog{}).Where("id IN (?)", ids).
+		Updates(map[string]interface{}{"archived": true, "archived_at": time.Now()})
+	return res.RowsAffected, res.Error
+}
+
+func (r *auditRetentionRepository) CountPurgeable(ctx context.Context, scope RetentionScope, archivedBefore time.Time) (int64, error) {
+	var n int64
+	err := r.purgeable(ctx, scope, archivedBefore).Count(&n).Error
+	return n, err
+}
+
+func (r *auditRetentionRepository) PurgeableIDs(ctx context.Context, scope RetentionScope, archivedBefore time.Time, limit int) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.purgeable(ctx, scope, archivedBefore).Order("audit_logs.timestamp ASC").Limit(limit).Pluck("audit_logs.id", &ids).Error
+	return ids, err
+}
@@ -1,17 +1,19 @@
 package repositories
 
 import (
+	"context"
+
 	"github.com/google/uuid"
 	"github.com/rm/roadmap/backend/internal/models"
 	"gorm.io/gorm"
 )
 
 type CompanyRepository interface {
-	Create(c *models.Company) error
+	Create(ctx context.Context, c *models.Company) error
 	GetByID(id uuid.UUID) (*models.Company, error)
 	List(holdingCompanyID *uuid.UUID) ([]models.Company, error)
-	Update(c *models.Company) error
-	Delete(id uuid.UUID) error
+	Update(ctx context.Context, c *models.Company) error
+	Delete(ctx context.Context, id uuid.UUID) error
 }
 
 type companyRepository struct {
@@ -22,8 +24,8 @@ func NewCompanyRepository(db *gorm.DB) CompanyRepository {
 	return &companyRepository{db: db}
 }
 
-func (r *companyRepository) Create(c *models.Company) error {
-	return r.db.Create(c).Error
+func (r *companyRepository) Create(ctx context.Context, c *models.Company) error {
+	return dbFor(ctx, r.db).Create(c).Error
 }
 
 func (r *companyRepository) GetByID(id uuid.UUID) (*models.Company, error) {
@@ -44,10 +46,10 @@ func (r *companyRepository) List(holdingCompanyID *uuid.UUID) ([]models.Company,
 	return list, err
 }
 
-func (r *companyRepository) Update(c *models.Company) error {
-	return r.db.Save(c).Error
+func (r *companyRepository) Update(ctx context.Context, c *models.Company) error {
+	return dbFor(ctx, r.db).Save(c).Error
 }
 
-func (r *companyRepository) Delete(id uuid.UUID) error {
-	return r.db.Delete(&models.Company{}, "id = ?", id).Error
+func (r *companyRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return dbFor(ctx, r.db).Delete(&models.Company{}, "id = ?", id).Error
 }
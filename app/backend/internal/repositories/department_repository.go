@@ -1,17 +1,19 @@
 package repositories
 
 import (
+	"context"
+
 	"github.com/google/uuid"
 	"github.com/rm/roadmap/backend/internal/models"
 	"gorm.io/gorm"
 )
 
 type DepartmentRepository interface {
-	Create(d *models.Department) error
+	Create(ctx context.Context, d *models.Department) error
 	GetByID(id uuid.UUID) (*models.Department, error)
 	List(functionID *uuid.UUID) ([]models.Department, error)
-	Update(d *models.Department) error
-	Delete(id uuid.UUID) error
+	Update(ctx context.Context, d *models.Department) error
+	Delete(ctx context.Context, id uuid.UUID) error
 }
 
 type departmentRepository struct {
@@ -22,8 +24,8 @@ func NewDepartmentRepository(db *gorm.DB) DepartmentRepository {
 	return &departmentRepository{db: db}
 }
 
-func (r *departmentRepository) Create(d *models.Department) error {
-	return r.db.Create(d).Error
+func (r *departmentRepository) Create(ctx context.Context, d *models.Department) error {
+	return dbFor(ctx, r.db).Create(d).Error
 }
 
 func (r *departmentRepository) GetByID(id uuid.UUID) (*models.Department, error) {
@@ -44,10 +46,10 @@ func (r *departmentRepository) List(functionID *uuid.UUID) ([]models.Department,
 	return list, err
 }
 
-func (r *departmentRepository) Update(d *models.Department) error {
-	return r.db.Save(d).Error
+func (r *departmentRepository) Update(ctx context.Context, d *models.Department) error {
+	return dbFor(ctx, r.db).Save(d).Error
 }
 
-func (r *departmentRepository) Delete(id uuid.UUID) error {
-	return r.db.Delete(&models.Department{}, "id = ?", id).Error
+func (r *departmentRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return dbFor(ctx, r.db).Delete(&models.Department{}, "id = ?", id).Error
 }
@@ -1,19 +1,20 @@
 package repositories
 
 import (
+	"context"
 	"github.com/google/uuid"
 	"github.com/rm/roadmap/backend/internal/models"
 	"gorm.io/gorm"
 )
 
 type DependencyRepository interface {
-	Create(d *models.Dependency) error
+	Create(ctx context.Context, d *models.Dependency) error
 	GetByID(id uuid.UUID) (*models.Dependency, error)
 	ListAll() ([]models.Dependency, error)
 	ListBySource(id uuid.UUID) ([]models.Dependency, error)
 	ListByTarget(id uuid.UUID) ([]models.Dependency, error)
-	Update(d *models.Dependency) error
-	Delete(id uuid.UUID) error
+	Update(ctx context.Context, d *models.Dependency) error
+	Delete(ctx context.Context, id uuid.UUID) error
 }
 
 type dependencyRepository struct {
@@ -24,8 +25,8 @@ func NewDependencyRepository(db *gorm.DB) DependencyRepository {
 	return &dependencyRepository{db: db}
 }
 
-func (r *dependencyRepository) Create(d *models.Dependency) error {
-	return r.db.Create(d).Error
+func (r *dependencyRepository) Create(ctx context.Context, d *models.Dependency) error {
+	return dbFor(ctx, r.db).Create(d).Error
 }
 
 func (r *dependencyRepository) GetByID(id uuid.UUID) (*models.Dependency, error) {
@@ -55,10 +56,10 @@ func (r *dependencyRepository) ListByTarget(id uuid.UUID) ([]models.Dependency,
 	return list, err
 }
 
-func (r *dependencyRepository) Update(d *models.Dependency) error {
-	return r.db.Save(d).Error
+func (r *dependencyRepository) Update(ctx context.Context, d *models.Dependency) error {
+	return dbFor(ctx, r.db).Save(d).Error
 }
 
-func (r *dependencyRepository) Delete(id uuid.UUID) error {
-	return r.db.Delete(&models.Dependency{}, "id = ?", id).Error
+func (r *dependencyRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return dbFor(ctx, r.db).Delete(&models.Dependency{}, "id = ?", id).Error
 }
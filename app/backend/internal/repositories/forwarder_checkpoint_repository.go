@@ -0,0 +1,44 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/rm/roadmap/backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ForwarderCheckpointRepository interface {
+	// Init stores cp unless a checkpoint for its stream already exists.
+	Init(ctx context.Context, cp *models.ForwarderCheckpoint) error
+	// Lock returns the stream's checkpoint locked until the transaction in ctx ends. If another transaction
+	// holds it, it returns gorm.ErrRecordNotFound instead of waiting.
+	Lock(ctx context.Context, stream string) (*models.ForwarderCheckpoint, error)
+	Save(ctx context.Context, cp *models.ForwarderCheckpoint) error
+}
+
+type forwarderCheckpointRepository struct {
+	db *gorm.DB
+}
+
+func NewForwarderCheckpointRepository(db *gorm.DB) ForwarderCheckpointRepository {
+	return &forwarderCheckpointRepository{db: db}
+}
+
+func (r *forwarderCheckpointRepository) Init(ctx context.Context, cp *models.ForwarderCheckpoint) error {
+	return dbFor(ctx, r.db).Clauses(clause.OnConflict{DoNothing: true}).Create(cp).Error
+}
+
+func (r *forwarderCheckpointRepository) Lock(ctx context.Context, stream string) (*models.ForwarderCheckpoint, error) {
+	var cp models.ForwarderCheckpoint
+	err := dbFor(ctx, r.db).Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		First(&cp, "stream = ?", stream).Error
+	if err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
+
+func (r *forwarderCheckpointRepository) Save(ctx context.Context, cp *models.ForwarderCheckpoint) error {
+	return dbFor(ctx, r.db).Save(cp).Error
+}
@@ -1,17 +1,19 @@
 package repositories
 
 import (
+	"context"
+
 	"github.com/google/uuid"
 	"github.com/rm/roadmap/backend/internal/models"
 	"gorm.io/gorm"
 )
 
 type FunctionRepository interface {
-	Create(f *models.Function) error
+	Create(ctx context.Context, f *models.Function) error
 	GetByID(id uuid.UUID) (*models.Function, error)
 	List(companyID *uuid.UUID) ([]models.Function, error)
-	Update(f *models.Function) error
-	Delete(id uuid.UUID) error
+	Update(ctx context.Context, f *models.Function) error
+	Delete(ctx context.Context, id uuid.UUID) error
 }
 
 type functionRepository struct {
@@ -22,8 +24,8 @@ func NewFunctionRepository(db *gorm.DB) FunctionRepository {
 	return &functionRepository{db: db}
 }
 
-func (r *functionRepository) Create(f *models.Function) error {
-	return r.db.Create(f).Error
+func (r *functionRepository) Create(ctx context.Context, f *models.Function) error {
+	return dbFor(ctx, r.db).Create(f).Error
 }
 
 func (r *functionRepository) GetByID(id uuid.UUID) (*models.Function, error) {
@@ -44,10 +46,10 @@ func (r *functionRepository) List(companyID *uuid.UUID) ([]models.Function, erro
 	return list, err
 }
 
-func (r *functionRepository) Update(f *models.Function) error {
-	return r.db.Save(f).Error
+func (r *functionRepository) Update(ctx context.Context, f *models.Function) error {
+	return dbFor(ctx, r.db).Save(f).Error
 }
 
-func (r *functionRepository) Delete(id uuid.UUID) error {
-	return r.db.Delete(&models.Function{}, "id = ?", id).Error
+func (r *functionRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return dbFor(ctx, r.db).Delete(&models.Function{}, "id = ?", id).Error
 }
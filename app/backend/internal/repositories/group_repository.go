@@ -1,20 +1,22 @@
 package repositories
 
 import (
+	"context"
+
 	"github.com/google/uuid"
 	"github.com/rm/roadmap/backend/internal/models"
 	"gorm.io/gorm"
 )
 
 type GroupRepository interface {
-	Create(group *models.Group) error
-	GetByID(id uuid.UUID) (*models.Group, error)
+	Create(ctx context.Context, group *models.Group) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Group, error)
 	List(createdBy *uuid.UUID) ([]models.Group, error)
-	Update(group *models.Group) error
-	Delete(id uuid.UUID) error
+	Update(ctx context.Context, group *models.Group) error
+	Delete(ctx context.Context, id uuid.UUID) error
 	GetProductIDs(groupID uuid.UUID) ([]uuid.UUID, error)
 	GetAllProductIDsInAnyGroup() ([]uuid.UUID, error)
-	SetProducts(groupID uuid.UUID, productIDs []uuid.UUID) error
+	SetProducts(ctx context.Context, groupID uuid.UUID, productIDs []uuid.UUID) error
 }
 
 type groupRepository struct {
@@ -25,13 +27,13 @@ func NewGroupRepository(db *gorm.DB) GroupRepository {
 	return &groupRepository{db: db}
 }
 
-func (r *groupRepository) Create(group *models.Group) error {
-	return r.db.Create(group).Error
+func (r *groupRepository) Create(ctx context.Context, group *models.Group) error {
+	return dbFor(ctx, r.db).Create(group).Error
 }
 
-func (r *groupRepository) GetByID(id uuid.UUID) (*models.Group, error) {
+func (r *groupRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Group, error) {
 	var g models.Group
-	if err := r.db.Preload("Products").First(&g, "id = ?", id).Error; err != nil {
+	if err := dbFor(ctx, r.db).Preload("Products").First(&g, "id = ?", id).Error; err != nil {
 		return nil, err
 	}
 	return &g, nil
@@ -47,15 +49,16 @@ func (r *groupRepository) List(createdBy *uuid.UUID) ([]models.Group, error) {
 	return list, err
 }
 
-func (r *groupRepository) Update(group *models.Group) error {
-	return r.db.Save(group).Error
+func (r *groupRepository) Update(ctx context.Context, group *models.Group) error {
+	return dbFor(ctx, r.db).Save(group).Error
 }
 
-func (r *groupRepository) Delete(id uuid.UUID) error {
-	if err := r.db.Exec("DELETE FROM group_products WHERE group_id = ?", id).Error; err != nil {
+func (r *groupRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	db := dbFor(ctx, r.db)
+	if err := db.Exec("DELETE FROM group_products WHERE group_id = ?", id).Error; err != nil {
 		return err
 	}
-	return r.db.Delete(&models.Group{}, "id = ?", id).Error
+	return db.Delete(&models.Group{}, "id = ?", id).Error
 }
 
 func (r *groupRepository) GetProductIDs(groupID uuid.UUID) ([]uuid.UUID, error) {
@@ -70,15 +73,16 @@ func (r *groupRepository) GetAllProductIDsInAnyGroup() ([]uuid.UUID, error) {
 	return ids, err
 }
 
-func (r *groupRepository) SetProducts(groupID uuid.UUID, productIDs []uuid.UUID) error {
-	if err := r.db.Exec("DELETE FROM group_products WHERE group_id = ?", groupID).Error; err != nil {
+func (r *groupRepository) SetProducts(ctx context.Context, groupID uuid.UUID, productIDs []uuid.UUID) error {
+	db := dbFor(ctx, r.db)
+	if err := db.Exec("DELETE FROM group_products WHERE group_id = ?", groupID).Error; err != nil {
 		return err
 	}
 	if len(productIDs) == 0 {
 		return nil
 	}
 	for _, pid := range productIDs {
-		if err := r.db.Exec("INSERT INTO group_products (group_id, product_id) VALUES (?, ?)", groupID, pid).Error; err != nil {
+		if err := db.Exec("INSERT INTO group_products (group_id, product_id) VALUES (?, ?)", groupID, pid).Error; err != nil {
 			return err
 		}
 	}
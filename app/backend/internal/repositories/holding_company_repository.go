@@ -1,17 +1,19 @@
 package repositories
 
 import (
+	"context"
+
 	"github.com/google/uuid"
 	"github.com/rm/roadmap/backend/internal/models"
 	"gorm.io/gorm"
 )
 
 type HoldingCompanyRepository interface {
-	Create(h *models.HoldingCompany) error
+	Create(ctx context.Context, h *models.HoldingCompany) error
 	GetByID(id uuid.UUID) (*models.HoldingCompany, error)
 	List() ([]models.HoldingCompany, error)
-	Update(h *models.HoldingCompany) error
-	Delete(id uuid.UUID) error
+	Update(ctx context.Context, h *models.HoldingCompany) error
+	Delete(ctx context.Context, id uuid.UUID) error
 }
 
 type holdingCompanyRepository struct {
@@ -22,8 +24,8 @@ func NewHoldingCompanyRepository(db *gorm.DB) HoldingCompanyRepository {
 	return &holdingCompanyRepository{db: db}
 }
 
-func (r *holdingCompanyRepository) Create(h *models.HoldingCompany) error {
-	return r.db.Create(h).Error
+func (r *holdingCompanyRepository) Create(ctx context.Context, h *models.HoldingCompany) error {
+	return dbFor(ctx, r.db).Create(h).Error
 }
 
 func (r *holdingCompanyRepository) GetByID(id uuid.UUID) (*models.HoldingCompany, error) {
@@ -40,10 +42,10 @@ func (r *holdingCompanyRepository) List() ([]models.HoldingCompany, error) {
 	return list, err
 }
 
-func (r *holdingCompanyRepository) Update(h *models.HoldingCompany) error {
-	return r.db.Save(h).Error
+func (r *holdingCompanyRepository) Update(ctx context.Context, h *models.HoldingCompany) error {
+	return dbFor(ctx, r.db).Save(h).Error
 }
 
-func (r *holdingCompanyRepository) Delete(id uuid.UUID) error {
-	return r.db.Delete(&models.HoldingCompany{}, "id = ?", id).Error
+func (r *holdingCompanyRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return dbFor(ctx, r.db).Delete(&models.HoldingCompany{}, "id = ?", id).Error
 }
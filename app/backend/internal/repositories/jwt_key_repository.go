@@ -0,0 +1,40 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/rm/roadmap/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type JWTKeyRepository interface {
+	// ListValid returns keys that are still accepted for verification at now, oldest first.
+	ListValid(ctx context.Context, now time.Time) ([]models.JWTSigningKey, error)
+	Create(ctx context.Context, k *models.JWTSigningKey) error
+	// DeleteExpired removes keys that expired before the given time.
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+type jwtKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewJWTKeyRepository(db *gorm.DB) JWTKeyRepository {
+	return &jwtKeyRepository{db: db}
+}
+
+func (r *jwtKeyRepository) ListValid(ctx context.Context, now time.Time) ([]models.JWTSigningKey, error) {
+	var list []models.JWTSigningKey
+	err := dbFor(ctx, r.db).Where("expires_at > ?", now).Order("activates_at ASC").Find(&list).Error
+	return list, err
+}
+
+func (r *jwtKeyRepository) Create(ctx context.Context, k *models.JWTSigningKey) error {
+	return dbFor(ctx, r.db).Create(k).Error
+}
+
+func (r *jwtKeyRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	res := dbFor(ctx, r.db).Where("expires_at <= ?", before).Delete(&models.JWTSigningKey{})
+	return res.RowsAffected, res.Error
+}
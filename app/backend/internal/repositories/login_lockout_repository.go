@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type LoginLockoutRepository interface {
+	Get(ctx context.Context, scope, key string) (*models.LoginLockout, error)
+	// RecordFailure atomically increments the failure counter for scope/key and returns the updated row.
+	// Failures older than windowStart are forgotten (unless a lock ended inside the window), so the counter restarts at 1.
+	RecordFailure(ctx context.Context, scope, key string, windowStart, now time.Time) (*models.LoginLockout, error)
+	SetLockedUntil(ctx context.Context, id uuid.UUID, until time.Time) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.LoginLockout, error)
+	// Reset removes the counter and any lock for scope/key.
+	Reset(ctx context.Context, scope, key string) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	// ListLocked returns rows whose lock is still in effect at now.
+	ListLocked(ctx context.Context, now time.Time) ([]models.LoginLockout, error)
+}
+
+type loginLockoutRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginLockoutRepository(db *gorm.DB) LoginLockoutRepository {
+	return &loginLockoutRepository{db: db}
+}
+
+func (r *loginLockoutRepository) Get(ctx context.Context, scope, key string) (*models.LoginLockout, error) {
+	var l models.LoginLockout
+	if err := dbFor(ctx, r.db).Where("scope = ? AND key = ?", scope, key).First(&l).Error; err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+func (r *loginLockoutRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.LoginLockout, error) {
+	var l models.LoginLockout
+	if err := dbFor(ctx, r.db).First(&l, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+func (r *loginLockoutRepository) RecordFailure(ctx context.Context, scope, key string, windowStart, now time.Time) (*models.LoginLockout, error) {
+	var l models.LoginLockout
+	err := dbFor(ctx, r.db).Raw(`
+		INSERT INTO login_lockouts (id, scope, key, failed_count, last_failed_at, created_at, updated_at)
+		VALUES (?, ?, ?, 1, ?, ?, ?)
+		ON CONFLICT (scope, key) DO UPDATE SET
+			failed_count = CASE
+				WHEN login_lockouts.last_failed_at < ? AND (login_lockouts.locked_until IS NULL OR login_lockouts.locked_until < ?) THEN 1
+				ELSE login_lockouts.failed_count + 1
+			END,
+			last_failed_at = EXCLUDED.last_failed_at,
+			updated_at = EXCLUDED.updated_at
+		RETURNING *`,
+		uuid.New(), scope, key, now, now, now, windowStart, windowStart,
+	).Scan(&l).Error
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+func (r *loginLockoutRepository) SetLockedUntil(ctx context.Context, id uuid.UUID, until time.Time) error {
+	return dbFor(ctx, r.db).Model(&models.LoginLockout{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"locked_until": until, "updated_at": time.Now()}).Error
+}
+
+func (r *loginLockoutRepository) Reset(ctx context.Context, scope, key string) error {
+	return dbFor(ctx, r.db).Where("scope = ? AND key = ?", scope, key).Delete(&models.LoginLockout{}).Error
+}
+
+func (r *loginLockoutRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return dbFor(ctx, r.db).Delete(&models.LoginLockout{}, "id = ?", id).Error
+}
+
+func (r *loginLockoutRepository) ListLocked(ctx context.Context, now time.Time) ([]models.LoginLockout, error) {
+	var list []models.LoginLockout
+	err := dbFor(ctx, r.db).Where("locked_until > ?", now).Order("locked_until DESC").Find(&list).Error
+	return list, err
+}
@@ -0,0 +1,43 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/rm/roadmap/backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MilestoneReminderRepository interface {
+	// ListOpen returns the milestones not completed, of products not deleted, that start or end in [from, to],
+	// with their product.
+	ListOpen(ctx context.Context, from, to time.Time) ([]models.Milestone, error)
+	// Claim records r and reports whether it was new; false means the reminder was already sent.
+	Claim(ctx context.Context, r *models.MilestoneReminder) (bool, error)
+}
+
+type milestoneReminderRepository struct {
+	db *gorm.DB
+}
+
+func NewMilestoneReminderRepository(db *gorm.DB) MilestoneReminderRepository {
+	return &milestoneReminderRepository{db: db}
+}
+
+func (r *milestoneReminderRepository) ListOpen(ctx context.Context, from, to time.Time) ([]models.Milestone, error) {
+	var list []models.Milestone
+	err := dbFor(ctx, r.db).
+		Joins("JOIN products ON products.id = milestones.product_id AND products.deleted_at IS NULL").
+		Where("milestones.completed_at IS NULL").
+		Where("(milestones.start_date BETWEEN ? AND ?) OR (milestones.end_date BETWEEN ? AND ?)", from, to, from, to).
+		Preload("Product").
+		Order("milestones.start_date").
+		Find(&list).Error
+	return list, err
+}
+
+func (r *milestoneReminderRepository) Claim(ctx context.Context, m *models.MilestoneReminder) (bool, error) {
+	res := dbFor(ctx, r.db).Clauses(clause.OnConflict{DoNothing: true}).Create(m)
+	return res.RowsAffected == 1, res.Error
+}
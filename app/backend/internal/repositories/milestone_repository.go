@@ -1,17 +1,22 @@
 package repositories
 
 import (
+	"context"
 	"github.com/google/uuid"
 	"github.com/rm/roadmap/backend/internal/models"
 	"gorm.io/gorm"
 )
 
 type MilestoneRepository interface {
-	Create(m *models.Milestone) error
+	Create(ctx context.Context, m *models.Milestone) error
 	GetByID(id uuid.UUID) (*models.Milestone, error)
 	ListByProductID(productID uuid.UUID) ([]models.Milestone, error)
-	Update(m *models.Milestone) error
-	Delete(id uuid.UUID) error
+	Update(ctx context.Context, m *models.Milestone) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	// GetByIDUnscoped returns the milestone even if it is soft-deleted.
+	GetByIDUnscoped(ctx context.Context, id uuid.UUID) (*models.Milestone, error)
+	// Undelete clears deleted_at on a soft-deleted milestone.
+	Undelete(ctx context.Context, id uuid.UUID) error
 }
 
 type milestoneRepository struct {
@@ -22,8 +27,8 @@ func NewMilestoneRepository(db *gorm.DB) MilestoneRepository {
 	return &milestoneRepository{db: db}
 }
 
-func (r *milestoneRepository) Create(m *models.Milestone) error {
-	return r.db.Create(m).Error
+func (r *milestoneRepository) Create(ctx context.Context, m *models.Milestone) error {
+	return dbFor(ctx, r.db).Create(m).Error
 }
 
 func (r *milestoneRepository) GetByID(id uuid.UUID) (*models.Milestone, error) {
@@ -41,10 +46,22 @@ func (r *milestoneRepository) ListByProductID(productID uuid.UUID) ([]models.Mil
 	return list, err
 }
 
-func (r *milestoneRepository) Update(m *models.Milestone) error {
-	return r.db.Save(m).Error
+func (r *milestoneRepository) Update(ctx context.Context, m *models.Milestone) error {
+	return dbFor(ctx, r.db).Save(m).Error
 }
 
-func (r *milestoneRepository) Delete(id uuid.UUID) error {
-	return r.db.Delete(&models.Milestone{}, "id = ?", id).Error
+func (r *milestoneRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return dbFor(ctx, r.db).Delete(&models.Milestone{}, "id = ?", id).Error
+}
+
+func (r *milestoneRepository) GetByIDUnscoped(ctx context.Context, id uuid.UUID) (*models.Milestone, error) {
+	var m models.Milestone
+	if err := dbFor(ctx, r.db).Unscoped().First(&m, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *milestoneRepository) Undelete(ctx context.Context, id uuid.UUID) error {
+	return dbFor(ctx, r.db).Unscoped().Model(&models.Milestone{}).Where("id = ?", id).Update("deleted_at", nil).Error
 }
@@ -0,0 +1,170 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationDeliveryRepository interface {
+	Enqueue(ctx context.Context, d *models.NotificationDelivery) error
+	// ClaimDue leases up to limit pending immediate deliveries on channel that are due at now, by moving their
+	// NextAttemptAt to now+lease, so other instances skip them while they are sent. A delivery whose sender
+	// dies is picked up again when the lease runs out.
+	ClaimDue(ctx context.Context, channel string, now time.Time, lease time.Duration, limit int) ([]models.NotificationDelivery, error)
+	// ListDigest returns the pending digest deliveries of a user on channel, oldest first.
+	ListDigest(ctx context.Context, userID uuid.UUID, channel string) ([]models.NotificationDelivery, error)
+	MarkSent(ctx context.Context, ids []int64, at time.Time) error
+	// MarkRetry counts a failed attempt and schedules the next one.
+	MarkRetry(ctx context.Context, ids []int64, errMsg string, next time.Time) error
+	// MarkFailed counts a failed attempt and gives up on the deliveries.
+	MarkFailed(ctx context.Context, ids []int64, errMsg string) error
+	// Reroute moves a user's pending deliveries on channel to digest or immediate delivery, or cancels them.
+	Reroute(ctx context.Context, userID uuid.UUID, channel, frequency string, now time.Time) error
+	// Pending returns the number of pending deliveries on channel, immediate and digest.
+	Pending(ctx context.Context, channel string) (immediate, digest int64, err error)
+
+	// GetEmailSetting returns the user's email setting, or gorm.ErrRecordNotFound if they never chose one.
+	GetEmailSetting(ctx context.Context, userID uuid.UUID) (*models.NotificationEmailSetting, error)
+	SaveEmailSetting(ctx context.Context, s *models.NotificationEmailSetting) error
+	// DueDigests returns settings whose digest period has passed and that have a digest delivery due at now.
+	DueDigests(ctx context.Context, now time.Time, limit int) ([]models.NotificationEmailSetting, error)
+	// ClaimDigest moves the user's LastDigestAt from prev to now; false means another instance got there first.
+	ClaimDigest(ctx context.Context, userID uuid.UUID, prev, now time.Time) (bool, error)
+}
+
+type notificationDeliveryRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationDeliveryRepository(db *gorm.DB) NotificationDeliveryRepository {
+	return &notificationDeliveryRepository{db: db}
+}
+
+func (r *notificationDeliveryRepository) Enqueue(ctx context.Context, d *models.NotificationDelivery) error {
+	return dbFor(ctx, r.db).Create(d).Error
+}
+
+func (r *notificationDeliveryRepository) ClaimDue(ctx context.Context, channel string, now time.Time, lease time.Duration, limit int) ([]models.NotificationDelivery, error) {
+	var list []models.NotificationDelivery
+	err := r.db.WithContext(ctx).Raw(`
+		UPDATE notification_deliveries SET next_attempt_at = ?
+		WHERE id IN (
+			SELECT id FROM notification_deliveries
+			WHERE channel = ? AND status = ? AND NOT digest AND next_attempt_at <= ?
+			ORDER BY next_attempt_at, id
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		now.Add(lease), channel, models.DeliveryStatusPending, now, limit).Scan(&list).Error
+	return list, err
+}
+
+func (r *notificationDeliveryRepository) ListDigest(ctx context.Context, userID uuid.UUID, channel string) ([]models.NotificationDelivery, error) {
+	var list []models.NotificationDelivery
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND channel = ? AND status = ? AND digest", userID, channel, models.DeliveryStatusPending).
+		Order("created_at, id").Find(&list).Error
+	return list, err
+}
+
+func (r *notificationDeliveryRepository) MarkSent(ctx context.Context, ids []int64, at time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Model(&models.NotificationDelivery{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+		"status":     models.DeliveryStatusSent,
+		"attempts":   gorm.Expr("attempts + 1"),
+		"sent_at":    at,
+		"last_error": "",
+	}).Error
+}
+
+func (r *notificationDeliveryRepository) MarkRetry(ctx context.Context, ids []int64, errMsg string, next time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Model(&models.NotificationDelivery{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+		"attempts":        gorm.Expr("attempts + 1"),
+		"last_error":      errMsg,
+		"next_attempt_at": next,
+	}).Error
+}
+
+func (r *notificationDeliveryRepository) MarkFailed(ctx context.Context, ids []int64, errMsg string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Model(&models.NotificationDelivery{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+		"status":     models.DeliveryStatusFailed,
+		"attempts":   gorm.Expr("attempts + 1"),
+		"last_error": errMsg,
+	}).Error
+}
+
+func (r *notificationDeliveryRepository) Reroute(ctx context.Context, userID uuid.UUID, channel, frequency string, now time.Time) error {
+	q := dbFor(ctx, r.db).Model(&models.NotificationDelivery{}).
+		Where("user_id = ? AND channel = ? AND status = ?", userID, channel, models.DeliveryStatusPending)
+	switch frequency {
+	case models.EmailFrequencyOff:
+		return q.Update("status", models.DeliveryStatusCancelled).Error
+	case models.EmailFrequencyImmediate:
+		return q.Where("digest").Updates(map[string]interface{}{"digest": false, "next_attempt_at": now}).Error
+	default:
+		return q.Where("NOT digest").Update("digest", true).Error
+	}
+}
+
+func (r *notificationDeliveryRepository) Pending(ctx context.Context, channel string) (immediate, digest int64, err error) {
+	var row struct {
+		Immediate int64
+		Digest    int64
+	}
+	err = r.db.WithContext(ctx).Model(&models.NotificationDelivery{}).
+		Select("COUNT(*) FILTER (WHERE NOT digest) AS immediate, COUNT(*) FILTER (WHERE digest) AS digest").
+		Where("channel = ? AND status = ?", channel, models.DeliveryStatusPending).
+		Scan(&row).Error
+	return row.Immediate, row.Digest, err
+}
+
+func (r *notificationDeliveryRepository) GetEmailSetting(ctx context.Context, userID uuid.UUID) (*models.NotificationEmailSetting, error) {
+	var s models.NotificationEmailSetting
+	if err := dbFor(ctx, r.db).First(&s, "user_id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *notificationDeliveryRepository) SaveEmailSetting(ctx context.Context, s *models.NotificationEmailSetting) error {
+	return dbFor(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"frequency", "last_digest_at", "updated_at"}),
+	}).Create(s).Error
+}
+
+func (r *notificationDeliveryRepository) DueDigests(ctx context.Context, now time.Time, limit int) ([]models.NotificationEmailSetting, error) {
+	var list []models.NotificationEmailSetting
+	err := r.db.WithContext(ctx).
+		Where("(frequency = ? AND last_digest_at <= ?) OR (frequency = ? AND last_digest_at <= ?)",
+			models.EmailFrequencyDaily, now.Add(-24*time.Hour), models.EmailFrequencyWeekly, now.Add(-7*24*time.Hour)).
+		Where(`EXISTS (SELECT 1 FROM notification_deliveries d WHERE d.user_id = notification_email_settings.user_id
+			AND d.channel = ? AND d.status = ? AND d.digest AND d.next_attempt_at <= ?)`,
+			models.DeliveryChannelEmail, models.DeliveryStatusPending, now).
+		Order("last_digest_at").Limit(limit).Find(&list).Error
+	return list, err
+}
+
+func (r *notificationDeliveryRepository) ClaimDigest(ctx context.Context, userID uuid.UUID, prev, now time.Time) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&models.NotificationEmailSetting{}).
+		Where("user_id = ? AND last_digest_at = ?", userID, prev).
+		Update("last_digest_at", now)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
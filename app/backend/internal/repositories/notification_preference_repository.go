@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationPreferenceRepository interface {
+	// ListForUser returns the user's preferences, or only those for notifType when it is not empty.
+	ListForUser(ctx context.Context, userID uuid.UUID, notifType string) ([]models.NotificationPreference, error)
+	Save(ctx context.Context, p *models.NotificationPreference) error
+	Delete(ctx context.Context, userID uuid.UUID, notifType, channel string) error
+	// ListDefaults returns the organization defaults, or only those for notifType when it is not empty.
+	ListDefaults(ctx context.Context, notifType string) ([]models.NotificationDefault, error)
+	SaveDefault(ctx context.Context, d *models.NotificationDefault) error
+}
+
+type notificationPreferenceRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationPreferenceRepository(db *gorm.DB) NotificationPreferenceRepository {
+	return &notificationPreferenceRepository{db: db}
+}
+
+func (r *notificationPreferenceRepository) ListForUser(ctx context.Context, userID uuid.UUID, notifType string) ([]models.NotificationPreference, error) {
+	var list []models.NotificationPreference
+	q := dbFor(ctx, r.db).Where("user_id = ?", userID)
+	if notifType != "" {
+		q = q.Where("type = ?", notifType)
+	}
+	err := q.Order("type, channel").Find(&list).Error
+	return list, err
+}
+
+func (r *notificationPreferenceRepository) Save(ctx context.Context, p *models.NotificationPreference) error {
+	return dbFor(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}, {Name: "channel"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+	}).Create(p).Error
+}
+
+func (r *notificationPreferenceRepository) Delete(ctx context.Context, userID uuid.UUID, notifType, channel string) error {
+	return dbFor(ctx, r.db).Where("user_id = ? AND type = ? AND channel = ?", userID, notifType, channel).
+		Delete(&models.NotificationPreference{}).Error
+}
+
+func (r *notificationPreferenceRepository) ListDefaults(ctx context.Context, notifType string) ([]models.NotificationDefault, error) {
+	var list []models.NotificationDefault
+	q := dbFor(ctx, r.db)
+	if notifType != "" {
+		q = q.Where("type = ?", notifType)
+	}
+	err := q.Order("type, channel").Find(&list).Error
+	return list, err
+}
+
+func (r *notificationPreferenceRepository) SaveDefault(ctx context.Context, d *models.NotificationDefault) error {
+	return dbFor(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "type"}, {Name: "channel"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "mandatory", "updated_by", "updated_at"}),
+	}).Create(d).Error
+}
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"time"
 
 	"github.com/google/uuid"
@@ -8,16 +9,50 @@ import (
 	"gorm.io/gorm"
 )
 
+// NotificationFilter selects among a user's visible notifications; zero fields match any.
+type NotificationFilter struct {
+	Archived          *bool // true: only archived, false: only not archived
+	Read              *bool // true: only read, false: only unread
+	Type              string
+	RelatedEntityType string
+	RelatedEntityID   *uuid.UUID
+	Before            *time.Time // created before
+	IDs               []uuid.UUID
+	Threads           bool // widen IDs to every notification in the same thread
+}
+
+// NotificationThread is the newest notification of a thread with the thread's size. A thread is the
+// notifications of one type about the same related entity; a notification without one is its own thread.
+type NotificationThread struct {
+	models.Notification
+	ThreadCount  int64
+	ThreadUnread int64
+}
+
+// notificationThreadKey partitions notifications into threads.
+const notificationThreadKey = "type, COALESCE(related_entity_type, ''), COALESCE(related_entity_id, id)"
+
 type NotificationRepository interface {
 	Create(n *models.Notification) error
-	List(userID uuid.UUID, includeArchived bool, limit, offset int) ([]models.Notification, error)
-	Count(userID uuid.UUID, includeArchived bool) (int64, error)
+	List(userID uuid.UUID, f NotificationFilter, limit, offset int) ([]models.Notification, error)
+	Count(userID uuid.UUID, f NotificationFilter) (int64, error)
+	// ListThreads returns the threads of the matching notifications, newest first, and their total number.
+	ListThreads(userID uuid.UUID, f NotificationFilter, limit, offset int) ([]NotificationThread, int64, error)
 	UnreadCount(userID uuid.UUID) (int64, error)
 	GetByID(id, userID uuid.UUID) (*models.Notification, error)
 	MarkRead(id, userID uuid.UUID) error
 	MarkReadAll(userID uuid.UUID) error
 	Archive(id, userID uuid.UUID) error
 	Delete(id, userID uuid.UUID) error
+	// MarkReadMatching, ArchiveMatching and DeleteMatching apply to every matching notification and return
+	// how many changed.
+	MarkReadMatching(userID uuid.UUID, f NotificationFilter) (int64, error)
+	ArchiveMatching(userID uuid.UUID, f NotificationFilter) (int64, error)
+	DeleteMatching(userID uuid.UUID, f NotificationFilter) (int64, error)
+	// Purge permanently deletes up to limit notifications created before cutoff that were read, archived,
+	// deleted or only stored for email, except those with email still pending, together with their
+	// deliveries. It returns how many it deleted.
+	Purge(ctx context.Context, cutoff time.Time, limit int) (int64, error)
 }
 
 type notificationRepository struct {
@@ -32,30 +67,76 @@ func (r *notificationRepository) Create(n *models.Notification) error {
 	return r.db.Create(n).Error
 }
 
-func (r *notificationRepository) List(userID uuid.UUID, includeArchived bool, limit, offset int) ([]models.Notification, error) {
-	var list []models.Notification
-	q := r.db.Where("user_id = ? AND deleted_at IS NULL", userID)
-	if !includeArchived {
-		q = q.Where("archived_at IS NULL")
+// matching scopes a query to the user's visible notifications that match f.
+func (r *notificationRepository) matching(userID uuid.UUID, f NotificationFilter) *gorm.DB {
+	q := r.db.Model(&models.Notification{}).Where("user_id = ? AND deleted_at IS NULL AND NOT hidden", userID)
+	if f.Archived != nil {
+		if *f.Archived {
+			q = q.Where("archived_at IS NOT NULL")
+		} else {
+			q = q.Where("archived_at IS NULL")
+		}
+	}
+	if f.Read != nil {
+		if *f.Read {
+			q = q.Where("read_at IS NOT NULL")
+		} else {
+			q = q.Where("read_at IS NULL")
+		}
 	}
-	err := q.Order("created_at DESC").Limit(limit).Offset(offset).Find(&list).Error
+	if f.Type != "" {
+		q = q.Where("type = ?", f.Type)
+	}
+	if f.RelatedEntityType != "" {
+		q = q.Where("related_entity_type = ?", f.RelatedEntityType)
+	}
+	if f.RelatedEntityID != nil {
+		q = q.Where("related_entity_id = ?", *f.RelatedEntityID)
+	}
+	if f.Before != nil {
+		q = q.Where("created_at < ?", *f.Before)
+	}
+	if len(f.IDs) > 0 {
+		if f.Threads {
+			q = q.Where("("+notificationThreadKey+") IN (SELECT "+notificationThreadKey+" FROM notifications WHERE user_id = ? AND id IN ?)", userID, f.IDs)
+		} else {
+			q = q.Where("id IN ?", f.IDs)
+		}
+	}
+	return q
+}
+
+func (r *notificationRepository) List(userID uuid.UUID, f NotificationFilter, limit, offset int) ([]models.Notification, error) {
+	var list []models.Notification
+	err := r.matching(userID, f).Order("created_at DESC").Limit(limit).Offset(offset).Find(&list).Error
 	return list, err
 }
 
-func (r *notificationRepository) Count(userID uuid.UUID, includeArchived bool) (int64, error) {
+func (r *notificationRepository) Count(userID uuid.UUID, f NotificationFilter) (int64, error) {
 	var count int64
-	q := r.db.Model(&models.Notification{}).Where("user_id = ? AND deleted_at IS NULL", userID)
-	if !includeArchived {
-		q = q.Where("archived_at IS NULL")
-	}
-	err := q.Count(&count).Error
+	err := r.matching(userID, f).Count(&count).Error
 	return count, err
 }
 
+func (r *notificationRepository) ListThreads(userID uuid.UUID, f NotificationFilter, limit, offset int) ([]NotificationThread, int64, error) {
+	inner := r.matching(userID, f).Select("notifications.*, " +
+		"COUNT(*) OVER (PARTITION BY " + notificationThreadKey + ") AS thread_count, " +
+		"COUNT(*) FILTER (WHERE read_at IS NULL) OVER (PARTITION BY " + notificationThreadKey + ") AS thread_unread, " +
+		"ROW_NUMBER() OVER (PARTITION BY " + notificationThreadKey + " ORDER BY created_at DESC, id) AS thread_pos")
+	q := r.db.Table("(?) AS t", inner).Where("thread_pos = 1")
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var list []NotificationThread
+	err := q.Order("created_at DESC").Limit(limit).Offset(offset).Scan(&list).Error
+	return list, total, err
+}
+
 func (r *notificationRepository) UnreadCount(userID uuid.UUID) (int64, error) {
 	var count int64
 	err := r.db.Model(&models.Notification{}).
-		Where("user_id = ? AND read_at IS NULL AND archived_at IS NULL AND deleted_at IS NULL", userID).
+		Where("user_id = ? AND read_at IS NULL AND archived_at IS NULL AND deleted_at IS NULL AND NOT hidden", userID).
 		Count(&count).Error
 	return count, err
 }
@@ -95,3 +176,45 @@ func (r *notificationRepository) Delete(id, userID uuid.UUID) error {
 	return r.db.Where("id = ? AND user_id = ? AND deleted_at IS NULL", id, userID).
 		Delete(&models.Notification{}).Error
 }
+
+func (r *notificationRepository) MarkReadMatching(userID uuid.UUID, f NotificationFilter) (int64, error) {
+	res := r.matching(userID, f).Where("read_at IS NULL").Update("read_at", time.Now().UTC())
+	return res.RowsAffected, res.Error
+}
+
+func (r *notificationRepository) ArchiveMatching(userID uuid.UUID, f NotificationFilter) (int64, error) {
+	now := time.Now().UTC()
+	res := r.matching(userID, f).Where("archived_at IS NULL").
+		Updates(map[string]interface{}{"archived_at": now, "read_at": gorm.Expr("COALESCE(read_at, ?)", now)})
+	return res.RowsAffected, res.Error
+}
+
+func (r *notificationRepository) DeleteMatching(userID uuid.UUID, f NotificationFilter) (int64, error) {
+	res := r.matching(userID, f).Delete(&models.Notification{})
+	return res.RowsAffected, res.Error
+}
+
+func (r *notificationRepository) Purge(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var ids []uuid.UUID
+		if err := tx.Raw(`
+			DELETE FROM notifications WHERE id IN (
+				SELECT n.id FROM notifications n
+				WHERE n.created_at < ?
+				AND (n.read_at IS NOT NULL OR n.archived_at IS NOT NULL OR n.deleted_at IS NOT NULL OR n.hidden)
+				AND NOT EXISTS (SELECT 1 FROM notification_deliveries d WHERE d.notification_id = n.id AND d.status = ?)
+				LIMIT ?
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id`, cutoff, models.DeliveryStatusPending, limit).Scan(&ids).Error; err != nil {
+			return err
+		}
+		n = int64(len(ids))
+		if n == 0 {
+			return nil
+		}
+		return tx.Where("notification_id IN ?", ids).Delete(&models.NotificationDelivery{}).Error
+	})
+	return n, err
+}
@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/rm/roadmap/backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OutboxRepository interface {
+	// Enqueue inserts the event, inside the caller's transaction when ctx carries one.
+	Enqueue(ctx context.Context, e *models.OutboxEvent) error
+	// Dispatch locks up to limit pending events in insertion order, passes them to deliver and deletes them,
+	// all in one transaction; deliver's writes join it through ctx. Returns the number of events handled.
+	Dispatch(ctx context.Context, limit int, deliver func(ctx context.Context, events []models.OutboxEvent) error) (int, error)
+	// MarkFailed counts a failed attempt for the events. Events reaching maxAttempts are set aside (failed_at) with the error kept.
+	MarkFailed(ctx context.Context, ids []int64, errMsg string, maxAttempts int) error
+	// Stats returns the number of pending and set-aside events.
+	Stats(ctx context.Context) (pending, failed int64, err error)
+}
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+func (r *outboxRepository) Enqueue(ctx context.Context, e *models.OutboxEvent) error {
+	return dbFor(ctx, r.db).Create(e).Error
+}
+
+func (r *outboxRepository) Dispatch(ctx context.Context, limit int, deliver func(ctx context.Context, events []models.OutboxEvent) error) (int, error) {
+	var n int
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Plain FOR UPDATE (not SKIP LOCKED): a second instance waits for the first batch instead of
+		// overtaking it, which keeps the audit chain in insertion order.
+		var events []models.OutboxEvent
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("failed_at IS NULL").Order("id ASC").Limit(limit).Find(&events).Error; err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+		if err := deliver(context.WithValue(ctx, txKey{}, tx), events); err != nil {
+			return err
+		}
+		ids := make([]int64, len(events))
+		for i := range events {
+			ids[i] = events[i].ID
+		}
+		n = len(events)
+		return tx.Where("id IN ?", ids).Delete(&models.OutboxEvent{}).Error
+	})
+	return n, err
+}
+
+func (r *outboxRepository) MarkFailed(ctx context.Context, ids []int64, errMsg string, maxAttempts int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Model(&models.OutboxEvent{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+		"attempts":   gorm.Expr("attempts + 1"),
+		"last_error": errMsg,
+		"failed_at":  gorm.Expr("CASE WHEN attempts + 1 >= ? THEN ?::timestamptz ELSE NULL END", maxAttempts, time.Now()),
+	}).Error
+}
+
+func (r *outboxRepository) Stats(ctx context.Context) (pending, failed int64, err error) {
+	var row struct {
+		Pending int64
+		Failed  int64
+	}
+	err = r.db.WithContext(ctx).Model(&models.OutboxEvent{}).
+		Select("COUNT(*) FILTER (WHERE failed_at IS NULL) AS pending, COUNT(*) FILTER (WHERE failed_at IS NOT NULL) AS failed").
+		Scan(&row).Error
+	return row.Pending, row.Failed, err
+}
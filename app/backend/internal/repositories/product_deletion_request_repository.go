@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,11 +10,11 @@ import (
 )
 
 type ProductDeletionRequestRepository interface {
-	Create(req *models.ProductDeletionRequest) error
+	Create(ctx context.Context, req *models.ProductDeletionRequest) error
 	GetByID(id uuid.UUID) (*models.ProductDeletionRequest, error)
 	List(status *models.RequestStatus, productOwnerID *uuid.UUID, requestedBy *uuid.UUID, fromDate, toDate *time.Time) ([]models.ProductDeletionRequest, error)
 	FindPendingByProductID(productID uuid.UUID) (*models.ProductDeletionRequest, error)
-	Update(req *models.ProductDeletionRequest) error
+	Update(ctx context.Context, req *models.ProductDeletionRequest) error
 }
 
 type productDeletionRequestRepository struct {
@@ -24,8 +25,8 @@ func NewProductDeletionRequestRepository(db *gorm.DB) ProductDeletionRequestRepo
 	return &productDeletionRequestRepository{db: db}
 }
 
-func (r *productDeletionRequestRepository) Create(req *models.ProductDeletionRequest) error {
-	return r.db.Create(req).Error
+func (r *productDeletionRequestRepository) Create(ctx context.Context, req *models.ProductDeletionRequest) error {
+	return dbFor(ctx, r.db).Create(req).Error
 }
 
 func (r *productDeletionRequestRepository) GetByID(id uuid.UUID) (*models.ProductDeletionRequest, error) {
@@ -73,6 +74,6 @@ func (r *productDeletionRequestRepository) FindPendingByProductID(productID uuid
 	return &req, nil
 }
 
-func (r *productDeletionRequestRepository) Update(req *models.ProductDeletionRequest) error {
-	return r.db.Save(req).Error
+func (r *productDeletionRequestRepository) Update(ctx context.Context, req *models.ProductDeletionRequest) error {
+	return dbFor(ctx, r.db).Save(req).Error
 }
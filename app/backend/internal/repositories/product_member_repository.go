@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type ProductMemberRepository interface {
+	ListByProduct(ctx context.Context, productID uuid.UUID) ([]models.ProductMember, error)
+	Get(ctx context.Context, productID, userID uuid.UUID) (*models.ProductMember, error)
+	Create(ctx context.Context, m *models.ProductMember) error
+	UpdateRole(ctx context.Context, id uuid.UUID, role models.ProductMemberRole) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	// DeleteByUser removes userID from every product.
+	DeleteByUser(ctx context.Context, userID uuid.UUID) error
+	// ListProductIDsForUser returns the products userID is a member of, in any role.
+	ListProductIDsForUser(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error)
+}
+
+type productMemberRepository struct {
+	db *gorm.DB
+}
+
+func NewProductMemberRepository(db *gorm.DB) ProductMemberRepository {
+	return &productMemberRepository{db: db}
+}
+
+func (r *productMemberRepository) ListByProduct(ctx context.Context, productID uuid.UUID) ([]models.ProductMember, error) {
+	var list []models.ProductMember
+	err := dbFor(ctx, r.db).Preload("User").Where("product_id = ?", productID).Order("created_at ASC").Find(&list).Error
+	return list, err
+}
+
+func (r *productMemberRepository) Get(ctx context.Context, productID, userID uuid.UUID) (*models.ProductMember, error) {
+	var m models.ProductMember
+	if err := dbFor(ctx, r.db).Preload("User").Where("product_id = ? AND user_id = ?", productID, userID).First(&m).Error; err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *productMemberRepository) Create(ctx context.Context, m *models.ProductMember) error {
+	return dbFor(ctx, r.db).Create(m).Error
+}
+
+func (r *productMemberRepository) UpdateRole(ctx context.Context, id uuid.UUID, role models.ProductMemberRole) error {
+	return dbFor(ctx, r.db).Model(&models.ProductMember{}).Where("id = ?", id).Update("role", role).Error
+}
+
+func (r *productMemberRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return dbFor(ctx, r.db).Delete(&models.ProductMember{}, "id = ?", id).Error
+}
+
+func (r *productMemberRepository) DeleteByUser(ctx context.Context, userID uuid.UUID) error {
+	return dbFor(ctx, r.db).Delete(&models.ProductMember{}, "user_id = ?", userID).Error
+}
+
+func (r *productMemberRepository) ListProductIDsForUser(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := dbFor(ctx, r.db).Model(&models.ProductMember{}).Where("user_id = ?", userID).Pluck("product_id", &ids).Error
+	return ids, err
+}
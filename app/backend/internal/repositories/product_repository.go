@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,12 +10,21 @@ import (
 )
 
 type ProductRepository interface {
-	Create(product *models.Product) error
-	GetByID(id uuid.UUID) (*models.Product, error)
+	Create(ctx context.Context, product *models.Product) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Product, error)
 	List(ownerID *uuid.UUID, status *models.ProductStatus, lifecycleStatus *models.LifecycleStatus, category1, category2, category3 *string, productIDs *[]uuid.UUID, excludedProductIDs *[]uuid.UUID, dateFrom, dateTo *time.Time, sortBy, order string, limit, offset int) ([]models.Product, int64, error)
-	Update(product *models.Product) error
-	Delete(id uuid.UUID) error
-	ClearOwnerForUser(userID uuid.UUID) error
+	Update(ctx context.Context, product *models.Product) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	// GetByIDUnscoped returns the product even if it is soft-deleted.
+	GetByIDUnscoped(ctx context.Context, id uuid.UUID) (*models.Product, error)
+	// Undelete clears deleted_at on a soft-deleted product.
+	Undelete(ctx context.Context, id uuid.UUID) error
+	ClearOwnerForUser(ctx context.Context, userID uuid.UUID) error
+	ListIDsByOwners(ownerIDs []uuid.UUID) ([]uuid.UUID, error)
+	ListByOwner(ownerID uuid.UUID) ([]models.Product, error)
+	// SearchByName returns up to limit approved products whose name contains q, case-insensitively, exact
+	// matches first.
+	SearchByName(ctx context.Context, q string, limit int) ([]models.Product, error)
 }
 
 type productRepository struct {
@@ -25,13 +35,13 @@ func NewProductRepository(db *gorm.DB) ProductRepository {
 	return &productRepository{db: db}
 }
 
-func (r *productRepository) Create(product *models.Product) error {
-	return r.db.Create(product).Error
+func (r *productRepository) Create(ctx context.Context, product *models.Product) error {
+	return dbFor(ctx, r.db).Create(product).Error
 }
 
-func (r *productRepository) GetByID(id uuid.UUID) (*models.Product, error) {
+func (r *productRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Product, error) {
 	var p models.Product
-	err := r.db.Preload("Owner").Preload("Milestones").Preload("ProductVersions").First(&p, "id = ?", id).Error
+	err := dbFor(ctx, r.db).Preload("Owner").Preload("Milestones").Preload("ProductVersions").First(&p, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -108,15 +118,51 @@ func (r *productRepository) List(ownerID *uuid.UUID, status *models.ProductStatu
 	return products, total, err
 }
 
-func (r *productRepository) Update(product *models.Product) error {
+func (r *productRepository) Update(ctx context.Context, product *models.Product) error {
 	// Omit associations so only scalar columns (including owner_id) are updated
-	return r.db.Model(product).Omit("Owner", "Milestones", "ProductVersions").Save(product).Error
+	return dbFor(ctx, r.db).Model(product).Omit("Owner", "Milestones", "ProductVersions").Save(product).Error
 }
 
-func (r *productRepository) Delete(id uuid.UUID) error {
-	return r.db.Delete(&models.Product{}, "id = ?", id).Error
+func (r *productRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return dbFor(ctx, r.db).Delete(&models.Product{}, "id = ?", id).Error
 }
 
-func (r *productRepository) ClearOwnerForUser(userID uuid.UUID) error {
-	return r.db.Model(&models.Product{}).Where("owner_id = ?", userID).Update("owner_id", nil).Error
+func (r *productRepository) ClearOwnerForUser(ctx context.Context, userID uuid.UUID) error {
+	return dbFor(ctx, r.db).Model(&models.Product{}).Where("owner_id = ?", userID).Update("owner_id", nil).Error
+}
+
+func (r *productRepository) ListIDsByOwners(ownerIDs []uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	if len(ownerIDs) == 0 {
+		return ids, nil
+	}
+	err := r.db.Model(&models.Product{}).Where("owner_id IN ?", ownerIDs).Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (r *productRepository) ListByOwner(ownerID uuid.UUID) ([]models.Product, error) {
+	var list []models.Product
+	err := r.db.Where("owner_id = ?", ownerID).Order("name").Find(&list).Error
+	return list, err
+}
+
+func (r *productRepository) GetByIDUnscoped(ctx context.Context, id uuid.UUID) (*models.Product, error) {
+	var p models.Product
+	if err := dbFor(ctx, r.db).Unscoped().Preload("Owner").First(&p, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *productRepository) Undelete(ctx context.Context, id uuid.UUID) error {
+	return dbFor(ctx, r.db).Unscoped().Model(&models.Product{}).Where("id = ?", id).Update("deleted_at", nil).Error
+}
+
+func (r *productRepository) SearchByName(ctx context.Context, q string, limit int) ([]models.Product, error) {
+	var list []models.Product
+	err := dbFor(ctx, r.db).
+		Where("status = ? AND name ILIKE ?", models.StatusApproved, "%"+escapeLike(q)+"%").
+		Order(gorm.Expr("lower(name) = lower(?) DESC, name", q)).
+		Limit(limit).Find(&list).Error
+	return list, err
 }
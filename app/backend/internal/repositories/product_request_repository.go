@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,10 +10,10 @@ import (
 )
 
 type ProductRequestRepository interface {
-	Create(req *models.ProductRequest) error
+	Create(ctx context.Context, req *models.ProductRequest) error
 	GetByID(id uuid.UUID) (*models.ProductRequest, error)
 	List(status *models.RequestStatus, requestedBy *uuid.UUID, fromDate, toDate *time.Time) ([]models.ProductRequest, error)
-	Update(req *models.ProductRequest) error
+	Update(ctx context.Context, req *models.ProductRequest) error
 }
 
 type productRequestRepository struct {
@@ -23,8 +24,8 @@ func NewProductRequestRepository(db *gorm.DB) ProductRequestRepository {
 	return &productRequestRepository{db: db}
 }
 
-func (r *productRequestRepository) Create(req *models.ProductRequest) error {
-	return r.db.Create(req).Error
+func (r *productRequestRepository) Create(ctx context.Context, req *models.ProductRequest) error {
+	return dbFor(ctx, r.db).Create(req).Error
 }
 
 func (r *productRequestRepository) GetByID(id uuid.UUID) (*models.ProductRequest, error) {
@@ -56,6 +57,6 @@ func (r *productRequestRepository) List(status *models.RequestStatus, requestedB
 	return list, err
 }
 
-func (r *productRequestRepository) Update(req *models.ProductRequest) error {
-	return r.db.Save(req).Error
+func (r *productRequestRepository) Update(ctx context.Context, req *models.ProductRequest) error {
+	return dbFor(ctx, r.db).Save(req).Error
 }
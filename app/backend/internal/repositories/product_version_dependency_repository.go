@@ -11,7 +11,11 @@ import (
 type ProductVersionDependencyRepository interface {
 	Create(ctx context.Context, d *models.ProductVersionDependency) error
 	ListBySourceProductVersionID(ctx context.Context, sourceProductVersionID uuid.UUID) ([]models.ProductVersionDependency, error)
+	// ListByTargetProductID returns the dependencies on a product (any of its versions), with their source version.
+	ListByTargetProductID(ctx context.Context, targetProductID uuid.UUID) ([]models.ProductVersionDependency, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*models.ProductVersionDependency, error)
+	// List returns every dependency with its source version and product and its target product and version.
+	List(ctx context.Context) ([]models.ProductVersionDependency, error)
 	Delete(ctx context.Context, id uuid.UUID) error
 }
 
@@ -24,25 +28,39 @@ func NewProductVersionDependencyRepository(db *gorm.DB) ProductVersionDependency
 }
 
 func (r *productVersionDependencyRepository) Create(ctx context.Context, d *models.ProductVersionDependency) error {
-	return r.db.WithContext(ctx).Create(d).Error
+	return dbFor(ctx, r.db).Create(d).Error
 }
 
 func (r *productVersionDependencyRepository) ListBySourceProductVersionID(ctx context.Context, sourceProductVersionID uuid.UUID) ([]models.ProductVersionDependency, error) {
 	var list []models.ProductVersionDependency
-	err := r.db.WithContext(ctx).Where("source_product_version_id = ?", sourceProductVersionID).
+	err := dbFor(ctx, r.db).Where("source_product_version_id = ?", sourceProductVersionID).
 		Preload("TargetProduct").Preload("TargetProductVersion").Find(&list).Error
 	return list, err
 }
 
+func (r *productVersionDependencyRepository) ListByTargetProductID(ctx context.Context, targetProductID uuid.UUID) ([]models.ProductVersionDependency, error) {
+	var list []models.ProductVersionDependency
+	err := dbFor(ctx, r.db).Where("target_product_id = ?", targetProductID).
+		Preload("SourceProductVersion").Find(&list).Error
+	return list, err
+}
+
 func (r *productVersionDependencyRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.ProductVersionDependency, error) {
 	var d models.ProductVersionDependency
-	err := r.db.WithContext(ctx).Preload("TargetProduct").Preload("TargetProductVersion").First(&d, "id = ?", id).Error
+	err := dbFor(ctx, r.db).Preload("TargetProduct").Preload("TargetProductVersion").First(&d, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &d, nil
 }
 
+func (r *productVersionDependencyRepository) List(ctx context.Context) ([]models.ProductVersionDependency, error) {
+	var list []models.ProductVersionDependency
+	err := dbFor(ctx, r.db).Preload("SourceProductVersion.Product").Preload("TargetProduct").Preload("TargetProductVersion").
+		Order("created_at").Find(&list).Error
+	return list, err
+}
+
 func (r *productVersionDependencyRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	return r.db.WithContext(ctx).Delete(&models.ProductVersionDependency{}, "id = ?", id).Error
+	return dbFor(ctx, r.db).Delete(&models.ProductVersionDependency{}, "id = ?", id).Error
 }
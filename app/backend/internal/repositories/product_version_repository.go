@@ -1,18 +1,23 @@
 package repositories
 
 import (
+	"context"
 	"github.com/google/uuid"
 	"github.com/rm/roadmap/backend/internal/models"
 	"gorm.io/gorm"
 )
 
 type ProductVersionRepository interface {
-	Create(pv *models.ProductVersion) error
+	Create(ctx context.Context, pv *models.ProductVersion) error
 	GetByID(id uuid.UUID) (*models.ProductVersion, error)
 	ListByProductID(productID uuid.UUID) ([]models.ProductVersion, error)
-	Update(pv *models.ProductVersion) error
-	Delete(id uuid.UUID) error
+	Update(ctx context.Context, pv *models.ProductVersion) error
+	Delete(ctx context.Context, id uuid.UUID) error
 	CountByProductID(productID uuid.UUID) (int64, error)
+	// GetByIDUnscoped returns the version even if it is soft-deleted.
+	GetByIDUnscoped(ctx context.Context, id uuid.UUID) (*models.ProductVersion, error)
+	// Undelete clears deleted_at on a soft-deleted version.
+	Undelete(ctx context.Context, id uuid.UUID) error
 }
 
 type productVersionRepository struct {
@@ -23,8 +28,8 @@ func NewProductVersionRepository(db *gorm.DB) ProductVersionRepository {
 	return &productVersionRepository{db: db}
 }
 
-func (r *productVersionRepository) Create(pv *models.ProductVersion) error {
-	return r.db.Create(pv).Error
+func (r *productVersionRepository) Create(ctx context.Context, pv *models.ProductVersion) error {
+	return dbFor(ctx, r.db).Create(pv).Error
 }
 
 func (r *productVersionRepository) GetByID(id uuid.UUID) (*models.ProductVersion, error) {
@@ -42,12 +47,12 @@ func (r *productVersionRepository) ListByProductID(productID uuid.UUID) ([]model
 	return list, err
 }
 
-func (r *productVersionRepository) Update(pv *models.ProductVersion) error {
-	return r.db.Save(pv).Error
+func (r *productVersionRepository) Update(ctx context.Context, pv *models.ProductVersion) error {
+	return dbFor(ctx, r.db).Save(pv).Error
 }
 
-func (r *productVersionRepository) Delete(id uuid.UUID) error {
-	return r.db.Delete(&models.ProductVersion{}, "id = ?", id).Error
+func (r *productVersionRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return dbFor(ctx, r.db).Delete(&models.ProductVersion{}, "id = ?", id).Error
 }
 
 func (r *productVersionRepository) CountByProductID(productID uuid.UUID) (int64, error) {
@@ -55,3 +60,15 @@ func (r *productVersionRepository) CountByProductID(productID uuid.UUID) (int64,
 	err := r.db.Model(&models.ProductVersion{}).Where("product_id = ?", productID).Count(&n).Error
 	return n, err
 }
+
+func (r *productVersionRepository) GetByIDUnscoped(ctx context.Context, id uuid.UUID) (*models.ProductVersion, error) {
+	var pv models.ProductVersion
+	if err := dbFor(ctx, r.db).Unscoped().First(&pv, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &pv, nil
+}
+
+func (r *productVersionRepository) Undelete(ctx context.Context, id uuid.UUID) error {
+	return dbFor(ctx, r.db).Unscoped().Model(&models.ProductVersion{}).Where("id = ?", id).Update("deleted_at", nil).Error
+}
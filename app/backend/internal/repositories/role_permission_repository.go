@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/rm/roadmap/backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RolePermissionRepository interface {
+	ListAll(ctx context.Context) ([]models.RolePermission, error)
+	// ListSeeded returns the default grants already written by Seed.
+	ListSeeded(ctx context.Context) ([]models.RolePermissionSeed, error)
+	// Seed grants permissions to role where not granted yet and records them as seeded, in one transaction.
+	Seed(ctx context.Context, role models.Role, permissions []string) error
+	// ReplaceForRole swaps the full permission set of a role in one transaction.
+	ReplaceForRole(ctx context.Context, role models.Role, permissions []string) error
+}
+
+type rolePermissionRepository struct {
+	db *gorm.DB
+}
+
+func NewRolePermissionRepository(db *gorm.DB) RolePermissionRepository {
+	return &rolePermissionRepository{db: db}
+}
+
+func (r *rolePermissionRepository) ListAll(ctx context.Context) ([]models.RolePermission, error) {
+	var list []models.RolePermission
+	err := r.db.WithContext(ctx).Order("role, permission").Find(&list).Error
+	return list, err
+}
+
+func (r *rolePermissionRepository) ListSeeded(ctx context.Context) ([]models.RolePermissionSeed, error) {
+	var list []models.RolePermissionSeed
+	err := r.db.WithContext(ctx).Find(&list).Error
+	return list, err
+}
+
+func (r *rolePermissionRepository) Seed(ctx context.Context, role models.Role, permissions []string) error {
+	if len(permissions) == 0 {
+		return nil
+	}
+	grants := make([]models.RolePermission, len(permissions))
+	seeds := make([]models.RolePermissionSeed, len(permissions))
+	for i, p := range permissions {
+		grants[i] = models.RolePermission{Role: role, Permission: p}
+		seeds[i] = models.RolePermissionSeed{Role: role, Permission: p}
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&grants).Error; err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&seeds).Error
+	})
+}
+
+func (r *rolePermissionRepository) ReplaceForRole(ctx context.Context, role models.Role, permissions []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role = ?", role).Delete(&models.RolePermission{}).Error; err != nil {
+			return err
+		}
+		if len(permissions) == 0 {
+			return nil
+		}
+		rows := make([]models.RolePermission, len(permissions))
+		for i, p := range permissions {
+			rows[i] = models.RolePermission{Role: role, Permission: p}
+		}
+		return tx.Create(&rows).Error
+	})
+}
@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type SessionRepository interface {
+	Create(ctx context.Context, s *models.Session) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Session, error)
+	// ListActiveByUser returns unrevoked, unexpired sessions, most recently seen first.
+	ListActiveByUser(ctx context.Context, userID uuid.UUID, now time.Time) ([]models.Session, error)
+	Touch(ctx context.Context, id uuid.UUID, at time.Time) error
+	// Rotate swaps the token jtis after a refresh. It only succeeds while the session still holds
+	// oldRefreshJTI and is not revoked, so two concurrent refreshes with one token cannot both win.
+	Rotate(ctx context.Context, id uuid.UUID, oldRefreshJTI, accessJTI, refreshJTI string, expiresAt, now time.Time) (bool, error)
+	// Revoke marks one session revoked; it reports false if it was already revoked or does not exist.
+	Revoke(ctx context.Context, id uuid.UUID, at time.Time) (bool, error)
+	// RevokeAllForUser revokes every active session of the user except the given ones and returns their IDs.
+	RevokeAllForUser(ctx context.Context, userID uuid.UUID, except []uuid.UUID, at time.Time) ([]uuid.UUID, error)
+}
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) Create(ctx context.Context, s *models.Session) error {
+	return dbFor(ctx, r.db).Create(s).Error
+}
+
+func (r *sessionRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Session, error) {
+	var s models.Session
+	if err := dbFor(ctx, r.db).First(&s, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *sessionRepository) ListActiveByUser(ctx context.Context, userID uuid.UUID, now time.Time) ([]models.Session, error) {
+	var list []models.Session
+	err := dbFor(ctx, r.db).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Order("last_seen_at DESC").Find(&list).Error
+	return list, err
+}
+
+func (r *sessionRepository) Touch(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return dbFor(ctx, r.db).Model(&models.Session{}).Where("id = ?", id).Update("last_seen_at", at).Error
+}
+
+func (r *sessionRepository) Rotate(ctx context.Context, id uuid.UUID, oldRefreshJTI, accessJTI, refreshJTI string, expiresAt, now time.Time) (bool, error) {
+	res := dbFor(ctx, r.db).Model(&models.Session{}).
+		Where("id = ? AND refresh_jti = ? AND revoked_at IS NULL", id, oldRefreshJTI).
+		Updates(map[string]interface{}{
+			"access_jti":   accessJTI,
+			"refresh_jti":  refreshJTI,
+			"expires_at":   expiresAt,
+			"last_seen_at": now,
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *sessionRepository) Revoke(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	res := dbFor(ctx, r.db).Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).Update("revoked_at", at)
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *sessionRepository) RevokeAllForUser(ctx context.Context, userID uuid.UUID, except []uuid.UUID, at time.Time) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := dbFor(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		q := tx.Model(&models.Session{}).Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, at)
+		if len(except) > 0 {
+			q = q.Where("id NOT IN ?", except)
+		}
+		if err := q.Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		return tx.Model(&models.Session{}).Where("id IN ?", ids).Update("revoked_at", at).Error
+	})
+	return ids, err
+}
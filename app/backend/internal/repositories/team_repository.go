@@ -1,17 +1,21 @@
 package repositories
 
 import (
+	"context"
+
 	"github.com/google/uuid"
 	"github.com/rm/roadmap/backend/internal/models"
 	"gorm.io/gorm"
 )
 
 type TeamRepository interface {
-	Create(t *models.Team) error
+	Create(ctx context.Context, t *models.Team) error
 	GetByID(id uuid.UUID) (*models.Team, error)
 	List(departmentID *uuid.UUID) ([]models.Team, error)
-	Update(t *models.Team) error
-	Delete(id uuid.UUID) error
+	Update(ctx context.Context, t *models.Team) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	// Search returns teams matching a parameterized WHERE clause (empty matches all) with members preloaded.
+	Search(ctx context.Context, where string, args []interface{}, offset, limit int) ([]models.Team, int64, error)
 }
 
 type teamRepository struct {
@@ -22,8 +26,8 @@ func NewTeamRepository(db *gorm.DB) TeamRepository {
 	return &teamRepository{db: db}
 }
 
-func (r *teamRepository) Create(t *models.Team) error {
-	return r.db.Create(t).Error
+func (r *teamRepository) Create(ctx context.Context, t *models.Team) error {
+	return dbFor(ctx, r.db).Create(t).Error
 }
 
 func (r *teamRepository) GetByID(id uuid.UUID) (*models.Team, error) {
@@ -44,10 +48,27 @@ func (r *teamRepository) List(departmentID *uuid.UUID) ([]models.Team, error) {
 	return list, err
 }
 
-func (r *teamRepository) Update(t *models.Team) error {
-	return r.db.Save(t).Error
+func (r *teamRepository) Update(ctx context.Context, t *models.Team) error {
+	return dbFor(ctx, r.db).Save(t).Error
+}
+
+func (r *teamRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return dbFor(ctx, r.db).Delete(&models.Team{}, "id = ?", id).Error
 }
 
-func (r *teamRepository) Delete(id uuid.UUID) error {
-	return r.db.Delete(&models.Team{}, "id = ?", id).Error
+func (r *teamRepository) Search(ctx context.Context, where string, args []interface{}, offset, limit int) ([]models.Team, int64, error) {
+	q := r.db.WithContext(ctx).Model(&models.Team{})
+	if where != "" {
+		q = q.Where(where, args...)
+	}
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var list []models.Team
+	if limit == 0 {
+		return list, total, nil
+	}
+	err := q.Preload("Members").Order("created_at, id").Offset(offset).Limit(limit).Find(&list).Error
+	return list, total, err
 }
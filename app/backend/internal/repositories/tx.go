@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Transactor runs a function in one database transaction. Repository methods that take a context use the
+// transaction carried by the context passed to fn, so a business change and the audit/activity outbox rows
+// describing it commit or roll back together.
+type Transactor interface {
+	// InTx runs fn in a transaction and commits if it returns nil. Nested calls join the outer transaction.
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type txKey struct{}
+
+// afterCommitKey carries the functions AfterCommit queued on the outermost transaction.
+type afterCommitKey struct{}
+
+type transactor struct {
+	db *gorm.DB
+}
+
+func NewTransactor(db *gorm.DB) Transactor {
+	return &transactor{db: db}
+}
+
+func (t *transactor) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+	var after []func()
+	err := t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(context.WithValue(ctx, txKey{}, tx), afterCommitKey{}, &after))
+	})
+	if err != nil {
+		return err
+	}
+	for _, f := range after {
+		f()
+	}
+	return nil
+}
+
+// AfterCommit runs f once the transaction carried by ctx has committed, or at once when there is none. It
+// is for side effects other instances must not see before the change is durable, such as broadcasts.
+func AfterCommit(ctx context.Context, f func()) {
+	if after, ok := ctx.Value(afterCommitKey{}).(*[]func()); ok {
+		*after = append(*after, f)
+		return
+	}
+	f()
+}
+
+// dbFor returns the transaction carried by ctx, or db bound to ctx when there is none.
+func dbFor(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx
+	}
+	return db.WithContext(ctx)
+}
@@ -1,15 +1,17 @@
 package repositories
 
 import (
+	"context"
+
 	"github.com/google/uuid"
 	"github.com/rm/roadmap/backend/internal/models"
 	"gorm.io/gorm"
 )
 
 type UserDottedLineRepository interface {
-	Create(ud *models.UserDottedLineManager) error
+	Create(ctx context.Context, ud *models.UserDottedLineManager) error
 	ListByUserID(userID uuid.UUID) ([]models.UserDottedLineManager, error)
-	Delete(userID, managerID uuid.UUID) error
+	Delete(ctx context.Context, userID, managerID uuid.UUID) error
 	Exists(userID, managerID uuid.UUID) (bool, error)
 }
 
@@ -21,8 +23,8 @@ func NewUserDottedLineRepository(db *gorm.DB) UserDottedLineRepository {
 	return &userDottedLineRepository{db: db}
 }
 
-func (r *userDottedLineRepository) Create(ud *models.UserDottedLineManager) error {
-	return r.db.Create(ud).Error
+func (r *userDottedLineRepository) Create(ctx context.Context, ud *models.UserDottedLineManager) error {
+	return dbFor(ctx, r.db).Create(ud).Error
 }
 
 func (r *userDottedLineRepository) ListByUserID(userID uuid.UUID) ([]models.UserDottedLineManager, error) {
@@ -31,8 +33,8 @@ func (r *userDottedLineRepository) ListByUserID(userID uuid.UUID) ([]models.User
 	return list, err
 }
 
-func (r *userDottedLineRepository) Delete(userID, managerID uuid.UUID) error {
-	return r.db.Where("user_id = ? AND manager_id = ?", userID, managerID).
+func (r *userDottedLineRepository) Delete(ctx context.Context, userID, managerID uuid.UUID) error {
+	return dbFor(ctx, r.db).Where("user_id = ? AND manager_id = ?", userID, managerID).
 		Delete(&models.UserDottedLineManager{}).Error
 }
 
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -15,14 +16,23 @@ var ErrManagerCycle = errors.New("manager hierarchy contains a cycle")
 const MaxManagerHierarchyDepth = 16
 
 type UserRepository interface {
-	Create(user *models.User) error
+	Create(ctx context.Context, user *models.User) error
 	GetByID(id uuid.UUID) (*models.User, error)
 	GetByEmail(email string) (*models.User, error)
 	List(teamID, directManagerID *uuid.UUID) ([]models.User, error)
 	ListByRole(role models.Role) ([]models.User, error)
-	Update(user *models.User) error
-	Delete(id uuid.UUID) error
-	ManagerChainDepth(userID uuid.UUID) (int, error)
+	Update(ctx context.Context, user *models.User) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	ManagerChainDepth(ctx context.Context, userID uuid.UUID) (int, error)
+	// ReportingSubtree returns everyone who reports to managerID, transitively, through a direct manager,
+	// a dotted-line manager or a team the manager leads. managerID itself is never included.
+	ReportingSubtree(ctx context.Context, managerID uuid.UUID) ([]uuid.UUID, error)
+	// Search returns users matching a parameterized WHERE clause (empty matches all), ordered by creation,
+	// with team and dotted-line managers preloaded, plus the total count.
+	Search(ctx context.Context, where string, args []interface{}, offset, limit int) ([]models.User, int64, error)
+	// SetTeam assigns userIDs to teamID (nil clears it).
+	SetTeam(ctx context.Context, userIDs []uuid.UUID, teamID *uuid.UUID) error
+	ListIDsByTeam(ctx context.Context, teamID uuid.UUID) ([]uuid.UUID, error)
 }
 
 type userRepository struct {
@@ -33,8 +43,8 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 	return &userRepository{db: db}
 }
 
-func (r *userRepository) Create(user *models.User) error {
-	return r.db.Create(user).Error
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	return dbFor(ctx, r.db).Create(user).Error
 }
 
 func (r *userRepository) GetByID(id uuid.UUID) (*models.User, error) {
@@ -74,22 +84,53 @@ func (r *userRepository) ListByRole(role models.Role) ([]models.User, error) {
 	return users, err
 }
 
-func (r *userRepository) Update(user *models.User) error {
-	return r.db.Save(user).Error
+func (r *userRepository) Search(ctx context.Context, where string, args []interface{}, offset, limit int) ([]models.User, int64, error) {
+	q := r.db.WithContext(ctx).Model(&models.User{})
+	if where != "" {
+		q = q.Where(where, args...)
+	}
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var users []models.User
+	if limit == 0 {
+		return users, total, nil
+	}
+	err := q.Preload("Team").Preload("DottedLineManagers").Order("created_at, id").Offset(offset).Limit(limit).Find(&users).Error
+	return users, total, err
 }
 
-func (r *userRepository) Delete(id uuid.UUID) error {
-	return r.db.Delete(&models.User{}, "id = ?", id).Error
+func (r *userRepository) SetTeam(ctx context.Context, userIDs []uuid.UUID, teamID *uuid.UUID) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	return dbFor(ctx, r.db).Model(&models.User{}).Where("id IN ?", userIDs).Update("team_id", teamID).Error
+}
+
+func (r *userRepository) ListIDsByTeam(ctx context.Context, teamID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Model(&models.User{}).Where("team_id = ?", teamID).Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (r *userRepository) Update(ctx context.Context, user *models.User) error {
+	return dbFor(ctx, r.db).Save(user).Error
+}
+
+func (r *userRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return dbFor(ctx, r.db).Delete(&models.User{}, "id = ?", id).Error
 }
 
 // ManagerChainDepth returns the number of steps from user up the direct_manager chain (0 = no manager).
-func (r *userRepository) ManagerChainDepth(userID uuid.UUID) (int, error) {
+func (r *userRepository) ManagerChainDepth(ctx context.Context, userID uuid.UUID) (int, error) {
+	db := dbFor(ctx, r.db)
 	seen := make(map[uuid.UUID]bool)
 	depth := 0
 	current := userID
 	for depth <= MaxManagerHierarchyDepth {
 		var u models.User
-		if err := r.db.Select("direct_manager_id").First(&u, "id = ?", current).Error; err != nil {
+		if err := db.Select("direct_manager_id").First(&u, "id = ?", current).Error; err != nil {
 			return 0, err
 		}
 		if u.DirectManagerID == nil {
@@ -104,3 +145,33 @@ func (r *userRepository) ManagerChainDepth(userID uuid.UUID) (int, error) {
 	}
 	return 0, fmt.Errorf("%w (max %d)", ErrManagerHierarchyTooDeep, MaxManagerHierarchyDepth)
 }
+
+// reportingSubtreeSQL walks the union of all manager edges (direct, dotted-line, team manager) from the
+// given manager downwards. UNION plus the depth bound keeps cycles from recursing forever.
+const reportingSubtreeSQL = `
+WITH RECURSIVE edges(manager_id, user_id) AS (
+	SELECT direct_manager_id, id FROM users
+	WHERE direct_manager_id IS NOT NULL AND deleted_at IS NULL
+	UNION
+	SELECT d.manager_id, d.user_id FROM user_dotted_line_managers d
+	JOIN users u ON u.id = d.user_id AND u.deleted_at IS NULL
+	UNION
+	SELECT t.manager_id, u.id FROM users u
+	JOIN teams t ON t.id = u.team_id AND t.deleted_at IS NULL
+	WHERE t.manager_id IS NOT NULL AND u.deleted_at IS NULL
+),
+subtree(user_id, depth) AS (
+	SELECT user_id, 1 FROM edges WHERE manager_id = @manager
+	UNION
+	SELECT e.user_id, s.depth + 1 FROM edges e JOIN subtree s ON e.manager_id = s.user_id
+	WHERE s.depth < @max_depth
+)
+SELECT DISTINCT user_id FROM subtree WHERE user_id <> @manager`
+
+func (r *userRepository) ReportingSubtree(ctx context.Context, managerID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Raw(reportingSubtreeSQL,
+		map[string]interface{}{"manager": managerID, "max_depth": MaxManagerHierarchyDepth},
+	).Scan(&ids).Error
+	return ids, err
+}
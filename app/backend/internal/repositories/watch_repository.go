@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WatchRepository interface {
+	// Create stores w unless the user already watches the entity; it reports whether w is new.
+	Create(ctx context.Context, w *models.Watch) (bool, error)
+	// Delete reports whether there was a watch to remove.
+	Delete(ctx context.Context, userID uuid.UUID, entityType string, entityID uuid.UUID) (bool, error)
+	ListForUser(ctx context.Context, userID uuid.UUID) ([]models.Watch, error)
+	// Watchers returns the users watching the product, the version (when not nil) or a group the product is in.
+	Watchers(ctx context.Context, productID uuid.UUID, versionID *uuid.UUID) ([]uuid.UUID, error)
+}
+
+type watchRepository struct {
+	db *gorm.DB
+}
+
+func NewWatchRepository(db *gorm.DB) WatchRepository {
+	return &watchRepository{db: db}
+}
+
+func (r *watchRepository) Create(ctx context.Context, w *models.Watch) (bool, error) {
+	res := dbFor(ctx, r.db).Clauses(clause.OnConflict{DoNothing: true}).Create(w)
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *watchRepository) Delete(ctx context.Context, userID uuid.UUID, entityType string, entityID uuid.UUID) (bool, error) {
+	res := dbFor(ctx, r.db).Where("user_id = ? AND entity_type = ? AND entity_id = ?", userID, entityType, entityID).Delete(&models.Watch{})
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *watchRepository) ListForUser(ctx context.Context, userID uuid.UUID) ([]models.Watch, error) {
+	var list []models.Watch
+	err := dbFor(ctx, r.db).Where("user_id = ?", userID).Order("created_at DESC").Find(&list).Error
+	return list, err
+}
+
+func (r *watchRepository) Watchers(ctx context.Context, productID uuid.UUID, versionID *uuid.UUID) ([]uuid.UUID, error) {
+	q := dbFor(ctx, r.db).Model(&models.Watch{}).
+		Where("(entity_type = ? AND entity_id = ?)", models.WatchEntityProduct, productID).
+		Or("(entity_type = ? AND entity_id IN (SELECT group_id FROM group_products WHERE product_id = ?))", models.WatchEntityGroup, productID)
+	if versionID != nil {
+		q = q.Or("(entity_type = ? AND entity_id = ?)", models.WatchEntityProductVersion, *versionID)
+	}
+	var ids []uuid.UUID
+	err := q.Distinct("user_id").Pluck("user_id", &ids).Error
+	return ids, err
+}
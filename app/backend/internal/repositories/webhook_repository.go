@@ -0,0 +1,170 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type WebhookRepository interface {
+	Create(ctx context.Context, s *models.WebhookSubscription) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.WebhookSubscription, error)
+	List(ctx context.Context) ([]models.WebhookSubscription, error)
+	ListActive(ctx context.Context) ([]models.WebhookSubscription, error)
+	Update(ctx context.Context, s *models.WebhookSubscription) error
+	// Delete removes the subscription with its deliveries and attempts.
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	EnqueueDeliveries(ctx context.Context, list []models.WebhookDelivery) error
+	// ClaimDue leases up to limit pending deliveries due at now, by moving their NextAttemptAt to now+lease,
+	// so other instances skip them while they are sent.
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error)
+	// RecordAttempt stores an attempt and the delivery's resulting state together.
+	RecordAttempt(ctx context.Context, d *models.WebhookDelivery, a *models.WebhookAttempt) error
+	GetDelivery(ctx context.Context, subscriptionID, id uuid.UUID) (*models.WebhookDelivery, error)
+	ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, status string, limit, offset int) ([]models.WebhookDelivery, int64, error)
+	ListAttempts(ctx context.Context, deliveryID uuid.UUID) ([]models.WebhookAttempt, error)
+	// Requeue makes a delivery pending again with a fresh set of attempts.
+	Requeue(ctx context.Context, id uuid.UUID, now time.Time) error
+	// Pending returns the number of pending and dead deliveries.
+	Pending(ctx context.Context) (pending, dead int64, err error)
+}
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+func (r *webhookRepository) Create(ctx context.Context, s *models.WebhookSubscription) error {
+	return dbFor(ctx, r.db).Create(s).Error
+}
+
+func (r *webhookRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.WebhookSubscription, error) {
+	var s models.WebhookSubscription
+	if err := dbFor(ctx, r.db).First(&s, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *webhookRepository) List(ctx context.Context) ([]models.WebhookSubscription, error) {
+	var list []models.WebhookSubscription
+	err := dbFor(ctx, r.db).Order("created_at").Find(&list).Error
+	return list, err
+}
+
+func (r *webhookRepository) ListActive(ctx context.Context) ([]models.WebhookSubscription, error) {
+	var list []models.WebhookSubscription
+	err := dbFor(ctx, r.db).Where("active").Find(&list).Error
+	return list, err
+}
+
+func (r *webhookRepository) Update(ctx context.Context, s *models.WebhookSubscription) error {
+	return dbFor(ctx, r.db).Save(s).Error
+}
+
+func (r *webhookRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	db := dbFor(ctx, r.db)
+	if err := db.Where("delivery_id IN (SELECT id FROM webhook_deliveries WHERE subscription_id = ?)", id).Delete(&models.WebhookAttempt{}).Error; err != nil {
+		return err
+	}
+	if err := db.Where("subscription_id = ?", id).Delete(&models.WebhookDelivery{}).Error; err != nil {
+		return err
+	}
+	return db.Delete(&models.WebhookSubscription{}, "id = ?", id).Error
+}
+
+func (r *webhookRepository) EnqueueDeliveries(ctx context.Context, list []models.WebhookDelivery) error {
+	if len(list) == 0 {
+		return nil
+	}
+	return dbFor(ctx, r.db).CreateInBatches(list, 100).Error
+}
+
+func (r *webhookRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	var list []models.WebhookDelivery
+	err := r.db.WithContext(ctx).Raw(`
+		UPDATE webhook_deliveries SET next_attempt_at = ?
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY next_attempt_at, created_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		now.Add(lease), models.WebhookDeliveryPending, now, limit).Scan(&list).Error
+	return list, err
+}
+
+func (r *webhookRepository) RecordAttempt(ctx context.Context, d *models.WebhookDelivery, a *models.WebhookAttempt) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(a).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.WebhookDelivery{}).Where("id = ?", d.ID).Updates(map[string]interface{}{
+			"status":           d.Status,
+			"attempts":         d.Attempts,
+			"next_attempt_at":  d.NextAttemptAt,
+			"last_status_code": d.LastStatusCode,
+			"last_error":       d.LastError,
+			"delivered_at":     d.DeliveredAt,
+			"updated_at":       time.Now(),
+		}).Error
+	})
+}
+
+func (r *webhookRepository) GetDelivery(ctx context.Context, subscriptionID, id uuid.UUID) (*models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	if err := dbFor(ctx, r.db).First(&d, "id = ? AND subscription_id = ?", id, subscriptionID).Error; err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *webhookRepository) ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, status string, limit, offset int) ([]models.WebhookDelivery, int64, error) {
+	q := dbFor(ctx, r.db).Model(&models.WebhookDelivery{}).Where("subscription_id = ?", subscriptionID)
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var list []models.WebhookDelivery
+	err := q.Order("created_at DESC").Limit(limit).Offset(offset).Find(&list).Error
+	return list, total, err
+}
+
+func (r *webhookRepository) ListAttempts(ctx context.Context, deliveryID uuid.UUID) ([]models.WebhookAttempt, error) {
+	var list []models.WebhookAttempt
+	err := dbFor(ctx, r.db).Where("delivery_id = ?", deliveryID).Order("id").Find(&list).Error
+	return list, err
+}
+
+func (r *webhookRepository) Requeue(ctx context.Context, id uuid.UUID, now time.Time) error {
+	return dbFor(ctx, r.db).Model(&models.WebhookDelivery{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":          models.WebhookDeliveryPending,
+		"attempts":        0,
+		"next_attempt_at": now,
+		"updated_at":      now,
+	}).Error
+}
+
+func (r *webhookRepository) Pending(ctx context.Context) (pending, dead int64, err error) {
+	var row struct {
+		Pending int64
+		Dead    int64
+	}
+	err = r.db.WithContext(ctx).Model(&models.WebhookDelivery{}).
+		Select("COUNT(*) FILTER (WHERE status = ?) AS pending, COUNT(*) FILTER (WHERE status = ?) AS dead",
+			models.WebhookDeliveryPending, models.WebhookDeliveryDead).
+		Scan(&row).Error
+	return row.Pending, row.Dead, err
+}
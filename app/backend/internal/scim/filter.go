@@ -0,0 +1,436 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Expr is a parsed SCIM filter (RFC 7644 3.4.2.2).
+type Expr interface{ isExpr() }
+
+// Compare is "attr op value"; Op is lower-case (eq, ne, co, sw, ew, gt, ge, lt, le).
+type Compare struct {
+	Attr  string
+	Op    string
+	Value interface{} // string, float64, bool or nil
+}
+
+// Present is "attr pr".
+type Present struct{ Attr string }
+
+// Logical is "left and|or right".
+type Logical struct {
+	Op          string
+	Left, Right Expr
+}
+
+type Not struct{ X Expr }
+
+func (Compare) isExpr() {}
+func (Present) isExpr() {}
+func (Logical) isExpr() {}
+func (Not) isExpr()     {}
+
+var compareOps = map[string]bool{"eq": true, "ne": true, "co": true, "sw": true, "ew": true, "gt": true, "ge": true, "lt": true, "le": true}
+
+// ParseFilter parses a filter. Attribute names are returned lower-cased with any core schema URN prefix removed.
+// Complex attribute filters (emails[type eq "work"]) are not supported in search filters.
+func ParseFilter(s string) (Expr, error) {
+	toks, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	e, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.toks) {
+		return nil, BadRequest("invalidFilter", "unexpected %q", p.toks[p.pos].text)
+	}
+	return e, nil
+}
+
+type token struct {
+	text   string
+	quoted bool
+}
+
+func tokenize(s string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '(' || c == ')' || c == '[' || c == ']':
+			toks = append(toks, token{text: string(c)})
+			i++
+		case c == '"':
+			j := i + 1
+			for j < len(s) && s[j] != '"' {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(s) {
+				return nil, BadRequest("invalidFilter", "unterminated string")
+			}
+			var v string
+			if err := json.Unmarshal([]byte(s[i:j+1]), &v); err != nil {
+				return nil, BadRequest("invalidFilter", "invalid string %s", s[i:j+1])
+			}
+			toks = append(toks, token{text: v, quoted: true})
+			i = j + 1
+		default:
+			j := i
+			for j < len(s) && !strings.ContainsRune(" \t\n()[]\"", rune(s[j])) {
+				j++
+			}
+			toks = append(toks, token{text: s[i:j]})
+			i = j
+		}
+	}
+	return toks, nil
+}
+
+type parser struct {
+	toks []token
+	pos  int
+}
+
+func (p *parser) peekWord(w string) bool {
+	return p.pos < len(p.toks) && !p.toks[p.pos].quoted && strings.EqualFold(p.toks[p.pos].text, w)
+}
+
+func (p *parser) or() (Expr, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekWord("or") {
+		p.pos++
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = Logical{Op: "or", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) and() (Expr, error) {
+	left, err := p.factor()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekWord("and") {
+		p.pos++
+		right, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		left = Logical{Op: "and", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) factor() (Expr, error) {
+	if p.pos >= len(p.toks) {
+		return nil, BadRequest("invalidFilter", "unexpected end of filter")
+	}
+	if p.peekWord("not") {
+		p.pos++
+		if !p.peekWord("(") {
+			return nil, BadRequest("invalidFilter", "expected ( after not")
+		}
+		x, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		return Not{X: x}, nil
+	}
+	if p.peekWord("(") {
+		p.pos++
+		x, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.peekWord(")") {
+			return nil, BadRequest("invalidFilter", "missing )")
+		}
+		p.pos++
+		return x, nil
+	}
+	attr := p.toks[p.pos]
+	if attr.quoted {
+		return nil, BadRequest("invalidFilter", "expected attribute, got %q", attr.text)
+	}
+	p.pos++
+	if p.peekWord("[") {
+		return nil, BadRequest("invalidFilter", "complex attribute filters are not supported")
+	}
+	name := NormalizeAttr(attr.text)
+	if p.peekWord("pr") {
+		p.pos++
+		return Present{Attr: name}, nil
+	}
+	if p.pos >= len(p.toks) || p.toks[p.pos].quoted || !compareOps[strings.ToLower(p.toks[p.pos].text)] {
+		return nil, BadRequest("invalidFilter", "expected operator after %s", attr.text)
+	}
+	op := strings.ToLower(p.toks[p.pos].text)
+	p.pos++
+	if p.pos >= len(p.toks) {
+		return nil, BadRequest("invalidFilter", "missing value for %s", attr.text)
+	}
+	v := p.toks[p.pos]
+	p.pos++
+	if v.quoted {
+		return Compare{Attr: name, Op: op, Value: v.text}, nil
+	}
+	switch strings.ToLower(v.text) {
+	case "true":
+		return Compare{Attr: name, Op: op, Value: true}, nil
+	case "false":
+		return Compare{Attr: name, Op: op, Value: false}, nil
+	case "null":
+		return Compare{Attr: name, Op: op, Value: nil}, nil
+	}
+	f, err := strconv.ParseFloat(v.text, 64)
+	if err != nil {
+		return nil, BadRequest("invalidFilter", "invalid value %q", v.text)
+	}
+	return Compare{Attr: name, Op: op, Value: f}, nil
+}
+
+// NormalizeAttr lower-cases an attribute path and strips the core User/Group schema prefix.
+func NormalizeAttr(a string) string {
+	lower := strings.ToLower(a)
+	for _, urn := range []string{SchemaUser, SchemaGroup} {
+		if rest, ok := strings.CutPrefix(lower, strings.ToLower(urn)+":"); ok {
+			return rest
+		}
+	}
+	return lower
+}
+
+// ColumnType says how filter values are converted for a column.
+type ColumnType int
+
+const (
+	ColumnText ColumnType = iota
+	ColumnCaseExactText
+	ColumnBool
+	ColumnTime
+	ColumnUUID
+)
+
+// Column maps a filterable SCIM attribute to a SQL column.
+type Column struct {
+	Name string
+	Type ColumnType
+}
+
+// ToSQL renders e as a parameterized WHERE clause. Only attributes in columns (keys lower-case) may be used;
+// column names come from that whitelist, values are always bound.
+func ToSQL(e Expr, columns map[string]Column) (string, []interface{}, error) {
+	switch x := e.(type) {
+	case Logical:
+		l, la, err := ToSQL(x.Left, columns)
+		if err != nil {
+			return "", nil, err
+		}
+		r, ra, err := ToSQL(x.Right, columns)
+		if err != nil {
+			return "", nil, err
+		}
+		return "(" + l + " " + strings.ToUpper(x.Op) + " " + r + ")", append(la, ra...), nil
+	case Not:
+		s, a, err := ToSQL(x.X, columns)
+		if err != nil {
+			return "", nil, err
+		}
+		return "NOT (" + s + ")", a, nil
+	case Present:
+		col, ok := columns[x.Attr]
+		if !ok {
+			return "", nil, BadRequest("invalidFilter", "attribute %s is not filterable", x.Attr)
+		}
+		if col.Type == ColumnText || col.Type == ColumnCaseExactText {
+			return "(" + col.Name + " IS NOT NULL AND " + col.Name + " <> '')", nil, nil
+		}
+		return col.Name + " IS NOT NULL", nil, nil
+	case Compare:
+		col, ok := columns[x.Attr]
+		if !ok {
+			return "", nil, BadRequest("invalidFilter", "attribute %s is not filterable", x.Attr)
+		}
+		return compareSQL(col, x)
+	}
+	return "", nil, BadRequest("invalidFilter", "unsupported expression")
+}
+
+func compareSQL(col Column, x Compare) (string, []interface{}, error) {
+	if x.Value == nil {
+		switch x.Op {
+		case "eq":
+			return col.Name + " IS NULL", nil, nil
+		case "ne":
+			return col.Name + " IS NOT NULL", nil, nil
+		}
+		return "", nil, BadRequest("invalidFilter", "null only supports eq and ne")
+	}
+	switch col.Type {
+	case ColumnBool:
+		b, ok := x.Value.(bool)
+		if !ok || (x.Op != "eq" && x.Op != "ne") {
+			return "", nil, BadRequest("invalidFilter", "%s takes eq/ne with true or false", x.Attr)
+		}
+		return col.Name + sqlOp(x.Op) + "?", []interface{}{b}, nil
+	case ColumnUUID:
+		s, ok := x.Value.(string)
+		if !ok || (x.Op != "eq" && x.Op != "ne") {
+			return "", nil, BadRequest("invalidFilter", "%s takes eq/ne with a string", x.Attr)
+		}
+		id, err := uuid.Parse(s)
+		if err != nil {
+			// No row has a malformed ID.
+			if x.Op == "eq" {
+				return "1 = 0", nil, nil
+			}
+			return "1 = 1", nil, nil
+		}
+		return col.Name + sqlOp(x.Op) + "?", []interface{}{id}, nil
+	case ColumnTime:
+		s, ok := x.Value.(string)
+		if !ok {
+			return "", nil, BadRequest("invalidFilter", "%s takes a date-time string", x.Attr)
+		}
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil || !map[string]bool{"eq": true, "ne": true, "gt": true, "ge": true, "lt": true, "le": true}[x.Op] {
+			return "", nil, BadRequest("invalidFilter", "invalid date-time comparison on %s", x.Attr)
+		}
+		return col.Name + sqlOp(x.Op) + "?", []interface{}{t}, nil
+	}
+	s, ok := x.Value.(string)
+	if !ok {
+		return "", nil, BadRequest("invalidFilter", "%s takes a string", x.Attr)
+	}
+	name := col.Name
+	if col.Type == ColumnText {
+		name, s = "LOWER("+col.Name+")", strings.ToLower(s)
+	}
+	switch x.Op {
+	case "co":
+		return name + " LIKE ? ESCAPE '\\'", []interface{}{"%" + escapeLike(s) + "%"}, nil
+	case "sw":
+		return name + " LIKE ? ESCAPE '\\'", []interface{}{escapeLike(s) + "%"}, nil
+	case "ew":
+		return name + " LIKE ? ESCAPE '\\'", []interface{}{"%" + escapeLike(s)}, nil
+	}
+	return name + sqlOp(x.Op) + "?", []interface{}{s}, nil
+}
+
+func sqlOp(op string) string {
+	switch op {
+	case "ne":
+		return " <> "
+	case "gt":
+		return " > "
+	case "ge":
+		return " >= "
+	case "lt":
+		return " < "
+	case "le":
+		return " <= "
+	}
+	return " = "
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// Match evaluates e in memory against get, which returns a sub-attribute's value (used for
+// value filters in PATCH paths such as members[value eq "..."]). String comparison is case-insensitive.
+func Match(e Expr, get func(attr string) (string, bool)) bool {
+	switch x := e.(type) {
+	case Logical:
+		if x.Op == "and" {
+			return Match(x.Left, get) && Match(x.Right, get)
+		}
+		return Match(x.Left, get) || Match(x.Right, get)
+	case Not:
+		return !Match(x.X, get)
+	case Present:
+		v, ok := get(x.Attr)
+		return ok && v != ""
+	case Compare:
+		v, ok := get(x.Attr)
+		want := strings.ToLower(fmt.Sprint(x.Value))
+		got := strings.ToLower(v)
+		switch x.Op {
+		case "eq":
+			return ok && got == want
+		case "ne":
+			return !ok || got != want
+		case "co":
+			return ok && strings.Contains(got, want)
+		case "sw":
+			return ok && strings.HasPrefix(got, want)
+		case "ew":
+			return ok && strings.HasSuffix(got, want)
+		}
+	}
+	return false
+}
+
+// Path is a PATCH target: attr, an optional value filter and an optional sub-attribute,
+// e.g. emails[type eq "work"].value → {Attr: "emails", Filter: type eq "work", Sub: "value"}.
+type Path struct {
+	Attr   string
+	Filter Expr
+	Sub    string
+}
+
+// ParsePath parses a PATCH path. Extension attributes keep their schema URN in Attr
+// (e.g. "urn:ietf:params:scim:schemas:extension:enterprise:2.0:user:manager").
+func ParsePath(s string) (Path, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Path{}, nil
+	}
+	open := strings.IndexByte(s, '[')
+	if open < 0 {
+		attr := NormalizeAttr(s)
+		// name.givenName style; extension URNs contain dots in the version, so only split plain paths.
+		if !strings.HasPrefix(attr, "urn:") {
+			if a, sub, ok := strings.Cut(attr, "."); ok {
+				return Path{Attr: a, Sub: sub}, nil
+			}
+		}
+		return Path{Attr: attr}, nil
+	}
+	closeIdx := strings.LastIndexByte(s, ']')
+	if closeIdx < open {
+		return Path{}, BadRequest("invalidPath", "invalid path %q", s)
+	}
+	f, err := ParseFilter(s[open+1 : closeIdx])
+	if err != nil {
+		return Path{}, BadRequest("invalidPath", "invalid path %q", s)
+	}
+	p := Path{Attr: NormalizeAttr(s[:open]), Filter: f}
+	if rest := s[closeIdx+1:]; rest != "" {
+		if !strings.HasPrefix(rest, ".") {
+			return Path{}, BadRequest("invalidPath", "invalid path %q", s)
+		}
+		p.Sub = strings.ToLower(rest[1:])
+	}
+	return p, nil
+}
@@ -0,0 +1,88 @@
+package scim
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseFilter(t *testing.T) {
+	for filter, want := range map[string]Expr{
+		`userName eq "bjensen"`: Compare{Attr: "username", Op: "eq", Value: "bjensen"},
+		`urn:ietf:params:scim:schemas:core:2.0:User:userName Sw "j"`: Compare{Attr: "username", Op: "sw", Value: "j"},
+		`meta.version gt 2.5`: Compare{Attr: "meta.version", Op: "gt", Value: 2.5},
+		`active eq TRUE`:      Compare{Attr: "active", Op: "eq", Value: true},
+		`manager eq null`:     Compare{Attr: "manager", Op: "eq", Value: nil},
+		`title pr`:            Present{Attr: "title"},
+		`title PR and active eq false`: Logical{Op: "and",
+			Left:  Present{Attr: "title"},
+			Right: Compare{Attr: "active", Op: "eq", Value: false}},
+
+		// and binds tighter than or; both are left-associative.
+		`a eq "1" or b eq "2" and c eq "3"`: Logical{Op: "or",
+			Left: Compare{Attr: "a", Op: "eq", Value: "1"},
+			Right: Logical{Op: "and",
+				Left:  Compare{Attr: "b", Op: "eq", Value: "2"},
+				Right: Compare{Attr: "c", Op: "eq", Value: "3"}}},
+		`a eq "1" and b eq "2" or c eq "3"`: Logical{Op: "or",
+			Left: Logical{Op: "and",
+				Left:  Compare{Attr: "a", Op: "eq", Value: "1"},
+				Right: Compare{Attr: "b", Op: "eq", Value: "2"}},
+			Right: Compare{Attr: "c", Op: "eq", Value: "3"}},
+		`a pr or b pr or c pr`: Logical{Op: "or",
+			Left:  Logical{Op: "or", Left: Present{Attr: "a"}, Right: Present{Attr: "b"}},
+			Right: Present{Attr: "c"}},
+		`(a eq "1" or b eq "2") and c eq "3"`: Logical{Op: "and",
+			Left: Logical{Op: "or",
+				Left:  Compare{Attr: "a", Op: "eq", Value: "1"},
+				Right: Compare{Attr: "b", Op: "eq", Value: "2"}},
+			Right: Compare{Attr: "c", Op: "eq", Value: "3"}},
+
+		`not (active eq true)`: Not{X: Compare{Attr: "active", Op: "eq", Value: true}},
+		`not (a pr or b pr) and c pr`: Logical{Op: "and",
+			Left:  Not{X: Logical{Op: "or", Left: Present{Attr: "a"}, Right: Present{Attr: "b"}}},
+			Right: Present{Attr: "c"}},
+
+		// Quoted values are JSON strings: escapes are decoded and keywords stay values.
+		`displayName eq "Ann \"The Hammer\" O'Neil"`: Compare{Attr: "displayname", Op: "eq", Value: `Ann "The Hammer" O'Neil`},
+		`displayName co "back\\slash"`:               Compare{Attr: "displayname", Op: "co", Value: `back\slash`},
+		`displayName eq "café (and) or"`:             Compare{Attr: "displayname", Op: "eq", Value: "café (and) or"},
+		`displayName eq "true"`:                      Compare{Attr: "displayname", Op: "eq", Value: "true"},
+	} {
+		got, err := ParseFilter(filter)
+		if err != nil {
+			t.Errorf("ParseFilter(%s): %v", filter, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ParseFilter(%s) = %#v, want %#v", filter, got, want)
+		}
+	}
+}
+
+func TestParseFilterRejects(t *testing.T) {
+	for _, filter := range []string{
+		`emails[type eq "work"]`,
+		`emails[type eq "work" and value co "@example.com"]`,
+		`members[value eq "1"].display eq "x"`,
+		``,
+		`userName`,
+		`userName eq`,
+		`userName is "x"`,
+		`userName eq bjensen`,
+		`"userName" eq "x"`,
+		`userName eq "unterminated`,
+		`userName eq "bad \q escape"`,
+		`not active eq true`,
+		`(active eq true`,
+		`active eq true)`,
+		`a pr and`,
+		`a pr b pr`,
+	} {
+		_, err := ParseFilter(filter)
+		var e *Error
+		if !errors.As(err, &e) || e.ScimType != "invalidFilter" {
+			t.Errorf("ParseFilter(%s) = %v, want an invalidFilter error", filter, err)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+// Package scim holds the SCIM 2.0 (RFC 7643/7644) wire types, errors and filter parsing used by the
+// provisioning endpoints. Mapping to our models lives in services.SCIMService.
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+const (
+	SchemaUser            = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaGroup           = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SchemaEnterpriseUser  = "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"
+	SchemaRoadmapUser     = "urn:rm:params:scim:schemas:extension:roadmap:2.0:User"
+	SchemaRoadmapGroup    = "urn:rm:params:scim:schemas:extension:roadmap:2.0:Group"
+	SchemaListResponse    = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SchemaPatchOp         = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SchemaError           = "urn:ietf:params:scim:api:messages:2.0:Error"
+	SchemaServiceProvider = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	SchemaResourceType    = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+	ContentType           = "application/scim+json"
+	DefaultCount          = 100
+	MaxCount              = 500
+)
+
+// Error is a SCIM error response. ScimType is one of the RFC 7644 3.12 detail codes, when applicable.
+type Error struct {
+	Status   int
+	ScimType string
+	Detail   string
+}
+
+func (e *Error) Error() string { return e.Detail }
+
+func (e *Error) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Schemas  []string `json:"schemas"`
+		Status   string   `json:"status"`
+		ScimType string   `json:"scimType,omitempty"`
+		Detail   string   `json:"detail,omitempty"`
+	}{[]string{SchemaError}, strconv.Itoa(e.Status), e.ScimType, e.Detail})
+}
+
+func BadRequest(scimType, format string, args ...interface{}) *Error {
+	return &Error{Status: http.StatusBadRequest, ScimType: scimType, Detail: fmt.Sprintf(format, args...)}
+}
+
+func NotFound(resource, id string) *Error {
+	return &Error{Status: http.StatusNotFound, Detail: fmt.Sprintf("%s %s not found", resource, id)}
+}
+
+func Conflict(format string, args ...interface{}) *Error {
+	return &Error{Status: http.StatusConflict, ScimType: "uniqueness", Detail: fmt.Sprintf(format, args...)}
+}
+
+type Meta struct {
+	ResourceType string `json:"resourceType"`
+	Created      string `json:"created,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	Location     string `json:"location,omitempty"`
+}
+
+type Name struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+type MultiValue struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+type Manager struct {
+	Value       string `json:"value,omitempty"`
+	DisplayName string `json:"displayName,omitempty"`
+	Ref         string `json:"$ref,omitempty"`
+}
+
+type EnterpriseUser struct {
+	Manager *Manager `json:"manager,omitempty"`
+}
+
+// RoadmapUser carries attributes SCIM has no standard place for.
+type RoadmapUser struct {
+	DottedLineManagers []MultiValue `json:"dottedLineManagers,omitempty"`
+}
+
+type User struct {
+	Schemas     []string        `json:"schemas"`
+	ID          string          `json:"id,omitempty"`
+	ExternalID  string          `json:"externalId,omitempty"`
+	UserName    string          `json:"userName"`
+	Name        *Name           `json:"name,omitempty"`
+	DisplayName string          `json:"displayName,omitempty"`
+	Emails      []MultiValue    `json:"emails,omitempty"`
+	Active      *bool           `json:"active,omitempty"`
+	Password    string          `json:"password,omitempty"` // write-only
+	Groups      []MultiValue    `json:"groups,omitempty"`   // read-only
+	Enterprise  *EnterpriseUser `json:"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User,omitempty"`
+	Roadmap     *RoadmapUser    `json:"urn:rm:params:scim:schemas:extension:roadmap:2.0:User,omitempty"`
+	Meta        *Meta           `json:"meta,omitempty"`
+}
+
+// RoadmapGroup places a new team in the org hierarchy.
+type RoadmapGroup struct {
+	DepartmentID string `json:"departmentId,omitempty"`
+}
+
+type Group struct {
+	Schemas     []string      `json:"schemas"`
+	ID          string        `json:"id,omitempty"`
+	ExternalID  string        `json:"externalId,omitempty"`
+	DisplayName string        `json:"displayName"`
+	Members     []MultiValue  `json:"members,omitempty"`
+	Roadmap     *RoadmapGroup `json:"urn:rm:params:scim:schemas:extension:roadmap:2.0:Group,omitempty"`
+	Meta        *Meta         `json:"meta,omitempty"`
+}
+
+type ListResponse struct {
+	Schemas      []string    `json:"schemas"`
+	TotalResults int64       `json:"totalResults"`
+	StartIndex   int         `json:"startIndex"`
+	ItemsPerPage int         `json:"itemsPerPage"`
+	Resources    interface{} `json:"Resources"`
+}
+
+type PatchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+type PatchRequest struct {
+	Schemas    []string  `json:"schemas"`
+	Operations []PatchOp `json:"Operations"`
+}
+
+// Page turns SCIM startIndex (1-based) and count into an offset and limit.
+func Page(startIndex, count int) (offset, limit int) {
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	if count < 0 {
+		count = 0
+	}
+	if count > MaxCount {
+		count = MaxCount
+	}
+	return startIndex - 1, count
+}
@@ -2,10 +2,10 @@ package services
 
 import (
 	"context"
-	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/authz"
 	"github.com/rm/roadmap/backend/internal/dto"
 	"github.com/rm/roadmap/backend/internal/models"
 	"github.com/rm/roadmap/backend/internal/repositories"
@@ -23,18 +23,22 @@ type ActivityEntry struct {
 }
 
 type ActivityService struct {
-	repo repositories.ActivityRepository
-	log  *zap.Logger
+	repo       repositories.ActivityRepository
+	outbox     *OutboxService
+	visibility *OrgVisibilityService
+	log        *zap.Logger
+	policy     *authz.Engine
 }
 
 
-func NewActivityService(repo repositories.ActivityRepository, log *zap.Logger) *ActivityService {
-	return &ActivityService{repo: repo, log: log}
+func NewActivityService(repo repositories.ActivityRepository, outbox *OutboxService, visibility *OrgVisibilityService, log *zap.Logger, policy *authz.Engine) *ActivityService {
+	return &ActivityService{repo: repo, outbox: outbox, visibility: visibility, log: log, policy: policy}
 }
 
-// Log writes an activity entry asynchronously.
-func (s *ActivityService) Log(ctx context.Context, entry ActivityEntry) {
-	entryCopy := ActivityEntry{
+// Log records an activity entry through the outbox, in ctx's transaction when there is one (see AuditService.Log).
+func (s *ActivityService) Log(ctx context.Context, entry ActivityEntry) error {
+	rec := models.ActivityLog{
+		Timestamp:  time.Now(),
 		UserID:     entry.UserID,
 		Action:     entry.Action,
 		EntityType: entry.EntityType,
@@ -43,31 +47,32 @@ func (s *ActivityService) Log(ctx context.Context, entry ActivityEntry) {
 		IPAddress:  entry.IPAddress,
 		UserAgent:  entry.UserAgent,
 	}
-	go func() {
-		rec := &models.ActivityLog{
-			UserID:     entryCopy.UserID,
-			Action:     entryCopy.Action,
-			EntityType: entryCopy.EntityType,
-			EntityID:   entryCopy.EntityID,
-			Details:    entryCopy.Details,
-			IPAddress:  entryCopy.IPAddress,
-			UserAgent:  entryCopy.UserAgent,
-		}
-		writeCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-		if err := s.repo.Create(writeCtx, rec); err != nil {
-			s.log.Error("activity log write failed", zap.Error(err), zap.String("action", entryCopy.Action))
-		}
-	}()
+	if err := s.outbox.Enqueue(ctx, models.OutboxKindActivity, rec); err != nil {
+		s.log.Error("activity log enqueue failed", zap.Error(err), zap.String("action", entry.Action))
+		return err
+	}
+	return nil
+}
+
+// CanReadAll reports whether the caller may read every user's activity (activity:read without the own scope).
+func (s *ActivityService) CanReadAll(ctx context.Context, callerID uuid.UUID, callerRole string) bool {
+	return s.policy.Allowed(ctx, authz.Subject{UserID: callerID, Role: models.Role(callerRole)}, authz.PermActivityRead, authz.Any)
 }
 
-// List returns paginated activity logs. For admin: all logs. For non-admin: only the caller's logs (handler should require date range for non-admin).
+// List returns paginated activity logs. With activity:read: all logs. With activity:read:own: the caller's logs and those of their reporting subtree (handler should require date range then).
 func (s *ActivityService) List(ctx context.Context, limit, offset int, action string, dateFrom, dateTo *time.Time, sortBy, order string, callerID uuid.UUID, callerRole string) ([]dto.ActivityLogResponse, int64, error) {
-	var userID *uuid.UUID
-	if !models.Role(strings.ToLower(callerRole)).IsAdminOrAbove() {
-		userID = &callerID
+	var userIDs []uuid.UUID
+	if !s.CanReadAll(ctx, callerID, callerRole) {
+		if !s.policy.HasOwnScope(ctx, authz.Subject{UserID: callerID, Role: models.Role(callerRole)}, authz.PermActivityRead) {
+			return nil, 0, ErrForbidden
+		}
+		visible, err := s.visibility.VisibleUserIDs(ctx, callerID)
+		if err != nil {
+			return nil, 0, err
+		}
+		userIDs = visible
 	}
-	list, total, err := s.repo.List(ctx, limit, offset, action, dateFrom, dateTo, sortBy, order, userID)
+	list, total, err := s.repo.List(ctx, limit, offset, action, dateFrom, dateTo, sortBy, order, userIDs)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -78,6 +83,40 @@ func (s *ActivityService) List(ctx context.Context, limit, offset int, action st
 	return out, total, nil
 }
 
+// Export streams the activity logs matching the List filters to fn, oldest first, a page at a time. Scope is
+// resolved before the first row: ErrForbidden means fn was never called.
+func (s *ActivityService) Export(ctx context.Context, action string, dateFrom, dateTo *time.Time, callerID uuid.UUID, callerRole string, fn func(*dto.ActivityLogResponse) error) error {
+	var userIDs []uuid.UUID
+	if !s.CanReadAll(ctx, callerID, callerRole) {
+		if !s.policy.HasOwnScope(ctx, authz.Subject{UserID: callerID, Role: models.Role(callerRole)}, authz.PermActivityRead) {
+			return ErrForbidden
+		}
+		visible, err := s.visibility.VisibleUserIDs(ctx, callerID)
+		if err != nil {
+			return err
+		}
+		userIDs = visible
+	}
+	var afterTS time.Time
+	var afterID uuid.UUID
+	for {
+		page, err := s.repo.ExportPage(ctx, action, dateFrom, dateTo, userIDs, afterTS, afterID, exportPageSize)
+		if err != nil {
+			return err
+		}
+		for i := range page {
+			resp := activityLogToResponse(&page[i])
+			if err := fn(&resp); err != nil {
+				return err
+			}
+		}
+		if len(page) < exportPageSize {
+			return nil
+		}
+		afterTS, afterID = page[len(page)-1].Timestamp, page[len(page)-1].ID
+	}
+}
+
 func activityLogToResponse(a *models.ActivityLog) dto.ActivityLogResponse {
 	resp := dto.ActivityLogResponse{
 		ID:         a.ID.String(),
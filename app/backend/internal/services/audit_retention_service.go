@@ -0,0 +1,464 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rm/roadmap/backend/internal/dto"
+	"github.com/rm/roadmap/backend/internal/models"
+	"github.com/rm/roadmap/backend/internal/repositories"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRetentionInvalid        = errors.New("archive_after_days and purge_after_days must not be negative, and at least one must be set")
+	ErrRetentionPolicyNotFound = errors.New("retention policy not found")
+	ErrRetentionPolicyExists   = errors.New("a retention policy for this entity type and action already exists")
+	ErrLegalHoldNotFound       = errors.New("legal hold not found")
+	ErrLegalHoldExists         = errors.New("entity is already on legal hold")
+	ErrRetentionAlreadyRunning = errors.New("a retention run is already in progress")
+)
+
+var (
+	auditRetentionArchivedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "audit_retention_archived_total",
+		Help: "Total number of audit logs archived by retention policies",
+	})
+	auditRetentionPurgedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "audit_retention_purged_total",
+		Help: "Total number of archived audit logs purged by retention policies",
+	})
+	auditRetentionRunsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "audit_retention_runs_total",
+			Help: "Total number of retention runs, by result (ok or error)",
+		},
+		[]string{"result"},
+	)
+	auditRetentionLastRun = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "audit_retention_last_run_timestamp_seconds",
+		Help: "Unix time of the last completed retention run",
+	})
+	auditRetentionBacklog = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "audit_retention_backlog",
+			Help: "Audit logs due for archiving or purging after the last retention run, by stage",
+		},
+		[]string{"stage"},
+	)
+)
+
+type AuditRetentionConfig struct {
+	Interval  time.Duration // time between scheduled runs; 0 disables the scheduler
+	BatchSize int           // rows archived or purged per statement
+}
+
+// AuditRetentionService manages retention policies and legal holds and applies them: rows older than a
+// policy's archive_after_days are archived, and archived rows older than its purge_after_days are deleted
+// (leaving hash-chain tombstones). Entities on legal hold are never touched. Each run that changes anything
+// is audited.
+type AuditRetentionService struct {
+	repo     repositories.AuditRetentionRepository
+	tx       repositories.Transactor
+	auditSvc *AuditService
+	cfg      AuditRetentionConfig
+	log      *zap.Logger
+	running  sync.Mutex
+}
+
+func NewAuditRetentionService(repo repositories.AuditRetentionRepository, tx repositories.Transactor, auditSvc *AuditService, cfg AuditRetentionConfig, log *zap.Logger) *AuditRetentionService {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 500
+	}
+	if log == nil {
+		log = zap.NewNop()
+	}
+	return &AuditRetentionService{repo: repo, tx: tx, auditSvc: auditSvc, cfg: cfg, log: log}
+}
+
+func (s *AuditRetentionService) ListPolicies(ctx context.Context) ([]dto.AuditRetentionPolicyResponse, error) {
+	list, err := s.repo.ListPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dto.AuditRetentionPolicyResponse, len(list))
+	for i := range list {
+		out[i] = retentionPolicyToResponse(&list[i])
+	}
+	return out, nil
+}
+
+func (s *AuditRetentionService) CreatePolicy(ctx context.Context, req dto.AuditRetentionPolicyRequest, meta dto.AuditMeta) (*dto.AuditRetentionPolicyResponse, error) {
+	p := &models.AuditRetentionPolicy{CreatedBy: meta.UserID, Enabled: true}
+	if err := s.applyPolicy(ctx, p, req); err != nil {
+		return nil, err
+	}
+	var resp dto.AuditRetentionPolicyResponse
+	if err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.CreatePolicy(ctx, p); err != nil {
+			return err
+		}
+		resp = retentionPolicyToResponse(p)
+		return s.logChange(ctx, "create", "audit_retention_policy", p.ID.String(), nil, ToJSONB(resp), meta)
+	}); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (s *AuditRetentionService) UpdatePolicy(ctx context.Context, id uuid.UUID, req dto.AuditRetentionPolicyRequest, meta dto.AuditMeta) (*dto.AuditRetentionPolicyResponse, error) {
+	p, err := s.repo.GetPolicy(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRetentionPolicyNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	oldData := ToJSONB(retentionPolicyToResponse(p))
+	if err := s.applyPolicy(ctx, p, req); err != nil {
+		return nil, err
+	}
+	var resp dto.AuditRetentionPolicyResponse
+	if err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.UpdatePolicy(ctx, p); err != nil {
+			return err
+		}
+		resp = retentionPolicyToResponse(p)
+		return s.logChange(ctx, "update", "audit_retention_policy", p.ID.String(), oldData, ToJSONB(resp), meta)
+	}); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (s *AuditRetentionService) DeletePolicy(ctx context.Context, id uuid.UUID, meta dto.AuditMeta) error {
+	p, err := s.repo.GetPolicy(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrRetentionPolicyNotFound
+	} else if err != nil {
+		return err
+	}
+	return s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.DeletePolicy(ctx, id); err != nil {
+			return err
+		}
+		return s.logChange(ctx, "delete", "audit_retention_policy", id.String(), ToJSONB(retentionPolicyToResponse(p)), nil, meta)
+	})
+}
+
+// applyPolicy validates req and copies it onto p. The entity type and action pair must be unique.
+func (s *AuditRetentionService) applyPolicy(ctx context.Context, p *models.AuditRetentionPolicy, req dto.AuditRetentionPolicyRequest) error {
+	if req.ArchiveAfterDays < 0 || req.PurgeAfterDays < 0 || (req.ArchiveAfterDays == 0 && req.PurgeAfterDays == 0) {
+		return ErrRetentionInvalid
+	}
+	existing, err := s.repo.ListPolicies(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range existing {
+		if existing[i].ID != p.ID && existing[i].EntityType == req.EntityType && existing[i].Action == req.Action {
+			return ErrRetentionPolicyExists
+		}
+	}
+	p.EntityType = req.EntityType
+	p.Action = req.Action
+	p.ArchiveAfterDays = req.ArchiveAfterDays
+	p.PurgeAfterDays = req.PurgeAfterDays
+	if req.Enabled != nil {
+		p.Enabled = *req.Enabled
+	}
+	return nil
+}
+
+func (s *AuditRetentionService) ListHolds(ctx context.Context) ([]dto.AuditLegalHoldResponse, error) {
+	list, err := s.repo.ListHolds(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dto.AuditLegalHoldResponse, len(list))
+	for i := range list {
+		out[i] = legalHoldToResponse(&list[i])
+	}
+	return out, nil
+}
+
+func (s *AuditRetentionService) CreateHold(ctx context.Context, req dto.AuditLegalHoldRequest, meta dto.AuditMeta) (*dto.AuditLegalHoldResponse, error) {
+	holds, err := s.repo.ListHolds(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range holds {
+		if holds[i].EntityType == req.EntityType && holds[i].EntityID == req.EntityID {
+			return nil, ErrLegalHoldExists
+		}
+	}
+	h := &models.AuditLegalHold{EntityType: req.EntityType, EntityID: req.EntityID, Reason: req.Reason, CreatedBy: meta.UserID}
+	var resp dto.AuditLegalHoldResponse
+	if err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.CreateHold(ctx, h); err != nil {
+			return err
+		}
+		resp = legalHoldToResponse(h)
+		return s.logChange(ctx, "create", "audit_legal_hold", h.ID.String(), nil, ToJSONB(resp), meta)
+	}); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (s *AuditRetentionService) DeleteHold(ctx context.Context, id uuid.UUID, meta dto.AuditMeta) error {
+	h, err := s.repo.GetHold(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrLegalHoldNotFound
+	} else if err != nil {
+		return err
+	}
+	return s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.DeleteHold(ctx, id); err != nil {
+			return err
+		}
+		return s.logChange(ctx, "delete", "audit_legal_hold", id.String(), ToJSONB(legalHoldToResponse(h)), nil, meta)
+	})
+}
+
+// Run applies the enabled policies once. With dryRun nothing changes and the result holds what a run would
+// archive and purge now. A real run that archives or purges anything is audited, including when it stops
+// early; Incomplete is then set and the next run picks up the rest.
+func (s *AuditRetentionService) Run(ctx context.Context, dryRun bool, meta dto.AuditMeta) (*dto.AuditRetentionRunResult, error) {
+	if !dryRun {
+		if !s.running.TryLock() {
+			return nil, ErrRetentionAlreadyRunning
+		}
+		defer s.running.Unlock()
+	}
+	now := time.Now()
+	res := &dto.AuditRetentionRunResult{DryRun: dryRun, StartedAt: now.Format(time.RFC3339), Policies: []dto.AuditRetentionPolicyResult{}}
+	policies, err := s.repo.ListPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var purgedSeqs []int64
+	var runErr error
+	for _, ps := range retentionScopes(policies) {
+		pr := dto.AuditRetentionPolicyResult{PolicyID: ps.policy.ID.String(), EntityType: ps.policy.EntityType, Action: ps.policy.Action}
+		if days := ps.policy.ArchiveAfterDays; days > 0 {
+			pr.Archived, runErr = s.archive(ctx, ps.scope, now.AddDate(0, 0, -days), dryRun)
+		}
+		if days := ps.policy.PurgeAfterDays; days > 0 && runErr == nil {
+			var seqs []int64
+			pr.Purged, seqs, runErr = s.purge(ctx, ps, now.AddDate(0, 0, -days), meta, dryRun)
+			purgedSeqs = append(purgedSeqs, seqs...)
+		}
+		res.Archived += pr.Archived
+		res.Purged += pr.Purged
+		res.Policies = append(res.Policies, pr)
+		if runErr != nil {
+			res.Incomplete = true
+			break
+		}
+	}
+	if dryRun {
+		return res, runErr
+	}
+	auditRetentionArchivedTotal.Add(float64(res.Archived))
+	auditRetentionPurgedTotal.Add(float64(res.Purged))
+	if res.Archived > 0 || res.Purged > 0 {
+		md := models.JSONB{"archived": res.Archived, "purged": res.Purged, "policies": res.Policies, "scheduled": meta.UserID == nil}
+		if res.Incomplete {
+			md["incomplete"] = true
+		}
+		if len(purgedSeqs) > 0 {
+			md["seqs"] = purgedSeqs
+		}
+		// Each purge batch was already audited with the deletion; this summarizes the run.
+		if err := s.auditSvc.Record(context.WithoutCancel(ctx), meta, "retention_run", "audit_log", "", nil, nil, md); err != nil {
+			s.log.Error("audit retention: recording the run failed", zap.Error(err))
+			if runErr == nil {
+				runErr = err
+			}
+		}
+	}
+	if runErr != nil {
+		auditRetentionRunsTotal.WithLabelValues("error").Inc()
+		return res, runErr
+	}
+	auditRetentionRunsTotal.WithLabelValues("ok").Inc()
+	auditRetentionLastRun.Set(float64(time.Now().Unix()))
+	return res, nil
+}
+
+// archive archives rows in scope older than before in batches until none are left or ctx is done.
+func (s *AuditRetentionService) archive(ctx context.Context, scope repositories.RetentionScope, before time.Time, dryRun bool) (int64, error) {
+	if dryRun {
+		return s.repo.CountArchivable(ctx, scope, before)
+	}
+	var total int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		n, err := s.repo.ArchiveBatch(ctx, scope, before, s.cfg.BatchSize)
+		total += n
+		if err != nil || n < int64(s.cfg.BatchSize) {
+			return total, err
+		}
+	}
+}
+
+// purge deletes archived rows in the policy's scope archived before archivedBefore in batches, returning the
+// chain seqs of the deleted rows. Each batch is audited as retention_purge in its own transaction. meta has
+// no user for scheduled runs.
+func (s *AuditRetentionService) purge(ctx context.Context, ps scopedPolicy, archivedBefore time.Time, meta dto.AuditMeta, dryRun bool) (int64, []int64, error) {
+	scope := ps.scope
+	if dryRun {
+		n, err := s.repo.CountPurgeable(ctx, scope, archivedBefore)
+		return n, nil, err
+	}
+	md := models.JSONB{"policy_id": ps.policy.ID.String(), "scheduled": meta.UserID == nil}
+	var total int64
+	var seqs []int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, seqs, err
+		}
+		ids, err := s.repo.PurgeableIDs(ctx, scope, archivedBefore, s.cfg.BatchSize)
+		if err != nil || len(ids) == 0 {
+			return total, seqs, err
+		}
+		rows, err := s.auditSvc.purge(ctx, ids, "retention_purge", md, meta)
+		total += int64(len(rows))
+		seqs = append(seqs, chainSeqs(rows)...)
+		if err != nil || len(ids) < s.cfg.BatchSize {
+			return total, seqs, err
+		}
+	}
+}
+
+// Start runs the retention policies every Interval until ctx is done. It returns immediately when the
+// scheduler is disabled.
+func (s *AuditRetentionService) Start(ctx context.Context) {
+	if s.cfg.Interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			res, err := s.Run(ctx, false, dto.AuditMeta{})
+			if err != nil {
+				if !errors.Is(err, ErrRetentionAlreadyRunning) && ctx.Err() == nil {
+					s.log.Error("audit retention run failed", zap.Error(err))
+				}
+				continue
+			}
+			if res.Archived > 0 || res.Purged > 0 {
+				s.log.Info("audit retention run", zap.Int64("archived", res.Archived), zap.Int64("purged", res.Purged))
+			}
+			s.updateBacklog(ctx)
+		}
+	}
+}
+
+// updateBacklog sets the backlog gauges from a dry run.
+func (s *AuditRetentionService) updateBacklog(ctx context.Context) {
+	res, err := s.Run(ctx, true, dto.AuditMeta{})
+	if err != nil {
+		return
+	}
+	auditRetentionBacklog.WithLabelValues("archive").Set(float64(res.Archived))
+	auditRetentionBacklog.WithLabelValues("purge").Set(float64(res.Purged))
+}
+
+func (s *AuditRetentionService) logChange(ctx context.Context, action, entityType, entityID string, oldData, newData models.JSONB, meta dto.AuditMeta) error {
+	if s.auditSvc == nil {
+		return nil
+	}
+	return s.auditSvc.Log(ctx, AuditEntry{
+		UserID:     meta.UserID,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		OldData:    oldData,
+		NewData:    newData,
+		IPAddress:  meta.IP,
+		UserAgent:  meta.UserAgent,
+		TraceID:    meta.TraceID,
+	})
+}
+
+type scopedPolicy struct {
+	policy models.AuditRetentionPolicy
+	scope  repositories.RetentionScope
+}
+
+// retentionScopes turns the enabled policies into disjoint scopes: each policy excludes the rows that an
+// overlapping, more specific policy governs. Entity type outranks action, so ("product", any) takes
+// ("product", "delete") rows from (any, "delete").
+func retentionScopes(policies []models.AuditRetentionPolicy) []scopedPolicy {
+	rank := func(p models.AuditRetentionPolicy) int {
+		r := 0
+		if p.EntityType != "" {
+			r += 2
+		}
+		if p.Action != "" {
+			r++
+		}
+		return r
+	}
+	overlap := func(a, b string) bool { return a == "" || b == "" || a == b }
+	var out []scopedPolicy
+	for _, p := range policies {
+		if !p.Enabled {
+			continue
+		}
+		sp := scopedPolicy{policy: p, scope: repositories.RetentionScope{EntityType: p.EntityType, Action: p.Action}}
+		for _, q := range policies {
+			if q.Enabled && rank(q) > rank(p) && overlap(p.EntityType, q.EntityType) && overlap(p.Action, q.Action) {
+				sp.scope.Except = append(sp.scope.Except, repositories.RetentionScope{EntityType: q.EntityType, Action: q.Action})
+			}
+		}
+		out = append(out, sp)
+	}
+	return out
+}
+
+func retentionPolicyToResponse(p *models.AuditRetentionPolicy) dto.AuditRetentionPolicyResponse {
+	resp := dto.AuditRetentionPolicyResponse{
+		ID:               p.ID.String(),
+		EntityType:       p.EntityType,
+		Action:           p.Action,
+		ArchiveAfterDays: p.ArchiveAfterDays,
+		PurgeAfterDays:   p.PurgeAfterDays,
+		Enabled:          p.Enabled,
+		CreatedAt:        p.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:        p.UpdatedAt.Format(time.RFC3339),
+	}
+	if p.CreatedBy != nil {
+		s := p.CreatedBy.String()
+		resp.CreatedBy = &s
+	}
+	return resp
+}
+
+func legalHoldToResponse(h *models.AuditLegalHold) dto.AuditLegalHoldResponse {
+	resp := dto.AuditLegalHoldResponse{
+		ID:         h.ID.String(),
+		EntityType: h.EntityType,
+		EntityID:   h.EntityID,
+		Reason:     h.Reason,
+		CreatedAt:  h.CreatedAt.Format(time.RFC3339),
+	}
+	if h.CreatedBy != nil {
+		s := h.CreatedBy.String()
+		resp.CreatedBy = &s
+	}
+	return resp
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/rm/roadmap/backend/internal/repositories"
+)
+
+var ErrInvalidAuditPredicate = errors.New(`invalid data filter: use <old_data|new_data|data>.<path><=|!=|~><value> or changed.<path>`)
+
+var auditPathSegment = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// ParseAuditDataPredicate parses a data filter from the audit search API:
+//
+//	new_data.owner_id=<uuid>     value at the path equals <uuid>
+//	old_data.status!=draft       value is missing or differs
+//	data.labels~GA               array holds "GA" or text contains it, in old_data or new_data
+//	changed.owner_id             value differs between old_data and new_data
+//
+// Paths are dot-separated keys and array indexes (tags.0). Values that parse as JSON numbers, booleans or null
+// are compared as such; anything else, or a value in double quotes, is a string.
+func ParseAuditDataPredicate(expr string) (repositories.AuditDataPredicate, error) {
+	var p repositories.AuditDataPredicate
+	if rest, ok := strings.CutPrefix(expr, "changed."); ok {
+		p.Op = repositories.AuditDataChanged
+		if p.Path = parseAuditPath(rest); p.Path == nil {
+			return p, ErrInvalidAuditPredicate
+		}
+		return p, nil
+	}
+	col, rest, ok := strings.Cut(expr, ".")
+	if !ok {
+		return p, ErrInvalidAuditPredicate
+	}
+	switch col {
+	case "old_data", "new_data":
+		p.Column = col
+	case "data":
+	default:
+		return p, ErrInvalidAuditPredicate
+	}
+	i := strings.IndexAny(rest, "=!~")
+	if i < 0 {
+		return p, ErrInvalidAuditPredicate
+	}
+	path, value := rest[:i], rest[i:]
+	switch {
+	case strings.HasPrefix(value, "!="):
+		p.Op, value = repositories.AuditDataNe, value[2:]
+	case strings.HasPrefix(value, "="):
+		p.Op, value = repositories.AuditDataEq, value[1:]
+	case strings.HasPrefix(value, "~"):
+		p.Op, value = repositories.AuditDataContains, value[1:]
+	default:
+		return p, ErrInvalidAuditPredicate
+	}
+	if p.Path = parseAuditPath(path); p.Path == nil {
+		return p, ErrInvalidAuditPredicate
+	}
+	p.Value = parseAuditValue(value)
+	return p, nil
+}
+
+func parseAuditPath(s string) []string {
+	segs := strings.Split(s, ".")
+	for _, seg := range segs {
+		if !auditPathSegment.MatchString(seg) {
+			return nil
+		}
+	}
+	return segs
+}
+
+func parseAuditValue(s string) interface{} {
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err == nil {
+		switch v.(type) {
+		case float64, bool, string, nil:
+			return v
+		}
+	}
+	return s
+}
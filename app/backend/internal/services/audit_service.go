@@ -4,10 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/auditchain"
+	"github.com/rm/roadmap/backend/internal/auditdiff"
+	"github.com/rm/roadmap/backend/internal/authz"
 	"github.com/rm/roadmap/backend/internal/dto"
 	"github.com/rm/roadmap/backend/internal/models"
 	"github.com/rm/roadmap/backend/internal/repositories"
@@ -29,19 +31,26 @@ type AuditEntry struct {
 
 type AuditService struct {
 	repo       repositories.AuditRepository
+	outbox     *OutboxService
+	tx         repositories.Transactor
 	productRepo repositories.ProductRepository
+	memberRepo repositories.ProductMemberRepository
+	visibility *OrgVisibilityService
 	log        *zap.Logger
+	policy     *authz.Engine
+	chainKey   []byte // signs deletion tombstones (auditchain)
 }
 
-func NewAuditService(repo repositories.AuditRepository, productRepo repositories.ProductRepository, log *zap.Logger) *AuditService {
-	return &AuditService{repo: repo, productRepo: productRepo, log: log}
+func NewAuditService(repo repositories.AuditRepository, outbox *OutboxService, tx repositories.Transactor, productRepo repositories.ProductRepository, memberRepo repositories.ProductMemberRepository, visibility *OrgVisibilityService, log *zap.Logger, policy *authz.Engine, chainKey []byte) *AuditService {
+	return &AuditService{repo: repo, outbox: outbox, tx: tx, productRepo: productRepo, memberRepo: memberRepo, visibility: visibility, log: log, policy: policy, chainKey: chainKey}
 }
 
-// Log writes an audit entry asynchronously. Business logic must not depend on its success.
-// Uses a fresh context with timeout so the write is never tied to the request context (avoids "context canceled" when client disconnects).
-func (s *AuditService) Log(ctx context.Context, entry AuditEntry) {
-	// Copy entry into the goroutine so we don't retain request-scoped references (e.g. context).
-	entryCopy := AuditEntry{
+// Log records an audit entry through the outbox. When ctx carries a transaction (repositories.Transactor)
+// the entry commits or rolls back with it, and callers should return the error so the change is not kept
+// without its audit record. Outside a transaction the error is only logged; business logic does not depend on it.
+func (s *AuditService) Log(ctx context.Context, entry AuditEntry) error {
+	rec := models.AuditLog{
+		Timestamp:  time.Now(),
 		UserID:     entry.UserID,
 		Action:     entry.Action,
 		EntityType: entry.EntityType,
@@ -53,33 +62,40 @@ func (s *AuditService) Log(ctx context.Context, entry AuditEntry) {
 		UserAgent:  entry.UserAgent,
 		TraceID:    entry.TraceID,
 	}
-	go func() {
-		rec := &models.AuditLog{
-			UserID:     entryCopy.UserID,
-			Action:     entryCopy.Action,
-			EntityType: entryCopy.EntityType,
-			EntityID:   entryCopy.EntityID,
-			OldData:    entryCopy.OldData,
-			NewData:    entryCopy.NewData,
-			Metadata:   entryCopy.Metadata,
-			IPAddress:  entryCopy.IPAddress,
-			UserAgent:  entryCopy.UserAgent,
-			TraceID:    entryCopy.TraceID,
-		}
-		// Fresh context with timeout so write is never canceled by request lifecycle (client disconnect, etc.)
-		writeCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-		if err := s.repo.Create(writeCtx, rec); err != nil {
-			s.log.Error("audit log write failed", zap.Error(err), zap.String("action", entryCopy.Action), zap.String("entity_type", entryCopy.EntityType))
-		}
-	}()
-}
-
-// List returns paginated audit logs. For admin: all logs. For others: only logs for products the caller owns.
-// archived: nil or false = main (non-archived), true = archive only.
-func (s *AuditService) List(ctx context.Context, limit, offset int, entityType, action string, dateFrom, dateTo *time.Time, archived *bool, sortBy, order string, callerID uuid.UUID, callerRole string) ([]dto.AuditLogResponse, int64, error) {
-	if models.Role(strings.ToLower(callerRole)).IsAdminOrAbove() {
-		list, total, err := s.repo.List(ctx, limit, offset, entityType, action, dateFrom, dateTo, archived, sortBy, order)
+	if err := s.outbox.Enqueue(ctx, models.OutboxKindAudit, rec); err != nil {
+		s.log.Error("audit log enqueue failed", zap.Error(err), zap.String("action", entry.Action), zap.String("entity_type", entry.EntityType))
+		return err
+	}
+	return nil
+}
+
+// Record logs a change made by the caller meta describes. Call it in the transaction making the change so
+// that both commit or neither does, and fail the change with its error. A nil service records nothing.
+func (s *AuditService) Record(ctx context.Context, meta dto.AuditMeta, action, entityType, entityID string, oldData, newData, metadata models.JSONB) error {
+	if s == nil {
+		return nil
+	}
+	return s.Log(ctx, AuditEntry{
+		UserID:     meta.UserID,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		OldData:    oldData,
+		NewData:    newData,
+		Metadata:   metadata,
+		IPAddress:  meta.IP,
+		UserAgent:  meta.UserAgent,
+		TraceID:    meta.TraceID,
+	})
+}
+
+// List returns paginated audit logs. With audit:read: all logs. With audit:read:own: logs for products the caller owns or is a member of,
+// plus products owned by and actions taken by anyone in the caller's reporting subtree.
+// f.Archived: false = main (non-archived), true = archive only, nil = both.
+func (s *AuditService) List(ctx context.Context, limit, offset int, f repositories.AuditFilter, sortBy, order string, callerID uuid.UUID, callerRole string) ([]dto.AuditLogResponse, int64, error) {
+	sub := authz.Subject{UserID: callerID, Role: models.Role(callerRole)}
+	if s.policy.Allowed(ctx, sub, authz.PermAuditRead, authz.Any) {
+		list, total, err := s.repo.List(ctx, limit, offset, f, sortBy, order)
 		if err != nil {
 			return nil, 0, err
 		}
@@ -90,16 +106,14 @@ func (s *AuditService) List(ctx context.Context, limit, offset int, entityType,
 		}
 		return out, total, nil
 	}
-	// Non-admin: only logs for products they own
-	products, _, err := s.productRepo.List(&callerID, nil, nil, nil, nil, nil, nil, nil, nil, nil, "created_at", "desc", 2000, 0)
+	if !s.policy.HasOwnScope(ctx, sub, authz.PermAuditRead) {
+		return nil, 0, ErrForbidden
+	}
+	productIDs, visible, err := s.ownScope(ctx, callerID)
 	if err != nil {
 		return nil, 0, err
 	}
-	productIDs := make([]uuid.UUID, 0, len(products))
-	for _, p := range products {
-		productIDs = append(productIDs, p.ID)
-	}
-	list, total, err := s.repo.ListForOwner(ctx, productIDs, limit, offset, entityType, action, dateFrom, dateTo, archived, sortBy, order)
+	list, total, err := s.repo.ListForOwner(ctx, productIDs, visible[1:], limit, offset, f, sortBy, order)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -111,14 +125,173 @@ func (s *AuditService) List(ctx context.Context, limit, offset int, entityType,
 	return out, total, nil
 }
 
+// exportPageSize is how many rows an export reads per query.
+const exportPageSize = 500
+
+// Export streams the audit logs matching the List filters to fn, oldest first, a page at a time, so exports of
+// any size run in constant memory. The caller's scope is resolved before the first row: ErrForbidden means fn
+// was never called.
+func (s *AuditService) Export(ctx context.Context, f repositories.AuditFilter, callerID uuid.UUID, callerRole string, fn func(*dto.AuditLogResponse) error) error {
+	sub := authz.Subject{UserID: callerID, Role: models.Role(callerRole)}
+	if !s.policy.Allowed(ctx, sub, authz.PermAuditRead, authz.Any) {
+		if !s.policy.HasOwnScope(ctx, sub, authz.PermAuditRead) {
+			return ErrForbidden
+		}
+		productIDs, visible, err := s.ownScope(ctx, callerID)
+		if err != nil {
+			return err
+		}
+		f.Own, f.ProductIDs, f.ActorIDs = true, productIDs, visible[1:]
+	}
+	var afterTS time.Time
+	var afterID uuid.UUID
+	for {
+		page, err := s.repo.ExportPage(ctx, f, afterTS, afterID, exportPageSize)
+		if err != nil {
+			return err
+		}
+		for i := range page {
+			resp := auditLogToResponse(&page[i])
+			if err := fn(&resp); err != nil {
+				return err
+			}
+		}
+		if len(page) < exportPageSize {
+			return nil
+		}
+		afterTS, afterID = page[len(page)-1].Timestamp, page[len(page)-1].ID
+	}
+}
+
+// ownScope returns the products whose audit trail an audit:read:own caller sees (owned by them or their reports,
+// or collaborated on with any member role, including viewer) and the visible users, the caller first.
+func (s *AuditService) ownScope(ctx context.Context, callerID uuid.UUID) (productIDs, visible []uuid.UUID, err error) {
+	visible, err = s.visibility.VisibleUserIDs(ctx, callerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	productIDs, err = s.productRepo.ListIDsByOwners(visible)
+	if err != nil {
+		return nil, nil, err
+	}
+	if s.memberRepo != nil {
+		memberOf, err := s.memberRepo.ListProductIDsForUser(ctx, callerID)
+		if err != nil {
+			return nil, nil, err
+		}
+		productIDs = append(productIDs, memberOf...)
+	}
+	return productIDs, visible, nil
+}
+
+// EntityHistory returns the change timeline of one entity (product, milestone, version, ...), oldest first, with
+// field-level changes and actor names. With audit:read:own the entity must belong to a product in the caller's
+// scope; for entities other than products the product is taken from the product_id in the logged data.
+func (s *AuditService) EntityHistory(ctx context.Context, entityType, entityID string, limit, offset int, callerID uuid.UUID, callerRole string) (*dto.AuditTimelineResponse, error) {
+	list, total, err := s.repo.ListByEntity(ctx, entityType, entityID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	resp := &dto.AuditTimelineResponse{EntityType: entityType, EntityID: entityID, Entries: make([]dto.AuditTimelineEntry, len(list)), Total: total, Limit: limit, Offset: offset}
+	var productID uuid.UUID
+	if entityType == "product" {
+		productID, _ = uuid.Parse(entityID)
+	}
+	for i := range list {
+		resp.Entries[i] = auditLogToTimelineEntry(&list[i])
+		if productID == uuid.Nil {
+			productID = dataProductID(list[i].NewData, list[i].OldData)
+		}
+	}
+	sub := authz.Subject{UserID: callerID, Role: models.Role(callerRole)}
+	if !s.policy.Allowed(ctx, sub, authz.PermAuditRead, authz.Any) {
+		if !s.policy.HasOwnScope(ctx, sub, authz.PermAuditRead) {
+			return nil, ErrForbidden
+		}
+		if len(list) == 0 && productID == uuid.Nil {
+			return resp, nil
+		}
+		productIDs, _, err := s.ownScope(ctx, callerID)
+		if err != nil {
+			return nil, err
+		}
+		if productID == uuid.Nil || !containsUUID(productIDs, productID) {
+			return nil, ErrForbidden
+		}
+	}
+	if productID != uuid.Nil {
+		resp.ProductID = productID.String()
+		if p, err := s.productRepo.GetByID(ctx, productID); err == nil {
+			resp.ProductName = p.Name
+		}
+	}
+	return resp, nil
+}
+
 // Archive marks the given log IDs as archived. Only non-archived rows are updated. Admin only in handler.
 func (s *AuditService) Archive(ctx context.Context, ids []uuid.UUID) error {
 	return s.repo.Archive(ctx, ids)
 }
 
 // DeleteArchived permanently deletes archived audit log rows. Only rows with archived=true are deleted. Admin only in handler.
-func (s *AuditService) DeleteArchived(ctx context.Context, ids []uuid.UUID) error {
-	return s.repo.DeleteArchived(ctx, ids)
+// Each deleted row leaves a signed tombstone in the chain, and the deletion itself is audited. Returns the number deleted.
+func (s *AuditService) DeleteArchived(ctx context.Context, ids []uuid.UUID, meta dto.AuditMeta) (int, error) {
+	rows, err := s.purge(ctx, ids, "delete_archived", nil, meta)
+	return len(rows), err
+}
+
+// purge deletes archived rows, leaving a tombstone signed for the caller (nil for the retention scheduler).
+// The deletion is audited as action, with metadata plus the count and chain seqs of the rows deleted, in
+// the same transaction, so rows are never deleted without a record of who deleted them.
+func (s *AuditService) purge(ctx context.Context, ids []uuid.UUID, action string, metadata models.JSONB, meta dto.AuditMeta) ([]models.AuditLog, error) {
+	now := time.Now()
+	var rows []models.AuditLog
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		rows, err = s.repo.DeleteArchived(ctx, ids, func(e *models.AuditLog) models.AuditTombstone {
+			return auditchain.NewTombstone(e, meta.UserID, now, s.chainKey)
+		})
+		if err != nil || len(rows) == 0 {
+			return err
+		}
+		md := models.JSONB{"count": len(rows), "seqs": chainSeqs(rows)}
+		for k, v := range metadata {
+			md[k] = v
+		}
+		return s.Record(ctx, meta, action, "audit_log", "", nil, nil, md)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// chainSeqs returns the chain positions of rows, skipping rows that were never chained.
+func chainSeqs(rows []models.AuditLog) []int64 {
+	seqs := make([]int64, 0, len(rows))
+	for i := range rows {
+		if rows[i].Seq != nil {
+			seqs = append(seqs, *rows[i].Seq)
+		}
+	}
+	return seqs
+}
+
+// VerifyChain checks the whole audit hash chain, including tombstones of deleted rows.
+func (s *AuditService) VerifyChain(ctx context.Context) (*auditchain.Result, error) {
+	return auditchain.Verify(ctx, s.repo, s.chainKey)
+}
+
+// SealUnchained adds rows written before the hash chain existed to the end of the chain. Run at startup.
+func (s *AuditService) SealUnchained(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		n, err := s.repo.SealUnchained(ctx, 500)
+		total += n
+		if err != nil || n == 0 {
+			return total, err
+		}
+	}
 }
 
 // enrichProductNameVersion sets ProductName and ProductVersion on the response when the log relates to a product.
@@ -159,7 +332,7 @@ func enrichProductNameVersion(ctx context.Context, productRepo repositories.Prod
 	if productID == uuid.Nil {
 		return
 	}
-	p, err := productRepo.GetByID(productID)
+	p, err := productRepo.GetByID(ctx, productID)
 	if err != nil || p == nil {
 		return
 	}
@@ -171,6 +344,45 @@ func enrichProductNameVersion(ctx context.Context, productRepo repositories.Prod
 	}
 }
 
+// dataProductID returns the product_id found in the first of the logged payloads that has one.
+func dataProductID(payloads ...models.JSONB) uuid.UUID {
+	for _, d := range payloads {
+		if v, ok := d["product_id"].(string); ok {
+			if id, err := uuid.Parse(v); err == nil {
+				return id
+			}
+		}
+	}
+	return uuid.Nil
+}
+
+// auditChanges is the field-level diff between a record's old and new data.
+func auditChanges(a *models.AuditLog) []dto.AuditFieldChange {
+	changes := auditdiff.Diff(a.OldData, a.NewData)
+	out := make([]dto.AuditFieldChange, len(changes))
+	for i, c := range changes {
+		out[i] = dto.AuditFieldChange{Path: c.Path, Op: c.Op, Old: c.Old, New: c.New}
+	}
+	return out
+}
+
+func auditLogToTimelineEntry(a *models.AuditLog) dto.AuditTimelineEntry {
+	e := dto.AuditTimelineEntry{
+		ID:        a.ID.String(),
+		Timestamp: a.Timestamp.Format("2006-01-02T15:04:05Z07:00"),
+		Action:    a.Action,
+		Changes:   auditChanges(a),
+		Archived:  a.Archived,
+		TraceID:   a.TraceID,
+	}
+	if a.User != nil && a.User.ID != uuid.Nil {
+		e.Actor = &dto.AuditActor{ID: a.User.ID.String(), Name: a.User.Name, Email: a.User.Email}
+	} else if a.UserID != nil {
+		e.Actor = &dto.AuditActor{ID: a.UserID.String()}
+	}
+	return e
+}
+
 func auditLogToResponse(a *models.AuditLog) dto.AuditLogResponse {
 	resp := dto.AuditLogResponse{
 		ID:         a.ID.String(),
@@ -196,9 +408,39 @@ func auditLogToResponse(a *models.AuditLog) dto.AuditLogResponse {
 	if a.NewData != nil {
 		resp.NewData = a.NewData
 	}
+	if a.OldData != nil || a.NewData != nil {
+		resp.Changes = auditChanges(a)
+	}
+	resp.EntityName = auditEntityName(a)
 	return resp
 }
 
+// auditEntityName returns a readable name for org hierarchy, group and user entries, taken from the
+// entity's snapshot (new data first, so renames show the current name) or, for user entries whose data is
+// not the user itself, from the user_name metadata.
+func auditEntityName(a *models.AuditLog) string {
+	var keys []string
+	switch a.EntityType {
+	case "holding_company", "company", "function", "department", "team", "group":
+		keys = []string{"name"}
+	case "user":
+		keys = []string{"name", "email"}
+	default:
+		return ""
+	}
+	for _, m := range []models.JSONB{a.NewData, a.OldData} {
+		for _, k := range keys {
+			if v, ok := m[k].(string); ok && v != "" {
+				return v
+			}
+		}
+	}
+	if v, ok := a.Metadata["user_name"].(string); ok {
+		return v
+	}
+	return ""
+}
+
 func ToJSONB(v interface{}) models.JSONB {
 	if v == nil {
 		return nil
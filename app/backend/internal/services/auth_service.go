@@ -1,7 +1,11 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/rm/roadmap/backend/internal/auth"
@@ -15,45 +19,130 @@ import (
 var (
 	ErrInvalidCredentials = errors.New("invalid email or password")
 	ErrEmailExists        = errors.New("email already registered")
+	ErrAccountDisabled    = errors.New("account is deactivated")
+	ErrDirectoryDown      = errors.New("directory unavailable, try again later")
 )
 
 type AuthService struct {
-	userRepo repositories.UserRepository
-	jwt      *auth.JWTService
+	userRepo   repositories.UserRepository
+	jwt        *auth.JWTService
+	loginGuard *LoginGuardService
+	sessions   *SessionService
+	directory  auth.Authenticator // nil = local password auth only
 }
 
-func NewAuthService(userRepo repositories.UserRepository, jwt *auth.JWTService) *AuthService {
-	return &AuthService{userRepo: userRepo, jwt: jwt}
+func NewAuthService(userRepo repositories.UserRepository, jwt *auth.JWTService, loginGuard *LoginGuardService, sessions *SessionService, directory auth.Authenticator) *AuthService {
+	return &AuthService{userRepo: userRepo, jwt: jwt, loginGuard: loginGuard, sessions: sessions, directory: directory}
 }
 
-func (s *AuthService) Login(req dto.LoginRequest) (*dto.AuthResponse, error) {
-	u, err := s.userRepo.GetByEmail(req.Email)
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+
+	// comparePassword is bcrypt.CompareHashAndPassword; tests count the comparisons through it.
+	comparePassword = bcrypt.CompareHashAndPassword
+)
+
+// compareDummyHash burns the same bcrypt cost as a real check so response timing does not reveal unknown emails.
+func compareDummyHash(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password-for-timing"), bcrypt.DefaultCost)
+	})
+	_ = comparePassword(dummyHash, []byte(password))
+}
+
+// Login authenticates by email and password and starts a new session. clientIP is used for per-IP
+// brute-force tracking. Returns *LoginLockedError (errors.Is ErrLoginLocked) while the account or IP is locked.
+func (s *AuthService) Login(ctx context.Context, req dto.LoginRequest, clientIP, userAgent string) (*dto.AuthResponse, error) {
+	if s.loginGuard != nil {
+		if err := s.loginGuard.Check(ctx, req.Email, clientIP); err != nil {
+			return nil, err
+		}
+	}
+	u, err := s.authenticate(ctx, req.Email, req.Password)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+		// A failure during a directory outage counts too: break-glass local accounts stay rate-limited.
+		if errors.Is(err, ErrInvalidCredentials) || errors.Is(err, ErrDirectoryDown) {
+			s.recordLoginFailure(ctx, req.Email, clientIP)
+		}
+		return nil, err
+	}
+	if s.loginGuard != nil {
+		s.loginGuard.RecordSuccess(ctx, req.Email)
+	}
+	if !u.Active {
+		return nil, ErrAccountDisabled
+	}
+	return s.startSession(ctx, u, clientIP, userAgent)
+}
+
+// authenticate checks the directory first when one is configured. Local password auth is the fallback for
+// accounts the directory does not know, and for local accounts while it is down (break-glass); directory
+// users never fall back to a local password. Every failure costs one bcrypt comparison, and while the
+// directory is down every failure is ErrDirectoryDown, so neither timing nor the response tells a local
+// account from an unknown email or a directory user.
+func (s *AuthService) authenticate(ctx context.Context, email, password string) (*models.User, error) {
+	var directoryErr error
+	if s.directory != nil {
+		id, err := s.directory.Authenticate(ctx, email, password)
+		switch {
+		case err == nil:
+			return s.syncDirectoryUser(ctx, id)
+		case errors.Is(err, auth.ErrDirectoryInvalidCredentials), errors.Is(err, auth.ErrDirectoryNoRole):
+			compareDummyHash(password)
 			return nil, ErrInvalidCredentials
+		case errors.Is(err, auth.ErrDirectoryUserNotFound), errors.Is(err, auth.ErrDirectoryUnavailable):
+			directoryErr = err
+		default:
+			return nil, err
 		}
+	}
+	directoryDown := errors.Is(directoryErr, auth.ErrDirectoryUnavailable)
+	u, err := s.userRepo.GetByEmail(email)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, err
 	}
-	if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(req.Password)); err != nil {
-		return nil, ErrInvalidCredentials
+	switch {
+	case err != nil || u.AuthSource == models.AuthSourceLDAP:
+		compareDummyHash(password)
+	case comparePassword([]byte(u.PasswordHash), []byte(password)) == nil:
+		return u, nil
+	}
+	if directoryDown {
+		return nil, fmt.Errorf("%w: %v", ErrDirectoryDown, directoryErr)
+	}
+	return nil, ErrInvalidCredentials
+}
+
+// syncDirectoryUser creates or updates the local user for a directory identity. Name, email and role follow
+// the directory on every login; the account is marked ldap so its local password no longer works.
+func (s *AuthService) syncDirectoryUser(ctx context.Context, id *auth.Identity) (*models.User, error) {
+	u, err := s.userRepo.GetByEmail(id.Email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		u = &models.User{Name: id.Name, Email: id.Email, Role: id.Role, AuthSource: models.AuthSourceLDAP, Active: true}
+		if err := s.userRepo.Create(ctx, u); err != nil {
+			return nil, err
+		}
+		return u, nil
 	}
-	access, expSec, err := s.jwt.GenerateAccessToken(u.ID, u.Email, string(u.Role))
 	if err != nil {
 		return nil, err
 	}
-	refresh, err := s.jwt.GenerateRefreshToken(u.ID)
-	if err != nil {
+	if u.Name == id.Name && u.Email == id.Email && u.Role == id.Role && u.AuthSource == models.AuthSourceLDAP {
+		return u, nil
+	}
+	u.Name = id.Name
+	u.Email = id.Email
+	u.Role = id.Role
+	u.AuthSource = models.AuthSourceLDAP
+	u.PasswordHash = ""
+	if err := s.userRepo.Update(ctx, u); err != nil {
 		return nil, err
 	}
-	return &dto.AuthResponse{
-		AccessToken:  access,
-		RefreshToken: refresh,
-		ExpiresIn:    expSec,
-		User:         userToResponse(u),
-	}, nil
+	return u, nil
 }
 
-func (s *AuthService) Register(req dto.RegisterRequest) (*dto.AuthResponse, error) {
+func (s *AuthService) Register(ctx context.Context, req dto.RegisterRequest, clientIP, userAgent string) (*dto.AuthResponse, error) {
 	_, err := s.userRepo.GetByEmail(req.Email)
 	if err == nil {
 		return nil, ErrEmailExists
@@ -81,60 +170,100 @@ func (s *AuthService) Register(req dto.RegisterRequest) (*dto.AuthResponse, erro
 		PasswordHash: string(hash),
 		Role:         role,
 	}
-	if err := s.userRepo.Create(u); err != nil {
+	if err := s.userRepo.Create(ctx, u); err != nil {
 		return nil, err
 	}
-	access, expSec, err := s.jwt.GenerateAccessToken(u.ID, u.Email, string(u.Role))
+	return s.startSession(ctx, u, clientIP, userAgent)
+}
+
+// Refresh exchanges the session's current refresh token for a new token pair in the same session.
+// Tokens issued before session tracking (no sid) are rejected, so those clients log in again once.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*dto.AuthResponse, error) {
+	claims, err := s.jwt.ValidateToken(refreshToken, auth.TokenTypeRefresh)
 	if err != nil {
 		return nil, err
 	}
-	refresh, err := s.jwt.GenerateRefreshToken(u.ID)
+	id, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return nil, auth.ErrInvalidToken
+	}
+	sid, err := uuid.Parse(claims.SessionID)
+	if err != nil {
+		return nil, auth.ErrInvalidToken
+	}
+	u, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !u.Active {
+		return nil, ErrAccountDisabled
+	}
+	access, accessJTI, expSec, err := s.jwt.GenerateAccessToken(u.ID, u.Email, string(u.Role), sid)
 	if err != nil {
 		return nil, err
 	}
+	newRefresh, refreshJTI, err := s.jwt.GenerateRefreshToken(u.ID, sid)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sessions.Rotate(ctx, sid, u.ID, claims.ID, accessJTI, refreshJTI, time.Now().Add(s.jwt.RefreshExpiry())); err != nil {
+		return nil, err
+	}
 	return &dto.AuthResponse{
 		AccessToken:  access,
-		RefreshToken: refresh,
+		RefreshToken: newRefresh,
 		ExpiresIn:    expSec,
 		User:         userToResponse(u),
 	}, nil
 }
 
-func (s *AuthService) Refresh(refreshToken string) (*dto.AuthResponse, error) {
-	claims, err := s.jwt.ValidateToken(refreshToken)
+// startSession issues a token pair bound to a new session and records the session.
+func (s *AuthService) startSession(ctx context.Context, u *models.User, clientIP, userAgent string) (*dto.AuthResponse, error) {
+	sid := uuid.New()
+	access, accessJTI, expSec, err := s.jwt.GenerateAccessToken(u.ID, u.Email, string(u.Role), sid)
 	if err != nil {
 		return nil, err
 	}
-	id, err := uuid.Parse(claims.UserID)
-	if err != nil {
-		return nil, auth.ErrInvalidToken
-	}
-	u, err := s.userRepo.GetByID(id)
+	refresh, refreshJTI, err := s.jwt.GenerateRefreshToken(u.ID, sid)
 	if err != nil {
 		return nil, err
 	}
-	access, expSec, err := s.jwt.GenerateAccessToken(u.ID, u.Email, string(u.Role))
-	if err != nil {
-		return nil, err
-	}
-	newRefresh, err := s.jwt.GenerateRefreshToken(u.ID)
-	if err != nil {
+	if err := s.sessions.Create(ctx, sid, u.ID, accessJTI, refreshJTI, clientIP, userAgent, time.Now().Add(s.jwt.RefreshExpiry())); err != nil {
 		return nil, err
 	}
 	return &dto.AuthResponse{
 		AccessToken:  access,
-		RefreshToken: newRefresh,
+		RefreshToken: refresh,
 		ExpiresIn:    expSec,
 		User:         userToResponse(u),
 	}, nil
 }
 
+func (s *AuthService) recordLoginFailure(ctx context.Context, email, clientIP string) {
+	if s.loginGuard != nil {
+		s.loginGuard.RecordFailure(ctx, email, clientIP)
+	}
+}
+
 // VerifyPassword checks that the given password matches the user's password. Returns nil if valid.
+// Directory users are checked against the directory.
 func (s *AuthService) VerifyPassword(userID uuid.UUID, password string) error {
 	u, err := s.userRepo.GetByID(userID)
 	if err != nil {
 		return err
 	}
+	if u.AuthSource == models.AuthSourceLDAP {
+		if s.directory == nil {
+			return ErrInvalidCredentials
+		}
+		if _, err := s.directory.Authenticate(context.Background(), u.Email, password); err != nil {
+			if errors.Is(err, auth.ErrDirectoryUnavailable) {
+				return ErrDirectoryDown
+			}
+			return ErrInvalidCredentials
+		}
+		return nil
+	}
 	if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)); err != nil {
 		return ErrInvalidCredentials
 	}
@@ -143,10 +272,12 @@ func (s *AuthService) VerifyPassword(userID uuid.UUID, password string) error {
 
 func userToResponse(u *models.User) dto.UserResponse {
 	resp := dto.UserResponse{
-		ID:    u.ID.String(),
-		Name:  u.Name,
-		Email: u.Email,
-		Role:  string(u.Role),
+		ID:     u.ID.String(),
+		Name:   u.Name,
+		Email:  u.Email,
+		Role:   string(u.Role),
+		Active: u.Active,
+		Locale: u.Locale,
 	}
 	if u.TeamID != nil {
 		s := u.TeamID.String()
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rm/roadmap/backend/internal/auth"
+	"github.com/rm/roadmap/backend/internal/models"
+	"github.com/rm/roadmap/backend/internal/repositories"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// memUsers looks users up by email; the rest of the repository is not used by authenticate.
+type memUsers struct {
+	repositories.UserRepository
+	byEmail map[string]*models.User
+}
+
+func (m *memUsers) GetByEmail(email string) (*models.User, error) {
+	if u, ok := m.byEmail[email]; ok {
+		return u, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// stubDirectory fails every bind with err.
+type stubDirectory struct{ err error }
+
+func (d stubDirectory) Authenticate(context.Context, string, string) (*auth.Identity, error) {
+	return nil, d.err
+}
+
+// countCompares counts bcrypt comparisons until the test ends.
+func countCompares(t *testing.T) *int {
+	n := 0
+	orig := comparePassword
+	comparePassword = func(hash, password []byte) error {
+		n++
+		return orig(hash, password)
+	}
+	t.Cleanup(func() { comparePassword = orig })
+	return &n
+}
+
+func TestAuthenticateDirectoryDown(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("local secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := &memUsers{byEmail: map[string]*models.User{
+		"local@example.com": {Email: "local@example.com", PasswordHash: string(hash), AuthSource: models.AuthSourceLocal, Active: true},
+		"ldap@example.com":  {Email: "ldap@example.com", AuthSource: models.AuthSourceLDAP, Active: true},
+	}}
+	compares := countCompares(t)
+
+	for _, directoryErr := range []error{auth.ErrDirectoryUnavailable, auth.ErrDirectoryUserNotFound} {
+		s := &AuthService{userRepo: users, directory: stubDirectory{directoryErr}}
+		want := ErrInvalidCredentials
+		if directoryErr == auth.ErrDirectoryUnavailable {
+			want = ErrDirectoryDown
+		}
+		for _, email := range []string{"unknown@example.com", "local@example.com", "ldap@example.com"} {
+			*compares = 0
+			_, err := s.authenticate(context.Background(), email, "guess")
+			if !errors.Is(err, want) {
+				t.Errorf("%v, %s: got %v, want %v", directoryErr, email, err, want)
+			}
+			if *compares != 1 {
+				t.Errorf("%v, %s: %d bcrypt comparisons, want 1", directoryErr, email, *compares)
+			}
+		}
+
+		// Break-glass: a local account with its password still logs in.
+		u, err := s.authenticate(context.Background(), "local@example.com", "local secret")
+		if err != nil || u.Email != "local@example.com" {
+			t.Errorf("%v: local login got %v, %v", directoryErr, u, err)
+		}
+		if _, err := s.authenticate(context.Background(), "ldap@example.com", ""); !errors.Is(err, want) {
+			t.Errorf("%v: directory user with an empty local password got %v", directoryErr, err)
+		}
+	}
+}
+
+func TestAuthenticateDirectoryRejects(t *testing.T) {
+	compares := countCompares(t)
+	s := &AuthService{userRepo: &memUsers{}, directory: stubDirectory{auth.ErrDirectoryInvalidCredentials}}
+	if _, err := s.authenticate(context.Background(), "someone@example.com", "guess"); err != ErrInvalidCredentials {
+		t.Errorf("got %v, want ErrInvalidCredentials", err)
+	}
+	if *compares != 1 {
+		t.Errorf("%d bcrypt comparisons, want 1", *compares)
+	}
+}
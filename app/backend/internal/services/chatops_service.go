@@ -0,0 +1,202 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/chatops"
+	"github.com/rm/roadmap/backend/internal/models"
+	"github.com/rm/roadmap/backend/internal/repositories"
+)
+
+const (
+	// chatOpsProductMatches is how many product names an ambiguous command lists.
+	chatOpsProductMatches = 5
+	// chatOpsBlockedLimit caps the blocked versions listed in one answer.
+	chatOpsBlockedLimit = 20
+)
+
+// ChatOpsService answers the Slack and Teams slash commands parsed by chatops.ParseCommand. Answers only
+// cover approved products; anyone in the chat workspace can run a command.
+type ChatOpsService struct {
+	productRepo    repositories.ProductRepository
+	versionDepRepo repositories.ProductVersionDependencyRepository
+	watchSvc       *WatchService
+	baseURL        string
+}
+
+func NewChatOpsService(productRepo repositories.ProductRepository, versionDepRepo repositories.ProductVersionDependencyRepository, watchSvc *WatchService, baseURL string) *ChatOpsService {
+	return &ChatOpsService{productRepo: productRepo, versionDepRepo: versionDepRepo, watchSvc: watchSvc, baseURL: baseURL}
+}
+
+// Answer runs cmd. public reports whether the answer is worth showing to the whole channel; help texts and
+// failed lookups are shown only to the user who asked.
+func (s *ChatOpsService) Answer(ctx context.Context, cmd chatops.Command, now time.Time) (m chatops.Message, public bool, err error) {
+	switch cmd.Kind {
+	case chatops.CommandNext:
+		return s.next(ctx, cmd.Milestone, cmd.Product, now)
+	case chatops.CommandBlocked:
+		return s.blocked(ctx, cmd.Product)
+	}
+	return chatops.Message{Title: "Roadmap commands", Text: chatops.Usage}, false, nil
+}
+
+// next answers with the earliest milestone of the product whose type or label is milestone and that is
+// not completed or over.
+func (s *ChatOpsService) next(ctx context.Context, milestone, product string, now time.Time) (chatops.Message, bool, error) {
+	p, m, err := s.findProduct(ctx, product)
+	if p == nil || err != nil {
+		return m, false, err
+	}
+	today := utcDay(now)
+	var found *models.Milestone
+	for i := range p.Milestones {
+		ms := &p.Milestones[i]
+		if !strings.EqualFold(ms.Type, milestone) && !strings.EqualFold(strings.TrimSpace(ms.Label), milestone) {
+			continue
+		}
+		last := ms.StartDate
+		if ms.EndDate != nil {
+			last = *ms.EndDate
+		}
+		if ms.CompletedAt != nil || utcDay(last).Before(today) {
+			continue
+		}
+		if found == nil || ms.StartDate.Before(found.StartDate) {
+			found = ms
+		}
+	}
+	title := fmt.Sprintf("Next %s for %s", milestone, p.Name)
+	link := chatops.ProductLink(s.baseURL, p.ID.String())
+	if found == nil {
+		return chatops.Message{Title: title, Text: fmt.Sprintf("No upcoming %s milestone is planned.", milestone), Link: link}, true, nil
+	}
+	fields := []chatops.Field{{Name: "Milestone", Value: found.Label}}
+	if v := productVersionName(p, found.ProductVersionID); v != "" {
+		fields = append(fields, chatops.Field{Name: "Version", Value: v})
+	}
+	fields = append(fields, chatops.Field{Name: "Start", Value: found.StartDate.Format("2006-01-02")})
+	if found.EndDate != nil {
+		fields = append(fields, chatops.Field{Name: "End", Value: found.EndDate.Format("2006-01-02")})
+	}
+	text := "Starts today."
+	if days := int(utcDay(found.StartDate).Sub(today).Hours() / 24); days == 1 {
+		text = "Starts tomorrow."
+	} else if days > 1 {
+		text = fmt.Sprintf("Starts in %d days.", days)
+	} else if days < 0 {
+		text = "In progress."
+	}
+	return chatops.Message{Title: title, Text: text, Fields: fields, Link: link}, true, nil
+}
+
+// blocked answers with the product versions that depend on a product that has not reached the required
+// status yet, optionally only those of one product.
+func (s *ChatOpsService) blocked(ctx context.Context, product string) (chatops.Message, bool, error) {
+	var only *models.Product
+	if product != "" {
+		p, m, err := s.findProduct(ctx, product)
+		if p == nil || err != nil {
+			return m, false, err
+		}
+		only = p
+	}
+	deps, err := s.versionDepRepo.List(ctx)
+	if err != nil {
+		return chatops.Message{}, false, err
+	}
+	readiness := map[uuid.UUID]map[uuid.UUID]bool{}
+	var fields []chatops.Field
+	total := 0
+	for _, d := range deps {
+		src := d.SourceProductVersion
+		if src == nil || src.Product.ID == uuid.Nil || src.Product.Status != models.StatusApproved || d.TargetProduct == nil {
+			continue
+		}
+		if only != nil && src.ProductID != only.ID {
+			continue
+		}
+		ready, ok := readiness[d.TargetProductID]
+		if !ok {
+			ready = s.watchSvc.DependencyReadiness(ctx, d.TargetProductID)
+			readiness[d.TargetProductID] = ready
+		}
+		if met, tracked := ready[d.ID]; !tracked || met {
+			continue
+		}
+		total++
+		if len(fields) < chatOpsBlockedLimit {
+			target := d.TargetProduct.Name
+			if d.TargetProductVersion != nil {
+				target += " " + d.TargetProductVersion.Version
+			}
+			fields = append(fields, chatops.Field{
+				Name:  src.Product.Name + " " + src.Version,
+				Value: fmt.Sprintf("waits for %s to reach %q", target, d.RequiredStatus),
+			})
+		}
+	}
+	m := chatops.Message{Title: "Blocked product versions", Fields: fields}
+	if only != nil {
+		m.Title = "Blocked versions of " + only.Name
+		m.Link = chatops.ProductLink(s.baseURL, only.ID.String())
+	}
+	switch {
+	case total == 0:
+		m.Text = "Nothing is blocked; every dependency has reached its required status."
+		m.Color = chatops.ColorGood
+	case total > len(fields):
+		m.Text = fmt.Sprintf("%d dependencies are not ready; the first %d are listed.", total, len(fields))
+		m.Color = chatops.ColorWarning
+	case total == 1:
+		m.Text = "1 dependency is not ready."
+		m.Color = chatops.ColorWarning
+	default:
+		m.Text = fmt.Sprintf("%d dependencies are not ready.", total)
+		m.Color = chatops.ColorWarning
+	}
+	return m, true, nil
+}
+
+// findProduct resolves a product name given in a command. When no product or several match, it returns
+// nil and the message to answer with instead.
+func (s *ChatOpsService) findProduct(ctx context.Context, name string) (*models.Product, chatops.Message, error) {
+	list, err := s.productRepo.SearchByName(ctx, name, chatOpsProductMatches+1)
+	if err != nil {
+		return nil, chatops.Message{}, err
+	}
+	if len(list) == 0 {
+		return nil, chatops.Message{Title: "Product not found", Text: fmt.Sprintf("No product matches %q.", name)}, nil
+	}
+	if len(list) > 1 && !strings.EqualFold(list[0].Name, name) {
+		names := make([]string, 0, chatOpsProductMatches)
+		for _, p := range list[:min(len(list), chatOpsProductMatches)] {
+			names = append(names, p.Name)
+		}
+		text := fmt.Sprintf("%q matches %s", name, strings.Join(names, ", "))
+		if len(list) > chatOpsProductMatches {
+			text += ", …"
+		}
+		return nil, chatops.Message{Title: "Which product?", Text: text + ". Use the full name."}, nil
+	}
+	p, err := s.productRepo.GetByID(ctx, list[0].ID)
+	if err != nil {
+		return nil, chatops.Message{}, err
+	}
+	return p, chatops.Message{}, nil
+}
+
+func productVersionName(p *models.Product, versionID *uuid.UUID) string {
+	if versionID == nil {
+		return ""
+	}
+	i := slices.IndexFunc(p.ProductVersions, func(v models.ProductVersion) bool { return v.ID == *versionID })
+	if i < 0 {
+		return ""
+	}
+	return p.ProductVersions[i].Version
+}
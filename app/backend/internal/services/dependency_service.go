@@ -12,12 +12,13 @@ import (
 type DependencyService struct {
 	depRepo      repositories.DependencyRepository
 	milestoneRepo repositories.MilestoneRepository
+	tx           repositories.Transactor
 	auditSvc     *AuditService
 	activitySvc  *ActivityService
 }
 
-func NewDependencyService(depRepo repositories.DependencyRepository, milestoneRepo repositories.MilestoneRepository, auditSvc *AuditService, activitySvc *ActivityService) *DependencyService {
-	return &DependencyService{depRepo: depRepo, milestoneRepo: milestoneRepo, auditSvc: auditSvc, activitySvc: activitySvc}
+func NewDependencyService(depRepo repositories.DependencyRepository, milestoneRepo repositories.MilestoneRepository, tx repositories.Transactor, auditSvc *AuditService, activitySvc *ActivityService) *DependencyService {
+	return &DependencyService{depRepo: depRepo, milestoneRepo: milestoneRepo, tx: tx, auditSvc: auditSvc, activitySvc: activitySvc}
 }
 
 func (s *DependencyService) Create(ctx context.Context, req dto.DependencyCreateRequest, meta dto.AuditMeta) (*dto.DependencyResponse, error) {
@@ -28,22 +29,29 @@ func (s *DependencyService) Create(ctx context.Context, req dto.DependencyCreate
 		TargetMilestoneID: tgt,
 		Type:              models.DependencyType(req.Type),
 	}
-	if err := s.depRepo.Create(d); err != nil {
+	var resp *dto.DependencyResponse
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.depRepo.Create(ctx, d); err != nil {
+			return err
+		}
+		resp = dependencyToResponse(d)
+		if s.auditSvc != nil {
+			return s.auditSvc.Log(ctx, AuditEntry{
+				UserID:     meta.UserID,
+				Action:     "create",
+				EntityType: "dependency",
+				EntityID:   d.ID.String(),
+				NewData:    ToJSONB(resp),
+				IPAddress:  meta.IP,
+				UserAgent:  meta.UserAgent,
+				TraceID:    meta.TraceID,
+			})
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
-	resp := dependencyToResponse(d)
-	if s.auditSvc != nil {
-		s.auditSvc.Log(ctx, AuditEntry{
-			UserID:     meta.UserID,
-			Action:     "create",
-			EntityType: "dependency",
-			EntityID:   d.ID.String(),
-			NewData:    ToJSONB(resp),
-			IPAddress:  meta.IP,
-			UserAgent:  meta.UserAgent,
-			TraceID:    meta.TraceID,
-		})
-	}
 	return resp, nil
 }
 
@@ -91,33 +99,37 @@ func (s *DependencyService) Delete(ctx context.Context, id uuid.UUID, meta dto.A
 		return err
 	}
 	oldData := ToJSONB(dependencyToResponse(d))
-	if err := s.depRepo.Delete(id); err != nil {
-		return err
-	}
-	if s.auditSvc != nil {
-		s.auditSvc.Log(ctx, AuditEntry{
-			UserID:     meta.UserID,
-			Action:     "delete",
-			EntityType: "dependency",
-			EntityID:   id.String(),
-			OldData:    oldData,
-			IPAddress:  meta.IP,
-			UserAgent:  meta.UserAgent,
-			TraceID:    meta.TraceID,
-		})
-	}
-	if s.activitySvc != nil && meta.UserID != nil {
-		s.activitySvc.Log(ctx, ActivityEntry{
-			UserID:     meta.UserID,
-			Action:     "delete",
-			EntityType: "dependency",
-			EntityID:   id.String(),
-			Details:    string(d.Type),
-			IPAddress:  meta.IP,
-			UserAgent:  meta.UserAgent,
-		})
-	}
-	return nil
+	return s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.depRepo.Delete(ctx, id); err != nil {
+			return err
+		}
+		if s.auditSvc != nil {
+			if err := s.auditSvc.Log(ctx, AuditEntry{
+				UserID:     meta.UserID,
+				Action:     "delete",
+				EntityType: "dependency",
+				EntityID:   id.String(),
+				OldData:    oldData,
+				IPAddress:  meta.IP,
+				UserAgent:  meta.UserAgent,
+				TraceID:    meta.TraceID,
+			}); err != nil {
+				return err
+			}
+		}
+		if s.activitySvc != nil && meta.UserID != nil {
+			return s.activitySvc.Log(ctx, ActivityEntry{
+				UserID:     meta.UserID,
+				Action:     "delete",
+				EntityType: "dependency",
+				EntityID:   id.String(),
+				Details:    string(d.Type),
+				IPAddress:  meta.IP,
+				UserAgent:  meta.UserAgent,
+			})
+		}
+		return nil
+	})
 }
 
 func dependencyToResponse(d *models.Dependency) *dto.DependencyResponse {
@@ -1,10 +1,12 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"strings"
 
 	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/authz"
 	"github.com/rm/roadmap/backend/internal/dto"
 	"github.com/rm/roadmap/backend/internal/models"
 	"github.com/rm/roadmap/backend/internal/repositories"
@@ -16,14 +18,21 @@ var (
 )
 
 type GroupService struct {
-	repo repositories.GroupRepository
+	repo     repositories.GroupRepository
+	policy   *authz.Engine
+	tx       repositories.Transactor
+	auditSvc *AuditService
 }
 
-func NewGroupService(repo repositories.GroupRepository) *GroupService {
-	return &GroupService{repo: repo}
+func NewGroupService(repo repositories.GroupRepository, policy *authz.Engine, tx repositories.Transactor, auditSvc *AuditService) *GroupService {
+	return &GroupService{repo: repo, policy: policy, tx: tx, auditSvc: auditSvc}
 }
 
-func (s *GroupService) Create(req dto.GroupCreateRequest, createdBy uuid.UUID) (*dto.GroupResponse, error) {
+func groupResource(g *models.Group) authz.Resource {
+	return authz.Owned("group", g.ID.String(), g.CreatedBy)
+}
+
+func (s *GroupService) Create(ctx context.Context, req dto.GroupCreateRequest, createdBy uuid.UUID, meta dto.AuditMeta) (*dto.GroupResponse, error) {
 	req.Name = strings.TrimSpace(req.Name)
 	req.Description = strings.TrimSpace(req.Description)
 	if req.Name == "" {
@@ -41,30 +50,43 @@ func (s *GroupService) Create(req dto.GroupCreateRequest, createdBy uuid.UUID) (
 		Description: req.Description,
 		CreatedBy:   &createdBy,
 	}
-	if err := s.repo.Create(g); err != nil {
-		return nil, err
-	}
-	if err := s.repo.SetProducts(g.ID, productIDs); err != nil {
+	var resp *dto.GroupResponse
+	if err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, g); err != nil {
+			return err
+		}
+		if err := s.repo.SetProducts(ctx, g.ID, productIDs); err != nil {
+			return err
+		}
+		var err error
+		if resp, err = s.getByID(ctx, g.ID); err != nil {
+			return err
+		}
+		return s.auditSvc.Record(ctx, meta, "create", "group", resp.ID, nil, ToJSONB(resp), nil)
+	}); err != nil {
 		return nil, err
 	}
-	return s.getByID(g.ID)
+	return resp, nil
 }
 
-func (s *GroupService) GetByID(id uuid.UUID, callerID uuid.UUID, callerRole models.Role) (*dto.GroupResponse, error) {
-	g, err := s.repo.GetByID(id)
+func (s *GroupService) GetByID(ctx context.Context, id uuid.UUID, callerID uuid.UUID, callerRole models.Role) (*dto.GroupResponse, error) {
+	g, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, ErrGroupNotFound
 	}
-	// Non-admin can only see their own groups
-	if callerRole != models.RoleAdmin && (g.CreatedBy == nil || *g.CreatedBy != callerID) {
-		return nil, ErrForbidden
+	if err := s.policy.Authorize(ctx, authz.Subject{UserID: callerID, Role: callerRole}, authz.PermGroupRead, groupResource(g)); err != nil {
+		return nil, err
 	}
 	return groupToResponse(g), nil
 }
 
-func (s *GroupService) List(callerID uuid.UUID, callerRole models.Role) ([]dto.GroupResponse, error) {
+func (s *GroupService) List(ctx context.Context, callerID uuid.UUID, callerRole models.Role) ([]dto.GroupResponse, error) {
+	sub := authz.Subject{UserID: callerID, Role: callerRole}
 	var createdBy *uuid.UUID
-	if !callerRole.IsAdminOrAbove() {
+	if !s.policy.Allowed(ctx, sub, authz.PermGroupRead, authz.Any) {
+		if !s.policy.HasOwnScope(ctx, sub, authz.PermGroupRead) {
+			return nil, ErrForbidden
+		}
 		createdBy = &callerID
 	}
 	list, err := s.repo.List(createdBy)
@@ -78,14 +100,15 @@ func (s *GroupService) List(callerID uuid.UUID, callerRole models.Role) ([]dto.G
 	return out, nil
 }
 
-func (s *GroupService) Update(id uuid.UUID, req dto.GroupUpdateRequest, callerID uuid.UUID, callerRole models.Role) (*dto.GroupResponse, error) {
-	g, err := s.repo.GetByID(id)
+func (s *GroupService) Update(ctx context.Context, id uuid.UUID, req dto.GroupUpdateRequest, callerID uuid.UUID, callerRole models.Role, meta dto.AuditMeta) (*dto.GroupResponse, error) {
+	g, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, ErrGroupNotFound
 	}
-	if !callerRole.IsAdminOrAbove() && (g.CreatedBy == nil || *g.CreatedBy != callerID) {
-		return nil, ErrForbidden
+	if err := s.policy.Authorize(ctx, authz.Subject{UserID: callerID, Role: callerRole}, authz.PermGroupWrite, groupResource(g)); err != nil {
+		return nil, err
 	}
+	oldData := ToJSONB(groupToResponse(g))
 	if req.Name != nil {
 		name := strings.TrimSpace(*req.Name)
 		if name == "" {
@@ -100,34 +123,51 @@ func (s *GroupService) Update(id uuid.UUID, req dto.GroupUpdateRequest, callerID
 		}
 		g.Description = desc
 	}
-	if err := s.repo.Update(g); err != nil {
-		return nil, err
-	}
+	var productIDs []uuid.UUID
 	if req.ProductIDs != nil {
-		productIDs, err := parseUUIDs(req.ProductIDs)
-		if err != nil {
+		if productIDs, err = parseUUIDs(req.ProductIDs); err != nil {
 			return nil, err
 		}
-		if err := s.repo.SetProducts(id, productIDs); err != nil {
-			return nil, err
+	}
+	var resp *dto.GroupResponse
+	if err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Update(ctx, g); err != nil {
+			return err
 		}
+		if req.ProductIDs != nil {
+			if err := s.repo.SetProducts(ctx, id, productIDs); err != nil {
+				return err
+			}
+		}
+		var err error
+		if resp, err = s.getByID(ctx, id); err != nil {
+			return err
+		}
+		return s.auditSvc.Record(ctx, meta, "update", "group", resp.ID, oldData, ToJSONB(resp), nil)
+	}); err != nil {
+		return nil, err
 	}
-	return s.getByID(id)
+	return resp, nil
 }
 
-func (s *GroupService) Delete(id uuid.UUID, callerID uuid.UUID, callerRole models.Role) error {
-	g, err := s.repo.GetByID(id)
+func (s *GroupService) Delete(ctx context.Context, id uuid.UUID, callerID uuid.UUID, callerRole models.Role, meta dto.AuditMeta) error {
+	g, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return ErrGroupNotFound
 	}
-	if !callerRole.IsAdminOrAbove() && (g.CreatedBy == nil || *g.CreatedBy != callerID) {
-		return ErrForbidden
+	if err := s.policy.Authorize(ctx, authz.Subject{UserID: callerID, Role: callerRole}, authz.PermGroupWrite, groupResource(g)); err != nil {
+		return err
 	}
-	return s.repo.Delete(id)
+	return s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return s.auditSvc.Record(ctx, meta, "delete", "group", id.String(), ToJSONB(groupToResponse(g)), nil, nil)
+	})
 }
 
-func (s *GroupService) getByID(id uuid.UUID) (*dto.GroupResponse, error) {
-	g, err := s.repo.GetByID(id)
+func (s *GroupService) getByID(ctx context.Context, id uuid.UUID) (*dto.GroupResponse, error) {
+	g, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
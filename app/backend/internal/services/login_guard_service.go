@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rm/roadmap/backend/internal/dto"
+	"github.com/rm/roadmap/backend/internal/models"
+	"github.com/rm/roadmap/backend/internal/repositories"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var ErrLoginLocked = errors.New("too many failed login attempts; try again later")
+
+// LoginLockedError is returned while an account or client IP is locked. It matches ErrLoginLocked via errors.Is.
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string { return ErrLoginLocked.Error() }
+func (e *LoginLockedError) Unwrap() error { return ErrLoginLocked }
+
+var loginLockoutsTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "auth_login_lockouts_total",
+		Help: "Total number of temporary login lockouts, by scope (account or ip)",
+	},
+	[]string{"scope"},
+)
+
+// maxLockoutShift bounds the exponential backoff exponent so the shift never overflows.
+const maxLockoutShift = 20
+
+type LoginGuardConfig struct {
+	MaxFailedAttempts   int           // per account
+	IPMaxFailedAttempts int           // per client IP
+	FailureWindow       time.Duration // failures older than this are forgotten
+	LockoutBase         time.Duration // first lockout; doubles with each further failure
+	LockoutMax          time.Duration // cap for a single lockout
+}
+
+// LoginGuardService tracks failed logins per account and per client IP and applies
+// exponential-backoff lockouts. Unknown emails are tracked exactly like real ones so
+// responses never reveal whether an account exists.
+type LoginGuardService struct {
+	repo        repositories.LoginLockoutRepository
+	userRepo    repositories.UserRepository
+	cfg         LoginGuardConfig
+	tx          repositories.Transactor
+	auditSvc    *AuditService
+	activitySvc *ActivityService
+	log         *zap.Logger
+}
+
+func NewLoginGuardService(repo repositories.LoginLockoutRepository, userRepo repositories.UserRepository, cfg LoginGuardConfig, tx repositories.Transactor, auditSvc *AuditService, activitySvc *ActivityService, log *zap.Logger) *LoginGuardService {
+	if log == nil {
+		log = zap.NewNop()
+	}
+	return &LoginGuardService{repo: repo, userRepo: userRepo, cfg: cfg, tx: tx, auditSvc: auditSvc, activitySvc: activitySvc, log: log}
+}
+
+func normalizeLoginEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Check returns a *LoginLockedError when the account or the client IP is currently locked.
+func (s *LoginGuardService) Check(ctx context.Context, email, ip string) error {
+	now := time.Now()
+	var retry time.Duration
+	for _, k := range s.keys(email, ip) {
+		l, err := s.repo.Get(ctx, k.scope, k.key)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return err
+		}
+		if l.LockedUntil != nil && l.LockedUntil.After(now) {
+			if d := l.LockedUntil.Sub(now); d > retry {
+				retry = d
+			}
+		}
+	}
+	if retry > 0 {
+		return &LoginLockedError{RetryAfter: retry}
+	}
+	return nil
+}
+
+// RecordFailure counts a failed login for the account and the client IP and locks either once its threshold is reached.
+func (s *LoginGuardService) RecordFailure(ctx context.Context, email, ip string) {
+	now := time.Now()
+	windowStart := now.Add(-s.cfg.FailureWindow)
+	for _, k := range s.keys(email, ip) {
+		l, err := s.repo.RecordFailure(ctx, k.scope, k.key, windowStart, now)
+		if err != nil {
+			s.log.Error("login failure tracking failed", zap.Error(err), zap.String("scope", k.scope))
+			continue
+		}
+		if l.FailedCount < k.threshold {
+			continue
+		}
+		d := s.lockoutDuration(l.FailedCount - k.threshold)
+		until := now.Add(d)
+		if err := s.repo.SetLockedUntil(ctx, l.ID, until); err != nil {
+			s.log.Error("login lockout write failed", zap.Error(err), zap.String("scope", k.scope))
+			continue
+		}
+		loginLockoutsTotal.WithLabelValues(k.scope).Inc()
+		s.logLocked(ctx, k.scope, k.key, l.FailedCount, d, ip)
+	}
+}
+
+// RecordSuccess clears the account counter. The IP counter is left alone so one valid
+// account cannot be used to reset an attacker's IP budget.
+func (s *LoginGuardService) RecordSuccess(ctx context.Context, email string) {
+	if err := s.repo.Reset(ctx, models.LockoutScopeAccount, normalizeLoginEmail(email)); err != nil {
+		s.log.Error("login lockout reset failed", zap.Error(err))
+	}
+}
+
+// ListLocked returns all lockouts currently in effect (admin view).
+func (s *LoginGuardService) ListLocked(ctx context.Context) ([]dto.LoginLockoutResponse, error) {
+	list, err := s.repo.ListLocked(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dto.LoginLockoutResponse, len(list))
+	for i := range list {
+		out[i] = loginLockoutToResponse(&list[i])
+	}
+	return out, nil
+}
+
+// UnlockUser clears the account lockout for the given user.
+func (s *LoginGuardService) UnlockUser(ctx context.Context, userID uuid.UUID, meta dto.AuditMeta) error {
+	u, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	key := normalizeLoginEmail(u.Email)
+	return s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Reset(ctx, models.LockoutScopeAccount, key); err != nil {
+			return err
+		}
+		return s.auditUnlock(ctx, models.LockoutScopeAccount, key, "user", userID.String(), meta)
+	})
+}
+
+// Unlock removes a single lockout row (account or IP) by ID.
+func (s *LoginGuardService) Unlock(ctx context.Context, id uuid.UUID, meta dto.AuditMeta) error {
+	l, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return s.auditUnlock(ctx, l.Scope, l.Key, "login_lockout", id.String(), meta)
+	})
+}
+
+type lockoutKey struct {
+	scope     string
+	key       string
+	threshold int
+}
+
+func (s *LoginGuardService) keys(email, ip string) []lockoutKey {
+	keys := make([]lockoutKey, 0, 2)
+	if e := normalizeLoginEmail(email); e != "" && s.cfg.MaxFailedAttempts > 0 {
+		keys = append(keys, lockoutKey{scope: models.LockoutScopeAccount, key: e, threshold: s.cfg.MaxFailedAttempts})
+	}
+	if ip != "" && s.cfg.IPMaxFailedAttempts > 0 {
+		keys = append(keys, lockoutKey{scope: models.LockoutScopeIP, key: ip, threshold: s.cfg.IPMaxFailedAttempts})
+	}
+	return keys
+}
+
+// lockoutDuration returns base * 2^excess, capped at LockoutMax.
+func (s *LoginGuardService) lockoutDuration(excess int) time.Duration {
+	if excess > maxLockoutShift {
+		excess = maxLockoutShift
+	}
+	d := s.cfg.LockoutBase << uint(excess)
+	if s.cfg.LockoutMax > 0 && (d > s.cfg.LockoutMax || d <= 0) {
+		d = s.cfg.LockoutMax
+	}
+	return d
+}
+
+func (s *LoginGuardService) logLocked(ctx context.Context, scope, key string, failed int, d time.Duration, ip string) {
+	if s.activitySvc == nil {
+		return
+	}
+	entry := ActivityEntry{
+		Action:     "login_locked",
+		EntityType: "login_lockout",
+		EntityID:   scope + ":" + key,
+		Details:    fmt.Sprintf("%s locked for %s after %d failed attempts", scope, d, failed),
+		IPAddress:  ip,
+	}
+	// Activity logs are admin-only, so resolving the account here does not leak existence to the caller.
+	if scope == models.LockoutScopeAccount && s.userRepo != nil {
+		if u, err := s.userRepo.GetByEmail(key); err == nil {
+			entry.UserID = &u.ID
+		}
+	}
+	// The lockout itself is already in effect; a failed record must not undo it or fail the login.
+	if err := s.activitySvc.Log(ctx, entry); err != nil {
+		s.log.Error("login lockout activity log failed", zap.Error(err), zap.String("scope", scope))
+	}
+}
+
+func (s *LoginGuardService) auditUnlock(ctx context.Context, scope, key, entityType, entityID string, meta dto.AuditMeta) error {
+	return s.auditSvc.Record(ctx, meta, "unlock", entityType, entityID, models.JSONB{"scope": scope, "key": key}, nil, nil)
+}
+
+func loginLockoutToResponse(l *models.LoginLockout) dto.LoginLockoutResponse {
+	resp := dto.LoginLockoutResponse{
+		ID:           l.ID.String(),
+		Scope:        l.Scope,
+		Key:          l.Key,
+		FailedCount:  l.FailedCount,
+		LastFailedAt: l.LastFailedAt.Format(time.RFC3339),
+	}
+	if l.LockedUntil != nil {
+		s := l.LockedUntil.Format(time.RFC3339)
+		resp.LockedUntil = &s
+	}
+	return resp
+}
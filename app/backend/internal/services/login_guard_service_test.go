@@ -0,0 +1,278 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/models"
+	"github.com/rm/roadmap/backend/internal/repositories"
+	"gorm.io/gorm"
+)
+
+// memLockouts is an in-memory login_lockouts table. RecordFailure follows the repository's SQL: a counter
+// whose last failure is older than the window, and whose lock (if any) also ended before it, restarts at 1.
+type memLockouts struct {
+	rows map[string]*models.LoginLockout
+}
+
+func newMemLockouts() *memLockouts { return &memLockouts{rows: map[string]*models.LoginLockout{}} }
+
+func (m *memLockouts) Get(_ context.Context, scope, key string) (*models.LoginLockout, error) {
+	if l, ok := m.rows[scope+"/"+key]; ok {
+		c := *l
+		return &c, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *memLockouts) RecordFailure(_ context.Context, scope, key string, windowStart, now time.Time) (*models.LoginLockout, error) {
+	l, ok := m.rows[scope+"/"+key]
+	switch {
+	case !ok:
+		l = &models.LoginLockout{ID: uuid.New(), Scope: scope, Key: key, FailedCount: 1}
+		m.rows[scope+"/"+key] = l
+	case l.LastFailedAt.Before(windowStart) && (l.LockedUntil == nil || l.LockedUntil.Before(windowStart)):
+		l.FailedCount = 1
+	default:
+		l.FailedCount++
+	}
+	l.LastFailedAt = now
+	c := *l
+	return &c, nil
+}
+
+func (m *memLockouts) SetLockedUntil(_ context.Context, id uuid.UUID, until time.Time) error {
+	for _, l := range m.rows {
+		if l.ID == id {
+			l.LockedUntil = &until
+		}
+	}
+	return nil
+}
+
+func (m *memLockouts) GetByID(_ context.Context, id uuid.UUID) (*models.LoginLockout, error) {
+	for _, l := range m.rows {
+		if l.ID == id {
+			c := *l
+			return &c, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *memLockouts) Reset(_ context.Context, scope, key string) error {
+	delete(m.rows, scope+"/"+key)
+	return nil
+}
+
+func (m *memLockouts) Delete(_ context.Context, id uuid.UUID) error {
+	for k, l := range m.rows {
+		if l.ID == id {
+			delete(m.rows, k)
+		}
+	}
+	return nil
+}
+
+func (m *memLockouts) ListLocked(_ context.Context, now time.Time) ([]models.LoginLockout, error) {
+	var out []models.LoginLockout
+	for _, l := range m.rows {
+		if l.LockedUntil != nil && l.LockedUntil.After(now) {
+			out = append(out, *l)
+		}
+	}
+	return out, nil
+}
+
+// lockedFor is how long the row for scope/key stays locked from now; 0 when it is not locked.
+func (m *memLockouts) lockedFor(scope, key string) time.Duration {
+	l, ok := m.rows[scope+"/"+key]
+	if !ok || l.LockedUntil == nil {
+		return 0
+	}
+	return max(time.Until(*l.LockedUntil), 0)
+}
+
+// age moves the row's last failure and lock back by d, as if d had passed.
+func (m *memLockouts) age(scope, key string, d time.Duration) {
+	l := m.rows[scope+"/"+key]
+	l.LastFailedAt = l.LastFailedAt.Add(-d)
+	if l.LockedUntil != nil {
+		until := l.LockedUntil.Add(-d)
+		l.LockedUntil = &until
+	}
+}
+
+// memOutbox records the events written through it.
+type memOutbox struct {
+	repositories.OutboxRepository
+	events []models.OutboxEvent
+}
+
+func (m *memOutbox) Enqueue(_ context.Context, e *models.OutboxEvent) error {
+	m.events = append(m.events, *e)
+	return nil
+}
+
+var testGuardConfig = LoginGuardConfig{
+	MaxFailedAttempts:   3,
+	IPMaxFailedAttempts: 5,
+	FailureWindow:       15 * time.Minute,
+	LockoutBase:         time.Minute,
+	LockoutMax:          5 * time.Minute,
+}
+
+func newTestGuard(cfg LoginGuardConfig) (*LoginGuardService, *memLockouts, *memOutbox) {
+	repo, outbox := newMemLockouts(), &memOutbox{}
+	activity := NewActivityService(nil, NewOutboxService(outbox, nil, nil, nil, OutboxConfig{}, nil), nil, nil, nil)
+	return NewLoginGuardService(repo, &memUsers{}, cfg, nil, nil, activity, nil), repo, outbox
+}
+
+// near reports whether got is want, give or take the time the test took.
+func near(got, want time.Duration) bool {
+	return got <= want && got > want-5*time.Second
+}
+
+func TestLoginGuardThreshold(t *testing.T) {
+	ctx := context.Background()
+	g, repo, outbox := newTestGuard(testGuardConfig)
+	const email, ip = "Ann@Example.com ", "192.0.2.1"
+	for i := 1; i < testGuardConfig.MaxFailedAttempts; i++ {
+		g.RecordFailure(ctx, email, ip)
+		if err := g.Check(ctx, email, ip); err != nil {
+			t.Fatalf("locked after %d failures, threshold %d", i, testGuardConfig.MaxFailedAttempts)
+		}
+	}
+	g.RecordFailure(ctx, email, ip)
+	err := g.Check(ctx, email, ip)
+	var locked *LoginLockedError
+	if !errors.As(err, &locked) || !errors.Is(err, ErrLoginLocked) {
+		t.Fatalf("at the threshold: got %v, want a LoginLockedError", err)
+	}
+	if !near(locked.RetryAfter, testGuardConfig.LockoutBase) {
+		t.Errorf("RetryAfter %s, want %s", locked.RetryAfter, testGuardConfig.LockoutBase)
+	}
+	// The account key is the normalized email; other addresses from the same IP are not locked yet.
+	if repo.lockedFor(models.LockoutScopeAccount, "ann@example.com") == 0 {
+		t.Error("account row not locked under the normalized email")
+	}
+	if err := g.Check(ctx, "bob@example.com", ip); err != nil {
+		t.Errorf("another account from the same IP: %v", err)
+	}
+	if len(outbox.events) != 1 || outbox.events[0].Payload["action"] != "login_locked" {
+		t.Errorf("activity %v, want one login_locked entry", outbox.events)
+	}
+}
+
+func TestLoginGuardBackoff(t *testing.T) {
+	ctx := context.Background()
+	g, repo, _ := newTestGuard(testGuardConfig)
+	const email, ip = "ann@example.com", "192.0.2.1"
+	for i := 1; i < testGuardConfig.MaxFailedAttempts; i++ {
+		g.RecordFailure(ctx, email, ip)
+	}
+	// base, 2×base, 4×base, then capped at LockoutMax.
+	for _, want := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute} {
+		g.RecordFailure(ctx, email, ip)
+		if got := repo.lockedFor(models.LockoutScopeAccount, email); !near(got, want) {
+			t.Errorf("lockout %s, want %s", got, want)
+		}
+	}
+
+	for excess, want := range map[int]time.Duration{0: time.Minute, 1: 2 * time.Minute, 2: 4 * time.Minute, 3: 5 * time.Minute, 62: 5 * time.Minute, 1000: 5 * time.Minute} {
+		if got := g.lockoutDuration(excess); got != want {
+			t.Errorf("lockoutDuration(%d) = %s, want %s", excess, got, want)
+		}
+	}
+	uncapped := &LoginGuardService{cfg: LoginGuardConfig{LockoutBase: time.Hour}}
+	if got := uncapped.lockoutDuration(1000); got != time.Hour<<maxLockoutShift {
+		t.Errorf("uncapped lockoutDuration(1000) = %s, want the shift bounded at %d", got, maxLockoutShift)
+	}
+}
+
+func TestLoginGuardWindow(t *testing.T) {
+	ctx := context.Background()
+	g, repo, _ := newTestGuard(testGuardConfig)
+	const email, ip = "ann@example.com", "192.0.2.1"
+	window := testGuardConfig.FailureWindow
+
+	// Failures spread over more than the window never add up to a lockout.
+	for i := 0; i < 2*testGuardConfig.MaxFailedAttempts; i++ {
+		g.RecordFailure(ctx, email, ip)
+		repo.age(models.LockoutScopeAccount, email, window+time.Second)
+		repo.age(models.LockoutScopeIP, ip, window+time.Second)
+	}
+	if err := g.Check(ctx, email, ip); err != nil {
+		t.Fatalf("failures outside the window locked: %v", err)
+	}
+	if l, _ := repo.Get(ctx, models.LockoutScopeAccount, email); l.FailedCount != 1 {
+		t.Errorf("counter %d after an expired window, want 1", l.FailedCount)
+	}
+
+	// A lock that ended within the window keeps the counter, so the next failure locks again, for longer.
+	for i := 0; i < testGuardConfig.MaxFailedAttempts; i++ {
+		g.RecordFailure(ctx, email, ip)
+	}
+	if got := repo.lockedFor(models.LockoutScopeAccount, email); !near(got, time.Minute) {
+		t.Fatalf("lockout %s, want %s", got, time.Minute)
+	}
+	repo.age(models.LockoutScopeAccount, email, 2*time.Minute)
+	if err := g.Check(ctx, email, ip); err != nil {
+		t.Fatalf("lock still in effect after it ended: %v", err)
+	}
+	g.RecordFailure(ctx, email, ip)
+	if got := repo.lockedFor(models.LockoutScopeAccount, email); !near(got, 2*time.Minute) {
+		t.Errorf("lockout after a lock ended in the window: %s, want %s", got, 2*time.Minute)
+	}
+}
+
+func TestLoginGuardIP(t *testing.T) {
+	ctx := context.Background()
+	g, _, _ := newTestGuard(testGuardConfig)
+	const ip = "192.0.2.1"
+	// One failure each for many addresses, known or not, exhausts the IP budget.
+	for i := 0; i < testGuardConfig.IPMaxFailedAttempts; i++ {
+		g.RecordFailure(ctx, uuid.NewString()+"@example.com", ip)
+	}
+	var locked *LoginLockedError
+	if err := g.Check(ctx, "new@example.com", ip); !errors.As(err, &locked) {
+		t.Errorf("IP over its budget: got %v, want locked", err)
+	}
+	if err := g.Check(ctx, "new@example.com", "198.51.100.7"); err != nil {
+		t.Errorf("the same email from another IP: %v", err)
+	}
+
+	// RecordSuccess clears the account counter only; the IP stays locked.
+	g.RecordSuccess(ctx, "new@example.com")
+	if err := g.Check(ctx, "new@example.com", ip); !errors.As(err, &locked) {
+		t.Errorf("RecordSuccess unlocked the IP: got %v", err)
+	}
+}
+
+func TestLoginGuardRecordSuccess(t *testing.T) {
+	ctx := context.Background()
+	g, repo, _ := newTestGuard(testGuardConfig)
+	const email, ip = "ann@example.com", "192.0.2.1"
+	for i := 1; i < testGuardConfig.MaxFailedAttempts; i++ {
+		g.RecordFailure(ctx, email, ip)
+	}
+	g.RecordSuccess(ctx, " ANN@example.com")
+	if _, err := repo.Get(ctx, models.LockoutScopeAccount, email); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("account counter kept after a success: %v", err)
+	}
+	l, err := repo.Get(ctx, models.LockoutScopeIP, ip)
+	if err != nil || l.FailedCount != testGuardConfig.MaxFailedAttempts-1 {
+		t.Errorf("IP counter %v, %v; want it untouched", l, err)
+	}
+
+	// The account counts from zero again.
+	for i := 1; i < testGuardConfig.MaxFailedAttempts; i++ {
+		g.RecordFailure(ctx, email, "198.51.100.7")
+	}
+	if err := g.Check(ctx, email, "198.51.100.7"); err != nil {
+		t.Errorf("locked before reaching the threshold again: %v", err)
+	}
+}
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rm/roadmap/backend/internal/i18n"
+	"github.com/rm/roadmap/backend/internal/models"
+	"github.com/rm/roadmap/backend/internal/repositories"
+	"go.uber.org/zap"
+)
+
+var (
+	milestoneRemindersTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "milestone_reminders_sent_total",
+			Help: "Total number of milestone reminders and overdue alerts sent, by kind (start, end or overdue)",
+		},
+		[]string{"kind"},
+	)
+	milestoneReminderLeader = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "milestone_reminder_leader",
+		Help: "1 when this instance holds the reminder scheduler lock, 0 otherwise",
+	})
+)
+
+type MilestoneReminderConfig struct {
+	Interval            time.Duration // how often due reminders are checked; 0 disables the scheduler
+	DaysBefore          []int         // lead times of start and end reminders, e.g. 7 and 1
+	OverdueLookbackDays int           // milestones that ended longer ago than this get no overdue alert
+}
+
+// MilestoneReminderService reminds product owners, co-owners and watchers of milestones that start or end soon and
+// alerts them about milestones past their end date that are not completed. Only the replica holding the
+// advisory lock runs it, and each reminder is recorded before it is sent, so it fires once per milestone
+// date even across restarts and leader changes.
+type MilestoneReminderService struct {
+	repo            repositories.MilestoneReminderRepository
+	memberRepo      repositories.ProductMemberRepository
+	watchRepo       repositories.WatchRepository
+	notificationSvc *NotificationService
+	lock            *repositories.AdvisoryLock
+	cfg             MilestoneReminderConfig
+	log             *zap.Logger
+}
+
+func NewMilestoneReminderService(repo repositories.MilestoneReminderRepository, memberRepo repositories.ProductMemberRepository, watchRepo repositories.WatchRepository, notificationSvc *NotificationService, lock *repositories.AdvisoryLock, cfg MilestoneReminderConfig, log *zap.Logger) *MilestoneReminderService {
+	days := slices.DeleteFunc(slices.Clone(cfg.DaysBefore), func(d int) bool { return d < 0 })
+	slices.Sort(days)
+	cfg.DaysBefore = slices.Compact(days)
+	if cfg.OverdueLookbackDays < 0 {
+		cfg.OverdueLookbackDays = 0
+	}
+	if log == nil {
+		log = zap.NewNop()
+	}
+	return &MilestoneReminderService{repo: repo, memberRepo: memberRepo, watchRepo: watchRepo, notificationSvc: notificationSvc, lock: lock, cfg: cfg, log: log}
+}
+
+// ParseReminderDays splits a comma-separated list of lead times in days such as "7,1".
+func ParseReminderDays(s string) ([]int, error) {
+	var out []int
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+		d, err := strconv.Atoi(v)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid reminder lead time %q", v)
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+// Start checks for due reminders every Interval while this replica is the leader, until ctx is done.
+func (s *MilestoneReminderService) Start(ctx context.Context) {
+	if s.cfg.Interval <= 0 {
+		return
+	}
+	defer func() {
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		s.lock.Release(releaseCtx)
+		milestoneReminderLeader.Set(0)
+	}()
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		leader, err := s.lock.Hold(ctx)
+		if err != nil && ctx.Err() == nil {
+			s.log.Warn("milestone reminders: leader election failed", zap.Error(err))
+		}
+		if leader {
+			milestoneReminderLeader.Set(1)
+			if n, err := s.Run(ctx, time.Now()); err != nil {
+				if ctx.Err() == nil {
+					s.log.Error("milestone reminders run failed", zap.Error(err))
+				}
+			} else if n > 0 {
+				s.log.Info("milestone reminders sent", zap.Int("count", n))
+			}
+		} else {
+			milestoneReminderLeader.Set(0)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Run sends the reminders and overdue alerts due at now and returns how many it sent. Dates are compared
+// as UTC calendar days. For each date only the shortest lead time not yet passed fires, so a milestone
+// created three days ahead gets the 7-day reminder ("in 3 days") and later the 1-day one.
+func (s *MilestoneReminderService) Run(ctx context.Context, now time.Time) (int, error) {
+	today := utcDay(now)
+	horizon := 0
+	if n := len(s.cfg.DaysBefore); n > 0 {
+		horizon = s.cfg.DaysBefore[n-1]
+	}
+	from := today.AddDate(0, 0, -s.cfg.OverdueLookbackDays)
+	to := today.AddDate(0, 0, horizon+1).Add(-time.Nanosecond)
+	list, err := s.repo.ListOpen(ctx, from, to)
+	if err != nil {
+		return 0, err
+	}
+	sent := 0
+	for i := range list {
+		if ctx.Err() != nil {
+			return sent, ctx.Err()
+		}
+		m := &list[i]
+		start := utcDay(m.StartDate)
+		if days, ok := s.leadTime(today, start); ok {
+			if s.remind(ctx, m, models.MilestoneReminderStart, start, days, today, now) {
+				sent++
+			}
+		}
+		if m.EndDate == nil {
+			continue
+		}
+		end := utcDay(*m.EndDate)
+		if days, ok := s.leadTime(today, end); ok && !end.Equal(start) {
+			if s.remind(ctx, m, models.MilestoneReminderEnd, end, days, today, now) {
+				sent++
+			}
+		} else if end.Before(today) && !end.Before(from) {
+			if s.remind(ctx, m, models.MilestoneReminderOverdue, end, 0, today, now) {
+				sent++
+			}
+		}
+	}
+	return sent, nil
+}
+
+// leadTime returns the configured lead time a reminder for date is due under: the shortest one at least as
+// long as the days left.
+func (s *MilestoneReminderService) leadTime(today, date time.Time) (int, bool) {
+	left := int(date.Sub(today).Hours() / 24)
+	if left < 0 {
+		return 0, false
+	}
+	for _, d := range s.cfg.DaysBefore {
+		if left <= d {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// remind claims the reminder and notifies the milestone's recipients; it reports whether it was sent.
+// Recipients are resolved before the claim, so a failed lookup leaves the reminder for the next run.
+func (s *MilestoneReminderService) remind(ctx context.Context, m *models.Milestone, kind string, date time.Time, daysBefore int, today, now time.Time) bool {
+	log := s.log.With(zap.String("milestone_id", m.ID.String()), zap.String("kind", kind), zap.Time("date", date))
+	recipients, err := s.recipients(ctx, m)
+	if err != nil {
+		log.Error("milestone reminder recipients failed", zap.Error(err))
+		return false
+	}
+	claimed, err := s.repo.Claim(ctx, &models.MilestoneReminder{MilestoneID: m.ID, Kind: kind, Date: date, DaysBefore: daysBefore, SentAt: now})
+	if err != nil {
+		log.Error("milestone reminder claim failed", zap.Error(err))
+		return false
+	}
+	if !claimed {
+		return false
+	}
+	notifType, title, message := milestoneReminderText(m, kind, date, today)
+	for _, userID := range recipients {
+		if _, err := s.notificationSvc.Create(userID, notifType, title, message, "product", &m.ProductID); err != nil {
+			log.Error("milestone reminder notification failed", zap.Error(err), zap.String("user_id", userID.String()))
+		}
+	}
+	milestoneRemindersTotal.WithLabelValues(kind).Inc()
+	return true
+}
+
+// recipients are the owner and co-owners of the milestone's product and the watchers of the product, the
+// milestone's version or a group the product is in.
+func (s *MilestoneReminderService) recipients(ctx context.Context, m *models.Milestone) ([]uuid.UUID, error) {
+	p := &m.Product
+	var ids []uuid.UUID
+	if p.OwnerID != nil {
+		ids = append(ids, *p.OwnerID)
+	}
+	members, err := s.memberRepo.ListByProduct(ctx, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, pm := range members {
+		if pm.Role == models.ProductMemberCoOwner && !slices.Contains(ids, pm.UserID) {
+			ids = append(ids, pm.UserID)
+		}
+	}
+	watchers, err := s.watchRepo.Watchers(ctx, p.ID, m.ProductVersionID)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range watchers {
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func milestoneReminderText(m *models.Milestone, kind string, date, today time.Time) (notifType string, title, message i18n.Message) {
+	params := []string{"milestone", m.Label, "product", m.Product.Name, "date", date.Format("2006-01-02")}
+	if kind == models.MilestoneReminderOverdue {
+		return models.NotificationTypeMilestoneOverdue,
+			i18n.M("notification.milestone_overdue.title", params...),
+			i18n.M("notification.milestone_overdue.message", params...)
+	}
+	key := "notification.milestone_reminder.start"
+	if kind == models.MilestoneReminderEnd {
+		key = "notification.milestone_reminder.end"
+	}
+	when := ".title_today"
+	switch left := int(date.Sub(today).Hours() / 24); left {
+	case 0:
+	case 1:
+		when = ".title_tomorrow"
+	default:
+		when = ".title_days"
+		params = append(params, "days", strconv.Itoa(left))
+	}
+	return models.NotificationTypeMilestoneReminder,
+		i18n.M(key+when, params...),
+		i18n.M(key+".message", params...)
+}
+
+// utcDay is the UTC calendar day of t, at midnight.
+func utcDay(t time.Time) time.Time {
+	y, mo, d := t.UTC().Date()
+	return time.Date(y, mo, d, 0, 0, 0, 0, time.UTC)
+}
@@ -7,8 +7,10 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/authz"
 	"github.com/rm/roadmap/backend/internal/dto"
 	"github.com/rm/roadmap/backend/internal/models"
+	"github.com/rm/roadmap/backend/internal/realtime"
 	"github.com/rm/roadmap/backend/internal/repositories"
 )
 
@@ -19,39 +21,69 @@ type MilestoneService struct {
 	milestoneRepo repositories.MilestoneRepository
 	productRepo   repositories.ProductRepository
 	depRepo       repositories.DependencyRepository
+	memberRepo    repositories.ProductMemberRepository
+	tx            repositories.Transactor
 	auditSvc      *AuditService
 	activitySvc   *ActivityService
+	policy        *authz.Engine
+	watchSvc      *WatchService
+	hub           *realtime.Hub
 }
 
 func NewMilestoneService(
 	milestoneRepo repositories.MilestoneRepository,
 	productRepo repositories.ProductRepository,
 	depRepo repositories.DependencyRepository,
+	memberRepo repositories.ProductMemberRepository,
+	tx repositories.Transactor,
 	auditSvc *AuditService,
 	activitySvc *ActivityService,
+	policy *authz.Engine,
+	watchSvc *WatchService,
+	hub *realtime.Hub,
 ) *MilestoneService {
 	return &MilestoneService{
 		milestoneRepo: milestoneRepo,
 		productRepo:   productRepo,
 		depRepo:       depRepo,
+		memberRepo:    memberRepo,
+		tx:            tx,
 		auditSvc:      auditSvc,
 		activitySvc:   activitySvc,
+		policy:        policy,
+		watchSvc:      watchSvc,
+		hub:           hub,
 	}
 }
 
+// canWrite checks milestone:write for the product. A grant scoped to own products covers
+// co-owners and editors too, and only applies while the product is active.
+func (s *MilestoneService) canWrite(ctx context.Context, productID, callerID uuid.UUID, callerRole models.Role) error {
+	sub := authz.Subject{UserID: callerID, Role: callerRole}
+	if s.policy.Allowed(ctx, sub, authz.PermMilestoneWrite, authz.Any) {
+		return nil
+	}
+	p, err := s.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		return err
+	}
+	res, err := productResource(ctx, s.memberRepo, p, productEditorRoles...)
+	if err != nil {
+		return err
+	}
+	if err := s.policy.Authorize(ctx, sub, authz.PermMilestoneWrite, res); err != nil {
+		return err
+	}
+	if p.LifecycleStatus != models.LifecycleActive {
+		return ErrForbidden
+	}
+	return nil
+}
+
 func (s *MilestoneService) Create(ctx context.Context, req dto.MilestoneCreateRequest, callerID uuid.UUID, callerRole models.Role, meta dto.AuditMeta) (*dto.MilestoneResponse, error) {
 	productID, _ := uuid.Parse(req.ProductID)
-	if callerRole == models.RoleOwner {
-		p, err := s.productRepo.GetByID(productID)
-		if err != nil {
-			return nil, err
-		}
-		if p.LifecycleStatus != models.LifecycleActive {
-			return nil, ErrForbidden
-		}
-		if p.OwnerID == nil || *p.OwnerID != callerID {
-			return nil, ErrForbidden
-		}
+	if err := s.canWrite(ctx, productID, callerID, callerRole); err != nil {
+		return nil, err
 	}
 	var endDate *time.Time
 	if !req.EndDate.IsZero() {
@@ -98,33 +130,46 @@ func (s *MilestoneService) Create(ctx context.Context, req dto.MilestoneCreateRe
 			return nil, ErrCertifyRequiresTestedSuccessfully
 		}
 	}
-	if err := s.milestoneRepo.Create(m); err != nil {
+	readiness := s.watchSvc.DependencyReadiness(ctx, productID)
+	var resp *dto.MilestoneResponse
+	if err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.milestoneRepo.Create(ctx, m); err != nil {
+			return err
+		}
+		resp = milestoneToResponse(m)
+		if s.auditSvc != nil {
+			if err := s.auditSvc.Log(ctx, AuditEntry{
+				UserID:     meta.UserID,
+				Action:     "create",
+				EntityType: "milestone",
+				EntityID:   m.ID.String(),
+				NewData:    ToJSONB(resp),
+				IPAddress:  meta.IP,
+				UserAgent:  meta.UserAgent,
+				TraceID:    meta.TraceID,
+			}); err != nil {
+				return err
+			}
+		}
+		if s.activitySvc != nil && meta.UserID != nil {
+			if err := s.activitySvc.Log(ctx, ActivityEntry{
+				UserID:     meta.UserID,
+				Action:     "create",
+				EntityType: "milestone",
+				EntityID:   m.ID.String(),
+				Details:    m.Label,
+				IPAddress:  meta.IP,
+				UserAgent:  meta.UserAgent,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
 		return nil, err
 	}
-	resp := milestoneToResponse(m)
-	if s.auditSvc != nil {
-		s.auditSvc.Log(ctx, AuditEntry{
-			UserID:     meta.UserID,
-			Action:     "create",
-			EntityType: "milestone",
-			EntityID:   m.ID.String(),
-			NewData:    ToJSONB(resp),
-			IPAddress:  meta.IP,
-			UserAgent:  meta.UserAgent,
-			TraceID:    meta.TraceID,
-		})
-	}
-	if s.activitySvc != nil && meta.UserID != nil {
-		s.activitySvc.Log(ctx, ActivityEntry{
-			UserID:     meta.UserID,
-			Action:     "create",
-			EntityType: "milestone",
-			EntityID:   m.ID.String(),
-			Details:    m.Label,
-			IPAddress:  meta.IP,
-			UserAgent:  meta.UserAgent,
-		})
-	}
+	s.publish(ctx, "create", productID, resp)
+	s.watchSvc.MilestoneChanged(ctx, "create", m, readiness, meta.UserID)
 	return resp, nil
 }
 
@@ -153,17 +198,8 @@ func (s *MilestoneService) Update(ctx context.Context, id uuid.UUID, req dto.Mil
 	if err != nil {
 		return nil, err
 	}
-	if callerRole == models.RoleOwner {
-		p, err := s.productRepo.GetByID(m.ProductID)
-		if err != nil {
-			return nil, err
-		}
-		if p.LifecycleStatus != models.LifecycleActive {
-			return nil, ErrForbidden
-		}
-		if p.OwnerID == nil || *p.OwnerID != callerID {
-			return nil, ErrForbidden
-		}
+	if err := s.canWrite(ctx, m.ProductID, callerID, callerRole); err != nil {
+		return nil, err
 	}
 	oldResp := milestoneToResponse(m)
 	applyMilestoneUpdate(m, req)
@@ -196,40 +232,53 @@ func (s *MilestoneService) Update(ctx context.Context, id uuid.UUID, req dto.Mil
 			return nil, ErrCertifyRequiresTestedSuccessfully
 		}
 	}
-	if err := s.milestoneRepo.Update(m); err != nil {
+	readiness := s.watchSvc.DependencyReadiness(ctx, m.ProductID)
+	var newResp *dto.MilestoneResponse
+	if err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.milestoneRepo.Update(ctx, m); err != nil {
+			return err
+		}
+		// TODO: auto-reschedule dependents when dates change
+		_ = s.rescheduleDependents(ctx, m)
+		newResp = milestoneToResponse(m)
+		if s.auditSvc != nil {
+			if err := s.auditSvc.Log(ctx, AuditEntry{
+				UserID:     meta.UserID,
+				Action:     "update",
+				EntityType: "milestone",
+				EntityID:   id.String(),
+				OldData:    ToJSONB(oldResp),
+				NewData:    ToJSONB(newResp),
+				IPAddress:  meta.IP,
+				UserAgent:  meta.UserAgent,
+				TraceID:    meta.TraceID,
+			}); err != nil {
+				return err
+			}
+		}
+		if s.activitySvc != nil && meta.UserID != nil {
+			if err := s.activitySvc.Log(ctx, ActivityEntry{
+				UserID:     meta.UserID,
+				Action:     "save",
+				EntityType: "milestone",
+				EntityID:   id.String(),
+				Details:    m.Label,
+				IPAddress:  meta.IP,
+				UserAgent:  meta.UserAgent,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
 		return nil, err
 	}
-	// TODO: auto-reschedule dependents when dates change
-	_ = s.rescheduleDependents(m)
-	newResp := milestoneToResponse(m)
-	if s.auditSvc != nil {
-		s.auditSvc.Log(ctx, AuditEntry{
-			UserID:     meta.UserID,
-			Action:     "update",
-			EntityType: "milestone",
-			EntityID:   id.String(),
-			OldData:    ToJSONB(oldResp),
-			NewData:    ToJSONB(newResp),
-			IPAddress:  meta.IP,
-			UserAgent:  meta.UserAgent,
-			TraceID:    meta.TraceID,
-		})
-	}
-	if s.activitySvc != nil && meta.UserID != nil {
-		s.activitySvc.Log(ctx, ActivityEntry{
-			UserID:     meta.UserID,
-			Action:     "save",
-			EntityType: "milestone",
-			EntityID:   id.String(),
-			Details:    m.Label,
-			IPAddress:  meta.IP,
-			UserAgent:  meta.UserAgent,
-		})
-	}
+	s.publish(ctx, "update", m.ProductID, newResp)
+	s.watchSvc.MilestoneChanged(ctx, "update", m, readiness, meta.UserID)
 	return newResp, nil
 }
 
-func (s *MilestoneService) rescheduleDependents(m *models.Milestone) error {
+func (s *MilestoneService) rescheduleDependents(ctx context.Context, m *models.Milestone) error {
 	if m.EndDate == nil {
 		return nil
 	}
@@ -272,66 +321,80 @@ func (s *MilestoneService) rescheduleDependents(m *models.Milestone) error {
 		}
 		target.StartDate = newStart
 		target.EndDate = &newEnd
-		_ = s.milestoneRepo.Update(target)
+		_ = s.milestoneRepo.Update(ctx, target)
 	}
 	return nil
 }
 
 func (s *MilestoneService) Delete(ctx context.Context, id uuid.UUID, callerID uuid.UUID, callerRole models.Role, meta dto.AuditMeta) error {
-	if callerRole == models.RoleOwner {
-		m, err := s.milestoneRepo.GetByID(id)
-		if err != nil {
-			return err
-		}
-		p, err := s.productRepo.GetByID(m.ProductID)
-		if err != nil {
-			return err
-		}
-		if p.LifecycleStatus != models.LifecycleActive {
-			return ErrForbidden
-		}
-		if p.OwnerID == nil || *p.OwnerID != callerID {
-			return ErrForbidden
-		}
+	m, err := s.milestoneRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.canWrite(ctx, m.ProductID, callerID, callerRole); err != nil {
+		return err
 	}
-	m, _ := s.milestoneRepo.GetByID(id)
 	oldData := models.JSONB(nil)
 	if m != nil {
 		oldData = ToJSONB(milestoneToResponse(m))
 	}
-	if err := s.milestoneRepo.Delete(id); err != nil {
-		return err
-	}
-	if s.auditSvc != nil {
-		s.auditSvc.Log(ctx, AuditEntry{
-			UserID:     meta.UserID,
-			Action:     "delete",
-			EntityType: "milestone",
-			EntityID:   id.String(),
-			OldData:    oldData,
-			IPAddress:  meta.IP,
-			UserAgent:  meta.UserAgent,
-			TraceID:    meta.TraceID,
-		})
-	}
-	if s.activitySvc != nil && meta.UserID != nil {
-		details := ""
-		if m != nil {
-			details = m.Label
+	readiness := s.watchSvc.DependencyReadiness(ctx, m.ProductID)
+	if err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.milestoneRepo.Delete(ctx, id); err != nil {
+			return err
+		}
+		if s.auditSvc != nil {
+			if err := s.auditSvc.Log(ctx, AuditEntry{
+				UserID:     meta.UserID,
+				Action:     "delete",
+				EntityType: "milestone",
+				EntityID:   id.String(),
+				OldData:    oldData,
+				IPAddress:  meta.IP,
+				UserAgent:  meta.UserAgent,
+				TraceID:    meta.TraceID,
+			}); err != nil {
+				return err
+			}
+		}
+		if s.activitySvc != nil && meta.UserID != nil {
+			details := ""
+			if m != nil {
+				details = m.Label
+			}
+			if err := s.activitySvc.Log(ctx, ActivityEntry{
+				UserID:     meta.UserID,
+				Action:     "delete",
+				EntityType: "milestone",
+				EntityID:   id.String(),
+				Details:    details,
+				IPAddress:  meta.IP,
+				UserAgent:  meta.UserAgent,
+			}); err != nil {
+				return err
+			}
 		}
-		s.activitySvc.Log(ctx, ActivityEntry{
-			UserID:     meta.UserID,
-			Action:     "delete",
-			EntityType: "milestone",
-			EntityID:   id.String(),
-			Details:    details,
-			IPAddress:  meta.IP,
-			UserAgent:  meta.UserAgent,
-		})
+		return nil
+	}); err != nil {
+		return err
 	}
+	s.publish(ctx, "delete", m.ProductID, map[string]string{"id": id.String(), "product_id": m.ProductID.String()})
+	s.watchSvc.MilestoneChanged(ctx, "delete", m, readiness, meta.UserID)
 	return nil
 }
 
+// publish tells the clients that can see the milestone's product about the change.
+func (s *MilestoneService) publish(ctx context.Context, action string, productID uuid.UUID, data interface{}) {
+	if s.hub == nil {
+		return
+	}
+	var owners []*uuid.UUID
+	if p, err := s.productRepo.GetByID(ctx, productID); err == nil {
+		owners = append(owners, p.OwnerID)
+	}
+	publishProductEvent(ctx, s.hub, realtime.EventMilestone, action, productID, owners, data)
+}
+
 func applyMilestoneUpdate(m *models.Milestone, req dto.MilestoneUpdateRequest) {
 	if req.Label != nil {
 		m.Label = *req.Label
@@ -351,6 +414,14 @@ func applyMilestoneUpdate(m *models.Milestone, req dto.MilestoneUpdateRequest) {
 	if req.Extra != nil {
 		m.Extra = models.JSONB(req.Extra)
 	}
+	if req.Completed != nil {
+		if !*req.Completed {
+			m.CompletedAt = nil
+		} else if m.CompletedAt == nil {
+			now := time.Now()
+			m.CompletedAt = &now
+		}
+	}
 }
 
 func milestoneToResponse(m *models.Milestone) *dto.MilestoneResponse {
@@ -366,6 +437,9 @@ func milestoneToResponse(m *models.Milestone) *dto.MilestoneResponse {
 	if m.EndDate != nil {
 		resp.EndDate = m.EndDate.Format("2006-01-02")
 	}
+	if m.CompletedAt != nil {
+		resp.CompletedAt = m.CompletedAt.Format("2006-01-02T15:04:05Z07:00")
+	}
 	if m.ProductVersionID != nil {
 		s := m.ProductVersionID.String()
 		resp.ProductVersionID = &s
@@ -0,0 +1,490 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rm/roadmap/backend/internal/dto"
+	"github.com/rm/roadmap/backend/internal/mail"
+	"github.com/rm/roadmap/backend/internal/models"
+	"github.com/rm/roadmap/backend/internal/repositories"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	notificationEmailsSentTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_emails_sent_total",
+			Help: "Total number of notification emails sent, by kind (immediate or digest)",
+		},
+		[]string{"kind"},
+	)
+	notificationEmailErrorsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_email_errors_total",
+			Help: "Total number of failed notification email attempts, by result (retry or failed)",
+		},
+		[]string{"result"},
+	)
+	notificationEmailPending = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "notification_email_pending",
+			Help: "Notification emails waiting to be sent, by kind (immediate or digest)",
+		},
+		[]string{"kind"},
+	)
+)
+
+const (
+	// emailRetryBase is the wait after the first failed attempt; it doubles per attempt up to emailRetryMax.
+	emailRetryBase = time.Minute
+	emailRetryMax  = 6 * time.Hour
+	// emailLease is how long a claimed delivery is hidden from other instances while it is sent.
+	emailLease = 5 * time.Minute
+	// emailSendTimeout bounds one SMTP transaction.
+	emailSendTimeout = time.Minute
+)
+
+var (
+	ErrInvalidEmailFrequency   = errors.New("frequency must be immediate, daily, weekly or off")
+	ErrInvalidUnsubscribeToken = errors.New("invalid unsubscribe link")
+)
+
+type NotificationEmailConfig struct {
+	BaseURL      string        // public URL of the app, for links in email
+	PollInterval time.Duration // how often the queue is checked when not woken
+	BatchSize    int           // deliveries claimed per poll
+	MaxAttempts  int           // failed attempts before a delivery is given up
+	Secret       string        // signs unsubscribe links
+}
+
+// NotificationEmailService delivers notifications by email. NotificationService.Create queues a delivery
+// row; Run, in the background, sends due deliveries one message each and each user's digest deliveries as
+// one daily or weekly message, retrying failures with exponential backoff. Request handlers therefore only
+// ever insert a row, however slow the SMTP relay is. Claims use row leases and conditional updates, so
+// several instances can run the sender side by side.
+type NotificationEmailService struct {
+	repo      repositories.NotificationDeliveryRepository
+	notifRepo repositories.NotificationRepository
+	userRepo  repositories.UserRepository
+	tx        repositories.Transactor
+	sender    mail.Sender // nil = email disabled; settings can still be changed
+	renderer  *mail.Renderer
+	cfg       NotificationEmailConfig
+	key       []byte
+	log       *zap.Logger
+	wake      chan struct{}
+}
+
+func NewNotificationEmailService(repo repositories.NotificationDeliveryRepository, notifRepo repositories.NotificationRepository, userRepo repositories.UserRepository, tx repositories.Transactor, sender mail.Sender, cfg NotificationEmailConfig, log *zap.Logger) (*NotificationEmailService, error) {
+	renderer, err := mail.NewRenderer()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 10 * time.Second
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 50
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 8
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	if log == nil {
+		log = zap.NewNop()
+	}
+	key := sha256.Sum256([]byte("roadmap unsubscribe key\x00" + cfg.Secret))
+	return &NotificationEmailService{
+		repo: repo, notifRepo: notifRepo, userRepo: userRepo, tx: tx, sender: sender, renderer: renderer,
+		cfg: cfg, key: key[:], log: log, wake: make(chan struct{}, 1),
+	}, nil
+}
+
+// Enabled reports whether email is sent at all.
+func (s *NotificationEmailService) Enabled() bool { return s.sender != nil }
+
+// Enqueue queues n for email according to the recipient's frequency. Mandatory notifications are sent
+// immediately even when the user turned email off. A failure is logged rather than returned: the in-app
+// notification already exists and must not be reported as failed.
+func (s *NotificationEmailService) Enqueue(ctx context.Context, n *models.Notification, mandatory bool) {
+	if s.sender == nil {
+		return
+	}
+	freq, err := s.frequency(ctx, n.UserID)
+	if err != nil {
+		s.log.Warn("notification email: reading settings failed", zap.Error(err), zap.String("user_id", n.UserID.String()))
+		freq = models.EmailFrequencyImmediate
+	}
+	if freq == models.EmailFrequencyOff && !mandatory {
+		return
+	}
+	d := &models.NotificationDelivery{
+		NotificationID: n.ID,
+		UserID:         n.UserID,
+		Channel:        models.DeliveryChannelEmail,
+		Digest:         isDigest(freq),
+		Status:         models.DeliveryStatusPending,
+		NextAttemptAt:  time.Now(),
+	}
+	if err := s.repo.Enqueue(ctx, d); err != nil {
+		s.log.Error("notification email: enqueue failed", zap.Error(err), zap.String("notification_id", n.ID.String()))
+		return
+	}
+	if !d.Digest {
+		s.notify()
+	}
+}
+
+func (s *NotificationEmailService) frequency(ctx context.Context, userID uuid.UUID) (string, error) {
+	st, err := s.repo.GetEmailSetting(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.EmailFrequencyImmediate, nil
+	} else if err != nil {
+		return "", err
+	}
+	return st.Frequency, nil
+}
+
+func (s *NotificationEmailService) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Settings returns the user's email frequency.
+func (s *NotificationEmailService) Settings(ctx context.Context, userID uuid.UUID) (*dto.NotificationEmailSettingsResponse, error) {
+	freq, err := s.frequency(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.NotificationEmailSettingsResponse{Frequency: freq, EmailEnabled: s.Enabled()}, nil
+}
+
+// UpdateSettings sets the user's email frequency and moves their queued email along: switching to a
+// digest holds pending email for it, switching to immediate releases held email now, and off cancels it.
+// The first digest goes out one period after it is chosen.
+func (s *NotificationEmailService) UpdateSettings(ctx context.Context, userID uuid.UUID, frequency string) (*dto.NotificationEmailSettingsResponse, error) {
+	switch frequency {
+	case models.EmailFrequencyImmediate, models.EmailFrequencyDaily, models.EmailFrequencyWeekly, models.EmailFrequencyOff:
+	default:
+		return nil, ErrInvalidEmailFrequency
+	}
+	now := time.Now()
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		st, err := s.repo.GetEmailSetting(ctx, userID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			st = &models.NotificationEmailSetting{UserID: userID, Frequency: models.EmailFrequencyImmediate}
+		} else if err != nil {
+			return err
+		}
+		if !isDigest(st.Frequency) {
+			st.LastDigestAt = now
+		}
+		st.Frequency = frequency
+		if err := s.repo.SaveEmailSetting(ctx, st); err != nil {
+			return err
+		}
+		return s.repo.Reroute(ctx, userID, models.DeliveryChannelEmail, frequency, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if frequency == models.EmailFrequencyImmediate {
+		s.notify()
+	}
+	return &dto.NotificationEmailSettingsResponse{Frequency: frequency, EmailEnabled: s.Enabled()}, nil
+}
+
+func isDigest(frequency string) bool {
+	return frequency == models.EmailFrequencyDaily || frequency == models.EmailFrequencyWeekly
+}
+
+// Unsubscribe turns off notification email for the user the token was issued to.
+func (s *NotificationEmailService) Unsubscribe(ctx context.Context, token string) error {
+	userID, ok := s.verifyUnsubscribeToken(token)
+	if !ok {
+		return ErrInvalidUnsubscribeToken
+	}
+	_, err := s.UpdateSettings(ctx, userID, models.EmailFrequencyOff)
+	return err
+}
+
+// unsubscribeToken is the user ID followed by a truncated HMAC of it. It does not expire, as unsubscribe
+// links in old email must keep working; all it can do is turn email off.
+func (s *NotificationEmailService) unsubscribeToken(userID uuid.UUID) string {
+	return base64.RawURLEncoding.EncodeToString(append(userID[:], s.unsubscribeMAC(userID)...))
+}
+
+func (s *NotificationEmailService) verifyUnsubscribeToken(token string) (uuid.UUID, bool) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(b) != 32 {
+		return uuid.Nil, false
+	}
+	userID, _ := uuid.FromBytes(b[:16])
+	return userID, hmac.Equal(b[16:], s.unsubscribeMAC(userID))
+}
+
+func (s *NotificationEmailService) unsubscribeMAC(userID uuid.UUID) []byte {
+	m := hmac.New(sha256.New, s.key)
+	m.Write([]byte("unsubscribe\x00"))
+	m.Write(userID[:])
+	return m.Sum(nil)[:16]
+}
+
+// Run sends queued email until ctx is cancelled.
+func (s *NotificationEmailService) Run(ctx context.Context) {
+	t := time.NewTicker(s.cfg.PollInterval)
+	defer t.Stop()
+	for {
+		for ctx.Err() == nil {
+			n, err := s.sendDue(ctx)
+			if err != nil {
+				s.log.Warn("notification email: claiming deliveries failed", zap.Error(err))
+				break
+			}
+			if n < s.cfg.BatchSize {
+				break
+			}
+		}
+		if err := s.sendDigests(ctx); err != nil && ctx.Err() == nil {
+			s.log.Warn("notification email: digest run failed", zap.Error(err))
+		}
+		s.refreshGauges(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-t.C:
+		}
+	}
+}
+
+// sendDue sends one batch of due immediate deliveries and returns how many it claimed.
+func (s *NotificationEmailService) sendDue(ctx context.Context) (int, error) {
+	list, err := s.repo.ClaimDue(ctx, models.DeliveryChannelEmail, time.Now(), emailLease, s.cfg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	for i := range list {
+		if ctx.Err() != nil {
+			break // unsent claims are retried when their lease runs out
+		}
+		s.sendOne(ctx, &list[i])
+	}
+	return len(list), nil
+}
+
+func (s *NotificationEmailService) sendOne(ctx context.Context, d *models.NotificationDelivery) {
+	ids := []int64{d.ID}
+	u, ok := s.recipient(ctx, d.UserID, ids)
+	if !ok {
+		return
+	}
+	n, err := s.notifRepo.GetByID(d.NotificationID, d.UserID)
+	if err != nil {
+		s.giveUp(ctx, ids, "notification no longer exists")
+		return
+	}
+	links := s.links(u.ID)
+	title, message := n.Text(u.Locale)
+	m, err := s.renderer.Notification(&mail.Notification{
+		RecipientName: u.Name,
+		Locale:        u.Locale,
+		Type:          n.Type,
+		Title:         title,
+		Message:       message,
+		Link:          s.notificationLink(n),
+		CreatedAt:     n.CreatedAt,
+		Links:         links,
+	})
+	if err != nil {
+		s.giveUp(ctx, ids, err.Error())
+		return
+	}
+	s.send(ctx, u, m, links, ids, d.Attempts, "immediate")
+}
+
+// sendDigests sends the digests that are due, one message per user with all their held notifications.
+func (s *NotificationEmailService) sendDigests(ctx context.Context) error {
+	// Postgres keeps microseconds; truncating lets ClaimDigest match the stored value when it is put back.
+	now := time.Now().Truncate(time.Microsecond)
+	due, err := s.repo.DueDigests(ctx, now, s.cfg.BatchSize)
+	if err != nil {
+		return err
+	}
+	for i := range due {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		st := &due[i]
+		claimed, err := s.repo.ClaimDigest(ctx, st.UserID, st.LastDigestAt, now)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+		if !s.sendDigest(ctx, st, now) {
+			// Put the period back so the digest is due again once its retry time has passed.
+			if _, err := s.repo.ClaimDigest(ctx, st.UserID, now, st.LastDigestAt); err != nil {
+				s.log.Error("notification email: digest claim release failed", zap.Error(err))
+			}
+		}
+	}
+	return nil
+}
+
+// sendDigest reports whether the user's digest was sent (or had nothing left to send).
+func (s *NotificationEmailService) sendDigest(ctx context.Context, st *models.NotificationEmailSetting, now time.Time) bool {
+	list, err := s.repo.ListDigest(ctx, st.UserID, models.DeliveryChannelEmail)
+	if err != nil {
+		s.log.Warn("notification email: listing digest failed", zap.Error(err))
+		return false
+	}
+	var ids, gone []int64
+	var notifications []*models.Notification
+	attempts := 0
+	for i := range list {
+		n, err := s.notifRepo.GetByID(list[i].NotificationID, st.UserID)
+		if err != nil {
+			gone = append(gone, list[i].ID)
+			continue
+		}
+		ids = append(ids, list[i].ID)
+		attempts = max(attempts, list[i].Attempts)
+		notifications = append(notifications, n)
+	}
+	s.giveUp(ctx, gone, "notification no longer exists")
+	if len(ids) == 0 {
+		return true
+	}
+	u, ok := s.recipient(ctx, st.UserID, ids)
+	if !ok {
+		return true
+	}
+	items := make([]mail.Notification, len(notifications))
+	for i, n := range notifications {
+		title, message := n.Text(u.Locale)
+		items[i] = mail.Notification{Type: n.Type, Title: title, Message: message, Link: s.notificationLink(n), CreatedAt: n.CreatedAt}
+	}
+	links := s.links(u.ID)
+	m, err := s.renderer.Digest(&mail.Digest{
+		RecipientName: u.Name,
+		Locale:        u.Locale,
+		Period:        st.Frequency,
+		Items:         items,
+		Link:          s.cfg.BaseURL + "/notifications",
+		Links:         links,
+	})
+	if err != nil {
+		s.giveUp(ctx, ids, err.Error())
+		return true
+	}
+	return s.send(ctx, u, m, links, ids, attempts, "digest")
+}
+
+// recipient loads the user deliveries ids are for; if they cannot get email any more the deliveries are given up.
+func (s *NotificationEmailService) recipient(ctx context.Context, userID uuid.UUID, ids []int64) (*models.User, bool) {
+	u, err := s.userRepo.GetByID(userID)
+	switch {
+	case err != nil:
+		s.giveUp(ctx, ids, "recipient no longer exists")
+	case !u.Active:
+		s.giveUp(ctx, ids, "recipient is deactivated")
+	case u.Email == "":
+		s.giveUp(ctx, ids, "recipient has no email address")
+	default:
+		return u, true
+	}
+	return nil, false
+}
+
+// send delivers m to u and records the outcome for ids; attempts is how often they were tried before.
+func (s *NotificationEmailService) send(ctx context.Context, u *models.User, m *mail.Message, links mail.Links, ids []int64, attempts int, kind string) bool {
+	m.To, m.ToName = u.Email, u.Name
+	m.Headers = map[string]string{
+		"List-Unsubscribe":      "<" + links.Unsubscribe + ">",
+		"List-Unsubscribe-Post": "List-Unsubscribe=One-Click", // RFC 8058
+		"Auto-Submitted":        "auto-generated",
+	}
+	sendCtx, cancel := context.WithTimeout(ctx, emailSendTimeout)
+	err := s.sender.Send(sendCtx, m)
+	cancel()
+	if err == nil {
+		notificationEmailsSentTotal.WithLabelValues(kind).Inc()
+		if err := s.repo.MarkSent(ctx, ids, time.Now()); err != nil {
+			s.log.Error("notification email: marking sent failed; it may be sent again", zap.Error(err))
+		}
+		return true
+	}
+	if mail.IsPermanent(err) || attempts+1 >= s.cfg.MaxAttempts {
+		notificationEmailErrorsTotal.WithLabelValues("failed").Inc()
+		s.log.Warn("notification email: giving up", zap.Error(err), zap.String("user_id", u.ID.String()), zap.Int("attempts", attempts+1))
+		if err := s.repo.MarkFailed(ctx, ids, err.Error()); err != nil {
+			s.log.Error("notification email: marking failed failed", zap.Error(err))
+		}
+		return true
+	}
+	notificationEmailErrorsTotal.WithLabelValues("retry").Inc()
+	next := time.Now().Add(min(emailRetryBase<<min(attempts, 16), emailRetryMax))
+	s.log.Warn("notification email: send failed, retrying", zap.Error(err), zap.String("user_id", u.ID.String()), zap.Time("next_attempt_at", next))
+	if err := s.repo.MarkRetry(ctx, ids, err.Error(), next); err != nil {
+		s.log.Error("notification email: scheduling retry failed", zap.Error(err))
+	}
+	return false
+}
+
+func (s *NotificationEmailService) giveUp(ctx context.Context, ids []int64, reason string) {
+	if len(ids) == 0 {
+		return
+	}
+	if err := s.repo.MarkFailed(ctx, ids, reason); err != nil {
+		s.log.Error("notification email: marking failed failed", zap.Error(err))
+	}
+}
+
+func (s *NotificationEmailService) links(userID uuid.UUID) mail.Links {
+	return mail.Links{
+		Unsubscribe: s.cfg.BaseURL + "/api/notifications/unsubscribe?token=" + s.unsubscribeToken(userID),
+		Settings:    s.cfg.BaseURL + "/notifications",
+	}
+}
+
+// notificationLink points at the page in the app where the notification can be acted on.
+func (s *NotificationEmailService) notificationLink(n *models.Notification) string {
+	switch n.Type {
+	case models.NotificationTypeProductRequestSubmitted:
+		return s.cfg.BaseURL + "/admin/requests"
+	case models.NotificationTypeProductDeletionRequestSubmitted:
+		return s.cfg.BaseURL + "/admin/deletion-requests"
+	}
+	switch n.RelatedEntityType {
+	case "product":
+		if n.RelatedEntityID != nil {
+			return s.cfg.BaseURL + "/products/" + n.RelatedEntityID.String()
+		}
+	case "product_request", "product_deletion_request":
+		return s.cfg.BaseURL + "/requests"
+	}
+	return s.cfg.BaseURL + "/notifications"
+}
+
+func (s *NotificationEmailService) refreshGauges(ctx context.Context) {
+	immediate, digest, err := s.repo.Pending(ctx, models.DeliveryChannelEmail)
+	if err != nil {
+		return
+	}
+	notificationEmailPending.WithLabelValues("immediate").Set(float64(immediate))
+	notificationEmailPending.WithLabelValues("digest").Set(float64(digest))
+}
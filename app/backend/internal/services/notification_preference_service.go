@@ -0,0 +1,296 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/dto"
+	"github.com/rm/roadmap/backend/internal/models"
+	"github.com/rm/roadmap/backend/internal/repositories"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrInvalidNotificationPreference   = errors.New("unknown notification type or channel")
+	ErrNotificationPreferenceMandatory = errors.New("notification is mandatory on this channel and cannot be turned off")
+)
+
+// notificationPreferenceEntity is the audit entity type of preference changes. The entity ID is the user's
+// ID, or notificationDefaultsEntityID for the organization defaults.
+const (
+	notificationPreferenceEntity = "notification_preference"
+	notificationDefaultsEntityID = "defaults"
+)
+
+// builtinNotificationDefault is the default of a channel no admin has configured: every channel is on.
+func builtinNotificationDefault(channel string) bool {
+	return true
+}
+
+// NotificationRoute is where one notification goes: the channels it is delivered on, and which of them the
+// recipient cannot turn off.
+type NotificationRoute struct {
+	enabled   map[string]bool
+	mandatory map[string]bool
+}
+
+func (r NotificationRoute) Enabled(channel string) bool   { return r.enabled[channel] }
+func (r NotificationRoute) Mandatory(channel string) bool { return r.mandatory[channel] }
+
+func builtinNotificationRoute() NotificationRoute {
+	r := NotificationRoute{enabled: map[string]bool{}, mandatory: map[string]bool{}}
+	for _, ch := range models.DeliveryChannels {
+		r.enabled[ch] = builtinNotificationDefault(ch)
+	}
+	return r
+}
+
+// NotificationPreferenceService decides which channels each notification is delivered on. A preference is
+// keyed by user, notification type and channel; a mandatory organization default wins over the user's
+// choice, which wins over the default.
+type NotificationPreferenceService struct {
+	repo     repositories.NotificationPreferenceRepository
+	tx       repositories.Transactor
+	auditSvc *AuditService
+	log      *zap.Logger
+}
+
+func NewNotificationPreferenceService(repo repositories.NotificationPreferenceRepository, tx repositories.Transactor, auditSvc *AuditService, log *zap.Logger) *NotificationPreferenceService {
+	if log == nil {
+		log = zap.NewNop()
+	}
+	return &NotificationPreferenceService{repo: repo, tx: tx, auditSvc: auditSvc, log: log}
+}
+
+// Route resolves the channels a notification of notifType goes to for userID. If the preferences cannot be
+// read the built-in defaults apply, so a database hiccup does not swallow notifications.
+func (s *NotificationPreferenceService) Route(ctx context.Context, userID uuid.UUID, notifType string) NotificationRoute {
+	defaults, err := s.repo.ListDefaults(ctx, notifType)
+	if err != nil {
+		s.log.Warn("notification preferences: reading defaults failed", zap.Error(err), zap.String("type", notifType))
+		return builtinNotificationRoute()
+	}
+	prefs, err := s.repo.ListForUser(ctx, userID, notifType)
+	if err != nil {
+		s.log.Warn("notification preferences: reading preferences failed", zap.Error(err), zap.String("user_id", userID.String()))
+		return builtinNotificationRoute()
+	}
+	r := NotificationRoute{enabled: map[string]bool{}, mandatory: map[string]bool{}}
+	for _, p := range effectivePreferences([]string{notifType}, defaults, prefs) {
+		r.enabled[p.Channel] = p.Enabled
+		r.mandatory[p.Channel] = p.Mandatory
+	}
+	return r
+}
+
+// ForUser returns the user's effective setting of every notification type on every channel.
+func (s *NotificationPreferenceService) ForUser(ctx context.Context, userID uuid.UUID) (*dto.NotificationPreferencesResponse, error) {
+	defaults, err := s.repo.ListDefaults(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	prefs, err := s.repo.ListForUser(ctx, userID, "")
+	if err != nil {
+		return nil, err
+	}
+	return &dto.NotificationPreferencesResponse{
+		Items:    effectivePreferences(models.NotificationTypes, defaults, prefs),
+		Types:    models.NotificationTypes,
+		Channels: models.DeliveryChannels,
+	}, nil
+}
+
+// Update applies the user's changes. Turning off a mandatory notification is rejected; turning it on is
+// accepted and has no effect. The change is audited with the user's preferences before and after.
+func (s *NotificationPreferenceService) Update(ctx context.Context, userID uuid.UUID, req dto.NotificationPreferencesRequest, meta dto.AuditMeta) (*dto.NotificationPreferencesResponse, error) {
+	for _, u := range req.Preferences {
+		if !validNotificationPreference(u.Type, u.Channel) {
+			return nil, ErrInvalidNotificationPreference
+		}
+	}
+	now := time.Now()
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		defaults, err := s.repo.ListDefaults(ctx, "")
+		if err != nil {
+			return err
+		}
+		mandatory := map[string]bool{}
+		for _, d := range defaults {
+			mandatory[preferenceKey(d.Type, d.Channel)] = d.Mandatory
+		}
+		before, err := s.repo.ListForUser(ctx, userID, "")
+		if err != nil {
+			return err
+		}
+		for _, u := range req.Preferences {
+			switch {
+			case u.Enabled == nil:
+				err = s.repo.Delete(ctx, userID, u.Type, u.Channel)
+			case !*u.Enabled && mandatory[preferenceKey(u.Type, u.Channel)]:
+				err = ErrNotificationPreferenceMandatory
+			default:
+				err = s.repo.Save(ctx, &models.NotificationPreference{UserID: userID, Type: u.Type, Channel: u.Channel, Enabled: *u.Enabled, UpdatedAt: now})
+			}
+			if err != nil {
+				return err
+			}
+		}
+		after, err := s.repo.ListForUser(ctx, userID, "")
+		if err != nil {
+			return err
+		}
+		return s.logChange(ctx, userID.String(), preferenceSnapshot(before), preferenceSnapshot(after), meta)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.ForUser(ctx, userID)
+}
+
+// Defaults returns the organization default of every notification type on every channel.
+func (s *NotificationPreferenceService) Defaults(ctx context.Context) (*dto.NotificationDefaultsResponse, error) {
+	list, err := s.repo.ListDefaults(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	set := map[string]*models.NotificationDefault{}
+	for i := range list {
+		set[preferenceKey(list[i].Type, list[i].Channel)] = &list[i]
+	}
+	items := make([]dto.NotificationDefaultResponse, 0, len(models.NotificationTypes)*len(models.DeliveryChannels))
+	for _, t := range models.NotificationTypes {
+		for _, ch := range models.DeliveryChannels {
+			d, ok := set[preferenceKey(t, ch)]
+			if !ok {
+				items = append(items, dto.NotificationDefaultResponse{Type: t, Channel: ch, Enabled: builtinNotificationDefault(ch)})
+				continue
+			}
+			item := dto.NotificationDefaultResponse{Type: t, Channel: ch, Enabled: d.Enabled, Mandatory: d.Mandatory}
+			if d.UpdatedBy != nil {
+				item.UpdatedBy = d.UpdatedBy.String()
+			}
+			at := d.UpdatedAt.Format(time.RFC3339)
+			item.UpdatedAt = &at
+			items = append(items, item)
+		}
+	}
+	return &dto.NotificationDefaultsResponse{Items: items, Types: models.NotificationTypes, Channels: models.DeliveryChannels}, nil
+}
+
+// UpdateDefaults sets organization defaults. Making a type mandatory overrides every user's choice on that
+// channel without changing their stored preference, so lifting it later restores what they picked.
+func (s *NotificationPreferenceService) UpdateDefaults(ctx context.Context, req dto.NotificationDefaultsRequest, meta dto.AuditMeta) (*dto.NotificationDefaultsResponse, error) {
+	for _, u := range req.Defaults {
+		if !validNotificationPreference(u.Type, u.Channel) {
+			return nil, ErrInvalidNotificationPreference
+		}
+	}
+	now := time.Now()
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		before, err := s.repo.ListDefaults(ctx, "")
+		if err != nil {
+			return err
+		}
+		for _, u := range req.Defaults {
+			d := &models.NotificationDefault{Type: u.Type, Channel: u.Channel, Enabled: u.Enabled || u.Mandatory, Mandatory: u.Mandatory, UpdatedBy: meta.UserID, UpdatedAt: now}
+			if err := s.repo.SaveDefault(ctx, d); err != nil {
+				return err
+			}
+		}
+		after, err := s.repo.ListDefaults(ctx, "")
+		if err != nil {
+			return err
+		}
+		return s.logChange(ctx, notificationDefaultsEntityID, defaultSnapshot(before), defaultSnapshot(after), meta)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.Defaults(ctx)
+}
+
+// History returns the audit trail of preference and default changes, newest first; entityID narrows it to
+// one user's ID or to "defaults". Reading it also needs audit:read.
+func (s *NotificationPreferenceService) History(ctx context.Context, entityID string, limit, offset int, callerID uuid.UUID, callerRole string) ([]dto.AuditLogResponse, int64, error) {
+	f := repositories.AuditFilter{EntityType: notificationPreferenceEntity, EntityID: entityID}
+	return s.auditSvc.List(ctx, limit, offset, f, "timestamp", "desc", callerID, callerRole)
+}
+
+func (s *NotificationPreferenceService) logChange(ctx context.Context, entityID string, oldData, newData map[string]interface{}, meta dto.AuditMeta) error {
+	if s.auditSvc == nil || maps.EqualFunc(oldData, newData, func(a, b interface{}) bool { return a == b }) {
+		return nil
+	}
+	return s.auditSvc.Log(ctx, AuditEntry{
+		UserID:     meta.UserID,
+		Action:     "update",
+		EntityType: notificationPreferenceEntity,
+		EntityID:   entityID,
+		OldData:    ToJSONB(oldData),
+		NewData:    ToJSONB(newData),
+		IPAddress:  meta.IP,
+		UserAgent:  meta.UserAgent,
+		TraceID:    meta.TraceID,
+	})
+}
+
+// effectivePreferences resolves every type in types on every channel.
+func effectivePreferences(types []string, defaults []models.NotificationDefault, prefs []models.NotificationPreference) []dto.NotificationPreferenceResponse {
+	def := map[string]models.NotificationDefault{}
+	for _, d := range defaults {
+		def[preferenceKey(d.Type, d.Channel)] = d
+	}
+	own := map[string]bool{}
+	for _, p := range prefs {
+		own[preferenceKey(p.Type, p.Channel)] = p.Enabled
+	}
+	out := make([]dto.NotificationPreferenceResponse, 0, len(types)*len(models.DeliveryChannels))
+	for _, t := range types {
+		for _, ch := range models.DeliveryChannels {
+			key := preferenceKey(t, ch)
+			item := dto.NotificationPreferenceResponse{Type: t, Channel: ch, Enabled: builtinNotificationDefault(ch), Source: "default"}
+			d, hasDefault := def[key]
+			if hasDefault {
+				item.Enabled, item.Mandatory = d.Enabled, d.Mandatory
+			}
+			if enabled, ok := own[key]; ok && !item.Mandatory {
+				item.Enabled, item.Source = enabled, "user"
+			}
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func validNotificationPreference(notifType, channel string) bool {
+	return slices.Contains(models.NotificationTypes, notifType) && slices.Contains(models.DeliveryChannels, channel)
+}
+
+func preferenceKey(notifType, channel string) string { return notifType + ":" + channel }
+
+// preferenceSnapshot and defaultSnapshot are the audit data of a change: "type:channel" to the setting.
+func preferenceSnapshot(list []models.NotificationPreference) map[string]interface{} {
+	out := map[string]interface{}{}
+	for _, p := range list {
+		out[preferenceKey(p.Type, p.Channel)] = p.Enabled
+	}
+	return out
+}
+
+func defaultSnapshot(list []models.NotificationDefault) map[string]interface{} {
+	out := map[string]interface{}{}
+	for _, d := range list {
+		switch {
+		case d.Mandatory:
+			out[preferenceKey(d.Type, d.Channel)] = "mandatory"
+		case d.Enabled:
+			out[preferenceKey(d.Type, d.Channel)] = "on"
+		default:
+			out[preferenceKey(d.Type, d.Channel)] = "off"
+		}
+	}
+	return out
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rm/roadmap/backend/internal/repositories"
+	"go.uber.org/zap"
+)
+
+var notificationsPurgedTotal = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "notifications_purged_total",
+	Help: "Total number of notifications permanently deleted by the retention job",
+})
+
+type NotificationRetentionConfig struct {
+	Interval  time.Duration // time between runs; 0 disables the job
+	MaxAge    time.Duration // read, archived and deleted notifications older than this are purged; 0 keeps them
+	BatchSize int           // notifications deleted per statement
+}
+
+// NotificationRetentionService permanently deletes old notifications the user is done with: read,
+// archived or deleted ones, and those stored only for email once it has been sent. Unread notifications
+// are kept however old they are.
+type NotificationRetentionService struct {
+	repo repositories.NotificationRepository
+	cfg  NotificationRetentionConfig
+	log  *zap.Logger
+}
+
+func NewNotificationRetentionService(repo repositories.NotificationRepository, cfg NotificationRetentionConfig, log *zap.Logger) *NotificationRetentionService {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 1000
+	}
+	if log == nil {
+		log = zap.NewNop()
+	}
+	return &NotificationRetentionService{repo: repo, cfg: cfg, log: log}
+}
+
+// Start purges every Interval until ctx is done. Replicas may run it at the same time; each batch skips
+// rows another one is deleting.
+func (s *NotificationRetentionService) Start(ctx context.Context) {
+	if s.cfg.Interval <= 0 || s.cfg.MaxAge <= 0 {
+		return
+	}
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.Run(ctx, time.Now())
+			if err != nil && ctx.Err() == nil {
+				s.log.Error("notification retention run failed", zap.Error(err), zap.Int64("purged", n))
+			} else if n > 0 {
+				s.log.Info("notification retention run", zap.Int64("purged", n))
+			}
+		}
+	}
+}
+
+// Run purges the notifications past MaxAge at now, in batches, and returns how many it deleted.
+func (s *NotificationRetentionService) Run(ctx context.Context, now time.Time) (int64, error) {
+	cutoff := now.Add(-s.cfg.MaxAge)
+	var total int64
+	for ctx.Err() == nil {
+		n, err := s.repo.Purge(ctx, cutoff, s.cfg.BatchSize)
+		total += n
+		notificationsPurgedTotal.Add(float64(n))
+		if err != nil {
+			return total, err
+		}
+		if n < int64(s.cfg.BatchSize) {
+			break
+		}
+	}
+	return total, ctx.Err()
+}
@@ -1,47 +1,103 @@
 package services
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/rm/roadmap/backend/internal/dto"
+	"github.com/rm/roadmap/backend/internal/i18n"
 	"github.com/rm/roadmap/backend/internal/models"
+	"github.com/rm/roadmap/backend/internal/realtime"
 	"github.com/rm/roadmap/backend/internal/repositories"
 )
 
+var ErrNotificationBulkInvalid = errors.New("action must be read, archive or delete, with valid ids or at least one filter")
+
 type NotificationService struct {
-	repo repositories.NotificationRepository
+	repo     repositories.NotificationRepository
+	userRepo repositories.UserRepository
+	prefs    *NotificationPreferenceService
+	emailSvc *NotificationEmailService
+	hub      *realtime.Hub
 }
 
-func NewNotificationService(repo repositories.NotificationRepository) *NotificationService {
-	return &NotificationService{repo: repo}
+func NewNotificationService(repo repositories.NotificationRepository, userRepo repositories.UserRepository, prefs *NotificationPreferenceService, emailSvc *NotificationEmailService, hub *realtime.Hub) *NotificationService {
+	return &NotificationService{repo: repo, userRepo: userRepo, prefs: prefs, emailSvc: emailSvc, hub: hub}
 }
 
-func (s *NotificationService) Create(recipientUserID uuid.UUID, notifType, title, message string, relatedEntityType string, relatedEntityID *uuid.UUID) (*dto.NotificationResponse, error) {
+// Create notifies the recipient on the channels their preferences route notifType to. A notification muted
+// in the app is still stored, hidden, for the other channels; when every channel is muted nothing is stored
+// and the response is nil. Title and message are catalog messages: the English text is stored for search
+// and older clients, and every reader gets them in their own locale.
+func (s *NotificationService) Create(recipientUserID uuid.UUID, notifType string, title, message i18n.Message, relatedEntityType string, relatedEntityID *uuid.UUID) (*dto.NotificationResponse, error) {
+	ctx := context.Background()
+	route := builtinNotificationRoute()
+	if s.prefs != nil {
+		route = s.prefs.Route(ctx, recipientUserID, notifType)
+	}
+	inApp := route.Enabled(models.DeliveryChannelInApp)
+	email := route.Enabled(models.DeliveryChannelEmail) && s.emailSvc != nil && s.emailSvc.Enabled()
+	if !inApp && !email {
+		return nil, nil
+	}
 	n := &models.Notification{
 		UserID:            recipientUserID,
 		Type:              notifType,
-		Title:             title,
-		Message:           message,
+		Title:             title.String(),
+		Message:           message.String(),
+		LocalizedTitle:    &title,
+		LocalizedMessage:  &message,
 		RelatedEntityType: relatedEntityType,
 		RelatedEntityID:   relatedEntityID,
+		Hidden:            !inApp,
 	}
 	if err := s.repo.Create(n); err != nil {
 		return nil, err
 	}
-	return notificationToResponse(n), nil
+	// Email is only queued here; NotificationEmailService sends it in the background.
+	if email {
+		s.emailSvc.Enqueue(ctx, n, route.Mandatory(models.DeliveryChannelEmail))
+	}
+	resp := notificationToResponse(n, s.userLocale(recipientUserID))
+	if inApp {
+		publishUserEvent(ctx, s.hub, realtime.EventNotification, recipientUserID, resp)
+		s.publishUnreadCount(recipientUserID)
+	}
+	return resp, nil
 }
 
-func (s *NotificationService) List(userID uuid.UUID, includeArchived bool, limit, offset int) (*dto.NotificationListResponse, error) {
-	list, err := s.repo.List(userID, includeArchived, limit, offset)
-	if err != nil {
-		return nil, err
-	}
-	items := make([]dto.NotificationResponse, len(list))
-	for i := range list {
-		items[i] = *notificationToResponse(&list[i])
+// List returns the user's notifications matching f, newest first, in locale. Threaded, repeated
+// notifications of one type about the same entity collapse into their newest one with the thread's counts,
+// and Total counts threads.
+func (s *NotificationService) List(userID uuid.UUID, locale string, f repositories.NotificationFilter, threaded bool, limit, offset int) (*dto.NotificationListResponse, error) {
+	var items []dto.NotificationResponse
+	var total int64
+	if threaded {
+		list, n, err := s.repo.ListThreads(userID, f, limit, offset)
+		if err != nil {
+			return nil, err
+		}
+		items = make([]dto.NotificationResponse, len(list))
+		for i := range list {
+			items[i] = *notificationToResponse(&list[i].Notification, locale)
+			items[i].ThreadCount = list[i].ThreadCount
+			items[i].ThreadUnreadCount = list[i].ThreadUnread
+		}
+		total = n
+	} else {
+		list, err := s.repo.List(userID, f, limit, offset)
+		if err != nil {
+			return nil, err
+		}
+		items = make([]dto.NotificationResponse, len(list))
+		for i := range list {
+			items[i] = *notificationToResponse(&list[i], locale)
+		}
+		total, _ = s.repo.Count(userID, f)
 	}
-	total, _ := s.repo.Count(userID, includeArchived)
 	return &dto.NotificationListResponse{
 		Items:  items,
 		Total:  total,
@@ -55,28 +111,132 @@ func (s *NotificationService) UnreadCount(userID uuid.UUID) (int64, error) {
 }
 
 func (s *NotificationService) MarkRead(id, userID uuid.UUID) error {
-	return s.repo.MarkRead(id, userID)
+	if err := s.repo.MarkRead(id, userID); err != nil {
+		return err
+	}
+	s.publishUnreadCount(userID)
+	return nil
 }
 
 func (s *NotificationService) MarkReadAll(userID uuid.UUID) error {
-	return s.repo.MarkReadAll(userID)
+	if err := s.repo.MarkReadAll(userID); err != nil {
+		return err
+	}
+	s.publishUnreadCount(userID)
+	return nil
 }
 
 func (s *NotificationService) Archive(id, userID uuid.UUID) error {
-	return s.repo.Archive(id, userID)
+	if err := s.repo.Archive(id, userID); err != nil {
+		return err
+	}
+	s.publishUnreadCount(userID)
+	return nil
 }
 
 func (s *NotificationService) Delete(id, userID uuid.UUID) error {
-	return s.repo.Delete(id, userID)
+	if err := s.repo.Delete(id, userID); err != nil {
+		return err
+	}
+	s.publishUnreadCount(userID)
+	return nil
 }
 
-func notificationToResponse(n *models.Notification) *dto.NotificationResponse {
+// Bulk marks read, archives or deletes every notification of the user that matches req and returns how
+// many changed.
+func (s *NotificationService) Bulk(userID uuid.UUID, req dto.NotificationBulkRequest) (int64, error) {
+	f, err := notificationBulkFilter(req)
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	switch req.Action {
+	case "read":
+		n, err = s.repo.MarkReadMatching(userID, f)
+	case "archive":
+		n, err = s.repo.ArchiveMatching(userID, f)
+	case "delete":
+		n, err = s.repo.DeleteMatching(userID, f)
+	default:
+		return 0, ErrNotificationBulkInvalid
+	}
+	if err != nil {
+		return 0, err
+	}
+	if n > 0 {
+		s.publishUnreadCount(userID)
+	}
+	return n, nil
+}
+
+func notificationBulkFilter(req dto.NotificationBulkRequest) (repositories.NotificationFilter, error) {
+	f := repositories.NotificationFilter{
+		Type:              strings.TrimSpace(req.Type),
+		RelatedEntityType: strings.TrimSpace(req.RelatedEntityType),
+		Read:              req.Read,
+		Archived:          req.Archived,
+		Threads:           req.Threads,
+	}
+	for _, v := range req.IDs {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return f, ErrNotificationBulkInvalid
+		}
+		f.IDs = append(f.IDs, id)
+	}
+	if req.RelatedEntityID != "" {
+		id, err := uuid.Parse(req.RelatedEntityID)
+		if err != nil {
+			return f, ErrNotificationBulkInvalid
+		}
+		f.RelatedEntityID = &id
+	}
+	if req.Before != "" {
+		t, err := time.Parse(time.RFC3339, req.Before)
+		if err != nil {
+			if t, err = time.Parse("2006-01-02", req.Before); err != nil {
+				return f, ErrNotificationBulkInvalid
+			}
+		}
+		f.Before = &t
+	}
+	if len(f.IDs) == 0 && f.Type == "" && f.RelatedEntityType == "" && f.RelatedEntityID == nil && f.Read == nil && f.Archived == nil && f.Before == nil {
+		return f, ErrNotificationBulkInvalid
+	}
+	return f, nil
+}
+
+// publishUnreadCount pushes the user's unread count to their connected clients, on every replica.
+func (s *NotificationService) publishUnreadCount(userID uuid.UUID) {
+	if s.hub == nil {
+		return
+	}
+	count, err := s.repo.UnreadCount(userID)
+	if err != nil {
+		return
+	}
+	publishUserEvent(context.Background(), s.hub, realtime.EventUnreadCount, userID, dto.NotificationUnreadCountResponse{Count: count})
+}
+
+// userLocale is the user's chosen locale, for what is rendered outside a request; the default when they have
+// none.
+func (s *NotificationService) userLocale(userID uuid.UUID) string {
+	if s.userRepo != nil {
+		if u, err := s.userRepo.GetByID(userID); err == nil && u.Locale != "" {
+			return u.Locale
+		}
+	}
+	return i18n.DefaultLocale
+}
+
+func notificationToResponse(n *models.Notification, locale string) *dto.NotificationResponse {
+	title, message := n.Text(locale)
 	resp := &dto.NotificationResponse{
 		ID:                n.ID.String(),
 		UserID:            n.UserID.String(),
 		Type:              n.Type,
-		Title:             n.Title,
-		Message:           n.Message,
+		Title:             title,
+		Message:           message,
 		RelatedEntityType: n.RelatedEntityType,
 		CreatedAt:         n.CreatedAt.Format(time.RFC3339),
 	}
@@ -1,12 +1,15 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/rm/roadmap/backend/internal/dto"
 	"github.com/rm/roadmap/backend/internal/models"
 	"github.com/rm/roadmap/backend/internal/repositories"
+	"gorm.io/gorm"
 )
 
 type OrgService struct {
@@ -15,6 +18,8 @@ type OrgService struct {
 	funcRepo    repositories.FunctionRepository
 	deptRepo    repositories.DepartmentRepository
 	teamRepo    repositories.TeamRepository
+	tx          repositories.Transactor
+	auditSvc    *AuditService
 }
 
 func NewOrgService(
@@ -23,6 +28,8 @@ func NewOrgService(
 	funcRepo repositories.FunctionRepository,
 	deptRepo repositories.DepartmentRepository,
 	teamRepo repositories.TeamRepository,
+	tx repositories.Transactor,
+	auditSvc *AuditService,
 ) *OrgService {
 	return &OrgService{
 		holdingRepo: holdingRepo,
@@ -30,18 +37,27 @@ func NewOrgService(
 		funcRepo:    funcRepo,
 		deptRepo:    deptRepo,
 		teamRepo:    teamRepo,
+		tx:          tx,
+		auditSvc:    auditSvc,
 	}
 }
 
 func ts(t time.Time) string { return t.Format(time.RFC3339) }
 
 // Holding companies
-func (s *OrgService) CreateHoldingCompany(req dto.HoldingCompanyCreateRequest) (*dto.HoldingCompanyResponse, error) {
+func (s *OrgService) CreateHoldingCompany(ctx context.Context, req dto.HoldingCompanyCreateRequest, meta dto.AuditMeta) (*dto.HoldingCompanyResponse, error) {
 	h := &models.HoldingCompany{Name: req.Name, Description: req.Description}
-	if err := s.holdingRepo.Create(h); err != nil {
+	var resp *dto.HoldingCompanyResponse
+	if err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.holdingRepo.Create(ctx, h); err != nil {
+			return err
+		}
+		resp = holdingCompanyToResp(h)
+		return s.auditSvc.Record(ctx, meta, "create", "holding_company", h.ID.String(), nil, ToJSONB(resp), nil)
+	}); err != nil {
 		return nil, err
 	}
-	return &dto.HoldingCompanyResponse{ID: h.ID.String(), Name: h.Name, Description: h.Description, CreatedAt: ts(h.CreatedAt)}, nil
+	return resp, nil
 }
 func (s *OrgService) ListHoldingCompanies() ([]dto.HoldingCompanyResponse, error) {
 	list, err := s.holdingRepo.List()
@@ -50,7 +66,7 @@ func (s *OrgService) ListHoldingCompanies() ([]dto.HoldingCompanyResponse, error
 	}
 	out := make([]dto.HoldingCompanyResponse, len(list))
 	for i := range list {
-		out[i] = dto.HoldingCompanyResponse{ID: list[i].ID.String(), Name: list[i].Name, Description: list[i].Description, CreatedAt: ts(list[i].CreatedAt)}
+		out[i] = *holdingCompanyToResp(&list[i])
 	}
 	return out, nil
 }
@@ -59,36 +75,64 @@ func (s *OrgService) GetHoldingCompany(id uuid.UUID) (*dto.HoldingCompanyRespons
 	if err != nil {
 		return nil, err
 	}
-	return &dto.HoldingCompanyResponse{ID: h.ID.String(), Name: h.Name, Description: h.Description, CreatedAt: ts(h.CreatedAt)}, nil
+	return holdingCompanyToResp(h), nil
 }
-func (s *OrgService) UpdateHoldingCompany(id uuid.UUID, req dto.HoldingCompanyUpdateRequest) (*dto.HoldingCompanyResponse, error) {
+func (s *OrgService) UpdateHoldingCompany(ctx context.Context, id uuid.UUID, req dto.HoldingCompanyUpdateRequest, meta dto.AuditMeta) (*dto.HoldingCompanyResponse, error) {
 	h, err := s.holdingRepo.GetByID(id)
 	if err != nil {
 		return nil, err
 	}
+	oldData := ToJSONB(holdingCompanyToResp(h))
 	if req.Name != nil {
 		h.Name = *req.Name
 	}
 	if req.Description != nil {
 		h.Description = *req.Description
 	}
-	if err := s.holdingRepo.Update(h); err != nil {
+	var resp *dto.HoldingCompanyResponse
+	if err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.holdingRepo.Update(ctx, h); err != nil {
+			return err
+		}
+		resp = holdingCompanyToResp(h)
+		return s.auditSvc.Record(ctx, meta, "update", "holding_company", h.ID.String(), oldData, ToJSONB(resp), nil)
+	}); err != nil {
 		return nil, err
 	}
-	return &dto.HoldingCompanyResponse{ID: h.ID.String(), Name: h.Name, Description: h.Description, CreatedAt: ts(h.CreatedAt)}, nil
+	return resp, nil
 }
-func (s *OrgService) DeleteHoldingCompany(id uuid.UUID) error {
-	return s.holdingRepo.Delete(id)
+func (s *OrgService) DeleteHoldingCompany(ctx context.Context, id uuid.UUID, meta dto.AuditMeta) error {
+	h, err := s.holdingRepo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil // already gone; deleting is idempotent
+		}
+		return err
+	}
+	oldData := ToJSONB(holdingCompanyToResp(h))
+	return s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.holdingRepo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return s.auditSvc.Record(ctx, meta, "delete", "holding_company", id.String(), oldData, nil, nil)
+	})
 }
 
 // Companies
-func (s *OrgService) CreateCompany(req dto.CompanyCreateRequest) (*dto.CompanyResponse, error) {
+func (s *OrgService) CreateCompany(ctx context.Context, req dto.CompanyCreateRequest, meta dto.AuditMeta) (*dto.CompanyResponse, error) {
 	hid, _ := uuid.Parse(req.HoldingCompanyID)
 	c := &models.Company{HoldingCompanyID: hid, Name: req.Name}
-	if err := s.companyRepo.Create(c); err != nil {
+	var resp *dto.CompanyResponse
+	if err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.companyRepo.Create(ctx, c); err != nil {
+			return err
+		}
+		resp = companyToResp(c)
+		return s.auditSvc.Record(ctx, meta, "create", "company", c.ID.String(), nil, ToJSONB(resp), nil)
+	}); err != nil {
 		return nil, err
 	}
-	return &dto.CompanyResponse{ID: c.ID.String(), HoldingCompanyID: c.HoldingCompanyID.String(), Name: c.Name, CreatedAt: ts(c.CreatedAt)}, nil
+	return resp, nil
 }
 func (s *OrgService) ListCompanies(holdingCompanyID *uuid.UUID) ([]dto.CompanyResponse, error) {
 	list, err := s.companyRepo.List(holdingCompanyID)
@@ -97,7 +141,7 @@ func (s *OrgService) ListCompanies(holdingCompanyID *uuid.UUID) ([]dto.CompanyRe
 	}
 	out := make([]dto.CompanyResponse, len(list))
 	for i := range list {
-		out[i] = dto.CompanyResponse{ID: list[i].ID.String(), HoldingCompanyID: list[i].HoldingCompanyID.String(), Name: list[i].Name, CreatedAt: ts(list[i].CreatedAt)}
+		out[i] = *companyToResp(&list[i])
 	}
 	return out, nil
 }
@@ -106,13 +150,14 @@ func (s *OrgService) GetCompany(id uuid.UUID) (*dto.CompanyResponse, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &dto.CompanyResponse{ID: c.ID.String(), HoldingCompanyID: c.HoldingCompanyID.String(), Name: c.Name, CreatedAt: ts(c.CreatedAt)}, nil
+	return companyToResp(c), nil
 }
-func (s *OrgService) UpdateCompany(id uuid.UUID, req dto.CompanyUpdateRequest) (*dto.CompanyResponse, error) {
+func (s *OrgService) UpdateCompany(ctx context.Context, id uuid.UUID, req dto.CompanyUpdateRequest, meta dto.AuditMeta) (*dto.CompanyResponse, error) {
 	c, err := s.companyRepo.GetByID(id)
 	if err != nil {
 		return nil, err
 	}
+	oldData := ToJSONB(companyToResp(c))
 	if req.HoldingCompanyID != nil {
 		hid, _ := uuid.Parse(*req.HoldingCompanyID)
 		c.HoldingCompanyID = hid
@@ -120,23 +165,50 @@ func (s *OrgService) UpdateCompany(id uuid.UUID, req dto.CompanyUpdateRequest) (
 	if req.Name != nil {
 		c.Name = *req.Name
 	}
-	if err := s.companyRepo.Update(c); err != nil {
+	var resp *dto.CompanyResponse
+	if err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.companyRepo.Update(ctx, c); err != nil {
+			return err
+		}
+		resp = companyToResp(c)
+		return s.auditSvc.Record(ctx, meta, "update", "company", c.ID.String(), oldData, ToJSONB(resp), nil)
+	}); err != nil {
 		return nil, err
 	}
-	return &dto.CompanyResponse{ID: c.ID.String(), HoldingCompanyID: c.HoldingCompanyID.String(), Name: c.Name, CreatedAt: ts(c.CreatedAt)}, nil
+	return resp, nil
 }
-func (s *OrgService) DeleteCompany(id uuid.UUID) error {
-	return s.companyRepo.Delete(id)
+func (s *OrgService) DeleteCompany(ctx context.Context, id uuid.UUID, meta dto.AuditMeta) error {
+	c, err := s.companyRepo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	oldData := ToJSONB(companyToResp(c))
+	return s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.companyRepo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return s.auditSvc.Record(ctx, meta, "delete", "company", id.String(), oldData, nil, nil)
+	})
 }
 
 // Functions
-func (s *OrgService) CreateFunction(req dto.FunctionCreateRequest) (*dto.FunctionResponse, error) {
+func (s *OrgService) CreateFunction(ctx context.Context, req dto.FunctionCreateRequest, meta dto.AuditMeta) (*dto.FunctionResponse, error) {
 	cid, _ := uuid.Parse(req.CompanyID)
 	f := &models.Function{CompanyID: cid, Name: req.Name}
-	if err := s.funcRepo.Create(f); err != nil {
+	var resp *dto.FunctionResponse
+	if err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.funcRepo.Create(ctx, f); err != nil {
+			return err
+		}
+		resp = functionToResp(f)
+		return s.auditSvc.Record(ctx, meta, "create", "function", f.ID.String(), nil, ToJSONB(resp), nil)
+	}); err != nil {
 		return nil, err
 	}
-	return &dto.FunctionResponse{ID: f.ID.String(), CompanyID: f.CompanyID.String(), Name: f.Name, CreatedAt: ts(f.CreatedAt)}, nil
+	return resp, nil
 }
 func (s *OrgService) ListFunctions(companyID *uuid.UUID) ([]dto.FunctionResponse, error) {
 	list, err := s.funcRepo.List(companyID)
@@ -145,7 +217,7 @@ func (s *OrgService) ListFunctions(companyID *uuid.UUID) ([]dto.FunctionResponse
 	}
 	out := make([]dto.FunctionResponse, len(list))
 	for i := range list {
-		out[i] = dto.FunctionResponse{ID: list[i].ID.String(), CompanyID: list[i].CompanyID.String(), Name: list[i].Name, CreatedAt: ts(list[i].CreatedAt)}
+		out[i] = *functionToResp(&list[i])
 	}
 	return out, nil
 }
@@ -154,13 +226,14 @@ func (s *OrgService) GetFunction(id uuid.UUID) (*dto.FunctionResponse, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &dto.FunctionResponse{ID: f.ID.String(), CompanyID: f.CompanyID.String(), Name: f.Name, CreatedAt: ts(f.CreatedAt)}, nil
+	return functionToResp(f), nil
 }
-func (s *OrgService) UpdateFunction(id uuid.UUID, req dto.FunctionUpdateRequest) (*dto.FunctionResponse, error) {
+func (s *OrgService) UpdateFunction(ctx context.Context, id uuid.UUID, req dto.FunctionUpdateRequest, meta dto.AuditMeta) (*dto.FunctionResponse, error) {
 	f, err := s.funcRepo.GetByID(id)
 	if err != nil {
 		return nil, err
 	}
+	oldData := ToJSONB(functionToResp(f))
 	if req.CompanyID != nil {
 		cid, _ := uuid.Parse(*req.CompanyID)
 		f.CompanyID = cid
@@ -168,23 +241,50 @@ func (s *OrgService) UpdateFunction(id uuid.UUID, req dto.FunctionUpdateRequest)
 	if req.Name != nil {
 		f.Name = *req.Name
 	}
-	if err := s.funcRepo.Update(f); err != nil {
+	var resp *dto.FunctionResponse
+	if err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.funcRepo.Update(ctx, f); err != nil {
+			return err
+		}
+		resp = functionToResp(f)
+		return s.auditSvc.Record(ctx, meta, "update", "function", f.ID.String(), oldData, ToJSONB(resp), nil)
+	}); err != nil {
 		return nil, err
 	}
-	return &dto.FunctionResponse{ID: f.ID.String(), CompanyID: f.CompanyID.String(), Name: f.Name, CreatedAt: ts(f.CreatedAt)}, nil
+	return resp, nil
 }
-func (s *OrgService) DeleteFunction(id uuid.UUID) error {
-	return s.funcRepo.Delete(id)
+func (s *OrgService) DeleteFunction(ctx context.Context, id uuid.UUID, meta dto.AuditMeta) error {
+	f, err := s.funcRepo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	oldData := ToJSONB(functionToResp(f))
+	return s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.funcRepo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return s.auditSvc.Record(ctx, meta, "delete", "function", id.String(), oldData, nil, nil)
+	})
 }
 
 // Departments
-func (s *OrgService) CreateDepartment(req dto.DepartmentCreateRequest) (*dto.DepartmentResponse, error) {
+func (s *OrgService) CreateDepartment(ctx context.Context, req dto.DepartmentCreateRequest, meta dto.AuditMeta) (*dto.DepartmentResponse, error) {
 	fid, _ := uuid.Parse(req.FunctionID)
 	d := &models.Department{FunctionID: fid, Name: req.Name}
-	if err := s.deptRepo.Create(d); err != nil {
+	var resp *dto.DepartmentResponse
+	if err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.deptRepo.Create(ctx, d); err != nil {
+			return err
+		}
+		resp = departmentToResp(d)
+		return s.auditSvc.Record(ctx, meta, "create", "department", d.ID.String(), nil, ToJSONB(resp), nil)
+	}); err != nil {
 		return nil, err
 	}
-	return &dto.DepartmentResponse{ID: d.ID.String(), FunctionID: d.FunctionID.String(), Name: d.Name, CreatedAt: ts(d.CreatedAt)}, nil
+	return resp, nil
 }
 func (s *OrgService) ListDepartments(functionID *uuid.UUID) ([]dto.DepartmentResponse, error) {
 	list, err := s.deptRepo.List(functionID)
@@ -193,7 +293,7 @@ func (s *OrgService) ListDepartments(functionID *uuid.UUID) ([]dto.DepartmentRes
 	}
 	out := make([]dto.DepartmentResponse, len(list))
 	for i := range list {
-		out[i] = dto.DepartmentResponse{ID: list[i].ID.String(), FunctionID: list[i].FunctionID.String(), Name: list[i].Name, CreatedAt: ts(list[i].CreatedAt)}
+		out[i] = *departmentToResp(&list[i])
 	}
 	return out, nil
 }
@@ -202,13 +302,14 @@ func (s *OrgService) GetDepartment(id uuid.UUID) (*dto.DepartmentResponse, error
 	if err != nil {
 		return nil, err
 	}
-	return &dto.DepartmentResponse{ID: d.ID.String(), FunctionID: d.FunctionID.String(), Name: d.Name, CreatedAt: ts(d.CreatedAt)}, nil
+	return departmentToResp(d), nil
 }
-func (s *OrgService) UpdateDepartment(id uuid.UUID, req dto.DepartmentUpdateRequest) (*dto.DepartmentResponse, error) {
+func (s *OrgService) UpdateDepartment(ctx context.Context, id uuid.UUID, req dto.DepartmentUpdateRequest, meta dto.AuditMeta) (*dto.DepartmentResponse, error) {
 	d, err := s.deptRepo.GetByID(id)
 	if err != nil {
 		return nil, err
 	}
+	oldData := ToJSONB(departmentToResp(d))
 	if req.FunctionID != nil {
 		fid, _ := uuid.Parse(*req.FunctionID)
 		d.FunctionID = fid
@@ -216,27 +317,54 @@ func (s *OrgService) UpdateDepartment(id uuid.UUID, req dto.DepartmentUpdateRequ
 	if req.Name != nil {
 		d.Name = *req.Name
 	}
-	if err := s.deptRepo.Update(d); err != nil {
+	var resp *dto.DepartmentResponse
+	if err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.deptRepo.Update(ctx, d); err != nil {
+			return err
+		}
+		resp = departmentToResp(d)
+		return s.auditSvc.Record(ctx, meta, "update", "department", d.ID.String(), oldData, ToJSONB(resp), nil)
+	}); err != nil {
 		return nil, err
 	}
-	return &dto.DepartmentResponse{ID: d.ID.String(), FunctionID: d.FunctionID.String(), Name: d.Name, CreatedAt: ts(d.CreatedAt)}, nil
+	return resp, nil
 }
-func (s *OrgService) DeleteDepartment(id uuid.UUID) error {
-	return s.deptRepo.Delete(id)
+func (s *OrgService) DeleteDepartment(ctx context.Context, id uuid.UUID, meta dto.AuditMeta) error {
+	d, err := s.deptRepo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	oldData := ToJSONB(departmentToResp(d))
+	return s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.deptRepo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return s.auditSvc.Record(ctx, meta, "delete", "department", id.String(), oldData, nil, nil)
+	})
 }
 
 // Teams
-func (s *OrgService) CreateTeam(req dto.TeamCreateRequest) (*dto.TeamResponse, error) {
+func (s *OrgService) CreateTeam(ctx context.Context, req dto.TeamCreateRequest, meta dto.AuditMeta) (*dto.TeamResponse, error) {
 	did, _ := uuid.Parse(req.DepartmentID)
 	t := &models.Team{DepartmentID: did, Name: req.Name}
 	if req.ManagerID != nil && *req.ManagerID != "" {
 		mid, _ := uuid.Parse(*req.ManagerID)
 		t.ManagerID = &mid
 	}
-	if err := s.teamRepo.Create(t); err != nil {
+	var resp *dto.TeamResponse
+	if err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.teamRepo.Create(ctx, t); err != nil {
+			return err
+		}
+		resp = teamToResp(t)
+		return s.auditSvc.Record(ctx, meta, "create", "team", t.ID.String(), nil, ToJSONB(resp), nil)
+	}); err != nil {
 		return nil, err
 	}
-	return teamToResp(t), nil
+	return resp, nil
 }
 func (s *OrgService) ListTeams(departmentID *uuid.UUID) ([]dto.TeamResponse, error) {
 	list, err := s.teamRepo.List(departmentID)
@@ -256,11 +384,12 @@ func (s *OrgService) GetTeam(id uuid.UUID) (*dto.TeamResponse, error) {
 	}
 	return teamToResp(t), nil
 }
-func (s *OrgService) UpdateTeam(id uuid.UUID, req dto.TeamUpdateRequest) (*dto.TeamResponse, error) {
+func (s *OrgService) UpdateTeam(ctx context.Context, id uuid.UUID, req dto.TeamUpdateRequest, meta dto.AuditMeta) (*dto.TeamResponse, error) {
 	t, err := s.teamRepo.GetByID(id)
 	if err != nil {
 		return nil, err
 	}
+	oldData := ToJSONB(teamToResp(t))
 	if req.DepartmentID != nil {
 		did, _ := uuid.Parse(*req.DepartmentID)
 		t.DepartmentID = did
@@ -279,13 +408,49 @@ func (s *OrgService) UpdateTeam(id uuid.UUID, req dto.TeamUpdateRequest) (*dto.T
 	// Clear associations so GORM persists department_id and manager_id columns
 	t.Department = nil
 	t.Manager = nil
-	if err := s.teamRepo.Update(t); err != nil {
+	var resp *dto.TeamResponse
+	if err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.teamRepo.Update(ctx, t); err != nil {
+			return err
+		}
+		resp = teamToResp(t)
+		return s.auditSvc.Record(ctx, meta, "update", "team", t.ID.String(), oldData, ToJSONB(resp), nil)
+	}); err != nil {
 		return nil, err
 	}
-	return teamToResp(t), nil
+	return resp, nil
+}
+func (s *OrgService) DeleteTeam(ctx context.Context, id uuid.UUID, meta dto.AuditMeta) error {
+	t, err := s.teamRepo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	oldData := ToJSONB(teamToResp(t))
+	return s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.teamRepo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return s.auditSvc.Record(ctx, meta, "delete", "team", id.String(), oldData, nil, nil)
+	})
+}
+
+func holdingCompanyToResp(h *models.HoldingCompany) *dto.HoldingCompanyResponse {
+	return &dto.HoldingCompanyResponse{ID: h.ID.String(), Name: h.Name, Description: h.Description, CreatedAt: ts(h.CreatedAt)}
 }
-func (s *OrgService) DeleteTeam(id uuid.UUID) error {
-	return s.teamRepo.Delete(id)
+
+func companyToResp(c *models.Company) *dto.CompanyResponse {
+	return &dto.CompanyResponse{ID: c.ID.String(), HoldingCompanyID: c.HoldingCompanyID.String(), Name: c.Name, CreatedAt: ts(c.CreatedAt)}
+}
+
+func functionToResp(f *models.Function) *dto.FunctionResponse {
+	return &dto.FunctionResponse{ID: f.ID.String(), CompanyID: f.CompanyID.String(), Name: f.Name, CreatedAt: ts(f.CreatedAt)}
+}
+
+func departmentToResp(d *models.Department) *dto.DepartmentResponse {
+	return &dto.DepartmentResponse{ID: d.ID.String(), FunctionID: d.FunctionID.String(), Name: d.Name, CreatedAt: ts(d.CreatedAt)}
 }
 
 func teamToResp(t *models.Team) *dto.TeamResponse {
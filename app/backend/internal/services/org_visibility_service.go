@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/repositories"
+)
+
+// OrgVisibilityService resolves which users a manager may see: everyone in their reporting subtree
+// through direct managers, dotted-line managers and teams they lead. Results are cached for the
+// lifetime of a request when the context carries a cache (see WithVisibilityCache).
+type OrgVisibilityService struct {
+	userRepo repositories.UserRepository
+}
+
+func NewOrgVisibilityService(userRepo repositories.UserRepository) *OrgVisibilityService {
+	return &OrgVisibilityService{userRepo: userRepo}
+}
+
+type visibilityCacheKey struct{}
+
+type visibilityCache struct {
+	mu       sync.Mutex
+	subtrees map[uuid.UUID][]uuid.UUID
+}
+
+// WithVisibilityCache returns a context that caches reporting subtrees until the request ends.
+func WithVisibilityCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, visibilityCacheKey{}, &visibilityCache{subtrees: make(map[uuid.UUID][]uuid.UUID)})
+}
+
+// ReportingSubtree returns the users reporting to managerID, excluding managerID.
+func (s *OrgVisibilityService) ReportingSubtree(ctx context.Context, managerID uuid.UUID) ([]uuid.UUID, error) {
+	cache, _ := ctx.Value(visibilityCacheKey{}).(*visibilityCache)
+	if cache != nil {
+		cache.mu.Lock()
+		ids, ok := cache.subtrees[managerID]
+		cache.mu.Unlock()
+		if ok {
+			return ids, nil
+		}
+	}
+	ids, err := s.userRepo.ReportingSubtree(ctx, managerID)
+	if err != nil {
+		return nil, err
+	}
+	if cache != nil {
+		cache.mu.Lock()
+		cache.subtrees[managerID] = ids
+		cache.mu.Unlock()
+	}
+	return ids, nil
+}
+
+// VisibleUserIDs returns userID plus their reporting subtree.
+func (s *OrgVisibilityService) VisibleUserIDs(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	if s == nil {
+		return []uuid.UUID{userID}, nil
+	}
+	subtree, err := s.ReportingSubtree(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return append([]uuid.UUID{userID}, subtree...), nil
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rm/roadmap/backend/internal/models"
+	"github.com/rm/roadmap/backend/internal/repositories"
+	"go.uber.org/zap"
+)
+
+var (
+	outboxPending = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "outbox_pending_events",
+		Help: "Audit and activity events waiting in the outbox",
+	})
+	outboxFailed = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "outbox_failed_events",
+		Help: "Outbox events set aside after exhausting their delivery attempts",
+	})
+	outboxDispatchedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "outbox_dispatched_total",
+			Help: "Total number of outbox events written to their log table, by kind (audit or activity)",
+		},
+		[]string{"kind"},
+	)
+	outboxDispatchErrorsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "outbox_dispatch_errors_total",
+		Help: "Total number of failed outbox dispatch batches",
+	})
+)
+
+// maxOutboxBackoff caps the wait between retries after a failed batch.
+const maxOutboxBackoff = 30 * time.Second
+
+type OutboxConfig struct {
+	BatchSize    int           // events written per transaction
+	PollInterval time.Duration // how often the outbox is checked without a wake-up
+	MaxAttempts  int           // failed attempts before an event is set aside
+}
+
+// OutboxService is the audit and activity pipeline. Enqueue stores an event in the outbox, inside the
+// caller's transaction when there is one; a single background dispatcher per instance moves events in
+// batches, in insertion order, to audit_logs (sealing them into the hash chain) and activity_logs.
+type OutboxService struct {
+	repo         repositories.OutboxRepository
+	auditRepo    repositories.AuditRepository
+	activityRepo repositories.ActivityRepository
+	sink         AuditSink
+	cfg          OutboxConfig
+	log          *zap.Logger
+	wake         chan struct{}
+}
+
+// NewOutboxService builds the dispatcher; sink, when not nil, sees each batch of audit records once written.
+func NewOutboxService(repo repositories.OutboxRepository, auditRepo repositories.AuditRepository, activityRepo repositories.ActivityRepository, sink AuditSink, cfg OutboxConfig, log *zap.Logger) *OutboxService {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 10
+	}
+	if log == nil {
+		log = zap.NewNop()
+	}
+	return &OutboxService{repo: repo, auditRepo: auditRepo, activityRepo: activityRepo, sink: sink, cfg: cfg, log: log, wake: make(chan struct{}, 1)}
+}
+
+// Enqueue stores v (a models.AuditLog or models.ActivityLog) as an outbox event of the given kind and wakes the dispatcher.
+func (s *OutboxService) Enqueue(ctx context.Context, kind string, v interface{}) error {
+	payload := ToJSONB(v)
+	if payload == nil {
+		return fmt.Errorf("outbox: cannot encode %s event", kind)
+	}
+	// Keep ctx's transaction but not its cancellation: a client hanging up must not lose the record.
+	if err := s.repo.Enqueue(context.WithoutCancel(ctx), &models.OutboxEvent{Kind: kind, Payload: payload}); err != nil {
+		return err
+	}
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Run dispatches events until ctx is cancelled. Call Drain afterwards to flush what is left.
+func (s *OutboxService) Run(ctx context.Context) {
+	t := time.NewTicker(s.cfg.PollInterval)
+	defer t.Stop()
+	backoff := time.Duration(0)
+	for {
+		if backoff > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+		} else {
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.wake:
+			case <-t.C:
+			}
+		}
+		if err := s.flush(ctx, backoff > 0); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			backoff = min(max(2*backoff, s.cfg.PollInterval), maxOutboxBackoff)
+			s.log.Warn("outbox dispatch failed; retrying", zap.Error(err), zap.Duration("backoff", backoff))
+			continue
+		}
+		backoff = 0
+	}
+}
+
+// Drain dispatches everything pending, for graceful shutdown. It stops at the first error or when ctx expires;
+// undelivered events stay in the outbox for the next start.
+func (s *OutboxService) Drain(ctx context.Context) error {
+	return s.flush(ctx, false)
+}
+
+// flush dispatches batches until the outbox is empty. After a failure (retry) events go one at a time, so
+// one bad event cannot hold back the others; only such single-event failures count as attempts, and the
+// event is set aside after MaxAttempts.
+func (s *OutboxService) flush(ctx context.Context, retry bool) error {
+	defer s.refreshGauges(ctx)
+	limit := s.cfg.BatchSize
+	if retry {
+		limit = 1
+	}
+	for {
+		var batch []models.OutboxEvent
+		n, err := s.repo.Dispatch(ctx, limit, func(txCtx context.Context, events []models.OutboxEvent) error {
+			batch = events
+			return s.deliver(txCtx, events)
+		})
+		if err != nil {
+			outboxDispatchErrorsTotal.Inc()
+			if len(batch) == 1 && ctx.Err() == nil {
+				if mErr := s.repo.MarkFailed(ctx, []int64{batch[0].ID}, err.Error(), s.cfg.MaxAttempts); mErr != nil {
+					s.log.Error("outbox mark failed", zap.Error(mErr))
+				}
+			}
+			return err
+		}
+		for i := range batch {
+			outboxDispatchedTotal.WithLabelValues(batch[i].Kind).Inc()
+		}
+		if n < limit {
+			return nil
+		}
+		limit = s.cfg.BatchSize
+	}
+}
+
+func (s *OutboxService) deliver(ctx context.Context, events []models.OutboxEvent) error {
+	var audits []models.AuditLog
+	var activities []models.ActivityLog
+	for i := range events {
+		b, err := json.Marshal(events[i].Payload)
+		if err != nil {
+			return fmt.Errorf("outbox event %d: %w", events[i].ID, err)
+		}
+		switch events[i].Kind {
+		case models.OutboxKindAudit:
+			var a models.AuditLog
+			if err := json.Unmarshal(b, &a); err != nil {
+				return fmt.Errorf("outbox event %d: %w", events[i].ID, err)
+			}
+			audits = append(audits, a)
+		case models.OutboxKindActivity:
+			var a models.ActivityLog
+			if err := json.Unmarshal(b, &a); err != nil {
+				return fmt.Errorf("outbox event %d: %w", events[i].ID, err)
+			}
+			activities = append(activities, a)
+		default:
+			return fmt.Errorf("outbox event %d: unknown kind %q", events[i].ID, events[i].Kind)
+		}
+	}
+	if err := s.auditRepo.CreateBatch(ctx, audits); err != nil {
+		return err
+	}
+	if s.sink != nil {
+		if err := s.sink.AuditWritten(ctx, audits); err != nil {
+			return err
+		}
+	}
+	return s.activityRepo.CreateBatch(ctx, activities)
+}
+
+func (s *OutboxService) refreshGauges(ctx context.Context) {
+	if ctx.Err() != nil {
+		return
+	}
+	pending, failed, err := s.repo.Stats(ctx)
+	if err != nil {
+		s.log.Warn("outbox stats failed", zap.Error(err))
+		return
+	}
+	outboxPending.Set(float64(pending))
+	outboxFailed.Set(float64(failed))
+}
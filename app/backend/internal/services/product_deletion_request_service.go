@@ -2,11 +2,12 @@ package services
 
 import (
 	"context"
-	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/authz"
 	"github.com/rm/roadmap/backend/internal/dto"
+	"github.com/rm/roadmap/backend/internal/i18n"
 	"github.com/rm/roadmap/backend/internal/models"
 	"github.com/rm/roadmap/backend/internal/repositories"
 )
@@ -16,9 +17,11 @@ type ProductDeletionRequestService struct {
 	productRepo     repositories.ProductRepository
 	versionRepo     repositories.ProductVersionRepository
 	userRepo        repositories.UserRepository
+	tx              repositories.Transactor
 	auditSvc        *AuditService
 	activitySvc     *ActivityService
 	notificationSvc *NotificationService
+	policy          *authz.Engine
 }
 
 func NewProductDeletionRequestService(
@@ -26,18 +29,22 @@ func NewProductDeletionRequestService(
 	productRepo repositories.ProductRepository,
 	versionRepo repositories.ProductVersionRepository,
 	userRepo repositories.UserRepository,
+	tx repositories.Transactor,
 	auditSvc *AuditService,
 	activitySvc *ActivityService,
 	notificationSvc *NotificationService,
+	policy *authz.Engine,
 ) *ProductDeletionRequestService {
 	return &ProductDeletionRequestService{
 		reqRepo:         reqRepo,
 		productRepo:     productRepo,
 		versionRepo:     versionRepo,
 		userRepo:        userRepo,
+		tx:              tx,
 		auditSvc:        auditSvc,
 		activitySvc:     activitySvc,
 		notificationSvc: notificationSvc,
+		policy:          policy,
 	}
 }
 
@@ -47,53 +54,58 @@ func (s *ProductDeletionRequestService) Create(ctx context.Context, productID uu
 		RequestedBy: requestedBy,
 		Status:      models.RequestPending,
 	}
-	if err := s.reqRepo.Create(req); err != nil {
-		return nil, err
-	}
-	resp := deletionRequestToResponse(req)
-	if s.auditSvc != nil {
-		s.auditSvc.Log(ctx, AuditEntry{
-			UserID:     meta.UserID,
-			Action:     "create",
-			EntityType: "product_deletion_request",
-			EntityID:   req.ID.String(),
-			NewData:    ToJSONB(resp),
-			IPAddress:  meta.IP,
-			UserAgent:  meta.UserAgent,
-			TraceID:    meta.TraceID,
-		})
-	}
-	if s.activitySvc != nil && meta.UserID != nil {
-		details := req.ProductID.String()
-		if p, err := s.productRepo.GetByID(productID); err == nil {
-			details = p.Name
+	var resp *dto.ProductDeletionRequestResponse
+	if err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.reqRepo.Create(ctx, req); err != nil {
+			return err
+		}
+		resp = deletionRequestToResponse(req)
+		if s.auditSvc != nil {
+			if err := s.auditSvc.Log(ctx, AuditEntry{
+				UserID:     meta.UserID,
+				Action:     "create",
+				EntityType: "product_deletion_request",
+				EntityID:   req.ID.String(),
+				NewData:    ToJSONB(resp),
+				IPAddress:  meta.IP,
+				UserAgent:  meta.UserAgent,
+				TraceID:    meta.TraceID,
+			}); err != nil {
+				return err
+			}
 		}
-		s.activitySvc.Log(ctx, ActivityEntry{
-			UserID:     meta.UserID,
-			Action:     "create",
-			EntityType: "product_deletion_request",
-			EntityID:   req.ID.String(),
-			Details:    details,
-			IPAddress:  meta.IP,
-			UserAgent:  meta.UserAgent,
-		})
+		if s.activitySvc != nil && meta.UserID != nil {
+			details := req.ProductID.String()
+			if p, err := s.productRepo.GetByID(ctx, productID); err == nil {
+				details = p.Name
+			}
+			if err := s.activitySvc.Log(ctx, ActivityEntry{
+				UserID:     meta.UserID,
+				Action:     "create",
+				EntityType: "product_deletion_request",
+				EntityID:   req.ID.String(),
+				Details:    details,
+				IPAddress:  meta.IP,
+				UserAgent:  meta.UserAgent,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
 	}
 	// Notify admin and superadmin users (so they can review and approve/reject)
 	if s.notificationSvc != nil && s.userRepo != nil {
 		admins, _ := s.userRepo.ListByRole(models.RoleAdmin)
 		superadmins, _ := s.userRepo.ListByRole(models.RoleSuperadmin)
 		productName := ""
-		if p, err := s.productRepo.GetByID(productID); err == nil {
+		if p, err := s.productRepo.GetByID(ctx, productID); err == nil {
 			productName = p.Name
 		}
 		reqID := req.ID
-		title := "New product deletion request"
-		message := "A user has requested to delete a product."
-		if productName != "" {
-			message = "A user has requested to delete the product \"" + productName + "\". Review and approve or reject from the Requests page."
-		} else {
-			message = "A user has requested to delete a product. Review and approve or reject from the Requests page."
-		}
+		title := i18n.M("notification.product_deletion_request_submitted.title")
+		message := productDeletionMessage(models.NotificationTypeProductDeletionRequestSubmitted, productName)
 		for i := range admins {
 			if admins[i].ID != requestedBy {
 				_, _ = s.notificationSvc.Create(admins[i].ID, models.NotificationTypeProductDeletionRequestSubmitted, title, message, "product_deletion_request", &reqID)
@@ -108,12 +120,12 @@ func (s *ProductDeletionRequestService) Create(ctx context.Context, productID uu
 	return resp, nil
 }
 
-func (s *ProductDeletionRequestService) List(status *models.RequestStatus, callerID uuid.UUID, callerRole string, ownerID *uuid.UUID, fromDate, toDate *time.Time) ([]dto.ProductDeletionRequestResponse, error) {
+func (s *ProductDeletionRequestService) List(ctx context.Context, status *models.RequestStatus, callerID uuid.UUID, callerRole string, ownerID *uuid.UUID, fromDate, toDate *time.Time) ([]dto.ProductDeletionRequestResponse, error) {
 	var productOwnerID *uuid.UUID
 	var requestedBy *uuid.UUID
 	if ownerID != nil {
 		requestedBy = ownerID
-	} else if !models.Role(strings.ToLower(callerRole)).IsAdminOrAbove() {
+	} else if !s.policy.Allowed(ctx, authz.Subject{UserID: callerID, Role: models.Role(callerRole)}, authz.PermRequestApprove, authz.Any) {
 		productOwnerID = &callerID
 	}
 	list, err := s.reqRepo.List(status, productOwnerID, requestedBy, fromDate, toDate)
@@ -133,96 +145,95 @@ func (s *ProductDeletionRequestService) Approve(ctx context.Context, id uuid.UUI
 		return nil, err
 	}
 	oldResp := deletionRequestToResponse(req)
-	if approved {
-		// Archive the product instead of deleting it (double confirmation is done in the UI)
-		p, err := s.productRepo.GetByID(req.ProductID)
-		if err != nil {
-			return nil, err
+	var newResp *dto.ProductDeletionRequestResponse
+	if err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if approved {
+			// Archive the product instead of deleting it (double confirmation is done in the UI)
+			p, err := s.productRepo.GetByID(ctx, req.ProductID)
+			if err != nil {
+				return err
+			}
+			oldProductResp := productToResponse(p)
+			p.Status = models.StatusArchived
+			if err := s.productRepo.Update(ctx, p); err != nil {
+				return err
+			}
+			fresh, _ := s.productRepo.GetByID(ctx, req.ProductID)
+			newProductResp := productToResponse(fresh)
+			if s.auditSvc != nil {
+				if err := s.auditSvc.Log(ctx, AuditEntry{
+					UserID:     meta.UserID,
+					Action:     "update",
+					EntityType: "product",
+					EntityID:   p.ID.String(),
+					OldData:    ToJSONB(oldProductResp),
+					NewData:    ToJSONB(newProductResp),
+					IPAddress:  meta.IP,
+					UserAgent:  meta.UserAgent,
+					TraceID:    meta.TraceID,
+				}); err != nil {
+					return err
+				}
+			}
+			req.Status = models.RequestApproved
+		} else {
+			req.Status = models.RequestRejected
 		}
-		oldProductResp := productToResponse(p)
-		p.Status = models.StatusArchived
-		if err := s.productRepo.Update(p); err != nil {
-			return nil, err
+		if err := s.reqRepo.Update(ctx, req); err != nil {
+			return err
 		}
-		fresh, _ := s.productRepo.GetByID(req.ProductID)
-		newProductResp := productToResponse(fresh)
+		newResp = deletionRequestToResponse(req)
 		if s.auditSvc != nil {
-			s.auditSvc.Log(ctx, AuditEntry{
+			action := "approve"
+			if !approved {
+				action = "reject"
+			}
+			if err := s.auditSvc.Log(ctx, AuditEntry{
 				UserID:     meta.UserID,
-				Action:     "update",
-				EntityType: "product",
-				EntityID:   p.ID.String(),
-				OldData:    ToJSONB(oldProductResp),
-				NewData:    ToJSONB(newProductResp),
+				Action:     action,
+				EntityType: "product_deletion_request",
+				EntityID:   id.String(),
+				OldData:    ToJSONB(oldResp),
+				NewData:    ToJSONB(newResp),
 				IPAddress:  meta.IP,
 				UserAgent:  meta.UserAgent,
 				TraceID:    meta.TraceID,
-			})
-		}
-		req.Status = models.RequestApproved
-	} else {
-		req.Status = models.RequestRejected
-	}
-	if err := s.reqRepo.Update(req); err != nil {
-		return nil, err
-	}
-	newResp := deletionRequestToResponse(req)
-	if s.auditSvc != nil {
-		action := "approve"
-		if !approved {
-			action = "reject"
+			}); err != nil {
+				return err
+			}
 		}
-		s.auditSvc.Log(ctx, AuditEntry{
-			UserID:     meta.UserID,
-			Action:     action,
-			EntityType: "product_deletion_request",
-			EntityID:   id.String(),
-			OldData:    ToJSONB(oldResp),
-			NewData:    ToJSONB(newResp),
-			IPAddress:  meta.IP,
-			UserAgent:  meta.UserAgent,
-			TraceID:    meta.TraceID,
-		})
-	}
-	if s.activitySvc != nil && meta.UserID != nil {
-		details := string(req.Status)
-		if p, err := s.productRepo.GetByID(req.ProductID); err == nil {
-			details = p.Name + " " + details
+		if s.activitySvc != nil && meta.UserID != nil {
+			details := string(req.Status)
+			if p, err := s.productRepo.GetByID(ctx, req.ProductID); err == nil {
+				details = p.Name + " " + details
+			}
+			if err := s.activitySvc.Log(ctx, ActivityEntry{
+				UserID:     meta.UserID,
+				Action:     "save",
+				EntityType: "product_deletion_request",
+				EntityID:   id.String(),
+				Details:    details,
+				IPAddress:  meta.IP,
+				UserAgent:  meta.UserAgent,
+			}); err != nil {
+				return err
+			}
 		}
-		s.activitySvc.Log(ctx, ActivityEntry{
-			UserID:     meta.UserID,
-			Action:     "save",
-			EntityType: "product_deletion_request",
-			EntityID:   id.String(),
-			Details:    details,
-			IPAddress:  meta.IP,
-			UserAgent:  meta.UserAgent,
-		})
+		return nil
+	}); err != nil {
+		return nil, err
 	}
 	if s.notificationSvc != nil {
 		productName := ""
-		if p, err := s.productRepo.GetByID(req.ProductID); err == nil {
+		if p, err := s.productRepo.GetByID(ctx, req.ProductID); err == nil {
 			productName = p.Name
 		}
-		var notifType, title, message string
-		if approved {
-			notifType = models.NotificationTypeProductDeletionApproved
-			title = "Product deletion request approved"
-			if productName != "" {
-				message = "Your request to delete the product \"" + productName + "\" was approved. The product has been archived."
-			} else {
-				message = "Your request to delete the product was approved. The product has been archived."
-			}
-		} else {
+		notifType := models.NotificationTypeProductDeletionApproved
+		if !approved {
 			notifType = models.NotificationTypeProductDeletionRejected
-			title = "Product deletion request rejected"
-			if productName != "" {
-				message = "Your request to delete the product \"" + productName + "\" was rejected. The product remains active."
-			} else {
-				message = "Your request to delete the product was rejected. The product remains active."
-			}
 		}
-		_, _ = s.notificationSvc.Create(req.RequestedBy, notifType, title, message, "product_deletion_request", &id)
+		title := i18n.M("notification." + notifType + ".title")
+		_, _ = s.notificationSvc.Create(req.RequestedBy, notifType, title, productDeletionMessage(notifType, productName), "product_deletion_request", &id)
 	}
 	return newResp, nil
 }
@@ -241,3 +252,12 @@ func deletionRequestToResponse(r *models.ProductDeletionRequest) *dto.ProductDel
 	}
 	return resp
 }
+
+// productDeletionMessage is the message of a deletion request notification, naming the product when it is
+// still known.
+func productDeletionMessage(notifType, productName string) i18n.Message {
+	if productName == "" {
+		return i18n.M("notification." + notifType + ".message_unnamed")
+	}
+	return i18n.M("notification."+notifType+".message", "product", productName)
+}
@@ -0,0 +1,301 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/authz"
+	"github.com/rm/roadmap/backend/internal/dto"
+	"github.com/rm/roadmap/backend/internal/i18n"
+	"github.com/rm/roadmap/backend/internal/models"
+	"github.com/rm/roadmap/backend/internal/repositories"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrProductMemberNotFound = errors.New("product member not found")
+	ErrProductMemberExists   = errors.New("user is already a member of this product")
+	ErrInvalidMemberRole     = errors.New("role must be co_owner, editor or viewer")
+	ErrMemberIsOwner         = errors.New("user is the product owner")
+	ErrUserNotFound          = errors.New("user not found")
+)
+
+// Member roles that act for the owner when editing milestones, versions and dependencies.
+var productEditorRoles = []models.ProductMemberRole{models.ProductMemberCoOwner, models.ProductMemberEditor}
+
+// productResource builds the authz resource for a product. Members holding one of roles are
+// delegates, so ":own" grants apply to them as they do to the owner.
+func productResource(ctx context.Context, memberRepo repositories.ProductMemberRepository, p *models.Product, roles ...models.ProductMemberRole) (authz.Resource, error) {
+	var delegates []uuid.UUID
+	if memberRepo != nil && len(roles) > 0 {
+		members, err := memberRepo.ListByProduct(ctx, p.ID)
+		if err != nil {
+			return authz.Resource{}, err
+		}
+		for _, m := range members {
+			for _, r := range roles {
+				if m.Role == r {
+					delegates = append(delegates, m.UserID)
+					break
+				}
+			}
+		}
+	}
+	return authz.Owned("product", p.ID.String(), p.OwnerID, delegates...), nil
+}
+
+// ProductMemberService manages per-product collaborators (co-owners, editors, viewers).
+type ProductMemberService struct {
+	memberRepo      repositories.ProductMemberRepository
+	productRepo     repositories.ProductRepository
+	products        *ProductService
+	userRepo        repositories.UserRepository
+	tx              repositories.Transactor
+	auditSvc        *AuditService
+	activitySvc     *ActivityService
+	notificationSvc *NotificationService
+	policy          *authz.Engine
+}
+
+func NewProductMemberService(
+	memberRepo repositories.ProductMemberRepository,
+	productRepo repositories.ProductRepository,
+	products *ProductService,
+	userRepo repositories.UserRepository,
+	tx repositories.Transactor,
+	auditSvc *AuditService,
+	activitySvc *ActivityService,
+	notificationSvc *NotificationService,
+	policy *authz.Engine,
+) *ProductMemberService {
+	return &ProductMemberService{
+		memberRepo:      memberRepo,
+		productRepo:     productRepo,
+		products:        products,
+		userRepo:        userRepo,
+		tx:              tx,
+		auditSvc:        auditSvc,
+		activitySvc:     activitySvc,
+		notificationSvc: notificationSvc,
+		policy:          policy,
+	}
+}
+
+// List returns the members of a product the caller may read; other products are reported as not found.
+func (s *ProductMemberService) List(ctx context.Context, productID, callerID uuid.UUID, callerRole models.Role) ([]dto.ProductMemberResponse, error) {
+	p, err := s.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, ErrProductNotFound
+	}
+	if err := s.products.CanRead(ctx, p, callerID, callerRole); err != nil {
+		return nil, err
+	}
+	list, err := s.memberRepo.ListByProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dto.ProductMemberResponse, len(list))
+	for i := range list {
+		out[i] = productMemberToResponse(&list[i])
+	}
+	return out, nil
+}
+
+func (s *ProductMemberService) Add(ctx context.Context, productID uuid.UUID, req dto.ProductMemberAddRequest, callerID uuid.UUID, callerRole models.Role, meta dto.AuditMeta) (*dto.ProductMemberResponse, error) {
+	role := models.ProductMemberRole(req.Role)
+	if !role.Valid() {
+		return nil, ErrInvalidMemberRole
+	}
+	userID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	p, err := s.canManage(ctx, productID, callerID, callerRole)
+	if err != nil {
+		return nil, err
+	}
+	if p.OwnerID != nil && *p.OwnerID == userID {
+		return nil, ErrMemberIsOwner
+	}
+	if _, err := s.userRepo.GetByID(userID); err != nil {
+		return nil, ErrUserNotFound
+	}
+	if _, err := s.memberRepo.Get(ctx, productID, userID); err == nil {
+		return nil, ErrProductMemberExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	m := &models.ProductMember{ProductID: productID, UserID: userID, Role: role, AddedBy: meta.UserID}
+	var resp dto.ProductMemberResponse
+	if err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.memberRepo.Create(ctx, m); err != nil {
+			return err
+		}
+		created, err := s.memberRepo.Get(ctx, productID, userID)
+		if err != nil {
+			return err
+		}
+		resp = productMemberToResponse(created)
+		return s.logChange(ctx, "create", created, nil, &resp, p.Name, meta)
+	}); err != nil {
+		return nil, err
+	}
+	s.notify(userID, models.NotificationTypeProductMemberAdded,
+		i18n.M("notification.product_member_added.message", "product", p.Name).Term("role", memberRoleTerm(role)), productID)
+	return &resp, nil
+}
+
+func (s *ProductMemberService) UpdateRole(ctx context.Context, productID, userID uuid.UUID, req dto.ProductMemberUpdateRequest, callerID uuid.UUID, callerRole models.Role, meta dto.AuditMeta) (*dto.ProductMemberResponse, error) {
+	role := models.ProductMemberRole(req.Role)
+	if !role.Valid() {
+		return nil, ErrInvalidMemberRole
+	}
+	p, err := s.canManage(ctx, productID, callerID, callerRole)
+	if err != nil {
+		return nil, err
+	}
+	m, err := s.memberRepo.Get(ctx, productID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductMemberNotFound
+		}
+		return nil, err
+	}
+	oldResp := productMemberToResponse(m)
+	if m.Role == role {
+		return &oldResp, nil
+	}
+	m.Role = role
+	resp := productMemberToResponse(m)
+	if err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.memberRepo.UpdateRole(ctx, m.ID, role); err != nil {
+			return err
+		}
+		return s.logChange(ctx, "update", m, &oldResp, &resp, p.Name, meta)
+	}); err != nil {
+		return nil, err
+	}
+	s.notify(userID, models.NotificationTypeProductMemberRoleChanged,
+		i18n.M("notification.product_member_role_changed.message", "product", p.Name).Term("role", memberRoleTerm(role)), productID)
+	return &resp, nil
+}
+
+func (s *ProductMemberService) Remove(ctx context.Context, productID, userID uuid.UUID, callerID uuid.UUID, callerRole models.Role, meta dto.AuditMeta) error {
+	m, err := s.memberRepo.Get(ctx, productID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrProductMemberNotFound
+		}
+		return err
+	}
+	// Members may always leave a product on their own.
+	var p *models.Product
+	if userID == callerID {
+		if p, err = s.productRepo.GetByID(ctx, productID); err != nil {
+			return ErrProductNotFound
+		}
+	} else if p, err = s.canManage(ctx, productID, callerID, callerRole); err != nil {
+		return err
+	}
+	oldResp := productMemberToResponse(m)
+	if err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.memberRepo.Delete(ctx, m.ID); err != nil {
+			return err
+		}
+		return s.logChange(ctx, "delete", m, &oldResp, nil, p.Name, meta)
+	}); err != nil {
+		return err
+	}
+	if userID != callerID {
+		s.notify(userID, models.NotificationTypeProductMemberRemoved,
+			i18n.M("notification.product_member_removed.message", "product", p.Name), productID)
+	}
+	return nil
+}
+
+// canManage checks product:members; co-owners manage members like the owner.
+func (s *ProductMemberService) canManage(ctx context.Context, productID, callerID uuid.UUID, callerRole models.Role) (*models.Product, error) {
+	p, err := s.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, ErrProductNotFound
+	}
+	res, err := productResource(ctx, s.memberRepo, p, models.ProductMemberCoOwner)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.policy.Authorize(ctx, authz.Subject{UserID: callerID, Role: callerRole}, authz.PermProductMembers, res); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (s *ProductMemberService) logChange(ctx context.Context, action string, m *models.ProductMember, oldResp, newResp *dto.ProductMemberResponse, productName string, meta dto.AuditMeta) error {
+	if s.auditSvc != nil {
+		entry := AuditEntry{
+			UserID:     meta.UserID,
+			Action:     action,
+			EntityType: "product_member",
+			EntityID:   m.ID.String(),
+			IPAddress:  meta.IP,
+			UserAgent:  meta.UserAgent,
+			TraceID:    meta.TraceID,
+		}
+		if oldResp != nil {
+			entry.OldData = ToJSONB(oldResp)
+		}
+		if newResp != nil {
+			entry.NewData = ToJSONB(newResp)
+		}
+		if err := s.auditSvc.Log(ctx, entry); err != nil {
+			return err
+		}
+	}
+	if s.activitySvc != nil && meta.UserID != nil {
+		return s.activitySvc.Log(ctx, ActivityEntry{
+			UserID:     meta.UserID,
+			Action:     action,
+			EntityType: "product_member",
+			EntityID:   m.ID.String(),
+			Details:    fmt.Sprintf("%s: %s (%s)", productName, m.User.Email, m.Role),
+			IPAddress:  meta.IP,
+			UserAgent:  meta.UserAgent,
+		})
+	}
+	return nil
+}
+
+func (s *ProductMemberService) notify(userID uuid.UUID, notifType string, msg i18n.Message, productID uuid.UUID) {
+	if s.notificationSvc == nil {
+		return
+	}
+	_, _ = s.notificationSvc.Create(userID, notifType, i18n.M("notification."+notifType+".title"), msg, "product", &productID)
+}
+
+// memberRoleTerm is the catalog key of a member role's name.
+func memberRoleTerm(r models.ProductMemberRole) string {
+	switch r {
+	case models.ProductMemberCoOwner, models.ProductMemberEditor:
+		return "term.member_role." + string(r)
+	default:
+		return "term.member_role.viewer"
+	}
+}
+
+func productMemberToResponse(m *models.ProductMember) dto.ProductMemberResponse {
+	resp := dto.ProductMemberResponse{
+		ID:        m.ID.String(),
+		ProductID: m.ProductID.String(),
+		UserID:    m.UserID.String(),
+		UserName:  m.User.Name,
+		UserEmail: m.User.Email,
+		Role:      string(m.Role),
+		CreatedAt: m.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if m.AddedBy != nil {
+		s := m.AddedBy.String()
+		resp.AddedBy = &s
+	}
+	return resp
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/authz"
+	"github.com/rm/roadmap/backend/internal/models"
+	"github.com/rm/roadmap/backend/internal/repositories"
+	"gorm.io/gorm"
+)
+
+// staticGrants serves a fixed role_permissions table.
+type staticGrants struct {
+	repositories.RolePermissionRepository
+	grants map[models.Role][]string
+}
+
+func (g staticGrants) ListAll(context.Context) ([]models.RolePermission, error) {
+	var out []models.RolePermission
+	for role, perms := range g.grants {
+		for _, p := range perms {
+			out = append(out, models.RolePermission{Role: role, Permission: p})
+		}
+	}
+	return out, nil
+}
+
+type memProducts struct {
+	repositories.ProductRepository
+	byID map[uuid.UUID]*models.Product
+}
+
+func (m *memProducts) GetByID(_ context.Context, id uuid.UUID) (*models.Product, error) {
+	if p, ok := m.byID[id]; ok {
+		return p, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+type memMembers struct {
+	repositories.ProductMemberRepository
+	byProduct map[uuid.UUID][]models.ProductMember
+}
+
+func (m *memMembers) ListByProduct(_ context.Context, productID uuid.UUID) ([]models.ProductMember, error) {
+	return m.byProduct[productID], nil
+}
+
+func TestProductMemberListScope(t *testing.T) {
+	owner, viewer, stranger := uuid.New(), uuid.New(), uuid.New()
+	p := &models.Product{ID: uuid.New(), OwnerID: &owner}
+	products := &memProducts{byID: map[uuid.UUID]*models.Product{p.ID: p}}
+	members := &memMembers{byProduct: map[uuid.UUID][]models.ProductMember{
+		p.ID: {{ID: uuid.New(), ProductID: p.ID, UserID: viewer, Role: models.ProductMemberViewer}},
+	}}
+	policy := authz.NewEngine(staticGrants{grants: map[models.Role][]string{
+		models.RoleAdmin: {string(authz.PermProductRead)},
+		models.RoleOwner: {string(authz.PermProductRead) + authz.OwnSuffix},
+	}}, time.Minute)
+	productSvc := NewProductService(products, nil, nil, nil, nil, members, nil, nil, nil, nil, nil, policy, nil, nil)
+	s := NewProductMemberService(members, products, productSvc, nil, nil, nil, nil, nil, policy)
+
+	for _, tc := range []struct {
+		name   string
+		caller uuid.UUID
+		role   models.Role
+		want   error
+	}{
+		{"product:read", stranger, models.RoleAdmin, nil},
+		{"owner with :own", owner, models.RoleOwner, nil},
+		{"member with :own", viewer, models.RoleOwner, nil},
+		{"outside the subtree with :own", stranger, models.RoleOwner, ErrProductNotFound},
+		{"no grant", owner, models.RoleUser, ErrProductNotFound},
+	} {
+		list, err := s.List(context.Background(), p.ID, tc.caller, tc.role)
+		if !errors.Is(err, tc.want) || (tc.want == nil && err != nil) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+		if tc.want == nil && len(list) != 1 {
+			t.Errorf("%s: %d members, want 1", tc.name, len(list))
+		}
+	}
+	if _, err := s.List(context.Background(), uuid.New(), owner, models.RoleAdmin); err != ErrProductNotFound {
+		t.Errorf("unknown product: got %v", err)
+	}
+}
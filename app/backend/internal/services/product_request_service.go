@@ -2,11 +2,12 @@ package services
 
 import (
 	"context"
-	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/authz"
 	"github.com/rm/roadmap/backend/internal/dto"
+	"github.com/rm/roadmap/backend/internal/i18n"
 	"github.com/rm/roadmap/backend/internal/models"
 	"github.com/rm/roadmap/backend/internal/repositories"
 )
@@ -15,20 +16,24 @@ type ProductRequestService struct {
 	reqRepo         repositories.ProductRequestRepository
 	productRepo     repositories.ProductRepository
 	userRepo        repositories.UserRepository
+	tx              repositories.Transactor
 	auditSvc        *AuditService
 	activitySvc     *ActivityService
 	notificationSvc *NotificationService
+	policy          *authz.Engine
 }
 
 func NewProductRequestService(
 	reqRepo repositories.ProductRequestRepository,
 	productRepo repositories.ProductRepository,
 	userRepo repositories.UserRepository,
+	tx repositories.Transactor,
 	auditSvc *AuditService,
 	activitySvc *ActivityService,
 	notificationSvc *NotificationService,
+	policy *authz.Engine,
 ) *ProductRequestService {
-	return &ProductRequestService{reqRepo: reqRepo, productRepo: productRepo, userRepo: userRepo, auditSvc: auditSvc, activitySvc: activitySvc, notificationSvc: notificationSvc}
+	return &ProductRequestService{reqRepo: reqRepo, productRepo: productRepo, userRepo: userRepo, tx: tx, auditSvc: auditSvc, activitySvc: activitySvc, notificationSvc: notificationSvc, policy: policy}
 }
 
 func (s *ProductRequestService) Create(ctx context.Context, req dto.ProductRequestCreateRequest, userID uuid.UUID, meta dto.AuditMeta) (*dto.ProductRequestResponse, error) {
@@ -38,40 +43,50 @@ func (s *ProductRequestService) Create(ctx context.Context, req dto.ProductReque
 		Description: req.Description,
 		Status:      models.RequestPending,
 	}
-	if err := s.reqRepo.Create(r); err != nil {
+	var resp *dto.ProductRequestResponse
+	if err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.reqRepo.Create(ctx, r); err != nil {
+			return err
+		}
+		resp = productRequestToResponse(r)
+		if s.auditSvc != nil {
+			if err := s.auditSvc.Log(ctx, AuditEntry{
+				UserID:     meta.UserID,
+				Action:     "create",
+				EntityType: "product_request",
+				EntityID:   r.ID.String(),
+				NewData:    ToJSONB(resp),
+				IPAddress:  meta.IP,
+				UserAgent:  meta.UserAgent,
+				TraceID:    meta.TraceID,
+			}); err != nil {
+				return err
+			}
+		}
+		if s.activitySvc != nil && meta.UserID != nil {
+			if err := s.activitySvc.Log(ctx, ActivityEntry{
+				UserID:     meta.UserID,
+				Action:     "create",
+				EntityType: "product_request",
+				EntityID:   r.ID.String(),
+				Details:    r.Name,
+				IPAddress:  meta.IP,
+				UserAgent:  meta.UserAgent,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
 		return nil, err
 	}
-	resp := productRequestToResponse(r)
-	if s.auditSvc != nil {
-		s.auditSvc.Log(ctx, AuditEntry{
-			UserID:     meta.UserID,
-			Action:     "create",
-			EntityType: "product_request",
-			EntityID:   r.ID.String(),
-			NewData:    ToJSONB(resp),
-			IPAddress:  meta.IP,
-			UserAgent:  meta.UserAgent,
-			TraceID:    meta.TraceID,
-		})
-	}
-	if s.activitySvc != nil && meta.UserID != nil {
-		s.activitySvc.Log(ctx, ActivityEntry{
-			UserID:     meta.UserID,
-			Action:     "create",
-			EntityType: "product_request",
-			EntityID:   r.ID.String(),
-			Details:    r.Name,
-			IPAddress:  meta.IP,
-			UserAgent:  meta.UserAgent,
-		})
-	}
 	// Notify admin and superadmin users (so they can review and approve/reject)
 	if s.notificationSvc != nil && s.userRepo != nil {
 		admins, _ := s.userRepo.ListByRole(models.RoleAdmin)
 		superadmins, _ := s.userRepo.ListByRole(models.RoleSuperadmin)
 		reqID := r.ID
-		title := "New product creation request"
-		message := "A user has submitted a product creation request: \"" + r.Name + "\". Review and approve or reject from the Requests page."
+		title := i18n.M("notification.product_request_submitted.title")
+		message := i18n.M("notification.product_request_submitted.message", "product", r.Name)
 		for i := range admins {
 			if admins[i].ID != userID {
 				_, _ = s.notificationSvc.Create(admins[i].ID, models.NotificationTypeProductRequestSubmitted, title, message, "product_request", &reqID)
@@ -94,11 +109,11 @@ func (s *ProductRequestService) GetByID(id uuid.UUID) (*dto.ProductRequestRespon
 	return productRequestToResponse(r), nil
 }
 
-func (s *ProductRequestService) List(status *models.RequestStatus, callerID uuid.UUID, callerRole string, ownerID *uuid.UUID, fromDate, toDate *time.Time) ([]dto.ProductRequestResponse, error) {
+func (s *ProductRequestService) List(ctx context.Context, status *models.RequestStatus, callerID uuid.UUID, callerRole string, ownerID *uuid.UUID, fromDate, toDate *time.Time) ([]dto.ProductRequestResponse, error) {
 	var requestedBy *uuid.UUID
 	if ownerID != nil {
 		requestedBy = ownerID
-	} else if !models.Role(strings.ToLower(callerRole)).IsAdminOrAbove() {
+	} else if !s.policy.Allowed(ctx, authz.Subject{UserID: callerID, Role: models.Role(callerRole)}, authz.PermRequestApprove, authz.Any) {
 		requestedBy = &callerID
 	}
 	list, err := s.reqRepo.List(status, requestedBy, fromDate, toDate)
@@ -118,62 +133,70 @@ func (s *ProductRequestService) Approve(ctx context.Context, id uuid.UUID, appro
 		return nil, err
 	}
 	oldResp := productRequestToResponse(r)
-	if approved && ownerID != nil {
-		r.Status = models.RequestApproved
-		product := &models.Product{
-			Name:        r.Name,
-			Description: r.Description,
-			OwnerID:     ownerID,
-			Status:      models.StatusApproved,
+	var newResp *dto.ProductRequestResponse
+	if err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if approved && ownerID != nil {
+			r.Status = models.RequestApproved
+			product := &models.Product{
+				Name:        r.Name,
+				Description: r.Description,
+				OwnerID:     ownerID,
+				Status:      models.StatusApproved,
+			}
+			if err := s.productRepo.Create(ctx, product); err != nil {
+				return err
+			}
+		} else {
+			r.Status = models.RequestRejected
 		}
-		if err := s.productRepo.Create(product); err != nil {
-			return nil, err
+		if err := s.reqRepo.Update(ctx, r); err != nil {
+			return err
 		}
-	} else {
-		r.Status = models.RequestRejected
-	}
-	if err := s.reqRepo.Update(r); err != nil {
-		return nil, err
-	}
-	newResp := productRequestToResponse(r)
-	if s.auditSvc != nil {
-		action := "approve"
-		if !approved {
-			action = "reject"
+		newResp = productRequestToResponse(r)
+		if s.auditSvc != nil {
+			action := "approve"
+			if !approved {
+				action = "reject"
+			}
+			if err := s.auditSvc.Log(ctx, AuditEntry{
+				UserID:     meta.UserID,
+				Action:     action,
+				EntityType: "product_request",
+				EntityID:   id.String(),
+				OldData:    ToJSONB(oldResp),
+				NewData:    ToJSONB(newResp),
+				IPAddress:  meta.IP,
+				UserAgent:  meta.UserAgent,
+				TraceID:    meta.TraceID,
+			}); err != nil {
+				return err
+			}
 		}
-		s.auditSvc.Log(ctx, AuditEntry{
-			UserID:     meta.UserID,
-			Action:     action,
-			EntityType: "product_request",
-			EntityID:   id.String(),
-			OldData:    ToJSONB(oldResp),
-			NewData:    ToJSONB(newResp),
-			IPAddress:  meta.IP,
-			UserAgent:  meta.UserAgent,
-			TraceID:    meta.TraceID,
-		})
-	}
-	if s.activitySvc != nil && meta.UserID != nil {
-		action := "save"
-		s.activitySvc.Log(ctx, ActivityEntry{
-			UserID:     meta.UserID,
-			Action:     action,
-			EntityType: "product_request",
-			EntityID:   id.String(),
-			Details:    r.Name + " " + string(r.Status),
-			IPAddress:  meta.IP,
-			UserAgent:  meta.UserAgent,
-		})
+		if s.activitySvc != nil && meta.UserID != nil {
+			action := "save"
+			if err := s.activitySvc.Log(ctx, ActivityEntry{
+				UserID:     meta.UserID,
+				Action:     action,
+				EntityType: "product_request",
+				EntityID:   id.String(),
+				Details:    r.Name + " " + string(r.Status),
+				IPAddress:  meta.IP,
+				UserAgent:  meta.UserAgent,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
 	}
 	if s.notificationSvc != nil {
 		notifType := models.NotificationTypeProductRequestApproved
-		title := "Product creation request approved"
-		message := "Your product creation request for \"" + r.Name + "\" was approved. The product is now available."
 		if !approved {
 			notifType = models.NotificationTypeProductRequestRejected
-			title = "Product creation request rejected"
-			message = "Your product creation request for \"" + r.Name + "\" was rejected."
 		}
+		title := i18n.M("notification." + notifType + ".title")
+		message := i18n.M("notification."+notifType+".message", "product", r.Name)
 		_, _ = s.notificationSvc.Create(r.RequestedBy, notifType, title, message, "product_request", &id)
 	}
 	return newResp, nil
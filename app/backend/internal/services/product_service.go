@@ -7,14 +7,17 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/authz"
 	"github.com/rm/roadmap/backend/internal/dto"
+	"github.com/rm/roadmap/backend/internal/i18n"
 	"github.com/rm/roadmap/backend/internal/models"
+	"github.com/rm/roadmap/backend/internal/realtime"
 	"github.com/rm/roadmap/backend/internal/repositories"
 )
 
 var (
 	ErrProductNotFound   = errors.New("product not found")
-	ErrForbidden         = errors.New("forbidden")
+	ErrForbidden         = authz.ErrForbidden
 	ErrInvalidOwnerID    = errors.New("invalid owner_id")
 )
 
@@ -24,13 +27,19 @@ type ProductService struct {
 	deletionReqRepo repositories.ProductDeletionRequestRepository
 	groupRepo       repositories.GroupRepository
 	milestoneRepo   repositories.MilestoneRepository
+	memberRepo      repositories.ProductMemberRepository
+	visibility      *OrgVisibilityService
+	tx              repositories.Transactor
 	auditSvc        *AuditService
 	activitySvc     *ActivityService
 	notificationSvc *NotificationService
+	policy          *authz.Engine
+	watchSvc        *WatchService
+	hub             *realtime.Hub
 }
 
-func NewProductService(productRepo repositories.ProductRepository, versionRepo repositories.ProductVersionRepository, deletionReqRepo repositories.ProductDeletionRequestRepository, groupRepo repositories.GroupRepository, milestoneRepo repositories.MilestoneRepository, auditSvc *AuditService, activitySvc *ActivityService, notificationSvc *NotificationService) *ProductService {
-	return &ProductService{productRepo: productRepo, versionRepo: versionRepo, deletionReqRepo: deletionReqRepo, groupRepo: groupRepo, milestoneRepo: milestoneRepo, auditSvc: auditSvc, activitySvc: activitySvc, notificationSvc: notificationSvc}
+func NewProductService(productRepo repositories.ProductRepository, versionRepo repositories.ProductVersionRepository, deletionReqRepo repositories.ProductDeletionRequestRepository, groupRepo repositories.GroupRepository, milestoneRepo repositories.MilestoneRepository, memberRepo repositories.ProductMemberRepository, visibility *OrgVisibilityService, tx repositories.Transactor, auditSvc *AuditService, activitySvc *ActivityService, notificationSvc *NotificationService, policy *authz.Engine, watchSvc *WatchService, hub *realtime.Hub) *ProductService {
+	return &ProductService{productRepo: productRepo, versionRepo: versionRepo, deletionReqRepo: deletionReqRepo, groupRepo: groupRepo, milestoneRepo: milestoneRepo, memberRepo: memberRepo, visibility: visibility, tx: tx, auditSvc: auditSvc, activitySvc: activitySvc, notificationSvc: notificationSvc, policy: policy, watchSvc: watchSvc, hub: hub}
 }
 
 func (s *ProductService) Create(ctx context.Context, req dto.ProductCreateRequest, ownerID *uuid.UUID, isAdmin bool, meta dto.AuditMeta) (*dto.ProductResponse, error) {
@@ -57,38 +66,49 @@ func (s *ProductService) Create(ctx context.Context, req dto.ProductCreateReques
 		LifecycleStatus: lifecycle,
 		Metadata:        models.JSONB(req.Metadata),
 	}
-	if err := s.productRepo.Create(p); err != nil {
+	var resp *dto.ProductResponse
+	if err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.productRepo.Create(ctx, p); err != nil {
+			return err
+		}
+		resp = productToResponse(p)
+		if s.auditSvc != nil {
+			if err := s.auditSvc.Log(ctx, AuditEntry{
+				UserID:     meta.UserID,
+				Action:     "create",
+				EntityType: "product",
+				EntityID:   p.ID.String(),
+				NewData:    ToJSONB(resp),
+				IPAddress:  meta.IP,
+				UserAgent:  meta.UserAgent,
+				TraceID:    meta.TraceID,
+			}); err != nil {
+				return err
+			}
+		}
+		if s.activitySvc != nil && meta.UserID != nil {
+			if err := s.activitySvc.Log(ctx, ActivityEntry{
+				UserID:     meta.UserID,
+				Action:     "create",
+				EntityType: "product",
+				EntityID:   p.ID.String(),
+				Details:    p.Name,
+				IPAddress:  meta.IP,
+				UserAgent:  meta.UserAgent,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
 		return nil, err
 	}
-	resp := productToResponse(p)
-	if s.auditSvc != nil {
-		s.auditSvc.Log(ctx, AuditEntry{
-			UserID:     meta.UserID,
-			Action:     "create",
-			EntityType: "product",
-			EntityID:   p.ID.String(),
-			NewData:    ToJSONB(resp),
-			IPAddress:  meta.IP,
-			UserAgent:  meta.UserAgent,
-			TraceID:    meta.TraceID,
-		})
-	}
-	if s.activitySvc != nil && meta.UserID != nil {
-		s.activitySvc.Log(ctx, ActivityEntry{
-			UserID:     meta.UserID,
-			Action:     "create",
-			EntityType: "product",
-			EntityID:   p.ID.String(),
-			Details:    p.Name,
-			IPAddress:  meta.IP,
-			UserAgent:  meta.UserAgent,
-		})
-	}
+	publishProductEvent(ctx, s.hub, realtime.EventProduct, "create", p.ID, []*uuid.UUID{p.OwnerID}, resp)
 	return resp, nil
 }
 
-func (s *ProductService) GetByID(id uuid.UUID) (*dto.ProductResponse, error) {
-	p, err := s.productRepo.GetByID(id)
+func (s *ProductService) GetByID(ctx context.Context, id uuid.UUID) (*dto.ProductResponse, error) {
+	p, err := s.productRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -108,18 +128,34 @@ func (s *ProductService) GetByID(id uuid.UUID) (*dto.ProductResponse, error) {
 	return resp, nil
 }
 
-func (s *ProductService) List(ownerID *uuid.UUID, status *models.ProductStatus, lifecycleStatus *models.LifecycleStatus, category1, category2, category3 *string, groupID *uuid.UUID, ungroupedOnly bool, dateFrom, dateTo *time.Time, sortBy, order string, callerID uuid.UUID, callerRole models.Role, limit, offset int) (*dto.PageResult[dto.ProductResponse], error) {
-	if callerRole == models.RoleOwner && ownerID == nil {
-		ownerID = &callerID
-	}
+func (s *ProductService) List(ctx context.Context, ownerID *uuid.UUID, status *models.ProductStatus, lifecycleStatus *models.LifecycleStatus, category1, category2, category3 *string, groupID *uuid.UUID, ungroupedOnly bool, dateFrom, dateTo *time.Time, sortBy, order string, callerID uuid.UUID, callerRole models.Role, limit, offset int) (*dto.PageResult[dto.ProductResponse], error) {
 	var productIDs *[]uuid.UUID
 	var excludedProductIDs *[]uuid.UUID
+	if ownerID == nil {
+		visible, all, err := s.ReadScope(ctx, callerID, callerRole)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case all:
+		case len(visible) == 1 && visible[0] == callerID:
+			ownerID = &callerID
+		case len(visible) == 0:
+			productIDs = &[]uuid.UUID{}
+		default:
+			ids, err := s.productRepo.ListIDsByOwners(visible)
+			if err != nil {
+				return nil, err
+			}
+			productIDs = &ids
+		}
+	}
 	if groupID != nil && s.groupRepo != nil {
 		ids, err := s.groupRepo.GetProductIDs(*groupID)
 		if err != nil {
 			return nil, err
 		}
-		productIDs = &ids
+		productIDs = intersectIDs(productIDs, ids)
 	} else if ungroupedOnly && s.groupRepo != nil {
 		ids, err := s.groupRepo.GetAllProductIDsInAnyGroup()
 		if err != nil {
@@ -138,65 +174,136 @@ func (s *ProductService) List(ownerID *uuid.UUID, status *models.ProductStatus,
 	return &dto.PageResult[dto.ProductResponse]{Items: out, Total: total, Limit: limit, Offset: offset}, nil
 }
 
-func (s *ProductService) Update(ctx context.Context, id uuid.UUID, req dto.ProductUpdateRequest, callerID uuid.UUID, callerRole models.Role, meta dto.AuditMeta) (*dto.ProductResponse, error) {
-	p, err := s.productRepo.GetByID(id)
+// ReadScope returns which products the caller sees in lists and event streams: all of them with product:read,
+// or with product:read:own those owned by the caller or anyone in their reporting subtree, whose IDs it
+// returns. Without either grant the caller sees none.
+func (s *ProductService) ReadScope(ctx context.Context, callerID uuid.UUID, callerRole models.Role) (owners []uuid.UUID, all bool, err error) {
+	sub := authz.Subject{UserID: callerID, Role: callerRole}
+	if err := s.policy.Authorize(ctx, sub, authz.PermProductRead, authz.Any); err == nil {
+		return nil, true, nil
+	} else if !errors.Is(err, authz.ErrForbidden) {
+		return nil, false, err
+	}
+	if !s.policy.HasOwnScope(ctx, sub, authz.PermProductRead) {
+		return nil, false, nil
+	}
+	owners, err = s.visibility.VisibleUserIDs(ctx, callerID)
+	return owners, false, err
+}
+
+// CanRead checks product:read for one product, with the scope ReadScope gives lists; members of the product
+// count as its delegates. A product out of scope is reported as ErrProductNotFound.
+func (s *ProductService) CanRead(ctx context.Context, p *models.Product, callerID uuid.UUID, callerRole models.Role) error {
+	owners, all, err := s.ReadScope(ctx, callerID, callerRole)
+	if err != nil || all {
+		return err
+	}
+	for _, id := range owners {
+		if p.OwnerID != nil && *p.OwnerID == id {
+			return nil
+		}
+	}
+	res, err := productResource(ctx, s.memberRepo, p, models.ProductMemberCoOwner, models.ProductMemberEditor, models.ProductMemberViewer)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	// Admin/superadmin can always update. Product owner can update only when assigned and (other checks below).
-	if !callerRole.IsAdminOrAbove() && (p.OwnerID == nil || *p.OwnerID != callerID) {
-		return nil, ErrForbidden
+	if err := s.policy.Authorize(ctx, authz.Subject{UserID: callerID, Role: callerRole}, authz.PermProductRead, res); err != nil {
+		if errors.Is(err, authz.ErrForbidden) {
+			return ErrProductNotFound
+		}
+		return err
 	}
-	if callerRole == models.RoleOwner && req.Status != nil {
-		return nil, ErrForbidden
+	return nil
+}
+
+func (s *ProductService) Update(ctx context.Context, id uuid.UUID, req dto.ProductUpdateRequest, callerID uuid.UUID, callerRole models.Role, meta dto.AuditMeta) (*dto.ProductResponse, error) {
+	p, err := s.productRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
 	}
-	if callerRole == models.RoleOwner && p.LifecycleStatus != models.LifecycleActive {
-		return nil, ErrForbidden
+	sub := authz.Subject{UserID: callerID, Role: callerRole}
+	// product:update on any product (admin) edits freely; product:update:own only edits an
+	// active product the caller owns or co-owns. Status and lifecycle changes additionally need product:status.
+	unrestricted := s.policy.Allowed(ctx, sub, authz.PermProductUpdate, authz.Any)
+	if !unrestricted {
+		res, err := productResource(ctx, s.memberRepo, p, models.ProductMemberCoOwner)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.policy.Authorize(ctx, sub, authz.PermProductUpdate, res); err != nil {
+			return nil, err
+		}
+		if p.LifecycleStatus != models.LifecycleActive {
+			return nil, ErrForbidden
+		}
 	}
-	if callerRole == models.RoleOwner && req.LifecycleStatus != nil {
-		return nil, ErrForbidden
+	if req.Status != nil || req.LifecycleStatus != nil {
+		if err := s.policy.Authorize(ctx, sub, authz.PermProductStatus, authz.Any); err != nil {
+			return nil, err
+		}
 	}
 	oldResp := productToResponse(p)
+	var oldOwnerID *uuid.UUID
+	if p.OwnerID != nil {
+		owner := *p.OwnerID
+		oldOwnerID = &owner
+	}
 	if err := applyProductUpdate(p, req); err != nil {
 		return nil, err
 	}
 	// Clear association so GORM persists owner_id column; refetch will repopulate Owner
 	p.Owner = nil
-	if err := s.productRepo.Update(p); err != nil {
-		return nil, err
-	}
-	// Refetch so Owner (and other associations) are correct in the response
-	fresh, err := s.productRepo.GetByID(id)
-	if err != nil {
+	var fresh *models.Product
+	var newResp *dto.ProductResponse
+	if err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.productRepo.Update(ctx, p); err != nil {
+			return err
+		}
+		// Refetch so Owner (and other associations) are correct in the response
+		var err error
+		fresh, err = s.productRepo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		newResp = productToResponse(fresh)
+		if s.auditSvc != nil {
+			if err := s.auditSvc.Log(ctx, AuditEntry{
+				UserID:     meta.UserID,
+				Action:     "update",
+				EntityType: "product",
+				EntityID:   id.String(),
+				OldData:    ToJSONB(oldResp),
+				NewData:    ToJSONB(newResp),
+				IPAddress:  meta.IP,
+				UserAgent:  meta.UserAgent,
+				TraceID:    meta.TraceID,
+			}); err != nil {
+				return err
+			}
+		}
+		if s.activitySvc != nil && meta.UserID != nil {
+			if err := s.activitySvc.Log(ctx, ActivityEntry{
+				UserID:     meta.UserID,
+				Action:     "save",
+				EntityType: "product",
+				EntityID:   id.String(),
+				Details:    fresh.Name,
+				IPAddress:  meta.IP,
+				UserAgent:  meta.UserAgent,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
 		return nil, err
 	}
-	newResp := productToResponse(fresh)
-	if s.auditSvc != nil {
-		s.auditSvc.Log(ctx, AuditEntry{
-			UserID:     meta.UserID,
-			Action:     "update",
-			EntityType: "product",
-			EntityID:   id.String(),
-			OldData:    ToJSONB(oldResp),
-			NewData:    ToJSONB(newResp),
-			IPAddress:  meta.IP,
-			UserAgent:  meta.UserAgent,
-			TraceID:    meta.TraceID,
-		})
-	}
-	if s.activitySvc != nil && meta.UserID != nil {
-		s.activitySvc.Log(ctx, ActivityEntry{
-			UserID:     meta.UserID,
-			Action:     "save",
-			EntityType: "product",
-			EntityID:   id.String(),
-			Details:    fresh.Name,
-			IPAddress:  meta.IP,
-			UserAgent:  meta.UserAgent,
-		})
+	publishProductEvent(ctx, s.hub, realtime.EventProduct, "update", id, []*uuid.UUID{oldOwnerID, fresh.OwnerID}, newResp)
+	if oldResp.LifecycleStatus != newResp.LifecycleStatus {
+		s.watchSvc.LifecycleChanged(ctx, fresh, models.LifecycleStatus(oldResp.LifecycleStatus), meta.UserID)
 	}
 	// When admin/superadmin changes status, lifecycle, or owner, notify the product owner (the selected user)
-	if callerRole.IsAdminOrAbove() && s.notificationSvc != nil && fresh.OwnerID != nil {
+	if unrestricted && s.notificationSvc != nil && fresh.OwnerID != nil {
 		statusChanged := req.Status != nil && (oldResp.Status != newResp.Status)
 		lifecycleChanged := req.LifecycleStatus != nil && (oldResp.LifecycleStatus != newResp.LifecycleStatus)
 		oldOwnerStr, newOwnerStr := "", ""
@@ -208,16 +315,16 @@ func (s *ProductService) Update(ctx context.Context, id uuid.UUID, req dto.Produ
 		}
 		ownerChanged := (req.OwnerID != nil && *req.OwnerID != "" || (req.ClearOwner != nil && *req.ClearOwner)) && oldOwnerStr != newOwnerStr
 		if statusChanged || lifecycleChanged || ownerChanged {
-			title := "Product updated"
-			msg := "An admin has updated your product \"" + fresh.Name + "\"."
+			title := i18n.M("notification.product_status_changed.title")
+			msg := i18n.M("notification.product_status_changed.message", "product", fresh.Name)
 			if statusChanged {
-				msg += " Status is now: " + string(fresh.Status) + "."
+				msg = msg.Append(i18n.M("notification.product_status_changed.status").Term("status", "term.product_status."+string(fresh.Status)))
 			}
 			if lifecycleChanged {
-				msg += " Lifecycle status is now: " + string(fresh.LifecycleStatus) + "."
+				msg = msg.Append(i18n.M("notification.product_status_changed.lifecycle").Term("lifecycle", "term.lifecycle."+string(fresh.LifecycleStatus)))
 			}
 			if ownerChanged {
-				msg += " You have been set as the product owner."
+				msg = msg.Append(i18n.M("notification.product_status_changed.owner"))
 			}
 			_, _ = s.notificationSvc.Create(*fresh.OwnerID, models.NotificationTypeProductStatusChanged, title, msg, "product", &id)
 		}
@@ -228,8 +335,8 @@ func (s *ProductService) Update(ctx context.Context, id uuid.UUID, req dto.Produ
 var ErrProductHasVersions = errors.New("product has versions; delete all versions first")
 
 func (s *ProductService) Delete(ctx context.Context, id uuid.UUID, callerRole models.Role, meta dto.AuditMeta) error {
-	if !callerRole.IsAdminOrAbove() {
-		return ErrForbidden
+	if err := s.policy.Authorize(ctx, authz.Subject{Role: callerRole}, authz.PermProductDelete, authz.Any); err != nil {
+		return err
 	}
 	n, err := s.versionRepo.CountByProductID(id)
 	if err != nil {
@@ -238,44 +345,76 @@ func (s *ProductService) Delete(ctx context.Context, id uuid.UUID, callerRole mo
 	if n > 0 {
 		return ErrProductHasVersions
 	}
-	p, _ := s.productRepo.GetByID(id)
+	p, _ := s.productRepo.GetByID(ctx, id)
 	oldData := models.JSONB(nil)
 	if p != nil {
 		oldData = ToJSONB(productToResponse(p))
 	}
-	if err := s.productRepo.Delete(id); err != nil {
+	if err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.productRepo.Delete(ctx, id); err != nil {
+			return err
+		}
+		if s.auditSvc != nil {
+			if err := s.auditSvc.Log(ctx, AuditEntry{
+				UserID:     meta.UserID,
+				Action:     "delete",
+				EntityType: "product",
+				EntityID:   id.String(),
+				OldData:    oldData,
+				IPAddress:  meta.IP,
+				UserAgent:  meta.UserAgent,
+				TraceID:    meta.TraceID,
+			}); err != nil {
+				return err
+			}
+		}
+		if s.activitySvc != nil && meta.UserID != nil {
+			details := ""
+			if p != nil {
+				details = p.Name
+			}
+			if err := s.activitySvc.Log(ctx, ActivityEntry{
+				UserID:     meta.UserID,
+				Action:     "delete",
+				EntityType: "product",
+				EntityID:   id.String(),
+				Details:    details,
+				IPAddress:  meta.IP,
+				UserAgent:  meta.UserAgent,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
 		return err
 	}
-	if s.auditSvc != nil {
-		s.auditSvc.Log(ctx, AuditEntry{
-			UserID:     meta.UserID,
-			Action:     "delete",
-			EntityType: "product",
-			EntityID:   id.String(),
-			OldData:    oldData,
-			IPAddress:  meta.IP,
-			UserAgent:  meta.UserAgent,
-			TraceID:    meta.TraceID,
-		})
-	}
-	if s.activitySvc != nil && meta.UserID != nil {
-		details := ""
-		if p != nil {
-			details = p.Name
-		}
-		s.activitySvc.Log(ctx, ActivityEntry{
-			UserID:     meta.UserID,
-			Action:     "delete",
-			EntityType: "product",
-			EntityID:   id.String(),
-			Details:    details,
-			IPAddress:  meta.IP,
-			UserAgent:  meta.UserAgent,
-		})
+	var ownerID *uuid.UUID
+	if p != nil {
+		ownerID = p.OwnerID
 	}
+	publishProductEvent(ctx, s.hub, realtime.EventProduct, "delete", id, []*uuid.UUID{ownerID}, map[string]string{"id": id.String()})
 	return nil
 }
 
+// intersectIDs narrows an optional ID filter by ids; a nil filter means "no restriction yet".
+func intersectIDs(filter *[]uuid.UUID, ids []uuid.UUID) *[]uuid.UUID {
+	if filter == nil {
+		return &ids
+	}
+	keep := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		keep[id] = true
+	}
+	out := make([]uuid.UUID, 0, len(*filter))
+	for _, id := range *filter {
+		if keep[id] {
+			out = append(out, id)
+		}
+	}
+	return &out
+}
+
 func applyProductUpdate(p *models.Product, req dto.ProductUpdateRequest) error {
 	if req.Name != nil {
 		p.Name = *req.Name
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/authz"
 	"github.com/rm/roadmap/backend/internal/dto"
 	"github.com/rm/roadmap/backend/internal/models"
 	"github.com/rm/roadmap/backend/internal/repositories"
@@ -14,43 +15,53 @@ type ProductVersionDependencyService struct {
 	versionDepRepo repositories.ProductVersionDependencyRepository
 	versionRepo    repositories.ProductVersionRepository
 	productRepo    repositories.ProductRepository
+	memberRepo     repositories.ProductMemberRepository
+	tx             repositories.Transactor
 	auditSvc       *AuditService
+	policy         *authz.Engine
 }
 
 func NewProductVersionDependencyService(
 	versionDepRepo repositories.ProductVersionDependencyRepository,
 	versionRepo repositories.ProductVersionRepository,
 	productRepo repositories.ProductRepository,
+	memberRepo repositories.ProductMemberRepository,
+	tx repositories.Transactor,
 	auditSvc *AuditService,
+	policy *authz.Engine,
 ) *ProductVersionDependencyService {
 	return &ProductVersionDependencyService{
 		versionDepRepo: versionDepRepo,
 		versionRepo:    versionRepo,
 		productRepo:    productRepo,
+		memberRepo:     memberRepo,
+		tx:             tx,
 		auditSvc:       auditSvc,
+		policy:         policy,
 	}
 }
 
-func (s *ProductVersionDependencyService) canModifySourceVersion(sourceProductVersionID, callerID uuid.UUID, callerRole models.Role) (productID uuid.UUID, err error) {
+func (s *ProductVersionDependencyService) canModifySourceVersion(ctx context.Context, sourceProductVersionID, callerID uuid.UUID, callerRole models.Role) (productID uuid.UUID, err error) {
 	pv, err := s.versionRepo.GetByID(sourceProductVersionID)
 	if err != nil {
 		return uuid.Nil, err
 	}
-	p, err := s.productRepo.GetByID(pv.ProductID)
+	p, err := s.productRepo.GetByID(ctx, pv.ProductID)
 	if err != nil {
 		return uuid.Nil, err
 	}
-	if callerRole.IsAdminOrAbove() {
-		return p.ID, nil
+	res, err := productResource(ctx, s.memberRepo, p, productEditorRoles...)
+	if err != nil {
+		return uuid.Nil, err
 	}
-	if p.OwnerID == nil || *p.OwnerID != callerID {
-		return uuid.Nil, ErrForbidden
+	if err := s.policy.Authorize(ctx, authz.Subject{UserID: callerID, Role: callerRole}, authz.PermDependencyWrite, res); err != nil {
+		return uuid.Nil, err
 	}
 	return p.ID, nil
 }
 
 func (s *ProductVersionDependencyService) ListByProductVersionID(ctx context.Context, sourceProductVersionID uuid.UUID, callerID uuid.UUID, callerRole models.Role) ([]dto.ProductVersionDependencyResponse, error) {
-	if _, err := s.canModifySourceVersion(sourceProductVersionID, callerID, callerRole); err != nil {
+	if _, err := s.canModifySourceVersion(ctx, sourceProductVersionID, callerID, callerRole); err != nil {
 		return nil, err
 	}
 	list, err := s.versionDepRepo.ListBySourceProductVersionID(ctx, sourceProductVersionID)
@@ -69,14 +80,14 @@ func (s *ProductVersionDependencyService) Create(ctx context.Context, req dto.Pr
 	if err != nil {
 		return nil, err
 	}
-	if _, err := s.canModifySourceVersion(sourceVersionID, callerID, callerRole); err != nil {
+	if _, err := s.canModifySourceVersion(ctx, sourceVersionID, callerID, callerRole); err != nil {
 		return nil, err
 	}
 	targetProductID, err := uuid.Parse(req.TargetProductID)
 	if err != nil {
 		return nil, err
 	}
-	if _, err := s.productRepo.GetByID(targetProductID); err != nil {
+	if _, err := s.productRepo.GetByID(ctx, targetProductID); err != nil {
 		return nil, err
 	}
 	d := &models.ProductVersionDependency{
@@ -98,23 +109,31 @@ func (s *ProductVersionDependencyService) Create(ctx context.Context, req dto.Pr
 		}
 		d.TargetProductVersionID = &targetVersionID
 	}
-	if err := s.versionDepRepo.Create(ctx, d); err != nil {
+	var resp *dto.ProductVersionDependencyResponse
+	if err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.versionDepRepo.Create(ctx, d); err != nil {
+			return err
+		}
+		fresh, _ := s.versionDepRepo.GetByID(ctx, d.ID)
+		resp = productVersionDependencyToResponse(fresh)
+		if s.auditSvc != nil {
+			if err := s.auditSvc.Log(ctx, AuditEntry{
+				UserID:     meta.UserID,
+				Action:     "create",
+				EntityType: "product_version_dependency",
+				EntityID:   d.ID.String(),
+				NewData:    ToJSONB(resp),
+				IPAddress:  meta.IP,
+				UserAgent:  meta.UserAgent,
+				TraceID:    meta.TraceID,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
 		return nil, err
 	}
-	fresh, _ := s.versionDepRepo.GetByID(ctx, d.ID)
-	resp := productVersionDependencyToResponse(fresh)
-	if s.auditSvc != nil {
-		s.auditSvc.Log(ctx, AuditEntry{
-			UserID:     meta.UserID,
-			Action:     "create",
-			EntityType: "product_version_dependency",
-			EntityID:   d.ID.String(),
-			NewData:    ToJSONB(resp),
-			IPAddress:  meta.IP,
-			UserAgent:  meta.UserAgent,
-			TraceID:    meta.TraceID,
-		})
-	}
 	return resp, nil
 }
 
@@ -123,26 +142,30 @@ func (s *ProductVersionDependencyService) Delete(ctx context.Context, id uuid.UU
 	if err != nil {
 		return err
 	}
-	if _, err := s.canModifySourceVersion(d.SourceProductVersionID, callerID, callerRole); err != nil {
+	if _, err := s.canModifySourceVersion(ctx, d.SourceProductVersionID, callerID, callerRole); err != nil {
 		return err
 	}
 	oldData := ToJSONB(productVersionDependencyToResponse(d))
-	if err := s.versionDepRepo.Delete(ctx, id); err != nil {
-		return err
-	}
-	if s.auditSvc != nil {
-		s.auditSvc.Log(ctx, AuditEntry{
-			UserID:     meta.UserID,
-			Action:     "delete",
-			EntityType: "product_version_dependency",
-			EntityID:   id.String(),
-			OldData:    oldData,
-			IPAddress:  meta.IP,
-			UserAgent:  meta.UserAgent,
-			TraceID:    meta.TraceID,
-		})
-	}
-	return nil
+	return s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.versionDepRepo.Delete(ctx, id); err != nil {
+			return err
+		}
+		if s.auditSvc != nil {
+			if err := s.auditSvc.Log(ctx, AuditEntry{
+				UserID:     meta.UserID,
+				Action:     "delete",
+				EntityType: "product_version_dependency",
+				EntityID:   id.String(),
+				OldData:    oldData,
+				IPAddress:  meta.IP,
+				UserAgent:  meta.UserAgent,
+				TraceID:    meta.TraceID,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 func productVersionDependencyToResponse(d *models.ProductVersionDependency) *dto.ProductVersionDependencyResponse {
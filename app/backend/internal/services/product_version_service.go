@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/authz"
 	"github.com/rm/roadmap/backend/internal/dto"
 	"github.com/rm/roadmap/backend/internal/models"
 	"github.com/rm/roadmap/backend/internal/repositories"
@@ -12,27 +13,37 @@ import (
 type ProductVersionService struct {
 	versionRepo repositories.ProductVersionRepository
 	productRepo repositories.ProductRepository
+	memberRepo  repositories.ProductMemberRepository
+	tx          repositories.Transactor
 	auditSvc    *AuditService
 	activitySvc *ActivityService
+	policy      *authz.Engine
+	watchSvc    *WatchService
 }
 
-func NewProductVersionService(versionRepo repositories.ProductVersionRepository, productRepo repositories.ProductRepository, auditSvc *AuditService, activitySvc *ActivityService) *ProductVersionService {
-	return &ProductVersionService{versionRepo: versionRepo, productRepo: productRepo, auditSvc: auditSvc, activitySvc: activitySvc}
+func NewProductVersionService(versionRepo repositories.ProductVersionRepository, productRepo repositories.ProductRepository, memberRepo repositories.ProductMemberRepository, tx repositories.Transactor, auditSvc *AuditService, activitySvc *ActivityService, policy *authz.Engine, watchSvc *WatchService) *ProductVersionService {
+	return &ProductVersionService{versionRepo: versionRepo, productRepo: productRepo, memberRepo: memberRepo, tx: tx, auditSvc: auditSvc, activitySvc: activitySvc, policy: policy, watchSvc: watchSvc}
 }
 
-// canModifyVersions returns nil if caller (admin or product owner) can add/modify versions when product is active.
-func (s *ProductVersionService) canModifyVersions(productID, callerID uuid.UUID, callerRole models.Role) error {
-	p, err := s.productRepo.GetByID(productID)
+// canModifyVersions checks version:write. A grant scoped to own products covers co-owners and editors too,
+// and only applies while the product is active.
+func (s *ProductVersionService) canModifyVersions(ctx context.Context, productID, callerID uuid.UUID, callerRole models.Role) error {
+	p, err := s.productRepo.GetByID(ctx, productID)
 	if err != nil {
 		return err
 	}
-	if callerRole.IsAdminOrAbove() {
+	sub := authz.Subject{UserID: callerID, Role: callerRole}
+	if s.policy.Allowed(ctx, sub, authz.PermVersionWrite, authz.Any) {
 		return nil
 	}
-	if p.LifecycleStatus != models.LifecycleActive {
-		return ErrForbidden
+	res, err := productResource(ctx, s.memberRepo, p, productEditorRoles...)
+	if err != nil {
+		return err
 	}
-	if p.OwnerID == nil || *p.OwnerID != callerID {
+	if err := s.policy.Authorize(ctx, sub, authz.PermVersionWrite, res); err != nil {
+		return err
+	}
+	if p.LifecycleStatus != models.LifecycleActive {
 		return ErrForbidden
 	}
 	return nil
@@ -43,45 +54,56 @@ func (s *ProductVersionService) Create(ctx context.Context, req dto.ProductVersi
 	if err != nil {
 		return nil, err
 	}
-	if err := s.canModifyVersions(productID, callerID, callerRole); err != nil {
+	if err := s.canModifyVersions(ctx, productID, callerID, callerRole); err != nil {
 		return nil, err
 	}
 	pv := &models.ProductVersion{
 		ProductID: productID,
 		Version:   req.Version,
 	}
-	if err := s.versionRepo.Create(pv); err != nil {
+	var resp *dto.ProductVersionResponse
+	if err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.versionRepo.Create(ctx, pv); err != nil {
+			return err
+		}
+		resp = &dto.ProductVersionResponse{
+			ID:        pv.ID.String(),
+			ProductID: pv.ProductID.String(),
+			Version:   pv.Version,
+			CreatedAt: pv.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		}
+		if s.auditSvc != nil {
+			if err := s.auditSvc.Log(ctx, AuditEntry{
+				UserID:     meta.UserID,
+				Action:     "create",
+				EntityType: "product_version",
+				EntityID:   pv.ID.String(),
+				NewData:    ToJSONB(resp),
+				IPAddress:  meta.IP,
+				UserAgent:  meta.UserAgent,
+				TraceID:    meta.TraceID,
+			}); err != nil {
+				return err
+			}
+		}
+		if s.activitySvc != nil && meta.UserID != nil {
+			if err := s.activitySvc.Log(ctx, ActivityEntry{
+				UserID:     meta.UserID,
+				Action:     "create",
+				EntityType: "product_version",
+				EntityID:   pv.ID.String(),
+				Details:    pv.Version,
+				IPAddress:  meta.IP,
+				UserAgent:  meta.UserAgent,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
 		return nil, err
 	}
-	resp := &dto.ProductVersionResponse{
-		ID:        pv.ID.String(),
-		ProductID: pv.ProductID.String(),
-		Version:   pv.Version,
-		CreatedAt: pv.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	}
-	if s.auditSvc != nil {
-		s.auditSvc.Log(ctx, AuditEntry{
-			UserID:     meta.UserID,
-			Action:     "create",
-			EntityType: "product_version",
-			EntityID:   pv.ID.String(),
-			NewData:    ToJSONB(resp),
-			IPAddress:  meta.IP,
-			UserAgent:  meta.UserAgent,
-			TraceID:    meta.TraceID,
-		})
-	}
-	if s.activitySvc != nil && meta.UserID != nil {
-		s.activitySvc.Log(ctx, ActivityEntry{
-			UserID:     meta.UserID,
-			Action:     "create",
-			EntityType: "product_version",
-			EntityID:   pv.ID.String(),
-			Details:    pv.Version,
-			IPAddress:  meta.IP,
-			UserAgent:  meta.UserAgent,
-		})
-	}
+	s.watchSvc.VersionChanged(ctx, "create", pv, "", meta.UserID)
 	return resp, nil
 }
 
@@ -90,7 +112,7 @@ func (s *ProductVersionService) Update(ctx context.Context, id uuid.UUID, req dt
 	if err != nil {
 		return nil, err
 	}
-	if err := s.canModifyVersions(pv.ProductID, callerID, callerRole); err != nil {
+	if err := s.canModifyVersions(ctx, pv.ProductID, callerID, callerRole); err != nil {
 		return nil, err
 	}
 	oldData := ToJSONB(&dto.ProductVersionResponse{
@@ -99,40 +121,52 @@ func (s *ProductVersionService) Update(ctx context.Context, id uuid.UUID, req dt
 		Version:   pv.Version,
 		CreatedAt: pv.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 	})
+	oldVersion := pv.Version
 	pv.Version = req.Version
-	if err := s.versionRepo.Update(pv); err != nil {
+	var resp *dto.ProductVersionResponse
+	if err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.versionRepo.Update(ctx, pv); err != nil {
+			return err
+		}
+		resp = &dto.ProductVersionResponse{
+			ID:        pv.ID.String(),
+			ProductID: pv.ProductID.String(),
+			Version:   pv.Version,
+			CreatedAt: pv.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		}
+		if s.auditSvc != nil {
+			if err := s.auditSvc.Log(ctx, AuditEntry{
+				UserID:     meta.UserID,
+				Action:     "update",
+				EntityType: "product_version",
+				EntityID:   id.String(),
+				OldData:    oldData,
+				NewData:    ToJSONB(resp),
+				IPAddress:  meta.IP,
+				UserAgent:  meta.UserAgent,
+				TraceID:    meta.TraceID,
+			}); err != nil {
+				return err
+			}
+		}
+		if s.activitySvc != nil && meta.UserID != nil {
+			if err := s.activitySvc.Log(ctx, ActivityEntry{
+				UserID:     meta.UserID,
+				Action:     "save",
+				EntityType: "product_version",
+				EntityID:   id.String(),
+				Details:    pv.Version,
+				IPAddress:  meta.IP,
+				UserAgent:  meta.UserAgent,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
 		return nil, err
 	}
-	resp := &dto.ProductVersionResponse{
-		ID:        pv.ID.String(),
-		ProductID: pv.ProductID.String(),
-		Version:   pv.Version,
-		CreatedAt: pv.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	}
-	if s.auditSvc != nil {
-		s.auditSvc.Log(ctx, AuditEntry{
-			UserID:     meta.UserID,
-			Action:     "update",
-			EntityType: "product_version",
-			EntityID:   id.String(),
-			OldData:    oldData,
-			NewData:    ToJSONB(resp),
-			IPAddress:  meta.IP,
-			UserAgent:  meta.UserAgent,
-			TraceID:    meta.TraceID,
-		})
-	}
-	if s.activitySvc != nil && meta.UserID != nil {
-		s.activitySvc.Log(ctx, ActivityEntry{
-			UserID:     meta.UserID,
-			Action:     "save",
-			EntityType: "product_version",
-			EntityID:   id.String(),
-			Details:    pv.Version,
-			IPAddress:  meta.IP,
-			UserAgent:  meta.UserAgent,
-		})
-	}
+	s.watchSvc.VersionChanged(ctx, "update", pv, oldVersion, meta.UserID)
 	return resp, nil
 }
 
@@ -158,7 +192,7 @@ func (s *ProductVersionService) Delete(ctx context.Context, id uuid.UUID, caller
 	if err != nil {
 		return err
 	}
-	if err := s.canModifyVersions(pv.ProductID, callerID, callerRole); err != nil {
+	if err := s.canModifyVersions(ctx, pv.ProductID, callerID, callerRole); err != nil {
 		return err
 	}
 	oldData := ToJSONB(&dto.ProductVersionResponse{
@@ -167,31 +201,41 @@ func (s *ProductVersionService) Delete(ctx context.Context, id uuid.UUID, caller
 		Version:   pv.Version,
 		CreatedAt: pv.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 	})
-	if err := s.versionRepo.Delete(id); err != nil {
+	if err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.versionRepo.Delete(ctx, id); err != nil {
+			return err
+		}
+		if s.auditSvc != nil {
+			if err := s.auditSvc.Log(ctx, AuditEntry{
+				UserID:     meta.UserID,
+				Action:     "delete",
+				EntityType: "product_version",
+				EntityID:   id.String(),
+				OldData:    oldData,
+				IPAddress:  meta.IP,
+				UserAgent:  meta.UserAgent,
+				TraceID:    meta.TraceID,
+			}); err != nil {
+				return err
+			}
+		}
+		if s.activitySvc != nil && meta.UserID != nil {
+			if err := s.activitySvc.Log(ctx, ActivityEntry{
+				UserID:     meta.UserID,
+				Action:     "delete",
+				EntityType: "product_version",
+				EntityID:   id.String(),
+				Details:    pv.Version,
+				IPAddress:  meta.IP,
+				UserAgent:  meta.UserAgent,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
 		return err
 	}
-	if s.auditSvc != nil {
-		s.auditSvc.Log(ctx, AuditEntry{
-			UserID:     meta.UserID,
-			Action:     "delete",
-			EntityType: "product_version",
-			EntityID:   id.String(),
-			OldData:    oldData,
-			IPAddress:  meta.IP,
-			UserAgent:  meta.UserAgent,
-			TraceID:    meta.TraceID,
-		})
-	}
-	if s.activitySvc != nil && meta.UserID != nil {
-		s.activitySvc.Log(ctx, ActivityEntry{
-			UserID:     meta.UserID,
-			Action:     "delete",
-			EntityType: "product_version",
-			EntityID:   id.String(),
-			Details:    pv.Version,
-			IPAddress:  meta.IP,
-			UserAgent:  meta.UserAgent,
-		})
-	}
+	s.watchSvc.VersionChanged(ctx, "delete", pv, "", meta.UserID)
 	return nil
 }
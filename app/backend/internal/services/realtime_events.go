@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/realtime"
+)
+
+// publishProductEvent tells the clients that can see a product about a change to it or to one of its
+// milestones. owners are the product's owners before and after the change; data is the changed entity, or
+// just its ID for deletes. It runs after the commit, on a context the end of the request does not cancel.
+func publishProductEvent(ctx context.Context, hub *realtime.Hub, eventType, action string, productID uuid.UUID, owners []*uuid.UUID, data interface{}) {
+	if hub == nil {
+		return
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	ev := realtime.Event{Type: eventType, Action: action, ProductID: &productID, Data: b}
+	for _, o := range owners {
+		if o != nil {
+			ev.OwnerIDs = append(ev.OwnerIDs, *o)
+		}
+	}
+	hub.Publish(context.WithoutCancel(ctx), ev)
+}
+
+// publishUserEvent sends an event to one user's clients.
+func publishUserEvent(ctx context.Context, hub *realtime.Hub, eventType string, userID uuid.UUID, data interface{}) {
+	if hub == nil {
+		return
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	hub.Publish(context.WithoutCancel(ctx), realtime.Event{Type: eventType, UserID: &userID, Data: b})
+}
@@ -0,0 +1,434 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/authz"
+	"github.com/rm/roadmap/backend/internal/dto"
+	"github.com/rm/roadmap/backend/internal/models"
+	"github.com/rm/roadmap/backend/internal/repositories"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRestoreUnsupported  = errors.New("restore is supported for products, milestones and product versions")
+	ErrRestoreInvalidState = errors.New("state must be before or after")
+	ErrRestoreNoSnapshot   = errors.New("audit entry has no snapshot in the requested state")
+	ErrRestoreConflict     = errors.New("entity has changed since its latest audit entry; review its history and retry")
+	ErrRestoreParentGone   = errors.New("the product or version this entity belongs to is deleted; restore it first")
+)
+
+// restorableFields are the snapshot fields written back on restore, per entity type. They are also the
+// fields compared with the latest audit entry to detect changes made outside the audit trail.
+var restorableFields = map[string][]string{
+	"product":         {"name", "version", "description", "owner_id", "status", "lifecycle_status", "category_1", "category_2", "category_3", "metadata"},
+	"milestone":       {"product_version_id", "label", "start_date", "end_date", "type", "color", "extra"},
+	"product_version": {"version"},
+}
+
+// RestoreService reverts products, milestones and versions to a snapshot stored in an audit entry,
+// undeleting them when they are soft-deleted. The restore is itself audited.
+type RestoreService struct {
+	auditRepo     repositories.AuditRepository
+	productRepo   repositories.ProductRepository
+	milestoneRepo repositories.MilestoneRepository
+	versionRepo   repositories.ProductVersionRepository
+	memberRepo    repositories.ProductMemberRepository
+	tx            repositories.Transactor
+	auditSvc      *AuditService
+	activitySvc   *ActivityService
+	policy        *authz.Engine
+}
+
+func NewRestoreService(
+	auditRepo repositories.AuditRepository,
+	productRepo repositories.ProductRepository,
+	milestoneRepo repositories.MilestoneRepository,
+	versionRepo repositories.ProductVersionRepository,
+	memberRepo repositories.ProductMemberRepository,
+	tx repositories.Transactor,
+	auditSvc *AuditService,
+	activitySvc *ActivityService,
+	policy *authz.Engine,
+) *RestoreService {
+	return &RestoreService{
+		auditRepo:     auditRepo,
+		productRepo:   productRepo,
+		milestoneRepo: milestoneRepo,
+		versionRepo:   versionRepo,
+		memberRepo:    memberRepo,
+		tx:            tx,
+		auditSvc:      auditSvc,
+		activitySvc:   activitySvc,
+		policy:        policy,
+	}
+}
+
+// restoreTarget is one restore in progress.
+type restoreTarget struct {
+	entry   *models.AuditLog
+	state   string
+	snap    models.JSONB
+	deleted bool
+	oldData models.JSONB // nil when the entity was deleted
+}
+
+// Restore reverts the entity of audit entry auditID to the entry's old_data (state "before", i.e. undo the
+// entry and everything after it) or new_data (state "after"). Unless req.Force is set, the entity must
+// still match its latest audit entry; req.ExpectedLatestID additionally pins which entry that is.
+func (s *RestoreService) Restore(ctx context.Context, auditID uuid.UUID, req dto.AuditRestoreRequest, callerID uuid.UUID, callerRole models.Role, meta dto.AuditMeta) (*dto.AuditRestoreResponse, error) {
+	state := req.State
+	if state == "" {
+		state = "before"
+	}
+	if state != "before" && state != "after" {
+		return nil, ErrRestoreInvalidState
+	}
+	entry, err := s.auditRepo.GetByID(ctx, auditID)
+	if err != nil {
+		return nil, err
+	}
+	fields, ok := restorableFields[entry.EntityType]
+	if !ok {
+		return nil, ErrRestoreUnsupported
+	}
+	entityID, err := uuid.Parse(entry.EntityID)
+	if err != nil {
+		return nil, ErrRestoreUnsupported
+	}
+	t := &restoreTarget{entry: entry, state: state, snap: entry.OldData}
+	if state == "after" {
+		t.snap = entry.NewData
+	}
+	if len(t.snap) == 0 {
+		return nil, ErrRestoreNoSnapshot
+	}
+	latest, err := s.auditRepo.LatestForEntity(ctx, entry.EntityType, entry.EntityID)
+	if err != nil {
+		return nil, err
+	}
+	if req.ExpectedLatestID != "" && latest.ID.String() != req.ExpectedLatestID {
+		return nil, ErrRestoreConflict
+	}
+	// drifted reports whether the live entity no longer matches what the audit trail last recorded.
+	drifted := func(live models.JSONB, deleted bool) bool {
+		if req.Force {
+			return false
+		}
+		if latest.Action == "delete" || deleted {
+			return latest.Action != "delete" || !deleted
+		}
+		if latest.NewData == nil {
+			return false
+		}
+		for _, f := range fields {
+			if !reflect.DeepEqual(latest.NewData[f], live[f]) {
+				return true
+			}
+		}
+		return false
+	}
+	sub := authz.Subject{UserID: callerID, Role: callerRole}
+	switch entry.EntityType {
+	case "product":
+		return s.restoreProduct(ctx, entityID, t, drifted, sub, meta)
+	case "milestone":
+		return s.restoreMilestone(ctx, entityID, t, drifted, sub, meta)
+	default:
+		return s.restoreVersion(ctx, entityID, t, drifted, sub, meta)
+	}
+}
+
+func (s *RestoreService) restoreProduct(ctx context.Context, id uuid.UUID, t *restoreTarget, drifted func(models.JSONB, bool) bool, sub authz.Subject, meta dto.AuditMeta) (*dto.AuditRestoreResponse, error) {
+	p, err := s.productRepo.GetByIDUnscoped(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	t.deleted = p.DeletedAt.Valid
+	live := ToJSONB(productToResponse(p))
+	if drifted(live, t.deleted) {
+		return nil, ErrRestoreConflict
+	}
+	if !t.deleted {
+		t.oldData = live
+	}
+	var restored dto.ProductResponse
+	if err := fromJSONB(t.snap, &restored); err != nil {
+		return nil, err
+	}
+	// Same rules as ProductService.Update and Delete: owner changes need product:update on any product,
+	// status changes need product:status, and bringing a product back needs product:delete.
+	perms := []authz.Permission{authz.PermAuditRestore, authz.PermProductUpdate}
+	if err := s.authorize(ctx, sub, p, []models.ProductMemberRole{models.ProductMemberCoOwner}, perms...); err != nil {
+		return nil, err
+	}
+	if restored.Status != string(p.Status) || restored.LifecycleStatus != string(p.LifecycleStatus) {
+		if err := s.policy.Authorize(ctx, sub, authz.PermProductStatus, authz.Any); err != nil {
+			return nil, err
+		}
+	}
+	var ownerID *uuid.UUID
+	if restored.OwnerID != nil {
+		parsed, err := uuid.Parse(*restored.OwnerID)
+		if err != nil {
+			return nil, ErrInvalidOwnerID
+		}
+		ownerID = &parsed
+	}
+	if !reflect.DeepEqual(ownerID, p.OwnerID) {
+		if err := s.policy.Authorize(ctx, sub, authz.PermProductUpdate, authz.Any); err != nil {
+			return nil, err
+		}
+	}
+	if t.deleted {
+		if err := s.policy.Authorize(ctx, sub, authz.PermProductDelete, authz.Any); err != nil {
+			return nil, err
+		}
+	}
+	p.Name = restored.Name
+	p.Version = restored.Version
+	p.Description = restored.Description
+	p.OwnerID = ownerID
+	p.Status = models.ProductStatus(restored.Status)
+	p.LifecycleStatus = models.LifecycleStatus(restored.LifecycleStatus)
+	p.Category1 = restored.Category1
+	p.Category2 = restored.Category2
+	p.Category3 = restored.Category3
+	p.Metadata = models.JSONB(restored.Metadata)
+	p.Owner = nil
+	p.DeletedAt = gorm.DeletedAt{}
+	var newData models.JSONB
+	if err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if t.deleted {
+			if err := s.productRepo.Undelete(ctx, id); err != nil {
+				return err
+			}
+		}
+		if err := s.productRepo.Update(ctx, p); err != nil {
+			return err
+		}
+		fresh, err := s.productRepo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		newData = ToJSONB(productToResponse(fresh))
+		return s.logRestore(ctx, t, newData, fresh.Name, meta)
+	}); err != nil {
+		return nil, err
+	}
+	return restoreResponse(t, newData), nil
+}
+
+func (s *RestoreService) restoreMilestone(ctx context.Context, id uuid.UUID, t *restoreTarget, drifted func(models.JSONB, bool) bool, sub authz.Subject, meta dto.AuditMeta) (*dto.AuditRestoreResponse, error) {
+	m, err := s.milestoneRepo.GetByIDUnscoped(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	t.deleted = m.DeletedAt.Valid
+	live := ToJSONB(milestoneToResponse(m))
+	if drifted(live, t.deleted) {
+		return nil, ErrRestoreConflict
+	}
+	if !t.deleted {
+		t.oldData = live
+	}
+	p, err := s.productRepo.GetByID(ctx, m.ProductID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRestoreParentGone
+	} else if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx, sub, p, productEditorRoles, authz.PermAuditRestore, authz.PermMilestoneWrite); err != nil {
+		return nil, err
+	}
+	var restored dto.MilestoneResponse
+	if err := fromJSONB(t.snap, &restored); err != nil {
+		return nil, err
+	}
+	start, err := time.Parse("2006-01-02", restored.StartDate)
+	if err != nil {
+		return nil, err
+	}
+	var end *time.Time
+	if restored.EndDate != "" {
+		e, err := time.Parse("2006-01-02", restored.EndDate)
+		if err != nil {
+			return nil, err
+		}
+		if e.Before(start) {
+			return nil, ErrEndDateBeforeStart
+		}
+		end = &e
+	}
+	var versionID *uuid.UUID
+	if restored.ProductVersionID != nil {
+		vid, err := uuid.Parse(*restored.ProductVersionID)
+		if err != nil {
+			return nil, err
+		}
+		if pv, err := s.versionRepo.GetByID(vid); err != nil || pv.ProductID != m.ProductID {
+			return nil, ErrRestoreParentGone
+		}
+		versionID = &vid
+	}
+	m.ProductVersionID = versionID
+	m.Label = restored.Label
+	m.StartDate = start
+	m.EndDate = end
+	m.Type = restored.Type
+	m.Color = restored.Color
+	m.Extra = models.JSONB(restored.Extra)
+	m.DeletedAt = gorm.DeletedAt{}
+	var newData models.JSONB
+	if err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if t.deleted {
+			if err := s.milestoneRepo.Undelete(ctx, id); err != nil {
+				return err
+			}
+		}
+		if err := s.milestoneRepo.Update(ctx, m); err != nil {
+			return err
+		}
+		newData = ToJSONB(milestoneToResponse(m))
+		return s.logRestore(ctx, t, newData, m.Label, meta)
+	}); err != nil {
+		return nil, err
+	}
+	return restoreResponse(t, newData), nil
+}
+
+func (s *RestoreService) restoreVersion(ctx context.Context, id uuid.UUID, t *restoreTarget, drifted func(models.JSONB, bool) bool, sub authz.Subject, meta dto.AuditMeta) (*dto.AuditRestoreResponse, error) {
+	pv, err := s.versionRepo.GetByIDUnscoped(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	t.deleted = pv.DeletedAt.Valid
+	live := ToJSONB(&dto.ProductVersionResponse{
+		ID:        pv.ID.String(),
+		ProductID: pv.ProductID.String(),
+		Version:   pv.Version,
+		CreatedAt: pv.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	})
+	if drifted(live, t.deleted) {
+		return nil, ErrRestoreConflict
+	}
+	if !t.deleted {
+		t.oldData = live
+	}
+	p, err := s.productRepo.GetByID(ctx, pv.ProductID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRestoreParentGone
+	} else if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx, sub, p, productEditorRoles, authz.PermAuditRestore, authz.PermVersionWrite); err != nil {
+		return nil, err
+	}
+	var restored dto.ProductVersionResponse
+	if err := fromJSONB(t.snap, &restored); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(restored.Version) == "" {
+		return nil, ErrRestoreNoSnapshot
+	}
+	pv.Version = restored.Version
+	pv.DeletedAt = gorm.DeletedAt{}
+	var newData models.JSONB
+	if err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if t.deleted {
+			if err := s.versionRepo.Undelete(ctx, id); err != nil {
+				return err
+			}
+		}
+		if err := s.versionRepo.Update(ctx, pv); err != nil {
+			return err
+		}
+		newData = ToJSONB(&dto.ProductVersionResponse{
+			ID:        pv.ID.String(),
+			ProductID: pv.ProductID.String(),
+			Version:   pv.Version,
+			CreatedAt: pv.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		})
+		return s.logRestore(ctx, t, newData, pv.Version, meta)
+	}); err != nil {
+		return nil, err
+	}
+	return restoreResponse(t, newData), nil
+}
+
+// authorize requires each permission either for every product or, through an :own grant, for p (owned by
+// the caller or shared with them in one of roles). Own-scoped grants only apply while p is active.
+func (s *RestoreService) authorize(ctx context.Context, sub authz.Subject, p *models.Product, roles []models.ProductMemberRole, perms ...authz.Permission) error {
+	res, err := productResource(ctx, s.memberRepo, p, roles...)
+	if err != nil {
+		return err
+	}
+	for _, perm := range perms {
+		if s.policy.Allowed(ctx, sub, perm, authz.Any) {
+			continue
+		}
+		if err := s.policy.Authorize(ctx, sub, perm, res); err != nil {
+			return err
+		}
+		if p.LifecycleStatus != models.LifecycleActive {
+			return ErrForbidden
+		}
+	}
+	return nil
+}
+
+func (s *RestoreService) logRestore(ctx context.Context, t *restoreTarget, newData models.JSONB, details string, meta dto.AuditMeta) error {
+	if s.auditSvc != nil {
+		if err := s.auditSvc.Log(ctx, AuditEntry{
+			UserID:     meta.UserID,
+			Action:     "restore",
+			EntityType: t.entry.EntityType,
+			EntityID:   t.entry.EntityID,
+			OldData:    t.oldData,
+			NewData:    newData,
+			Metadata:   models.JSONB{"restored_from": t.entry.ID.String(), "state": t.state, "undeleted": t.deleted},
+			IPAddress:  meta.IP,
+			UserAgent:  meta.UserAgent,
+			TraceID:    meta.TraceID,
+		}); err != nil {
+			return err
+		}
+	}
+	if s.activitySvc != nil && meta.UserID != nil {
+		return s.activitySvc.Log(ctx, ActivityEntry{
+			UserID:     meta.UserID,
+			Action:     "restore",
+			EntityType: t.entry.EntityType,
+			EntityID:   t.entry.EntityID,
+			Details:    details,
+			IPAddress:  meta.IP,
+			UserAgent:  meta.UserAgent,
+		})
+	}
+	return nil
+}
+
+func restoreResponse(t *restoreTarget, newData models.JSONB) *dto.AuditRestoreResponse {
+	return &dto.AuditRestoreResponse{
+		EntityType:   t.entry.EntityType,
+		EntityID:     t.entry.EntityID,
+		Undeleted:    t.deleted,
+		RestoredFrom: t.entry.ID.String(),
+		Data:         newData,
+	}
+}
+
+// fromJSONB decodes a stored snapshot into a response struct (the reverse of ToJSONB).
+func fromJSONB(j models.JSONB, v interface{}) error {
+	b, err := json.Marshal(j)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
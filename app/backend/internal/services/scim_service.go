@@ -0,0 +1,1118 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/dto"
+	"github.com/rm/roadmap/backend/internal/i18n"
+	"github.com/rm/roadmap/backend/internal/models"
+	"github.com/rm/roadmap/backend/internal/repositories"
+	"github.com/rm/roadmap/backend/internal/scim"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+type SCIMConfig struct {
+	BaseURL             string     // public URL of /scim/v2, used for meta.location; relative when empty
+	DefaultDepartmentID *uuid.UUID // department for groups created without the roadmap extension
+}
+
+// SCIMService maps SCIM Users to models.User and SCIM Groups to models.Team. Group membership is the
+// user's team (a user belongs to at most one team, so adding them to a group moves them). The enterprise
+// manager is the direct manager; dotted-line managers use the roadmap extension.
+type SCIMService struct {
+	userRepo        repositories.UserRepository
+	teamRepo        repositories.TeamRepository
+	dottedRepo      repositories.UserDottedLineRepository
+	productRepo     repositories.ProductRepository
+	memberRepo      repositories.ProductMemberRepository
+	sessionSvc      *SessionService
+	tx              repositories.Transactor
+	auditSvc        *AuditService
+	notificationSvc *NotificationService
+	cfg             SCIMConfig
+	log             *zap.Logger
+}
+
+func NewSCIMService(userRepo repositories.UserRepository, teamRepo repositories.TeamRepository, dottedRepo repositories.UserDottedLineRepository, productRepo repositories.ProductRepository, memberRepo repositories.ProductMemberRepository, sessionSvc *SessionService, tx repositories.Transactor, auditSvc *AuditService, notificationSvc *NotificationService, cfg SCIMConfig, log *zap.Logger) *SCIMService {
+	if log == nil {
+		log = zap.NewNop()
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	return &SCIMService{userRepo: userRepo, teamRepo: teamRepo, dottedRepo: dottedRepo, productRepo: productRepo, memberRepo: memberRepo, sessionSvc: sessionSvc, tx: tx, auditSvc: auditSvc, notificationSvc: notificationSvc, cfg: cfg, log: log}
+}
+
+var scimUserColumns = map[string]scim.Column{
+	"id":                {Name: "id", Type: scim.ColumnUUID},
+	"username":          {Name: "email", Type: scim.ColumnText},
+	"emails":            {Name: "email", Type: scim.ColumnText},
+	"emails.value":      {Name: "email", Type: scim.ColumnText},
+	"externalid":        {Name: "external_id", Type: scim.ColumnCaseExactText},
+	"displayname":       {Name: "name", Type: scim.ColumnText},
+	"name.formatted":    {Name: "name", Type: scim.ColumnText},
+	"active":            {Name: "active", Type: scim.ColumnBool},
+	"meta.created":      {Name: "created_at", Type: scim.ColumnTime},
+	"meta.lastmodified": {Name: "updated_at", Type: scim.ColumnTime},
+}
+
+var scimGroupColumns = map[string]scim.Column{
+	"id":                {Name: "id", Type: scim.ColumnUUID},
+	"displayname":       {Name: "name", Type: scim.ColumnText},
+	"externalid":        {Name: "external_id", Type: scim.ColumnCaseExactText},
+	"meta.created":      {Name: "created_at", Type: scim.ColumnTime},
+	"meta.lastmodified": {Name: "updated_at", Type: scim.ColumnTime},
+}
+
+var (
+	scimManagerAttr    = strings.ToLower(scim.SchemaEnterpriseUser) + ":manager"
+	scimDottedLineAttr = strings.ToLower(scim.SchemaRoadmapUser) + ":dottedlinemanagers"
+)
+
+func scimWhere(filter string, columns map[string]scim.Column) (string, []interface{}, error) {
+	if strings.TrimSpace(filter) == "" {
+		return "", nil, nil
+	}
+	e, err := scim.ParseFilter(filter)
+	if err != nil {
+		return "", nil, err
+	}
+	return scim.ToSQL(e, columns)
+}
+
+// ---- Users ----
+
+func (s *SCIMService) ListUsers(ctx context.Context, filter string, startIndex, count int) (*scim.ListResponse, error) {
+	where, args, err := scimWhere(filter, scimUserColumns)
+	if err != nil {
+		return nil, err
+	}
+	offset, limit := scim.Page(startIndex, count)
+	users, total, err := s.userRepo.Search(ctx, where, args, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]scim.User, len(users))
+	for i := range users {
+		out[i] = s.toSCIMUser(&users[i])
+	}
+	return &scim.ListResponse{Schemas: []string{scim.SchemaListResponse}, TotalResults: total, StartIndex: offset + 1, ItemsPerPage: len(out), Resources: out}, nil
+}
+
+func (s *SCIMService) GetUser(ctx context.Context, id string) (*scim.User, error) {
+	u, err := s.loadUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	out := s.toSCIMUser(u)
+	return &out, nil
+}
+
+func (s *SCIMService) CreateUser(ctx context.Context, in scim.User, meta dto.AuditMeta) (*scim.User, error) {
+	st := scimUserState{active: true}
+	if err := st.setFromResource(in, true); err != nil {
+		return nil, err
+	}
+	if st.email == "" {
+		return nil, scim.BadRequest("invalidValue", "userName is required")
+	}
+	u := &models.User{Role: models.RoleUser, Active: true}
+	if err := s.applyUser(ctx, u, st, true, meta); err != nil {
+		return nil, err
+	}
+	return s.GetUser(ctx, u.ID.String())
+}
+
+// ReplaceUser implements PUT. Manager and dotted-line managers are only replaced when their extension is sent,
+// since many IdPs never send them.
+func (s *SCIMService) ReplaceUser(ctx context.Context, id string, in scim.User, meta dto.AuditMeta) (*scim.User, error) {
+	u, err := s.loadUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	st := s.userState(u)
+	st.externalID = nil
+	st.active = true
+	if err := st.setFromResource(in, false); err != nil {
+		return nil, err
+	}
+	if st.email == "" {
+		return nil, scim.BadRequest("invalidValue", "userName is required")
+	}
+	if err := s.applyUser(ctx, u, st, false, meta); err != nil {
+		return nil, err
+	}
+	return s.GetUser(ctx, u.ID.String())
+}
+
+func (s *SCIMService) PatchUser(ctx context.Context, id string, req scim.PatchRequest, meta dto.AuditMeta) (*scim.User, error) {
+	u, err := s.loadUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	st := s.userState(u)
+	for _, op := range req.Operations {
+		if err := st.patch(op); err != nil {
+			return nil, err
+		}
+	}
+	if st.email == "" {
+		return nil, scim.BadRequest("invalidValue", "userName cannot be removed")
+	}
+	if err := s.applyUser(ctx, u, st, false, meta); err != nil {
+		return nil, err
+	}
+	return s.GetUser(ctx, u.ID.String())
+}
+
+// DeleteUser deactivates the user (clearing product ownership and sessions), removes their product
+// memberships and soft-deletes them.
+func (s *SCIMService) DeleteUser(ctx context.Context, id string, meta dto.AuditMeta) error {
+	u, err := s.loadUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.tx.InTx(ctx, func(ctx context.Context) error {
+		if u.Active {
+			if err := s.offboard(ctx, u, meta); err != nil {
+				return err
+			}
+		}
+		if err := s.memberRepo.DeleteByUser(ctx, u.ID); err != nil {
+			return err
+		}
+		if err := s.userRepo.Delete(ctx, u.ID); err != nil {
+			return err
+		}
+		return s.audit(ctx, "delete", "user", u.ID.String(), scimUserAudit(u), nil, meta)
+	})
+}
+
+func (s *SCIMService) loadUser(ctx context.Context, id string) (*models.User, error) {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, scim.NotFound("User", id)
+	}
+	users, _, err := s.userRepo.Search(ctx, "id = ?", []interface{}{uid}, 0, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, scim.NotFound("User", id)
+	}
+	return &users[0], nil
+}
+
+// scimUserState is the writable part of a SCIM User; PUT and PATCH both compute one and hand it to applyUser.
+type scimUserState struct {
+	name, givenName, familyName string
+	email                       string
+	externalID                  *string
+	active                      bool
+	password                    string
+	managerID                   *uuid.UUID
+	dottedLine                  []uuid.UUID
+	dottedLineSet               bool // dottedLine should replace the current set
+	managerSet                  bool
+}
+
+func (s *SCIMService) userState(u *models.User) scimUserState {
+	st := scimUserState{name: u.Name, email: u.Email, externalID: u.ExternalID, active: u.Active, managerID: u.DirectManagerID}
+	st.givenName, st.familyName, _ = strings.Cut(u.Name, " ")
+	for _, d := range u.DottedLineManagers {
+		st.dottedLine = append(st.dottedLine, d.ManagerID)
+	}
+	return st
+}
+
+func (st *scimUserState) setFromResource(in scim.User, create bool) error {
+	st.email = scimPrimaryEmail(in)
+	if in.ExternalID != "" {
+		ext := in.ExternalID
+		st.externalID = &ext
+	}
+	switch {
+	case in.Name != nil && in.Name.Formatted != "":
+		st.name = in.Name.Formatted
+	case in.Name != nil && (in.Name.GivenName != "" || in.Name.FamilyName != ""):
+		st.name = strings.TrimSpace(in.Name.GivenName + " " + in.Name.FamilyName)
+	case in.DisplayName != "":
+		st.name = in.DisplayName
+	case create:
+		st.name = st.email
+	}
+	if in.Active != nil {
+		st.active = *in.Active
+	}
+	st.password = in.Password
+	if in.Enterprise != nil || create {
+		st.managerSet = true
+		st.managerID = nil
+		if in.Enterprise != nil && in.Enterprise.Manager != nil && in.Enterprise.Manager.Value != "" {
+			id, err := uuid.Parse(in.Enterprise.Manager.Value)
+			if err != nil {
+				return scim.BadRequest("invalidValue", "manager.value must be a user id")
+			}
+			st.managerID = &id
+		}
+	}
+	if in.Roadmap != nil || create {
+		st.dottedLineSet = true
+		st.dottedLine = nil
+		if in.Roadmap != nil {
+			ids, err := scimIDs(in.Roadmap.DottedLineManagers)
+			if err != nil {
+				return err
+			}
+			st.dottedLine = ids
+		}
+	}
+	return nil
+}
+
+func scimPrimaryEmail(in scim.User) string {
+	if in.UserName != "" {
+		return strings.TrimSpace(in.UserName)
+	}
+	for _, e := range in.Emails {
+		if e.Primary {
+			return strings.TrimSpace(e.Value)
+		}
+	}
+	if len(in.Emails) > 0 {
+		return strings.TrimSpace(in.Emails[0].Value)
+	}
+	return ""
+}
+
+func (st *scimUserState) patch(op scim.PatchOp) error {
+	kind := strings.ToLower(op.Op)
+	if kind != "add" && kind != "replace" && kind != "remove" {
+		return scim.BadRequest("invalidSyntax", "unsupported op %q", op.Op)
+	}
+	if op.Path == "" {
+		if kind == "remove" {
+			return scim.BadRequest("noTarget", "remove requires a path")
+		}
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(op.Value, &obj); err != nil {
+			return scim.BadRequest("invalidValue", "value must be an object when path is omitted")
+		}
+		for k, v := range obj {
+			lk := strings.ToLower(k)
+			if lk == strings.ToLower(scim.SchemaEnterpriseUser) || lk == strings.ToLower(scim.SchemaRoadmapUser) || lk == "name" {
+				var sub map[string]json.RawMessage
+				if err := json.Unmarshal(v, &sub); err != nil {
+					return scim.BadRequest("invalidValue", "%s must be an object", k)
+				}
+				sep := ":"
+				if lk == "name" {
+					sep = "."
+				}
+				for sk, sv := range sub {
+					if err := st.patchPath(kind, k+sep+sk, sv); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			if err := st.patchPath(kind, k, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return st.patchPath(kind, op.Path, op.Value)
+}
+
+func (st *scimUserState) patchPath(kind, rawPath string, value json.RawMessage) error {
+	p, err := scim.ParsePath(rawPath)
+	if err != nil {
+		return err
+	}
+	remove := kind == "remove"
+	switch {
+	case p.Attr == "active" && p.Sub == "":
+		if remove {
+			return scim.BadRequest("mutability", "active cannot be removed")
+		}
+		b, err := scimBool(value)
+		if err != nil {
+			return err
+		}
+		st.active = b
+	case p.Attr == "username" && p.Sub == "":
+		if remove {
+			st.email = ""
+			return nil
+		}
+		return scimString(value, &st.email)
+	case p.Attr == "emails":
+		if remove {
+			return nil // the email is the userName; it cannot be removed on its own
+		}
+		if p.Sub == "value" || p.Filter != nil {
+			return scimString(value, &st.email)
+		}
+		var list []scim.MultiValue
+		if err := json.Unmarshal(value, &list); err != nil {
+			return scim.BadRequest("invalidValue", "emails must be a list")
+		}
+		if e := scimPrimaryEmail(scim.User{Emails: list}); e != "" {
+			st.email = e
+		}
+	case p.Attr == "displayname" || (p.Attr == "name" && p.Sub == "formatted"):
+		if remove {
+			return nil
+		}
+		return scimString(value, &st.name)
+	case p.Attr == "name" && (p.Sub == "givenname" || p.Sub == "familyname"):
+		var v string
+		if !remove {
+			if err := scimString(value, &v); err != nil {
+				return err
+			}
+		}
+		if p.Sub == "givenname" {
+			st.givenName = v
+		} else {
+			st.familyName = v
+		}
+		if n := strings.TrimSpace(st.givenName + " " + st.familyName); n != "" {
+			st.name = n
+		}
+	case p.Attr == "name" && p.Sub == "":
+		if remove {
+			return nil
+		}
+		var n scim.Name
+		if err := json.Unmarshal(value, &n); err != nil {
+			return scim.BadRequest("invalidValue", "name must be an object")
+		}
+		if n.Formatted != "" {
+			st.name = n.Formatted
+		} else if full := strings.TrimSpace(n.GivenName + " " + n.FamilyName); full != "" {
+			st.name = full
+		}
+	case p.Attr == "externalid":
+		if remove {
+			st.externalID = nil
+			return nil
+		}
+		var v string
+		if err := scimString(value, &v); err != nil {
+			return err
+		}
+		st.externalID = &v
+	case p.Attr == "password":
+		if remove {
+			return nil
+		}
+		return scimString(value, &st.password)
+	case p.Attr == scimManagerAttr || p.Attr == scimManagerAttr+".value":
+		st.managerSet = true
+		if remove {
+			st.managerID = nil
+			return nil
+		}
+		id, err := scimRefID(value)
+		if err != nil {
+			return err
+		}
+		st.managerID = id
+	case p.Attr == scimDottedLineAttr:
+		st.dottedLineSet = true
+		return st.patchDottedLine(kind, p, value)
+	case p.Attr == "groups":
+		return scim.BadRequest("mutability", "groups is read-only; change membership through the Group resource")
+	default:
+		return scim.BadRequest("invalidPath", "unsupported path %q", rawPath)
+	}
+	return nil
+}
+
+func (st *scimUserState) patchDottedLine(kind string, p scim.Path, value json.RawMessage) error {
+	var ids []uuid.UUID
+	if len(value) > 0 {
+		var list []scim.MultiValue
+		if err := json.Unmarshal(value, &list); err != nil {
+			return scim.BadRequest("invalidValue", "dottedLineManagers must be a list")
+		}
+		var err error
+		if ids, err = scimIDs(list); err != nil {
+			return err
+		}
+	}
+	switch kind {
+	case "replace":
+		st.dottedLine = ids
+	case "add":
+		st.dottedLine = appendMissing(st.dottedLine, ids...)
+	case "remove":
+		if p.Filter == nil && len(ids) == 0 {
+			st.dottedLine = nil
+			return nil
+		}
+		kept := st.dottedLine[:0:0]
+		for _, m := range st.dottedLine {
+			drop := containsUUID(ids, m)
+			if p.Filter != nil && scim.Match(p.Filter, func(a string) (string, bool) {
+				if a == "value" {
+					return m.String(), true
+				}
+				return "", false
+			}) {
+				drop = true
+			}
+			if !drop {
+				kept = append(kept, m)
+			}
+		}
+		st.dottedLine = kept
+	}
+	return nil
+}
+
+// applyUser validates st and writes it to u. It offboards the user when st deactivates them.
+func (s *SCIMService) applyUser(ctx context.Context, u *models.User, st scimUserState, create bool, meta dto.AuditMeta) error {
+	old := scimUserAudit(u)
+	wasActive := u.Active
+	if !strings.EqualFold(st.email, u.Email) {
+		if existing, err := s.userRepo.GetByEmail(st.email); err == nil && existing.ID != u.ID {
+			return scim.Conflict("userName %s already exists", st.email)
+		} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+	}
+	if st.externalID != nil && (u.ExternalID == nil || *u.ExternalID != *st.externalID) {
+		users, _, err := s.userRepo.Search(ctx, "external_id = ?", []interface{}{*st.externalID}, 0, 1)
+		if err != nil {
+			return err
+		}
+		if len(users) > 0 && users[0].ID != u.ID {
+			return scim.Conflict("externalId %s already exists", *st.externalID)
+		}
+	}
+	if st.managerSet && st.managerID != nil {
+		if !create && *st.managerID == u.ID {
+			return scim.BadRequest("invalidValue", "user cannot be their own manager")
+		}
+		if _, err := s.userRepo.GetByID(*st.managerID); err != nil {
+			return scim.BadRequest("invalidValue", "manager %s not found", st.managerID)
+		}
+		depth, err := s.userRepo.ManagerChainDepth(ctx, *st.managerID)
+		if err != nil {
+			return scim.BadRequest("invalidValue", "%s", err.Error())
+		}
+		if depth >= repositories.MaxManagerHierarchyDepth {
+			return scim.BadRequest("invalidValue", "manager hierarchy would exceed maximum depth (%d)", repositories.MaxManagerHierarchyDepth)
+		}
+	}
+	for _, m := range st.dottedLine {
+		if m == u.ID {
+			return scim.BadRequest("invalidValue", "user cannot be their own dotted-line manager")
+		}
+		if _, err := s.userRepo.GetByID(m); err != nil {
+			return scim.BadRequest("invalidValue", "dotted-line manager %s not found", m)
+		}
+	}
+
+	u.Name = st.name
+	u.Email = st.email
+	u.ExternalID = st.externalID
+	u.Active = st.active
+	if st.managerSet {
+		u.DirectManagerID = st.managerID
+	}
+	if st.password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(st.password), bcrypt.DefaultCost)
+		if err != nil {
+			return err
+		}
+		u.PasswordHash = string(hash)
+	}
+	// Clear associations so GORM persists the foreign key columns
+	u.Team = nil
+	u.DirectManager = nil
+	dotted := u.DottedLineManagers
+	u.DottedLineManagers = nil
+	return s.tx.InTx(ctx, func(ctx context.Context) error {
+		if create {
+			if err := s.userRepo.Create(ctx, u); err != nil {
+				return err
+			}
+			// active has a database default of true, which GORM applies to a false zero value on insert.
+			if !st.active {
+				u.Active = false
+				if err := s.userRepo.Update(ctx, u); err != nil {
+					return err
+				}
+			}
+		} else if err := s.userRepo.Update(ctx, u); err != nil {
+			return err
+		}
+		if st.managerSet && st.managerID != nil {
+			// A cycle rolls the whole change back.
+			if _, err := s.userRepo.ManagerChainDepth(ctx, u.ID); err != nil {
+				return scim.BadRequest("invalidValue", "%s", err.Error())
+			}
+		}
+		if st.dottedLineSet {
+			if err := s.syncDottedLine(ctx, u.ID, dotted, st.dottedLine); err != nil {
+				return err
+			}
+		}
+		if wasActive && !u.Active && !create {
+			if err := s.offboard(ctx, u, meta); err != nil {
+				return err
+			}
+		}
+		action := "update"
+		if create {
+			action, old = "create", nil
+		}
+		return s.audit(ctx, action, "user", u.ID.String(), old, scimUserAudit(u), meta)
+	})
+}
+
+func (s *SCIMService) syncDottedLine(ctx context.Context, userID uuid.UUID, current []models.UserDottedLineManager, want []uuid.UUID) error {
+	have := make([]uuid.UUID, len(current))
+	for i := range current {
+		have[i] = current[i].ManagerID
+	}
+	for _, m := range have {
+		if !containsUUID(want, m) {
+			if err := s.dottedRepo.Delete(ctx, userID, m); err != nil {
+				return err
+			}
+		}
+	}
+	for _, m := range want {
+		if !containsUUID(have, m) {
+			if err := s.dottedRepo.Create(ctx, &models.UserDottedLineManager{UserID: userID, ManagerID: m}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// offboard runs in the transaction deactivating a user: their products lose their owner (admins are notified so
+// the products can be reassigned) and all sessions are revoked.
+func (s *SCIMService) offboard(ctx context.Context, u *models.User, meta dto.AuditMeta) error {
+	products, err := s.productRepo.ListByOwner(u.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.productRepo.ClearOwnerForUser(ctx, u.ID); err != nil {
+		return err
+	}
+	if s.sessionSvc != nil {
+		if _, err := s.sessionSvc.RevokeAllForUser(ctx, u.ID, nil, meta); err != nil {
+			return err
+		}
+	}
+	for i := range products {
+		if err := s.audit(ctx, "clear_owner", "product", products[i].ID.String(),
+			models.JSONB{"owner_id": u.ID.String()}, models.JSONB{"owner_id": nil}, meta); err != nil {
+			return err
+		}
+	}
+	if len(products) > 0 {
+		repositories.AfterCommit(ctx, func() { s.notifyOrphanedProducts(u, products) })
+	}
+	return nil
+}
+
+func (s *SCIMService) notifyOrphanedProducts(u *models.User, products []models.Product) {
+	if s.notificationSvc == nil {
+		return
+	}
+	admins, _ := s.userRepo.ListByRole(models.RoleAdmin)
+	superadmins, _ := s.userRepo.ListByRole(models.RoleSuperadmin)
+	recipients := append(admins, superadmins...)
+	for i := range products {
+		p := &products[i]
+		title := i18n.M("notification.product_owner_deactivated.title")
+		message := i18n.M("notification.product_owner_deactivated.message", "user", u.Name, "product", p.Name)
+		for j := range recipients {
+			if !recipients[j].Active {
+				continue
+			}
+			if _, err := s.notificationSvc.Create(recipients[j].ID, models.NotificationTypeProductOwnerDeactivated, title, message, "product", &p.ID); err != nil {
+				s.log.Warn("orphaned product notification failed", zap.String("product_id", p.ID.String()), zap.Error(err))
+			}
+		}
+	}
+}
+
+func (s *SCIMService) toSCIMUser(u *models.User) scim.User {
+	active := u.Active
+	out := scim.User{
+		Schemas:     []string{scim.SchemaUser, scim.SchemaEnterpriseUser, scim.SchemaRoadmapUser},
+		ID:          u.ID.String(),
+		UserName:    u.Email,
+		Name:        &scim.Name{Formatted: u.Name},
+		DisplayName: u.Name,
+		Emails:      []scim.MultiValue{{Value: u.Email, Type: "work", Primary: true}},
+		Active:      &active,
+		Meta:        s.meta("User", "Users", u.ID, u.CreatedAt, u.UpdatedAt),
+	}
+	if given, family, ok := strings.Cut(u.Name, " "); ok {
+		out.Name.GivenName, out.Name.FamilyName = given, family
+	}
+	if u.ExternalID != nil {
+		out.ExternalID = *u.ExternalID
+	}
+	if u.TeamID != nil {
+		g := scim.MultiValue{Value: u.TeamID.String(), Ref: s.location("Groups", *u.TeamID)}
+		if u.Team != nil {
+			g.Display = u.Team.Name
+		}
+		out.Groups = []scim.MultiValue{g}
+	}
+	if u.DirectManagerID != nil {
+		out.Enterprise = &scim.EnterpriseUser{Manager: &scim.Manager{Value: u.DirectManagerID.String(), Ref: s.location("Users", *u.DirectManagerID)}}
+	}
+	if len(u.DottedLineManagers) > 0 {
+		ext := &scim.RoadmapUser{}
+		for _, d := range u.DottedLineManagers {
+			ext.DottedLineManagers = append(ext.DottedLineManagers, scim.MultiValue{Value: d.ManagerID.String(), Ref: s.location("Users", d.ManagerID)})
+		}
+		out.Roadmap = ext
+	}
+	return out
+}
+
+func scimUserAudit(u *models.User) models.JSONB {
+	data := models.JSONB{"name": u.Name, "email": u.Email, "active": u.Active}
+	if u.ExternalID != nil {
+		data["external_id"] = *u.ExternalID
+	}
+	if u.DirectManagerID != nil {
+		data["direct_manager_id"] = u.DirectManagerID.String()
+	}
+	return data
+}
+
+// ---- Groups ----
+
+func (s *SCIMService) ListGroups(ctx context.Context, filter string, startIndex, count int, withMembers bool) (*scim.ListResponse, error) {
+	where, args, err := scimWhere(filter, scimGroupColumns)
+	if err != nil {
+		return nil, err
+	}
+	offset, limit := scim.Page(startIndex, count)
+	teams, total, err := s.teamRepo.Search(ctx, where, args, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]scim.Group, len(teams))
+	for i := range teams {
+		out[i] = s.toSCIMGroup(&teams[i], withMembers)
+	}
+	return &scim.ListResponse{Schemas: []string{scim.SchemaListResponse}, TotalResults: total, StartIndex: offset + 1, ItemsPerPage: len(out), Resources: out}, nil
+}
+
+func (s *SCIMService) GetGroup(ctx context.Context, id string, withMembers bool) (*scim.Group, error) {
+	t, err := s.loadTeam(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	out := s.toSCIMGroup(t, withMembers)
+	return &out, nil
+}
+
+func (s *SCIMService) CreateGroup(ctx context.Context, in scim.Group, meta dto.AuditMeta) (*scim.Group, error) {
+	if strings.TrimSpace(in.DisplayName) == "" {
+		return nil, scim.BadRequest("invalidValue", "displayName is required")
+	}
+	deptID := s.cfg.DefaultDepartmentID
+	if in.Roadmap != nil && in.Roadmap.DepartmentID != "" {
+		id, err := uuid.Parse(in.Roadmap.DepartmentID)
+		if err != nil {
+			return nil, scim.BadRequest("invalidValue", "departmentId must be a department id")
+		}
+		deptID = &id
+	}
+	if deptID == nil {
+		return nil, scim.BadRequest("invalidValue", "departmentId is required (set SCIM_DEFAULT_DEPARTMENT_ID to provide a default)")
+	}
+	members, err := scimIDs(in.Members)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkGroupExternalID(ctx, uuid.Nil, in.ExternalID); err != nil {
+		return nil, err
+	}
+	t := &models.Team{DepartmentID: *deptID, Name: in.DisplayName}
+	if in.ExternalID != "" {
+		ext := in.ExternalID
+		t.ExternalID = &ext
+	}
+	err = s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.teamRepo.Create(ctx, t); err != nil {
+			return err
+		}
+		if err := s.syncMembers(ctx, t.ID, members); err != nil {
+			return err
+		}
+		return s.audit(ctx, "create", "team", t.ID.String(), nil, scimTeamAudit(t, members), meta)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetGroup(ctx, t.ID.String(), true)
+}
+
+func (s *SCIMService) ReplaceGroup(ctx context.Context, id string, in scim.Group, meta dto.AuditMeta) (*scim.Group, error) {
+	t, err := s.loadTeam(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(in.DisplayName) == "" {
+		return nil, scim.BadRequest("invalidValue", "displayName is required")
+	}
+	members, err := scimIDs(in.Members)
+	if err != nil {
+		return nil, err
+	}
+	var ext *string
+	if in.ExternalID != "" {
+		ext = &in.ExternalID
+	}
+	return s.applyGroup(ctx, t, in.DisplayName, ext, members, meta)
+}
+
+func (s *SCIMService) PatchGroup(ctx context.Context, id string, req scim.PatchRequest, meta dto.AuditMeta) (*scim.Group, error) {
+	t, err := s.loadTeam(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	name, ext := t.Name, t.ExternalID
+	members := make([]uuid.UUID, len(t.Members))
+	for i := range t.Members {
+		members[i] = t.Members[i].ID
+	}
+	for _, op := range req.Operations {
+		kind := strings.ToLower(op.Op)
+		if kind != "add" && kind != "replace" && kind != "remove" {
+			return nil, scim.BadRequest("invalidSyntax", "unsupported op %q", op.Op)
+		}
+		paths := map[string]json.RawMessage{op.Path: op.Value}
+		if op.Path == "" {
+			if kind == "remove" {
+				return nil, scim.BadRequest("noTarget", "remove requires a path")
+			}
+			if err := json.Unmarshal(op.Value, &paths); err != nil {
+				return nil, scim.BadRequest("invalidValue", "value must be an object when path is omitted")
+			}
+		}
+		for raw, value := range paths {
+			p, err := scim.ParsePath(raw)
+			if err != nil {
+				return nil, err
+			}
+			switch p.Attr {
+			case "displayname":
+				if kind == "remove" {
+					return nil, scim.BadRequest("mutability", "displayName cannot be removed")
+				}
+				if err := scimString(value, &name); err != nil {
+					return nil, err
+				}
+			case "externalid":
+				if kind == "remove" {
+					ext = nil
+					continue
+				}
+				var v string
+				if err := scimString(value, &v); err != nil {
+					return nil, err
+				}
+				ext = &v
+			case "members":
+				if members, err = patchMembers(kind, p, value, members); err != nil {
+					return nil, err
+				}
+			default:
+				return nil, scim.BadRequest("invalidPath", "unsupported path %q", raw)
+			}
+		}
+	}
+	return s.applyGroup(ctx, t, name, ext, members, meta)
+}
+
+func patchMembers(kind string, p scim.Path, value json.RawMessage, members []uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	if len(value) > 0 && string(value) != "null" {
+		var list []scim.MultiValue
+		if err := json.Unmarshal(value, &list); err != nil {
+			return nil, scim.BadRequest("invalidValue", "members must be a list")
+		}
+		var err error
+		if ids, err = scimIDs(list); err != nil {
+			return nil, err
+		}
+	}
+	switch kind {
+	case "replace":
+		return ids, nil
+	case "add":
+		return appendMissing(members, ids...), nil
+	}
+	if p.Filter == nil && len(ids) == 0 {
+		return nil, nil
+	}
+	kept := members[:0:0]
+	for _, m := range members {
+		drop := containsUUID(ids, m)
+		if p.Filter != nil && scim.Match(p.Filter, func(a string) (string, bool) {
+			if a == "value" {
+				return m.String(), true
+			}
+			return "", false
+		}) {
+			drop = true
+		}
+		if !drop {
+			kept = append(kept, m)
+		}
+	}
+	return kept, nil
+}
+
+func (s *SCIMService) applyGroup(ctx context.Context, t *models.Team, name string, ext *string, members []uuid.UUID, meta dto.AuditMeta) (*scim.Group, error) {
+	oldMembers := make([]uuid.UUID, len(t.Members))
+	for i := range t.Members {
+		oldMembers[i] = t.Members[i].ID
+	}
+	old := scimTeamAudit(t, oldMembers)
+	if ext != nil {
+		if err := s.checkGroupExternalID(ctx, t.ID, *ext); err != nil {
+			return nil, err
+		}
+	}
+	t.Name = name
+	t.ExternalID = ext
+	t.Members = nil
+	t.Manager = nil
+	t.Department = nil
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.teamRepo.Update(ctx, t); err != nil {
+			return err
+		}
+		if err := s.syncMembers(ctx, t.ID, members); err != nil {
+			return err
+		}
+		return s.audit(ctx, "update", "team", t.ID.String(), old, scimTeamAudit(t, members), meta)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetGroup(ctx, t.ID.String(), true)
+}
+
+// DeleteGroup deletes the team; its members are left without a team.
+func (s *SCIMService) DeleteGroup(ctx context.Context, id string, meta dto.AuditMeta) error {
+	t, err := s.loadTeam(ctx, id)
+	if err != nil {
+		return err
+	}
+	members := make([]uuid.UUID, len(t.Members))
+	for i := range t.Members {
+		members[i] = t.Members[i].ID
+	}
+	return s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.syncMembers(ctx, t.ID, nil); err != nil {
+			return err
+		}
+		if err := s.teamRepo.Delete(ctx, t.ID); err != nil {
+			return err
+		}
+		return s.audit(ctx, "delete", "team", t.ID.String(), scimTeamAudit(t, members), nil, meta)
+	})
+}
+
+// syncMembers makes exactly want the members of teamID.
+func (s *SCIMService) syncMembers(ctx context.Context, teamID uuid.UUID, want []uuid.UUID) error {
+	for _, id := range want {
+		if _, err := s.userRepo.GetByID(id); err != nil {
+			return scim.BadRequest("invalidValue", "member %s not found", id)
+		}
+	}
+	have, err := s.userRepo.ListIDsByTeam(ctx, teamID)
+	if err != nil {
+		return err
+	}
+	var leave []uuid.UUID
+	for _, id := range have {
+		if !containsUUID(want, id) {
+			leave = append(leave, id)
+		}
+	}
+	if err := s.userRepo.SetTeam(ctx, leave, nil); err != nil {
+		return err
+	}
+	return s.userRepo.SetTeam(ctx, want, &teamID)
+}
+
+func (s *SCIMService) checkGroupExternalID(ctx context.Context, teamID uuid.UUID, ext string) error {
+	if ext == "" {
+		return nil
+	}
+	teams, _, err := s.teamRepo.Search(ctx, "external_id = ?", []interface{}{ext}, 0, 1)
+	if err != nil {
+		return err
+	}
+	if len(teams) > 0 && teams[0].ID != teamID {
+		return scim.Conflict("externalId %s already exists", ext)
+	}
+	return nil
+}
+
+func (s *SCIMService) loadTeam(ctx context.Context, id string) (*models.Team, error) {
+	tid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, scim.NotFound("Group", id)
+	}
+	teams, _, err := s.teamRepo.Search(ctx, "id = ?", []interface{}{tid}, 0, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(teams) == 0 {
+		return nil, scim.NotFound("Group", id)
+	}
+	return &teams[0], nil
+}
+
+func (s *SCIMService) toSCIMGroup(t *models.Team, withMembers bool) scim.Group {
+	out := scim.Group{
+		Schemas:     []string{scim.SchemaGroup, scim.SchemaRoadmapGroup},
+		ID:          t.ID.String(),
+		DisplayName: t.Name,
+		Roadmap:     &scim.RoadmapGroup{DepartmentID: t.DepartmentID.String()},
+		Meta:        s.meta("Group", "Groups", t.ID, t.CreatedAt, t.UpdatedAt),
+	}
+	if t.ExternalID != nil {
+		out.ExternalID = *t.ExternalID
+	}
+	if withMembers {
+		out.Members = make([]scim.MultiValue, 0, len(t.Members))
+		for i := range t.Members {
+			out.Members = append(out.Members, scim.MultiValue{Value: t.Members[i].ID.String(), Display: t.Members[i].Name, Ref: s.location("Users", t.Members[i].ID)})
+		}
+	}
+	return out
+}
+
+func scimTeamAudit(t *models.Team, members []uuid.UUID) models.JSONB {
+	ids := make([]string, len(members))
+	for i, m := range members {
+		ids[i] = m.String()
+	}
+	data := models.JSONB{"name": t.Name, "department_id": t.DepartmentID.String(), "members": ids}
+	if t.ExternalID != nil {
+		data["external_id"] = *t.ExternalID
+	}
+	return data
+}
+
+// ---- helpers ----
+
+func (s *SCIMService) meta(resourceType, collection string, id uuid.UUID, created, modified time.Time) *scim.Meta {
+	return &scim.Meta{
+		ResourceType: resourceType,
+		Created:      created.Format(time.RFC3339),
+		LastModified: modified.Format(time.RFC3339),
+		Location:     s.location(collection, id),
+	}
+}
+
+func (s *SCIMService) location(collection string, id uuid.UUID) string {
+	return s.cfg.BaseURL + "/" + collection + "/" + id.String()
+}
+
+// audit records a SCIM change in the transaction making it. The actor is the IdP, so UserID is nil and
+// metadata marks the source.
+func (s *SCIMService) audit(ctx context.Context, action, entityType, entityID string, oldData, newData models.JSONB, meta dto.AuditMeta) error {
+	return s.auditSvc.Record(ctx, meta, action, entityType, entityID, oldData, newData, models.JSONB{"source": "scim"})
+}
+
+func scimIDs(list []scim.MultiValue) ([]uuid.UUID, error) {
+	ids := make([]uuid.UUID, 0, len(list))
+	for _, m := range list {
+		id, err := uuid.Parse(m.Value)
+		if err != nil {
+			return nil, scim.BadRequest("invalidValue", "%q is not a valid id", m.Value)
+		}
+		ids = appendMissing(ids, id)
+	}
+	return ids, nil
+}
+
+// scimRefID reads a reference given either as "id" or as {"value": "id"}; empty means none.
+func scimRefID(value json.RawMessage) (*uuid.UUID, error) {
+	var v string
+	if err := json.Unmarshal(value, &v); err != nil {
+		var obj scim.Manager
+		if err := json.Unmarshal(value, &obj); err != nil {
+			return nil, scim.BadRequest("invalidValue", "expected an id or {\"value\": id}")
+		}
+		v = obj.Value
+	}
+	if v == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(v)
+	if err != nil {
+		return nil, scim.BadRequest("invalidValue", "%q is not a valid id", v)
+	}
+	return &id, nil
+}
+
+func scimString(value json.RawMessage, dst *string) error {
+	if err := json.Unmarshal(value, dst); err != nil {
+		return scim.BadRequest("invalidValue", "expected a string")
+	}
+	return nil
+}
+
+// scimBool accepts JSON booleans and the "True"/"False" strings some IdPs send.
+func scimBool(value json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+	var str string
+	if err := json.Unmarshal(value, &str); err == nil {
+		if b, err := strconv.ParseBool(str); err == nil {
+			return b, nil
+		}
+	}
+	return false, scim.BadRequest("invalidValue", "expected a boolean")
+}
+
+func containsUUID(list []uuid.UUID, id uuid.UUID) bool {
+	for _, x := range list {
+		if x == id {
+			return true
+		}
+	}
+	return false
+}
+
+func appendMissing(list []uuid.UUID, ids ...uuid.UUID) []uuid.UUID {
+	for _, id := range ids {
+		if !containsUUID(list, id) {
+			list = append(list, id)
+		}
+	}
+	return list
+}
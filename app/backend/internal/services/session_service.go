@@ -0,0 +1,353 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/auth"
+	"github.com/rm/roadmap/backend/internal/dto"
+	"github.com/rm/roadmap/backend/internal/models"
+	"github.com/rm/roadmap/backend/internal/realtime"
+	"github.com/rm/roadmap/backend/internal/repositories"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	ErrSessionRevoked  = errors.New("session revoked or expired")
+)
+
+const (
+	// sessionCacheTTL bounds how long a revocation can go unnoticed by another server instance. Revocations
+	// are also broadcast so other instances drop the session at once; the TTL covers a lost broadcast.
+	sessionCacheTTL = 10 * time.Second
+	// sessionTouchInterval limits last_seen_at writes to one per session per interval.
+	sessionTouchInterval = time.Minute
+)
+
+type cachedSession struct {
+	userID    uuid.UUID
+	expiresAt time.Time
+	revoked   bool
+	lastSeen  time.Time
+	checkedAt time.Time
+}
+
+// SessionService tracks logins server-side so tokens can be listed and revoked before they expire.
+type SessionService struct {
+	repo     repositories.SessionRepository
+	tx       repositories.Transactor
+	auditSvc *AuditService
+	hub      *realtime.Hub
+	log      *zap.Logger
+
+	mu    sync.Mutex
+	cache map[uuid.UUID]cachedSession
+}
+
+func NewSessionService(repo repositories.SessionRepository, tx repositories.Transactor, auditSvc *AuditService, hub *realtime.Hub, log *zap.Logger) *SessionService {
+	if log == nil {
+		log = zap.NewNop()
+	}
+	return &SessionService{repo: repo, tx: tx, auditSvc: auditSvc, hub: hub, log: log, cache: make(map[uuid.UUID]cachedSession)}
+}
+
+// Run drops sessions revoked on any instance from the cache until ctx is done. When broadcasts may have
+// been missed the whole cache is dropped. Without a hub it returns at once and the TTL alone applies.
+func (s *SessionService) Run(ctx context.Context) {
+	if s.hub == nil {
+		return
+	}
+	for ctx.Err() == nil {
+		sub := s.hub.Subscribe(func(ev realtime.Event) bool { return ev.Type == realtime.EventSessionRevoked })
+		s.consume(ctx, sub)
+		s.hub.Unsubscribe(sub)
+	}
+}
+
+// consume handles revocation events until ctx is done or the hub closes the subscription for falling behind.
+func (s *SessionService) consume(ctx context.Context, sub *realtime.Subscription) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-sub.C:
+			if !ok {
+				s.forgetAll()
+				return
+			}
+			var ids []uuid.UUID
+			if ev.Type != realtime.EventSessionRevoked || json.Unmarshal(ev.Data, &ids) != nil || len(ids) == 0 {
+				// A resync, or a payload dropped for size.
+				s.forgetAll()
+				continue
+			}
+			for _, id := range ids {
+				s.forget(id)
+			}
+		}
+	}
+}
+
+// Create records a new session. The ID must be the sid already embedded in the issued tokens.
+func (s *SessionService) Create(ctx context.Context, id, userID uuid.UUID, accessJTI, refreshJTI, ip, userAgent string, expiresAt time.Time) error {
+	now := time.Now()
+	return s.repo.Create(ctx, &models.Session{
+		ID:         id,
+		UserID:     userID,
+		AccessJTI:  accessJTI,
+		RefreshJTI: refreshJTI,
+		IPAddress:  ip,
+		UserAgent:  userAgent,
+		Device:     deviceFromUserAgent(userAgent),
+		LastSeenAt: now,
+		ExpiresAt:  expiresAt,
+	})
+}
+
+// Validate returns nil when the session exists, belongs to userID and is neither revoked nor expired.
+// Results are cached briefly; last_seen_at is refreshed at most once per sessionTouchInterval.
+func (s *SessionService) Validate(ctx context.Context, sessionID, userID uuid.UUID) error {
+	now := time.Now()
+	s.mu.Lock()
+	c, ok := s.cache[sessionID]
+	s.mu.Unlock()
+	if !ok || now.Sub(c.checkedAt) > sessionCacheTTL {
+		sess, err := s.repo.GetByID(ctx, sessionID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrSessionRevoked
+			}
+			return err
+		}
+		c = cachedSession{
+			userID:    sess.UserID,
+			expiresAt: sess.ExpiresAt,
+			revoked:   sess.RevokedAt != nil,
+			lastSeen:  sess.LastSeenAt,
+			checkedAt: now,
+		}
+		s.mu.Lock()
+		s.pruneLocked(now)
+		s.cache[sessionID] = c
+		s.mu.Unlock()
+	}
+	if c.revoked || c.userID != userID || !now.Before(c.expiresAt) {
+		return ErrSessionRevoked
+	}
+	if now.Sub(c.lastSeen) > sessionTouchInterval {
+		s.mu.Lock()
+		if cur, ok := s.cache[sessionID]; ok {
+			cur.lastSeen = now
+			s.cache[sessionID] = cur
+		}
+		s.mu.Unlock()
+		if err := s.repo.Touch(ctx, sessionID, now); err != nil {
+			s.log.Warn("session touch failed", zap.String("session_id", sessionID.String()), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// Rotate records the jtis of a refreshed token pair. presentedRefreshJTI must be the session's current
+// refresh token; presenting an older one means the token was replayed, so the whole session is revoked.
+func (s *SessionService) Rotate(ctx context.Context, sessionID, userID uuid.UUID, presentedRefreshJTI, accessJTI, refreshJTI string, expiresAt time.Time) error {
+	now := time.Now()
+	sess, err := s.repo.GetByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSessionRevoked
+		}
+		return err
+	}
+	if sess.UserID != userID || sess.RevokedAt != nil || !now.Before(sess.ExpiresAt) {
+		return ErrSessionRevoked
+	}
+	if presentedRefreshJTI == sess.AccessJTI {
+		// An access token is not a refresh token; reject it without treating it as replay.
+		return auth.ErrInvalidToken
+	}
+	ok, err := s.repo.Rotate(ctx, sessionID, presentedRefreshJTI, accessJTI, refreshJTI, expiresAt, now)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		s.log.Warn("refresh token reuse detected; revoking session",
+			zap.String("session_id", sessionID.String()), zap.String("user_id", userID.String()))
+		err := s.tx.InTx(ctx, func(ctx context.Context) error {
+			if _, err := s.repo.Revoke(ctx, sessionID, now); err != nil {
+				return err
+			}
+			s.revoked(ctx, sessionID)
+			return s.audit(ctx, sess, "refresh_token_reuse", dto.AuditMeta{UserID: &userID})
+		})
+		if err != nil {
+			return err
+		}
+		return ErrSessionRevoked
+	}
+	s.forget(sessionID)
+	return nil
+}
+
+// List returns the user's active sessions; currentID marks the caller's own session.
+func (s *SessionService) List(ctx context.Context, userID uuid.UUID, currentID uuid.UUID) ([]dto.SessionResponse, error) {
+	list, err := s.repo.ListActiveByUser(ctx, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dto.SessionResponse, len(list))
+	for i := range list {
+		out[i] = sessionToResponse(&list[i])
+		out[i].Current = list[i].ID == currentID
+	}
+	return out, nil
+}
+
+// Revoke ends one session of userID. Sessions of other users are reported as not found.
+func (s *SessionService) Revoke(ctx context.Context, userID, sessionID uuid.UUID, meta dto.AuditMeta) error {
+	sess, err := s.repo.GetByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+	if sess.UserID != userID {
+		return ErrSessionNotFound
+	}
+	err = s.tx.InTx(ctx, func(ctx context.Context) error {
+		ok, err := s.repo.Revoke(ctx, sessionID, time.Now())
+		if err != nil || !ok {
+			return err
+		}
+		s.revoked(ctx, sessionID)
+		return s.audit(ctx, sess, "", meta)
+	})
+	if err != nil {
+		return err
+	}
+	s.forget(sessionID)
+	return nil
+}
+
+// RevokeAllForUser ends every active session of userID except the listed ones and returns how many were revoked.
+func (s *SessionService) RevokeAllForUser(ctx context.Context, userID uuid.UUID, except []uuid.UUID, meta dto.AuditMeta) (int, error) {
+	var ids []uuid.UUID
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		ids, err = s.repo.RevokeAllForUser(ctx, userID, except, time.Now())
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		s.revoked(ctx, ids...)
+		revoked := make([]string, len(ids))
+		for i, id := range ids {
+			revoked[i] = id.String()
+		}
+		return s.auditSvc.Record(ctx, meta, "revoke_all", "user", userID.String(), nil, nil, models.JSONB{"session_ids": revoked})
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+func (s *SessionService) audit(ctx context.Context, sess *models.Session, reason string, meta dto.AuditMeta) error {
+	var md models.JSONB
+	if reason != "" {
+		md = models.JSONB{"reason": reason}
+	}
+	return s.auditSvc.Record(ctx, meta, "revoke", "session", sess.ID.String(),
+		models.JSONB{"user_id": sess.UserID.String(), "ip_address": sess.IPAddress, "device": sess.Device}, nil, md)
+}
+
+// revoked drops sessions revoked in ctx's transaction from the cache of every instance once it commits.
+func (s *SessionService) revoked(ctx context.Context, ids ...uuid.UUID) {
+	data, _ := json.Marshal(ids)
+	repositories.AfterCommit(ctx, func() {
+		for _, id := range ids {
+			s.forget(id)
+		}
+		s.hub.Publish(context.WithoutCancel(ctx), realtime.Event{Type: realtime.EventSessionRevoked, Data: data})
+	})
+}
+
+func (s *SessionService) forget(id uuid.UUID) {
+	s.mu.Lock()
+	delete(s.cache, id)
+	s.mu.Unlock()
+}
+
+func (s *SessionService) forgetAll() {
+	s.mu.Lock()
+	clear(s.cache)
+	s.mu.Unlock()
+}
+
+// pruneLocked drops stale cache entries so the map does not grow with every session ever seen.
+func (s *SessionService) pruneLocked(now time.Time) {
+	for id, c := range s.cache {
+		if now.Sub(c.checkedAt) > sessionCacheTTL {
+			delete(s.cache, id)
+		}
+	}
+}
+
+func sessionToResponse(s *models.Session) dto.SessionResponse {
+	return dto.SessionResponse{
+		ID:         s.ID.String(),
+		UserID:     s.UserID.String(),
+		IPAddress:  s.IPAddress,
+		UserAgent:  s.UserAgent,
+		Device:     s.Device,
+		CreatedAt:  s.CreatedAt.Format(time.RFC3339),
+		LastSeenAt: s.LastSeenAt.Format(time.RFC3339),
+		ExpiresAt:  s.ExpiresAt.Format(time.RFC3339),
+	}
+}
+
+// deviceFromUserAgent returns a short "Browser on OS" label; it is a display hint, not a fingerprint.
+func deviceFromUserAgent(ua string) string {
+	if ua == "" {
+		return "Unknown"
+	}
+	browser := "Unknown browser"
+	switch {
+	case strings.Contains(ua, "Edg/"):
+		browser = "Edge"
+	case strings.Contains(ua, "OPR/"), strings.Contains(ua, "Opera"):
+		browser = "Opera"
+	case strings.Contains(ua, "Firefox/"):
+		browser = "Firefox"
+	case strings.Contains(ua, "Chrome/"), strings.Contains(ua, "CriOS/"):
+		browser = "Chrome"
+	case strings.Contains(ua, "Safari/"):
+		browser = "Safari"
+	case strings.HasPrefix(ua, "curl/"):
+		return "curl"
+	}
+	os := ""
+	switch {
+	case strings.Contains(ua, "Android"):
+		os = "Android"
+	case strings.Contains(ua, "iPhone"), strings.Contains(ua, "iPad"):
+		os = "iOS"
+	case strings.Contains(ua, "Windows"):
+		os = "Windows"
+	case strings.Contains(ua, "Mac OS X"), strings.Contains(ua, "Macintosh"):
+		os = "macOS"
+	case strings.Contains(ua, "Linux"):
+		os = "Linux"
+	}
+	if os == "" {
+		return browser
+	}
+	return browser + " on " + os
+}
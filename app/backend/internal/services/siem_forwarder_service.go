@@ -0,0 +1,276 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rm/roadmap/backend/internal/models"
+	"github.com/rm/roadmap/backend/internal/repositories"
+	"github.com/rm/roadmap/backend/internal/siem"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	siemStreamAudit    = "audit"
+	siemStreamActivity = "activity"
+)
+
+var (
+	siemForwardedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "siem_forwarded_total",
+			Help: "Total number of records forwarded to the SIEM, by stream",
+		},
+		[]string{"stream"},
+	)
+	siemForwardErrorsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "siem_forward_errors_total",
+			Help: "Total number of failed SIEM forwarding attempts, by stream",
+		},
+		[]string{"stream"},
+	)
+	siemLastForward = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "siem_forward_last_success_timestamp_seconds",
+			Help: "Unix time the stream was last caught up with the SIEM",
+		},
+		[]string{"stream"},
+	)
+)
+
+type SIEMForwarderConfig struct {
+	Target    string        // tcp://, udp:// or tls://host:port
+	Format    string        // siem.FormatSyslog or siem.FormatCEF
+	Streams   []string      // "audit", "activity"
+	Interval  time.Duration // time between polls
+	BatchSize int           // records sent per checkpoint update
+	Timeout   time.Duration // dial and write timeout
+}
+
+// SIEMForwarder ships new audit and activity records to a SIEM as syslog or CEF. Progress is checkpointed per
+// stream in forwarder_checkpoints after each delivered batch, so a restart resumes where it left off;
+// delivery is at least once. The checkpoint row is locked while a batch is sent, so with several instances
+// only one forwards a stream at a time. A new checkpoint starts at the current end of the stream; use the
+// export endpoints for history.
+type SIEMForwarder struct {
+	checkpoints  repositories.ForwarderCheckpointRepository
+	auditRepo    repositories.AuditRepository
+	activityRepo repositories.ActivityRepository
+	tx           repositories.Transactor
+	sender       *siem.Sender
+	format       siem.Formatter
+	cfg          SIEMForwarderConfig
+	log          *zap.Logger
+}
+
+func NewSIEMForwarder(checkpoints repositories.ForwarderCheckpointRepository, auditRepo repositories.AuditRepository, activityRepo repositories.ActivityRepository, tx repositories.Transactor, cfg SIEMForwarderConfig, log *zap.Logger) (*SIEMForwarder, error) {
+	sender, err := siem.NewSender(cfg.Target, cfg.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	hostname, _ := os.Hostname()
+	format, err := siem.NewFormatter(cfg.Format, hostname)
+	if err != nil {
+		return nil, err
+	}
+	for _, st := range cfg.Streams {
+		if st != siemStreamAudit && st != siemStreamActivity {
+			return nil, fmt.Errorf("siem: unknown stream %q", st)
+		}
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 200
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 5 * time.Second
+	}
+	if log == nil {
+		log = zap.NewNop()
+	}
+	return &SIEMForwarder{checkpoints: checkpoints, auditRepo: auditRepo, activityRepo: activityRepo, tx: tx, sender: sender, format: format, cfg: cfg, log: log}, nil
+}
+
+// ParseSIEMStreams splits a comma-separated stream list such as "audit,activity".
+func ParseSIEMStreams(s string) []string {
+	var out []string
+	for _, st := range strings.Split(s, ",") {
+		if st = strings.TrimSpace(st); st != "" {
+			out = append(out, st)
+		}
+	}
+	return out
+}
+
+// Run forwards until ctx is done.
+func (f *SIEMForwarder) Run(ctx context.Context) {
+	defer f.sender.Close()
+	if err := f.initCheckpoints(ctx); err != nil {
+		f.log.Error("siem forwarder: checkpoint init failed", zap.Error(err))
+		return
+	}
+	ticker := time.NewTicker(f.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		for _, st := range f.cfg.Streams {
+			if err := f.catchUp(ctx, st); err != nil && ctx.Err() == nil {
+				siemForwardErrorsTotal.WithLabelValues(st).Inc()
+				f.log.Warn("siem forwarder: delivery failed, retrying", zap.String("stream", st), zap.Error(err))
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func checkpointName(stream string) string { return "siem:" + stream }
+
+// initCheckpoints creates missing checkpoints at the current end of their streams.
+func (f *SIEMForwarder) initCheckpoints(ctx context.Context) error {
+	for _, st := range f.cfg.Streams {
+		cp := &models.ForwarderCheckpoint{Stream: checkpointName(st)}
+		switch st {
+		case siemStreamAudit:
+			head, err := f.auditRepo.ChainHead(ctx)
+			if err != nil {
+				return err
+			}
+			if head != nil {
+				cp.AfterSeq = head.Seq
+			}
+		case siemStreamActivity:
+			last, err := f.activityRepo.LastSeq(ctx)
+			if err != nil {
+				return err
+			}
+			cp.AfterSeq = last
+		}
+		if err := f.checkpoints.Init(ctx, cp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// catchUp forwards batches of a stream until it is drained.
+func (f *SIEMForwarder) catchUp(ctx context.Context, stream string) error {
+	for ctx.Err() == nil {
+		n, err := f.forwardBatch(ctx, stream)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			siemForwardedTotal.WithLabelValues(stream).Add(float64(n))
+		}
+		if n < f.cfg.BatchSize {
+			siemLastForward.WithLabelValues(stream).Set(float64(time.Now().Unix()))
+			return nil
+		}
+	}
+	return ctx.Err()
+}
+
+// forwardBatch sends the next batch of a stream and advances its checkpoint, all while holding the checkpoint
+// lock. It returns 0 if another instance holds the lock.
+func (f *SIEMForwarder) forwardBatch(ctx context.Context, stream string) (int, error) {
+	var n int
+	err := f.tx.InTx(ctx, func(ctx context.Context) error {
+		cp, err := f.checkpoints.Lock(ctx, checkpointName(stream))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		var events []siem.Event
+		if stream == siemStreamAudit {
+			events, err = f.auditEvents(ctx, cp)
+		} else {
+			events, err = f.activityEvents(ctx, cp)
+		}
+		if err != nil || len(events) == 0 {
+			return err
+		}
+		msgs := make([][]byte, len(events))
+		for i := range events {
+			msgs[i] = f.format(&events[i])
+		}
+		if err := f.sender.Send(msgs); err != nil {
+			return err
+		}
+		n = len(events)
+		return f.checkpoints.Save(ctx, cp)
+	})
+	return n, err
+}
+
+// auditEvents reads the audit logs after the checkpoint in chain order and moves cp past them.
+func (f *SIEMForwarder) auditEvents(ctx context.Context, cp *models.ForwarderCheckpoint) ([]siem.Event, error) {
+	rows, err := f.auditRepo.ChainPage(ctx, cp.AfterSeq, f.cfg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]siem.Event, len(rows))
+	for i := range rows {
+		a := &rows[i]
+		body, _ := json.Marshal(auditLogToResponse(a))
+		events[i] = siem.Event{
+			Stream:     siemStreamAudit,
+			ID:         a.ID.String(),
+			Seq:        *a.Seq,
+			Time:       a.Timestamp,
+			Action:     a.Action,
+			EntityType: a.EntityType,
+			EntityID:   a.EntityID,
+			IPAddress:  a.IPAddress,
+			UserAgent:  a.UserAgent,
+			TraceID:    a.TraceID,
+			JSON:       body,
+		}
+		if a.UserID != nil {
+			events[i].UserID = a.UserID.String()
+		}
+		cp.AfterSeq = *a.Seq
+	}
+	return events, nil
+}
+
+// activityEvents reads the activity logs after the checkpoint in seq order and moves cp past them.
+func (f *SIEMForwarder) activityEvents(ctx context.Context, cp *models.ForwarderCheckpoint) ([]siem.Event, error) {
+	rows, err := f.activityRepo.SeqPage(ctx, cp.AfterSeq, f.cfg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]siem.Event, len(rows))
+	for i := range rows {
+		a := &rows[i]
+		body, _ := json.Marshal(activityLogToResponse(a))
+		events[i] = siem.Event{
+			Stream:     siemStreamActivity,
+			ID:         a.ID.String(),
+			Seq:        *a.Seq,
+			Time:       a.Timestamp,
+			Action:     a.Action,
+			EntityType: a.EntityType,
+			EntityID:   a.EntityID,
+			IPAddress:  a.IPAddress,
+			UserAgent:  a.UserAgent,
+			JSON:       body,
+		}
+		if a.UserID != nil {
+			events[i].UserID = a.UserID.String()
+		}
+		cp.AfterSeq = *a.Seq
+	}
+	return events, nil
+}
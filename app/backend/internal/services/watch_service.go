@@ -0,0 +1,297 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/authz"
+	"github.com/rm/roadmap/backend/internal/dto"
+	"github.com/rm/roadmap/backend/internal/i18n"
+	"github.com/rm/roadmap/backend/internal/models"
+	"github.com/rm/roadmap/backend/internal/repositories"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrWatchInvalid        = errors.New("entity_type must be product, product_version or group, and entity_id a UUID")
+	ErrWatchEntityNotFound = errors.New("entity to watch not found")
+	ErrWatchNotFound       = errors.New("not watching this entity")
+)
+
+// WatchService lets users follow products, versions and groups, and notifies the watchers of a product
+// (directly, through the version concerned or through a group it is in) about milestone, version,
+// lifecycle and dependency readiness changes. The acting user is not notified of their own change.
+// The notify methods run after the change is committed and do nothing on a nil *WatchService.
+type WatchService struct {
+	repo            repositories.WatchRepository
+	productRepo     repositories.ProductRepository
+	versionRepo     repositories.ProductVersionRepository
+	groupRepo       repositories.GroupRepository
+	milestoneRepo   repositories.MilestoneRepository
+	versionDepRepo  repositories.ProductVersionDependencyRepository
+	notificationSvc *NotificationService
+	policy          *authz.Engine
+	log             *zap.Logger
+}
+
+func NewWatchService(repo repositories.WatchRepository, productRepo repositories.ProductRepository, versionRepo repositories.ProductVersionRepository, groupRepo repositories.GroupRepository, milestoneRepo repositories.MilestoneRepository, versionDepRepo repositories.ProductVersionDependencyRepository, notificationSvc *NotificationService, policy *authz.Engine, log *zap.Logger) *WatchService {
+	if log == nil {
+		log = zap.NewNop()
+	}
+	return &WatchService{repo: repo, productRepo: productRepo, versionRepo: versionRepo, groupRepo: groupRepo, milestoneRepo: milestoneRepo, versionDepRepo: versionDepRepo, notificationSvc: notificationSvc, policy: policy, log: log}
+}
+
+// Watch subscribes the caller to an entity; created is false when they already watched it. Groups need
+// group:read on the group, like viewing it.
+func (s *WatchService) Watch(ctx context.Context, req dto.WatchRequest, callerID uuid.UUID, callerRole models.Role) (resp *dto.WatchResponse, created bool, err error) {
+	id, err := uuid.Parse(req.EntityID)
+	if err != nil {
+		return nil, false, ErrWatchInvalid
+	}
+	w := &models.Watch{UserID: callerID, EntityType: req.EntityType, EntityID: id, CreatedAt: time.Now()}
+	resp, err = s.describe(ctx, w)
+	if err != nil {
+		return nil, false, err
+	}
+	if resp.Name == "" {
+		return nil, false, ErrWatchEntityNotFound
+	}
+	if w.EntityType == models.WatchEntityGroup {
+		g, err := s.groupRepo.GetByID(ctx, id)
+		if err != nil {
+			return nil, false, ErrWatchEntityNotFound
+		}
+		if err := s.policy.Authorize(ctx, authz.Subject{UserID: callerID, Role: callerRole}, authz.PermGroupRead, groupResource(g)); err != nil {
+			return nil, false, err
+		}
+	}
+	created, err = s.repo.Create(ctx, w)
+	if err != nil {
+		return nil, false, err
+	}
+	return resp, created, nil
+}
+
+// Unwatch removes the caller's watch.
+func (s *WatchService) Unwatch(ctx context.Context, entityType string, entityID, callerID uuid.UUID) error {
+	ok, err := s.repo.Delete(ctx, callerID, entityType, entityID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrWatchNotFound
+	}
+	return nil
+}
+
+// List returns what the caller watches, newest first. Watches of deleted entities are listed without a name
+// so they can be removed.
+func (s *WatchService) List(ctx context.Context, callerID uuid.UUID) ([]dto.WatchResponse, error) {
+	list, err := s.repo.ListForUser(ctx, callerID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dto.WatchResponse, 0, len(list))
+	for i := range list {
+		resp, err := s.describe(ctx, &list[i])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *resp)
+	}
+	return out, nil
+}
+
+// describe builds the response for w, with an empty name when its entity does not exist.
+func (s *WatchService) describe(ctx context.Context, w *models.Watch) (*dto.WatchResponse, error) {
+	resp := &dto.WatchResponse{EntityType: w.EntityType, EntityID: w.EntityID.String(), CreatedAt: w.CreatedAt.Format(time.RFC3339)}
+	switch w.EntityType {
+	case models.WatchEntityProduct:
+		p, err := s.productRepo.GetByID(ctx, w.EntityID)
+		if err == nil {
+			resp.Name = p.Name
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	case models.WatchEntityProductVersion:
+		pv, err := s.versionRepo.GetByID(w.EntityID)
+		if err == nil {
+			productID := pv.ProductID.String()
+			resp.ProductID = &productID
+			if p, err := s.productRepo.GetByID(ctx, pv.ProductID); err == nil {
+				resp.Name = p.Name + " " + pv.Version
+			} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, err
+			}
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	case models.WatchEntityGroup:
+		if g, err := s.groupRepo.GetByID(ctx, w.EntityID); err == nil {
+			resp.Name = g.Name
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	default:
+		return nil, ErrWatchInvalid
+	}
+	return resp, nil
+}
+
+// MilestoneChanged notifies the watchers of m's product and version that it was created, updated or
+// deleted, and the watchers of dependent versions whose readiness changed since readinessBefore
+// (from DependencyReadiness before the change).
+func (s *WatchService) MilestoneChanged(ctx context.Context, action string, m *models.Milestone, readinessBefore map[uuid.UUID]bool, actorID *uuid.UUID) {
+	if s == nil {
+		return
+	}
+	p, err := s.productRepo.GetByID(ctx, m.ProductID)
+	if err != nil {
+		s.log.Warn("watch: product lookup failed", zap.Error(err), zap.String("product_id", m.ProductID.String()))
+		return
+	}
+	title := i18n.M("notification.milestone_changed.title", "milestone", m.Label).Term("action", actionTerm(action))
+	message := i18n.M("notification.milestone_changed.message", "milestone", m.Label, "product", p.Name).Term("action", actionTerm(action))
+	if action != "delete" {
+		if m.EndDate != nil && !m.EndDate.Equal(m.StartDate) {
+			message = message.Append(i18n.M("notification.milestone_changed.range", "start", m.StartDate.Format("2006-01-02"), "end", m.EndDate.Format("2006-01-02")))
+		} else {
+			message = message.Append(i18n.M("notification.milestone_changed.date", "date", m.StartDate.Format("2006-01-02")))
+		}
+		if m.CompletedAt != nil {
+			message = message.Append(i18n.M("notification.milestone_changed.completed"))
+		}
+	}
+	s.notify(ctx, p.ID, m.ProductVersionID, actorID, models.NotificationTypeMilestoneChanged, title, message)
+	s.readinessChanged(ctx, p, readinessBefore, actorID)
+}
+
+// VersionChanged notifies the watchers of pv and its product; oldVersion is the name before an update.
+func (s *WatchService) VersionChanged(ctx context.Context, action string, pv *models.ProductVersion, oldVersion string, actorID *uuid.UUID) {
+	if s == nil {
+		return
+	}
+	p, err := s.productRepo.GetByID(ctx, pv.ProductID)
+	if err != nil {
+		s.log.Warn("watch: product lookup failed", zap.Error(err), zap.String("product_id", pv.ProductID.String()))
+		return
+	}
+	title := i18n.M("notification.product_version_changed.title", "version", pv.Version, "product", p.Name).Term("action", actionTerm(action))
+	message := i18n.M("notification.product_version_changed.message", "version", pv.Version, "product", p.Name).Term("action", actionTerm(action))
+	if action == "update" && oldVersion != pv.Version {
+		message = i18n.M("notification.product_version_changed.renamed", "old", oldVersion, "version", pv.Version, "product", p.Name)
+	}
+	s.notify(ctx, p.ID, &pv.ID, actorID, models.NotificationTypeProductVersionChanged, title, message)
+}
+
+// LifecycleChanged notifies the watchers of p that its lifecycle status changed from old.
+func (s *WatchService) LifecycleChanged(ctx context.Context, p *models.Product, old models.LifecycleStatus, actorID *uuid.UUID) {
+	if s == nil {
+		return
+	}
+	title := i18n.M("notification.product_lifecycle_changed.title", "product", p.Name).Term("lifecycle", "term.lifecycle."+string(p.LifecycleStatus))
+	message := i18n.M("notification.product_lifecycle_changed.message", "product", p.Name).
+		Term("old", "term.lifecycle."+string(old)).
+		Term("lifecycle", "term.lifecycle."+string(p.LifecycleStatus))
+	s.notify(ctx, p.ID, nil, actorID, models.NotificationTypeProductLifecycleChanged, title, message)
+}
+
+// DependencyReadiness returns, per version dependency on the product that names a required status, whether
+// it is met: the product (or the dependency's target version) has a completed milestone labelled with the
+// required status. It returns nil on a nil *WatchService or when there are none.
+func (s *WatchService) DependencyReadiness(ctx context.Context, productID uuid.UUID) map[uuid.UUID]bool {
+	if s == nil {
+		return nil
+	}
+	deps, err := s.versionDepRepo.ListByTargetProductID(ctx, productID)
+	if err != nil {
+		s.log.Warn("watch: dependency lookup failed", zap.Error(err), zap.String("product_id", productID.String()))
+		return nil
+	}
+	deps = slices.DeleteFunc(deps, func(d models.ProductVersionDependency) bool { return strings.TrimSpace(d.RequiredStatus) == "" })
+	if len(deps) == 0 {
+		return nil
+	}
+	milestones, err := s.milestoneRepo.ListByProductID(productID)
+	if err != nil {
+		s.log.Warn("watch: milestone lookup failed", zap.Error(err), zap.String("product_id", productID.String()))
+		return nil
+	}
+	ready := make(map[uuid.UUID]bool, len(deps))
+	for _, d := range deps {
+		required := strings.TrimSpace(d.RequiredStatus)
+		ready[d.ID] = slices.ContainsFunc(milestones, func(m models.Milestone) bool {
+			sameVersion := (d.TargetProductVersionID == nil && m.ProductVersionID == nil) ||
+				(d.TargetProductVersionID != nil && m.ProductVersionID != nil && *d.TargetProductVersionID == *m.ProductVersionID)
+			return sameVersion && m.CompletedAt != nil && strings.TrimSpace(m.Label) == required
+		})
+	}
+	return ready
+}
+
+// readinessChanged notifies the watchers of every dependent version whose dependency on target became met
+// or unmet since before.
+func (s *WatchService) readinessChanged(ctx context.Context, target *models.Product, before map[uuid.UUID]bool, actorID *uuid.UUID) {
+	after := s.DependencyReadiness(ctx, target.ID)
+	if len(after) == 0 {
+		return
+	}
+	deps, err := s.versionDepRepo.ListByTargetProductID(ctx, target.ID)
+	if err != nil {
+		s.log.Warn("watch: dependency lookup failed", zap.Error(err), zap.String("product_id", target.ID.String()))
+		return
+	}
+	for _, d := range deps {
+		now, tracked := after[d.ID]
+		if !tracked || now == before[d.ID] || d.SourceProductVersion == nil {
+			continue
+		}
+		src := d.SourceProductVersion
+		p, err := s.productRepo.GetByID(ctx, src.ProductID)
+		if err != nil {
+			continue
+		}
+		key := "notification.dependency_readiness_changed.met"
+		if !now {
+			key = "notification.dependency_readiness_changed.unmet"
+		}
+		params := []string{"product", p.Name, "version", src.Version, "target", target.Name, "status", strings.TrimSpace(d.RequiredStatus)}
+		title := i18n.M(key+".title", params...)
+		message := i18n.M(key+".message", params...)
+		s.notify(ctx, p.ID, &src.ID, actorID, models.NotificationTypeDependencyReadinessChanged, title, message)
+	}
+}
+
+func (s *WatchService) notify(ctx context.Context, productID uuid.UUID, versionID, actorID *uuid.UUID, notifType string, title, message i18n.Message) {
+	if s.notificationSvc == nil {
+		return
+	}
+	watchers, err := s.repo.Watchers(ctx, productID, versionID)
+	if err != nil {
+		s.log.Warn("watch: watcher lookup failed", zap.Error(err), zap.String("product_id", productID.String()))
+		return
+	}
+	for _, userID := range watchers {
+		if actorID != nil && userID == *actorID {
+			continue
+		}
+		if _, err := s.notificationSvc.Create(userID, notifType, title, message, "product", &productID); err != nil {
+			s.log.Warn("watch: notification failed", zap.Error(err), zap.String("user_id", userID.String()))
+		}
+	}
+}
+
+// actionTerm is the catalog key of the past tense of a change action.
+func actionTerm(action string) string {
+	switch action {
+	case "create", "delete":
+		return "term.action." + action
+	default:
+		return "term.action.update"
+	}
+}
@@ -0,0 +1,824 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rm/roadmap/backend/internal/chatops"
+	"github.com/rm/roadmap/backend/internal/dto"
+	"github.com/rm/roadmap/backend/internal/models"
+	"github.com/rm/roadmap/backend/internal/repositories"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	webhookDeliveriesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "webhook_deliveries_total",
+			Help: "Total number of webhook delivery attempts, by result (delivered, retry or dead)",
+		},
+		[]string{"result"},
+	)
+	webhookDeliveriesWaiting = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "webhook_deliveries_waiting",
+			Help: "Webhook deliveries not yet delivered, by status (pending or dead)",
+		},
+		[]string{"status"},
+	)
+)
+
+// Headers sent with every webhook request. The signature is "sha256=" and the hex HMAC-SHA256, keyed with
+// the subscription secret, of the timestamp header, a ".", and the body; receivers should reject requests
+// whose timestamp is more than a few minutes old.
+const (
+	WebhookEventHeader     = "X-Roadmap-Event"
+	WebhookDeliveryHeader  = "X-Roadmap-Delivery"
+	WebhookTimestampHeader = "X-Roadmap-Timestamp"
+	WebhookSignatureHeader = "X-Roadmap-Signature"
+)
+
+const (
+	// webhookRetryBase is the wait after the first failed attempt; it doubles per attempt up to webhookRetryMax.
+	webhookRetryBase = 30 * time.Second
+	webhookRetryMax  = 6 * time.Hour
+	// webhookLease is how long a claimed delivery is hidden from other instances while it is sent.
+	webhookLease = 2 * time.Minute
+	// webhookResponseLimit is how much of a response body the attempt log keeps.
+	webhookResponseLimit = 1024
+)
+
+var (
+	ErrWebhookNotFound         = errors.New("webhook not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrWebhookInvalid          = errors.New("url must be an absolute http or https URL; product_id and group_id must be UUIDs")
+	ErrWebhookDestination      = errors.New("url must not point to a loopback, private or link-local address")
+)
+
+// AuditSink sees audit records as the outbox dispatcher writes them, in the same transaction.
+type AuditSink interface {
+	AuditWritten(ctx context.Context, audits []models.AuditLog) error
+}
+
+// WebhookFanout queues a webhook delivery for every active subscription matching each audit record. It runs
+// inside the outbox dispatch transaction, so events come from exactly the changes that call AuditService.Log,
+// only once they are committed, and each record is queued once.
+type WebhookFanout struct {
+	repo      repositories.WebhookRepository
+	groupRepo repositories.GroupRepository
+	wake      chan struct{}
+}
+
+func NewWebhookFanout(repo repositories.WebhookRepository, groupRepo repositories.GroupRepository) *WebhookFanout {
+	return &WebhookFanout{repo: repo, groupRepo: groupRepo, wake: make(chan struct{}, 1)}
+}
+
+func (f *WebhookFanout) AuditWritten(ctx context.Context, audits []models.AuditLog) error {
+	if len(audits) == 0 {
+		return nil
+	}
+	subs, err := f.repo.ListActive(ctx)
+	if err != nil || len(subs) == 0 {
+		return err
+	}
+	groups := map[uuid.UUID][]uuid.UUID{} // group -> product IDs, loaded once per batch
+	now := time.Now()
+	var list []models.WebhookDelivery
+	for i := range audits {
+		a := &audits[i]
+		productID := auditProductID(a)
+		var payload models.JSONB
+		for j := range subs {
+			ok, err := f.matches(&subs[j], a, productID, groups)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+			if payload == nil {
+				payload = webhookPayload(a, productID)
+			}
+			list = append(list, models.WebhookDelivery{
+				SubscriptionID: subs[j].ID,
+				AuditLogID:     a.ID,
+				Event:          a.EntityType + "." + a.Action,
+				Payload:        payload,
+				Status:         models.WebhookDeliveryPending,
+				NextAttemptAt:  now,
+			})
+		}
+	}
+	if err := f.repo.EnqueueDeliveries(ctx, list); err != nil {
+		return err
+	}
+	if len(list) > 0 {
+		// The sender may look before the dispatch commits; it then finds the deliveries on its next poll.
+		select {
+		case f.wake <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+func (f *WebhookFanout) matches(s *models.WebhookSubscription, a *models.AuditLog, productID *uuid.UUID, groups map[uuid.UUID][]uuid.UUID) (bool, error) {
+	if !matchesList(s.EntityTypes, a.EntityType) || !matchesList(s.Actions, a.Action) {
+		return false, nil
+	}
+	if s.ProductID != nil && (productID == nil || *productID != *s.ProductID) {
+		return false, nil
+	}
+	if s.GroupID == nil {
+		return true, nil
+	}
+	if a.EntityType == "group" && a.EntityID == s.GroupID.String() {
+		return true, nil
+	}
+	if productID == nil {
+		return false, nil
+	}
+	ids, ok := groups[*s.GroupID]
+	if !ok {
+		var err error
+		if ids, err = f.groupRepo.GetProductIDs(*s.GroupID); err != nil {
+			return false, err
+		}
+		groups[*s.GroupID] = ids
+	}
+	return slices.Contains(ids, *productID), nil
+}
+
+// matchesList reports whether a comma-separated filter list is empty or contains v.
+func matchesList(list, v string) bool {
+	if list == "" {
+		return true
+	}
+	return slices.Contains(strings.Split(list, ","), v)
+}
+
+// auditProductID is the product an audit record is about: the product itself, or the product_id of the
+// milestone, version, member or request in its data.
+func auditProductID(a *models.AuditLog) *uuid.UUID {
+	if a.EntityType == "product" {
+		if id, err := uuid.Parse(a.EntityID); err == nil {
+			return &id
+		}
+	}
+	for _, data := range []models.JSONB{a.NewData, a.OldData} {
+		if s, ok := data["product_id"].(string); ok {
+			if id, err := uuid.Parse(s); err == nil {
+				return &id
+			}
+		}
+	}
+	return nil
+}
+
+// webhookPayload is the body posted for an audit record.
+func webhookPayload(a *models.AuditLog, productID *uuid.UUID) models.JSONB {
+	p := models.JSONB{
+		"id":          a.ID.String(),
+		"event":       a.EntityType + "." + a.Action,
+		"entity_type": a.EntityType,
+		"entity_id":   a.EntityID,
+		"action":      a.Action,
+		"timestamp":   a.Timestamp.UTC().Format(time.RFC3339),
+	}
+	if productID != nil {
+		p["product_id"] = productID.String()
+	}
+	if a.UserID != nil {
+		p["actor_id"] = a.UserID.String()
+	}
+	if a.TraceID != "" {
+		p["trace_id"] = a.TraceID
+	}
+	if a.OldData != nil {
+		p["old"] = map[string]interface{}(a.OldData)
+	}
+	if a.NewData != nil {
+		p["new"] = map[string]interface{}(a.NewData)
+	}
+	return p
+}
+
+type WebhookConfig struct {
+	PollInterval time.Duration  // how often due deliveries are checked without a wake-up
+	BatchSize    int            // deliveries claimed per check
+	MaxAttempts  int            // failed attempts before a delivery is dead
+	Timeout      time.Duration  // per request
+	BaseURL      string         // links Slack and Teams messages to the app
+	AllowedNets  []netip.Prefix // internal destinations deliveries may reach despite webhookBlockedNets
+}
+
+// WebhookService manages webhook subscriptions and sends the deliveries WebhookFanout queues, retrying
+// failures with exponential backoff until MaxAttempts, after which a delivery is dead until redelivered.
+type WebhookService struct {
+	repo     repositories.WebhookRepository
+	fanout   *WebhookFanout
+	tx       repositories.Transactor
+	auditSvc *AuditService
+	client   *http.Client
+	cfg      WebhookConfig
+	log      *zap.Logger
+}
+
+func NewWebhookService(repo repositories.WebhookRepository, fanout *WebhookFanout, tx repositories.Transactor, auditSvc *AuditService, cfg WebhookConfig, log *zap.Logger) *WebhookService {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 5 * time.Second
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 50
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 10
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if log == nil {
+		log = zap.NewNop()
+	}
+	// The destination is checked on the address actually dialed, after DNS resolution, so a hostname that
+	// resolves (or later rebinds) to an internal address is refused too. Without a proxy the dialed
+	// address is the receiver's.
+	dialer := &net.Dialer{Timeout: cfg.Timeout, Control: webhookDialControl(cfg.AllowedNets)}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	client := &http.Client{
+		Timeout:   cfg.Timeout,
+		Transport: transport,
+		// A redirect is reported as the delivery's response rather than followed with a different method.
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	return &WebhookService{repo: repo, fanout: fanout, tx: tx, auditSvc: auditSvc, client: client, cfg: cfg, log: log}
+}
+
+func (s *WebhookService) List(ctx context.Context) ([]dto.WebhookResponse, error) {
+	list, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dto.WebhookResponse, len(list))
+	for i := range list {
+		out[i] = webhookToResponse(&list[i])
+	}
+	return out, nil
+}
+
+func (s *WebhookService) Get(ctx context.Context, id uuid.UUID) (*dto.WebhookResponse, error) {
+	sub, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	resp := webhookToResponse(sub)
+	return &resp, nil
+}
+
+func (s *WebhookService) get(ctx context.Context, id uuid.UUID) (*models.WebhookSubscription, error) {
+	sub, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrWebhookNotFound
+	}
+	return sub, err
+}
+
+// Create adds a subscription. The response carries the secret, generated when the request has none; it is
+// not shown again.
+func (s *WebhookService) Create(ctx context.Context, req dto.WebhookRequest, meta dto.AuditMeta) (*dto.WebhookResponse, error) {
+	sub := &models.WebhookSubscription{CreatedBy: meta.UserID}
+	if err := applyWebhook(sub, req, s.cfg.AllowedNets); err != nil {
+		return nil, err
+	}
+	if sub.Secret == "" {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+		sub.Secret = secret
+	}
+	var resp dto.WebhookResponse
+	if err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, sub); err != nil {
+			return err
+		}
+		resp = webhookToResponse(sub)
+		return s.logChange(ctx, "create", sub.ID, nil, ToJSONB(resp), meta)
+	}); err != nil {
+		return nil, err
+	}
+	resp.Secret = sub.Secret
+	return &resp, nil
+}
+
+// Update replaces a subscription's settings; an empty secret keeps the current one.
+func (s *WebhookService) Update(ctx context.Context, id uuid.UUID, req dto.WebhookRequest, meta dto.AuditMeta) (*dto.WebhookResponse, error) {
+	sub, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	oldData := ToJSONB(webhookToResponse(sub))
+	if err := applyWebhook(sub, req, s.cfg.AllowedNets); err != nil {
+		return nil, err
+	}
+	var resp dto.WebhookResponse
+	if err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Update(ctx, sub); err != nil {
+			return err
+		}
+		resp = webhookToResponse(sub)
+		newData := ToJSONB(resp)
+		if req.Secret != "" {
+			newData["secret_rotated"] = true
+		}
+		return s.logChange(ctx, "update", id, oldData, newData, meta)
+	}); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Delete removes a subscription with its delivery log.
+func (s *WebhookService) Delete(ctx context.Context, id uuid.UUID, meta dto.AuditMeta) error {
+	sub, err := s.get(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return s.logChange(ctx, "delete", id, ToJSONB(webhookToResponse(sub)), nil, meta)
+	})
+}
+
+// ListDeliveries returns a subscription's delivery log, newest first; status narrows it to pending,
+// delivered or dead deliveries.
+func (s *WebhookService) ListDeliveries(ctx context.Context, id uuid.UUID, status string, limit, offset int) (*dto.PageResult[dto.WebhookDeliveryResponse], error) {
+	if _, err := s.get(ctx, id); err != nil {
+		return nil, err
+	}
+	list, total, err := s.repo.ListDeliveries(ctx, id, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dto.WebhookDeliveryResponse, len(list))
+	for i := range list {
+		out[i] = webhookDeliveryToResponse(&list[i])
+	}
+	return &dto.PageResult[dto.WebhookDeliveryResponse]{Items: out, Total: total, Limit: limit, Offset: offset}, nil
+}
+
+// GetDelivery returns one delivery with its payload and every attempt.
+func (s *WebhookService) GetDelivery(ctx context.Context, id, deliveryID uuid.UUID) (*dto.WebhookDeliveryResponse, error) {
+	d, err := s.repo.GetDelivery(ctx, id, deliveryID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrWebhookDeliveryNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	attempts, err := s.repo.ListAttempts(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	resp := webhookDeliveryToResponse(d)
+	resp.Payload = d.Payload
+	resp.AttemptLog = make([]dto.WebhookAttemptResponse, len(attempts))
+	for i, a := range attempts {
+		resp.AttemptLog[i] = dto.WebhookAttemptResponse{
+			StatusCode: a.StatusCode,
+			Error:      a.Error,
+			Response:   a.Response,
+			DurationMs: a.DurationMs,
+			CreatedAt:  a.CreatedAt.Format(time.RFC3339),
+		}
+	}
+	return &resp, nil
+}
+
+// Redeliver sends a delivery again with a fresh set of attempts, whatever its status. The payload is the
+// original one; the timestamp and signature are new.
+func (s *WebhookService) Redeliver(ctx context.Context, id, deliveryID uuid.UUID, meta dto.AuditMeta) (*dto.WebhookDeliveryResponse, error) {
+	d, err := s.repo.GetDelivery(ctx, id, deliveryID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrWebhookDeliveryNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Requeue(ctx, deliveryID, now); err != nil {
+			return err
+		}
+		return s.logChange(ctx, "redeliver", id, nil, models.JSONB{"delivery_id": deliveryID.String(), "event": d.Event, "previous_status": d.Status}, meta)
+	}); err != nil {
+		return nil, err
+	}
+	select {
+	case s.fanout.wake <- struct{}{}:
+	default:
+	}
+	d.Status, d.Attempts, d.NextAttemptAt = models.WebhookDeliveryPending, 0, now
+	resp := webhookDeliveryToResponse(d)
+	return &resp, nil
+}
+
+func (s *WebhookService) logChange(ctx context.Context, action string, id uuid.UUID, oldData, newData models.JSONB, meta dto.AuditMeta) error {
+	if s.auditSvc == nil {
+		return nil
+	}
+	return s.auditSvc.Log(ctx, AuditEntry{
+		UserID:     meta.UserID,
+		Action:     action,
+		EntityType: "webhook",
+		EntityID:   id.String(),
+		OldData:    oldData,
+		NewData:    newData,
+		IPAddress:  meta.IP,
+		UserAgent:  meta.UserAgent,
+		TraceID:    meta.TraceID,
+	})
+}
+
+// Run sends due deliveries until ctx is cancelled.
+func (s *WebhookService) Run(ctx context.Context) {
+	t := time.NewTicker(s.cfg.PollInterval)
+	defer t.Stop()
+	for {
+		for ctx.Err() == nil {
+			n, err := s.sendDue(ctx)
+			if err != nil {
+				s.log.Warn("webhooks: claiming deliveries failed", zap.Error(err))
+				break
+			}
+			if n < s.cfg.BatchSize {
+				break
+			}
+		}
+		s.refreshGauges(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.fanout.wake:
+		case <-t.C:
+		}
+	}
+}
+
+// sendDue sends one batch of due deliveries and returns how many it claimed.
+func (s *WebhookService) sendDue(ctx context.Context) (int, error) {
+	list, err := s.repo.ClaimDue(ctx, time.Now(), webhookLease, s.cfg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	subs := map[uuid.UUID]*models.WebhookSubscription{}
+	for i := range list {
+		if ctx.Err() != nil {
+			break // unsent claims are retried when their lease runs out
+		}
+		d := &list[i]
+		sub, ok := subs[d.SubscriptionID]
+		if !ok {
+			sub, err = s.repo.GetByID(ctx, d.SubscriptionID)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return i, err
+			}
+			subs[d.SubscriptionID] = sub
+		}
+		s.deliver(ctx, sub, d)
+	}
+	return len(list), nil
+}
+
+// deliver makes one attempt and records it.
+func (s *WebhookService) deliver(ctx context.Context, sub *models.WebhookSubscription, d *models.WebhookDelivery) {
+	a := &models.WebhookAttempt{DeliveryID: d.ID, CreatedAt: time.Now()}
+	retryable := false
+	if sub == nil || !sub.Active {
+		a.Error = "subscription is inactive"
+	} else {
+		a.StatusCode, a.Response, retryable, a.Error = s.post(ctx, sub, d)
+		a.DurationMs = time.Since(a.CreatedAt).Milliseconds()
+	}
+	d.Attempts++
+	d.LastStatusCode, d.LastError = a.StatusCode, a.Error
+	switch {
+	case a.Error == "":
+		now := time.Now()
+		d.Status, d.DeliveredAt = models.WebhookDeliveryDelivered, &now
+		webhookDeliveriesTotal.WithLabelValues("delivered").Inc()
+	case retryable && d.Attempts < s.cfg.MaxAttempts:
+		d.NextAttemptAt = time.Now().Add(min(webhookRetryBase<<min(d.Attempts-1, 16), webhookRetryMax))
+		webhookDeliveriesTotal.WithLabelValues("retry").Inc()
+	default:
+		d.Status = models.WebhookDeliveryDead
+		webhookDeliveriesTotal.WithLabelValues("dead").Inc()
+		s.log.Warn("webhooks: delivery dead", zap.String("delivery_id", d.ID.String()), zap.String("subscription_id", d.SubscriptionID.String()),
+			zap.Int("attempts", d.Attempts), zap.Int("status_code", a.StatusCode), zap.String("error", a.Error))
+	}
+	if err := s.repo.RecordAttempt(context.WithoutCancel(ctx), d, a); err != nil {
+		s.log.Error("webhooks: recording attempt failed; the delivery may be sent again", zap.Error(err), zap.String("delivery_id", d.ID.String()))
+	}
+}
+
+// post sends the signed request. Network errors, 5xx, 408 and 429 are worth retrying; any other non-2xx
+// response is final.
+func (s *WebhookService) post(ctx context.Context, sub *models.WebhookSubscription, d *models.WebhookDelivery) (status int, response string, retryable bool, errMsg string) {
+	body, err := webhookBody(sub.Format, d.Payload, s.cfg.BaseURL)
+	if err != nil {
+		return 0, "", false, err.Error()
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", false, err.Error()
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Roadmap-Webhooks/1")
+	req.Header.Set(WebhookEventHeader, d.Event)
+	req.Header.Set(WebhookDeliveryHeader, d.ID.String())
+	req.Header.Set(WebhookTimestampHeader, ts)
+	req.Header.Set(WebhookSignatureHeader, "sha256="+WebhookSignature(sub.Secret, ts, body))
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, "", !errors.Is(err, ErrWebhookDestination), err.Error()
+	}
+	defer resp.Body.Close()
+	head, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.StatusCode, string(head), false, ""
+	}
+	retryable = resp.StatusCode >= 500 || resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests
+	return resp.StatusCode, string(head), retryable, fmt.Sprintf("HTTP %d", resp.StatusCode)
+}
+
+// webhookBody is the request body for a delivery in the subscription's format.
+func webhookBody(format string, payload models.JSONB, baseURL string) ([]byte, error) {
+	if v, ok := chatops.Payload(format, chatops.EventMessage(payload, baseURL)); ok {
+		return json.Marshal(v)
+	}
+	return json.Marshal(payload)
+}
+
+// WebhookSignature is the hex HMAC-SHA256 of timestamp + "." + body under secret.
+func WebhookSignature(secret, timestamp string, body []byte) string {
+	m := hmac.New(sha256.New, []byte(secret))
+	m.Write([]byte(timestamp))
+	m.Write([]byte("."))
+	m.Write(body)
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+func (s *WebhookService) refreshGauges(ctx context.Context) {
+	if ctx.Err() != nil {
+		return
+	}
+	pending, dead, err := s.repo.Pending(ctx)
+	if err != nil {
+		s.log.Warn("webhooks: stats failed", zap.Error(err))
+		return
+	}
+	webhookDeliveriesWaiting.WithLabelValues("pending").Set(float64(pending))
+	webhookDeliveriesWaiting.WithLabelValues("dead").Set(float64(dead))
+}
+
+// applyWebhook validates req and copies it onto sub. A host given as an internal IP address or localhost is
+// refused here already; names that resolve to one are refused when a delivery dials them.
+func applyWebhook(sub *models.WebhookSubscription, req dto.WebhookRequest, allowed []netip.Prefix) error {
+	u, err := url.Parse(strings.TrimSpace(req.URL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrWebhookInvalid
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrWebhookDestination
+	}
+	if ip, err := netip.ParseAddr(host); err == nil && !webhookDestinationAllowed(ip, allowed) {
+		return ErrWebhookDestination
+	}
+	switch req.Format {
+	case "":
+		sub.Format = models.WebhookFormatJSON
+	case models.WebhookFormatJSON, models.WebhookFormatSlack, models.WebhookFormatTeams:
+		sub.Format = req.Format
+	default:
+		return ErrWebhookInvalid
+	}
+	sub.ProductID, sub.GroupID = nil, nil
+	if req.ProductID != "" {
+		id, err := uuid.Parse(req.ProductID)
+		if err != nil {
+			return ErrWebhookInvalid
+		}
+		sub.ProductID = &id
+	}
+	if req.GroupID != "" {
+		id, err := uuid.Parse(req.GroupID)
+		if err != nil {
+			return ErrWebhookInvalid
+		}
+		sub.GroupID = &id
+	}
+	sub.Name = strings.TrimSpace(req.Name)
+	sub.URL = u.String()
+	sub.EntityTypes = joinFilter(req.EntityTypes)
+	sub.Actions = joinFilter(req.Actions)
+	sub.Active = req.Active == nil || *req.Active
+	if req.Secret != "" {
+		sub.Secret = req.Secret
+	}
+	return nil
+}
+
+func joinFilter(list []string) string {
+	var out []string
+	for _, v := range list {
+		if v = strings.TrimSpace(v); v != "" && !slices.Contains(out, v) {
+			out = append(out, v)
+		}
+	}
+	return strings.Join(out, ",")
+}
+
+func splitFilter(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, ",")
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func webhookToResponse(s *models.WebhookSubscription) dto.WebhookResponse {
+	resp := dto.WebhookResponse{
+		ID:          s.ID.String(),
+		Name:        s.Name,
+		URL:         s.URL,
+		Format:      s.Format,
+		EntityTypes: splitFilter(s.EntityTypes),
+		Actions:     splitFilter(s.Actions),
+		Active:      s.Active,
+		CreatedAt:   s.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   s.UpdatedAt.Format(time.RFC3339),
+	}
+	if s.ProductID != nil {
+		v := s.ProductID.String()
+		resp.ProductID = &v
+	}
+	if s.GroupID != nil {
+		v := s.GroupID.String()
+		resp.GroupID = &v
+	}
+	if s.CreatedBy != nil {
+		v := s.CreatedBy.String()
+		resp.CreatedBy = &v
+	}
+	return resp
+}
+
+func webhookDeliveryToResponse(d *models.WebhookDelivery) dto.WebhookDeliveryResponse {
+	resp := dto.WebhookDeliveryResponse{
+		ID:             d.ID.String(),
+		SubscriptionID: d.SubscriptionID.String(),
+		AuditLogID:     d.AuditLogID.String(),
+		Event:          d.Event,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt.Format(time.RFC3339),
+	}
+	if d.Status == models.WebhookDeliveryPending {
+		v := d.NextAttemptAt.Format(time.RFC3339)
+		resp.NextAttemptAt = &v
+	}
+	if d.DeliveredAt != nil {
+		v := d.DeliveredAt.Format(time.RFC3339)
+		resp.DeliveredAt = &v
+	}
+	return resp
+}
+
+// webhookBlockedNets are ranges a delivery may not reach unless WebhookConfig.AllowedNets lets it: loopback,
+// RFC 1918 and unique local, link-local (including the cloud metadata address 169.254.169.254), shared
+// address space, "this network", multicast, and the IPv6 transition ranges whose IPv4 address cannot be
+// checked (IPv4-compatible, local-use NAT64 and Teredo). NAT64 and 6to4 addresses are judged by the IPv4
+// address they carry, and IPv4-mapped ones are unmapped first.
+var webhookBlockedNets = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("224.0.0.0/4"),
+	netip.MustParsePrefix("::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("2001::/32"),
+	netip.MustParsePrefix("fc00::/7"),
+	netip.MustParsePrefix("fe80::/10"),
+	netip.MustParsePrefix("ff00::/8"),
+}
+
+var (
+	webhookNAT64 = netip.MustParsePrefix("64:ff9b::/96")
+	webhook6to4  = netip.MustParsePrefix("2002::/16")
+)
+
+// webhookEmbeddedIPv4 returns the IPv4 address a NAT64 or 6to4 address reaches.
+func webhookEmbeddedIPv4(ip netip.Addr) (netip.Addr, bool) {
+	b := ip.As16()
+	switch {
+	case webhookNAT64.Contains(ip):
+		return netip.AddrFrom4([4]byte(b[12:16])), true
+	case webhook6to4.Contains(ip):
+		return netip.AddrFrom4([4]byte(b[2:6])), true
+	}
+	return netip.Addr{}, false
+}
+
+// webhookDestinationAllowed reports whether a delivery may connect to ip.
+func webhookDestinationAllowed(ip netip.Addr, allowed []netip.Prefix) bool {
+	ip = ip.Unmap().WithZone("")
+	for _, p := range allowed {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	if v4, ok := webhookEmbeddedIPv4(ip); ok {
+		return webhookDestinationAllowed(v4, allowed)
+	}
+	for _, p := range webhookBlockedNets {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// webhookDialControl refuses connections to blocked addresses. It runs for every address the dialer
+// tries, after resolution.
+func webhookDialControl(allowed []netip.Prefix) func(network, address string, c syscall.RawConn) error {
+	return func(network, address string, _ syscall.RawConn) error {
+		ap, err := netip.ParseAddrPort(address)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrWebhookDestination, address)
+		}
+		if !webhookDestinationAllowed(ap.Addr(), allowed) {
+			return fmt.Errorf("%w: %s", ErrWebhookDestination, ap.Addr())
+		}
+		return nil
+	}
+}
+
+// ParseWebhookAllowedNets splits a comma-separated list of CIDR ranges or single addresses such as
+// "10.20.0.0/16,192.168.1.5".
+func ParseWebhookAllowedNets(s string) ([]netip.Prefix, error) {
+	var out []netip.Prefix
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+		if ip, err := netip.ParseAddr(v); err == nil {
+			out = append(out, netip.PrefixFrom(ip.Unmap(), ip.Unmap().BitLen()))
+			continue
+		}
+		p, err := netip.ParsePrefix(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid webhook allowed range %q", v)
+		}
+		out = append(out, p.Masked())
+	}
+	return out, nil
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rm/roadmap/backend/internal/dto"
+	"github.com/rm/roadmap/backend/internal/models"
+)
+
+func TestWebhookSignature(t *testing.T) {
+	// Computed independently: HMAC-SHA256 of "1700000000." + body under the secret.
+	got := WebhookSignature("whsec_test", "1700000000", []byte(`{"event":"product.update"}`))
+	if want := "d7970dfbd0275bdf8c674e4ebfa2ad1b9f4862d9a2e64e77a991206e45468897"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestWebhookDialControl(t *testing.T) {
+	allowed, err := ParseWebhookAllowedNets(" 10.20.0.0/16, 192.168.1.5 ,fd00:1::/32")
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocked := map[string]string{
+		"0.0.0.0:80":                  "unspecified",
+		"127.0.0.1:443":               "loopback",
+		"127.9.9.9:443":               "loopback range",
+		"10.0.0.1:80":                 "RFC 1918",
+		"172.16.5.4:80":               "RFC 1918",
+		"192.168.0.1:80":              "RFC 1918",
+		"169.254.169.254:80":          "cloud metadata",
+		"100.64.0.1:80":               "shared address space",
+		"224.0.0.1:80":                "multicast",
+		"[::]:80":                     "IPv6 unspecified",
+		"[::1]:80":                    "IPv6 loopback",
+		"[fd12:3456::1]:80":           "unique local",
+		"[fe80::1%eth0]:80":           "IPv6 link-local with zone",
+		"[::ffff:127.0.0.1]:80":       "IPv4-mapped loopback",
+		"[::ffff:169.254.169.254]:80": "IPv4-mapped metadata",
+		"[::127.0.0.1]:80":            "IPv4-compatible",
+		"[64:ff9b::a9fe:a9fe]:80":     "NAT64 of the metadata address",
+		"[64:ff9b::7f00:1]:80":        "NAT64 of loopback",
+		"[64:ff9b:1::1]:80":           "local-use NAT64",
+		"[2002:c0a8:0101::1]:80":      "6to4 of 192.168.1.1",
+		"[2002:7f00:1::1]:80":         "6to4 of loopback",
+		"[2001:0:4136:e378::1]:80":    "Teredo",
+		"not-an-address":              "unparsable",
+	}
+	control := webhookDialControl(nil)
+	for addr, name := range blocked {
+		if err := control("tcp", addr, nil); !errors.Is(err, ErrWebhookDestination) {
+			t.Errorf("%s (%s): got %v, want ErrWebhookDestination", name, addr, err)
+		}
+	}
+
+	open := map[string]string{
+		"93.184.216.34:443":       "public IPv4",
+		"[2606:4700::1111]:443":   "public IPv6",
+		"[64:ff9b::5db8:d822]:80": "NAT64 of a public address",
+		"[2002:5db8:d822::1]:80":  "6to4 of a public address",
+	}
+	for addr, name := range open {
+		if err := control("tcp", addr, nil); err != nil {
+			t.Errorf("%s (%s): %v", name, addr, err)
+		}
+	}
+
+	control = webhookDialControl(allowed)
+	for addr, want := range map[string]bool{
+		"10.20.3.4:80":            true,
+		"10.21.0.1:80":            false,
+		"192.168.1.5:80":          true,
+		"192.168.1.6:80":          false,
+		"[::ffff:192.168.1.5]:80": true,
+		"[2002:c0a8:0105::1]:80":  true,
+		"[fd00:1:2::3]:80":        true,
+		"127.0.0.1:80":            false,
+		"169.254.169.254:80":      false,
+	} {
+		if err := control("tcp", addr, nil); (err == nil) != want {
+			t.Errorf("allowed nets, %s: got %v, want allowed %v", addr, err, want)
+		}
+	}
+
+	if _, err := ParseWebhookAllowedNets("10.0.0.0/8,internal"); err == nil {
+		t.Error("invalid range accepted")
+	}
+}
+
+func TestApplyWebhookDestination(t *testing.T) {
+	allowed := []netip.Prefix{netip.MustParsePrefix("10.20.0.0/16")}
+	for url, want := range map[string]error{
+		"https://hooks.example.com/x":   nil,
+		"https://93.184.216.34/x":       nil,
+		"http://10.20.0.7:8080/x":       nil,
+		"http://localhost:8080/x":       ErrWebhookDestination,
+		"http://api.LOCALHOST./x":       ErrWebhookDestination,
+		"http://127.0.0.1/x":            ErrWebhookDestination,
+		"http://169.254.169.254/latest": ErrWebhookDestination,
+		"http://[::1]:8080/x":           ErrWebhookDestination,
+		"http://[::ffff:10.0.0.1]/x":    ErrWebhookDestination,
+		"ftp://hooks.example.com/x":     ErrWebhookInvalid,
+	} {
+		err := applyWebhook(&models.WebhookSubscription{}, dto.WebhookRequest{Name: "hook", URL: url}, allowed)
+		if !errors.Is(err, want) || (want == nil && err != nil) {
+			t.Errorf("%s: got %v, want %v", url, err, want)
+		}
+	}
+}
+
+func TestWebhookPost(t *testing.T) {
+	var got *http.Request
+	var body []byte
+	followed := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/moved":
+			http.Redirect(w, r, "/target", http.StatusFound)
+		case "/target":
+			followed = true
+		default:
+			got = r
+			body, _ = io.ReadAll(r.Body)
+			_, _ = w.Write([]byte("ok"))
+		}
+	}))
+	defer srv.Close()
+
+	sub := &models.WebhookSubscription{URL: srv.URL + "/hook", Format: models.WebhookFormatJSON, Secret: "whsec_test", Active: true}
+	d := &models.WebhookDelivery{ID: uuid.New(), Event: "product.update", Payload: models.JSONB{"event": "product.update"}}
+
+	// httptest listens on loopback, which is blocked unless allowed.
+	s := NewWebhookService(nil, nil, nil, nil, WebhookConfig{}, nil)
+	status, _, retryable, errMsg := s.post(context.Background(), sub, d)
+	if status != 0 || retryable || !strings.Contains(errMsg, ErrWebhookDestination.Error()) {
+		t.Errorf("loopback: status %d, retryable %v, error %q; want a final destination error", status, retryable, errMsg)
+	}
+	if got != nil {
+		t.Fatal("request reached a loopback receiver")
+	}
+
+	s = NewWebhookService(nil, nil, nil, nil, WebhookConfig{AllowedNets: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}}, nil)
+	status, response, _, errMsg := s.post(context.Background(), sub, d)
+	if status != http.StatusOK || response != "ok" || errMsg != "" {
+		t.Fatalf("allowed loopback: status %d, response %q, error %q", status, response, errMsg)
+	}
+	ts := got.Header.Get(WebhookTimestampHeader)
+	if sig := got.Header.Get(WebhookSignatureHeader); sig != "sha256="+WebhookSignature("whsec_test", ts, body) {
+		t.Errorf("signature %q does not match the body", sig)
+	}
+	if got.Header.Get(WebhookEventHeader) != d.Event || got.Header.Get(WebhookDeliveryHeader) != d.ID.String() {
+		t.Errorf("headers %v", got.Header)
+	}
+
+	sub.URL = srv.URL + "/moved"
+	status, _, retryable, errMsg = s.post(context.Background(), sub, d)
+	if status != http.StatusFound || retryable || errMsg != "HTTP 302" || followed {
+		t.Errorf("redirect: status %d, retryable %v, error %q, followed %v; want the 302 as a final answer", status, retryable, errMsg, followed)
+	}
+}
@@ -0,0 +1,162 @@
+// Package siem formats audit and activity records for a SIEM and ships them over syslog transports.
+//
+// Records are sent as RFC 5424 syslog messages. With FormatSyslog the message carries the record's fields as
+// structured data and the full record as JSON; with FormatCEF the message body is an ArcSight CEF event, the
+// common way to carry CEF over syslog.
+package siem
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FormatSyslog = "syslog"
+	FormatCEF    = "cef"
+
+	appName = "roadmap"
+	// sdID is the structured data element; 32473 is the private enterprise number reserved for examples (RFC 5612).
+	sdID = "roadmap@32473"
+
+	facilityLogAudit = 13
+	severityNotice   = 5
+	severityInfo     = 6
+)
+
+// Event is one audit or activity record.
+type Event struct {
+	Stream     string // "audit" or "activity"; the syslog MSGID
+	ID         string
+	Seq        int64 // audit hash chain position or activity seq
+	Time       time.Time
+	UserID     string
+	Action     string
+	EntityType string
+	EntityID   string
+	IPAddress  string
+	UserAgent  string
+	TraceID    string
+	JSON       []byte // the full record, sent as the syslog message
+}
+
+// Formatter renders an Event as one syslog message.
+type Formatter func(e *Event) []byte
+
+// NewFormatter returns the formatter for format (FormatSyslog or FormatCEF), stamping hostname into the header.
+func NewFormatter(format, hostname string) (Formatter, error) {
+	if hostname == "" {
+		hostname = "-"
+	}
+	switch format {
+	case FormatSyslog:
+		return func(e *Event) []byte { return Syslog(e, hostname) }, nil
+	case FormatCEF:
+		return func(e *Event) []byte { return CEF(e, hostname) }, nil
+	}
+	return nil, fmt.Errorf("siem: unknown format %q", format)
+}
+
+// notable reports whether an action is logged at notice rather than informational severity.
+func notable(action string) bool {
+	switch action {
+	case "delete", "delete_archived", "retention_purge", "retention_run", "restore", "login_failed", "revoke", "revoke_all":
+		return true
+	}
+	return false
+}
+
+func header(e *Event, hostname string) string {
+	sev := severityInfo
+	if notable(e.Action) {
+		sev = severityNotice
+	}
+	return fmt.Sprintf("<%d>1 %s %s %s - %s ", facilityLogAudit*8+sev, e.Time.UTC().Format("2006-01-02T15:04:05.000000Z"), hostname, appName, e.Stream)
+}
+
+// Syslog renders e as an RFC 5424 message with the record's fields as structured data and its JSON as the message.
+func Syslog(e *Event, hostname string) []byte {
+	var b strings.Builder
+	b.WriteString(header(e, hostname))
+	b.WriteString("[" + sdID)
+	param := func(name, value string) {
+		if value != "" {
+			b.WriteString(" " + name + `="` + sdEscape(value) + `"`)
+		}
+	}
+	param("id", e.ID)
+	if e.Seq > 0 {
+		param("seq", strconv.FormatInt(e.Seq, 10))
+	}
+	param("action", e.Action)
+	param("entityType", e.EntityType)
+	param("entityId", e.EntityID)
+	param("userId", e.UserID)
+	param("ip", e.IPAddress)
+	param("traceId", e.TraceID)
+	b.WriteString("]")
+	if len(e.JSON) > 0 {
+		b.WriteString(" ")
+		b.Write(e.JSON)
+	}
+	return []byte(b.String())
+}
+
+// CEF renders e as a syslog message whose body is a CEF:0 event.
+func CEF(e *Event, hostname string) []byte {
+	sev := 3
+	if notable(e.Action) {
+		sev = 6
+	}
+	name := e.Action
+	if e.EntityType != "" {
+		name += " " + e.EntityType
+	}
+	var b strings.Builder
+	b.WriteString(header(e, hostname))
+	b.WriteString("- ") // no structured data
+	fmt.Fprintf(&b, "CEF:0|RM|Roadmap|1.0|%s|%s|%d|", cefHeaderEscape(e.Stream+":"+e.Action), cefHeaderEscape(name), sev)
+	ext := []string{"rt=" + strconv.FormatInt(e.Time.UnixMilli(), 10)}
+	add := func(key, value string) {
+		if value != "" {
+			ext = append(ext, key+"="+cefExtEscape(value))
+		}
+	}
+	add("externalId", e.ID)
+	add("act", e.Action)
+	add("suid", e.UserID)
+	add("src", e.IPAddress)
+	add("requestClientApplication", e.UserAgent)
+	if e.EntityType != "" {
+		add("cs1Label", "entityType")
+		add("cs1", e.EntityType)
+	}
+	if e.EntityID != "" {
+		add("cs2Label", "entityId")
+		add("cs2", e.EntityID)
+	}
+	if e.TraceID != "" {
+		add("cs3Label", "traceId")
+		add("cs3", e.TraceID)
+	}
+	if e.Seq > 0 {
+		add("cn1Label", "seq")
+		add("cn1", strconv.FormatInt(e.Seq, 10))
+	}
+	b.WriteString(strings.Join(ext, " "))
+	return []byte(b.String())
+}
+
+// sdEscape escapes a structured data parameter value (RFC 5424 section 6.3.3).
+func sdEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(s)
+}
+
+func cefHeaderEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\r", " ", "\n", " ").Replace(s)
+}
+
+func cefExtEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`).Replace(s)
+}
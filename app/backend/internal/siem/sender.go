@@ -0,0 +1,95 @@
+package siem
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Sender delivers syslog messages to one target: tcp://host:port and tls://host:port use octet-counted framing
+// (RFC 6587), udp://host:port sends one message per datagram. It connects lazily and reconnects after an
+// error. A Sender is not safe for concurrent use.
+type Sender struct {
+	network string
+	addr    string
+	tls     *tls.Config
+	timeout time.Duration
+	conn    net.Conn
+}
+
+// NewSender parses target and returns a Sender for it; no connection is made yet.
+func NewSender(target string, timeout time.Duration) (*Sender, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("siem: bad target %q: %w", target, err)
+	}
+	if u.Host == "" || u.Port() == "" {
+		return nil, fmt.Errorf("siem: target %q needs host:port", target)
+	}
+	s := &Sender{addr: u.Host, timeout: timeout}
+	switch u.Scheme {
+	case "tcp", "udp":
+		s.network = u.Scheme
+	case "tls":
+		s.network = "tcp"
+		s.tls = &tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12}
+	default:
+		return nil, fmt.Errorf("siem: target %q must be tcp://, udp:// or tls://", target)
+	}
+	return s, nil
+}
+
+// Send writes msgs in order. On error the connection is dropped; the next Send reconnects, so a batch may be
+// delivered more than once but never out of order.
+func (s *Sender) Send(msgs [][]byte) error {
+	if s.conn == nil {
+		if err := s.dial(); err != nil {
+			return err
+		}
+	}
+	if s.timeout > 0 {
+		_ = s.conn.SetWriteDeadline(time.Now().Add(s.timeout))
+	}
+	for _, m := range msgs {
+		var err error
+		if s.network == "udp" {
+			_, err = s.conn.Write(m)
+		} else {
+			_, err = s.conn.Write(append([]byte(strconv.Itoa(len(m))+" "), m...))
+		}
+		if err != nil {
+			s.Close()
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Sender) dial() error {
+	d := &net.Dialer{Timeout: s.timeout}
+	if s.tls != nil {
+		conn, err := tls.DialWithDialer(d, s.network, s.addr, s.tls)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+		return nil
+	}
+	conn, err := d.Dial(s.network, s.addr)
+	if err != nil {
+		return err
+	}
+	s.conn = conn
+	return nil
+}
+
+// Close drops the connection, if any.
+func (s *Sender) Close() {
+	if s.conn != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+}
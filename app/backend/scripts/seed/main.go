@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"time"
@@ -43,7 +44,7 @@ func main() {
 			log.Printf("%s already exists: %s", name, email)
 			return
 		}
-		if err := userRepo.Create(u); err != nil {
+		if err := userRepo.Create(context.Background(), u); err != nil {
 			log.Printf("failed to create %s: %v", name, err)
 			return
 		}
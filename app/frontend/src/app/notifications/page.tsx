@@ -4,7 +4,9 @@ import { useState, useCallback } from 'react';
 import Link from 'next/link';
 import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query';
 import { RequireAuth } from '@/components/RequireAuth';
-import { api, type Notification } from '@/lib/api';
+import { api, type Notification, type NotificationBulkRequest } from '@/lib/api';
+
+const localeNames: Record<string, string> = { en: 'English', de: 'Deutsch' };
 
 function NotificationsContent() {
   const queryClient = useQueryClient();
@@ -19,7 +21,7 @@ function NotificationsContent() {
   });
 
   const items = list?.items ?? [];
-  const hasUnread = items.some((n: Notification) => !n.read_at);
+  const hasUnread = items.some((n: Notification) => (n.thread_unread_count ?? (n.read_at ? 0 : 1)) > 0);
   const allIds = items.map((n: Notification) => n.id);
   const allSelected = allIds.length > 0 && allIds.every((id) => selectedIds.has(id));
   const someSelected = selectedIds.size > 0;
@@ -30,33 +32,34 @@ function NotificationsContent() {
     setSelectedIds(new Set());
   }, []);
 
-  const markReadMutation = useMutation({
-    mutationFn: (id: string) => api.notifications.markRead(id),
-    onSuccess: () => {
-      queryClient.invalidateQueries({ queryKey: ['notifications-page'] });
-      queryClient.invalidateQueries({ queryKey: ['notifications-unread-count'] });
+  const invalidate = useCallback(() => {
+    queryClient.invalidateQueries({ queryKey: ['notifications-page'] });
+    queryClient.invalidateQueries({ queryKey: ['notifications-unread-count'] });
+  }, [queryClient]);
+
+  const { data: localeSettings } = useQuery({
+    queryKey: ['locale'],
+    queryFn: () => api.locale.get(),
+  });
+  // Notifications are stored untranslated, so changing the language re-renders the ones already received.
+  const localeMutation = useMutation({
+    mutationFn: (locale: string) => api.locale.update(locale),
+    onSuccess: (res) => {
+      queryClient.setQueryData(['locale'], res);
+      invalidate();
     },
   });
+
   const markReadAllMutation = useMutation({
     mutationFn: () => api.notifications.markReadAll(),
-    onSuccess: () => {
-      queryClient.invalidateQueries({ queryKey: ['notifications-page'] });
-      queryClient.invalidateQueries({ queryKey: ['notifications-unread-count'] });
-    },
-  });
-  const archiveMutation = useMutation({
-    mutationFn: (id: string) => api.notifications.archive(id),
-    onSuccess: () => {
-      queryClient.invalidateQueries({ queryKey: ['notifications-page'] });
-      queryClient.invalidateQueries({ queryKey: ['notifications-unread-count'] });
-    },
+    onSuccess: invalidate,
   });
-  const deleteMutation = useMutation({
-    mutationFn: (id: string) => api.notifications.delete(id),
-    onSuccess: () => {
-      queryClient.invalidateQueries({ queryKey: ['notifications-page'] });
-      queryClient.invalidateQueries({ queryKey: ['notifications-unread-count'] });
-    },
+  // Rows are threads: repeated notifications about the same item. Row actions apply to the whole thread,
+  // within the current tab.
+  const threadMutation = useMutation({
+    mutationFn: ({ action, ids }: { action: NotificationBulkRequest['action']; ids: string[] }) =>
+      api.notifications.bulk({ action, ids, threads: true, archived: viewArchived ? undefined : false }),
+    onSuccess: invalidate,
   });
 
   const toggleSelectAll = () => {
@@ -76,26 +79,11 @@ function NotificationsContent() {
     });
   };
 
-  const handleArchiveSelected = async () => {
-    if (!someSelected || viewArchived) return;
+  const handleBulk = async (action: 'archive' | 'delete') => {
+    if (!someSelected || (action === 'archive' && viewArchived)) return;
     setIsBulkAction(true);
     try {
-      await Promise.allSettled(Array.from(selectedIds).map((id) => api.notifications.archive(id)));
-      queryClient.invalidateQueries({ queryKey: ['notifications-page'] });
-      queryClient.invalidateQueries({ queryKey: ['notifications-unread-count'] });
-      clearSelection();
-    } finally {
-      setIsBulkAction(false);
-    }
-  };
-
-  const handleDeleteSelected = async () => {
-    if (!someSelected) return;
-    setIsBulkAction(true);
-    try {
-      await Promise.allSettled(Array.from(selectedIds).map((id) => api.notifications.delete(id)));
-      queryClient.invalidateQueries({ queryKey: ['notifications-page'] });
-      queryClient.invalidateQueries({ queryKey: ['notifications-unread-count'] });
+      await threadMutation.mutateAsync({ action, ids: Array.from(selectedIds) });
       clearSelection();
     } finally {
       setIsBulkAction(false);
@@ -106,12 +94,32 @@ function NotificationsContent() {
     <div className="max-w-4xl mx-auto">
       <div className="flex justify-between items-center mb-6">
         <h1 className="text-3xl font-bold text-gray-900">Notifications</h1>
-        <Link href="/dashboard" className="btn-secondary">
-          Back to Dashboard
-        </Link>
+        <div className="flex items-center gap-4">
+          {localeSettings && (
+            <label className="flex items-center gap-2 text-sm text-gray-700">
+              Language
+              <select
+                value={localeSettings.locale}
+                onChange={(e) => localeMutation.mutate(e.target.value)}
+                disabled={localeMutation.isPending}
+                className="border border-gray-300 rounded px-2 py-1 text-sm"
+              >
+                <option value="">Browser default</option>
+                {localeSettings.supported.map((l) => (
+                  <option key={l} value={l}>
+                    {localeNames[l] ?? l}
+                  </option>
+                ))}
+              </select>
+            </label>
+          )}
+          <Link href="/dashboard" className="btn-secondary">
+            Back to Dashboard
+          </Link>
+        </div>
       </div>
       <p className="text-gray-600 mb-4">
-        Notifications when you submit product creation or deletion requests (admins are notified), and when admins approve or reject them or change product status. Use Select all to archive or delete multiple items. Deleted items are removed from both Inbox and Archived.
+        Notifications when you submit product creation or deletion requests (admins are notified), and when admins approve or reject them or change product status. Repeated notifications about the same item are grouped, and actions apply to the whole group. Use Select all to archive or delete multiple items. Deleted items are removed from both Inbox and Archived.
       </p>
 
       <div className="flex border-b border-gray-200 mb-4">
@@ -163,7 +171,7 @@ function NotificationsContent() {
                 {!viewArchived && (
                   <button
                     type="button"
-                    onClick={handleArchiveSelected}
+                    onClick={() => handleBulk('archive')}
                     disabled={isBulkAction}
                     className="btn-secondary text-sm py-1.5"
                   >
@@ -172,7 +180,7 @@ function NotificationsContent() {
                 )}
                 <button
                   type="button"
-                  onClick={handleDeleteSelected}
+                  onClick={() => handleBulk('delete')}
                   disabled={isBulkAction}
                   className="text-sm py-1.5 px-3 rounded-lg font-medium text-red-700 bg-red-50 hover:bg-red-100 border border-red-200 disabled:opacity-50"
                 >
@@ -195,61 +203,70 @@ function NotificationsContent() {
         </p>
       ) : (
         <div className="card divide-y divide-gray-100 overflow-hidden p-0">
-          {items.map((n: Notification) => (
-            <div
-              key={n.id}
-              className={`px-4 py-4 hover:bg-gray-50/50 flex items-start gap-3 ${!n.read_at ? 'bg-amber-50/30' : ''}`}
-            >
-              <label className="flex items-center pt-0.5 shrink-0 cursor-pointer">
-                <input
-                  type="checkbox"
-                  checked={selectedIds.has(n.id)}
-                  onChange={() => toggleSelectOne(n.id)}
-                  className="rounded border-gray-300 text-dhl-red focus:ring-dhl-red"
-                  aria-label={`Select notification ${n.title}`}
-                />
-              </label>
-              <div className="min-w-0 flex-1">
-                <p className={`text-sm ${!n.read_at ? 'font-semibold text-gray-900' : 'text-gray-900'}`}>
-                  {n.title}
-                </p>
-                <p className="text-sm text-gray-600 mt-1">{n.message}</p>
-                <p className="text-xs text-gray-400 mt-2">
-                  {new Date(n.created_at).toLocaleString()}
-                </p>
-              </div>
-              <div className="flex items-center gap-2 shrink-0">
-                {!n.read_at && (
-                  <button
-                    type="button"
-                    onClick={() => markReadMutation.mutate(n.id)}
-                    disabled={markReadMutation.isPending}
-                    className="text-sm text-gray-600 hover:text-gray-800"
-                  >
-                    Mark read
-                  </button>
-                )}
-                {!viewArchived && !n.archived_at && (
+          {items.map((n: Notification) => {
+            const unread = n.thread_unread_count ?? (n.read_at ? 0 : 1);
+            const count = n.thread_count ?? 1;
+            return (
+              <div
+                key={n.id}
+                className={`px-4 py-4 hover:bg-gray-50/50 flex items-start gap-3 ${unread > 0 ? 'bg-amber-50/30' : ''}`}
+              >
+                <label className="flex items-center pt-0.5 shrink-0 cursor-pointer">
+                  <input
+                    type="checkbox"
+                    checked={selectedIds.has(n.id)}
+                    onChange={() => toggleSelectOne(n.id)}
+                    className="rounded border-gray-300 text-dhl-red focus:ring-dhl-red"
+                    aria-label={`Select notification ${n.title}`}
+                  />
+                </label>
+                <div className="min-w-0 flex-1">
+                  <p className={`text-sm ${unread > 0 ? 'font-semibold text-gray-900' : 'text-gray-900'}`}>
+                    {n.title}
+                    {count > 1 && (
+                      <span className="ml-2 inline-block rounded-full bg-gray-100 px-2 py-0.5 text-xs font-medium text-gray-600">
+                        {count} notifications{unread > 0 ? `, ${unread} unread` : ''}
+                      </span>
+                    )}
+                  </p>
+                  <p className="text-sm text-gray-600 mt-1">{n.message}</p>
+                  <p className="text-xs text-gray-400 mt-2">
+                    {new Date(n.created_at).toLocaleString()}
+                  </p>
+                </div>
+                <div className="flex items-center gap-2 shrink-0">
+                  {unread > 0 && (
+                    <button
+                      type="button"
+                      onClick={() => threadMutation.mutate({ action: 'read', ids: [n.id] })}
+                      disabled={threadMutation.isPending}
+                      className="text-sm text-gray-600 hover:text-gray-800"
+                    >
+                      Mark read
+                    </button>
+                  )}
+                  {!viewArchived && !n.archived_at && (
+                    <button
+                      type="button"
+                      onClick={() => threadMutation.mutate({ action: 'archive', ids: [n.id] })}
+                      disabled={threadMutation.isPending}
+                      className="text-sm text-gray-600 hover:text-gray-800"
+                    >
+                      Archive
+                    </button>
+                  )}
                   <button
                     type="button"
-                    onClick={() => archiveMutation.mutate(n.id)}
-                    disabled={archiveMutation.isPending}
-                    className="text-sm text-gray-600 hover:text-gray-800"
+                    onClick={() => threadMutation.mutate({ action: 'delete', ids: [n.id] })}
+                    disabled={threadMutation.isPending}
+                    className="text-sm text-red-600 hover:text-red-800"
                   >
-                    Archive
+                    Delete
                   </button>
-                )}
-                <button
-                  type="button"
-                  onClick={() => deleteMutation.mutate(n.id)}
-                  disabled={deleteMutation.isPending}
-                  className="text-sm text-red-600 hover:text-red-800"
-                >
-                  Delete
-                </button>
+                </div>
               </div>
-            </div>
-          ))}
+            );
+          })}
         </div>
       )}
     </div>
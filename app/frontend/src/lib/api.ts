@@ -15,6 +15,21 @@ function getToken(): string | null {
   }
 }
 
+// The locale the user picked; sent as Accept-Language so the backend answers in it. Without one the
+// browser's languages apply.
+function getLocale(): string | null {
+  if (typeof window === 'undefined') return null;
+  return localStorage.getItem('locale');
+}
+
+/** An error response; code is the backend's stable error code, message is localized. */
+export class ApiError extends Error {
+  constructor(message: string, readonly status: number, readonly code?: string) {
+    super(message);
+    this.name = 'ApiError';
+  }
+}
+
 async function fetchApi<T>(
   path: string,
   options: RequestInit = {}
@@ -25,6 +40,8 @@ async function fetchApi<T>(
     ...(options.headers as Record<string, string>),
   };
   if (token) (headers as Record<string, string>)['Authorization'] = `Bearer ${token}`;
+  const locale = getLocale();
+  if (locale) (headers as Record<string, string>)['Accept-Language'] = locale;
 
   const res = await fetch(`${API_BASE}${path}`, { ...options, headers });
   if (!res.ok) {
@@ -36,9 +53,11 @@ async function fetchApi<T>(
     }
     const text = await res.text();
     let message = res.statusText;
+    let code: string | undefined;
     try {
-      const err = JSON.parse(text) as { error?: string; message?: string; msg?: string };
+      const err = JSON.parse(text) as { error?: string; code?: string; message?: string; msg?: string };
       message = err?.error ?? err?.message ?? err?.msg ?? message;
+      code = err?.code;
     } catch {
       if (text && text.length < 300) message = text.trim() || message;
       else if (res.status === 502 || res.status === 500) message = 'Backend unreachable or error. Check: backend running (docker compose logs backend), DB_HOST set correctly (postgres or host IP), and DevTools → Network → failed request → Response.';
@@ -46,13 +65,13 @@ async function fetchApi<T>(
     if (res.status === 500 && message === res.statusText) {
       message = `Server error (500). Backend may have returned a non-JSON response. Check docker compose logs backend and DevTools → Network → failed request → Response.`;
     }
-    throw new Error(message);
+    throw new ApiError(message, res.status, code);
   }
   if (res.status === 204) return undefined as T;
   return res.json();
 }
 
-export type User = { id: string; name: string; email: string; role: string; team_id?: string; direct_manager_id?: string };
+export type User = { id: string; name: string; email: string; role: string; team_id?: string; direct_manager_id?: string; locale?: string };
 export type HoldingCompany = { id: string; name: string; description?: string; created_at: string };
 export type Company = { id: string; holding_company_id: string; name: string; created_at: string };
 export type OrgFunction = { id: string; company_id: string; name: string; created_at: string };
@@ -70,6 +89,19 @@ export type Notification = {
   read_at?: string;
   archived_at?: string;
   created_at: string;
+  thread_count?: number;
+  thread_unread_count?: number;
+};
+export type NotificationBulkRequest = {
+  action: 'read' | 'archive' | 'delete';
+  ids?: string[];
+  threads?: boolean;
+  type?: string;
+  related_entity_type?: string;
+  related_entity_id?: string;
+  read?: boolean;
+  archived?: boolean;
+  before?: string;
 };
 export type ProductDeletionRequest = {
   id: string;
@@ -120,6 +152,7 @@ export type Milestone = {
   type: string;
   color: string;
   extra?: Record<string, unknown>;
+  completed_at?: string; // set when the milestone is marked done
   created_at: string;
 };
 export type Dependency = {
@@ -273,7 +306,7 @@ export const api = {
       color?: string;
       extra?: Record<string, unknown>;
     }) => fetchApi<Milestone>('/milestones', { method: 'POST', body: JSON.stringify(body) }),
-    update: (id: string, body: Partial<{ label: string; start_date: string; end_date?: string; type: string; color: string }>) =>
+    update: (id: string, body: Partial<{ label: string; start_date: string; end_date?: string; type: string; color: string; completed: boolean }>) =>
       fetchApi<Milestone>(`/milestones/${id}`, { method: 'PUT', body: JSON.stringify(body) }),
     delete: (id: string) => fetchApi<void>(`/milestones/${id}`, { method: 'DELETE' }),
   },
@@ -390,12 +423,17 @@ export const api = {
     delete: (id: string) => fetchApi<void>(`/groups/${id}`, { method: 'DELETE' }),
   },
   notifications: {
-    list: (params?: { limit?: number; offset?: number; archived?: boolean }) => {
+    list: (params?: { limit?: number; offset?: number; archived?: boolean; threads?: boolean; type?: string; related_entity_type?: string; related_entity_id?: string; unread?: boolean }) => {
       const p = params ?? {};
       const q = new URLSearchParams();
       if (p.limit != null) q.set('limit', String(p.limit));
       if (p.offset != null) q.set('offset', String(p.offset));
       if (p.archived !== undefined) q.set('archived', String(p.archived));
+      if (p.threads !== undefined) q.set('threads', String(p.threads));
+      if (p.type) q.set('type', p.type);
+      if (p.related_entity_type) q.set('related_entity_type', p.related_entity_type);
+      if (p.related_entity_id) q.set('related_entity_id', p.related_entity_id);
+      if (p.unread) q.set('unread', 'true');
       const s = q.toString();
       return fetchApi<{ items: Notification[]; total: number; offset: number; limit: number }>(`/notifications${s ? `?${s}` : ''}`);
     },
@@ -409,6 +447,20 @@ export const api = {
       fetchApi<void>(`/notifications/${id}/archive`, { method: 'PUT' }),
     delete: (id: string) =>
       fetchApi<void>(`/notifications/${id}`, { method: 'DELETE' }),
+    bulk: (body: NotificationBulkRequest) =>
+      fetchApi<{ count: number }>('/notifications/bulk', { method: 'POST', body: JSON.stringify(body) }),
+  },
+  locale: {
+    get: () => fetchApi<{ locale: string; supported: string[] }>('/locale'),
+    /** Saves the locale ('' follows the browser) and sends it with every later request. */
+    update: async (locale: string) => {
+      const res = await fetchApi<{ locale: string; supported: string[] }>('/locale', { method: 'PUT', body: JSON.stringify({ locale }) });
+      if (typeof window !== 'undefined') {
+        if (res.locale) localStorage.setItem('locale', res.locale);
+        else localStorage.removeItem('locale');
+      }
+      return res;
+    },
   },
   auditLogs: {
     list: (params?: { limit?: number; offset?: number; entity_type?: string; action?: string; date_from?: string; date_to?: string; sort_by?: string; order?: string; archived?: boolean }) => {
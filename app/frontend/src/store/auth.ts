@@ -30,6 +30,7 @@ export const useAuthStore = create<AuthState>()(
         }
         if (typeof window !== 'undefined') {
           localStorage.removeItem('access_token');
+          localStorage.removeItem('locale');
         }
         set({ user: null, accessToken: null });
       },
@@ -41,6 +42,9 @@ export const useAuthStore = create<AuthState>()(
         });
         if (typeof window !== 'undefined') {
           localStorage.setItem('access_token', res.access_token);
+          // Responses follow the user's chosen language from now on
+          if (res.user.locale) localStorage.setItem('locale', res.user.locale);
+          else localStorage.removeItem('locale');
         }
       },
       register: async (body) => {